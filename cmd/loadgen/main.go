@@ -0,0 +1,456 @@
+// Command loadgen replays a realistic traffic mix against a running
+// geocoding-api instance and reports latency percentiles per scenario.
+//
+// Queries are sampled from the database the target serves (DB_* env vars,
+// same as the API) so lookups hit real rows rather than cache-friendly
+// constants. Use -no-db to fall back to a small built-in sample.
+//
+//	go run ./cmd/loadgen -target http://localhost:8080 -api-key $KEY -rps 50 -duration 1m
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+)
+
+// scenario is one kind of request in the traffic mix
+type scenario struct {
+	name   string
+	weight int
+	build  func(s *samples, rng *rand.Rand) (*http.Request, error)
+}
+
+// samples holds the real values requests are built from
+type samples struct {
+	target    string
+	batchSize int
+	zips      []string
+	addresses []sampleAddress
+}
+
+type sampleAddress struct {
+	HouseNumber string
+	Street      string
+	City        string
+	Lat         float64
+	Lng         float64
+}
+
+// result is the outcome of a single request
+type result struct {
+	scenario string
+	status   int
+	latency  time.Duration
+	err      error
+}
+
+// scenarioReport summarizes one scenario for output
+type scenarioReport struct {
+	Scenario string         `json:"scenario"`
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Statuses map[string]int `json:"statuses"`
+	P50Ms    float64        `json:"p50_ms"`
+	P90Ms    float64        `json:"p90_ms"`
+	P95Ms    float64        `json:"p95_ms"`
+	P99Ms    float64        `json:"p99_ms"`
+	MaxMs    float64        `json:"max_ms"`
+}
+
+type report struct {
+	Target      string           `json:"target"`
+	DurationSec float64          `json:"duration_sec"`
+	TargetRPS   float64          `json:"target_rps"`
+	AchievedRPS float64          `json:"achieved_rps"`
+	Dropped     int              `json:"dropped"`
+	Scenarios   []scenarioReport `json:"scenarios"`
+}
+
+var scenarios = map[string]scenario{
+	"zip": {name: "zip", build: func(s *samples, rng *rand.Rand) (*http.Request, error) {
+		zip := s.zips[rng.Intn(len(s.zips))]
+		return http.NewRequest(http.MethodGet, s.target+"/api/v1/geocode/"+url.PathEscape(zip), nil)
+	}},
+	"address": {name: "address", build: func(s *samples, rng *rand.Rand) (*http.Request, error) {
+		a := s.addresses[rng.Intn(len(s.addresses))]
+		q := url.Values{"query": {a.HouseNumber + " " + a.Street}, "limit": {"10"}}
+		if a.City != "" && rng.Intn(2) == 0 {
+			q.Set("city", a.City)
+		}
+		return http.NewRequest(http.MethodGet, s.target+"/api/v1/addresses?"+q.Encode(), nil)
+	}},
+	"search": {name: "search", build: func(s *samples, rng *rand.Rand) (*http.Request, error) {
+		a := s.addresses[rng.Intn(len(s.addresses))]
+		q := url.Values{"q": {strings.TrimSpace(a.HouseNumber + " " + a.Street + " " + a.City)}, "limit": {"10"}}
+		return http.NewRequest(http.MethodGet, s.target+"/api/v1/addresses/search?"+q.Encode(), nil)
+	}},
+	"nearby": {name: "nearby", build: func(s *samples, rng *rand.Rand) (*http.Request, error) {
+		a := s.addresses[rng.Intn(len(s.addresses))]
+		q := url.Values{
+			"lat":    {strconv.FormatFloat(a.Lat, 'f', 6, 64)},
+			"lng":    {strconv.FormatFloat(a.Lng, 'f', 6, 64)},
+			"radius": {"0.5"},
+			"limit":  {"10"},
+		}
+		return http.NewRequest(http.MethodGet, s.target+"/api/v1/addresses/nearby?"+q.Encode(), nil)
+	}},
+	"batch": {name: "batch", build: func(s *samples, rng *rand.Rand) (*http.Request, error) {
+		type point struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		}
+		points := make([]point, s.batchSize)
+		for i := range points {
+			a := s.addresses[rng.Intn(len(s.addresses))]
+			points[i] = point{Lat: a.Lat, Lng: a.Lng}
+		}
+		body, err := json.Marshal(map[string]interface{}{"points": points})
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(http.MethodPost, s.target+"/api/v1/counties/contains/batch", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}},
+}
+
+func main() {
+	target := flag.String("target", "http://localhost:8080", "base URL of the API under test")
+	apiKey := flag.String("api-key", os.Getenv("LOADGEN_API_KEY"), "API key sent as X-API-Key (default $LOADGEN_API_KEY)")
+	rps := flag.Float64("rps", 20, "requests per second to issue")
+	duration := flag.Duration("duration", 30*time.Second, "how long to generate load")
+	concurrency := flag.Int("concurrency", 64, "maximum requests in flight; ticks beyond this are dropped")
+	mix := flag.String("mix", "zip=50,address=25,search=10,nearby=10,batch=5", "scenario weights")
+	batchSize := flag.Int("batch-size", 100, "points per batch request")
+	sampleSize := flag.Int("samples", 1000, "rows to sample from each table")
+	noDB := flag.Bool("no-db", false, "use the built-in sample instead of querying the database")
+	seed := flag.Int64("seed", 0, "random seed (0 picks one from the clock)")
+	jsonOut := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if *rps <= 0 || *duration <= 0 || *concurrency <= 0 || *batchSize <= 0 {
+		log.Fatal("rps, duration, concurrency and batch-size must be positive")
+	}
+
+	weighted, err := parseMix(*mix)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := &samples{target: strings.TrimRight(*target, "/"), batchSize: *batchSize}
+	if *noDB {
+		s.zips, s.addresses = builtinZips, builtinAddresses
+	} else {
+		if err := s.load(*sampleSize); err != nil {
+			log.Fatalf("failed to sample queries from the database: %v (use -no-db to skip)", err)
+		}
+	}
+	if len(s.zips) == 0 || len(s.addresses) == 0 {
+		log.Fatal("no sample data; load ZIP codes and addresses first or use -no-db")
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	log.Printf("Generating %.0f rps against %s for %s (mix %s, seed %d)", *rps, s.target, *duration, *mix, *seed)
+	rep := run(s, weighted, *apiKey, *rps, *duration, *concurrency, *seed)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+		return
+	}
+	printReport(rep)
+}
+
+// parseMix turns "zip=50,batch=5" into the weighted scenario list
+func parseMix(mix string) ([]scenario, error) {
+	var weighted []scenario
+	for _, part := range strings.Split(mix, ",") {
+		name, weightStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected name=weight", part)
+		}
+		sc, found := scenarios[name]
+		if !found {
+			return nil, fmt.Errorf("unknown scenario %q", name)
+		}
+		weight, err := strconv.Atoi(weightStr)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %q", name, weightStr)
+		}
+		if weight == 0 {
+			continue
+		}
+		sc.weight = weight
+		weighted = append(weighted, sc)
+	}
+	if len(weighted) == 0 {
+		return nil, fmt.Errorf("mix has no scenarios with a positive weight")
+	}
+	return weighted, nil
+}
+
+// load samples ZIP codes and addresses from the database
+func (s *samples) load(n int) error {
+	if err := database.InitDB(); err != nil {
+		return err
+	}
+	defer database.CloseDB()
+
+	rows, err := database.DB.Query(`SELECT zip_code FROM zip_codes ORDER BY random() LIMIT $1`, n)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var zip string
+		if err := rows.Scan(&zip); err != nil {
+			rows.Close()
+			return err
+		}
+		s.zips = append(s.zips, zip)
+	}
+	rows.Close()
+
+	// TABLESAMPLE keeps this fast on the full address table; fall back to a
+	// plain scan when the table is too small for the sample to return rows
+	query := `
+		SELECT house_number, street, COALESCE(city, ''), ST_Y(geom), ST_X(geom)
+		FROM ohio_addresses %s
+		WHERE house_number <> '' AND street <> ''
+		LIMIT $1`
+	for _, sample := range []string{"TABLESAMPLE SYSTEM (1)", ""} {
+		rows, err := database.DB.Query(fmt.Sprintf(query, sample), n)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var a sampleAddress
+			if err := rows.Scan(&a.HouseNumber, &a.Street, &a.City, &a.Lat, &a.Lng); err != nil {
+				rows.Close()
+				return err
+			}
+			s.addresses = append(s.addresses, a)
+		}
+		rows.Close()
+		if len(s.addresses) > 0 {
+			break
+		}
+	}
+
+	log.Printf("Sampled %d ZIP codes and %d addresses", len(s.zips), len(s.addresses))
+	return nil
+}
+
+// run issues requests at a fixed rate until duration elapses and then waits
+// for in-flight requests before building the report
+func run(s *samples, weighted []scenario, apiKey string, rps float64, duration time.Duration, concurrency int, seed int64) report {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        concurrency,
+			MaxIdleConnsPerHost: concurrency,
+		},
+	}
+
+	totalWeight := 0
+	for _, sc := range weighted {
+		totalWeight += sc.weight
+	}
+	rng := rand.New(rand.NewSource(seed))
+	pick := func() scenario {
+		n := rng.Intn(totalWeight)
+		for _, sc := range weighted {
+			if n < sc.weight {
+				return sc
+			}
+			n -= sc.weight
+		}
+		return weighted[len(weighted)-1]
+	}
+
+	results := make(chan result, concurrency)
+	var collected []result
+	collectorDone := make(chan struct{})
+	go func() {
+		for r := range results {
+			collected = append(collected, r)
+		}
+		close(collectorDone)
+	}()
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	dropped := 0
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+	defer ticker.Stop()
+	start := time.Now()
+	deadline := time.After(duration)
+
+loop:
+	for {
+		select {
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			sc := pick()
+			req, err := sc.build(s, rng)
+			if err != nil {
+				results <- result{scenario: sc.name, err: err}
+				continue
+			}
+			if apiKey != "" {
+				req.Header.Set("X-API-Key", apiKey)
+			}
+
+			select {
+			case slots <- struct{}{}:
+			default:
+				dropped++
+				continue
+			}
+			wg.Add(1)
+			go func(name string, req *http.Request) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				results <- do(client, name, req)
+			}(sc.name, req)
+		}
+	}
+
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+	<-collectorDone
+
+	return buildReport(s.target, collected, elapsed, rps, dropped)
+}
+
+// do sends one request and times it through reading the full body
+func do(client *http.Client, name string, req *http.Request) result {
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{scenario: name, latency: time.Since(start), err: err}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return result{scenario: name, status: resp.StatusCode, latency: time.Since(start)}
+}
+
+func buildReport(target string, results []result, elapsed time.Duration, rps float64, dropped int) report {
+	byScenario := map[string][]result{}
+	for _, r := range results {
+		byScenario[r.scenario] = append(byScenario[r.scenario], r)
+	}
+
+	rep := report{
+		Target:      target,
+		DurationSec: elapsed.Seconds(),
+		TargetRPS:   rps,
+		AchievedRPS: float64(len(results)) / elapsed.Seconds(),
+		Dropped:     dropped,
+		Scenarios:   []scenarioReport{},
+	}
+
+	names := make([]string, 0, len(byScenario))
+	for name := range byScenario {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sr := scenarioReport{Scenario: name, Statuses: map[string]int{}}
+		var latencies []float64
+		for _, r := range byScenario[name] {
+			sr.Requests++
+			switch {
+			case r.err != nil:
+				sr.Errors++
+				sr.Statuses["error"]++
+			default:
+				if r.status >= 400 {
+					sr.Errors++
+				}
+				sr.Statuses[strconv.Itoa(r.status)]++
+			}
+			if r.latency > 0 {
+				latencies = append(latencies, float64(r.latency)/float64(time.Millisecond))
+			}
+		}
+		sort.Float64s(latencies)
+		sr.P50Ms = percentile(latencies, 50)
+		sr.P90Ms = percentile(latencies, 90)
+		sr.P95Ms = percentile(latencies, 95)
+		sr.P99Ms = percentile(latencies, 99)
+		if len(latencies) > 0 {
+			sr.MaxMs = latencies[len(latencies)-1]
+		}
+		rep.Scenarios = append(rep.Scenarios, sr)
+	}
+	return rep
+}
+
+// percentile uses nearest-rank on sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func printReport(rep report) {
+	fmt.Printf("\nTarget:   %s\n", rep.Target)
+	fmt.Printf("Duration: %.1fs\n", rep.DurationSec)
+	fmt.Printf("Rate:     %.1f rps achieved (%.1f requested, %d dropped at the concurrency limit)\n\n", rep.AchievedRPS, rep.TargetRPS, rep.Dropped)
+
+	fmt.Printf("%-10s %8s %7s %9s %9s %9s %9s %9s  %s\n", "scenario", "requests", "errors", "p50 ms", "p90 ms", "p95 ms", "p99 ms", "max ms", "statuses")
+	for _, sr := range rep.Scenarios {
+		codes := make([]string, 0, len(sr.Statuses))
+		for code, count := range sr.Statuses {
+			codes = append(codes, fmt.Sprintf("%s:%d", code, count))
+		}
+		sort.Strings(codes)
+		fmt.Printf("%-10s %8d %7d %9.1f %9.1f %9.1f %9.1f %9.1f  %s\n",
+			sr.Scenario, sr.Requests, sr.Errors, sr.P50Ms, sr.P90Ms, sr.P95Ms, sr.P99Ms, sr.MaxMs, strings.Join(codes, " "))
+	}
+}
+
+// Built-in sample for -no-db runs
+var (
+	builtinZips = []string{"10001", "90210", "60601", "43215", "45202", "44114", "30301", "73301", "98101", "02108"}
+
+	builtinAddresses = []sampleAddress{
+		{HouseNumber: "1", Street: "Capitol Sq", City: "Columbus", Lat: 39.9612, Lng: -82.9988},
+		{HouseNumber: "801", Street: "Plum St", City: "Cincinnati", Lat: 39.1050, Lng: -84.5192},
+		{HouseNumber: "601", Street: "Lakeside Ave", City: "Cleveland", Lat: 41.5049, Lng: -81.6936},
+		{HouseNumber: "1", Street: "Government Ctr", City: "Toledo", Lat: 41.6528, Lng: -83.5379},
+		{HouseNumber: "166", Street: "S High St", City: "Akron", Lat: 41.0814, Lng: -81.5190},
+	}
+)
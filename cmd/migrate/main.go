@@ -0,0 +1,139 @@
+// Command migrate applies, rolls back and reports the SQL migrations in
+// migrations/, using the same database settings as the API.
+//
+//	go run ./cmd/migrate up           apply pending migrations
+//	go run ./cmd/migrate down [n]     roll back the last n migrations (default 1)
+//	go run ./cmd/migrate status       list migrations; exits 1 if any were modified
+//	go run ./cmd/migrate create NAME  add an empty up/down pair to migrations/
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/migrations"
+)
+
+const usage = `usage: migrate <command> [arguments]
+
+commands:
+  up           apply pending migrations
+  down [n]     roll back the last n migrations (default 1)
+  status       list migrations and whether they are applied
+  create NAME  add an empty up/down pair to migrations/`
+
+// migrationName is the NAME accepted by create
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	if command == "create" {
+		if len(args) != 1 || !migrationName.MatchString(args[0]) {
+			log.Fatal("usage: migrate create NAME (lowercase letters, digits and underscores)")
+		}
+		if err := create(args[0]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if _, err := config.Load(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if err := database.InitDB(); err != nil {
+		log.Fatal(err)
+	}
+	defer database.CloseDB()
+
+	ctx := context.Background()
+	var err error
+	switch command {
+	case "up":
+		err = database.MigrateUp(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				log.Fatalf("down takes a positive number of migrations, got %q", args[0])
+			}
+		}
+		err = database.MigrateDown(ctx, steps)
+	case "status":
+		err = status(ctx)
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		database.CloseDB()
+		log.Fatal(err)
+	}
+}
+
+// status prints every migration and fails if an applied one was modified
+func status(ctx context.Context) error {
+	states, err := database.GetMigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+	modified := 0
+	for _, s := range states {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case s.Modified:
+			state = "modified"
+			modified++
+		case s.Missing:
+			state = "missing"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, state, appliedAt, s.Description)
+	}
+	w.Flush()
+
+	if modified > 0 {
+		return fmt.Errorf("%d applied migration(s) have been modified since they ran", modified)
+	}
+	return nil
+}
+
+// create writes an empty up/down pair numbered after the latest migration
+func create(name string) error {
+	all, err := database.LoadMigrations(migrations.Files)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if len(all) > 0 {
+		next = all[len(all)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join("migrations", fmt.Sprintf("%06d_%s.%s.sql", next, name, direction))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return err
+		}
+		file.Close()
+		fmt.Println(path)
+	}
+	return nil
+}
@@ -0,0 +1,618 @@
+// Package config loads the server's settings once at startup from defaults,
+// an optional YAML file and environment variables, and validates them so a
+// misconfigured deployment fails before it serves requests.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// placeholderSecrets are the example values shipped in .env.example and
+// docker-compose.yml, which must never sign tokens in production
+var placeholderSecrets = map[string]bool{
+	"":                          true,
+	"change_this_in_production": true,
+	"CHANGE_THIS_32_CHAR_SECRET_IN_PRODUCTION": true,
+	developmentJWTSecret:                       true,
+}
+
+// developmentJWTSecret signs tokens when JWT_SECRET is unset outside production
+const developmentJWTSecret = "your-secret-key-change-in-production"
+
+// Config holds every setting the server reads at startup. Values are taken
+// from Default, then the YAML file named by CONFIG_FILE, then environment
+// variables, so the environment always wins.
+type Config struct {
+	// Env is GO_ENV (or ENV); "production" enables production defaults and checks
+	Env        string           `yaml:"env"`
+	Server     ServerConfig     `yaml:"server"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+	CORS       CORSConfig       `yaml:"cors"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Migrations MigrationsConfig `yaml:"migrations"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Email      EmailConfig      `yaml:"email"`
+	Billing    BillingConfig    `yaml:"billing"`
+	Demo       DemoConfig       `yaml:"demo"`
+	Workers    WorkersConfig    `yaml:"workers"`
+	Routing    RoutingConfig    `yaml:"routing"`
+	SLO        SLOConfig        `yaml:"slo"`
+	Datasets   DatasetsConfig   `yaml:"datasets"`
+	API        APIConfig        `yaml:"api"`
+	// ResponseCache caches whole responses of read-only endpoints
+	ResponseCache ResponseCacheConfig `yaml:"response_cache"`
+}
+
+// ServerConfig configures the HTTP listener. Timeouts are long by default so
+// large dataset uploads can finish.
+type ServerConfig struct {
+	Port              int           `yaml:"port"`
+	BindAllInterfaces bool          `yaml:"bind_all_interfaces"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	// RequestTimeout is the deadline for API requests other than uploads,
+	// exports and streams; their database queries are cancelled when it
+	// passes or the client disconnects
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// MaxBodySize is the largest request body accepted, e.g. "500M"
+	MaxBodySize string `yaml:"max_body_size"`
+	// CompressionLevel is the gzip level (1-9) for responses to clients that
+	// accept it; 0 turns compression off, e.g. behind a compressing proxy
+	CompressionLevel int `yaml:"compression_level"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in
+	// front of the server. The client IP is taken from X-Forwarded-For only
+	// when they relay it; with none the connection's address is used.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// GRPCConfig configures the gRPC listener
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API. When empty,
+// the production or development defaults apply.
+type CORSConfig struct {
+	Origins []string `yaml:"origins"`
+}
+
+// Database drivers for DB_DRIVER
+const (
+	DriverPostgres = "postgres"
+	// DriverSQLite serves the ZIP code and state lookups from an embedded
+	// SQLite database with bundled sample data, for local development and
+	// demos without PostGIS
+	DriverSQLite = "sqlite"
+)
+
+// DatabaseConfig holds the PostgreSQL connection and pool settings. A zero
+// ConnMaxLifetime or ConnMaxIdleTime keeps connections indefinitely.
+type DatabaseConfig struct {
+	// Driver is DriverPostgres or DriverSQLite
+	Driver string `yaml:"driver"`
+	// SQLitePath is the embedded database file with DriverSQLite. Empty
+	// keeps it in memory, loading the sample data on every start.
+	SQLitePath      string        `yaml:"sqlite_path"`
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// ReplicaDSN is a read replica's connection string (a postgres:// URL or
+	// key=value settings). Searches, lookups and stats read from it while it
+	// is reachable. Empty reads everything from the primary.
+	ReplicaDSN string `yaml:"replica_dsn"`
+	// BreakerThreshold is how many connection or health check failures in a
+	// row open the circuit breaker, turning requests away with 503
+	BreakerThreshold int `yaml:"breaker_threshold"`
+	// BreakerCooldown is how long the circuit stays open before a trial
+	// connection is let through
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown"`
+	// HealthCheckInterval is how often the database is pinged, and
+	// HealthCheckTimeout how long a ping may take before it counts as a
+	// failure
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+	HealthCheckTimeout  time.Duration `yaml:"health_check_timeout"`
+}
+
+// MigrationsConfig controls how migrations and data loading run at startup
+type MigrationsConfig struct {
+	// RunSync blocks startup until migrations finish
+	RunSync bool `yaml:"run_sync"`
+	// CleanupGeoJSON removes loaded GeoJSON files outside production too
+	CleanupGeoJSON bool `yaml:"cleanup_geojson"`
+}
+
+// AuthConfig holds token signing settings and lifetimes
+type AuthConfig struct {
+	JWTSecret                      string        `yaml:"jwt_secret"`
+	JWTIssuer                      string        `yaml:"jwt_issuer"`
+	JWTAudience                    string        `yaml:"jwt_audience"`
+	JWTLifetime                    time.Duration `yaml:"jwt_lifetime"`
+	RefreshTokenLifetime           time.Duration `yaml:"refresh_token_lifetime"`
+	PasswordResetTokenLifetime     time.Duration `yaml:"password_reset_token_lifetime"`
+	EmailVerificationTokenLifetime time.Duration `yaml:"email_verification_token_lifetime"`
+	APISecretKey                   string        `yaml:"api_secret_key"`
+	// AdminEmails are always treated as admins and synced to is_admin
+	AdminEmails []string `yaml:"admin_emails"`
+}
+
+// EmailConfig configures outgoing mail. Without an SMTP host, emails are
+// written to the log instead of sent.
+type EmailConfig struct {
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	From         string `yaml:"from"`
+	// AppBaseURL is the web app origin used in links sent by email
+	AppBaseURL string `yaml:"app_base_url"`
+	// Notifications sends account and quota notification emails: welcome,
+	// API key created, quota warnings, plan changes and dataset imports
+	Notifications bool `yaml:"notifications"`
+}
+
+// BillingConfig controls what happens to calls beyond a plan's monthly limit
+type BillingConfig struct {
+	// Overage lets users who opt in keep calling past their monthly limit,
+	// billed per call up to their own spend cap, instead of getting 429s
+	Overage bool `yaml:"overage"`
+	// MaxOverageSpendCap is the highest monthly overage spend cap, in
+	// dollars, a user may set
+	MaxOverageSpendCap float64 `yaml:"max_overage_spend_cap"`
+	// EndpointCosts overrides how many calls a request counts as against
+	// quotas, by route without the version prefix as "/counties/:name/boundary",
+	// or "POST /geocode/jobs" for one method
+	EndpointCosts map[string]int `yaml:"endpoint_costs"`
+}
+
+// DemoConfig controls the unauthenticated demo endpoints
+type DemoConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// RateLimit is requests per IP per minute, and the burst an idle IP
+	// may send at once
+	RateLimit int `yaml:"rate_limit"`
+	// DailyLimit caps requests per IP per UTC day
+	DailyLimit int `yaml:"daily_limit"`
+}
+
+// WorkersConfig sizes the background workers and their buffers
+type WorkersConfig struct {
+	GeocodeJobWorkers  int           `yaml:"geocode_job_workers"`
+	GeocodeJobMaxRows  int           `yaml:"geocode_job_max_rows"`
+	UsageBufferSize    int           `yaml:"usage_buffer_size"`
+	UsageFlushSize     int           `yaml:"usage_flush_size"`
+	UsageFlushInterval time.Duration `yaml:"usage_flush_interval"`
+	// UsageCaptureRequests stores each call's path and sanitized query
+	// string with its usage record, for the admin request console
+	UsageCaptureRequests bool `yaml:"usage_capture_requests"`
+	// StatsRefreshInterval is how often the admin dashboard stats views
+	// are refreshed
+	StatsRefreshInterval time.Duration `yaml:"stats_refresh_interval"`
+}
+
+// RoutingConfig points at the OSRM or Valhalla instance used for driving
+// distances. Driving distances are unavailable while Engine is empty.
+type RoutingConfig struct {
+	// Engine is "osrm" or "valhalla"
+	Engine  string        `yaml:"engine"`
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout"`
+	// CoverageSpeedMPH is the average speed coverage areas assume when no
+	// engine is configured
+	CoverageSpeedMPH int `yaml:"coverage_speed_mph"`
+}
+
+// SLOConfig controls how endpoint SLOs are measured and alerted on
+type SLOConfig struct {
+	// Window is the rolling window compliance is reported over
+	Window time.Duration `yaml:"window"`
+	// AlertWindow is the shorter window burn rates are alerted on
+	AlertWindow time.Duration `yaml:"alert_window"`
+	// BurnRateThreshold is the burn rate over AlertWindow that sends an alert
+	BurnRateThreshold float64 `yaml:"burn_rate_threshold"`
+	// CheckInterval is how often burn rates are checked
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// DatasetsConfig controls how uploaded address datasets are checked before
+// they are imported
+type DatasetsConfig struct {
+	// MaxInvalidPercent is the share of rows, 0 to 100, that may lack a
+	// usable point or fall outside the declared state before the dataset
+	// fails validation
+	MaxInvalidPercent float64 `yaml:"max_invalid_percent"`
+	// RefreshSources lists the OpenAddresses county sources kept up to
+	// date, as "OH/Franklin", or "OH" for every county with a dataset in
+	// that state. Empty turns automatic refresh off.
+	RefreshSources []string `yaml:"refresh_sources"`
+	// RefreshInterval is how often each source is checked for new data
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// APIConfig schedules the retirement of old API versions. /api/v2 is the
+// current version; /api/v1 is served alongside it until its sunset.
+type APIConfig struct {
+	// V1Deprecation is when /api/v1 was deprecated. From then on its
+	// responses carry a Deprecation header and a Link to /api/v2. Zero
+	// leaves v1 undeprecated.
+	V1Deprecation time.Time `yaml:"v1_deprecation"`
+	// V1Sunset is when /api/v1 is expected to stop being served, sent in the
+	// Sunset header. Zero announces no date.
+	V1Sunset time.Time `yaml:"v1_sunset"`
+}
+
+// ResponseCacheConfig configures the opt-in cache of read-only API responses.
+// Each cached route has its own TTL, set where it is registered.
+type ResponseCacheConfig struct {
+	// Backend is "memory" for a per-instance cache, "redis" for one shared
+	// by every instance, or empty to turn response caching off
+	Backend string `yaml:"backend"`
+	// MaxEntries bounds the memory backend; the least recently used
+	// responses are evicted first
+	MaxEntries int `yaml:"max_entries"`
+	// RedisURL is the redis:// or rediss:// URL of the redis backend
+	RedisURL string `yaml:"redis_url"`
+	// TTLs overrides route TTLs by route without the version prefix, as
+	// "/geocode/:zipcode". Zero stops caching a route.
+	TTLs map[string]time.Duration `yaml:"ttls"`
+}
+
+// Default returns the settings used when nothing is configured
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              8080,
+			ReadTimeout:       30 * time.Minute,
+			WriteTimeout:      30 * time.Minute,
+			IdleTimeout:       5 * time.Minute,
+			ReadHeaderTimeout: 60 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			RequestTimeout:    30 * time.Second,
+			MaxBodySize:       "500M",
+			CompressionLevel:  5,
+		},
+		GRPC: GRPCConfig{
+			Enabled: true,
+			Port:    9090,
+		},
+		Database: DatabaseConfig{
+			Driver:              DriverPostgres,
+			Host:                "localhost",
+			Port:                5432,
+			User:                "postgres",
+			Password:            "postgres",
+			Name:                "geocoding_db",
+			SSLMode:             "disable",
+			MaxOpenConns:        25,
+			MaxIdleConns:        10,
+			ConnMaxLifetime:     30 * time.Minute,
+			ConnMaxIdleTime:     5 * time.Minute,
+			BreakerThreshold:    5,
+			BreakerCooldown:     30 * time.Second,
+			HealthCheckInterval: 5 * time.Second,
+			HealthCheckTimeout:  2 * time.Second,
+		},
+		Auth: AuthConfig{
+			JWTIssuer:                      "geocoding-api",
+			JWTAudience:                    "geocoding-api",
+			JWTLifetime:                    15 * time.Minute,
+			RefreshTokenLifetime:           30 * 24 * time.Hour,
+			PasswordResetTokenLifetime:     time.Hour,
+			EmailVerificationTokenLifetime: 48 * time.Hour,
+		},
+		Email: EmailConfig{
+			SMTPPort:      587,
+			From:          "GeoCode API <no-reply@geocode.jfay.dev>",
+			AppBaseURL:    "http://localhost:8080",
+			Notifications: true,
+		},
+		Billing: BillingConfig{
+			MaxOverageSpendCap: 1000,
+		},
+		Demo: DemoConfig{
+			RateLimit:  10,
+			DailyLimit: 100,
+		},
+		Workers: WorkersConfig{
+			GeocodeJobWorkers:    2,
+			GeocodeJobMaxRows:    100000,
+			UsageBufferSize:      10000,
+			UsageFlushSize:       500,
+			UsageFlushInterval:   2 * time.Second,
+			StatsRefreshInterval: 5 * time.Minute,
+		},
+		Routing: RoutingConfig{
+			Timeout:          5 * time.Second,
+			CoverageSpeedMPH: 30,
+		},
+		SLO: SLOConfig{
+			Window:            24 * time.Hour,
+			AlertWindow:       time.Hour,
+			BurnRateThreshold: 2,
+			CheckInterval:     5 * time.Minute,
+		},
+		Datasets: DatasetsConfig{
+			MaxInvalidPercent: 5,
+			RefreshInterval:   7 * 24 * time.Hour,
+		},
+		ResponseCache: ResponseCacheConfig{
+			MaxEntries: 10000,
+		},
+	}
+}
+
+var (
+	mu      sync.RWMutex
+	current *Config
+)
+
+// Load reads the configuration, validates it and makes it available through
+// Get. It should be called once at startup, after any .env file is loaded.
+func Load() (*Config, error) {
+	cfg, err := read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	current = cfg
+	mu.Unlock()
+	return cfg, nil
+}
+
+// Get returns the configuration loaded by Load. Tools and tests that never
+// call Load get the defaults with environment overrides, unvalidated.
+func Get() *Config {
+	mu.RLock()
+	cfg := current
+	mu.RUnlock()
+	if cfg != nil {
+		return cfg
+	}
+
+	cfg, err := read()
+	if err != nil {
+		slog.Warn("ignoring invalid configuration", "error", err)
+		cfg = Default()
+		cfg.applyEnvironmentDefaults()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = cfg
+	}
+	return current
+}
+
+// read builds a Config from defaults, CONFIG_FILE and the environment
+func read() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.applyEnvironmentDefaults()
+	return cfg, nil
+}
+
+// applyEnvironmentDefaults fills settings whose defaults depend on Env
+func (c *Config) applyEnvironmentDefaults() {
+	if len(c.CORS.Origins) == 0 {
+		if c.IsProduction() {
+			c.CORS.Origins = []string{
+				"https://geocode.jfay.dev",
+				"https://www.geocode.jfay.dev",
+			}
+		} else {
+			c.CORS.Origins = []string{
+				"http://localhost:8080",
+				"http://127.0.0.1:8080",
+				"http://localhost:3000", // Common dev ports
+				"http://localhost:3001",
+			}
+		}
+	}
+	if c.Auth.JWTSecret == "" && !c.IsProduction() {
+		c.Auth.JWTSecret = developmentJWTSecret
+	}
+}
+
+// Validate reports every invalid setting at once. In production a real
+// JWT secret is required.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.IsProduction() && placeholderSecrets[c.Auth.JWTSecret] {
+		errs = append(errs, errors.New("JWT_SECRET must be set to a secure value in production (generate one with: openssl rand -hex 32)"))
+	}
+	for name, port := range map[string]int{"PORT": c.Server.Port, "GRPC_PORT": c.GRPC.Port, "DB_PORT": c.Database.Port, "SMTP_PORT": c.Email.SMTPPort} {
+		if port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("%s must be between 1 and 65535, got %d", name, port))
+		}
+	}
+	for name, d := range map[string]time.Duration{
+		"JWT_LIFETIME":                      c.Auth.JWTLifetime,
+		"REFRESH_TOKEN_LIFETIME":            c.Auth.RefreshTokenLifetime,
+		"PASSWORD_RESET_TOKEN_LIFETIME":     c.Auth.PasswordResetTokenLifetime,
+		"EMAIL_VERIFICATION_TOKEN_LIFETIME": c.Auth.EmailVerificationTokenLifetime,
+		"SHUTDOWN_TIMEOUT":                  c.Server.ShutdownTimeout,
+		"REQUEST_TIMEOUT":                   c.Server.RequestTimeout,
+		"USAGE_FLUSH_INTERVAL":              c.Workers.UsageFlushInterval,
+		"STATS_REFRESH_INTERVAL":            c.Workers.StatsRefreshInterval,
+		"SLO_WINDOW":                        c.SLO.Window,
+		"SLO_ALERT_WINDOW":                  c.SLO.AlertWindow,
+		"SLO_CHECK_INTERVAL":                c.SLO.CheckInterval,
+		"ROUTING_TIMEOUT":                   c.Routing.Timeout,
+		"DATASET_REFRESH_INTERVAL":          c.Datasets.RefreshInterval,
+		"DB_BREAKER_COOLDOWN":               c.Database.BreakerCooldown,
+		"DB_HEALTH_CHECK_INTERVAL":          c.Database.HealthCheckInterval,
+		"DB_HEALTH_CHECK_TIMEOUT":           c.Database.HealthCheckTimeout,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+	if c.Server.CompressionLevel < 0 || c.Server.CompressionLevel > 9 {
+		errs = append(errs, fmt.Errorf("COMPRESSION_LEVEL must be between 0 and 9, got %d", c.Server.CompressionLevel))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("TRUSTED_PROXIES must be IP addresses or CIDR ranges, got %q", proxy))
+		}
+	}
+	for name, n := range map[string]int{
+		"DEMO_RATE_LIMIT":            c.Demo.RateLimit,
+		"DEMO_DAILY_LIMIT":           c.Demo.DailyLimit,
+		"GEOCODE_JOB_WORKERS":        c.Workers.GeocodeJobWorkers,
+		"GEOCODE_JOB_MAX_ROWS":       c.Workers.GeocodeJobMaxRows,
+		"USAGE_BUFFER_SIZE":          c.Workers.UsageBufferSize,
+		"USAGE_FLUSH_SIZE":           c.Workers.UsageFlushSize,
+		"DB_MAX_OPEN_CONNS":          c.Database.MaxOpenConns,
+		"COVERAGE_SPEED_MPH":         c.Routing.CoverageSpeedMPH,
+		"RESPONSE_CACHE_MAX_ENTRIES": c.ResponseCache.MaxEntries,
+		"DB_BREAKER_THRESHOLD":       c.Database.BreakerThreshold,
+	} {
+		if n <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %d", name, n))
+		}
+	}
+	if c.SLO.AlertWindow > c.SLO.Window {
+		errs = append(errs, errors.New("SLO_ALERT_WINDOW must not be longer than SLO_WINDOW"))
+	}
+	if c.SLO.BurnRateThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("SLO_BURN_RATE_THRESHOLD must be positive, got %g", c.SLO.BurnRateThreshold))
+	}
+	if c.Billing.MaxOverageSpendCap <= 0 {
+		errs = append(errs, fmt.Errorf("OVERAGE_MAX_SPEND_CAP must be positive, got %g", c.Billing.MaxOverageSpendCap))
+	}
+	if c.Datasets.MaxInvalidPercent < 0 || c.Datasets.MaxInvalidPercent > 100 {
+		errs = append(errs, fmt.Errorf("DATASET_MAX_INVALID_PERCENT must be between 0 and 100, got %g", c.Datasets.MaxInvalidPercent))
+	}
+	for _, source := range c.Datasets.RefreshSources {
+		state, county, hasCounty := strings.Cut(source, "/")
+		if len(state) != 2 || (hasCounty && strings.TrimSpace(county) == "") {
+			errs = append(errs, fmt.Errorf("DATASET_REFRESH_SOURCES entries must be STATE or STATE/County, got %q", source))
+		}
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns))
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative"))
+	}
+	switch c.Database.Driver {
+	case DriverPostgres:
+		if c.Database.Host == "" || c.Database.Name == "" {
+			errs = append(errs, errors.New("DB_HOST and DB_NAME must be set"))
+		}
+	case DriverSQLite:
+		// The embedded database serves lookups without API keys
+		if c.IsProduction() {
+			errs = append(errs, errors.New("DB_DRIVER=sqlite is for local development and can't be used in production"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be postgres or sqlite, got %q", c.Database.Driver))
+	}
+	if !c.API.V1Sunset.IsZero() && c.API.V1Sunset.Before(c.API.V1Deprecation) {
+		errs = append(errs, errors.New("API_V1_SUNSET must not be before API_V1_DEPRECATION"))
+	}
+	switch c.Routing.Engine {
+	case "":
+	case "osrm", "valhalla":
+		if c.Routing.URL == "" {
+			errs = append(errs, fmt.Errorf("ROUTING_URL must be set when ROUTING_ENGINE is %s", c.Routing.Engine))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("ROUTING_ENGINE must be osrm or valhalla, got %q", c.Routing.Engine))
+	}
+	switch c.ResponseCache.Backend {
+	case "", "memory":
+	case "redis":
+		if u, err := url.Parse(c.ResponseCache.RedisURL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			errs = append(errs, errors.New("REDIS_URL must be a redis:// or rediss:// URL when RESPONSE_CACHE is redis"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("RESPONSE_CACHE must be memory or redis, got %q", c.ResponseCache.Backend))
+	}
+	for route, cost := range c.Billing.EndpointCosts {
+		_, path, hasMethod := strings.Cut(route, " ")
+		if !hasMethod {
+			path = route
+		}
+		if !strings.HasPrefix(path, "/") || cost < 1 {
+			errs = append(errs, fmt.Errorf("ENDPOINT_COSTS entries must be [METHOD ]/route=cost with a cost of at least 1, got %s=%d", route, cost))
+		}
+	}
+	for route, ttl := range c.ResponseCache.TTLs {
+		if !strings.HasPrefix(route, "/") || ttl < 0 {
+			errs = append(errs, fmt.Errorf("RESPONSE_CACHE_TTLS entries must be /route=duration with a duration of at least 0, got %s=%s", route, ttl))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsProduction reports whether the server runs with production defaults
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// BindAddress is the interface to listen on. All interfaces are used in
+// production and Docker; 127.0.0.1 locally avoids macOS IPv6 socket issues.
+func (c *Config) BindAddress() string {
+	if c.IsProduction() || c.Server.BindAllInterfaces {
+		return "0.0.0.0"
+	}
+	return "127.0.0.1"
+}
+
+// HTTPAddr is the HTTP listen address
+func (c *Config) HTTPAddr() string {
+	return c.BindAddress() + ":" + strconv.Itoa(c.Server.Port)
+}
+
+// GRPCAddr is the gRPC listen address
+func (c *Config) GRPCAddr() string {
+	return c.BindAddress() + ":" + strconv.Itoa(c.GRPC.Port)
+}
+
+// IsAdminEmail reports whether email is listed in AdminEmails
+func (c *Config) IsAdminEmail(email string) bool {
+	for _, adminEmail := range c.Auth.AdminEmails {
+		if adminEmail == email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("GO_ENV", "development")
+	t.Setenv("JWT_SECRET", "")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, "127.0.0.1:8080", cfg.HTTPAddr())
+	assert.Equal(t, developmentJWTSecret, cfg.Auth.JWTSecret)
+	assert.Equal(t, 15*time.Minute, cfg.Auth.JWTLifetime)
+	assert.Contains(t, cfg.CORS.Origins, "http://localhost:3000")
+	assert.True(t, cfg.Email.Notifications)
+	assert.False(t, cfg.Billing.Overage)
+	assert.Equal(t, 100, cfg.Demo.DailyLimit)
+	assert.Same(t, cfg, Get())
+}
+
+func TestLoadEnvironmentOverrides(t *testing.T) {
+	t.Setenv("GO_ENV", "production")
+	t.Setenv("JWT_SECRET", "a-real-secret")
+	t.Setenv("PORT", "9000")
+	t.Setenv("JWT_LIFETIME", "5m")
+	t.Setenv("CORS_ORIGINS", "https://a.example, ,https://b.example")
+	t.Setenv("ADMIN_EMAILS", " admin@example.com ,ops@example.com")
+	t.Setenv("GEOCODE_JOB_WORKERS", "4")
+	t.Setenv("API_V1_SUNSET", "2027-06-30")
+	t.Setenv("DB_REPLICA_DSN", "postgres://reader@replica:5432/geocoding_db")
+	t.Setenv("RESPONSE_CACHE", "redis")
+	t.Setenv("REDIS_URL", "redis://cache:6379/0")
+	t.Setenv("RESPONSE_CACHE_TTLS", "/geocode/:zipcode=30m, /addresses=0s")
+	t.Setenv("EMAIL_NOTIFICATIONS", "false")
+	t.Setenv("OVERAGE_BILLING", "true")
+	t.Setenv("OVERAGE_MAX_SPEND_CAP", "250.50")
+	t.Setenv("ENDPOINT_COSTS", "POST /geocode/jobs=50, /tiles/:layer/:z/:x/:y=2")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "0.0.0.0:9000", cfg.HTTPAddr())
+	assert.Equal(t, 5*time.Minute, cfg.Auth.JWTLifetime)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORS.Origins)
+	assert.True(t, cfg.IsAdminEmail("admin@example.com"))
+	assert.False(t, cfg.IsAdminEmail("someone@example.com"))
+	assert.Equal(t, 4, cfg.Workers.GeocodeJobWorkers)
+	assert.Equal(t, time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC), cfg.API.V1Sunset)
+	assert.True(t, cfg.API.V1Deprecation.IsZero())
+	assert.Equal(t, "postgres://reader@replica:5432/geocoding_db", cfg.Database.ReplicaDSN)
+	assert.Equal(t, "redis", cfg.ResponseCache.Backend)
+	assert.Equal(t, map[string]time.Duration{"/geocode/:zipcode": 30 * time.Minute, "/addresses": 0}, cfg.ResponseCache.TTLs)
+	assert.False(t, cfg.Email.Notifications)
+	assert.True(t, cfg.Billing.Overage)
+	assert.Equal(t, 250.50, cfg.Billing.MaxOverageSpendCap)
+	assert.Equal(t, map[string]int{"POST /geocode/jobs": 50, "/tiles/:layer/:z/:x/:y": 2}, cfg.Billing.EndpointCosts)
+}
+
+func TestLoadFileThenEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 7000
+  shutdown_timeout: 10s
+database:
+  host: db.internal
+workers:
+  geocode_job_workers: 8
+`), 0o600))
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("GO_ENV", "development")
+	t.Setenv("GEOCODE_JOB_WORKERS", "3")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, 7000, cfg.Server.Port)
+	assert.Equal(t, 10*time.Second, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, 3, cfg.Workers.GeocodeJobWorkers, "environment overrides the file")
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		message string
+	}{
+		{
+			name:    "missing JWT secret in production",
+			env:     map[string]string{"GO_ENV": "production", "JWT_SECRET": ""},
+			message: "JWT_SECRET must be set",
+		},
+		{
+			name:    "placeholder JWT secret in production",
+			env:     map[string]string{"GO_ENV": "production", "JWT_SECRET": "change_this_in_production"},
+			message: "JWT_SECRET must be set",
+		},
+		{
+			name:    "malformed duration",
+			env:     map[string]string{"GO_ENV": "development", "JWT_LIFETIME": "fifteen"},
+			message: "JWT_LIFETIME must be a duration",
+		},
+		{
+			name:    "malformed integer",
+			env:     map[string]string{"GO_ENV": "development", "PORT": "http"},
+			message: "PORT must be an integer",
+		},
+		{
+			name:    "out of range port",
+			env:     map[string]string{"GO_ENV": "development", "PORT": "70000"},
+			message: "PORT must be between 1 and 65535",
+		},
+		{
+			name:    "no workers",
+			env:     map[string]string{"GO_ENV": "development", "GEOCODE_JOB_WORKERS": "0"},
+			message: "GEOCODE_JOB_WORKERS must be positive",
+		},
+		{
+			name:    "zero stats refresh interval",
+			env:     map[string]string{"GO_ENV": "development", "STATS_REFRESH_INTERVAL": "0s"},
+			message: "STATS_REFRESH_INTERVAL must be positive",
+		},
+		{
+			name:    "SLO alert window longer than the SLO window",
+			env:     map[string]string{"GO_ENV": "development", "SLO_WINDOW": "1h", "SLO_ALERT_WINDOW": "6h"},
+			message: "SLO_ALERT_WINDOW must not be longer than SLO_WINDOW",
+		},
+		{
+			name:    "malformed number",
+			env:     map[string]string{"GO_ENV": "development", "SLO_BURN_RATE_THRESHOLD": "fast"},
+			message: "SLO_BURN_RATE_THRESHOLD must be a number",
+		},
+		{
+			name:    "zero overage spend cap",
+			env:     map[string]string{"GO_ENV": "development", "OVERAGE_MAX_SPEND_CAP": "0"},
+			message: "OVERAGE_MAX_SPEND_CAP must be positive",
+		},
+		{
+			name:    "malformed endpoint cost",
+			env:     map[string]string{"GO_ENV": "development", "ENDPOINT_COSTS": "/geocode/jobs=lots"},
+			message: "ENDPOINT_COSTS entries must be key=integer",
+		},
+		{
+			name:    "zero endpoint cost",
+			env:     map[string]string{"GO_ENV": "development", "ENDPOINT_COSTS": "POST /geocode/jobs=0"},
+			message: "ENDPOINT_COSTS entries must be [METHOD ]/route=cost",
+		},
+		{
+			name:    "invalid percent over 100",
+			env:     map[string]string{"GO_ENV": "development", "DATASET_MAX_INVALID_PERCENT": "150"},
+			message: "DATASET_MAX_INVALID_PERCENT must be between 0 and 100",
+		},
+		{
+			name:    "malformed refresh source",
+			env:     map[string]string{"GO_ENV": "development", "DATASET_REFRESH_SOURCES": "Ohio/Franklin"},
+			message: "DATASET_REFRESH_SOURCES entries must be STATE or STATE/County",
+		},
+		{
+			name:    "more idle than open connections",
+			env:     map[string]string{"GO_ENV": "development", "DB_MAX_OPEN_CONNS": "5", "DB_MAX_IDLE_CONNS": "10"},
+			message: "DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS",
+		},
+		{
+			name:    "unknown routing engine",
+			env:     map[string]string{"GO_ENV": "development", "ROUTING_ENGINE": "graphhopper", "ROUTING_URL": "http://localhost:8989"},
+			message: "ROUTING_ENGINE must be osrm or valhalla",
+		},
+		{
+			name:    "out of range compression level",
+			env:     map[string]string{"GO_ENV": "development", "COMPRESSION_LEVEL": "11"},
+			message: "COMPRESSION_LEVEL must be between 0 and 9",
+		},
+		{
+			name:    "routing engine without URL",
+			env:     map[string]string{"GO_ENV": "development", "ROUTING_ENGINE": "osrm"},
+			message: "ROUTING_URL must be set",
+		},
+		{
+			name:    "malformed trusted proxy",
+			env:     map[string]string{"GO_ENV": "development", "TRUSTED_PROXIES": "10.0.0.0/8,load-balancer"},
+			message: "TRUSTED_PROXIES must be IP addresses or CIDR ranges",
+		},
+		{
+			name:    "malformed date",
+			env:     map[string]string{"GO_ENV": "development", "API_V1_DEPRECATION": "next year"},
+			message: "API_V1_DEPRECATION must be a date",
+		},
+		{
+			name:    "sunset before deprecation",
+			env:     map[string]string{"GO_ENV": "development", "API_V1_DEPRECATION": "2027-01-01", "API_V1_SUNSET": "2026-12-31"},
+			message: "API_V1_SUNSET must not be before API_V1_DEPRECATION",
+		},
+		{
+			name:    "zero breaker threshold",
+			env:     map[string]string{"GO_ENV": "development", "DB_BREAKER_THRESHOLD": "0"},
+			message: "DB_BREAKER_THRESHOLD must be positive",
+		},
+		{
+			name:    "unknown database driver",
+			env:     map[string]string{"GO_ENV": "development", "DB_DRIVER": "mysql"},
+			message: "DB_DRIVER must be postgres or sqlite",
+		},
+		{
+			name:    "SQLite in production",
+			env:     map[string]string{"GO_ENV": "production", "JWT_SECRET": "a-real-secret", "DB_DRIVER": "sqlite"},
+			message: "DB_DRIVER=sqlite is for local development",
+		},
+		{
+			name:    "unknown response cache backend",
+			env:     map[string]string{"GO_ENV": "development", "RESPONSE_CACHE": "memcached"},
+			message: "RESPONSE_CACHE must be memory or redis",
+		},
+		{
+			name:    "redis response cache without URL",
+			env:     map[string]string{"GO_ENV": "development", "RESPONSE_CACHE": "redis"},
+			message: "REDIS_URL must be a redis:// or rediss:// URL",
+		},
+		{
+			name:    "malformed response cache TTL",
+			env:     map[string]string{"GO_ENV": "development", "RESPONSE_CACHE_TTLS": "/geocode/:zipcode"},
+			message: "RESPONSE_CACHE_TTLS entries must be key=duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader applies environment variables over a Config, collecting parse
+// errors so they can all be reported together
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) string(dst *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*dst = value
+	}
+}
+
+func (r *envReader) int(dst *int, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be an integer, got %q", name, value))
+		return
+	}
+	*dst = n
+}
+
+func (r *envReader) float(dst *float64, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a number, got %q", name, value))
+		return
+	}
+	*dst = f
+}
+
+func (r *envReader) bool(dst *bool, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be true or false, got %q", name, value))
+		return
+	}
+	*dst = b
+}
+
+func (r *envReader) duration(dst *time.Duration, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a duration such as 30s or 15m, got %q", name, value))
+		return
+	}
+	*dst = d
+}
+
+// date reads a calendar date such as 2026-12-31, taken as midnight UTC
+func (r *envReader) date(dst *time.Time, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a date such as 2026-12-31, got %q", name, value))
+		return
+	}
+	*dst = t
+}
+
+// list reads a comma-separated list, dropping blank entries
+func (r *envReader) list(dst *[]string, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+// durations reads a comma-separated list of key=duration pairs, replacing
+// any pairs from the config file
+func (r *envReader) durations(dst *map[string]time.Duration, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	pairs := make(map[string]time.Duration)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(item, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if !ok || err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s entries must be key=duration, got %q", name, item))
+			continue
+		}
+		pairs[strings.TrimSpace(key)] = d
+	}
+	*dst = pairs
+}
+
+// ints reads a comma-separated list of key=integer pairs, replacing any
+// pairs from the config file
+func (r *envReader) ints(dst *map[string]int, name string) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	pairs := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		key, raw, ok := strings.Cut(item, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if !ok || err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s entries must be key=integer, got %q", name, item))
+			continue
+		}
+		pairs[strings.TrimSpace(key)] = n
+	}
+	*dst = pairs
+}
+
+// applyEnv overrides settings with any environment variables that are set
+func (c *Config) applyEnv() error {
+	r := &envReader{}
+
+	r.string(&c.Env, "ENV")
+	r.string(&c.Env, "GO_ENV")
+
+	r.int(&c.Server.Port, "PORT")
+	r.bool(&c.Server.BindAllInterfaces, "BIND_ALL_INTERFACES")
+	r.duration(&c.Server.ReadTimeout, "SERVER_READ_TIMEOUT")
+	r.duration(&c.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	r.duration(&c.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	r.duration(&c.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT")
+	r.duration(&c.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+	r.string(&c.Server.MaxBodySize, "MAX_BODY_SIZE")
+	r.duration(&c.Server.RequestTimeout, "REQUEST_TIMEOUT")
+	r.int(&c.Server.CompressionLevel, "COMPRESSION_LEVEL")
+	r.list(&c.Server.TrustedProxies, "TRUSTED_PROXIES")
+
+	r.bool(&c.GRPC.Enabled, "GRPC_ENABLED")
+	r.int(&c.GRPC.Port, "GRPC_PORT")
+
+	r.list(&c.CORS.Origins, "CORS_ORIGINS")
+
+	r.string(&c.Database.Driver, "DB_DRIVER")
+	r.string(&c.Database.SQLitePath, "DB_SQLITE_PATH")
+	r.string(&c.Database.Host, "DB_HOST")
+	r.int(&c.Database.Port, "DB_PORT")
+	r.string(&c.Database.User, "DB_USER")
+	r.string(&c.Database.Password, "DB_PASSWORD")
+	r.string(&c.Database.Name, "DB_NAME")
+	r.string(&c.Database.SSLMode, "DB_SSLMODE")
+	r.int(&c.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS")
+	r.int(&c.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS")
+	r.duration(&c.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME")
+	r.duration(&c.Database.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME")
+	r.string(&c.Database.ReplicaDSN, "DB_REPLICA_DSN")
+	r.int(&c.Database.BreakerThreshold, "DB_BREAKER_THRESHOLD")
+	r.duration(&c.Database.BreakerCooldown, "DB_BREAKER_COOLDOWN")
+	r.duration(&c.Database.HealthCheckInterval, "DB_HEALTH_CHECK_INTERVAL")
+	r.duration(&c.Database.HealthCheckTimeout, "DB_HEALTH_CHECK_TIMEOUT")
+
+	r.bool(&c.Migrations.RunSync, "RUN_MIGRATIONS_SYNC")
+	r.bool(&c.Migrations.CleanupGeoJSON, "CLEANUP_GEOJSON")
+
+	r.string(&c.Auth.JWTSecret, "JWT_SECRET")
+	r.string(&c.Auth.JWTIssuer, "JWT_ISSUER")
+	r.string(&c.Auth.JWTAudience, "JWT_AUDIENCE")
+	r.duration(&c.Auth.JWTLifetime, "JWT_LIFETIME")
+	r.duration(&c.Auth.RefreshTokenLifetime, "REFRESH_TOKEN_LIFETIME")
+	r.duration(&c.Auth.PasswordResetTokenLifetime, "PASSWORD_RESET_TOKEN_LIFETIME")
+	r.duration(&c.Auth.EmailVerificationTokenLifetime, "EMAIL_VERIFICATION_TOKEN_LIFETIME")
+	r.string(&c.Auth.APISecretKey, "API_SECRET_KEY")
+	r.list(&c.Auth.AdminEmails, "ADMIN_EMAILS")
+
+	r.string(&c.Email.SMTPHost, "SMTP_HOST")
+	r.int(&c.Email.SMTPPort, "SMTP_PORT")
+	r.string(&c.Email.SMTPUsername, "SMTP_USERNAME")
+	r.string(&c.Email.SMTPPassword, "SMTP_PASSWORD")
+	r.string(&c.Email.From, "EMAIL_FROM")
+	r.string(&c.Email.AppBaseURL, "APP_BASE_URL")
+	r.bool(&c.Email.Notifications, "EMAIL_NOTIFICATIONS")
+
+	r.bool(&c.Billing.Overage, "OVERAGE_BILLING")
+	r.float(&c.Billing.MaxOverageSpendCap, "OVERAGE_MAX_SPEND_CAP")
+	r.ints(&c.Billing.EndpointCosts, "ENDPOINT_COSTS")
+
+	r.bool(&c.Demo.Enabled, "DEMO_MODE")
+	r.int(&c.Demo.RateLimit, "DEMO_RATE_LIMIT")
+	r.int(&c.Demo.DailyLimit, "DEMO_DAILY_LIMIT")
+
+	r.int(&c.Workers.GeocodeJobWorkers, "GEOCODE_JOB_WORKERS")
+	r.int(&c.Workers.GeocodeJobMaxRows, "GEOCODE_JOB_MAX_ROWS")
+	r.int(&c.Workers.UsageBufferSize, "USAGE_BUFFER_SIZE")
+	r.int(&c.Workers.UsageFlushSize, "USAGE_FLUSH_SIZE")
+	r.duration(&c.Workers.UsageFlushInterval, "USAGE_FLUSH_INTERVAL")
+	r.bool(&c.Workers.UsageCaptureRequests, "USAGE_CAPTURE_REQUESTS")
+	r.duration(&c.Workers.StatsRefreshInterval, "STATS_REFRESH_INTERVAL")
+
+	r.string(&c.Routing.Engine, "ROUTING_ENGINE")
+	r.string(&c.Routing.URL, "ROUTING_URL")
+	r.duration(&c.Routing.Timeout, "ROUTING_TIMEOUT")
+	r.int(&c.Routing.CoverageSpeedMPH, "COVERAGE_SPEED_MPH")
+
+	r.duration(&c.SLO.Window, "SLO_WINDOW")
+	r.duration(&c.SLO.AlertWindow, "SLO_ALERT_WINDOW")
+	r.float(&c.SLO.BurnRateThreshold, "SLO_BURN_RATE_THRESHOLD")
+	r.duration(&c.SLO.CheckInterval, "SLO_CHECK_INTERVAL")
+
+	r.float(&c.Datasets.MaxInvalidPercent, "DATASET_MAX_INVALID_PERCENT")
+	r.list(&c.Datasets.RefreshSources, "DATASET_REFRESH_SOURCES")
+	r.duration(&c.Datasets.RefreshInterval, "DATASET_REFRESH_INTERVAL")
+
+	r.date(&c.API.V1Deprecation, "API_V1_DEPRECATION")
+	r.date(&c.API.V1Sunset, "API_V1_SUNSET")
+
+	r.string(&c.ResponseCache.Backend, "RESPONSE_CACHE")
+	r.int(&c.ResponseCache.MaxEntries, "RESPONSE_CACHE_MAX_ENTRIES")
+	r.string(&c.ResponseCache.RedisURL, "REDIS_URL")
+	r.durations(&c.ResponseCache.TTLs, "RESPONSE_CACHE_TTLS")
+
+	return errors.Join(r.errs...)
+}
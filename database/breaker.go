@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"geocoding-api/config"
+)
+
+// ErrUnavailable is returned instead of a new connection while the circuit
+// is open
+var ErrUnavailable = errors.New("database unavailable")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitStatus reports a circuit breaker's state for health checks
+type CircuitStatus struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	// RetryAfterSeconds is how long until the open circuit lets a trial
+	// connection through
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
+}
+
+// CircuitBreaker stops the API from waiting on a database that is down or
+// too slow to answer. After threshold consecutive connection or health check
+// failures it opens: new connections fail at once with ErrUnavailable and
+// requests are turned away with 503. After cooldown it is half open, letting
+// connections through until one succeeds, which closes it, or fails, which
+// opens it again.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	lastErr  error
+}
+
+// NewCircuitBreaker returns a closed circuit breaker
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: CircuitClosed}
+}
+
+// Circuit guards connections to DB. InitDB configures it from DB_BREAKER_*.
+var Circuit = NewCircuitBreaker(5, 30*time.Second)
+
+// currentState returns the state, moving an open circuit whose cooldown has
+// passed to half open. The caller holds mu.
+func (b *CircuitBreaker) currentState() string {
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cooldown {
+		b.state = CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Open reports whether requests should be turned away without trying the
+// database
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState() == CircuitOpen
+}
+
+// RetryAfter is how long until an open circuit lets a trial connection
+// through, or zero
+func (b *CircuitBreaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.currentState() != CircuitOpen {
+		return 0
+	}
+	return b.cooldown - time.Since(b.openedAt)
+}
+
+// Success records a working connection, closing the circuit
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != CircuitClosed {
+		slog.Info("database circuit closed")
+	}
+	b.state = CircuitClosed
+	b.failures = 0
+	b.lastErr = nil
+}
+
+// Failure records a failed connection or health check. It opens the circuit
+// on the threshold-th failure in a row, or on any failure while half open.
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastErr = err
+	state := b.currentState()
+	if state == CircuitHalfOpen || (state == CircuitClosed && b.failures >= b.threshold) {
+		b.open()
+	}
+}
+
+// trip opens the circuit at once, e.g. for a database that can't be reached
+// at startup
+func (b *CircuitBreaker) trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastErr = err
+	b.open()
+}
+
+// open opens the circuit. The caller holds mu.
+func (b *CircuitBreaker) open() {
+	b.state = CircuitOpen
+	b.openedAt = time.Now()
+	slog.Warn("database circuit opened", "failures", b.failures, "cooldown", b.cooldown.String(), "error", b.lastErr)
+}
+
+// Status reports the breaker's state
+func (b *CircuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := CircuitStatus{State: b.currentState(), Failures: b.failures}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	if b.state == CircuitOpen {
+		status.RetryAfterSeconds = int((b.cooldown - time.Since(b.openedAt)).Seconds()) + 1
+	}
+	return status
+}
+
+// allow reports whether a new connection may be attempted
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState() != CircuitOpen
+}
+
+// circuitConnector opens connections through the circuit breaker, so an
+// unreachable database fails fast rather than each request waiting out a
+// dial timeout. breaker is unset while InitDB is still waiting for the
+// database to come up.
+type circuitConnector struct {
+	driver.Connector
+	breaker atomic.Pointer[CircuitBreaker]
+}
+
+func (c *circuitConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	breaker := c.breaker.Load()
+	if breaker == nil {
+		return c.Connector.Connect(ctx)
+	}
+	if !breaker.allow() {
+		return nil, ErrUnavailable
+	}
+	conn, err := c.Connector.Connect(ctx)
+	switch {
+	case err == nil:
+		breaker.Success()
+	case !errors.Is(err, context.Canceled):
+		// A client hanging up says nothing about the database; a
+		// deadline passing does
+		breaker.Failure(err)
+	}
+	return conn, err
+}
+
+// StartHealthMonitor pings the database, and the read replica if there is
+// one, every DB_HEALTH_CHECK_INTERVAL in the background. A ping that errors
+// or outlasts DB_HEALTH_CHECK_TIMEOUT counts against that connection's
+// circuit breaker, which catches a database that accepts connections but has
+// stopped answering; a ping that succeeds closes it.
+func StartHealthMonitor() {
+	cfg := config.Get().Database
+	go func() {
+		ticker := time.NewTicker(cfg.HealthCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ping(DB, Circuit, cfg.HealthCheckTimeout)
+			ping(Replica, ReplicaCircuit, cfg.HealthCheckTimeout)
+		}
+	}()
+}
+
+// ping checks one connection for StartHealthMonitor. An open circuit is
+// left alone until its cooldown passes.
+func ping(db *sql.DB, breaker *CircuitBreaker, timeout time.Duration) {
+	if db == nil || breaker.Open() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := db.PingContext(ctx)
+	switch {
+	case err == nil:
+		breaker.Success()
+	case !errors.Is(err, ErrUnavailable):
+		breaker.Failure(err)
+	}
+}
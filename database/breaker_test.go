@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(3, 50*time.Millisecond)
+	refused := errors.New("connection refused")
+
+	b.Failure(refused)
+	b.Failure(refused)
+	assert.False(t, b.Open())
+	b.Success()
+	b.Failure(refused)
+	b.Failure(refused)
+	assert.False(t, b.Open(), "a success resets the count")
+
+	b.Failure(refused)
+	require.True(t, b.Open())
+	assert.Greater(t, b.RetryAfter(), time.Duration(0))
+	status := b.Status()
+	assert.Equal(t, CircuitOpen, status.State)
+	assert.Equal(t, "connection refused", status.LastError)
+	assert.NotNil(t, status.OpenedAt)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, b.Open())
+	assert.Equal(t, CircuitHalfOpen, b.Status().State)
+
+	b.Failure(refused)
+	assert.True(t, b.Open(), "one failure while half open reopens")
+
+	time.Sleep(60 * time.Millisecond)
+	b.Success()
+	assert.Equal(t, CircuitStatus{State: CircuitClosed}, b.Status())
+}
+
+// fakeConnector fails every connection with err
+type fakeConnector struct {
+	err   error
+	calls int
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	c.calls++
+	return nil, c.err
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+func TestCircuitConnector(t *testing.T) {
+	inner := &fakeConnector{err: errors.New("connection refused")}
+	connector := &circuitConnector{Connector: inner}
+	ctx := context.Background()
+
+	// Without a breaker, as during startup, failures aren't counted
+	connector.Connect(ctx)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	connector.breaker.Store(breaker)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	inner.err = context.Canceled
+	connector.Connect(canceled)
+	assert.Equal(t, 0, breaker.Status().Failures, "a canceled request isn't the database's fault")
+
+	inner.err = errors.New("connection refused")
+	connector.Connect(ctx)
+	connector.Connect(ctx)
+	require.True(t, breaker.Open())
+
+	_, err := connector.Connect(ctx)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 4, inner.calls, "an open circuit doesn't dial")
+}
@@ -4,10 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
-	_ "github.com/lib/pq"
+	"geocoding-api/config"
+
+	"github.com/lib/pq"
 )
 
 // DB holds the database connection
@@ -15,33 +16,29 @@ var DB *sql.DB
 
 // InitDB initializes the database connection with retry logic
 func InitDB() error {
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "postgres")
-	dbname := getEnv("DB_NAME", "geocoding_db")
-	sslmode := getEnv("DB_SSLMODE", "disable")
+	db := config.Get().Database
+	if db.Driver == config.DriverSQLite {
+		return initSQLite(db)
+	}
 	
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
+	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		db.Host, db.Port, db.User, db.Password, db.Name, db.SSLMode)
 	
-	maskedUrl := fmt.Sprintf("postgres://%s:***@%s:%s/%s?sslmode=%s", user, host, port, dbname, sslmode)
+	maskedUrl := fmt.Sprintf("postgres://%s:***@%s:%d/%s?sslmode=%s", db.User, db.Host, db.Port, db.Name, db.SSLMode)
 	log.Printf("Connecting to database: %s", maskedUrl)
 
-	var err error
+	pqConnector, err := pq.NewConnector(psqlInfo)
+	if err != nil {
+		return fmt.Errorf("invalid database settings: %w", err)
+	}
+	connector := &circuitConnector{Connector: pqConnector}
 	
 	// Retry logic for database connection (useful for container startup ordering)
 	maxRetries := 30
 	retryDelay := 2 * time.Second
 	
 	for i := 0; i < maxRetries; i++ {
-		DB, err = sql.Open("postgres", psqlInfo)
-		if err != nil {
-			log.Printf("Attempt %d/%d: Failed to open database: %v", i+1, maxRetries, err)
-			time.Sleep(retryDelay)
-			continue
-		}
-
+		DB = sql.OpenDB(connector)
 		err = DB.Ping()
 		if err == nil {
 			break
@@ -56,10 +53,21 @@ func InitDB() error {
 		return fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, err)
 	}
 
-	// Optimize connection pool for performance
-	DB.SetMaxOpenConns(25)          // Maximum open connections
-	DB.SetMaxIdleConns(10)           // Keep connections ready
-	DB.SetConnMaxLifetime(0)         // Reuse connections indefinitely
+	// Size the pool from DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS; lifetimes
+	// of 0 reuse connections indefinitely
+	DB.SetMaxOpenConns(db.MaxOpenConns)
+	DB.SetMaxIdleConns(db.MaxIdleConns)
+	DB.SetConnMaxLifetime(db.ConnMaxLifetime)
+	DB.SetConnMaxIdleTime(db.ConnMaxIdleTime)
+
+	// Startup waits for the database above; from here on an outage trips
+	// the circuit breaker instead
+	Circuit = NewCircuitBreaker(db.BreakerThreshold, db.BreakerCooldown)
+	connector.breaker.Store(Circuit)
+
+	if err := initReplica(db); err != nil {
+		return err
+	}
 
 	log.Println("Database connection established successfully")
 	return nil
@@ -71,18 +79,13 @@ func CreateTables() error {
 	return RunMigrations()
 }
 
-// CloseDB closes the database connection
+// CloseDB closes the database connection and the read replica's
 func CloseDB() error {
+	if Replica != nil {
+		Replica.Close()
+	}
 	if DB != nil {
 		return DB.Close()
 	}
 	return nil
-}
-
-// getEnv gets an environment variable with a default fallback
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
 }
\ No newline at end of file
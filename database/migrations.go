@@ -1,14 +1,23 @@
 package database
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"geocoding-api/utils"
+	"geocoding-api/migrations"
+
+	"github.com/lib/pq"
 )
 
 // MigrationStatus tracks the status of async migrations
@@ -17,141 +26,104 @@ var (
 	MigrationError   error
 )
 
-// RunMigrations runs all database migrations in order
-func RunMigrations() error {
-	log.Println("Running database migrations...")
+// ErrMigrationModified is returned when the SQL of an applied migration no
+// longer matches the checksum recorded when it ran
+var ErrMigrationModified = errors.New("applied migration has been modified")
 
-	migrations := []Migration{
-		{
-			Version:     1,
-			Description: "Create zip_codes table",
-			Up:          createZipCodesTable,
-			Down:        dropZipCodesTable,
-		},
-		{
-			Version:     2,
-			Description: "Create migration tracking table",
-			Up:          createMigrationsTable,
-			Down:        dropMigrationsTable,
-		},
-		{
-			Version:     3,
-			Description: "Create authentication tables",
-			Up:          createAuthTables,
-			Down:        dropAuthTables,
-		},
-		{
-			Version:     4,
-			Description: "Add name and company fields to users table",
-			Up:          addUserFields,
-			Down:        removeUserFields,
-		},
-		{
-			Version:     5,
-			Description: "Add key_preview and expires_at to api_keys table",
-			Up:          addAPIKeyFields,
-			Down:        removeAPIKeyFields,
-		},
-		{
-			Version:     6,
-			Description: "Update subscriptions table with billing columns",
-			Up:          updateSubscriptionsTable,
-			Down:        revertSubscriptionsTable,
-		},
-		{
-			Version:     7,
-			Description: "Add admin role to users table",
-			Up:          addAdminRole,
-			Down:        removeAdminRole,
-		},
-		{
-			Version:     8,
-			Description: "Create Ohio addresses table and load county data",
-			Up:          createOhioAddressesTable,
-			Down:        dropOhioAddressesTable,
-		},
-		{
-			Version:     9,
-			Description: "Create Ohio counties table and load boundary data",
-			Up:          createOhioCountiesTable,
-			Down:        dropOhioCountiesTable,
-		},
-		{
-			Version:     10,
-			Description: "Add unique constraint to subscriptions user_id",
-			Up:          addSubscriptionsUniqueConstraint,
-			Down:        removeSubscriptionsUniqueConstraint,
-		},
-	{
-		Version:     11,
-		Description: "Add trigram indexes for faster text search",
-		Up:          addTrigramIndexes,
-		Down:        removeTrigramIndexes,
-	},
-	{
-		Version:     12,
-		Description: "Add composite index for rate limit queries",
-		Up:          addRateLimitIndex,
-		Down:        removeRateLimitIndex,
-	},
-	{
-		Version:     13,
-		Description: "Create cities table for US city data",
-		Up:          createCitiesTable,
-		Down:        dropCitiesTable,
-	},
-	{
-		Version:     14,
-		Description: "Create US states table with boundary data",
-		Up:          createStatesTable,
-		Down:        dropStatesTable,
-	},
-	{
-		Version:     15,
-		Description: "Add full_address column to ohio_addresses table",
-		Up:          addFullAddressColumn,
-		Down:        removeFullAddressColumn,
-	},
-	{
-		Version:     16,
-		Description: "Expand street abbreviations in full_address column",
-		Up:          expandStreetAbbreviations,
-		Down:        revertStreetAbbreviations,
-	},
-	{
-		Version:     17,
-		Description: "Create datasets table for tracking uploaded county data",
-		Up:          createDatasetsTable,
-		Down:        dropDatasetsTable,
-	},
-}	// Create migrations table if it doesn't exist
-	if err := createMigrationsTable(); err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+// migrationLockID is the advisory lock held while migrating, so instances
+// starting together don't apply the same version twice
+const migrationLockID = 720_306
+
+// migrationFileName matches NNNNNN_name.up.sql and NNNNNN_name.down.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change read from the migrations directory
+type Migration struct {
+	Version     int
+	Description string
+	Up          string
+	Down        string
+	// Checksum is the SHA-256 of the up SQL, recorded when it is applied
+	Checksum string
+}
+
+// MigrationState describes one migration for `migrate status`
+type MigrationState struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   *time.Time
+	// Modified is set when the up SQL changed after it was applied
+	Modified bool
+	// Missing is set when a version was applied but its files are gone
+	Missing bool
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	description string
+	appliedAt   time.Time
+	checksum    sql.NullString
+}
+
+// LoadMigrations reads the up/down file pairs in fsys, sorted by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
 	}
 
-	// Run pending migrations
-	for _, migration := range migrations {
-		applied, err := isMigrationApplied(migration.Version)
+	byVersion := make(map[int]*Migration)
+	files := make(map[int]int)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
 		if err != nil {
-			return fmt.Errorf("failed to check migration status: %w", err)
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
 		}
 
-		if !applied {
-			log.Printf("Running migration %d: %s", migration.Version, migration.Description)
-			if err := migration.Up(); err != nil {
-				return fmt.Errorf("failed to run migration %d: %w", migration.Version, err)
-			}
+		description := strings.ReplaceAll(match[2], "_", " ")
+		description = strings.ToUpper(description[:1]) + description[1:]
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Description: description}
+			byVersion[version] = m
+		} else if m.Description != description {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
 
-			if err := markMigrationApplied(migration.Version, migration.Description); err != nil {
-				return fmt.Errorf("failed to mark migration as applied: %w", err)
-			}
+		files[version]++
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
 		} else {
-			log.Printf("Migration %d already applied: %s", migration.Version, migration.Description)
+			m.Down = string(content)
 		}
 	}
 
-	log.Println("All migrations completed successfully")
-	return nil
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if files[m.Version] != 2 {
+			return nil, fmt.Errorf("migration %d needs both an up and a down file", m.Version)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// RunMigrations applies every pending migration in order
+func RunMigrations() error {
+	return MigrateUp(context.Background())
 }
 
 // RunMigrationsAsync runs migrations in a background goroutine
@@ -162,7 +134,7 @@ func RunMigrationsAsync() {
 		defer func() {
 			MigrationRunning = false
 		}()
-		
+
 		log.Println("Starting migrations in background...")
 		if err := RunMigrations(); err != nil {
 			MigrationError = err
@@ -173,984 +145,296 @@ func RunMigrationsAsync() {
 	}()
 }
 
-// Migration represents a database migration
-type Migration struct {
-	Version     int
-	Description string
-	Up          func() error
-	Down        func() error
-}
-
-// createMigrationsTable creates the schema_migrations table
-func createMigrationsTable() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS schema_migrations (
-		version INTEGER PRIMARY KEY,
-		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		description TEXT NOT NULL
-	)`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// dropMigrationsTable drops the schema_migrations table
-func dropMigrationsTable() error {
-	_, err := DB.Exec("DROP TABLE IF EXISTS schema_migrations")
-	return err
-}
-
-// createZipCodesTable creates the zip_codes table
-func createZipCodesTable() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS zip_codes (
-		zip_code VARCHAR(10) PRIMARY KEY,
-		city_name VARCHAR(255) NOT NULL,
-		state_code VARCHAR(2) NOT NULL,
-		state_name VARCHAR(255) NOT NULL,
-		zcta BOOLEAN NOT NULL DEFAULT FALSE,
-		zcta_parent VARCHAR(10),
-		population DECIMAL(12,2),
-		density DECIMAL(10,2),
-		primary_county_code VARCHAR(10) NOT NULL,
-		primary_county_name VARCHAR(255) NOT NULL,
-		county_weights JSONB,
-		county_names TEXT,
-		county_codes TEXT,
-		imprecise BOOLEAN NOT NULL DEFAULT FALSE,
-		military BOOLEAN NOT NULL DEFAULT FALSE,
-		timezone VARCHAR(100) NOT NULL,
-		latitude DECIMAL(10,7) NOT NULL,
-		longitude DECIMAL(10,7) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Create indexes for better query performance
-	CREATE INDEX IF NOT EXISTS idx_zip_codes_state_code ON zip_codes(state_code);
-	CREATE INDEX IF NOT EXISTS idx_zip_codes_city_name ON zip_codes(city_name);
-	CREATE INDEX IF NOT EXISTS idx_zip_codes_state_name ON zip_codes(state_name);
-	CREATE INDEX IF NOT EXISTS idx_zip_codes_county_name ON zip_codes(primary_county_name);
-	CREATE INDEX IF NOT EXISTS idx_zip_codes_location ON zip_codes(latitude, longitude);
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// dropZipCodesTable drops the zip_codes table
-func dropZipCodesTable() error {
-	_, err := DB.Exec("DROP TABLE IF EXISTS zip_codes")
-	return err
-}
+// MigrateUp applies pending migrations in version order. It refuses to run
+// if an applied migration's SQL has been edited since it ran.
+func MigrateUp(ctx context.Context) error {
+	log.Println("Running database migrations...")
 
-// isMigrationApplied checks if a migration has been applied
-func isMigrationApplied(version int) (bool, error) {
-	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&count)
+	all, err := LoadMigrations(migrations.Files)
 	if err != nil {
-		return false, err
+		return err
 	}
-	return count > 0, nil
-}
-
-// markMigrationApplied marks a migration as applied
-func markMigrationApplied(version int, description string) error {
-	_, err := DB.Exec("INSERT INTO schema_migrations (version, description) VALUES ($1, $2)", version, description)
-	return err
-}
-
-// createAuthTables creates all authentication related tables
-func createAuthTables() error {
-	query := `
-	-- Users table
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		email VARCHAR(255) UNIQUE NOT NULL,
-		password_hash VARCHAR(255) NOT NULL,
-		plan_type VARCHAR(50) DEFAULT 'free' CHECK (plan_type IN ('free', 'basic', 'pro', 'enterprise')),
-		is_active BOOLEAN DEFAULT true,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- API Keys table
-	CREATE TABLE IF NOT EXISTS api_keys (
-		id SERIAL PRIMARY KEY,
-		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
-		name VARCHAR(255) NOT NULL,
-		key_hash VARCHAR(255) NOT NULL UNIQUE,
-		permissions TEXT[], -- Array of permission strings
-		is_active BOOLEAN DEFAULT true,
-		last_used_at TIMESTAMP,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
 
-	-- Usage Records table
-	CREATE TABLE IF NOT EXISTS usage_records (
-		id SERIAL PRIMARY KEY,
-		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
-		api_key_id INTEGER REFERENCES api_keys(id) ON DELETE CASCADE,
-		endpoint VARCHAR(100) NOT NULL,
-		method VARCHAR(10) NOT NULL,
-		status_code INTEGER,
-		response_time_ms INTEGER,
-		ip_address INET,
-		user_agent TEXT,
-		billable BOOLEAN DEFAULT true,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Subscriptions table (for tracking billing periods and usage limits)
-	CREATE TABLE IF NOT EXISTS subscriptions (
-		id SERIAL PRIMARY KEY,
-		user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
-		plan_type VARCHAR(50) NOT NULL,
-		monthly_limit INTEGER NOT NULL,
-		current_usage INTEGER DEFAULT 0,
-		billing_period_start DATE NOT NULL,
-		billing_period_end DATE NOT NULL,
-		is_active BOOLEAN DEFAULT true,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Create indexes for performance
-	CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-	CREATE INDEX IF NOT EXISTS idx_users_plan_type ON users(plan_type);
-	CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
-	CREATE INDEX IF NOT EXISTS idx_api_keys_hash ON api_keys(key_hash);
-	CREATE INDEX IF NOT EXISTS idx_usage_records_user_id ON usage_records(user_id);
-	CREATE INDEX IF NOT EXISTS idx_usage_records_api_key_id ON usage_records(api_key_id);
-	CREATE INDEX IF NOT EXISTS idx_usage_records_created_at ON usage_records(created_at);
-	CREATE INDEX IF NOT EXISTS idx_usage_records_endpoint ON usage_records(endpoint);
-	CREATE INDEX IF NOT EXISTS idx_usage_records_billable ON usage_records(billable);
-	CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id);
-	CREATE INDEX IF NOT EXISTS idx_subscriptions_billing_period ON subscriptions(billing_period_start, billing_period_end);
-
-	-- Create a function to update the updated_at timestamp
-	CREATE OR REPLACE FUNCTION update_updated_at_column()
-	RETURNS TRIGGER AS $$
-	BEGIN
-		NEW.updated_at = CURRENT_TIMESTAMP;
-		RETURN NEW;
-	END;
-	$$ language 'plpgsql';
-
-	-- Create triggers to automatically update the updated_at column
-	DROP TRIGGER IF EXISTS update_users_updated_at ON users;
-	CREATE TRIGGER update_users_updated_at 
-		BEFORE UPDATE ON users 
-		FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-
-	DROP TRIGGER IF EXISTS update_api_keys_updated_at ON api_keys;
-	CREATE TRIGGER update_api_keys_updated_at 
-		BEFORE UPDATE ON api_keys 
-		FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-
-	DROP TRIGGER IF EXISTS update_subscriptions_updated_at ON subscriptions;
-	CREATE TRIGGER update_subscriptions_updated_at 
-		BEFORE UPDATE ON subscriptions 
-		FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// dropAuthTables drops all authentication related tables
-func dropAuthTables() error {
-	query := `
-	DROP TRIGGER IF EXISTS update_subscriptions_updated_at ON subscriptions;
-	DROP TRIGGER IF EXISTS update_api_keys_updated_at ON api_keys;
-	DROP TRIGGER IF EXISTS update_users_updated_at ON users;
-	DROP FUNCTION IF EXISTS update_updated_at_column();
-	DROP TABLE IF EXISTS subscriptions;
-	DROP TABLE IF EXISTS usage_records;
-	DROP TABLE IF EXISTS api_keys;
-	DROP TABLE IF EXISTS users;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// addUserFields adds name and company fields to users table
-func addUserFields() error {
-	query := `
-	ALTER TABLE users 
-	ADD COLUMN IF NOT EXISTS name VARCHAR(255),
-	ADD COLUMN IF NOT EXISTS company VARCHAR(255);
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// removeUserFields removes name and company fields from users table
-func removeUserFields() error {
-	query := `
-	ALTER TABLE users 
-	DROP COLUMN IF EXISTS name,
-	DROP COLUMN IF EXISTS company;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// addAPIKeyFields adds key_preview and expires_at fields to api_keys table
-func addAPIKeyFields() error {
-	query := `
-	ALTER TABLE api_keys 
-	ADD COLUMN IF NOT EXISTS key_preview VARCHAR(50),
-	ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// removeAPIKeyFields removes key_preview and expires_at fields from api_keys table
-func removeAPIKeyFields() error {
-	query := `
-	ALTER TABLE api_keys 
-	DROP COLUMN IF EXISTS key_preview,
-	DROP COLUMN IF EXISTS expires_at;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// updateSubscriptionsTable adds missing columns to subscriptions table for proper billing
-func updateSubscriptionsTable() error {
-	query := `
-	-- Add missing columns to subscriptions table
-	ALTER TABLE subscriptions 
-	ADD COLUMN IF NOT EXISTS status VARCHAR(50) DEFAULT 'active' CHECK (status IN ('active', 'cancelled', 'past_due', 'trialing')),
-	ADD COLUMN IF NOT EXISTS current_period_start TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-	ADD COLUMN IF NOT EXISTS current_period_end TIMESTAMP DEFAULT (CURRENT_TIMESTAMP + INTERVAL '1 month'),
-	ADD COLUMN IF NOT EXISTS price_per_call DECIMAL(10,6) DEFAULT 0.0,
-	ADD COLUMN IF NOT EXISTS stripe_customer_id VARCHAR(255),
-	ADD COLUMN IF NOT EXISTS stripe_subscription_id VARCHAR(255);
-
-	-- Remove old columns that are no longer used
-	ALTER TABLE subscriptions 
-	DROP COLUMN IF EXISTS billing_period_start,
-	DROP COLUMN IF EXISTS billing_period_end,
-	DROP COLUMN IF EXISTS current_usage;
-
-	-- Add indexes for new columns
-	CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
-	CREATE INDEX IF NOT EXISTS idx_subscriptions_current_period ON subscriptions(current_period_start, current_period_end);
-	CREATE INDEX IF NOT EXISTS idx_subscriptions_stripe_customer ON subscriptions(stripe_customer_id);
-	CREATE INDEX IF NOT EXISTS idx_subscriptions_stripe_subscription ON subscriptions(stripe_subscription_id);
-
-	-- Update existing records to have proper current period dates
-	UPDATE subscriptions 
-	SET 
-		current_period_start = COALESCE(current_period_start, created_at),
-		current_period_end = COALESCE(current_period_end, created_at + INTERVAL '1 month')
-	WHERE current_period_start IS NULL OR current_period_end IS NULL;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// revertSubscriptionsTable reverts the subscriptions table changes
-func revertSubscriptionsTable() error {
-	query := `
-	-- Remove indexes
-	DROP INDEX IF EXISTS idx_subscriptions_stripe_subscription;
-	DROP INDEX IF EXISTS idx_subscriptions_stripe_customer;
-	DROP INDEX IF EXISTS idx_subscriptions_current_period;
-	DROP INDEX IF EXISTS idx_subscriptions_status;
-
-	-- Add back old columns
-	ALTER TABLE subscriptions 
-	ADD COLUMN IF NOT EXISTS billing_period_start DATE,
-	ADD COLUMN IF NOT EXISTS billing_period_end DATE,
-	ADD COLUMN IF NOT EXISTS current_usage INTEGER DEFAULT 0;
-
-	-- Remove new columns
-	ALTER TABLE subscriptions 
-	DROP COLUMN IF EXISTS stripe_subscription_id,
-	DROP COLUMN IF EXISTS stripe_customer_id,
-	DROP COLUMN IF EXISTS price_per_call,
-	DROP COLUMN IF EXISTS current_period_end,
-	DROP COLUMN IF EXISTS current_period_start,
-	DROP COLUMN IF EXISTS status;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// addAdminRole adds is_admin column to users table
-func addAdminRole() error {
-	query := `
-	ALTER TABLE users 
-	ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE;
-
-	-- Create index for admin queries
-	CREATE INDEX IF NOT EXISTS idx_users_is_admin ON users(is_admin);
-	
-	-- Set first user as admin if no admins exist
-	UPDATE users 
-	SET is_admin = TRUE 
-	WHERE id = (SELECT MIN(id) FROM users) 
-	AND NOT EXISTS (SELECT 1 FROM users WHERE is_admin = TRUE);
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// removeAdminRole removes is_admin column from users table
-func removeAdminRole() error {
-	query := `
-	DROP INDEX IF EXISTS idx_users_is_admin;
-	ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
-}
-
-// createOhioAddressesTable creates the ohio_addresses table and loads data from GeoJSON files
-func createOhioAddressesTable() error {
-	// First enable PostGIS extension if not already enabled
-	if _, err := DB.Exec("CREATE EXTENSION IF NOT EXISTS postgis"); err != nil {
-		return fmt.Errorf("failed to enable PostGIS extension: %w", err)
-	}
-
-	// Create the table with PostGIS geometry support
-	createTableQuery := `
-	-- Create ohio_addresses table with PostGIS geometry
-	CREATE TABLE IF NOT EXISTS ohio_addresses (
-		id BIGSERIAL PRIMARY KEY,
-		hash VARCHAR(255) UNIQUE NOT NULL,
-		house_number VARCHAR(50),
-		street VARCHAR(255),
-		unit VARCHAR(50),
-		city VARCHAR(255),
-		district VARCHAR(10), -- County abbreviation
-		region VARCHAR(2), -- State code
-		postcode VARCHAR(10),
-		county VARCHAR(255), -- Full county name from filename
-		geom GEOMETRY(POINT, 4326) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
+	return withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksums(ctx, conn, all, applied); err != nil {
+			return err
+		}
 
-	-- Create spatial index for better query performance
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_geom ON ohio_addresses USING GIST (geom);
-	
-	-- Create indexes for common queries
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_hash ON ohio_addresses(hash);
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_county ON ohio_addresses(county);
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_district ON ohio_addresses(district);
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_city ON ohio_addresses(city);
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_postcode ON ohio_addresses(postcode);
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_street ON ohio_addresses(street);
-	`
-	
-	if _, err := DB.Exec(createTableQuery); err != nil {
-		return fmt.Errorf("failed to create ohio_addresses table: %w", err)
-	}
+		for _, m := range all {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
 
-	log.Println("Ohio addresses table created successfully. Data will be loaded by service if needed.")
-	return nil
-}
+			log.Printf("Running migration %d: %s", m.Version, m.Description)
+			if err := applyMigration(ctx, conn, m); err != nil {
+				return fmt.Errorf("failed to run migration %d: %w", m.Version, err)
+			}
+		}
 
-// dropOhioAddressesTable drops the ohio_addresses table
-func dropOhioAddressesTable() error {
-	_, err := DB.Exec("DROP TABLE IF EXISTS ohio_addresses")
-	return err
+		log.Printf("All migrations completed successfully (%d already applied)", len(applied))
+		return nil
+	})
 }
 
-// createOhioCountiesTable creates the ohio_counties table and loads boundary data from GeoJSON meta files
-func createOhioCountiesTable() error {
-	// First create the table with PostGIS geometry support
-	createTableQuery := `
-	-- Create ohio_counties table with PostGIS geometry
-	CREATE TABLE IF NOT EXISTS ohio_counties (
-		id SERIAL PRIMARY KEY,
-		county_name VARCHAR(255) UNIQUE NOT NULL,
-		source_name VARCHAR(255) NOT NULL,
-		layer VARCHAR(100) NOT NULL,
-		address_count INTEGER DEFAULT 0,
-		stats JSONB,
-		bounds_geometry GEOMETRY(POLYGON, 4326) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Create spatial index for better query performance
-	CREATE INDEX IF NOT EXISTS idx_ohio_counties_bounds ON ohio_counties USING GIST (bounds_geometry);
-	
-	-- Create indexes for common queries
-	CREATE INDEX IF NOT EXISTS idx_ohio_counties_name ON ohio_counties(county_name);
-	CREATE INDEX IF NOT EXISTS idx_ohio_counties_address_count ON ohio_counties(address_count);
-	`;
-	
-	if _, err := DB.Exec(createTableQuery); err != nil {
-		return fmt.Errorf("failed to create ohio_counties table: %w", err)
+// MigrateDown rolls back the most recently applied steps migrations, newest first
+func MigrateDown(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
 	}
 
-	// Load county boundary data from meta files
-	return loadOhioCountyBoundaries()
-}
-
-// dropOhioCountiesTable drops the ohio_counties table
-func dropOhioCountiesTable() error {
-	_, err := DB.Exec("DROP TABLE IF EXISTS ohio_counties")
-	return err
-}
-
-// loadOhioCountyBoundaries loads county boundary data from all Ohio county GeoJSON meta files
-func loadOhioCountyBoundaries() error {
-	log.Println("Loading Ohio county boundary data from GeoJSON meta files...")
-	
-	// Download Ohio data if not present
-	downloader := utils.NewFileDownloader("./cache")
-	if err := downloader.DownloadOhioData("."); err != nil {
-		log.Printf("Warning: Failed to download Ohio data: %v", err)
-		log.Println("Continuing with existing files if available...")
-	}
-	
-	// Get all meta files in the oh directory (only address county files, not buildings/parcels)
-	files, err := filepath.Glob("oh/*-addresses-county.geojson.meta")
+	all, err := LoadMigrations(migrations.Files)
 	if err != nil {
-		return fmt.Errorf("failed to find GeoJSON meta files: %w", err)
+		return err
+	}
+	byVersion := make(map[int]Migration, len(all))
+	for _, m := range all {
+		byVersion[m.Version] = m
 	}
 
-	totalRecords := 0
-	for _, filePath := range files {
-		// Extract county name from filename
-		filename := filepath.Base(filePath)
-		countyName := strings.TrimSuffix(filename, "-addresses-county.geojson.meta")
-		countyName = strings.ReplaceAll(countyName, "_", " ")
-		countyName = strings.ReplaceAll(countyName, "-", " ")
-		countyName = strings.Title(strings.ToLower(strings.TrimSpace(countyName)))
-
-		log.Printf("Processing county boundary: %s (%s)", filename, countyName)
-		
-		// Read and parse the meta file
-		data, err := os.ReadFile(filePath)
+	return withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
 		if err != nil {
-			log.Printf("Warning: Failed to read %s: %v", filePath, err)
-			continue
-		}
-
-		var metaData struct {
-			SourceName string `json:"source_name"`
-			Layer      string `json:"layer"`
-			Count      int    `json:"count"`
-			Stats      map[string]interface{} `json:"stats"`
-			Bounds     struct {
-				Type        string    `json:"type"`
-				Coordinates [][][]float64 `json:"coordinates"`
-			} `json:"bounds"`
+			return err
 		}
 
-		if err := json.Unmarshal(data, &metaData); err != nil {
-			log.Printf("Warning: Failed to parse JSON in %s: %v", filePath, err)
-			continue
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
 		}
-
-		// Skip if not a valid polygon
-		if metaData.Bounds.Type != "Polygon" || len(metaData.Bounds.Coordinates) == 0 {
-			log.Printf("Warning: Invalid polygon bounds in %s", filePath)
-			continue
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		if steps > len(versions) {
+			steps = len(versions)
 		}
 
-		// Convert coordinates to WKT format for PostGIS
-		coords := metaData.Bounds.Coordinates[0] // First ring of the polygon
-		var wktCoords []string
-		for _, coord := range coords {
-			if len(coord) >= 2 {
-				wktCoords = append(wktCoords, fmt.Sprintf("%f %f", coord[0], coord[1]))
+		for _, version := range versions[:steps] {
+			m, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but has no down file", version)
 			}
-		}
-		
-		if len(wktCoords) < 4 {
-			log.Printf("Warning: Invalid polygon coordinates in %s", filePath)
-			continue
-		}
-
-		polygonWKT := fmt.Sprintf("POLYGON((%s))", strings.Join(wktCoords, ", "))
 
-		// Convert stats to JSON
-		statsJSON, err := json.Marshal(metaData.Stats)
-		if err != nil {
-			log.Printf("Warning: Failed to marshal stats for %s: %v", filePath, err)
-			statsJSON = []byte("{}")
-		}
-
-		// Insert county boundary data
-		query := `
-		INSERT INTO ohio_counties (county_name, source_name, layer, address_count, stats, bounds_geometry)
-		VALUES ($1, $2, $3, $4, $5, ST_SetSRID(ST_GeomFromText($6), 4326))
-		ON CONFLICT (county_name) DO UPDATE SET
-			source_name = EXCLUDED.source_name,
-			layer = EXCLUDED.layer,
-			address_count = EXCLUDED.address_count,
-			stats = EXCLUDED.stats,
-			bounds_geometry = EXCLUDED.bounds_geometry,
-			updated_at = CURRENT_TIMESTAMP
-		`
-
-		_, err = DB.Exec(query, countyName, metaData.SourceName, metaData.Layer, metaData.Count, string(statsJSON), polygonWKT)
-		if err != nil {
-			log.Printf("Warning: Failed to insert county %s: %v", countyName, err)
-			continue
+			log.Printf("Rolling back migration %d: %s", m.Version, m.Description)
+			if err := revertMigration(ctx, conn, m); err != nil {
+				return fmt.Errorf("failed to roll back migration %d: %w", m.Version, err)
+			}
 		}
+		return nil
+	})
+}
 
-		totalRecords++
+// GetMigrationStatus reports every known or applied migration in version
+// order
+func GetMigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	all, err := LoadMigrations(migrations.Files)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("Successfully loaded %d county boundary records", totalRecords)
-	
-	// Clean up GeoJSON files after successful loading to save disk space
-	if err := cleanupGeoJSONFiles(); err != nil {
-		log.Printf("Warning: Failed to cleanup GeoJSON files: %v", err)
-		// Don't return error as the migration was successful
+	// Status doesn't take the migration lock so it can report on a database
+	// that is being migrated
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a database connection: %w", err)
 	}
-	
-	return nil
-}
+	defer conn.Close()
 
-// cleanupGeoJSONFiles removes GeoJSON and meta files after data has been loaded into database
-func cleanupGeoJSONFiles() error {
-	log.Println("Cleaning up GeoJSON files to save disk space...")
-	
-	// Check if we're in production environment
-	isProd := os.Getenv("ENV") == "production" || os.Getenv("GO_ENV") == "production"
-	
-	// Also check if CLEANUP_GEOJSON is explicitly set
-	cleanupEnabled := os.Getenv("CLEANUP_GEOJSON") == "true"
-	
-	if !isProd && !cleanupEnabled {
-		log.Println("Skipping GeoJSON cleanup in development environment. Set CLEANUP_GEOJSON=true to force cleanup.")
-		return nil
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
 	}
-	
-	// Get all GeoJSON files (both .geojson and .geojson.meta files)
-	patterns := []string{
-		"oh/*.geojson",
-		"oh/*.geojson.meta",
-	}
-	
-	totalFilesDeleted := 0
-	var totalSizeFreed int64
-	
-	for _, pattern := range patterns {
-		files, err := filepath.Glob(pattern)
-		if err != nil {
-			log.Printf("Warning: Failed to find files with pattern %s: %v", pattern, err)
-			continue
-		}
-		
-		for _, filePath := range files {
-			// Get file size before deletion
-			if info, err := os.Stat(filePath); err == nil {
-				totalSizeFreed += info.Size()
-			}
-			
-			// Delete the file
-			if err := os.Remove(filePath); err != nil {
-				log.Printf("Warning: Failed to delete %s: %v", filePath, err)
-				continue
-			}
-			
-			totalFilesDeleted++
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	known := make(map[int]bool, len(all))
+	for _, m := range all {
+		known[m.Version] = true
+		state := MigrationState{Version: m.Version, Description: m.Description}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.appliedAt
+			state.Applied = true
+			state.AppliedAt = &appliedAt
+			state.Modified = row.checksum.Valid && row.checksum.String != m.Checksum
 		}
+		states = append(states, state)
 	}
-	
-	// Convert bytes to human readable format
-	sizeFreedMB := float64(totalSizeFreed) / (1024 * 1024)
-	
-	log.Printf("Successfully cleaned up %d GeoJSON files, freed %.2f MB of disk space", 
-		totalFilesDeleted, sizeFreedMB)
-	
-	// Remove the oh directory if it's empty
-	if entries, err := os.ReadDir("oh"); err == nil && len(entries) == 0 {
-		if err := os.Remove("oh"); err != nil {
-			log.Printf("Warning: Failed to remove empty oh directory: %v", err)
-		} else {
-			log.Println("Removed empty oh directory")
+	for version, row := range applied {
+		if known[version] {
+			continue
 		}
+		appliedAt := row.appliedAt
+		states = append(states, MigrationState{
+			Version:     version,
+			Description: row.description,
+			Applied:     true,
+			AppliedAt:   &appliedAt,
+			Missing:     true,
+		})
 	}
-	
-	return nil
-}
-// addSubscriptionsUniqueConstraint adds a unique constraint on user_id in subscriptions table
-func addSubscriptionsUniqueConstraint() error {
-	_, err := DB.Exec(`
-		ALTER TABLE subscriptions 
-		ADD CONSTRAINT subscriptions_user_id_unique UNIQUE (user_id)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to add unique constraint to subscriptions: %w", err)
-	}
-	return nil
-}
 
-// removeSubscriptionsUniqueConstraint removes the unique constraint on user_id in subscriptions table
-func removeSubscriptionsUniqueConstraint() error {
-	_, err := DB.Exec(`
-		ALTER TABLE subscriptions 
-		DROP CONSTRAINT IF EXISTS subscriptions_user_id_unique
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to remove unique constraint from subscriptions: %w", err)
-	}
-	return nil
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
 }
 
-// addTrigramIndexes adds pg_trgm extension and GIN indexes for faster ILIKE queries
-func addTrigramIndexes() error {
-	// Enable pg_trgm extension for trigram-based text search
-	_, err := DB.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`)
+// SchemaVersion returns the newest applied migration version and the newest
+// one bundled with this build. applied is 0 before any migration has run.
+func SchemaVersion(ctx context.Context) (applied, latest int, err error) {
+	all, err := LoadMigrations(migrations.Files)
 	if err != nil {
-		return fmt.Errorf("failed to create pg_trgm extension: %w", err)
+		return 0, 0, err
 	}
-
-	// Create GIN indexes using trigram operator class for ILIKE performance
-	queries := []string{
-		`CREATE INDEX IF NOT EXISTS idx_ohio_addresses_street_trgm ON ohio_addresses USING gin (street gin_trgm_ops)`,
-		`CREATE INDEX IF NOT EXISTS idx_ohio_addresses_city_trgm ON ohio_addresses USING gin (city gin_trgm_ops)`,
-		`CREATE INDEX IF NOT EXISTS idx_ohio_addresses_house_number_trgm ON ohio_addresses USING gin (house_number gin_trgm_ops)`,
+	if len(all) > 0 {
+		latest = all[len(all)-1].Version
 	}
 
-	for _, query := range queries {
-		if _, err := DB.Exec(query); err != nil {
-			return fmt.Errorf("failed to create trigram index: %w", err)
-		}
+	err = DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&applied)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+		// schema_migrations doesn't exist until the first migration runs
+		return 0, latest, nil
 	}
-
-	log.Println("Created trigram indexes for faster text search")
-	return nil
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return applied, latest, nil
 }
 
-// removeTrigramIndexes removes trigram indexes
-func removeTrigramIndexes() error {
-	queries := []string{
-		`DROP INDEX IF EXISTS idx_ohio_addresses_street_trgm`,
-		`DROP INDEX IF EXISTS idx_ohio_addresses_city_trgm`,
-		`DROP INDEX IF EXISTS idx_ohio_addresses_house_number_trgm`,
+// withMigrationLock runs fn on a single connection holding the migration
+// advisory lock, after making sure schema_migrations is up to date
+func withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a database connection: %w", err)
 	}
+	defer conn.Close()
 
-	for _, query := range queries {
-		if _, err := DB.Exec(query); err != nil {
-			return fmt.Errorf("failed to drop trigram index: %w", err)
-		}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
 	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
 
-	return nil
-}
-
-func addRateLimitIndex() error {
-	log.Println("Creating composite index for rate limit queries...")
-	
-	// Composite index for the rate limit query: user_id + billable + created_at
-	_, err := DB.Exec(`
-		CREATE INDEX IF NOT EXISTS idx_usage_records_rate_limit 
-		ON usage_records(user_id, billable, created_at DESC);
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create rate limit index: %w", err)
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
-	
-	log.Println("Created composite index for rate limit queries")
-	return nil
+	return fn(conn)
 }
 
-func removeRateLimitIndex() error {
-	_, err := DB.Exec(`DROP INDEX IF EXISTS idx_usage_records_rate_limit;`)
+// createMigrationsTable creates schema_migrations, adding the checksum column
+// to tables created before checksums were recorded
+func createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		description TEXT NOT NULL
+	);
+	ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+	`)
 	return err
 }
 
-// createCitiesTable creates the cities table with trigram indexes for fuzzy search
-func createCitiesTable() error {
-	// Enable pg_trgm extension
-	if _, err := DB.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`); err != nil {
-		return fmt.Errorf("failed to enable pg_trgm extension: %w", err)
-	}
-
-	query := `
-	-- Create cities table for US city data
-	CREATE TABLE IF NOT EXISTS cities (
-		id BIGSERIAL PRIMARY KEY,
-		city VARCHAR(255) NOT NULL,
-		city_ascii VARCHAR(255) NOT NULL,
-		state_id VARCHAR(2) NOT NULL,
-		state_name VARCHAR(255) NOT NULL,
-		county_fips VARCHAR(10),
-		county_name VARCHAR(255),
-		lat DECIMAL(10, 7) NOT NULL,
-		lng DECIMAL(11, 7) NOT NULL,
-		population INTEGER,
-		density DECIMAL(10, 2),
-		source VARCHAR(50),
-		military BOOLEAN DEFAULT FALSE,
-		incorporated BOOLEAN DEFAULT FALSE,
-		timezone VARCHAR(100),
-		ranking INTEGER,
-		zips TEXT,
-		external_id VARCHAR(50),
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		CONSTRAINT unique_city_state UNIQUE (city_ascii, state_id)
-	);
-
-	-- Create indexes for efficient lookups
-	CREATE INDEX idx_cities_city ON cities (city);
-	CREATE INDEX idx_cities_city_ascii ON cities (city_ascii);
-	CREATE INDEX idx_cities_state_id ON cities (state_id);
-	CREATE INDEX idx_cities_state_name ON cities (state_name);
-	CREATE INDEX idx_cities_county_name ON cities (county_name);
-	CREATE INDEX idx_cities_ranking ON cities (ranking);
-
-	-- Create trigram indexes for fuzzy searching
-	CREATE INDEX idx_cities_city_trgm ON cities USING gin (city gin_trgm_ops);
-	CREATE INDEX idx_cities_city_ascii_trgm ON cities USING gin (city_ascii gin_trgm_ops);
-
-	-- Create spatial index for location-based queries
-	CREATE INDEX idx_cities_location ON cities USING btree (lat, lng);
-	`
-
-	_, err := DB.Exec(query)
+// appliedMigrations returns the schema_migrations rows keyed by version
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, description, applied_at, checksum FROM schema_migrations`)
 	if err != nil {
-		return fmt.Errorf("failed to create cities table: %w", err)
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
 	}
+	defer rows.Close()
 
-	log.Println("Cities table created successfully. Data will be loaded by service on startup.")
-	return nil
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.description, &row.appliedAt, &row.checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = row
+	}
+	return applied, rows.Err()
 }
 
-// dropCitiesTable drops the cities table
-func dropCitiesTable() error {
-	_, err := DB.Exec("DROP TABLE IF EXISTS cities")
-	return err
-}
+// verifyChecksums fails if an applied migration's up SQL has changed.
+// Versions applied before checksums were recorded adopt the current file's.
+func verifyChecksums(ctx context.Context, conn *sql.Conn, all []Migration, applied map[int]appliedMigration) error {
+	var modified []string
+	for _, m := range all {
+		row, ok := applied[m.Version]
+		if !ok {
+			continue
+		}
 
-// createStatesTable creates the us_states table with PostGIS geometry for state boundaries
-func createStatesTable() error {
-	// Enable PostGIS extension if not already enabled
-	if _, err := DB.Exec("CREATE EXTENSION IF NOT EXISTS postgis"); err != nil {
-		return fmt.Errorf("failed to enable PostGIS extension: %w", err)
+		if !row.checksum.Valid {
+			if _, err := conn.ExecContext(ctx, `UPDATE schema_migrations SET checksum = $1 WHERE version = $2`, m.Checksum, m.Version); err != nil {
+				return fmt.Errorf("failed to record checksum for migration %d: %w", m.Version, err)
+			}
+			continue
+		}
+		if row.checksum.String != m.Checksum {
+			modified = append(modified, strconv.Itoa(m.Version))
+		}
 	}
 
-	query := `
-	-- Create states table for US state boundary data
-	CREATE TABLE IF NOT EXISTS us_states (
-		id BIGSERIAL PRIMARY KEY,
-		state_fips VARCHAR(2) NOT NULL UNIQUE,
-		state_abbr VARCHAR(2) NOT NULL UNIQUE,
-		state_name VARCHAR(255) NOT NULL UNIQUE,
-		state_ns VARCHAR(50),
-		geoid VARCHAR(10),
-		region VARCHAR(10),
-		division VARCHAR(10),
-		lsad VARCHAR(10),
-		mtfcc VARCHAR(10),
-		funcstat VARCHAR(10),
-		area_land BIGINT,
-		area_water BIGINT,
-		internal_lat DECIMAL(10, 7),
-		internal_lng DECIMAL(11, 7),
-		geometry GEOMETRY(MULTIPOLYGON, 4326),
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Create indexes for efficient lookups
-	CREATE INDEX idx_states_fips ON us_states (state_fips);
-	CREATE INDEX idx_states_abbr ON us_states (state_abbr);
-	CREATE INDEX idx_states_name ON us_states (state_name);
-
-	-- Create spatial index for geometry queries
-	CREATE INDEX idx_states_geometry ON us_states USING GIST (geometry);
-	`
-
-	_, err := DB.Exec(query)
-	if err != nil {
-		return fmt.Errorf("failed to create states table: %w", err)
+	if len(modified) > 0 {
+		return fmt.Errorf("%w: version %s; add a new migration instead of editing one that has run",
+			ErrMigrationModified, strings.Join(modified, ", "))
 	}
-
-	log.Println("US states table created successfully. Data will be loaded by service on startup.")
 	return nil
 }
 
-// dropStatesTable drops the us_states table
-func dropStatesTable() error {
-	_, err := DB.Exec("DROP TABLE IF EXISTS us_states")
-	return err
-}
-
-// addFullAddressColumn adds full_address column to ohio_addresses table
-func addFullAddressColumn() error {
-	log.Println("Adding full_address column to ohio_addresses table...")
-	
-	query := `
-	-- Add full_address column
-	ALTER TABLE ohio_addresses ADD COLUMN IF NOT EXISTS full_address TEXT;
-
-	-- Populate full_address with formatted address
-	UPDATE ohio_addresses SET full_address = 
-	  CONCAT_WS(', ',
-		NULLIF(CONCAT_WS(' ', 
-		  NULLIF(house_number, ''), 
-		  NULLIF(street, ''),
-		  CASE WHEN unit != '' THEN 'Unit ' || unit ELSE NULL END
-		), ''),
-		NULLIF(city, ''),
-		CONCAT_WS(' ', NULLIF(region, ''), NULLIF(postcode, ''))
-	  );
-
-	-- Create GIN index for fast full-text search on the full address
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_full_address_trgm ON ohio_addresses USING gin (full_address gin_trgm_ops);
-
-	-- Create regular index for sorting/filtering
-	CREATE INDEX IF NOT EXISTS idx_ohio_addresses_full_address ON ohio_addresses(full_address);
-	`
-	
-	if _, err := DB.Exec(query); err != nil {
-		return fmt.Errorf("failed to add full_address column: %w", err)
+// applyMigration runs a migration's up SQL and records it in one transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Create trigger function
-	triggerFunc := `
-	CREATE OR REPLACE FUNCTION update_full_address()
-	RETURNS TRIGGER AS $$
-	BEGIN
-	  NEW.full_address := CONCAT_WS(', ',
-		NULLIF(CONCAT_WS(' ', 
-		  NULLIF(NEW.house_number, ''), 
-		  NULLIF(NEW.street, ''),
-		  CASE WHEN NEW.unit != '' THEN 'Unit ' || NEW.unit ELSE NULL END
-		), ''),
-		NULLIF(NEW.city, ''),
-		CONCAT_WS(' ', NULLIF(NEW.region, ''), NULLIF(NEW.postcode, ''))
-	  );
-	  RETURN NEW;
-	END;
-	$$ LANGUAGE plpgsql;
-	`
-	
-	if _, err := DB.Exec(triggerFunc); err != nil {
-		return fmt.Errorf("failed to create trigger function: %w", err)
+	if !isBlankSQL(m.Up) {
+		if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+			return err
+		}
 	}
-
-	// Create trigger
-	trigger := `
-	CREATE TRIGGER ohio_addresses_full_address_trigger
-	  BEFORE INSERT OR UPDATE ON ohio_addresses
-	  FOR EACH ROW
-	  EXECUTE FUNCTION update_full_address();
-	`
-	
-	if _, err := DB.Exec(trigger); err != nil {
-		return fmt.Errorf("failed to create trigger: %w", err)
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, description, checksum) VALUES ($1, $2, $3)`,
+		m.Version, m.Description, m.Checksum); err != nil {
+		return fmt.Errorf("failed to mark migration as applied: %w", err)
 	}
 
-	log.Println("Full address column, indexes, and trigger added successfully")
-	return nil
-}
-
-// removeFullAddressColumn removes full_address column from ohio_addresses table
-func removeFullAddressColumn() error {
-	query := `
-	-- Drop trigger and function
-	DROP TRIGGER IF EXISTS ohio_addresses_full_address_trigger ON ohio_addresses;
-	DROP FUNCTION IF EXISTS update_full_address();
-
-	-- Drop indexes
-	DROP INDEX IF EXISTS idx_ohio_addresses_full_address_trgm;
-	DROP INDEX IF EXISTS idx_ohio_addresses_full_address;
-
-	-- Drop column
-	ALTER TABLE ohio_addresses DROP COLUMN IF EXISTS full_address;
-	`
-	
-	_, err := DB.Exec(query)
-	return err
+	return tx.Commit()
 }
 
-// expandStreetAbbreviations adds function to expand street abbreviations and updates trigger
-func expandStreetAbbreviations() error {
-	migrationFile := "migrations/000016_expand_street_abbreviations.up.sql"
-	
-	// Read the migration file
-	content, err := os.ReadFile(migrationFile)
+// revertMigration runs a migration's down SQL and forgets it in one
+// transaction
+func revertMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
-	// Execute the migration
-	if _, err := DB.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
-	}
-	
-	log.Println("Street abbreviations expanded in full_address column")
-	return nil
-}
+	defer tx.Rollback()
 
-// revertStreetAbbreviations reverts the street abbreviation expansion
-func revertStreetAbbreviations() error {
-	migrationFile := "migrations/000016_expand_street_abbreviations.down.sql"
-	
-	// Read the migration file
-	content, err := os.ReadFile(migrationFile)
-	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
+	if !isBlankSQL(m.Down) {
+		if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+			return err
+		}
 	}
-	
-	// Execute the rollback
-	if _, err := DB.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute rollback: %w", err)
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+		return fmt.Errorf("failed to unmark migration: %w", err)
 	}
-	
-	log.Println("Street abbreviation expansion reverted")
-	return nil
-}
 
-// createDatasetsTable creates the datasets table
-func createDatasetsTable() error {
-	migrationFile := "migrations/000017_create_datasets_table.up.sql"
-	
-	// Read the migration file
-	content, err := os.ReadFile(migrationFile)
-	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
-	}
-	
-	// Execute the migration
-	if _, err := DB.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
-	}
-	
-	log.Println("Datasets table created successfully")
-	return nil
+	return tx.Commit()
 }
 
-// dropDatasetsTable drops the datasets table
-func dropDatasetsTable() error {
-	migrationFile := "migrations/000017_create_datasets_table.down.sql"
-	
-	// Read the migration file
-	content, err := os.ReadFile(migrationFile)
-	if err != nil {
-		return fmt.Errorf("failed to read migration file: %w", err)
-	}
-	
-	// Execute the rollback
-	if _, err := DB.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute rollback: %w", err)
+// isBlankSQL reports whether a migration file holds only comments
+func isBlankSQL(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
 	}
-	
-	log.Println("Datasets table dropped successfully")
-	return nil
+	return true
 }
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"geocoding-api/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrationsFromRepository(t *testing.T) {
+	all, err := LoadMigrations(migrations.Files)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	for i, m := range all {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		assert.Len(t, m.Checksum, 64)
+	}
+	assert.Equal(t, "Create zip codes table", all[0].Description)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_index.up.sql":      {Data: []byte("CREATE INDEX idx ON t(a);")},
+		"000002_add_index.down.sql":    {Data: []byte("DROP INDEX idx;")},
+		"000001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (a INT);")},
+		"000001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"embed.go":                     {Data: []byte("package migrations")},
+	}
+
+	all, err := LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	assert.Equal(t, 1, all[0].Version)
+	assert.Equal(t, "Create table", all[0].Description)
+	assert.Equal(t, "DROP TABLE t;", all[0].Down)
+	assert.Equal(t, 2, all[1].Version)
+
+	edited, err := LoadMigrations(fstest.MapFS{
+		"000001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (a BIGINT);")},
+		"000001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, all[0].Checksum, edited[0].Checksum, "editing the up SQL changes the checksum")
+}
+
+func TestLoadMigrationsRejectsIncompletePairs(t *testing.T) {
+	_, err := LoadMigrations(fstest.MapFS{
+		"000001_create_table.up.sql": {Data: []byte("CREATE TABLE t (a INT);")},
+	})
+	assert.ErrorContains(t, err, "migration 1 needs both an up and a down file")
+
+	_, err = LoadMigrations(fstest.MapFS{
+		"000001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (a INT);")},
+		"000001_create_other.down.sql": {Data: []byte("DROP TABLE t;")},
+	})
+	assert.ErrorContains(t, err, "different names")
+}
+
+func TestIsBlankSQL(t *testing.T) {
+	assert.True(t, isBlankSQL("-- nothing to undo\n\n"))
+	assert.False(t, isBlankSQL("-- drop it\nDROP TABLE t;"))
+}
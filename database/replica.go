@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"geocoding-api/config"
+
+	"github.com/lib/pq"
+)
+
+// Replica is the read replica named by DB_REPLICA_DSN, or nil. Searches,
+// lookups and stats read from it through ReadDB; everything else uses DB.
+var Replica *sql.DB
+
+// ReplicaCircuit guards connections to Replica. While it is open, reads go
+// to the primary.
+var ReplicaCircuit = NewCircuitBreaker(5, 30*time.Second)
+
+// primaryKey marks a context whose reads must see its own writes
+type primaryKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary, for reading
+// something just written, which the replica may not have replayed yet
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReadDB returns the connection for a read-only query: the replica when one
+// is configured and reachable, otherwise the primary. Rows read from it can
+// lag the primary by the replication delay.
+func ReadDB(ctx context.Context) *sql.DB {
+	if Replica == nil || ReplicaCircuit.Open() {
+		return DB
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return DB
+	}
+	return Replica
+}
+
+// initReplica connects to the read replica, if one is configured. Unlike the
+// primary it isn't waited for: an unreachable replica opens its circuit and
+// reads use the primary until the health monitor reaches it.
+func initReplica(db config.DatabaseConfig) error {
+	if db.ReplicaDSN == "" {
+		return nil
+	}
+	pqConnector, err := pq.NewConnector(db.ReplicaDSN)
+	if err != nil {
+		return fmt.Errorf("invalid DB_REPLICA_DSN: %w", err)
+	}
+
+	ReplicaCircuit = NewCircuitBreaker(db.BreakerThreshold, db.BreakerCooldown)
+	connector := &circuitConnector{Connector: pqConnector}
+	connector.breaker.Store(ReplicaCircuit)
+	Replica = sql.OpenDB(connector)
+	Replica.SetMaxOpenConns(db.MaxOpenConns)
+	Replica.SetMaxIdleConns(db.MaxIdleConns)
+	Replica.SetConnMaxLifetime(db.ConnMaxLifetime)
+	Replica.SetConnMaxIdleTime(db.ConnMaxIdleTime)
+
+	if err := Replica.Ping(); err != nil {
+		ReplicaCircuit.trip(err)
+		log.Printf("Read replica unreachable, reading from the primary: %v", err)
+		return nil
+	}
+	log.Println("Read replica connection established successfully")
+	return nil
+}
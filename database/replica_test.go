@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDB(t *testing.T) {
+	primary, replica := sql.OpenDB(&fakeConnector{}), sql.OpenDB(&fakeConnector{})
+	originalDB, originalReplica, originalCircuit := DB, Replica, ReplicaCircuit
+	t.Cleanup(func() { DB, Replica, ReplicaCircuit = originalDB, originalReplica, originalCircuit })
+	ctx := context.Background()
+
+	DB, Replica = primary, nil
+	assert.Same(t, primary, ReadDB(ctx), "no replica configured")
+
+	Replica, ReplicaCircuit = replica, NewCircuitBreaker(1, time.Minute)
+	assert.Same(t, replica, ReadDB(ctx))
+	assert.Same(t, primary, ReadDB(WithPrimary(ctx)), "reading its own writes")
+
+	ReplicaCircuit.Failure(errors.New("connection refused"))
+	assert.Same(t, primary, ReadDB(ctx), "replica unavailable")
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"log"
+
+	"geocoding-api/config"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the embedded database's tables
+//
+//go:embed sqlite_schema.sql
+var sqliteSchema string
+
+// embedded is set when DB is the embedded SQLite database
+var embedded bool
+
+// Embedded reports whether DB is the embedded SQLite database
+// (DB_DRIVER=sqlite). It has only the zip_codes and us_states tables, without
+// geometry, and none of PostGIS, pg_trgm or the account tables.
+func Embedded() bool {
+	return embedded
+}
+
+// initSQLite opens the embedded database at DB_SQLITE_PATH as DB
+func initSQLite(db config.DatabaseConfig) error {
+	conn, err := OpenSQLite(db.SQLitePath)
+	if err != nil {
+		return err
+	}
+	DB = conn
+	embedded = true
+	if db.SQLitePath == "" {
+		log.Println("Using in-memory embedded SQLite database")
+	} else {
+		log.Printf("Using embedded SQLite database: %s", db.SQLitePath)
+	}
+	return nil
+}
+
+// OpenSQLite opens an embedded database at path, or in memory for "", and
+// creates its tables
+func OpenSQLite(path string) (*sql.DB, error) {
+	if path == "" {
+		path = ":memory:"
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite takes one writer at a time, and an in-memory database lasts
+	// only as long as its connection, so keep a single one open for good
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite tables: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,46 @@
+-- Tables of the embedded SQLite database (DB_DRIVER=sqlite): the columns of
+-- zip_codes and us_states the lookups read, without the state geometry
+CREATE TABLE IF NOT EXISTS zip_codes (
+    zip_code TEXT PRIMARY KEY,
+    city_name TEXT NOT NULL,
+    state_code TEXT NOT NULL,
+    state_name TEXT NOT NULL,
+    zcta BOOLEAN NOT NULL DEFAULT FALSE,
+    zcta_parent TEXT,
+    population REAL,
+    density REAL,
+    primary_county_code TEXT NOT NULL,
+    primary_county_name TEXT NOT NULL,
+    county_weights BLOB,
+    county_names TEXT,
+    county_codes TEXT,
+    imprecise BOOLEAN NOT NULL DEFAULT FALSE,
+    military BOOLEAN NOT NULL DEFAULT FALSE,
+    timezone TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_zip_codes_state_code ON zip_codes(state_code);
+CREATE INDEX IF NOT EXISTS idx_zip_codes_city_name ON zip_codes(city_name);
+
+CREATE TABLE IF NOT EXISTS us_states (
+    id INTEGER PRIMARY KEY,
+    state_fips TEXT NOT NULL UNIQUE,
+    state_abbr TEXT NOT NULL UNIQUE,
+    state_name TEXT NOT NULL UNIQUE,
+    state_ns TEXT,
+    geoid TEXT,
+    region TEXT,
+    division TEXT,
+    lsad TEXT,
+    mtfcc TEXT,
+    funcstat TEXT,
+    area_land INTEGER,
+    area_water INTEGER,
+    internal_lat REAL,
+    internal_lng REAL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -16,6 +16,7 @@ export interface AdminUser {
   plan_type: string
   is_active: boolean
   is_admin: boolean
+  email_verified: boolean
   created_at: string
   monthly_usage?: number
   today_usage?: number
@@ -56,6 +57,29 @@ export interface AdminAPIKey {
   created_at: string
 }
 
+export interface UserDeletionResult {
+  user_id: number
+  api_keys_deactivated: number
+  usage_records_anonymized: number
+  organizations_transferred: number
+}
+
+export interface DataLoadProgress {
+  dataset: string
+  description: string
+  status: 'idle' | 'running' | 'completed' | 'failed'
+  dry_run: boolean
+  source?: string
+  processed: number
+  inserted: number
+  updated: number
+  skipped: number
+  invalid: number
+  error?: string
+  started_at?: string
+  finished_at?: string
+}
+
 export interface AdminAnalytics {
   total_calls: number
   billable_calls: number
@@ -80,19 +104,55 @@ export interface SystemStatus {
   migrations_current: boolean
 }
 
+export interface AuditLogEntry {
+  id: number
+  actor_user_id?: number
+  actor_email?: string
+  action: string
+  target_type?: string
+  target_id?: string
+  details?: Record<string, unknown>
+  ip_address?: string
+  created_at: string
+}
+
+export interface AuditLogFilter {
+  action?: string // exact action, or a group such as "api_key.*"
+  actor_id?: number
+  target_type?: string
+  target_id?: string
+  since?: string
+  until?: string
+  limit?: number
+  offset?: number
+}
+
 export const adminAPI = {
   getStats: async (): Promise<APIResponse<AdminStats>> => {
     return fetchAPI('/api/v1/admin/stats')
   },
 
-  getUsers: async (): Promise<APIResponse<AdminUser[]>> => {
-    return fetchAPI('/api/v1/admin/users')
+  getUsers: async (params?: { limit?: number; offset?: number }): Promise<APIResponse<AdminUser[]>> => {
+    const searchParams = new URLSearchParams()
+    if (params?.limit) searchParams.set('limit', params.limit.toString())
+    if (params?.offset) searchParams.set('offset', params.offset.toString())
+    const query = searchParams.toString()
+    return fetchAPI(`/api/v1/admin/users${query ? `?${query}` : ''}`)
   },
 
   getAPIKeys: async (): Promise<APIResponse<AdminAPIKey[]>> => {
     return fetchAPI('/api/v1/admin/api-keys')
   },
 
+  getAuditLog: async (filter: AuditLogFilter = {}): Promise<APIResponse<AuditLogEntry[]>> => {
+    const searchParams = new URLSearchParams()
+    Object.entries(filter).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') searchParams.set(key, value.toString())
+    })
+    const query = searchParams.toString()
+    return fetchAPI(`/api/v1/admin/audit-log${query ? `?${query}` : ''}`)
+  },
+
   getSystemStatus: async (): Promise<APIResponse<SystemStatus>> => {
     return fetchAPI('/api/v1/admin/system-status')
   },
@@ -117,12 +177,36 @@ export const adminAPI = {
     })
   },
 
-  loadData: async (): Promise<APIResponse<void>> => {
-    return fetchAPI('/api/v1/admin/load-data', {
+  deleteUser: async (userId: number): Promise<APIResponse<UserDeletionResult>> => {
+    return fetchAPI(`/api/v1/admin/users/${userId}`, {
+      method: 'DELETE',
+    })
+  },
+
+  revokeAPIKey: async (keyId: number, reason?: string): Promise<APIResponse<void>> => {
+    return fetchAPI(`/api/v1/admin/api-keys/${keyId}/revoke`, {
+      method: 'PUT',
+      body: JSON.stringify({ reason }),
+    })
+  },
+
+  getDataLoads: async (): Promise<APIResponse<DataLoadProgress[]>> => {
+    return fetchAPI('/api/v1/admin/load')
+  },
+
+  startDataLoad: async (
+    dataset: string,
+    dryRun: boolean = false
+  ): Promise<APIResponse<DataLoadProgress>> => {
+    return fetchAPI(`/api/v1/admin/load/${dataset}?dry_run=${dryRun}`, {
       method: 'POST',
     })
   },
 
+  getDataLoad: async (dataset: string): Promise<APIResponse<DataLoadProgress>> => {
+    return fetchAPI(`/api/v1/admin/load/${dataset}`)
+  },
+
   getUserMetrics: async (
     userId: number,
     days: number = 30
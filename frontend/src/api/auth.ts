@@ -2,8 +2,10 @@ import { fetchAPI } from '@/lib/api-client'
 import type {
   APIResponse,
   AuthResponse,
+  ForgotPasswordRequest,
   LoginRequest,
   RegisterRequest,
+  ResetPasswordRequest,
   User,
 } from '@/types/api'
 
@@ -22,12 +24,46 @@ export const authAPI = {
     })
   },
 
+  forgotPassword: async (data: ForgotPasswordRequest): Promise<APIResponse<void>> => {
+    return fetchAPI('/api/v1/auth/forgot-password', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    })
+  },
+
+  resetPassword: async (data: ResetPasswordRequest): Promise<APIResponse<void>> => {
+    return fetchAPI('/api/v1/auth/reset-password', {
+      method: 'POST',
+      body: JSON.stringify(data),
+    })
+  },
+
+  verifyEmail: async (token: string): Promise<APIResponse<void>> => {
+    return fetchAPI(`/api/v1/auth/verify?token=${encodeURIComponent(token)}`)
+  },
+
+  resendVerification: async (): Promise<APIResponse<void>> => {
+    return fetchAPI('/api/v1/user/resend-verification', { method: 'POST' })
+  },
+
   getProfile: async (): Promise<APIResponse<User>> => {
     return fetchAPI('/api/v1/user/profile')
   },
 
   logout: () => {
+    // Revoke the session server-side; local state is cleared regardless.
+    // The refresh token works even if the access token has already expired.
+    const refreshToken = localStorage.getItem('refreshToken')
+    if (refreshToken) {
+      fetchAPI('/api/v1/auth/logout', {
+        method: 'POST',
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      }).catch(() => {})
+    } else {
+      fetchAPI('/api/v1/user/logout', { method: 'POST' }).catch(() => {})
+    }
     localStorage.removeItem('authToken')
+    localStorage.removeItem('refreshToken')
     localStorage.removeItem('user')
   },
 }
@@ -10,11 +10,30 @@ export interface Dataset {
   file_path: string
   file_size: number
   record_count: number
-  status: 'pending' | 'processing' | 'completed' | 'failed'
+  status: 'pending' | 'validating' | 'processing' | 'completed' | 'failed'
   error_message?: string
   uploaded_by: number
   uploaded_at: string
   processed_at?: string
+  field_mapping?: Record<string, string>
+  validation_report?: DatasetValidationReport
+}
+
+export interface DatasetValidationReport {
+  passed: boolean
+  format?: string
+  feature_count: number
+  addressable_features: number
+  invalid_rows: number
+  no_geometry: number
+  out_of_bounds: number
+  invalid_percent: number
+  max_invalid_percent: number
+  state_bounds?: [number, number, number, number]
+  invalid_examples: { row: number; reason: string }[]
+  errors: string[]
+  warnings: string[]
+  validated_at: string
 }
 
 export interface DatasetStats {
@@ -37,6 +56,7 @@ export interface BatchUploadResult {
   success: boolean
   error?: string
   dataset?: Dataset
+  existing_dataset?: Dataset
 }
 
 export interface BulkUploadResponse {
@@ -47,6 +67,32 @@ export interface BulkUploadResponse {
   message: string
 }
 
+export interface DatasetValidation {
+  filename: string
+  file_size: number
+  compressed: boolean
+  format: 'FeatureCollection' | 'NDJSON' | 'CSV'
+  field_mapping?: Record<string, string>
+  bytes_inspected: number
+  truncated: boolean
+  features_inspected: number
+  point_features: number
+  addressable_features: number
+  estimated_record_count: number
+  properties: { name: string; count: number }[]
+  sample_addresses: {
+    house_number: string
+    street: string
+    unit: string
+    city: string
+    postcode: string
+    district: string
+    latitude: number
+    longitude: number
+  }[]
+  warnings: string[]
+}
+
 // SSE Event types for streaming upload (matches backend UploadProgressEvent)
 export type StreamEventType = 'start' | 'processing' | 'file_saved' | 'file_error' | 'processing_started' | 'complete'
 
@@ -101,6 +147,17 @@ export const datasetAPI = {
     })
   },
 
+  /**
+   * Inspect a file without importing it (only the first max_mb megabytes are read)
+   */
+  validate: async (formData: FormData): Promise<APIResponse<DatasetValidation>> => {
+    return fetchAPI('/api/v1/admin/datasets/validate', {
+      method: 'POST',
+      headers: {},
+      body: formData,
+    })
+  },
+
   /**
    * Upload files in batches to prevent memory exhaustion and timeouts
    * Uploads files one at a time with progress callbacks
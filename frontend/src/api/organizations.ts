@@ -0,0 +1,75 @@
+import { fetchAPI } from '@/lib/api-client'
+import type {
+  APIResponse,
+  Organization,
+  OrganizationDetails,
+  OrganizationInvitation,
+  OrganizationRole,
+  OrganizationUsage,
+} from '@/types/api'
+
+export const organizationsAPI = {
+  list: async (): Promise<APIResponse<Organization[]>> => {
+    return fetchAPI('/api/v1/user/organizations')
+  },
+
+  create: async (name: string): Promise<APIResponse<Organization>> => {
+    return fetchAPI('/api/v1/user/organizations', {
+      method: 'POST',
+      body: JSON.stringify({ name }),
+    })
+  },
+
+  get: async (orgId: number): Promise<APIResponse<OrganizationDetails>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}`)
+  },
+
+  usage: async (orgId: number): Promise<APIResponse<OrganizationUsage>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}/usage`)
+  },
+
+  invite: async (
+    orgId: number,
+    email: string,
+    role: OrganizationRole
+  ): Promise<APIResponse<OrganizationInvitation>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}/invitations`, {
+      method: 'POST',
+      body: JSON.stringify({ email, role }),
+    })
+  },
+
+  invitations: async (orgId: number): Promise<APIResponse<OrganizationInvitation[]>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}/invitations`)
+  },
+
+  revokeInvitation: async (orgId: number, invitationId: number): Promise<APIResponse<null>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}/invitations/${invitationId}`, {
+      method: 'DELETE',
+    })
+  },
+
+  updateMemberRole: async (
+    orgId: number,
+    userId: number,
+    role: OrganizationRole
+  ): Promise<APIResponse<null>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}/members/${userId}`, {
+      method: 'PUT',
+      body: JSON.stringify({ role }),
+    })
+  },
+
+  removeMember: async (orgId: number, userId: number): Promise<APIResponse<null>> => {
+    return fetchAPI(`/api/v1/user/organizations/${orgId}/members/${userId}`, {
+      method: 'DELETE',
+    })
+  },
+
+  acceptInvitation: async (token: string): Promise<APIResponse<Organization>> => {
+    return fetchAPI('/api/v1/user/invitations/accept', {
+      method: 'POST',
+      body: JSON.stringify({ token }),
+    })
+  },
+}
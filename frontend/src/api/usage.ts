@@ -17,6 +17,57 @@ export interface EndpointUsage {
   error_count: number
 }
 
+export type UsagePeriod = 'month' | 'week' | 'day'
+
+export interface UsagePeriodTotals {
+  start: string
+  end: string
+  total_calls: number
+  billable_calls: number
+}
+
+export interface EndpointUsageDelta {
+  endpoint: string
+  current_calls: number
+  previous_calls: number
+  delta: number
+  growth_percent: number | null
+}
+
+export interface UsageComparison {
+  period: UsagePeriod
+  user_id: number | null
+  current: UsagePeriodTotals
+  previous: UsagePeriodTotals
+  previous_full_period_calls: number
+  delta: number
+  growth_percent: number | null
+  billable_growth_percent: number | null
+  endpoints: EndpointUsageDelta[]
+}
+
+export type UsageGranularity = 'hour' | 'day' | 'week'
+
+export interface UsageTimeSeriesPoint {
+  start: string
+  total_calls: number
+  billable_calls: number
+  error_calls: number
+  error_rate: number
+  avg_response_time_ms: number
+  // Approximate, from a response time histogram
+  p50_response_time_ms: number
+  p95_response_time_ms: number
+  p99_response_time_ms: number
+}
+
+export interface UsageTimeSeries {
+  granularity: UsageGranularity
+  from: string
+  to: string
+  points: UsageTimeSeriesPoint[]
+}
+
 export const usageAPI = {
   getStats: async (): Promise<APIResponse<UsageStats>> => {
     return fetchAPI('/api/v1/user/usage')
@@ -29,4 +80,18 @@ export const usageAPI = {
   getEndpointUsage: async (days: number = 30): Promise<APIResponse<EndpointUsage[]>> => {
     return fetchAPI(`/api/v1/user/usage/endpoints?days=${days}`)
   },
+
+  compare: async (period: UsagePeriod = 'month'): Promise<APIResponse<UsageComparison>> => {
+    return fetchAPI(`/api/v1/user/usage/compare?period=${period}`)
+  },
+
+  getTimeSeries: async (
+    granularity: UsageGranularity = 'hour',
+    range: { from?: string; to?: string } = {}
+  ): Promise<APIResponse<UsageTimeSeries>> => {
+    const searchParams = new URLSearchParams({ granularity })
+    if (range.from) searchParams.set('from', range.from)
+    if (range.to) searchParams.set('to', range.to)
+    return fetchAPI(`/api/v1/user/usage/timeseries?${searchParams.toString()}`)
+  },
 }
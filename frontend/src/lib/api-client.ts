@@ -16,13 +16,53 @@ function handleAuthError(error: APIError) {
   if (error.status === 401) {
     // Token is expired or invalid, clear it and redirect to login
     localStorage.removeItem('authToken')
+    localStorage.removeItem('refreshToken')
     window.location.href = '/auth/signin'
   }
 }
 
+// Only one refresh runs at a time: refresh tokens rotate on use, so a second
+// concurrent refresh with the same token would end the session
+let refreshInFlight: Promise<boolean> | null = null
+
+// Exchange the stored refresh token for a new access token. Resolves false
+// when there is no refresh token or the server rejects it.
+export function refreshAccessToken(): Promise<boolean> {
+  if (refreshInFlight) {
+    return refreshInFlight
+  }
+
+  const refreshToken = localStorage.getItem('refreshToken')
+  if (!refreshToken) {
+    return Promise.resolve(false)
+  }
+
+  refreshInFlight = fetch(`${API_BASE_URL}/api/v1/auth/refresh`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ refresh_token: refreshToken }),
+  })
+    .then(async (response) => {
+      if (!response.ok) {
+        return false
+      }
+      const body = await response.json()
+      localStorage.setItem('authToken', body.data.token)
+      localStorage.setItem('refreshToken', body.data.refresh_token)
+      return true
+    })
+    .catch(() => false)
+    .finally(() => {
+      refreshInFlight = null
+    })
+
+  return refreshInFlight
+}
+
 export async function fetchAPI<T>(
   endpoint: string,
-  options: RequestInit = {}
+  options: RequestInit = {},
+  retried = false
 ): Promise<T> {
   const token = localStorage.getItem('authToken')
   
@@ -87,6 +127,13 @@ export async function fetchAPI<T>(
     data = {} as T
   }
 
+  // The access token has expired; refresh it once and replay the request
+  if (response.status === 401 && !retried && !endpoint.startsWith('/api/v1/auth/')) {
+    if (await refreshAccessToken()) {
+      return fetchAPI<T>(endpoint, options, true)
+    }
+  }
+
   if (!response.ok) {
     const apiError = new APIError(
       response.status,
@@ -31,6 +31,8 @@ import {
   TrendingUp,
   Clock,
   CheckCircle2,
+  Trash2,
+  Ban,
 } from 'lucide-react'
 import { ThemeToggle } from '@/components/theme-toggle'
 import { LineChart, Line, BarChart, Bar, PieChart, Pie, Cell, XAxis, YAxis, CartesianGrid, Tooltip, Legend, ResponsiveContainer } from 'recharts'
@@ -74,7 +76,7 @@ function AdminDashboard() {
       setLoading(true)
       const [statsResponse, usersResponse, keysResponse] = await Promise.all([
         adminAPI.getStats(),
-        adminAPI.getUsers(),
+        adminAPI.getUsers({ limit: 1000 }),
         adminAPI.getAPIKeys(),
       ])
 
@@ -125,18 +127,45 @@ function AdminDashboard() {
     }
   }
 
+  const handleDeleteUser = async (userId: number, email: string) => {
+    if (!confirm(`Delete ${email}? Their API keys will be deactivated and their usage history anonymized. This cannot be undone.`)) {
+      return
+    }
+
+    try {
+      await adminAPI.deleteUser(userId)
+      toast.success('User deleted successfully')
+      loadData()
+    } catch (err) {
+      toast.error('Failed to delete user')
+    }
+  }
+
+  const handleRevokeAPIKey = async (keyId: number) => {
+    const reason = prompt('Revoke this API key? Optionally enter a reason for the audit log.')
+    if (reason === null) {
+      return
+    }
+
+    try {
+      await adminAPI.revokeAPIKey(keyId, reason || undefined)
+      toast.success('API key revoked successfully')
+      loadData()
+    } catch (err) {
+      toast.error('Failed to revoke API key')
+    }
+  }
+
   const handleLoadData = async () => {
     if (!confirm('This will reload all ZIP code data. This may take several minutes. Continue?')) {
       return
     }
 
     try {
-      toast.info('Loading ZIP code data... This may take a few minutes.')
-      await adminAPI.loadData()
-      toast.success('ZIP code data loaded successfully')
-      loadData()
+      await adminAPI.startDataLoad('zip_codes')
+      toast.info('Loading ZIP code data in the background. This may take a few minutes.')
     } catch (err) {
-      toast.error('Failed to load ZIP code data')
+      toast.error('Failed to start ZIP code data load')
     }
   }
 
@@ -393,6 +422,14 @@ function AdminDashboard() {
                                   </>
                                 )}
                               </Button>
+                              <Button
+                                variant="destructive"
+                                size="sm"
+                                onClick={() => handleDeleteUser(u.id, u.email)}
+                              >
+                                <Trash2 className="mr-1 h-3 w-3" />
+                                Delete
+                              </Button>
                             </div>
                           </TableCell>
                         </TableRow>
@@ -421,6 +458,7 @@ function AdminDashboard() {
                         <TableHead>Name</TableHead>
                         <TableHead>Last Used</TableHead>
                         <TableHead>Status</TableHead>
+                        <TableHead>Actions</TableHead>
                       </TableRow>
                     </TableHeader>
                     <TableBody>
@@ -445,6 +483,18 @@ function AdminDashboard() {
                               <Badge variant="destructive">Inactive</Badge>
                             )}
                           </TableCell>
+                          <TableCell>
+                            {key.is_active && (
+                              <Button
+                                variant="outline"
+                                size="sm"
+                                onClick={() => handleRevokeAPIKey(key.id)}
+                              >
+                                <Ban className="mr-1 h-3 w-3" />
+                                Revoke
+                              </Button>
+                            )}
+                          </TableCell>
                         </TableRow>
                       ))}
                     </TableBody>
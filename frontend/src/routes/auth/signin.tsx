@@ -28,6 +28,7 @@ function SignIn() {
       
       if (response.success && response.data) {
         localStorage.setItem('authToken', response.data.token)
+        localStorage.setItem('refreshToken', response.data.refresh_token)
         localStorage.setItem('user', JSON.stringify(response.data.user))
         
         // TODO: Add admin route
@@ -90,7 +91,12 @@ function SignIn() {
               />
             </div>
             <div className="space-y-2">
-              <Label htmlFor="password">Password</Label>
+              <div className="flex items-center justify-between">
+                <Label htmlFor="password">Password</Label>
+                <Link to="/auth/forgot-password" className="text-sm text-primary hover:underline">
+                  Forgot password?
+                </Link>
+              </div>
               <Input
                 id="password"
                 type="password"
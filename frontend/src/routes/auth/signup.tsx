@@ -39,6 +39,7 @@ function SignUp() {
       
       if (response.success && response.data) {
         localStorage.setItem('authToken', response.data.token)
+        localStorage.setItem('refreshToken', response.data.refresh_token)
         localStorage.setItem('user', JSON.stringify(response.data.user))
         navigate({ to: '/dashboard' })
       } else {
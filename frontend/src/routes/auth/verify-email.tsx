@@ -0,0 +1,88 @@
+import { createFileRoute, Link } from '@tanstack/react-router'
+import { useEffect, useRef, useState } from 'react'
+import { authAPI } from '@/api/auth'
+import { Button } from '@/components/ui/button'
+import { Card, CardDescription, CardFooter, CardHeader, CardTitle } from '@/components/ui/card'
+import { ThemeToggle } from '@/components/theme-toggle'
+
+export const Route = createFileRoute('/auth/verify-email')({
+  validateSearch: (search: Record<string, unknown>): { token?: string } => ({
+    token: typeof search.token === 'string' ? search.token : undefined,
+  }),
+  component: VerifyEmail,
+})
+
+function VerifyEmail() {
+  const { token } = Route.useSearch()
+  const [status, setStatus] = useState<'verifying' | 'verified' | 'failed'>(token ? 'verifying' : 'failed')
+  const [error, setError] = useState(token ? '' : 'This verification link is incomplete.')
+  // Tokens are single-use, so the request must not be repeated when the
+  // effect runs twice in development
+  const requested = useRef(false)
+
+  useEffect(() => {
+    if (!token || requested.current) {
+      return
+    }
+    requested.current = true
+
+    authAPI
+      .verifyEmail(token)
+      .then(() => {
+        // Keep the cached profile in step so the dashboard banner goes away
+        const stored = localStorage.getItem('user')
+        if (stored) {
+          localStorage.setItem('user', JSON.stringify({ ...JSON.parse(stored), email_verified: true }))
+        }
+        setStatus('verified')
+      })
+      .catch((err) => {
+        setError(err instanceof Error ? err.message : 'Verification failed')
+        setStatus('failed')
+      })
+  }, [token])
+
+  return (
+    <div className="min-h-screen bg-background">
+      {/* Navigation */}
+      <nav className="bg-card shadow-sm border-b">
+        <div className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8">
+          <div className="flex justify-between h-16">
+            <div className="flex items-center">
+              <Link to="/" className="text-2xl font-bold bg-gradient-to-r from-blue-600 to-purple-600 bg-clip-text text-transparent">
+                🌍 GeoCode API
+              </Link>
+            </div>
+            <div className="flex items-center space-x-4">
+              <ThemeToggle />
+            </div>
+          </div>
+        </div>
+      </nav>
+      <div className="flex items-center justify-center py-12 px-4 sm:px-6 lg:px-8">
+      <Card className="w-full max-w-md">
+        <CardHeader className="space-y-1">
+          <CardTitle className="text-2xl font-bold">
+            {status === 'verifying' && 'Verifying your email...'}
+            {status === 'verified' && 'Email verified'}
+            {status === 'failed' && 'Verification failed'}
+          </CardTitle>
+          <CardDescription>
+            {status === 'verified' && 'Your email address is confirmed. You can now create API keys.'}
+            {status === 'failed' && `${error} Sign in and request a new link from your dashboard.`}
+          </CardDescription>
+        </CardHeader>
+        {status !== 'verifying' && (
+          <CardFooter>
+            <Button asChild className="w-full">
+              <Link to={localStorage.getItem('authToken') ? '/dashboard' : '/auth/signin'}>
+                {localStorage.getItem('authToken') ? 'Go to dashboard' : 'Sign in'}
+              </Link>
+            </Button>
+          </CardFooter>
+        )}
+      </Card>
+      </div>
+    </div>
+  )
+}
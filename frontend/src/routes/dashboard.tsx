@@ -1,9 +1,10 @@
 import { createFileRoute, redirect, useNavigate } from '@tanstack/react-router'
-import { useEffect, useState } from 'react'
+import { useEffect, useRef, useState } from 'react'
 import { toast } from 'sonner'
 import { apiKeysAPI } from '@/api/apiKeys'
 import { usageAPI } from '@/api/usage'
 import { authAPI } from '@/api/auth'
+import { organizationsAPI } from '@/api/organizations'
 import type { APIKey, UsageStats } from '@/types/api'
 import { Button } from '@/components/ui/button'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
@@ -22,6 +23,10 @@ import { Key, Trash2, Copy, Plus, LogOut } from 'lucide-react'
 import { ThemeToggle } from '@/components/theme-toggle'
 
 export const Route = createFileRoute('/dashboard')({
+  // Organization invitation emails link here with ?invitation=<token>
+  validateSearch: (search: Record<string, unknown>): { invitation?: string } => ({
+    invitation: typeof search.invitation === 'string' ? search.invitation : undefined,
+  }),
   beforeLoad: () => {
     const token = localStorage.getItem('authToken')
     if (!token) {
@@ -33,6 +38,7 @@ export const Route = createFileRoute('/dashboard')({
 
 function Dashboard() {
   const navigate = useNavigate()
+  const { invitation } = Route.useSearch()
   const [apiKeys, setApiKeys] = useState<APIKey[]>([])
   const [usage, setUsage] = useState<UsageStats | null>(null)
   const [loading, setLoading] = useState(true)
@@ -41,6 +47,8 @@ function Dashboard() {
   const [newKeyString, setNewKeyString] = useState('')
   const [keyName, setKeyName] = useState('')
   const [selectedPermissions, setSelectedPermissions] = useState<string[]>(['*'])
+  const [monthlyLimit, setMonthlyLimit] = useState('')
+  const [dailyLimit, setDailyLimit] = useState('')
   const [error, setError] = useState('')
 
   const user = JSON.parse(localStorage.getItem('user') || '{}')
@@ -49,6 +57,21 @@ function Dashboard() {
     loadData()
   }, [])
 
+  // Invitation tokens are single-use, so only try to accept once
+  const acceptedInvitation = useRef(false)
+  useEffect(() => {
+    if (!invitation || acceptedInvitation.current) {
+      return
+    }
+    acceptedInvitation.current = true
+
+    organizationsAPI
+      .acceptInvitation(invitation)
+      .then((response) => toast.success(response.message || 'Invitation accepted'))
+      .catch((err) => setError(err instanceof Error ? err.message : 'Failed to accept invitation'))
+      .finally(() => navigate({ to: '/dashboard', search: {}, replace: true }))
+  }, [invitation])
+
   const loadData = async () => {
     try {
       setLoading(true)
@@ -82,6 +105,8 @@ function Dashboard() {
       const response = await apiKeysAPI.create({
         name: keyName,
         permissions: selectedPermissions,
+        ...(monthlyLimit && { monthly_limit: Number(monthlyLimit) }),
+        ...(dailyLimit && { daily_limit: Number(dailyLimit) }),
       })
 
       if (response.success && response.data) {
@@ -90,6 +115,8 @@ function Dashboard() {
         setShowKeyModal(true)
         setKeyName('')
         setSelectedPermissions(['*'])
+        setMonthlyLimit('')
+        setDailyLimit('')
       }
     } catch (err) {
       setError(err instanceof Error ? err.message : 'Failed to create API key')
@@ -114,6 +141,15 @@ function Dashboard() {
     toast.success('API key copied to clipboard!')
   }
 
+  const handleResendVerification = async () => {
+    try {
+      const response = await authAPI.resendVerification()
+      toast.success(response.message || 'Verification email sent')
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to send verification email')
+    }
+  }
+
   const handleLogout = () => {
     authAPI.logout()
     navigate({ to: '/' })
@@ -175,6 +211,17 @@ function Dashboard() {
           </div>
         )}
 
+        {user.email_verified === false && (
+          <div className="mb-4 bg-primary/10 p-4 rounded-md flex items-center justify-between">
+            <span className="text-sm">
+              Verify your email address to create API keys. We sent a link to {user.email}.
+            </span>
+            <Button variant="outline" size="sm" onClick={handleResendVerification}>
+              Resend email
+            </Button>
+          </div>
+        )}
+
         {/* Stats */}
         <div className="grid grid-cols-1 md:grid-cols-3 gap-6 mb-8">
           <Card className="cursor-pointer hover:bg-accent" onClick={() => navigate({ to: '/usage' })}>
@@ -256,6 +303,8 @@ function Dashboard() {
                         {key.last_used_at && (
                           <> • Last used {new Date(key.last_used_at).toLocaleDateString()}</>
                         )}
+                        {key.monthly_limit != null && <> • {key.monthly_limit.toLocaleString()}/month cap</>}
+                        {key.daily_limit != null && <> • {key.daily_limit.toLocaleString()}/day cap</>}
                       </div>
                     </div>
                     <Button
@@ -311,6 +360,28 @@ function Dashboard() {
                 ))}
               </div>
             </div>
+            <div className="space-y-2">
+              <Label>Usage caps (optional)</Label>
+              <p className="text-xs text-muted-foreground">
+                Limit this key below your plan's limits so a leaked or test key can't use your whole quota
+              </p>
+              <div className="grid grid-cols-2 gap-2">
+                <Input
+                  type="number"
+                  min={1}
+                  value={monthlyLimit}
+                  onChange={(e) => setMonthlyLimit(e.target.value)}
+                  placeholder="Monthly limit"
+                />
+                <Input
+                  type="number"
+                  min={1}
+                  value={dailyLimit}
+                  onChange={(e) => setDailyLimit(e.target.value)}
+                  placeholder="Daily limit"
+                />
+              </div>
+            </div>
           </div>
           <DialogFooter>
             <Button variant="outline" onClick={() => setCreateModalOpen(false)}>
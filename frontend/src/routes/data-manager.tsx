@@ -431,6 +431,7 @@ function DataManager() {
         return <CheckCircle className="h-4 w-4 text-green-500" />
       case 'failed':
         return <XCircle className="h-4 w-4 text-red-500" />
+      case 'validating':
       case 'processing':
         return <Loader2 className="h-4 w-4 text-blue-500 animate-spin" />
       default:
@@ -441,6 +442,7 @@ function DataManager() {
   const getStatusBadge = (status: string) => {
     const variants: Record<string, "default" | "secondary" | "destructive" | "outline"> = {
       completed: 'default',
+      validating: 'secondary',
       processing: 'secondary',
       failed: 'destructive',
       pending: 'outline',
@@ -526,7 +528,7 @@ function DataManager() {
           <CardHeader>
             <CardTitle>Upload Datasets</CardTitle>
             <CardDescription>
-              Upload county address data in GeoJSON format (.geojson or .geojson.gz)
+              Upload county address data as GeoJSON (.geojson, .ndjson, or gzipped), CSV, a zipped shapefile, or a GeoPackage
             </CardDescription>
           </CardHeader>
           <CardContent>
@@ -576,6 +578,7 @@ function DataManager() {
                   <SelectContent>
                     <SelectItem value="all">All Status</SelectItem>
                     <SelectItem value="pending">Pending</SelectItem>
+                    <SelectItem value="validating">Validating</SelectItem>
                     <SelectItem value="processing">Processing</SelectItem>
                     <SelectItem value="completed">Completed</SelectItem>
                     <SelectItem value="failed">Failed</SelectItem>
@@ -717,11 +720,11 @@ function DataManager() {
             </div>
             
             <div>
-              <Label htmlFor="file">File (.geojson or .geojson.gz)</Label>
+              <Label htmlFor="file">File (.geojson, .geojson.gz, .csv, .zip shapefile or .gpkg)</Label>
               <Input
                 id="file"
                 type="file"
-                accept=".geojson,.json,.gz"
+                accept=".geojson,.json,.ndjson,.csv,.gz,.zip,.gpkg"
                 ref={fileInputRef}
                 onChange={handleFileChange}
               />
@@ -784,11 +787,11 @@ function DataManager() {
             </div>
             
             <div>
-              <Label htmlFor="bulk-files">Files (.geojson or .geojson.gz)</Label>
+              <Label htmlFor="bulk-files">Files (.geojson, .geojson.gz, .csv, .zip shapefile or .gpkg)</Label>
               <Input
                 id="bulk-files"
                 type="file"
-                accept=".geojson,.json,.gz"
+                accept=".geojson,.json,.ndjson,.csv,.gz,.zip,.gpkg"
                 multiple
                 ref={bulkFileInputRef}
                 onChange={handleBulkFileChange}
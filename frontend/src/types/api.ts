@@ -1,9 +1,44 @@
 // API Response types
+export interface Pagination {
+  total: number
+  limit: number
+  offset: number
+  next: string | null
+  prev: string | null
+}
+
+export type APIErrorCode =
+  | 'INVALID_REQUEST'
+  | 'VALIDATION_FAILED'
+  | 'INVALID_ZIP'
+  | 'INVALID_COORDINATES'
+  | 'UNAUTHORIZED'
+  | 'INVALID_API_KEY'
+  | 'INVALID_TOKEN'
+  | 'PERMISSION_DENIED'
+  | 'EMAIL_NOT_VERIFIED'
+  | 'NOT_FOUND'
+  | 'CONFLICT'
+  | 'PAYLOAD_TOO_LARGE'
+  | 'QUOTA_EXCEEDED'
+  | 'RATE_LIMITED'
+  | 'INTERNAL_ERROR'
+  | 'SERVICE_UNAVAILABLE'
+
+export interface FieldError {
+  field: string
+  rule: string
+  message: string
+}
+
 export interface APIResponse<T> {
   success: boolean
   data?: T
   error?: string
+  code?: APIErrorCode
+  details?: FieldError[]
   message?: string
+  pagination?: Pagination
 }
 
 // User types
@@ -14,6 +49,7 @@ export interface User {
   company?: string
   plan_type: string
   is_admin: boolean
+  email_verified: boolean
   created_at: string
 }
 
@@ -22,6 +58,15 @@ export interface LoginRequest {
   password: string
 }
 
+export interface ForgotPasswordRequest {
+  email: string
+}
+
+export interface ResetPasswordRequest {
+  token: string
+  password: string
+}
+
 export interface RegisterRequest {
   email: string
   password: string
@@ -31,7 +76,11 @@ export interface RegisterRequest {
 
 export interface AuthResponse {
   token: string
+  token_expires_at: string
+  refresh_token: string
+  refresh_token_expires_at: string
   user: User
+  message?: string
 }
 
 // API Key types
@@ -43,11 +92,19 @@ export interface APIKey {
   permissions: string[]
   created_at: string
   last_used_at?: string
+  monthly_limit?: number | null
+  daily_limit?: number | null
+  organization_id?: number | null
 }
 
 export interface CreateAPIKeyRequest {
   name: string
   permissions: string[]
+  // Optional caps below the plan limit
+  monthly_limit?: number
+  daily_limit?: number
+  // Bill the key to an organization instead of the personal account
+  organization_id?: number
 }
 
 export interface CreateAPIKeyResponse {
@@ -60,6 +117,55 @@ export interface APIKeysResponse {
   count: number
 }
 
+// Organization types
+export type OrganizationRole = 'owner' | 'member' | 'billing'
+
+export interface Organization {
+  id: number
+  name: string
+  plan_type: string
+  monthly_limit?: number | null
+  created_by?: number
+  role?: OrganizationRole
+  member_count: number
+  created_at: string
+  updated_at: string
+}
+
+export interface OrganizationMember {
+  user_id: number
+  email: string
+  name: string
+  role: OrganizationRole
+  joined_at: string
+}
+
+export interface OrganizationDetails {
+  organization: Organization
+  members: OrganizationMember[]
+}
+
+export interface OrganizationInvitation {
+  id: number
+  organization_id: number
+  email: string
+  role: OrganizationRole
+  invited_by?: number
+  expires_at: string
+  created_at: string
+  accepted_at?: string
+}
+
+export interface OrganizationUsage {
+  organization_id: number
+  plan_type: string
+  monthly_usage: number
+  monthly_limit: number // -1 means unlimited
+  daily_usage: number
+  daily_limit: number // -1 means unlimited
+  by_member: { user_id: number; email: string; requests: number }[]
+}
+
 // Usage types
 export interface UsageSummary {
   user_id: number
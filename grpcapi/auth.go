@@ -0,0 +1,179 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/grpcapi/geocodingpb"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodPermissions maps each RPC to the API key permission it requires, and
+// doubles as the usage endpoint name so gRPC calls show up next to REST ones
+var methodPermissions = map[string]string{
+	geocodingpb.Geocoding_Geocode_FullMethodName:         "geocode",
+	geocodingpb.Geocoding_ReverseGeocode_FullMethodName:  "addresses",
+	geocodingpb.Geocoding_SearchAddresses_FullMethodName: "addresses",
+	geocodingpb.Geocoding_Distance_FullMethodName:        "distance",
+}
+
+// caller is the authenticated API key behind an RPC
+type caller struct {
+	user     *models.User
+	apiKey   *models.APIKey
+	endpoint string
+	peerIP   string
+	agent    string
+	start    time.Time
+	// overage marks a call allowed past the monthly limit under overage billing
+	overage bool
+}
+
+// apiKeyFromMetadata reads the key from "x-api-key" or a Bearer "authorization" entry
+func apiKeyFromMetadata(md metadata.MD) string {
+	if values := md.Get("x-api-key"); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if parts := strings.SplitN(values[0], " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+// authenticate applies the same checks as the REST APIKeyAuth middleware:
+// key validity, plan and key rate limits, burst windows and permission.
+// Like the REST API it fails fast while the database circuit breaker is open.
+func authenticate(ctx context.Context, fullMethod string) (*caller, error) {
+	if database.Circuit.Open() {
+		return nil, status.Error(codes.Unavailable, "database unavailable, try again later")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := apiKeyFromMetadata(md)
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "API key required in x-api-key or authorization metadata")
+	}
+
+	cl := &caller{start: time.Now(), peerIP: peerIP(ctx)}
+	if agents := md.Get("user-agent"); len(agents) > 0 {
+		cl.agent = agents[0]
+	}
+
+	user, keyRecord, err := services.Auth.ValidateAPIKey(ctx, key)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	cl.user, cl.apiKey = user, keyRecord
+
+	permission, registered := methodPermissions[fullMethod]
+	if !registered {
+		return nil, status.Error(codes.PermissionDenied, "API key does not have permission for this method")
+	}
+	cl.endpoint = permission
+
+	withinLimit, _, _, err := services.Auth.CheckRateLimit(ctx, user.ID, keyRecord)
+	if err != nil {
+		slog.Error("failed to check rate limit", "user_id", user.ID, "error", err)
+		return nil, status.Error(codes.Internal, "failed to check rate limit")
+	}
+	if !withinLimit {
+		if window, allowed := services.Burst.Admit(ctx, user.ID); window != nil {
+			if !allowed {
+				return nil, status.Error(codes.ResourceExhausted, "burst window request rate exceeded")
+			}
+			withinLimit = true
+		}
+	}
+	if !withinLimit {
+		if cl.overage, err = services.Auth.AllowOverage(ctx, user.ID, keyRecord, 1); err != nil {
+			slog.Error("failed to check overage", "user_id", user.ID, "error", err)
+			return nil, status.Error(codes.Internal, "failed to check rate limit")
+		}
+	}
+	if !withinLimit && !cl.overage {
+		// Over-limit calls are recorded but not billed, as over REST
+		recordUsage(cl, codes.ResourceExhausted, false)
+		return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+
+	if !services.Auth.HasPermission(keyRecord, permission) {
+		return nil, status.Errorf(codes.PermissionDenied, "API key does not have the %q permission", permission)
+	}
+
+	return cl, nil
+}
+
+// httpStatusForCode maps a gRPC status to the HTTP status stored with usage
+// records, so error rates are comparable across transports
+func httpStatusForCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// recordUsage queues a usage record for an RPC
+func recordUsage(cl *caller, code codes.Code, billable bool) {
+	services.Usage.Record(services.UsageEvent{
+		UserID:         cl.user.ID,
+		APIKeyID:       cl.apiKey.ID,
+		Endpoint:       cl.endpoint,
+		Method:         "GRPC",
+		StatusCode:     httpStatusForCode(code),
+		ResponseTimeMs: int(time.Since(cl.start).Milliseconds()),
+		IPAddress:      cl.peerIP,
+		UserAgent:      cl.agent,
+		Billable:       billable,
+		Overage:        billable && cl.overage,
+	})
+}
+
+// unaryAuth authenticates unary RPCs and records their usage
+func unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	cl, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	recordUsage(cl, status.Code(err), true)
+	return resp, err
+}
+
+// streamAuth authenticates streaming RPCs and records one usage event per call
+func streamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	cl, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	err = handler(srv, ss)
+	recordUsage(cl, status.Code(err), true)
+	return err
+}
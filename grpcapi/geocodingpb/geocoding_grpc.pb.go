@@ -0,0 +1,248 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: geocoding.proto
+
+package geocodingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Geocoding_Geocode_FullMethodName         = "/geocoding.v1.Geocoding/Geocode"
+	Geocoding_ReverseGeocode_FullMethodName  = "/geocoding.v1.Geocoding/ReverseGeocode"
+	Geocoding_SearchAddresses_FullMethodName = "/geocoding.v1.Geocoding/SearchAddresses"
+	Geocoding_Distance_FullMethodName        = "/geocoding.v1.Geocoding/Distance"
+)
+
+// GeocodingClient is the client API for Geocoding service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GeocodingClient interface {
+	Geocode(ctx context.Context, in *GeocodeRequest, opts ...grpc.CallOption) (*ZipCode, error)
+	ReverseGeocode(ctx context.Context, in *ReverseGeocodeRequest, opts ...grpc.CallOption) (*ReverseGeocodeResponse, error)
+	SearchAddresses(ctx context.Context, in *SearchAddressesRequest, opts ...grpc.CallOption) (Geocoding_SearchAddressesClient, error)
+	Distance(ctx context.Context, in *DistanceRequest, opts ...grpc.CallOption) (*DistanceResponse, error)
+}
+
+type geocodingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGeocodingClient(cc grpc.ClientConnInterface) GeocodingClient {
+	return &geocodingClient{cc}
+}
+
+func (c *geocodingClient) Geocode(ctx context.Context, in *GeocodeRequest, opts ...grpc.CallOption) (*ZipCode, error) {
+	out := new(ZipCode)
+	err := c.cc.Invoke(ctx, Geocoding_Geocode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geocodingClient) ReverseGeocode(ctx context.Context, in *ReverseGeocodeRequest, opts ...grpc.CallOption) (*ReverseGeocodeResponse, error) {
+	out := new(ReverseGeocodeResponse)
+	err := c.cc.Invoke(ctx, Geocoding_ReverseGeocode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geocodingClient) SearchAddresses(ctx context.Context, in *SearchAddressesRequest, opts ...grpc.CallOption) (Geocoding_SearchAddressesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Geocoding_ServiceDesc.Streams[0], Geocoding_SearchAddresses_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &geocodingSearchAddressesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Geocoding_SearchAddressesClient interface {
+	Recv() (*Address, error)
+	grpc.ClientStream
+}
+
+type geocodingSearchAddressesClient struct {
+	grpc.ClientStream
+}
+
+func (x *geocodingSearchAddressesClient) Recv() (*Address, error) {
+	m := new(Address)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *geocodingClient) Distance(ctx context.Context, in *DistanceRequest, opts ...grpc.CallOption) (*DistanceResponse, error) {
+	out := new(DistanceResponse)
+	err := c.cc.Invoke(ctx, Geocoding_Distance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GeocodingServer is the server API for Geocoding service.
+// All implementations must embed UnimplementedGeocodingServer
+// for forward compatibility
+type GeocodingServer interface {
+	Geocode(context.Context, *GeocodeRequest) (*ZipCode, error)
+	ReverseGeocode(context.Context, *ReverseGeocodeRequest) (*ReverseGeocodeResponse, error)
+	SearchAddresses(*SearchAddressesRequest, Geocoding_SearchAddressesServer) error
+	Distance(context.Context, *DistanceRequest) (*DistanceResponse, error)
+	mustEmbedUnimplementedGeocodingServer()
+}
+
+// UnimplementedGeocodingServer must be embedded to have forward compatible implementations.
+type UnimplementedGeocodingServer struct {
+}
+
+func (UnimplementedGeocodingServer) Geocode(context.Context, *GeocodeRequest) (*ZipCode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Geocode not implemented")
+}
+func (UnimplementedGeocodingServer) ReverseGeocode(context.Context, *ReverseGeocodeRequest) (*ReverseGeocodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReverseGeocode not implemented")
+}
+func (UnimplementedGeocodingServer) SearchAddresses(*SearchAddressesRequest, Geocoding_SearchAddressesServer) error {
+	return status.Errorf(codes.Unimplemented, "method SearchAddresses not implemented")
+}
+func (UnimplementedGeocodingServer) Distance(context.Context, *DistanceRequest) (*DistanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Distance not implemented")
+}
+func (UnimplementedGeocodingServer) mustEmbedUnimplementedGeocodingServer() {}
+
+// UnsafeGeocodingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GeocodingServer will
+// result in compilation errors.
+type UnsafeGeocodingServer interface {
+	mustEmbedUnimplementedGeocodingServer()
+}
+
+func RegisterGeocodingServer(s grpc.ServiceRegistrar, srv GeocodingServer) {
+	s.RegisterService(&Geocoding_ServiceDesc, srv)
+}
+
+func _Geocoding_Geocode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GeocodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocodingServer).Geocode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoding_Geocode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocodingServer).Geocode(ctx, req.(*GeocodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geocoding_ReverseGeocode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseGeocodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocodingServer).ReverseGeocode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoding_ReverseGeocode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocodingServer).ReverseGeocode(ctx, req.(*ReverseGeocodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geocoding_SearchAddresses_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SearchAddressesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GeocodingServer).SearchAddresses(m, &geocodingSearchAddressesServer{stream})
+}
+
+type Geocoding_SearchAddressesServer interface {
+	Send(*Address) error
+	grpc.ServerStream
+}
+
+type geocodingSearchAddressesServer struct {
+	grpc.ServerStream
+}
+
+func (x *geocodingSearchAddressesServer) Send(m *Address) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Geocoding_Distance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DistanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocodingServer).Distance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoding_Distance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocodingServer).Distance(ctx, req.(*DistanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Geocoding_ServiceDesc is the grpc.ServiceDesc for Geocoding service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Geocoding_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "geocoding.v1.Geocoding",
+	HandlerType: (*GeocodingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Geocode",
+			Handler:    _Geocoding_Geocode_Handler,
+		},
+		{
+			MethodName: "ReverseGeocode",
+			Handler:    _Geocoding_ReverseGeocode_Handler,
+		},
+		{
+			MethodName: "Distance",
+			Handler:    _Geocoding_Distance_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SearchAddresses",
+			Handler:       _Geocoding_SearchAddresses_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "geocoding.proto",
+}
@@ -0,0 +1,178 @@
+// Package grpcapi serves the geocoding API over gRPC alongside the REST API,
+// using the same service layer, API keys and usage accounting. The protobuf
+// definitions live in proto/geocoding.proto.
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+
+	"geocoding-api/grpcapi/geocodingpb"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// geocodingServer implements geocodingpb.GeocodingServer
+type geocodingServer struct {
+	geocodingpb.UnimplementedGeocodingServer
+}
+
+// NewServer returns a gRPC server with the Geocoding service registered
+func NewServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryAuth),
+		grpc.ChainStreamInterceptor(streamAuth),
+	)
+	geocodingpb.RegisterGeocodingServer(server, &geocodingServer{})
+	return server
+}
+
+// peerIP returns the client's IP address without the port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// Geocode looks up a ZIP code
+func (s *geocodingServer) Geocode(ctx context.Context, req *geocodingpb.GeocodeRequest) (*geocodingpb.ZipCode, error) {
+	if len(req.ZipCode) < 5 || len(req.ZipCode) > 10 {
+		return nil, status.Error(codes.InvalidArgument, "invalid ZIP code format")
+	}
+
+	zip, err := services.GetZipCodeByZip(ctx, req.ZipCode)
+	if err != nil {
+		slog.Error("grpc geocode failed", "zip_code", req.ZipCode, "error", err)
+		return nil, status.Error(codes.Internal, "failed to retrieve ZIP code data")
+	}
+	if zip == nil {
+		return nil, status.Error(codes.NotFound, "ZIP code not found")
+	}
+
+	return &geocodingpb.ZipCode{
+		ZipCode:           zip.ZipCode,
+		CityName:          zip.CityName,
+		StateCode:         zip.StateCode,
+		StateName:         zip.StateName,
+		PrimaryCountyName: zip.PrimaryCountyName,
+		CountyNames:       zip.CountyNames,
+		Timezone:          zip.Timezone,
+		Latitude:          zip.Latitude,
+		Longitude:         zip.Longitude,
+	}, nil
+}
+
+// ReverseGeocode returns the addresses nearest a point
+func (s *geocodingServer) ReverseGeocode(ctx context.Context, req *geocodingpb.ReverseGeocodeRequest) (*geocodingpb.ReverseGeocodeResponse, error) {
+	if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+		return nil, status.Error(codes.InvalidArgument, "coordinates out of range")
+	}
+
+	// Same defaults and caps as GET /addresses/nearby
+	radius := 500.0
+	if req.RadiusMeters > 0 && req.RadiusMeters <= 50000 {
+		radius = req.RadiusMeters
+	}
+	limit := 10
+	if req.Limit > 0 && req.Limit <= 500 {
+		limit = int(req.Limit)
+	}
+
+	addresses, err := services.Address.FindNearbyAddresses(ctx, req.Latitude, req.Longitude, radius, limit)
+	if err != nil {
+		slog.Error("grpc reverse geocode failed", "error", err)
+		return nil, status.Error(codes.Internal, "failed to find nearby addresses")
+	}
+
+	resp := &geocodingpb.ReverseGeocodeResponse{
+		Addresses: make([]*geocodingpb.NearbyAddress, 0, len(addresses)),
+	}
+	for i := range addresses {
+		resp.Addresses = append(resp.Addresses, &geocodingpb.NearbyAddress{
+			Address:        addressMessage(&addresses[i].OhioAddress),
+			DistanceMeters: addresses[i].DistanceMeters,
+		})
+	}
+	return resp, nil
+}
+
+// SearchAddresses streams addresses matching the request's filters
+func (s *geocodingServer) SearchAddresses(req *geocodingpb.SearchAddressesRequest, stream geocodingpb.Geocoding_SearchAddressesServer) error {
+	params := models.AddressSearchParams{
+		Query:       req.Query,
+		HouseNumber: req.HouseNumber,
+		Street:      req.Street,
+		City:        req.City,
+		County:      req.County,
+		State:       req.State,
+		Postcode:    req.Postcode,
+		Limit:       int(req.Limit),
+		Offset:      int(req.Offset),
+	}
+
+	addresses, _, err := services.Address.SearchAddresses(stream.Context(), params)
+	if err != nil {
+		slog.Error("grpc address search failed", "error", err)
+		return status.Error(codes.Internal, "failed to search addresses")
+	}
+
+	for i := range addresses {
+		if err := stream.Send(addressMessage(&addresses[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Distance returns the distance between two ZIP codes
+func (s *geocodingServer) Distance(ctx context.Context, req *geocodingpb.DistanceRequest) (*geocodingpb.DistanceResponse, error) {
+	if req.FromZipCode == "" || req.ToZipCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "from_zip_code and to_zip_code are required")
+	}
+
+	result, err := services.CalculateDistanceBetweenZipCodes(ctx, req.FromZipCode, req.ToZipCode)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		slog.Error("grpc distance failed", "error", err)
+		return nil, status.Error(codes.Internal, "failed to calculate distance")
+	}
+
+	return &geocodingpb.DistanceResponse{
+		FromZipCode:   result.FromZipCode,
+		ToZipCode:     result.ToZipCode,
+		DistanceMiles: result.DistanceMiles,
+		DistanceKm:    result.DistanceKm,
+	}, nil
+}
+
+// addressMessage converts an address to its protobuf form
+func addressMessage(a *models.OhioAddress) *geocodingpb.Address {
+	return &geocodingpb.Address{
+		Id:          a.ID,
+		HouseNumber: a.HouseNumber,
+		Street:      a.Street,
+		Unit:        a.Unit,
+		City:        a.City,
+		County:      a.County,
+		State:       a.Region,
+		Postcode:    a.Postcode,
+		FullAddress: a.FullAddress,
+		Latitude:    a.Latitude,
+		Longitude:   a.Longitude,
+	}
+}
@@ -1,17 +1,178 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"geocoding-api/logging"
 	"geocoding-api/models"
 	"geocoding-api/services"
+	"geocoding-api/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
 // SearchOhioAddressesHandler handles address search requests
 func SearchOhioAddressesHandler(c echo.Context) error {
+	bound, ok := searchParamsFromRequest(c, parseAddressSearchParams, &models.AddressSearchParams{})
+	if !ok {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   "Invalid JSON request body",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	params := *bound
+	rawQuery := params.Query
+
+	if params.Sample < 0 || params.Sample > 1 {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   "Parameter 'sample' must be a fraction between 0 and 1 (e.g. 0.01)",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if !validBBox(params.BBox) {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   bboxError,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if params.Cluster != "" && params.Cluster != services.AddressClusterDistance {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   "Parameter 'cluster' must be 'distance'",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if params.ClusterRadius < 0 || params.ClusterRadius > services.MaxAddressClusterRadius {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Parameter 'cluster_radius' must be a distance in meters up to %d", services.MaxAddressClusterRadius),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	parsed := applyAddressQuery(&params)
+
+	// CSV export streams every matching row (up to maxCSVExportRows) instead of one page
+	if wantsCSV(c) {
+		return exportAddressesCSV(c, params)
+	}
+
+	offset := params.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	// Clusters of nearby results replace the rows, for map display
+	if params.Cluster != "" {
+		clusters, total, err := services.Address.ClusterAddresses(c.Request().Context(), params)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.AddressSearchResponse{
+				Success: false,
+				Error:   "Failed to cluster addresses: " + err.Error(),
+				Code:    models.ErrCodeInternal,
+			})
+		}
+		return c.JSON(http.StatusOK, models.AddressSearchResponse{
+			Success:    true,
+			Data:       []models.OhioAddress{},
+			Clusters:   clusters,
+			Count:      len(clusters),
+			Total:      total,
+			Pagination: paginate(c, total, services.AddressSearchLimit(params.Limit), offset),
+			Query:      rawQuery,
+			Filters:    addressSearchFilters(params, parsed),
+		})
+	}
+
+	// Search addresses
+	addresses, total, err := services.Address.SearchAddresses(c.Request().Context(), params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.AddressSearchResponse{
+			Success: false,
+			Error:   "Failed to search addresses: " + err.Error(),
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	// The API key owner's own addresses lead the first page; total and
+	// pagination count the public addresses only
+	custom := customAddresses(c, params)
+	if len(custom) > 0 {
+		addresses = append(custom, addresses...)
+	}
+
+	return c.JSON(http.StatusOK, models.AddressSearchResponse{
+		Success:     true,
+		Data:        addresses,
+		Count:       len(addresses),
+		CustomCount: len(custom),
+		Total:       total,
+		Pagination:  paginate(c, total, services.AddressSearchLimit(params.Limit), offset),
+		Query:       rawQuery,
+		Filters:     addressSearchFilters(params, parsed),
+	})
+}
+
+// addressSearchFilters echoes the filters an address search applied
+func addressSearchFilters(params models.AddressSearchParams, parsed *utils.ParsedAddress) map[string]any {
+	filters := make(map[string]any)
+	if params.County != "" {
+		filters["county"] = params.County
+	}
+	if params.City != "" {
+		filters["city"] = params.City
+	}
+	if params.Postcode != "" {
+		filters["postcode"] = params.Postcode
+	}
+	if params.Street != "" {
+		filters["street"] = params.Street
+	}
+	if params.HouseNumber != "" {
+		filters["house_number"] = params.HouseNumber
+	}
+	if params.Unit != "" {
+		filters["unit"] = params.Unit
+	}
+	if params.State != "" {
+		filters["state"] = params.State
+	}
+	if parsed != nil {
+		filters["parsed_as"] = parsed
+	}
+	if params.Lat != 0 && params.Lng != 0 {
+		filters["location"] = map[string]float64{
+			"lat": params.Lat,
+			"lng": params.Lng,
+		}
+		if params.Radius > 0 {
+			filters["radius_km"] = params.Radius
+		}
+	}
+	if params.BBox != nil {
+		filters["bbox"] = params.BBox
+	}
+	if params.Sample > 0 && params.Sample < 1 {
+		filters["sample"] = params.Sample
+	}
+	if params.Dedupe {
+		filters["dedupe"] = true
+	}
+	if params.Cluster != "" {
+		filters["cluster"] = params.Cluster
+		filters["cluster_radius_m"] = services.AddressClusterRadius(params.ClusterRadius)
+	}
+	return filters
+}
+
+// parseAddressSearchParams reads address search parameters from the query string
+func parseAddressSearchParams(c echo.Context) *models.AddressSearchParams {
 	var params models.AddressSearchParams
 	
 	// Manually parse query parameters (Echo's Bind doesn't always work for query params)
@@ -20,7 +181,10 @@ func SearchOhioAddressesHandler(c echo.Context) error {
 	params.City = c.QueryParam("city")
 	params.Postcode = c.QueryParam("postcode")
 	params.Street = c.QueryParam("street")
-	
+	params.HouseNumber = c.QueryParam("house_number")
+	params.Unit = c.QueryParam("unit")
+	params.State = c.QueryParam("state")
+
 	// Parse numeric parameters
 	if lat := c.QueryParam("lat"); lat != "" {
 		if val, err := strconv.ParseFloat(lat, 64); err == nil {
@@ -47,47 +211,173 @@ func SearchOhioAddressesHandler(c echo.Context) error {
 			params.Offset = val
 		}
 	}
+	if sample := c.QueryParam("sample"); sample != "" {
+		if val, err := strconv.ParseFloat(sample, 64); err == nil {
+			params.Sample = val
+		} else {
+			params.Sample = -1 // rejected by the handler
+		}
+	}
+	params.BBox = parseBBox(c)
+	params.Dedupe, _ = strconv.ParseBool(c.QueryParam("dedupe"))
+	params.Cluster = c.QueryParam("cluster")
+	if radius := c.QueryParam("cluster_radius"); radius != "" {
+		if val, err := strconv.ParseFloat(radius, 64); err == nil {
+			params.ClusterRadius = val
+		} else {
+			params.ClusterRadius = -1 // rejected by the handler
+		}
+	}
 
-	// Search addresses
-	addresses, total, err := services.Address.SearchAddresses(params)
+	return &params
+}
+
+// SearchAddressesWithinHandler handles POST /api/v1/addresses/within - page
+// through the address points inside a GeoJSON polygon, or only count them
+func SearchAddressesWithinHandler(c echo.Context) error {
+	var req models.AddressWithinRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	addresses, total, err := services.Address.AddressesWithin(c.Request().Context(), req.Geometry, req.Limit, req.Offset, req.CountOnly)
+	if errors.Is(err, services.ErrAddressPolygonInvalid) {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeValidationFailed,
+		})
+	}
 	if err != nil {
+		logging.FromContext(c).Error("failed to search addresses within polygon", "error", err)
 		return c.JSON(http.StatusInternalServerError, models.AddressSearchResponse{
 			Success: false,
-			Error:   "Failed to search addresses: " + err.Error(),
+			Error:   "Failed to search addresses within polygon",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	// Prepare filters for response
-	filters := make(map[string]any)
-	if params.County != "" {
-		filters["county"] = params.County
+	// pagination.total is reported even when it is 0, so count-only callers
+	// read it from there
+	return c.JSON(http.StatusOK, models.AddressSearchResponse{
+		Success:    true,
+		Data:       addresses,
+		Count:      len(addresses),
+		Total:      total,
+		Pagination: paginate(c, total, req.Limit, req.Offset),
+	})
+}
+
+// FindNearbyAddressesHandler returns the closest address points to a location
+func FindNearbyAddressesHandler(c echo.Context) error {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   "Valid 'lat' and 'lng' query parameters are required",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
 	}
-	if params.City != "" {
-		filters["city"] = params.City
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   "Coordinates out of range",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
 	}
-	if params.Postcode != "" {
-		filters["postcode"] = params.Postcode
+
+	// Radius in meters, default 500m, max 50km
+	radius := 500.0
+	if radiusStr := c.QueryParam("radius"); radiusStr != "" {
+		if val, err := strconv.ParseFloat(radiusStr, 64); err == nil && val > 0 && val <= 50000 {
+			radius = val
+		}
 	}
-	if params.Street != "" {
-		filters["street"] = params.Street
+
+	limit := 10
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 && val <= 500 {
+			limit = val
+		}
 	}
-	if params.Lat != 0 && params.Lng != 0 {
-		filters["location"] = map[string]float64{
-			"lat": params.Lat,
-			"lng": params.Lng,
+
+	census, ok := includesCensus(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   includeCensusError,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	addresses, err := services.Address.FindNearbyAddresses(c.Request().Context(), lat, lng, radius, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to find nearby addresses: " + err.Error(),
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	addresses, customCount := mergeNearbyCustomAddresses(c, addresses, lat, lng, radius, limit)
+
+	response := map[string]interface{}{
+		"success": true,
+		"data":    addresses,
+		"count":   len(addresses),
+		"filters": map[string]interface{}{
+			"location": map[string]float64{
+				"lat": lat,
+				"lng": lng,
+			},
+			"radius_meters": radius,
+		},
+	}
+
+	if customCount > 0 {
+		response["custom_count"] = customCount
+	}
+
+	if census {
+		// The search location's geography, then each address's
+		location, err := services.Census.GetCensusGeography(c.Request().Context(), lat, lng)
+		if err != nil {
+			return censusErrorResponse(c, err)
 		}
-		if params.Radius > 0 {
-			filters["radius_km"] = params.Radius
+		response["census"] = location
+
+		// Custom address IDs are not address point IDs, so those are skipped
+		ids := make([]int64, 0, len(addresses))
+		for i := range addresses {
+			if !isCustomAddress(addresses[i].OhioAddress) {
+				ids = append(ids, addresses[i].ID)
+			}
+		}
+		geographies, err := services.Census.GetAddressCensusGeography(c.Request().Context(), ids)
+		if err != nil {
+			return censusErrorResponse(c, err)
+		}
+		for i := range addresses {
+			if !isCustomAddress(addresses[i].OhioAddress) {
+				addresses[i].Census = geographies[addresses[i].ID]
+			}
 		}
 	}
 
-	return c.JSON(http.StatusOK, models.AddressSearchResponse{
-		Success: true,
-		Data:    addresses,
-		Count:   len(addresses),
-		Total:   total,
-		Query:   params.Query,
-		Filters: filters,
+	return c.JSON(http.StatusOK, response)
+}
+
+// censusErrorResponse reports a failed ?include=census lookup
+func censusErrorResponse(c echo.Context, err error) error {
+	logging.FromContext(c).Error("census geography lookup failed", "error", err)
+	return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+		Success: false,
+		Error:   "Failed to retrieve census geography",
+		Code:    models.ErrCodeInternal,
 	})
 }
 
@@ -99,23 +389,43 @@ func GetOhioAddressHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
 			Success: false,
 			Error:   "Invalid address ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	address, err := services.Address.GetAddressByID(id)
+	census, ok := includesCensus(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, models.AddressSearchResponse{
+			Success: false,
+			Error:   includeCensusError,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	address, err := services.Address.GetAddressByID(c.Request().Context(), id)
 	if err != nil {
 		if err.Error() == "address not found" {
 			return c.JSON(http.StatusNotFound, models.AddressSearchResponse{
 				Success: false,
 				Error:   "Address not found",
+				Code:    models.ErrCodeNotFound,
 			})
 		}
 		return c.JSON(http.StatusInternalServerError, models.AddressSearchResponse{
 			Success: false,
 			Error:   "Failed to get address: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	if census {
+		geographies, err := services.Census.GetAddressCensusGeography(c.Request().Context(), []int64{address.ID})
+		if err != nil {
+			return censusErrorResponse(c, err)
+		}
+		address.Census = geographies[address.ID]
+	}
+
 	return c.JSON(http.StatusOK, models.AddressSearchResponse{
 		Success: true,
 		Data:    []models.OhioAddress{*address},
@@ -125,17 +435,18 @@ func GetOhioAddressHandler(c echo.Context) error {
 
 // GetOhioCountyStatsHandler returns statistics about Ohio counties
 func GetOhioCountyStatsHandler(c echo.Context) error {
-	stats, err := services.Address.GetCountyStats()
+	stats, err := services.Address.GetCountyStats(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to get county statistics: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get county statistics: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    stats,
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    stats,
 	})
 }
 
@@ -143,9 +454,10 @@ func GetOhioCountyStatsHandler(c echo.Context) error {
 func FullTextSearchAddressesHandler(c echo.Context) error {
 	query := c.QueryParam("q")
 	if query == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Query parameter 'q' is required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Query parameter 'q' is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -158,14 +470,21 @@ func FullTextSearchAddressesHandler(c echo.Context) error {
 	}
 
 	// Perform full-text search
-	result, err := services.Address.FullTextSearchAddresses(query, limit)
+	result, err := services.Address.FullTextSearchAddresses(c.Request().Context(), query, limit)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to search addresses: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to search addresses: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	// The API key owner's own addresses lead the results
+	custom := customAddresses(c, models.AddressSearchParams{Query: query, Limit: limit})
+	if len(custom) > 0 {
+		result.Addresses = append(custom, result.Addresses...)
+	}
+
 	response := map[string]interface{}{
 		"success":       true,
 		"data":          result.Addresses,
@@ -175,10 +494,34 @@ func FullTextSearchAddressesHandler(c echo.Context) error {
 		"search_method": result.SearchMethod,
 	}
 
+	if len(custom) > 0 {
+		response["custom_count"] = len(custom)
+	}
 	if result.ParsedQuery != nil {
 		response["parsed_as"] = result.ParsedQuery
 	}
 
+	// County-level match (query only named a county)
+	if result.CountyMatch != nil {
+		response["county"] = result.CountyMatch
+		response["match_type"] = result.CountyMatch.MatchType
+		response["message"] = "No address found; returning the centroid of " + result.CountyMatch.CountyName + " County."
+	}
+
+	// Interpolated match (no address point; estimated from an address range)
+	if len(result.Interpolated) > 0 {
+		response["interpolated"] = result.Interpolated
+		response["match_level"] = "interpolated"
+		response["message"] = "No exact address found; position interpolated from the street's address range."
+	}
+
+	// Street-level match (no rooftop address found)
+	if len(result.Streets) > 0 {
+		response["streets"] = result.Streets
+		response["match_level"] = "street"
+		response["message"] = fmt.Sprintf("No exact address found; matched %d street(s).", len(result.Streets))
+	}
+
 	// Add fallback information if street-level matches were included
 	if result.FallbackCount > 0 {
 		response["fallback_count"] = result.FallbackCount
@@ -189,3 +532,157 @@ func FullTextSearchAddressesHandler(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+// ValidateAddressHandler handles POST /api/v1/addresses/validate - check a
+// submitted address against the loaded address points and return a verdict
+// (verified, partial_street, zip_only or unknown) with the matched record
+func ValidateAddressHandler(c echo.Context) error {
+	var req models.AddressValidationRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// Submitted components override the parse of the free-form address
+	parsed := utils.ParseAddressQuery(utils.StripUnitDesignator(strings.TrimSpace(req.Address)))
+	parsed.Raw = req.Address
+	for _, component := range []struct {
+		dest  *string
+		value string
+	}{
+		{&parsed.HouseNumber, req.HouseNumber},
+		{&parsed.Street, req.Street},
+		{&parsed.City, req.City},
+		{&parsed.State, req.State},
+		{&parsed.Zip, req.Postcode},
+	} {
+		if value := strings.TrimSpace(component.value); value != "" {
+			*component.dest = value
+		}
+	}
+
+	if parsed.Street == "" && parsed.Zip == "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Provide an address, or at least a street or postcode",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	result, err := services.Address.ValidateAddress(c.Request().Context(), parsed)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to validate address",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: struct {
+			*models.AddressValidationResult
+			ParsedAs *utils.ParsedAddress `json:"parsed_as"`
+		}{result, parsed},
+	})
+}
+
+// maxCSVExportRows caps the number of rows a single CSV export may return
+const maxCSVExportRows = 100000
+
+// exportAddressesCSV streams address search results as CSV
+func exportAddressesCSV(c echo.Context, params models.AddressSearchParams) error {
+	maxRows := maxCSVExportRows
+	if params.Limit > 0 && params.Limit < maxRows {
+		maxRows = params.Limit
+	}
+
+	stream, err := newCSVStream(c, "addresses.csv", []string{
+		"id", "house_number", "street", "unit", "city", "county", "region", "postcode",
+		"full_address", "latitude", "longitude",
+	})
+	if err != nil {
+		return err
+	}
+
+	err = services.Address.StreamAddresses(c.Request().Context(), params, maxRows, func(addr *models.OhioAddress) error {
+		return stream.Write([]string{
+			strconv.FormatInt(addr.ID, 10), addr.HouseNumber, addr.Street, addr.Unit, addr.City,
+			addr.County, addr.Region, addr.Postcode, addr.FullAddress,
+			strconv.FormatFloat(addr.Latitude, 'f', 6, 64), strconv.FormatFloat(addr.Longitude, 'f', 6, 64),
+		})
+	})
+	if err != nil {
+		// Headers are already sent; log and end the stream
+		c.Logger().Errorf("CSV address export failed: %v", err)
+	}
+	return stream.Flush()
+}
+
+// ParseAddressHandler handles GET /api/v1/parse - split a free-form address into
+// components with per-field confidence, without querying the database
+func ParseAddressHandler(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Query parameter 'q' is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	stripped := utils.StripUnitDesignator(query)
+	parsed := utils.ParseAddressQuery(stripped)
+	parsed.Raw = query
+
+	rules := parsed.Rules()
+	if stripped != query {
+		rules = append([]string{"unit.stripped"}, rules...)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"components": parsed,
+			"confidence": parsed.Confidence(),
+			"rules":      rules,
+		},
+	})
+}
+
+// applyAddressQuery decomposes a formatted address in params.Query ("2525
+// Oakley Ave Apt 3, Cincinnati OH 45209") into components so each part is
+// matched against its own column, and clears the query. Explicit query params
+// always take precedence over parsed values. It returns the parsed address,
+// or nil when the query doesn't look like one and is left to match as text.
+func applyAddressQuery(params *models.AddressSearchParams) *utils.ParsedAddress {
+	if params.Query == "" {
+		return nil
+	}
+	base, unit := utils.ExtractUnitDesignator(params.Query)
+	parsed := utils.ParseAddressQuery(base)
+	if parsed.HouseNumber == "" && parsed.Zip == "" && parsed.State == "" {
+		return nil
+	}
+	parsed.Unit = unit
+
+	if params.HouseNumber == "" {
+		params.HouseNumber = parsed.HouseNumber
+	}
+	if params.Street == "" {
+		params.Street = parsed.Street
+	}
+	if params.Unit == "" {
+		params.Unit = parsed.Unit
+	}
+	if params.City == "" {
+		params.City = parsed.City
+	}
+	if params.State == "" {
+		params.State = parsed.State
+	}
+	if params.Postcode == "" {
+		params.Postcode = parsed.Zip
+	}
+	params.Query = ""
+	return parsed
+}
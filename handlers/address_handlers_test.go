@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"geocoding-api/database"
@@ -197,6 +198,64 @@ func TestSearchWithProximity(t *testing.T) {
 	}
 }
 
+func TestSearchOhioAddressesClusterParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "unknown cluster mode", query: "cluster=grid"},
+		{name: "cluster radius over the cap", query: "cluster=distance&cluster_radius=10000"},
+		{name: "cluster radius not a number", query: "cluster=distance&cluster_radius=far"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/addresses?city=Cincinnati&"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			err := SearchOhioAddressesHandler(e.NewContext(req, rec))
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var response models.AddressSearchResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, models.ErrCodeInvalidRequest, response.Code)
+		})
+	}
+}
+
+func TestSearchAddressesWithinRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "malformed JSON", body: `{"geometry":`, code: models.ErrCodeInvalidRequest},
+		{name: "missing geometry", body: `{"limit": 10}`, code: models.ErrCodeValidationFailed},
+		{name: "limit over the cap", body: `{"geometry": {"type": "Polygon", "coordinates": []}, "limit": 5000}`, code: models.ErrCodeValidationFailed},
+		{name: "not a polygon", body: `{"geometry": {"type": "Point", "coordinates": [-84.4, 39.1]}}`, code: models.ErrCodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/addresses/within", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			err := SearchAddressesWithinHandler(e.NewContext(req, rec))
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var response models.AddressSearchResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Code)
+		})
+	}
+}
+
 func TestGetOhioAddressById(t *testing.T) {
 	setupTestEnvironment(t)
 
@@ -286,3 +345,90 @@ func TestInvalidAddressId(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAddress(t *testing.T) {
+	setupTestEnvironment(t)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		verdicts       []string
+	}{
+		{
+			name:           "Free-form address",
+			body:           `{"address": "2525 Oakley Dr, Cincinnati OH"}`,
+			expectedStatus: http.StatusOK,
+			verdicts:       []string{models.AddressVerified, models.AddressStreetMatch},
+		},
+		{
+			name:           "Components with misspelled street",
+			body:           `{"house_number": "2525", "street": "Oakly Drive", "city": "Cincinnati"}`,
+			expectedStatus: http.StatusOK,
+			verdicts:       []string{models.AddressVerified, models.AddressStreetMatch},
+		},
+		{
+			name:           "ZIP code only",
+			body:           `{"postcode": "45209"}`,
+			expectedStatus: http.StatusOK,
+			verdicts:       []string{models.AddressZipOnly, models.AddressUnknown},
+		},
+		{
+			name:           "Nothing to validate",
+			body:           `{"city": "Cincinnati"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/addresses/validate", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := ValidateAddressHandler(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Success bool                           `json:"success"`
+				Data    models.AddressValidationResult `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.True(t, response.Success)
+			assert.Contains(t, tt.verdicts, response.Data.Verdict)
+			assert.Equal(t, response.Data.Verdict == models.AddressVerified, response.Data.Deliverable)
+		})
+	}
+}
+
+func TestApplyAddressQuery(t *testing.T) {
+	params := models.AddressSearchParams{Query: "2525 Oakley Ave Apt 3, Cincinnati OH"}
+	parsed := applyAddressQuery(&params)
+	if assert.NotNil(t, parsed) {
+		assert.Equal(t, "3", parsed.Unit)
+	}
+	assert.Equal(t, "2525", params.HouseNumber)
+	assert.Equal(t, "3", params.Unit, "the unit fills the unit filter")
+	assert.Equal(t, "Cincinnati", params.City)
+	assert.Equal(t, "OH", params.State)
+	assert.Empty(t, params.Query)
+
+	// Explicit params take precedence over parsed values
+	params = models.AddressSearchParams{Query: "2525 Oakley Ave #F, Cincinnati OH 45209", Unit: "G"}
+	applyAddressQuery(&params)
+	assert.Equal(t, "G", params.Unit)
+	assert.Equal(t, "45209", params.Postcode)
+
+	// Anything else is left to the text search
+	params = models.AddressSearchParams{Query: "oakley cincinnati"}
+	assert.Nil(t, applyAddressQuery(&params))
+	assert.Equal(t, "oakley cincinnati", params.Query)
+	assert.Empty(t, params.Unit)
+}
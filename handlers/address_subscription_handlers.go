@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// addressSubscriptionErrorResponse maps address subscription service errors to responses
+func addressSubscriptionErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	code := models.ErrCodeInternal
+	switch {
+	case errors.Is(err, services.ErrAddressSubscriptionNotFound):
+		status, code = http.StatusNotFound, models.ErrCodeNotFound
+	case errors.Is(err, services.ErrAddressSubscriptionInvalid):
+		status, code = http.StatusBadRequest, models.ErrCodeValidationFailed
+	case errors.Is(err, services.ErrAddressSubscriptionLimit):
+		status, code = http.StatusConflict, models.ErrCodeConflict
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(c).Error("address subscription request failed", "error", err)
+		message = "Address subscription request failed"
+	}
+	return c.JSON(status, GeocodeResponse{
+		Success: false,
+		Error:   message,
+		Code:    code,
+	})
+}
+
+// addressSubscriptionParams extracts the authenticated user and the :id path
+// parameter. ok is false when an error response has been written; the caller
+// returns err.
+func addressSubscriptionParams(c echo.Context) (userID, id int, ok bool, err error) {
+	userID, ok = c.Get("user_id").(int)
+	if !ok {
+		return 0, 0, false, c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	id, convErr := strconv.Atoi(c.Param("id"))
+	if convErr != nil {
+		return 0, 0, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid subscription ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	return userID, id, true, nil
+}
+
+// CreateAddressSubscriptionHandler handles POST /api/v1/user/address-subscriptions -
+// watch a ZIP code, county or polygon for addresses added or removed by
+// dataset imports
+func CreateAddressSubscriptionHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.AddressSubscriptionRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	sub, err := services.AddressSubscriptions.CreateSubscription(c.Request().Context(), userID, req)
+	if err != nil {
+		return addressSubscriptionErrorResponse(c, err)
+	}
+
+	c.Response().Header().Set("Location", APIPath(c, fmt.Sprintf("/user/address-subscriptions/%d", sub.ID)))
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    sub,
+		Message: "Address subscription created",
+	})
+}
+
+// GetAddressSubscriptionsHandler handles GET /api/v1/user/address-subscriptions
+func GetAddressSubscriptionsHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	subs, err := services.AddressSubscriptions.GetUserSubscriptions(c.Request().Context(), userID)
+	if err != nil {
+		return addressSubscriptionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    subs,
+		Count:   len(subs),
+	})
+}
+
+// GetAddressSubscriptionHandler handles GET /api/v1/user/address-subscriptions/:id
+func GetAddressSubscriptionHandler(c echo.Context) error {
+	userID, id, ok, err := addressSubscriptionParams(c)
+	if !ok {
+		return err
+	}
+
+	sub, err := services.AddressSubscriptions.GetSubscription(c.Request().Context(), userID, id)
+	if err != nil {
+		return addressSubscriptionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    sub,
+	})
+}
+
+// UpdateAddressSubscriptionHandler handles PUT /api/v1/user/address-subscriptions/:id -
+// replace a subscription's name, target and notification settings
+func UpdateAddressSubscriptionHandler(c echo.Context) error {
+	userID, id, ok, err := addressSubscriptionParams(c)
+	if !ok {
+		return err
+	}
+
+	var req models.AddressSubscriptionRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	sub, err := services.AddressSubscriptions.UpdateSubscription(c.Request().Context(), userID, id, req)
+	if err != nil {
+		return addressSubscriptionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    sub,
+		Message: "Address subscription updated",
+	})
+}
+
+// DeleteAddressSubscriptionHandler handles DELETE /api/v1/user/address-subscriptions/:id
+func DeleteAddressSubscriptionHandler(c echo.Context) error {
+	userID, id, ok, err := addressSubscriptionParams(c)
+	if !ok {
+		return err
+	}
+
+	if err := services.AddressSubscriptions.DeleteSubscription(c.Request().Context(), userID, id); err != nil {
+		return addressSubscriptionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Address subscription deleted",
+	})
+}
+
+// GetAddressSubscriptionChangesHandler handles
+// GET /api/v1/user/address-subscriptions/:id/changes - page through the
+// addresses imports added or removed inside the subscription, newest first,
+// optionally for one import (dataset_id) or change type (change=added|removed)
+func GetAddressSubscriptionChangesHandler(c echo.Context) error {
+	userID, id, ok, err := addressSubscriptionParams(c)
+	if !ok {
+		return err
+	}
+
+	datasetID := 0
+	if raw := c.QueryParam("dataset_id"); raw != "" {
+		datasetID, err = strconv.Atoi(raw)
+		if err != nil || datasetID <= 0 {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   "Parameter 'dataset_id' must be a dataset ID",
+				Code:    models.ErrCodeInvalidRequest,
+			})
+		}
+	}
+	change := c.QueryParam("change")
+	if change != "" && change != models.AddressChangeAdded && change != models.AddressChangeRemoved {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Parameter 'change' must be 'added' or 'removed'",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	limit, offset := parsePagination(c, 100, 1000)
+	changes, total, err := services.AddressSubscriptions.GetChanges(c.Request().Context(), userID, id, datasetID, change, limit, offset)
+	if err != nil {
+		return addressSubscriptionErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       changes,
+		Count:      len(changes),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
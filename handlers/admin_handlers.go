@@ -1,54 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"geocoding-api/database"
+	"geocoding-api/logging"
 	"geocoding-api/models"
 	"geocoding-api/services"
 
 	"github.com/labstack/echo/v4"
 )
 
-// AdminStatsResponse contains admin dashboard statistics
-type AdminStatsResponse struct {
-	TotalUsers  int `json:"total_users"`
-	ActiveKeys  int `json:"active_keys"`
-	CallsToday  int `json:"calls_today"`
-	ZipCodes    int `json:"zip_codes"`
-}
-
-// AdminUserResponse contains user info for admin dashboard
-type AdminUserResponse struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Company   string    `json:"company"`
-	PlanType  string    `json:"plan_type"`
-	IsActive  bool      `json:"is_active"`
-	IsAdmin   bool      `json:"is_admin"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-// AdminAPIKeyResponse contains API key info for admin dashboard
-type AdminAPIKeyResponse struct {
-	ID          int       `json:"id"`
-	UserEmail   string    `json:"user_email"`
-	Name        string    `json:"name"`
-	KeyPreview  string    `json:"key_preview"`
-	IsActive    bool      `json:"is_active"`
-	LastUsedAt  *time.Time `json:"last_used_at"`
-	CreatedAt   time.Time `json:"created_at"`
-}
-
-// SystemStatusResponse contains system health information
-type SystemStatusResponse struct {
-	DatabaseConnected bool `json:"database_connected"`
-	MigrationsCurrent bool `json:"migrations_current"`
-	APIHealth         bool `json:"api_health"`
-}
-
 // GetUserStatusHandler returns the current user's status and admin privileges
 func GetUserStatusHandler(c echo.Context) error {
 	// Get user from API key authentication context
@@ -57,22 +23,23 @@ func GetUserStatusHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User authentication required",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
 	// Check admin status
-	isAdmin := services.Auth.IsUserAdmin(user.ID)
+	isAdmin := services.Auth.IsUserAdmin(c.Request().Context(), user.ID)
 
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
-		Data: map[string]interface{}{
-			"id":        user.ID,
-			"email":     user.Email,
-			"name":      user.Name,
-			"company":   user.Company,
-			"is_admin":  isAdmin,
-			"plan_type": user.PlanType,
-			"is_active": user.IsActive,
+		Data: models.AdminUserStatus{
+			ID:       user.ID,
+			Email:    user.Email,
+			Name:     user.Name,
+			Company:  user.Company,
+			IsAdmin:  isAdmin,
+			PlanType: user.PlanType,
+			IsActive: user.IsActive,
 		},
 	})
 }
@@ -80,11 +47,42 @@ func GetUserStatusHandler(c echo.Context) error {
 // GetAdminStatsHandler returns dashboard statistics
 func GetAdminStatsHandler(c echo.Context) error {
 	// Admin middleware already verified admin access, no need to double-check
-	stats, err := services.Auth.GetAdminStats()
+	stats, err := services.Auth.GetAdminStats(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get admin statistics",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// RefreshAdminStatsHandler recomputes the dashboard stats views and returns
+// the fresh stats, for when the background refresh is not recent enough
+func RefreshAdminStatsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := services.Stats.Refresh(ctx); err != nil {
+		logging.FromContext(c).Error("failed to refresh admin statistics", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to refresh admin statistics",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	// Read the fresh counts back from the primary; the replica may not
+	// have replayed the refresh yet
+	stats, err := services.Auth.GetAdminStats(database.WithPrimary(ctx))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get admin statistics",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -103,11 +101,12 @@ func GetAdminAnalyticsHandler(c echo.Context) error {
 		}
 	}
 
-	analytics, err := services.Auth.GetAdminAnalytics(days)
+	analytics, err := services.Auth.GetAdminAnalytics(c.Request().Context(), days)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get analytics data",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -117,29 +116,221 @@ func GetAdminAnalyticsHandler(c echo.Context) error {
 	})
 }
 
-// GetAllUsersHandler returns all users for admin dashboard
+// GetAdminUsageComparisonHandler compares usage across all users, or one
+// user with ?user_id=, this period to date with the previous period
+func GetAdminUsageComparisonHandler(c echo.Context) error {
+	period := c.QueryParam("period")
+	if period == "" {
+		period = models.UsagePeriodMonth
+	}
+	if !services.ValidUsagePeriod(period) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "period must be one of: month, week, day",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	userID := 0
+	if userIDParam := c.QueryParam("user_id"); userIDParam != "" {
+		id, err := strconv.Atoi(userIDParam)
+		if err != nil || id <= 0 {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   "Invalid user ID",
+				Code:    models.ErrCodeInvalidRequest,
+			})
+		}
+		userID = id
+	}
+
+	comparison, err := services.Auth.GetUsageComparison(c.Request().Context(), userID, period)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get usage comparison",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    comparison,
+	})
+}
+
+// adminUserQuery holds the admin user list filters as sent by the caller
+type adminUserQuery struct {
+	Email        string `json:"email"`
+	Plan         string `json:"plan"`
+	ActiveSince  string `json:"active_since"`
+	ActiveBefore string `json:"active_before"`
+}
+
+func adminUserQueryFromParams(c echo.Context) *adminUserQuery {
+	return &adminUserQuery{
+		Email:        c.QueryParam("email"),
+		Plan:         c.QueryParam("plan"),
+		ActiveSince:  c.QueryParam("active_since"),
+		ActiveBefore: c.QueryParam("active_before"),
+	}
+}
+
+// filter converts the query to a service filter, reporting each activity
+// date that fails to parse. active_before is exclusive, so a plain date
+// includes that whole day.
+func (q *adminUserQuery) filter() (models.AdminUserFilter, []models.FieldError) {
+	filter := models.AdminUserFilter{
+		Email: strings.TrimSpace(q.Email),
+		Plan:  strings.TrimSpace(q.Plan),
+	}
+
+	var fieldErrs []models.FieldError
+	for _, param := range []struct {
+		name       string
+		value      string
+		dest       *time.Time
+		upperBound bool
+	}{
+		{"active_since", q.ActiveSince, &filter.ActiveSince, false},
+		{"active_before", q.ActiveBefore, &filter.ActiveBefore, true},
+	} {
+		if param.value == "" {
+			continue
+		}
+		t, err := parseTimeParam(param.value, param.upperBound)
+		if err != nil {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   param.name,
+				Rule:    "datetime",
+				Message: param.name + " must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+			continue
+		}
+		*param.dest = t
+	}
+	return filter, fieldErrs
+}
+
+// GetAllUsersHandler returns a page of users for admin dashboard, optionally
+// filtered by ?email=, ?plan=, ?active_since= and ?active_before=
 func GetAllUsersHandler(c echo.Context) error {
-	users, err := services.Auth.GetAllUsers()
+	limit, offset := parsePagination(c, 100, 1000)
+	filter, fieldErrs := adminUserQueryFromParams(c).filter()
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+	filter.Limit, filter.Offset = limit, offset
+
+	users, total, err := services.Auth.GetAllUsers(c.Request().Context(), filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get users",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
 	return c.JSON(http.StatusOK, GeocodeResponse{
-		Success: true,
-		Data:    users,
+		Success:    true,
+		Data:       users,
+		Pagination: paginate(c, total, limit, offset),
 	})
 }
 
+// userExportHeader is the header row of the admin user CSV export
+var userExportHeader = []string{
+	"id", "email", "name", "company", "plan_type", "is_active", "is_admin", "email_verified",
+	"created_at", "last_active_at", "monthly_usage", "today_usage", "total_usage", "active_keys",
+}
+
+// userExportRecord formats one user as a userExportHeader row
+func userExportRecord(user models.AdminUser) []string {
+	lastActive := ""
+	if user.LastActiveAt != nil {
+		lastActive = user.LastActiveAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(user.ID),
+		user.Email,
+		stringOrEmpty(user.Name),
+		stringOrEmpty(user.Company),
+		user.PlanType,
+		strconv.FormatBool(user.IsActive),
+		strconv.FormatBool(user.IsAdmin),
+		strconv.FormatBool(user.EmailVerified),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		lastActive,
+		strconv.Itoa(user.MonthlyUsage),
+		strconv.Itoa(user.TodayUsage),
+		strconv.Itoa(user.TotalUsage),
+		strconv.Itoa(user.ActiveKeys),
+	}
+}
+
+// stringOrEmpty returns *s, or "" when s is nil
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ExportUsersHandler streams every user matching the filters as CSV with
+// usage counts. Filters come from a JSON body, or the query string when
+// there is none, so searched email addresses stay out of access logs.
+func ExportUsersHandler(c echo.Context) error {
+	// Body fields override the query string; an empty body keeps it
+	query, ok := searchParamsFromRequest(c, adminUserQueryFromParams, adminUserQueryFromParams(c))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request body",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	filter, fieldErrs := query.filter()
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+
+	recordAudit(c, models.AuditUsersExported, "user", "", map[string]interface{}{
+		"email": filter.Email, "plan": filter.Plan,
+		"active_since": query.ActiveSince, "active_before": query.ActiveBefore,
+	})
+
+	stream, err := newCSVStream(c, "users.csv", userExportHeader)
+	if err != nil {
+		return err
+	}
+	err = services.Auth.ExportUsers(c.Request().Context(), filter, func(user models.AdminUser) error {
+		return stream.Write(userExportRecord(user))
+	})
+	if err != nil {
+		// Headers are already sent; log and end the stream
+		logging.FromContext(c).Error("CSV user export failed", "error", err)
+	}
+	return stream.Flush()
+}
+
 // GetAllAPIKeysHandler returns all API keys for admin dashboard
 func GetAllAPIKeysHandler(c echo.Context) error {
-	apiKeys, err := services.Auth.GetAllAPIKeys()
+	apiKeys, err := services.Auth.GetAllAPIKeys(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get API keys",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -151,12 +342,13 @@ func GetAllAPIKeysHandler(c echo.Context) error {
 
 // UpdateUserStatusHandler toggles user active status
 func UpdateUserStatusHandler(c echo.Context) error {
-	// Get admin user from API key context (for audit logging)
+	// Get admin user from API key context
 	_, ok := c.Get("user").(*models.User)
 	if !ok {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "Admin authentication required",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
@@ -165,6 +357,7 @@ func UpdateUserStatusHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid user ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -176,23 +369,90 @@ func UpdateUserStatusHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid request body",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	err = services.Auth.UpdateUserStatus(userID, req.IsActive)
+	err = services.Auth.UpdateUserStatus(c.Request().Context(), userID, req.IsActive)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to update user status",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	recordAudit(c, models.AuditUserStatusChanged, "user", strconv.Itoa(userID),
+		map[string]interface{}{"is_active": req.IsActive})
+
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
 		Message: "User status updated successfully",
 	})
 }
 
+// BulkUpdateUserStatusHandler handles PUT /api/v1/admin/users/bulk-status -
+// activate or deactivate a list of users. Each change is audited like a
+// single status update.
+func BulkUpdateUserStatusHandler(c echo.Context) error {
+	adminUser, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "Admin authentication required",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.BulkUserStatusRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// Deactivating yourself would lock you out of the admin API
+	if !*req.IsActive {
+		for _, id := range req.UserIDs {
+			if id == adminUser.ID {
+				return c.JSON(http.StatusBadRequest, GeocodeResponse{
+					Success: false,
+					Error:   "Cannot deactivate your own account",
+					Code:    models.ErrCodeInvalidRequest,
+				})
+			}
+		}
+	}
+
+	updated, err := services.Auth.UpdateUsersStatus(c.Request().Context(), req.UserIDs, *req.IsActive)
+	if err != nil {
+		logging.FromContext(c).Error("failed to update user statuses", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to update user status",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	result := models.BulkUserStatusResult{IsActive: *req.IsActive, Updated: updated, NotFound: []int{}}
+	found := make(map[int]bool, len(updated))
+	for _, id := range updated {
+		found[id] = true
+		recordAudit(c, models.AuditUserStatusChanged, "user", strconv.Itoa(id),
+			map[string]interface{}{"is_active": *req.IsActive, "bulk": true})
+	}
+	for _, id := range req.UserIDs {
+		if !found[id] {
+			result.NotFound = append(result.NotFound, id)
+			found[id] = true
+		}
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    result,
+		Count:   len(updated),
+	})
+}
+
 // UpdateUserAdminHandler toggles user admin status
 func UpdateUserAdminHandler(c echo.Context) error {
 	// Get admin user from API key context
@@ -201,6 +461,7 @@ func UpdateUserAdminHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "Admin authentication required",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
@@ -209,6 +470,7 @@ func UpdateUserAdminHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid user ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -217,6 +479,7 @@ func UpdateUserAdminHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Cannot modify your own admin status",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -228,30 +491,204 @@ func UpdateUserAdminHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid request body",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	err = services.Auth.UpdateUserAdmin(userID, req.IsAdmin)
+	err = services.Auth.UpdateUserAdmin(c.Request().Context(), userID, req.IsAdmin)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to update admin status",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	recordAudit(c, models.AuditUserAdminChanged, "user", strconv.Itoa(userID),
+		map[string]interface{}{"is_admin": req.IsAdmin})
+
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
 		Message: "Admin status updated successfully",
 	})
 }
 
+// UpdateUserPlanHandler handles PUT /api/v1/admin/users/:id/plan - move a
+// user to another plan. The user is emailed about the change.
+func UpdateUserPlanHandler(c echo.Context) error {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid user ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	var req struct {
+		PlanType string `json:"plan_type" validate:"required,max=50"`
+	}
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	previous, err := services.Auth.UpdateUserPlan(c.Request().Context(), userID, req.PlanType)
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrPlanNotFound):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Unknown plan: " + req.PlanType,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	case err != nil:
+		logging.FromContext(c).Error("failed to update user plan", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to update plan",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	recordAudit(c, models.AuditUserPlanChanged, "user", strconv.Itoa(userID),
+		map[string]interface{}{"previous_plan": previous, "plan_type": req.PlanType})
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Plan updated successfully",
+	})
+}
+
+// DeleteUserHandler handles DELETE /api/v1/admin/users/:id - delete an
+// account, deactivating its API keys and anonymizing its usage records
+func DeleteUserHandler(c echo.Context) error {
+	adminUser, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "Admin authentication required",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid user ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if userID == adminUser.ID {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Cannot delete your own account",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	result, err := services.Auth.DeleteUser(c.Request().Context(), userID)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to delete user", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to delete user",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	recordAudit(c, models.AuditUserDeleted, "user", strconv.Itoa(userID), map[string]interface{}{
+		"api_keys_deactivated":      result.APIKeysDeactivated,
+		"usage_records_anonymized":  result.UsageRecordsAnonymized,
+		"organizations_transferred": result.OrganizationsTransferred,
+	})
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    result,
+		Message: "User deleted successfully",
+	})
+}
+
+// RevokeAPIKeyRequest optionally records why an admin revoked a key
+type RevokeAPIKeyRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// RevokeAPIKeyHandler handles PUT /api/v1/admin/api-keys/:id/revoke -
+// deactivate any user's API key
+func RevokeAPIKeyHandler(c echo.Context) error {
+	keyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid API key ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	// The body is optional; an empty one binds to no reason
+	var req RevokeAPIKeyRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	ownerID, err := services.Auth.RevokeAPIKey(c.Request().Context(), keyID)
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "API key not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrAPIKeyAlreadyRevoked):
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "API key is already revoked",
+			Code:    models.ErrCodeConflict,
+		})
+	case err != nil:
+		logging.FromContext(c).Error("failed to revoke API key", "api_key_id", keyID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to revoke API key",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	details := map[string]interface{}{"owner_user_id": ownerID}
+	if req.Reason != "" {
+		details["reason"] = req.Reason
+	}
+	recordAudit(c, models.AuditAPIKeyRevoked, "api_key", strconv.Itoa(keyID), details)
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+	})
+}
+
 // GetSystemStatusHandler returns system health information
 func GetSystemStatusHandler(c echo.Context) error {
-	status, err := services.Auth.GetSystemStatus()
+	status, err := services.Auth.GetSystemStatus(c.Request().Context())
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get system status",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -261,6 +698,63 @@ func GetSystemStatusHandler(c echo.Context) error {
 	})
 }
 
+// GetCacheStatsHandler returns hit/miss counters for the reference lookup
+// caches and the response cache
+func GetCacheStatsHandler(c echo.Context) error {
+	stats := append(services.GetLookupCacheStats(), services.ZipCentroids.Stats(), services.GetResponseCacheStats())
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    stats,
+		Count:   len(stats),
+	})
+}
+
+// PurgeCacheHandler empties the reference lookup caches
+func PurgeCacheHandler(c echo.Context) error {
+	services.PurgeLookupCaches()
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Lookup caches purged",
+	})
+}
+
+// PurgeResponseCacheHandler handles DELETE /api/v1/admin/cache/responses -
+// drop cached responses so the next requests reach the database, for one
+// route (?route=/geocode/:zipcode, without the version prefix) or all of them
+func PurgeResponseCacheHandler(c echo.Context) error {
+	route := c.QueryParam("route")
+	if route != "" && !strings.HasPrefix(route, "/") {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Parameter 'route' must be a route such as /geocode/:zipcode",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if !services.ResponseCacheEnabled() {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "Response caching is not enabled",
+			Code:    models.ErrCodeConflict,
+		})
+	}
+
+	purged, err := services.PurgeResponseCache(c.Request().Context(), route)
+	if err != nil {
+		logging.FromContext(c).Error("failed to purge response cache", "route", route, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to purge response cache",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Count:   purged,
+		Message: "Cached responses purged",
+	})
+}
+
 // GetUserUsageMetricsHandler returns detailed usage metrics for a specific user
 func GetUserUsageMetricsHandler(c echo.Context) error {
 	userID, err := strconv.Atoi(c.Param("id"))
@@ -268,6 +762,7 @@ func GetUserUsageMetricsHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid user ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -278,11 +773,12 @@ func GetUserUsageMetricsHandler(c echo.Context) error {
 		}
 	}
 
-	metrics, err := services.Auth.GetUserUsageMetrics(userID, days)
+	metrics, err := services.Auth.GetUserUsageMetrics(c.Request().Context(), userID, days)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get user metrics",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
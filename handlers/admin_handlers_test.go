@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminUserQueryFilter(t *testing.T) {
+	filter, fieldErrs := (&adminUserQuery{
+		Email:        " @example.com ",
+		Plan:         "free",
+		ActiveSince:  "2026-01-01T12:00:00Z",
+		ActiveBefore: "2026-03-31",
+	}).filter()
+	require.Empty(t, fieldErrs)
+	assert.Equal(t, "@example.com", filter.Email)
+	assert.Equal(t, "free", filter.Plan)
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), filter.ActiveSince)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), filter.ActiveBefore, "a date includes that whole day")
+
+	_, fieldErrs = (&adminUserQuery{ActiveSince: "last week", ActiveBefore: "soon"}).filter()
+	require.Len(t, fieldErrs, 2)
+	assert.Equal(t, "active_since", fieldErrs[0].Field)
+	assert.Equal(t, "active_before", fieldErrs[1].Field)
+}
+
+func TestAdminUserFiltersRejectInvalidDates(t *testing.T) {
+	e := echo.New()
+	for _, tt := range []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		handler echo.HandlerFunc
+	}{
+		{"list", http.MethodGet, "/api/v1/admin/users?active_since=yesterday", "", GetAllUsersHandler},
+		{"export query", http.MethodPost, "/api/v1/admin/users/export?active_before=soon", "", ExportUsersHandler},
+		{"export body", http.MethodPost, "/api/v1/admin/users/export", `{"active_since":"yesterday"}`, ExportUsersHandler},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			}
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, tt.handler(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"VALIDATION_FAILED"`)
+		})
+	}
+}
+
+func TestBulkUpdateUserStatusRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no users", `{"user_ids":[],"is_active":true}`},
+		{"no status", `{"user_ids":[2]}`},
+		{"invalid ID", `{"user_ids":[0],"is_active":true}`},
+		{"too many users", `{"user_ids":[` + strings.Repeat("2,", 1000) + `2],"is_active":true}`},
+		{"deactivating yourself", `{"user_ids":[2,7],"is_active":false}`},
+	}
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/bulk-status", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Set("user", &models.User{ID: 7})
+
+			assert.NoError(t, BulkUpdateUserStatusHandler(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestUpdateUserPlanRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		body string
+	}{
+		{"invalid user ID", "abc", `{"plan_type":"pro"}`},
+		{"no plan", "3", `{}`},
+	}
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/"+tt.id+"/plan", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			assert.NoError(t, UpdateUserPlanHandler(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestUserExportRecord(t *testing.T) {
+	name := "Ada"
+	lastActive := time.Date(2026, 5, 2, 8, 30, 0, 0, time.UTC)
+	record := userExportRecord(models.AdminUser{
+		ID:           3,
+		Email:        "ada@example.com",
+		Name:         &name,
+		PlanType:     "pro",
+		IsActive:     true,
+		CreatedAt:    time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		LastActiveAt: &lastActive,
+		MonthlyUsage: 120,
+		TotalUsage:   4000,
+		ActiveKeys:   2,
+	})
+
+	assert.Len(t, record, len(userExportHeader))
+	assert.Equal(t, []string{
+		"3", "ada@example.com", "Ada", "", "pro", "true", "false", "false",
+		"2025-01-15T00:00:00Z", "2026-05-02T08:30:00Z", "120", "0", "4000", "2",
+	}, record)
+
+	assert.Empty(t, userExportRecord(models.AdminUser{})[9], "never active")
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import "github.com/labstack/echo/v4"
+
+// APIVersionKey is the echo context key of the API version a request was
+// routed to, such as "v2", set by middleware.Version
+const APIVersionKey = "api_version"
+
+// APIPath returns path under the API version the request was made to, so
+// Location headers and links keep a client on the version it uses. Requests
+// not routed through a version get v1.
+func APIPath(c echo.Context, path string) string {
+	version, _ := c.Get(APIVersionKey).(string)
+	if version == "" {
+		version = "v1"
+	}
+	return "/api/" + version + path
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// recordAudit writes an audit log entry for the authenticated user behind c.
+// Failures are logged rather than returned so an audit outage never undoes an
+// action that has already happened.
+func recordAudit(c echo.Context, action, targetType, targetID string, details interface{}) {
+	entry := models.AuditLogEntry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IPAddress:  c.RealIP(),
+	}
+	if userID, ok := c.Get("user_id").(int); ok {
+		entry.ActorUserID = &userID
+	}
+	if email, ok := c.Get("user_email").(string); ok {
+		entry.ActorEmail = email
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			logging.FromContext(c).Error("failed to encode audit details", "action", action, "error", err)
+		} else {
+			entry.Details = raw
+		}
+	}
+
+	// The action has already happened, so a client hanging up must not
+	// cancel its audit entry
+	ctx := context.WithoutCancel(c.Request().Context())
+	if err := services.Audit.Record(ctx, entry); err != nil {
+		logging.FromContext(c).Error("failed to record audit log entry", "action", action, "error", err)
+	}
+}
+
+// auditDatasetUpload records a dataset saved by one of the upload endpoints
+func auditDatasetUpload(c echo.Context, dataset *models.Dataset, replace bool) {
+	recordAudit(c, models.AuditDatasetUploaded, "dataset", strconv.Itoa(dataset.ID), map[string]interface{}{
+		"name":    dataset.Name,
+		"state":   dataset.State,
+		"county":  dataset.County,
+		"replace": replace,
+	})
+}
+
+// GetAuditLogHandler returns a page of the audit log, newest first (admin only).
+// Filters: action (exact, or a group like "api_key.*"), actor_id, target_type,
+// target_id, since and until.
+func GetAuditLogHandler(c echo.Context) error {
+	limit, offset := parsePagination(c, 100, 1000)
+	filter := models.AuditLogFilter{
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	var fieldErrs []models.FieldError
+	if value := c.QueryParam("actor_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   "actor_id",
+				Rule:    "numeric",
+				Message: "actor_id must be a positive integer",
+			})
+		}
+		filter.ActorUserID = id
+	}
+	since, until, timeErrs := timeRangeParams(c, "since", "until")
+	filter.Since, filter.Until = since, until
+	fieldErrs = append(fieldErrs, timeErrs...)
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+
+	entries, total, err := services.Audit.List(c.Request().Context(), filter)
+	if err != nil {
+		logging.FromContext(c).Error("failed to get audit log", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get audit log",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       entries,
+		Count:      len(entries),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
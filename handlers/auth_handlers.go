@@ -1,12 +1,17 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"geocoding-api/logging"
+	"geocoding-api/models"
 	"geocoding-api/services"
 
 	"github.com/labstack/echo/v4"
@@ -26,107 +31,301 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// CreateAPIKeyRequest represents API key creation data
+// RefreshTokenRequest carries the refresh token for refresh and logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ForgotPasswordRequest starts a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest completes a password reset
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// CreateAPIKeyRequest represents API key creation data. MonthlyLimit and
+// DailyLimit optionally cap the key below the account's or organization's
+// plan limits. OrganizationID bills the key to an organization the user owns
+// or is a member of.
 type CreateAPIKeyRequest struct {
-	Name        string   `json:"name" validate:"required"`
-	Permissions []string `json:"permissions" validate:"required"`
+	Name           string   `json:"name" validate:"required"`
+	Permissions    []string `json:"permissions" validate:"required"`
+	MonthlyLimit   *int     `json:"monthly_limit" validate:"omitempty,min=1"`
+	DailyLimit     *int     `json:"daily_limit" validate:"omitempty,min=1"`
+	OrganizationID *int     `json:"organization_id"`
 }
 
 // RegisterHandler handles user registration
 func RegisterHandler(c echo.Context) error {
 	var req RegisterRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	user, err := services.Auth.RegisterUser(c.Request().Context(), req.Email, req.Password, req.Name, req.Company)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return c.JSON(http.StatusConflict, GeocodeResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    models.ErrCodeConflict,
+			})
+		}
+		logging.FromContext(c).Warn("registration failed", "email", req.Email, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
-			Error:   "Invalid request format",
+			Error:   "Failed to create user account",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	// Basic validation
-	if req.Email == "" || req.Password == "" || req.Name == "" {
-		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+	// Start a session for the new user
+	tokens, err := services.Auth.StartSession(c.Request().Context(), user)
+	if err != nil {
+		logging.FromContext(c).Error("failed to start session for new user", "user_id", user.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
-			Error:   "Email, password, and name are required",
+			Error:   "Failed to generate authentication token",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	if len(req.Password) < 8 {
-		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+	logger := logging.FromContext(c)
+	go func() {
+		if err := services.Auth.SendVerificationEmail(context.Background(), user); err != nil {
+			logger.Error("failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}()
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    authTokensResponse(user, tokens, "Account created successfully. Check your email to verify your address before creating API keys."),
+	})
+}
+
+// LoginHandler handles user authentication
+func LoginHandler(c echo.Context) error {
+	var req LoginRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	user, err := services.Auth.AuthenticateUser(c.Request().Context(), req.Email, req.Password)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
-			Error:   "Password must be at least 8 characters long",
+			Error:   "Invalid email or password",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
-	user, err := services.Auth.RegisterUser(req.Email, req.Password, req.Name, req.Company)
+	tokens, err := services.Auth.StartSession(c.Request().Context(), user)
 	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			return c.JSON(http.StatusConflict, GeocodeResponse{
+		logging.FromContext(c).Error("failed to start session", "user_id", user.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to generate authentication token",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    authTokensResponse(user, tokens, "Login successful"),
+	})
+}
+
+// RefreshTokenHandler exchanges a refresh token for a new access token and a
+// rotated refresh token
+func RefreshTokenHandler(c echo.Context) error {
+	var req RefreshTokenRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	tokens, user, err := services.Auth.RefreshSession(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+			return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 				Success: false,
 				Error:   err.Error(),
+				Code:    models.ErrCodeInvalidToken,
 			})
 		}
-		log.Printf("Registration error for %s: %v", req.Email, err)
+		logging.FromContext(c).Error("failed to refresh session", "error", err)
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
-			Error:   "Failed to create user account",
+			Error:   "Failed to refresh authentication token",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	// Generate JWT token for the new user
-	token, err := services.Auth.GenerateJWT(user)
-	if err != nil {
-		log.Printf("Failed to generate JWT for new user %s: %v", user.Email, err)
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    authTokensResponse(user, tokens, "Token refreshed"),
+	})
+}
+
+// authTokensResponse is the data payload for login, registration and refresh
+func authTokensResponse(user *models.User, tokens *models.AuthTokens, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"user":                     user,
+		"token":                    tokens.AccessToken,
+		"token_expires_at":         tokens.AccessTokenExpiresAt,
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshTokenExpiresAt,
+		"message":                  message,
+	}
+}
+
+// ForgotPasswordHandler emails a password reset link. The response is the
+// same whether or not the email is registered, and the email is sent in the
+// background so response time doesn't give it away either.
+func ForgotPasswordHandler(c echo.Context) error {
+	var req ForgotPasswordRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	logger := logging.FromContext(c)
+	go func() {
+		if err := services.Auth.RequestPasswordReset(context.Background(), req.Email); err != nil {
+			logger.Error("failed to send password reset", "error", err)
+		}
+	}()
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "If an account exists for that email, a password reset link has been sent",
+	})
+}
+
+// ResetPasswordHandler sets a new password from a reset link token
+func ResetPasswordHandler(c echo.Context) error {
+	var req ResetPasswordRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	if err := services.Auth.ResetPassword(c.Request().Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, services.ErrPasswordResetTokenInvalid) {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    models.ErrCodeInvalidToken,
+			})
+		}
+		logging.FromContext(c).Error("failed to reset password", "error", err)
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
-			Error:   "Failed to generate authentication token",
+			Error:   "Failed to reset password",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusCreated, GeocodeResponse{
+	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
-		Data: map[string]interface{}{
-			"user":    user,
-			"token":   token,
-			"message": "Account created successfully. You can now create API keys.",
-		},
+		Message: "Password has been reset. Sign in with your new password.",
 	})
 }
 
-// LoginHandler handles user authentication
-func LoginHandler(c echo.Context) error {
-	var req LoginRequest
-	if err := c.Bind(&req); err != nil {
+// VerifyEmailHandler marks the account verified from the emailed link token
+func VerifyEmailHandler(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
-			Error:   "Invalid request format",
+			Error:   "token parameter is required",
+			Code:    models.ErrCodeValidationFailed,
 		})
 	}
 
-	user, err := services.Auth.AuthenticateUser(req.Email, req.Password)
-	if err != nil {
+	if err := services.Auth.VerifyEmail(c.Request().Context(), token); err != nil {
+		if errors.Is(err, services.ErrEmailVerificationTokenInvalid) {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    models.ErrCodeInvalidToken,
+			})
+		}
+		logging.FromContext(c).Error("failed to verify email", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to verify email",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Email verified. You can now create API keys.",
+	})
+}
+
+// ResendVerificationHandler sends a fresh verification link to the
+// authenticated user
+func ResendVerificationHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
-			Error:   "Invalid email or password",
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
-	// Generate JWT token
-	token, err := services.Auth.GenerateJWT(user)
+	user, err := services.Auth.GetUserByID(c.Request().Context(), userID)
 	if err != nil {
-		log.Printf("Failed to generate JWT for user %s: %v", user.Email, err)
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+
+	// The per-IP limit on this route can't stop one account from asking
+	// through many addresses
+	wait, err := services.Auth.VerificationResendWait(c.Request().Context(), userID)
+	if err != nil {
+		logging.FromContext(c).Error("failed to check verification resend cooldown", "user_id", userID, "error", err)
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
-			Error:   "Failed to generate authentication token",
+			Error:   "Failed to send verification email",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	if wait > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return c.JSON(http.StatusTooManyRequests, GeocodeResponse{
+			Success: false,
+			Error:   "A verification email was sent recently. Check your inbox or try again later.",
+			Code:    models.ErrCodeRateLimited,
+		})
+	}
+
+	if err := services.Auth.SendVerificationEmail(c.Request().Context(), user); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			return c.JSON(http.StatusConflict, GeocodeResponse{
+				Success: false,
+				Error:   err.Error(),
+				Code:    models.ErrCodeConflict,
+			})
+		}
+		logging.FromContext(c).Error("failed to send verification email", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to send verification email",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
-		Data: map[string]interface{}{
-			"user":    user,
-			"token":   token,
-			"message": "Login successful",
-		},
+		Message: "Verification email sent to " + user.Email,
 	})
 }
 
@@ -137,14 +336,16 @@ func GetUserProfileHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
-	user, err := services.Auth.GetUserByID(userID)
+	user, err := services.Auth.GetUserByID(c.Request().Context(), userID)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, GeocodeResponse{
 			Success: false,
 			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
 		})
 	}
 
@@ -154,6 +355,62 @@ func GetUserProfileHandler(c echo.Context) error {
 	})
 }
 
+// LogoutHandler revokes the session of the presented token so it and any
+// other token from the same login stop working immediately
+func LogoutHandler(c echo.Context) error {
+	claims, ok := c.Get("jwt_claims").(*services.JWTClaims)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	if err := services.Auth.RevokeRefreshSession(c.Request().Context(), claims.SessionID); err != nil {
+		logging.FromContext(c).Error("failed to revoke refresh tokens", "session_id", claims.SessionID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to log out",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Logged out",
+	})
+}
+
+// RefreshLogoutHandler ends the session a refresh token belongs to. It needs
+// no access token so clients can log out after theirs has expired. Unknown
+// tokens are treated as already logged out.
+func RefreshLogoutHandler(c echo.Context) error {
+	var req RefreshTokenRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	if err := services.Auth.RevokeRefreshToken(c.Request().Context(), req.RefreshToken); err != nil && !errors.Is(err, services.ErrRefreshTokenInvalid) {
+		logging.FromContext(c).Error("failed to revoke refresh token", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to log out",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Logged out",
+	})
+}
+
+// IdempotentReplayContextKey is the echo context key holding the response to
+// store for Idempotency-Key retries in place of the one sent, for responses
+// carrying a secret that must not be kept
+const IdempotentReplayContextKey = "idempotent_replay"
+
 // CreateAPIKeyHandler creates a new API key for authenticated users
 func CreateAPIKeyHandler(c echo.Context) error {
 	userID, ok := c.Get("user_id").(int)
@@ -161,45 +418,94 @@ func CreateAPIKeyHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
 	var req CreateAPIKeyRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	// Unverified accounts can sign in but not use quota, which keeps
+	// throwaway signups from farming free-tier keys
+	user, err := services.Auth.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if !user.EmailVerified {
+		return c.JSON(http.StatusForbidden, GeocodeResponse{
 			Success: false,
-			Error:   "Invalid request format",
+			Error:   "Verify your email address before creating API keys",
+			Code:    models.ErrCodeEmailNotVerified,
 		})
 	}
 
-	// Validate permissions
-	validPermissions := []string{"geocode", "search", "distance", "nearby", "proximity", "addresses", "counties", "cities", "states", "*"}
+	// Validate permissions against the permission registry
 	for _, perm := range req.Permissions {
-		valid := false
-		for _, validPerm := range validPermissions {
-			if perm == validPerm {
-				valid = true
-				break
-			}
-		}
-		if !valid {
+		if !services.Permissions.IsValid(perm) {
 			return c.JSON(http.StatusBadRequest, GeocodeResponse{
 				Success: false,
 				Error:   "Invalid permission: " + perm,
+				Code:    models.ErrCodeInvalidRequest,
 			})
 		}
 	}
 
-	apiKey, keyString, err := services.Auth.GenerateAPIKey(userID, req.Name, req.Permissions)
+	if req.OrganizationID != nil {
+		if _, err := services.Organizations.RequireRole(c.Request().Context(), *req.OrganizationID, userID, models.OrgRoleOwner, models.OrgRoleMember); err != nil {
+			return organizationErrorResponse(c, err)
+		}
+	}
+
+	fieldErrs, err := checkAPIKeyLimits(c.Request().Context(), userID, &req)
+	if err != nil {
+		logging.FromContext(c).Error("failed to get plan limits", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to check plan limits",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+
+	apiKey, keyString, err := services.Auth.GenerateAPIKey(c.Request().Context(), userID, req.Name, req.Permissions, req.MonthlyLimit, req.DailyLimit, req.OrganizationID)
 	if err != nil {
 		// Log the actual error for debugging
 		c.Logger().Errorf("Failed to create API key: %v", err)
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to create API key: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	recordAudit(c, models.AuditAPIKeyCreated, "api_key", strconv.Itoa(apiKey.ID), map[string]interface{}{
+		"name":            apiKey.Name,
+		"permissions":     apiKey.Permissions,
+		"organization_id": apiKey.OrganizationID,
+	})
+
+	// Retries with the same Idempotency-Key get the key's details, not the key
+	c.Set(IdempotentReplayContextKey, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"api_key": apiKey,
+			"message": "API key was already created for this Idempotency-Key. The full key is only shown once.",
+		},
+	})
+
 	return c.JSON(http.StatusCreated, GeocodeResponse{
 		Success: true,
 		Data: map[string]interface{}{
@@ -218,26 +524,34 @@ func GetUsageHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
 	// Always use current month for security and data integrity
 	month := time.Now().Format("2006-01")
 
-	summary, err := services.Auth.GetUsageSummary(userID, month)
+	// CSV export streams the raw usage records for the current month
+	if wantsCSV(c) {
+		return exportUsageCSV(c, userID)
+	}
+
+	summary, err := services.Auth.GetUsageSummary(c.Request().Context(), userID, month)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get usage statistics",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
 	// Also get current rate limit status
-	withinLimit, currentUsage, monthlyLimit, err := services.Auth.CheckRateLimit(userID)
+	withinLimit, currentUsage, monthlyLimit, err := services.Auth.CheckRateLimit(c.Request().Context(), userID, nil)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to check rate limit",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -262,6 +576,7 @@ func GetDailyUsageHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
@@ -273,11 +588,12 @@ func GetDailyUsageHandler(c echo.Context) error {
 		}
 	}
 
-	dailyUsage, err := services.Auth.GetDailyUsage(userID, days)
+	dailyUsage, err := services.Auth.GetDailyUsage(c.Request().Context(), userID, days)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get daily usage statistics",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -294,6 +610,7 @@ func GetEndpointUsageHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
@@ -305,11 +622,12 @@ func GetEndpointUsageHandler(c echo.Context) error {
 		}
 	}
 
-	endpointUsage, err := services.Auth.GetEndpointUsage(userID, days)
+	endpointUsage, err := services.Auth.GetEndpointUsage(c.Request().Context(), userID, days)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to get endpoint usage statistics",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -319,6 +637,145 @@ func GetEndpointUsageHandler(c echo.Context) error {
 	})
 }
 
+// GetUsageComparisonHandler compares the user's usage this period to date
+// with the same span of the previous period (?period=month|week|day)
+func GetUsageComparisonHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	period := c.QueryParam("period")
+	if period == "" {
+		period = models.UsagePeriodMonth
+	}
+	if !services.ValidUsagePeriod(period) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "period must be one of: month, week, day",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	comparison, err := services.Auth.GetUsageComparison(c.Request().Context(), userID, period)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get usage comparison",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    comparison,
+	})
+}
+
+// GetUsageTimeSeriesHandler returns the user's usage bucketed by hour, day
+// or week (?granularity=hour|day|week&from=&to=) with error rates and
+// approximate latency percentiles
+func GetUsageTimeSeriesHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	granularity := c.QueryParam("granularity")
+	if granularity == "" {
+		granularity = models.UsageGranularityHour
+	}
+	if !services.ValidUsageGranularity(granularity) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "granularity must be one of: hour, day, week",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	from, to, fieldErrs := timeRangeParams(c, "from", "to")
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+
+	series, err := services.Auth.GetUsageTimeSeries(c.Request().Context(), userID, granularity, from, to)
+	if errors.Is(err, services.ErrUsageRangeInvalid) || errors.Is(err, services.ErrUsageRangeTooLarge) {
+		message := err.Error()
+		if errors.Is(err, services.ErrUsageRangeTooLarge) {
+			message = fmt.Sprintf("%s; the maximum for %s is %d days", message, granularity,
+				int(services.MaxUsageRange(granularity).Hours()/24))
+		}
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   message,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to get usage time series", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get usage time series",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    series,
+		Count:   len(series.Points),
+	})
+}
+
+// checkAPIKeyLimits rejects per-key caps above the plan limits the key bills
+// against, since those would never take effect
+func checkAPIKeyLimits(ctx context.Context, userID int, req *CreateAPIKeyRequest) ([]models.FieldError, error) {
+	if req.MonthlyLimit == nil && req.DailyLimit == nil {
+		return nil, nil
+	}
+
+	var monthlyLimit, dailyLimit int
+	var err error
+	if req.OrganizationID != nil {
+		monthlyLimit, dailyLimit, err = services.Organizations.GetPlanLimits(ctx, *req.OrganizationID)
+	} else {
+		monthlyLimit, dailyLimit, err = services.Auth.GetPlanLimits(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fieldErrs []models.FieldError
+	if req.MonthlyLimit != nil && monthlyLimit != -1 && *req.MonthlyLimit > monthlyLimit {
+		fieldErrs = append(fieldErrs, models.FieldError{
+			Field:   "monthly_limit",
+			Rule:    "max",
+			Message: fmt.Sprintf("monthly_limit must be at most %d, your plan's monthly limit", monthlyLimit),
+		})
+	}
+	if req.DailyLimit != nil && dailyLimit != -1 && *req.DailyLimit > dailyLimit {
+		fieldErrs = append(fieldErrs, models.FieldError{
+			Field:   "daily_limit",
+			Rule:    "max",
+			Message: fmt.Sprintf("daily_limit must be at most %d, your plan's daily limit", dailyLimit),
+		})
+	}
+	return fieldErrs, nil
+}
+
 // GetAPIKeysHandler returns all API keys for a user
 func GetAPIKeysHandler(c echo.Context) error {
 	userID, ok := c.Get("user_id").(int)
@@ -326,14 +783,16 @@ func GetAPIKeysHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
-	apiKeys, err := services.Auth.GetUserAPIKeys(userID)
+	apiKeys, err := services.Auth.GetUserAPIKeys(c.Request().Context(), userID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to fetch API keys",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -353,6 +812,7 @@ func DeleteAPIKeyHandler(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
 			Success: false,
 			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
 		})
 	}
 
@@ -362,23 +822,28 @@ func DeleteAPIKeyHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid API key ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	err = services.Auth.DeleteAPIKey(userID, keyIDInt)
+	err = services.Auth.DeleteAPIKey(c.Request().Context(), userID, keyIDInt)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return c.JSON(http.StatusNotFound, GeocodeResponse{
 				Success: false,
 				Error:   "API key not found",
+				Code:    models.ErrCodeNotFound,
 			})
 		}
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to delete API key",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	recordAudit(c, models.AuditAPIKeyDeleted, "api_key", keyID, nil)
+
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
 		Data: map[string]interface{}{
@@ -387,45 +852,35 @@ func DeleteAPIKeyHandler(c echo.Context) error {
 	})
 }
 
-// GetPlansHandler returns available pricing plans
-func GetPlansHandler(c echo.Context) error {
-	return c.JSON(http.StatusOK, GeocodeResponse{
-		Success: true,
-		Data: map[string]interface{}{
-			"plans": map[string]interface{}{
-				"free": map[string]interface{}{
-					"name":           "Free",
-					"monthly_limit":  3000,
-					"daily_limit":    500,
-					"price_per_call": 0,
-					"price_monthly":  0,
-					"features":       []string{"Basic geocoding", "City search", "Community support"},
-				},
-				"starter": map[string]interface{}{
-					"name":           "Starter", 
-					"monthly_limit":  30000,
-					"daily_limit":    5000,
-					"price_per_call": 0.001,
-					"price_monthly":  10,
-					"features":       []string{"All Free features", "Distance calculations", "Email support"},
-				},
-				"pro": map[string]interface{}{
-					"name":           "Pro",
-					"monthly_limit":  500000,
-					"daily_limit":    20000,
-					"price_per_call": 0.0008,
-					"price_monthly":  80,
-					"features":       []string{"All Starter features", "Bulk operations", "Priority support", "SLA"},
-				},
-				"enterprise": map[string]interface{}{
-					"name":           "Enterprise",
-					"monthly_limit":  -1,
-					"daily_limit":    -1,
-					"price_per_call": 0.0005,
-					"price_monthly":  500,
-					"features":       []string{"Unlimited usage", "All Pro features", "Custom integrations", "Dedicated support", "99.9% SLA"},
-				},
-			},
-		},
+// exportUsageCSV streams the user's current-month usage records as CSV
+func exportUsageCSV(c echo.Context, userID int) error {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	stream, err := newCSVStream(c, "usage-"+now.Format("2006-01")+".csv", []string{
+		"id", "api_key_id", "endpoint", "method", "status_code", "response_time_ms",
+		"ip_address", "billable", "created_at",
 	})
-}
\ No newline at end of file
+	if err != nil {
+		return err
+	}
+
+	sample := 0.0
+	if sampleStr := c.QueryParam("sample"); sampleStr != "" {
+		if val, err := strconv.ParseFloat(sampleStr, 64); err == nil && val > 0 && val <= 1 {
+			sample = val
+		}
+	}
+
+	err = services.Auth.StreamUsageRecords(c.Request().Context(), userID, monthStart, sample, func(r *models.UsageRecord) error {
+		return stream.Write([]string{
+			strconv.Itoa(r.ID), strconv.Itoa(r.APIKeyID), r.Endpoint, r.Method,
+			strconv.Itoa(r.StatusCode), strconv.Itoa(r.ResponseTime), r.IPAddress,
+			strconv.FormatBool(r.Billable), r.CreatedAt.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		c.Logger().Errorf("CSV usage export failed: %v", err)
+	}
+	return stream.Flush()
+}
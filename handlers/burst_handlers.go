@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreateBurstRequestHandler lets enterprise users request a burst window
+func CreateBurstRequestHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.BurstWindowRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request format. Expected starts_at, ends_at (RFC 3339) and requested_qps",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	user, err := services.Auth.GetUserByID(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+
+	window, err := services.Burst.CreateBurstRequest(c.Request().Context(), user, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "enterprise plan") {
+			status = http.StatusForbidden
+		} else if strings.Contains(err.Error(), "failed to") {
+			status = http.StatusInternalServerError
+		}
+		return c.JSON(status, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(status),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    window,
+		Message: "Burst window requested. It will take effect once approved by an administrator.",
+	})
+}
+
+// GetUserBurstRequestsHandler lists the authenticated user's burst windows
+func GetUserBurstRequestsHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	windows, err := services.Burst.GetBurstWindows(c.Request().Context(), userID, c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get burst requests",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    windows,
+		Count:   len(windows),
+	})
+}
+
+// GetBurstRequestsHandler lists burst windows across all users (admin only)
+func GetBurstRequestsHandler(c echo.Context) error {
+	windows, err := services.Burst.GetBurstWindows(c.Request().Context(), 0, c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get burst requests",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    windows,
+		Count:   len(windows),
+	})
+}
+
+// ReviewBurstRequestHandler approves or rejects a pending burst window (admin only)
+func ReviewBurstRequestHandler(c echo.Context) error {
+	adminUser, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "Admin authentication required",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid burst request ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request body",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	window, err := services.Burst.ReviewBurstRequest(c.Request().Context(), id, adminUser.ID, req.Status)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "must be") {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(status),
+		})
+	}
+
+	recordAudit(c, models.AuditBurstReviewed, "burst_request", strconv.Itoa(id), map[string]interface{}{
+		"status":        window.Status,
+		"user_id":       window.UserID,
+		"requested_qps": window.RequestedQPS,
+	})
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    window,
+		Message: "Burst request " + window.Status,
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// cellErrorResponse maps cell service errors to responses
+func cellErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	code := models.ErrCodeInternal
+	switch {
+	case errors.Is(err, services.ErrUnknownCellSystem),
+		errors.Is(err, services.ErrInvalidCellResolution),
+		errors.Is(err, services.ErrInvalidCell):
+		status, code = http.StatusBadRequest, models.ErrCodeInvalidRequest
+	case errors.Is(err, services.ErrH3Unavailable):
+		status, code = http.StatusServiceUnavailable, models.ErrCodeUnavailable
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(c).Error("cell request failed", "error", err)
+		message = "Cell request failed"
+	}
+	return c.JSON(status, GeocodeResponse{
+		Success: false,
+		Error:   message,
+		Code:    code,
+	})
+}
+
+// EncodeCellHandler handles GET /api/v1/encode?lat=&lng=&system=h3|geohash&resolution= -
+// the H3 cell or geohash containing a point. system defaults to h3 and
+// resolution to 9 (the geohash length for geohashes).
+func EncodeCellHandler(c echo.Context) error {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Valid 'lat' and 'lng' query parameters are required",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Coordinates out of range",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+
+	resolution := -1
+	if value := c.QueryParam("resolution"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   "'resolution' must be a non-negative integer",
+				Code:    models.ErrCodeInvalidRequest,
+			})
+		}
+		resolution = n
+	}
+
+	cell, err := services.Cells.Encode(c.Request().Context(), cellSystem(c), lat, lng, resolution)
+	if err != nil {
+		return cellErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    cell,
+	})
+}
+
+// DecodeCellHandler handles GET /api/v1/decode?cell=&system=h3|geohash - the
+// center, resolution and outline of an H3 cell or geohash
+func DecodeCellHandler(c echo.Context) error {
+	value := c.QueryParam("cell")
+	if value == "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "'cell' query parameter is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	cell, err := services.Cells.Decode(c.Request().Context(), cellSystem(c), value)
+	if err != nil {
+		return cellErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    cell,
+	})
+}
+
+// cellSystem returns the ?system= parameter, defaulting to h3
+func cellSystem(c echo.Context) string {
+	if system := c.QueryParam("system"); system != "" {
+		return system
+	}
+	return models.CellSystemH3
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Geohashes are computed without the database
+func TestGeohashCellHandlers(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		query   string
+		status  int
+		cell    string
+	}{
+		{"encode", EncodeCellHandler, "lat=42.6&lng=-5.6&system=geohash&resolution=5", http.StatusOK, "ezs42"},
+		{"encode default precision", EncodeCellHandler, "lat=57.64911&lng=10.40744&system=geohash", http.StatusOK, "u4pruydqq"},
+		{"decode", DecodeCellHandler, "cell=EZS42&system=geohash", http.StatusOK, "ezs42"},
+		{"precision too long", EncodeCellHandler, "lat=42.6&lng=-5.6&system=geohash&resolution=13", http.StatusBadRequest, ""},
+		{"unknown system", EncodeCellHandler, "lat=42.6&lng=-5.6&system=s2", http.StatusBadRequest, ""},
+		{"coordinates out of range", EncodeCellHandler, "lat=95&lng=-5.6&system=geohash", http.StatusBadRequest, ""},
+		{"invalid geohash", DecodeCellHandler, "cell=ezs4a&system=geohash", http.StatusBadRequest, ""},
+		{"missing cell", DecodeCellHandler, "system=geohash", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/cells?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, tt.handler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.cell == "" {
+				return
+			}
+
+			var response struct {
+				Data struct {
+					Cell       string `json:"cell"`
+					Resolution int    `json:"resolution"`
+					Boundary   struct {
+						Type string `json:"type"`
+					} `json:"boundary"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.cell, response.Data.Cell)
+			assert.Equal(t, len(tt.cell), response.Data.Resolution)
+			assert.Equal(t, "Polygon", response.Data.Boundary.Type)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"geocoding-api/models"
 	"geocoding-api/services"
 	"net/http"
@@ -11,52 +12,30 @@ import (
 
 // SearchCitiesHandler handles city search requests
 func SearchCitiesHandler(c echo.Context) error {
-	var params models.CitySearchParams
-	
-	// Parse query parameters
-	params.Query = c.QueryParam("query")
-	params.City = c.QueryParam("city")
-	params.State = c.QueryParam("state")
-	params.County = c.QueryParam("county")
-	
-	// Parse numeric parameters
-	if lat := c.QueryParam("lat"); lat != "" {
-		if val, err := strconv.ParseFloat(lat, 64); err == nil {
-			params.Lat = val
-		}
-	}
-	if lng := c.QueryParam("lng"); lng != "" {
-		if val, err := strconv.ParseFloat(lng, 64); err == nil {
-			params.Lng = val
-		}
-	}
-	if radius := c.QueryParam("radius"); radius != "" {
-		if val, err := strconv.ParseFloat(radius, 64); err == nil {
-			params.Radius = val
-		}
-	}
-	if minPop := c.QueryParam("min_population"); minPop != "" {
-		if val, err := strconv.Atoi(minPop); err == nil {
-			params.MinPop = val
-		}
-	}
-	if limit := c.QueryParam("limit"); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil {
-			params.Limit = val
-		}
+	bound, ok := searchParamsFromRequest(c, parseCitySearchParams, &models.CitySearchParams{})
+	if !ok {
+		return c.JSON(http.StatusBadRequest, models.CitySearchResponse{
+			Success: false,
+			Error:   "Invalid JSON request body",
+			Code:    models.ErrCodeInvalidRequest,
+		})
 	}
-	if offset := c.QueryParam("offset"); offset != "" {
-		if val, err := strconv.Atoi(offset); err == nil {
-			params.Offset = val
-		}
+	params := *bound
+	if !validBBox(params.BBox) {
+		return c.JSON(http.StatusBadRequest, models.CitySearchResponse{
+			Success: false,
+			Error:   bboxError,
+			Code:    models.ErrCodeInvalidRequest,
+		})
 	}
 
 	// Search cities
-	cities, total, err := services.City.SearchCities(params)
+	cities, total, err := services.City.SearchCities(c.Request().Context(), params)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.CitySearchResponse{
 			Success: false,
 			Error:   "Failed to search cities: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -74,6 +53,9 @@ func SearchCitiesHandler(c echo.Context) error {
 	if params.MinPop > 0 {
 		filters["min_population"] = params.MinPop
 	}
+	if params.MaxPop > 0 {
+		filters["max_population"] = params.MaxPop
+	}
 	if params.Lat != 0 && params.Lng != 0 {
 		filters["location"] = map[string]float64{
 			"lat": params.Lat,
@@ -83,6 +65,9 @@ func SearchCitiesHandler(c echo.Context) error {
 			filters["radius_km"] = params.Radius
 		}
 	}
+	if params.BBox != nil {
+		filters["bbox"] = params.BBox
+	}
 
 	return c.JSON(http.StatusOK, models.CitySearchResponse{
 		Success: true,
@@ -94,6 +79,57 @@ func SearchCitiesHandler(c echo.Context) error {
 	})
 }
 
+// parseCitySearchParams reads city search parameters from the query string
+func parseCitySearchParams(c echo.Context) *models.CitySearchParams {
+	var params models.CitySearchParams
+	
+	// Parse query parameters
+	params.Query = c.QueryParam("query")
+	params.City = c.QueryParam("city")
+	params.State = c.QueryParam("state")
+	params.County = c.QueryParam("county")
+	
+	// Parse numeric parameters
+	if lat := c.QueryParam("lat"); lat != "" {
+		if val, err := strconv.ParseFloat(lat, 64); err == nil {
+			params.Lat = val
+		}
+	}
+	if lng := c.QueryParam("lng"); lng != "" {
+		if val, err := strconv.ParseFloat(lng, 64); err == nil {
+			params.Lng = val
+		}
+	}
+	if radius := c.QueryParam("radius"); radius != "" {
+		if val, err := strconv.ParseFloat(radius, 64); err == nil {
+			params.Radius = val
+		}
+	}
+	if minPop := c.QueryParam("min_population"); minPop != "" {
+		if val, err := strconv.Atoi(minPop); err == nil {
+			params.MinPop = val
+		}
+	}
+	if maxPop := c.QueryParam("max_population"); maxPop != "" {
+		if val, err := strconv.Atoi(maxPop); err == nil {
+			params.MaxPop = val
+		}
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		if val, err := strconv.Atoi(limit); err == nil {
+			params.Limit = val
+		}
+	}
+	if offset := c.QueryParam("offset"); offset != "" {
+		if val, err := strconv.Atoi(offset); err == nil {
+			params.Offset = val
+		}
+	}
+	params.BBox = parseBBox(c)
+
+	return &params
+}
+
 // GetCityHandler retrieves a specific city by ID
 func GetCityHandler(c echo.Context) error {
 	idStr := c.Param("id")
@@ -102,14 +138,69 @@ func GetCityHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, models.CitySearchResponse{
 			Success: false,
 			Error:   "Invalid city ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	city, err := services.City.GetCityByID(id)
+	city, err := services.City.GetCityByID(c.Request().Context(), id)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, models.CitySearchResponse{
 			Success: false,
 			Error:   "City not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.CitySearchResponse{
+		Success: true,
+		Data:    []models.City{*city},
+		Count:   1,
+		Total:   1,
+	})
+}
+
+// GetCityByLocationHandler returns the nearest city to a point, optionally
+// only cities with at least min_population residents
+func GetCityByLocationHandler(c echo.Context) error {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(http.StatusBadRequest, models.CitySearchResponse{
+			Success: false,
+			Error:   "Valid 'lat' and 'lng' query parameters are required",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.JSON(http.StatusBadRequest, models.CitySearchResponse{
+			Success: false,
+			Error:   "Coordinates out of range",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+
+	// Radius in kilometers, default 50km, max 500km
+	radius := 50.0
+	if radiusStr := c.QueryParam("radius"); radiusStr != "" {
+		if val, err := strconv.ParseFloat(radiusStr, 64); err == nil && val > 0 && val <= 500 {
+			radius = val
+		}
+	}
+	minPop, _ := strconv.Atoi(c.QueryParam("min_population"))
+
+	city, err := services.City.GetNearestCity(c.Request().Context(), lat, lng, minPop, radius)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.CitySearchResponse{
+			Success: false,
+			Error:   "Failed to look up city",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	if city == nil {
+		return c.JSON(http.StatusNotFound, models.CitySearchResponse{
+			Success: false,
+			Error:   fmt.Sprintf("No city found within %gkm", radius),
+			Code:    models.ErrCodeNotFound,
 		})
 	}
 
@@ -130,20 +221,22 @@ func GetCityZIPCodesHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, models.CitySearchResponse{
 			Success: false,
 			Error:   "Both 'city' and 'state' parameters are required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	zips, err := services.City.GetZIPCodesForCity(city, state)
+	zips, err := services.City.GetZIPCodesForCity(c.Request().Context(), city, state)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.CitySearchResponse{
 			Success: false,
 			Error:   "Failed to get ZIP codes: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    map[string]interface{}{
 			"city":  city,
 			"state": state,
 			"zips":  zips,
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCityByLocationRejectsInvalidCoordinates(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing coordinates", ""},
+		{"missing longitude", "lat=39.7"},
+		{"non-numeric latitude", "lat=north&lng=-84.2"},
+		{"latitude out of range", "lat=91&lng=-84.2"},
+		{"longitude out of range", "lat=39.7&lng=-181"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/cities/lookup?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, GetCityByLocationHandler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"INVALID_COORDINATES"`)
+		})
+	}
+}
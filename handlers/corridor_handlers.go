@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SearchCorridorHandler handles POST /api/v1/corridor - the ZIP codes and
+// addresses within buffer_miles of a GeoJSON LineString, ordered by distance
+// along the route. The route needs the distance permission; addresses also
+// need the addresses permission.
+func SearchCorridorHandler(c echo.Context) error {
+	var req models.CorridorRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+	include := map[string]bool{}
+	for _, item := range req.Include {
+		include[item] = true
+	}
+	if len(include) == 0 {
+		include[models.CorridorZipCodes] = true
+		include[models.CorridorAddresses] = true
+	}
+
+	if key, ok := c.Get("api_key").(*models.APIKey); ok && include[models.CorridorAddresses] &&
+		!services.Auth.HasPermission(key, "addresses") {
+		return c.JSON(http.StatusForbidden, GeocodeResponse{
+			Success: false,
+			Error:   "API key does not have the addresses permission; include only zip_codes",
+			Code:    models.ErrCodePermissionDenied,
+		})
+	}
+
+	result, err := services.SearchCorridor(c.Request().Context(), req.Geometry, req.BufferMiles, include, req.Limit)
+	if errors.Is(err, services.ErrCorridorRouteInvalid) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeValidationFailed,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to search corridor", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to search along route",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    result,
+		Count:   len(result.ZipCodes) + len(result.Addresses),
+	})
+}
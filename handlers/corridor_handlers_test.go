@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchCorridorRejectsInvalidRequests(t *testing.T) {
+	const route = `{"type": "LineString", "coordinates": [[-83.0, 39.96], [-82.9, 40.0]]}`
+	tests := []struct {
+		name        string
+		body        string
+		permissions models.JSONArray
+		status      int
+		code        string
+	}{
+		{name: "malformed JSON", body: `{"geometry":`, status: http.StatusBadRequest, code: models.ErrCodeInvalidRequest},
+		{name: "missing buffer", body: `{"geometry": ` + route + `}`, status: http.StatusBadRequest, code: models.ErrCodeValidationFailed},
+		{name: "buffer over the cap", body: `{"geometry": ` + route + `, "buffer_miles": 50}`, status: http.StatusBadRequest, code: models.ErrCodeValidationFailed},
+		{name: "unknown result type", body: `{"geometry": ` + route + `, "buffer_miles": 2, "include": ["cities"]}`, status: http.StatusBadRequest, code: models.ErrCodeValidationFailed},
+		{name: "not a line", body: `{"geometry": {"type": "Point", "coordinates": [-83.0, 39.96]}, "buffer_miles": 2}`, status: http.StatusBadRequest, code: models.ErrCodeValidationFailed},
+		{
+			name:        "addresses without permission",
+			body:        `{"geometry": ` + route + `, "buffer_miles": 2}`,
+			permissions: models.JSONArray{"distance"},
+			status:      http.StatusForbidden,
+			code:        models.ErrCodePermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/corridor", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.permissions != nil {
+				c.Set("api_key", &models.APIKey{Permissions: tt.permissions})
+			}
+
+			assert.NoError(t, SearchCorridorHandler(c))
+			assert.Equal(t, tt.status, rec.Code)
+
+			var response GeocodeResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Code)
+		})
+	}
+}
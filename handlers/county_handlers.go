@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -12,12 +13,21 @@ import (
 
 // GetCountiesHandler returns a list of all Ohio counties
 func GetCountiesHandler(c echo.Context) error {
+	limit, offset := parsePagination(c, 100, 1000)
 	params := models.CountySearchParams{
 		Name:         c.QueryParam("name"),
 		MinAddresses: 0,
 		MaxAddresses: 0,
-		Limit:        100, // Default limit
-		Offset:       0,
+		Limit:        limit,
+		Offset:       offset,
+		BBox:         parseBBox(c),
+	}
+	if !validBBox(params.BBox) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   bboxError,
+			Code:    models.ErrCodeInvalidRequest,
+		})
 	}
 
 	// Parse numeric parameters
@@ -33,30 +43,20 @@ func GetCountiesHandler(c echo.Context) error {
 		}
 	}
 
-	if limit := c.QueryParam("limit"); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil && val > 0 && val <= 1000 {
-			params.Limit = val
-		}
-	}
-
-	if offset := c.QueryParam("offset"); offset != "" {
-		if val, err := strconv.Atoi(offset); err == nil && val >= 0 {
-			params.Offset = val
-		}
-	}
-
-	counties, err := services.County.GetAllCounties(params)
+	counties, total, err := services.County.GetAllCounties(c.Request().Context(), params)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to fetch counties: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to fetch counties: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    counties,
-		"count":   len(counties),
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       counties,
+		Count:      len(counties),
+		Pagination: paginate(c, total, params.Limit, params.Offset),
 	})
 }
 
@@ -64,29 +64,32 @@ func GetCountiesHandler(c echo.Context) error {
 func GetCountyDetailHandler(c echo.Context) error {
 	countyName := c.Param("name")
 	if countyName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "County name is required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "County name is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	county, err := services.County.GetCountyByName(countyName)
+	county, err := services.County.GetCountyByName(c.Request().Context(), countyName)
 	if err != nil {
 		if err.Error() == "county not found: "+countyName {
-			return c.JSON(http.StatusNotFound, map[string]interface{}{
-				"success": false,
-				"error":   "County not found",
+			return c.JSON(http.StatusNotFound, GeocodeResponse{
+				Success: false,
+				Error:   "County not found",
+				Code:    models.ErrCodeNotFound,
 			})
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to fetch county: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to fetch county: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    county,
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    county,
 	})
 }
 
@@ -94,23 +97,35 @@ func GetCountyDetailHandler(c echo.Context) error {
 func GetCountyBoundaryHandler(c echo.Context) error {
 	countyName := c.Param("name")
 	if countyName == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "County name is required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "County name is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	boundary, err := services.County.GetCountyBoundaryGeoJSON(countyName)
+	tolerance, resolution, errMessage := parseBoundaryDetail(c)
+	if errMessage != "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   errMessage,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	boundary, err := services.County.GetCountyBoundaryGeoJSON(c.Request().Context(), countyName, tolerance, resolution)
 	if err != nil {
 		if err.Error() == "county not found: "+countyName {
-			return c.JSON(http.StatusNotFound, map[string]interface{}{
-				"success": false,
-				"error":   "County not found",
+			return c.JSON(http.StatusNotFound, GeocodeResponse{
+				Success: false,
+				Error:   "County not found",
+				Code:    models.ErrCodeNotFound,
 			})
 		}
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to fetch county boundary: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to fetch county boundary: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -120,80 +135,55 @@ func GetCountyBoundaryHandler(c echo.Context) error {
 
 // GetCountyStatsHandler returns statistics about all Ohio counties
 func GetCountyStatsHandler(c echo.Context) error {
-	stats, err := services.County.GetCountyStats()
+	stats, err := services.County.GetCountyStats(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to get county statistics: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get county statistics: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    stats,
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    stats,
 	})
 }
 
 // GetCountiesInBoundsHandler returns counties within the specified geographic bounds
 func GetCountiesInBoundsHandler(c echo.Context) error {
-	// Parse bounding box parameters
-	minLatStr := c.QueryParam("min_lat")
-	minLonStr := c.QueryParam("min_lon")
-	maxLatStr := c.QueryParam("max_lat")
-	maxLonStr := c.QueryParam("max_lon")
-
-	if minLatStr == "" || minLonStr == "" || maxLatStr == "" || maxLonStr == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Bounding box parameters required: min_lat, min_lon, max_lat, max_lon",
+	bounds, ok := searchParamsFromRequest(c, parseBoundsRequest, &BoundsRequest{})
+	if !ok {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid JSON request body",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
-
-	minLat, err := strconv.ParseFloat(minLatStr, 64)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid min_lat parameter",
-		})
-	}
-
-	minLon, err := strconv.ParseFloat(minLonStr, 64)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid min_lon parameter",
-		})
-	}
-
-	maxLat, err := strconv.ParseFloat(maxLatStr, 64)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid max_lat parameter",
-		})
-	}
-
-	maxLon, err := strconv.ParseFloat(maxLonStr, 64)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid max_lon parameter",
+	if errMsg := bounds.validate(); errMsg != "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   errMsg,
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
+	minLat, minLon, maxLat, maxLon := *bounds.MinLat, *bounds.MinLon, *bounds.MaxLat, *bounds.MaxLon
 
 	// Validate bounding box
 	if minLat >= maxLat || minLon >= maxLon {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "Invalid bounding box: min values must be less than max values",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid bounding box: min values must be less than max values",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	counties, err := services.County.GetCountiesWithinBounds(minLat, minLon, maxLat, maxLon)
+	counties, err := services.County.GetCountiesWithinBounds(c.Request().Context(), minLat, minLon, maxLat, maxLon)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "Failed to fetch counties in bounds: " + err.Error(),
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to fetch counties in bounds: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -208,4 +198,111 @@ func GetCountiesInBoundsHandler(c echo.Context) error {
 			"max_lon": maxLon,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// ContainsPointsBatchHandler handles POST /api/v1/counties/contains/batch -
+// assign up to 10k points to counties in one request
+func ContainsPointsBatchHandler(c echo.Context) error {
+	var req models.CountyContainsBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid JSON request body. Expected {\"points\": [{\"lat\": ..., \"lng\": ...}]}",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if len(req.Points) == 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "At least one point is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if len(req.Points) > models.MaxCountyContainsBatch {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("A batch may contain at most %d points", models.MaxCountyContainsBatch),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	for i, p := range req.Points {
+		if p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Point %d has invalid coordinates", i),
+				Code:    models.ErrCodeInvalidCoordinates,
+			})
+		}
+	}
+
+	results, err := services.County.ContainsPointsBatch(c.Request().Context(), req.Points)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to assign counties: " + err.Error(),
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	matched := 0
+	for _, r := range results {
+		if r.CountyName != nil {
+			matched++
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    results,
+		"count":   len(results),
+		"matched": matched,
+	})
+}
+
+// BoundsRequest holds a bounding box (query string or JSON body)
+type BoundsRequest struct {
+	MinLat *float64 `json:"min_lat"`
+	MinLon *float64 `json:"min_lon"`
+	MaxLat *float64 `json:"max_lat"`
+	MaxLon *float64 `json:"max_lon"`
+
+	invalidParam string
+}
+
+// parseBoundsRequest reads bounding box parameters from the query string
+func parseBoundsRequest(c echo.Context) *BoundsRequest {
+	req := &BoundsRequest{}
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"min_lat", &req.MinLat}, {"min_lon", &req.MinLon},
+		{"max_lat", &req.MaxLat}, {"max_lon", &req.MaxLon},
+	} {
+		raw := c.QueryParam(p.name)
+		if raw == "" {
+			continue
+		}
+		val, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			if req.invalidParam == "" {
+				req.invalidParam = p.name
+			}
+			continue
+		}
+		*p.dst = &val
+	}
+	return req
+}
+
+// validate returns an error message if the bounding box is incomplete or malformed
+func (b *BoundsRequest) validate() string {
+	if b.invalidParam != "" {
+		return "Invalid " + b.invalidParam + " parameter"
+	}
+	if b.MinLat == nil || b.MinLon == nil || b.MaxLat == nil || b.MaxLon == nil {
+		return "Bounding box parameters required: min_lat, min_lon, max_lat, max_lon"
+	}
+	return ""
+}
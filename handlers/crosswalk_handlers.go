@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fiveDigits reports whether code is a 5-digit ZIP code or county FIPS code
+func fiveDigits(code string) bool {
+	return len(code) == 5 && strings.Trim(code, "0123456789") == ""
+}
+
+// crosswalkZipCodes reads ?zip=, one or more comma-separated 5-digit ZIP
+// codes. ok is false when an error response has been written; the caller
+// returns err.
+func crosswalkZipCodes(c echo.Context) (zipCodes []string, ok bool, err error) {
+	seen := map[string]bool{}
+	for _, zipCode := range strings.Split(c.QueryParam("zip"), ",") {
+		zipCode = strings.TrimSpace(zipCode)
+		if zipCode == "" || seen[zipCode] {
+			continue
+		}
+		if !fiveDigits(zipCode) {
+			return nil, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Invalid ZIP code %q", zipCode),
+				Code:    models.ErrCodeInvalidZip,
+			})
+		}
+		seen[zipCode] = true
+		zipCodes = append(zipCodes, zipCode)
+	}
+
+	if len(zipCodes) == 0 {
+		return nil, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "'zip' query parameter is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if len(zipCodes) > services.MaxCrosswalkZipCodes {
+		return nil, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("At most %d ZIP codes can be mapped per request", services.MaxCrosswalkZipCodes),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	return zipCodes, true, nil
+}
+
+// ZipToCountyHandler handles GET /api/v1/crosswalk/zip-to-county?zip= - the
+// counties each ZIP code overlaps, weighted by share of population
+func ZipToCountyHandler(c echo.Context) error {
+	zipCodes, ok, err := crosswalkZipCodes(c)
+	if !ok {
+		return err
+	}
+
+	mappings, err := services.Crosswalk.ZipToCounty(c.Request().Context(), zipCodes)
+	if err != nil {
+		logging.FromContext(c).Error("ZIP to county crosswalk failed", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to map ZIP codes to counties",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    mappings,
+		Count:   len(mappings),
+	})
+}
+
+// ZipToCBSAHandler handles GET /api/v1/crosswalk/zip-to-cbsa?zip= - the
+// CBSAs each ZIP code's addresses fall in, from the HUD crosswalk
+func ZipToCBSAHandler(c echo.Context) error {
+	zipCodes, ok, err := crosswalkZipCodes(c)
+	if !ok {
+		return err
+	}
+
+	mappings, err := services.Crosswalk.ZipToCBSA(c.Request().Context(), zipCodes)
+	if err != nil {
+		logging.FromContext(c).Error("ZIP to CBSA crosswalk failed", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to map ZIP codes to CBSAs",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    mappings,
+		Count:   len(mappings),
+	})
+}
+
+// ZipCodeCountiesHandler handles GET /api/v1/geocode/:zipcode/counties -
+// the counties a ZIP code overlaps, weighted by share of population
+func ZipCodeCountiesHandler(c echo.Context) error {
+	zipCode := c.Param("zipcode")
+	if !fiveDigits(zipCode) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid ZIP code %q", zipCode),
+			Code:    models.ErrCodeInvalidZip,
+		})
+	}
+
+	mappings, err := services.Crosswalk.CountiesForZip(c.Request().Context(), zipCode)
+	if err != nil {
+		logging.FromContext(c).Error("failed to get ZIP code counties", "zip_code", zipCode, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get the ZIP code's counties",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	if mappings == nil {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "ZIP code not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+
+	limit, offset := parsePagination(c, 100, 1000)
+	total := len(mappings)
+	page := mappings[min(offset, total):min(offset+limit, total)]
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       page,
+		Count:      len(page),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
+
+// CountyZipCodesHandler handles GET /api/v1/counties/:fips/zipcodes - the
+// ZIP codes overlapping a county, largest share of population first
+func CountyZipCodesHandler(c echo.Context) error {
+	countyFIPS := c.Param("fips")
+	if !fiveDigits(countyFIPS) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "County must be a 5-digit FIPS code, e.g. 39049",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	limit, offset := parsePagination(c, 100, 1000)
+	mappings, total, err := services.Crosswalk.ZipCodesInCounty(c.Request().Context(), countyFIPS, limit, offset)
+	if err != nil {
+		logging.FromContext(c).Error("failed to get county ZIP codes", "county_fips", countyFIPS, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get the county's ZIP codes",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	if total == 0 {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "No ZIP codes found for county " + countyFIPS,
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       mappings,
+		Count:      len(mappings),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
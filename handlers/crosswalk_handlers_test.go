@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrosswalkZipCodes(t *testing.T) {
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("%05d", 43000+i)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		zipCodes []string
+		code     string
+	}{
+		{"single", "zip=43215", []string{"43215"}, ""},
+		{"list with duplicates and spaces", "zip=43215,%2043016,43215", []string{"43215", "43016"}, ""},
+		{"missing", "", nil, "INVALID_REQUEST"},
+		{"ZIP+4", "zip=43215-1234", nil, "INVALID_ZIP"},
+		{"letters", "zip=4321A", nil, "INVALID_ZIP"},
+		{"too many", "zip=" + strings.Join(tooMany, ","), nil, "INVALID_REQUEST"},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/crosswalk/zip-to-county?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			zipCodes, ok, err := crosswalkZipCodes(e.NewContext(req, rec))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.code == "", ok)
+			assert.Equal(t, tt.zipCodes, zipCodes)
+			if tt.code != "" {
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.code+`"`)
+			}
+		})
+	}
+}
+
+func TestZipCountyEndpointsRejectInvalidCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		param   string
+		value   string
+		code    string
+	}{
+		{"ZIP+4", ZipCodeCountiesHandler, "zipcode", "43215-1234", "INVALID_ZIP"},
+		{"short ZIP", ZipCodeCountiesHandler, "zipcode", "4321", "INVALID_ZIP"},
+		{"county name", CountyZipCodesHandler, "fips", "Franklin", "INVALID_REQUEST"},
+		{"state FIPS", CountyZipCodesHandler, "fips", "39", "INVALID_REQUEST"},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames(tt.param)
+			c.SetParamValues(tt.value)
+
+			assert.NoError(t, tt.handler(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"`+tt.code+`"`)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// csvFlushEvery controls how many rows are buffered before a chunk is flushed to the client
+const csvFlushEvery = 500
+
+// wantsCSV reports whether the caller asked for CSV output via ?format=csv
+func wantsCSV(c echo.Context) bool {
+	return strings.EqualFold(c.QueryParam("format"), "csv")
+}
+
+// csvStream writes CSV rows straight to the response, flushing in chunks so
+// large exports never sit in memory
+type csvStream struct {
+	c       echo.Context
+	writer  *csv.Writer
+	pending int
+}
+
+// newCSVStream writes the CSV headers and header row and returns a stream for the body
+func newCSVStream(c echo.Context, filename string, header []string) (*csvStream, error) {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	res.WriteHeader(http.StatusOK)
+
+	stream := &csvStream{c: c, writer: csv.NewWriter(res)}
+	if err := stream.Write(header); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Write writes one row, flushing the chunk to the client every csvFlushEvery rows
+func (s *csvStream) Write(record []string) error {
+	if err := s.writer.Write(record); err != nil {
+		return err
+	}
+	s.pending++
+	if s.pending >= csvFlushEvery {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush sends any buffered rows to the client
+func (s *csvStream) Flush() error {
+	s.writer.Flush()
+	s.c.Response().Flush()
+	s.pending = 0
+	return s.writer.Error()
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxCustomDatasetName bounds the name given to an uploaded address list
+const maxCustomDatasetName = 255
+
+// customDatasetErrorResponse maps custom dataset service errors to responses
+func customDatasetErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrCustomDatasetNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "Custom dataset not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrCustomAddressLimit):
+		return c.JSON(http.StatusForbidden, GeocodeResponse{
+			Success: false,
+			Error:   "Upload exceeds your plan's custom address limit. Delete an existing list or upgrade your plan.",
+			Code:    models.ErrCodeQuotaExceeded,
+		})
+	}
+
+	logging.FromContext(c).Error("custom dataset request failed", "error", err)
+	return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+		Success: false,
+		Error:   "Custom dataset request failed",
+		Code:    models.ErrCodeInternal,
+	})
+}
+
+// CreateCustomDatasetHandler handles POST /api/v1/user/datasets - upload a
+// private address list. The multipart "file" field is a CSV with latitude
+// and longitude columns or a GeoJSON file of points; "name" and
+// "field_mapping" are optional. The addresses are searched only with the
+// user's own API keys, up to the row limit of their plan.
+func CreateCustomDatasetHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "A CSV or GeoJSON file of addresses is required in the 'file' form field",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		name = file.Filename
+	}
+	if len([]rune(name)) > maxCustomDatasetName {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Name must be at most %d characters", maxCustomDatasetName),
+			Code:    models.ErrCodeValidationFailed,
+		})
+	}
+	fieldMapping, err := parseFieldMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeValidationFailed,
+		})
+	}
+
+	usage, err := services.CustomDatasets.Usage(c.Request().Context(), userID)
+	if err != nil {
+		return customDatasetErrorResponse(c, err)
+	}
+	if usage.Limit == 0 {
+		return c.JSON(http.StatusForbidden, GeocodeResponse{
+			Success: false,
+			Error:   "Your plan doesn't include custom address lists",
+			Code:    models.ErrCodePermissionDenied,
+		})
+	}
+	remaining := -1
+	if usage.Limit > 0 {
+		remaining = max(usage.Limit-usage.Used, 0)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to read uploaded file",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	defer src.Close()
+
+	addresses, skipped, err := services.CustomDatasets.ReadAddresses(src, file.Filename, fieldMapping, remaining)
+	if errors.Is(err, services.ErrCustomAddressLimit) {
+		return customDatasetErrorResponse(c, err)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	dataset, err := services.CustomDatasets.CreateDataset(c.Request().Context(), userID, name, file.Filename, addresses, skipped)
+	if err != nil {
+		return customDatasetErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    dataset,
+		Message: fmt.Sprintf("Stored %d addresses (%d rows skipped)", dataset.RowCount, dataset.SkippedRows),
+	})
+}
+
+// GetCustomDatasetsHandler handles GET /api/v1/user/datasets - the user's
+// address lists with how many rows they use of their plan's limit
+func GetCustomDatasetsHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	datasets, err := services.CustomDatasets.ListDatasets(c.Request().Context(), userID)
+	if err != nil {
+		return customDatasetErrorResponse(c, err)
+	}
+	usage, err := services.CustomDatasets.Usage(c.Request().Context(), userID)
+	if err != nil {
+		return customDatasetErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"datasets": datasets,
+			"usage":    usage,
+		},
+		Count: len(datasets),
+	})
+}
+
+// DeleteCustomDatasetHandler handles DELETE /api/v1/user/datasets/:id -
+// remove an address list and its addresses
+func DeleteCustomDatasetHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid dataset ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if err := services.CustomDatasets.DeleteDataset(c.Request().Context(), userID, id); err != nil {
+		return customDatasetErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Custom dataset deleted",
+	})
+}
+
+// customAddresses returns the API key owner's custom addresses matching an
+// address search, to lead the first page of public results. A failed
+// lookup is logged and leaves them out.
+func customAddresses(c echo.Context, params models.AddressSearchParams) []models.OhioAddress {
+	user, ok := c.Get("user").(*models.User)
+	if !ok || params.Offset > 0 {
+		return nil
+	}
+	addresses, err := services.CustomDatasets.SearchAddresses(c.Request().Context(), user.ID, params)
+	if err != nil {
+		logging.FromContext(c).Warn("failed to search custom addresses", "user_id", user.ID, "error", err)
+		return nil
+	}
+	return addresses
+}
+
+// mergeNearbyCustomAddresses merges the API key owner's custom addresses near
+// a location into the public ones by distance, keeping the nearest limit, and
+// returns how many of those are custom
+func mergeNearbyCustomAddresses(c echo.Context, addresses []models.NearbyAddress, lat, lng, radius float64, limit int) ([]models.NearbyAddress, int) {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return addresses, 0
+	}
+	custom, err := services.CustomDatasets.NearbyAddresses(c.Request().Context(), user.ID, lat, lng, radius, limit)
+	if err != nil {
+		logging.FromContext(c).Warn("failed to find nearby custom addresses", "user_id", user.ID, "error", err)
+		return addresses, 0
+	}
+	if len(custom) == 0 {
+		return addresses, 0
+	}
+
+	merged := append(custom, addresses...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].DistanceMeters < merged[j].DistanceMeters
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	customCount := 0
+	for _, addr := range merged {
+		if isCustomAddress(addr.OhioAddress) {
+			customCount++
+		}
+	}
+	return merged, customCount
+}
+
+// isCustomAddress reports whether a result came from a user's custom list
+func isCustomAddress(addr models.OhioAddress) bool {
+	return addr.Match != nil && addr.Match.Source == models.MatchSourceCustom
+}
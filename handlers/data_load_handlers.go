@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListDataLoadsHandler lists the reference datasets and their latest load (admin endpoint)
+func ListDataLoadsHandler(c echo.Context) error {
+	loads := services.DataLoads.List()
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    loads,
+		Count:   len(loads),
+	})
+}
+
+// StartDataLoadHandler starts loading a reference dataset in the background
+// (admin endpoint). Loads are idempotent; dry_run=true reports what would
+// change without applying it. Poll GetDataLoadHandler for progress.
+func StartDataLoadHandler(c echo.Context) error {
+	dataset := c.Param("dataset")
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	progress, err := services.DataLoads.Start(dataset, dryRun)
+	if errors.Is(err, services.ErrUnknownDataset) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "Unknown dataset: " + dataset,
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if errors.Is(err, services.ErrDataLoadRunning) {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "A load of " + dataset + " is already running",
+			Code:    models.ErrCodeConflict,
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to start data load",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	message := "Data load started"
+	if dryRun {
+		message = "Dry run started: no changes will be applied"
+	} else {
+		recordAudit(c, models.AuditDataLoaded, "dataset", dataset, nil)
+	}
+	return c.JSON(http.StatusAccepted, GeocodeResponse{
+		Success: true,
+		Data:    progress,
+		Message: message,
+	})
+}
+
+// GetDataLoadHandler reports the progress of a dataset's latest load (admin endpoint)
+func GetDataLoadHandler(c echo.Context) error {
+	dataset := c.Param("dataset")
+
+	progress, err := services.DataLoads.Progress(dataset)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "Unknown dataset: " + dataset,
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    progress,
+	})
+}
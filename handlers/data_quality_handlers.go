@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetDataQualityReportHandler returns the latest integrity check report, or a
+// specific run's report with ?run_id= (admin only)
+func GetDataQualityReportHandler(c echo.Context) error {
+	var err error
+	var runID int
+	if runIDStr := c.QueryParam("run_id"); runIDStr != "" {
+		runID, err = strconv.Atoi(runIDStr)
+		if err != nil || runID <= 0 {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   "Invalid run_id",
+				Code:    models.ErrCodeInvalidRequest,
+			})
+		}
+	}
+
+	report, err := services.Integrity.GetLatestReport()
+	if runID > 0 {
+		report, err = services.Integrity.GetReport(runID)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.JSON(http.StatusNotFound, GeocodeResponse{
+				Success: false,
+				Error:   "No data quality report found",
+				Code:    models.ErrCodeNotFound,
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get data quality report",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetIntegrityRunsHandler lists recent integrity check runs (admin only)
+func GetIntegrityRunsHandler(c echo.Context) error {
+	limit := 30
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 365 {
+			limit = l
+		}
+	}
+
+	runs, err := services.Integrity.GetRuns(limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get integrity check runs",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    runs,
+		Count:   len(runs),
+	})
+}
+
+// RunIntegrityCheckHandler runs the integrity check immediately and returns
+// its report (admin only)
+func RunIntegrityCheckHandler(c echo.Context) error {
+	report, err := services.Integrity.RunCheck(c.Request().Context(), "manual")
+	if err != nil {
+		if strings.Contains(err.Error(), "already running") {
+			return c.JSON(http.StatusConflict, GeocodeResponse{
+				Success: false,
+				Error:   "An integrity check is already running",
+				Code:    models.ErrCodeConflict,
+			})
+		}
+		logging.FromContext(c).Error("integrity check failed", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Integrity check failed",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    report,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"geocoding-api/database"
+	"geocoding-api/logging"
 	"geocoding-api/models"
 	"geocoding-api/services"
 
@@ -37,12 +39,24 @@ func checkDatasetsTableExists() bool {
 	return err == nil && exists
 }
 
+// DatasetErrorResponse is the error envelope for dataset endpoints that need
+// to return context alongside the message
+type DatasetErrorResponse struct {
+	Success           bool            `json:"success"`
+	Error             string          `json:"error"`
+	Code              string          `json:"code,omitempty"`
+	ExistingDataset   *models.Dataset `json:"existing_dataset,omitempty"`
+	MigrationsRunning *bool           `json:"migrations_running,omitempty"`
+}
+
 // migrationsPendingResponse returns a standard response when migrations are still running
 func migrationsPendingResponse(c echo.Context) error {
-	return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
-		"success": false,
-		"error":   "Database migrations are still in progress. Please wait a moment and try again.",
-		"migrations_running": database.MigrationRunning,
+	running := database.MigrationRunning
+	return c.JSON(http.StatusServiceUnavailable, DatasetErrorResponse{
+		Success:           false,
+		Error:             "Database migrations are still in progress. Please wait a moment and try again.",
+		Code:              models.ErrCodeUnavailable,
+		MigrationsRunning: &running,
 	})
 }
 
@@ -59,82 +73,122 @@ func UploadDatasetHandler(c echo.Context) error {
 	county := c.FormValue("county")
 
 	if name == "" || state == "" || county == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "name, state, and county are required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "name, state, and county are required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	// Check for duplicate dataset
-	datasetService := services.NewDatasetService(services.GetDB())
-	exists, existingDataset, err := datasetService.CheckDatasetExists(state, county)
+	fieldMapping, err := parseFieldMapping(c)
 	if err != nil {
-		fmt.Printf("[Upload] Warning: Failed to check for existing dataset: %v\n", err)
-	} else if exists && existingDataset != nil {
-		return c.JSON(http.StatusConflict, map[string]interface{}{
-			"success": false,
-			"error":   fmt.Sprintf("Dataset for %s County, %s already exists (ID: %d, status: %s, %d records)", county, state, existingDataset.ID, existingDataset.Status, existingDataset.RecordCount),
-			"existing_dataset": existingDataset,
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	// Get uploaded file
 	file, err := c.FormFile("file")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "file is required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "file is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if err := services.CheckDatasetExtension(file.Filename); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	// Get user ID from context
 	userID, ok := c.Get("user_id").(int)
 	if !ok {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to get user ID",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to get user ID",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	// Check for an in-flight or existing upload of the same county
+	logger := logging.FromContext(c)
+	release, conflict, err := claimDatasetUpload(logger, state, county, c.FormValue("replace") == "true")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	if conflict != nil {
+		return c.JSON(http.StatusConflict, DatasetErrorResponse{
+			Success:         false,
+			Error:           conflict.Message,
+			Code:            models.ErrCodeConflict,
+			ExistingDataset: conflict.Existing,
 		})
 	}
+	defer release()
 
 	// Save and create dataset
-	dataset, err := saveUploadedFile(file, name, state, county, userID)
+	dataset, err := saveUploadedFile(logger, file, name, state, county, userID, fieldMapping)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   err.Error(),
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
+	auditDatasetUpload(c, dataset, c.FormValue("replace") == "true")
+
 	// Process the dataset asynchronously
 	go func() {
 		datasetSvc := services.NewDatasetService(services.GetDB())
 		if err := datasetSvc.ProcessGeoJSONDataset(dataset.ID); err != nil {
-			fmt.Printf("Error processing dataset %d: %v\n", dataset.ID, err)
+			logger.Error("failed to process dataset", "dataset_id", dataset.ID, "error", err)
 		}
 	}()
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    dataset,
-		"message": "File uploaded successfully and processing started",
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    dataset,
+		Message: "File uploaded successfully and processing started",
 	})
 }
 
 // BatchUploadResult represents the result of uploading a single file in a batch
 type BatchUploadResult struct {
-	Filename string          `json:"filename"`
-	Success  bool            `json:"success"`
-	Error    string          `json:"error,omitempty"`
-	Dataset  *models.Dataset `json:"dataset,omitempty"`
+	Filename        string          `json:"filename"`
+	Success         bool            `json:"success"`
+	Error           string          `json:"error,omitempty"`
+	Dataset         *models.Dataset `json:"dataset,omitempty"`
+	ExistingDataset *models.Dataset `json:"existing_dataset,omitempty"`
+}
+
+// BatchUploadSummary is the data returned by the bulk upload endpoint
+type BatchUploadSummary struct {
+	TotalFiles   int                 `json:"total_files"`
+	SuccessCount int                 `json:"success_count"`
+	FailCount    int                 `json:"fail_count"`
+	Results      []BatchUploadResult `json:"results"`
+	Message      string              `json:"message"`
 }
 
 // UploadMultipleHandler handles multiple file uploads with concurrent processing
 func UploadMultipleHandler(c echo.Context) error {
+	logger := logging.FromContext(c).With("op", "bulk_upload")
+
 	// Recover from any panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Printf("[BulkUpload] PANIC recovered: %v\n", r)
-			fmt.Printf("[BulkUpload] Stack trace:\n%s\n", debug.Stack())
+			logger.Error("panic recovered", "panic", r, "stack", string(debug.Stack()))
 		}
 	}()
 
@@ -143,62 +197,70 @@ func UploadMultipleHandler(c echo.Context) error {
 		return migrationsPendingResponse(c)
 	}
 
-	fmt.Println("[BulkUpload] Starting bulk upload request")
-	fmt.Printf("[BulkUpload] Content-Length: %d\n", c.Request().ContentLength)
-	fmt.Printf("[BulkUpload] Content-Type: %s\n", c.Request().Header.Get("Content-Type"))
-	
 	// Get form values
 	state := c.FormValue("state")
-	fmt.Printf("[BulkUpload] State: %s\n", state)
+	logger.Info("starting bulk upload", "state", state,
+		"content_length", c.Request().ContentLength, "content_type", c.Request().Header.Get("Content-Type"))
 
 	if state == "" {
-		fmt.Println("[BulkUpload] ERROR: state is required")
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "state is required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "state is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	fieldMapping, err := parseFieldMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
+	replace := c.FormValue("replace") == "true"
 
 	// Get user ID from context
 	userID, ok := c.Get("user_id").(int)
 	if !ok {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to get user ID",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to get user ID",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
 	// Get the multipart form
-	fmt.Println("[BulkUpload] Parsing multipart form...")
 	form, err := c.MultipartForm()
 	if err != nil {
-		fmt.Printf("[BulkUpload] ERROR parsing form: %v\n", err)
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "failed to parse multipart form: " + err.Error(),
+		logger.Warn("failed to parse multipart form", "error", err)
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "failed to parse multipart form: " + err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	files := form.File["files"]
-	fmt.Printf("[BulkUpload] Found %d files in form\n", len(files))
 	if len(files) == 0 {
-		fmt.Println("[BulkUpload] ERROR: no files provided")
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "no files provided",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "no files provided",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 	
 	// Log all file names
 	for i, f := range files {
-		fmt.Printf("[BulkUpload] File %d: %s (size: %d bytes)\n", i+1, f.Filename, f.Size)
+		logger.Debug("received file", "index", i+1, "filename", f.Filename, "size", f.Size)
 	}
 
 	// Ensure upload directory exists
 	if err := services.EnsureUploadDirectory(); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to create upload directory",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to create upload directory",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -213,24 +275,16 @@ func UploadMultipleHandler(c echo.Context) error {
 	results := make(chan BatchUploadResult, len(files))
 
 	// Start workers
-	fmt.Printf("[BulkUpload] Starting %d workers...\n", maxWorkers)
+	logger.Debug("starting upload workers", "files", len(files), "workers", maxWorkers)
 	var wg sync.WaitGroup
 	for i := 0; i < maxWorkers; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			fmt.Printf("[BulkUpload] Worker %d started\n", workerID)
+			workerLogger := logger.With("worker", workerID)
 			for file := range jobs {
-				fmt.Printf("[BulkUpload] Worker %d processing: %s\n", workerID, file.Filename)
-				result := processUploadedFile(file, state, userID)
-				if result.Success {
-					fmt.Printf("[BulkUpload] Worker %d SUCCESS: %s -> Dataset ID %d\n", workerID, file.Filename, result.Dataset.ID)
-				} else {
-					fmt.Printf("[BulkUpload] Worker %d FAILED: %s -> %s\n", workerID, file.Filename, result.Error)
-				}
-				results <- result
+				results <- processUploadedFile(workerLogger, file, state, userID, fieldMapping, replace)
 			}
-			fmt.Printf("[BulkUpload] Worker %d finished\n", workerID)
 		}(i)
 	}
 
@@ -247,7 +301,6 @@ func UploadMultipleHandler(c echo.Context) error {
 	}()
 
 	// Collect results
-	fmt.Println("[BulkUpload] Collecting results...")
 	uploadResults := make([]BatchUploadResult, 0, len(files))
 	successCount := 0
 	failCount := 0
@@ -259,31 +312,28 @@ func UploadMultipleHandler(c echo.Context) error {
 			successCount++
 			if result.Dataset != nil {
 				datasetIDs = append(datasetIDs, result.Dataset.ID)
+				auditDatasetUpload(c, result.Dataset, replace)
 			}
 		} else {
 			failCount++
 		}
 	}
 
-	fmt.Printf("[BulkUpload] Upload complete: %d success, %d failed out of %d total\n", successCount, failCount, len(files))
+	logger.Info("bulk upload complete", "success", successCount, "failed", failCount, "total", len(files), "dataset_ids", datasetIDs)
 
 	// Start concurrent processing for all successfully uploaded datasets
 	if len(datasetIDs) > 0 {
-		fmt.Printf("[BulkUpload] Starting background processing for %d datasets: %v\n", len(datasetIDs), datasetIDs)
-		go processDatasetsConcurrently(datasetIDs)
-	} else {
-		fmt.Println("[BulkUpload] No datasets to process")
-	}
-
-	fmt.Println("[BulkUpload] Returning response to client")
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"total_files":   len(files),
-			"success_count": successCount,
-			"fail_count":    failCount,
-			"results":       uploadResults,
-			"message":       fmt.Sprintf("Uploaded %d of %d files. Processing started.", successCount, len(files)),
+		go processDatasetsConcurrently(logger, datasetIDs)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: BatchUploadSummary{
+			TotalFiles:   len(files),
+			SuccessCount: successCount,
+			FailCount:    failCount,
+			Results:      uploadResults,
+			Message:      fmt.Sprintf("Uploaded %d of %d files. Processing started.", successCount, len(files)),
 		},
 	})
 }
@@ -305,11 +355,12 @@ type UploadProgressEvent struct {
 
 // UploadMultipleStreamHandler handles multiple file uploads with SSE streaming progress
 func UploadMultipleStreamHandler(c echo.Context) error {
+	logger := logging.FromContext(c).With("op", "bulk_upload_stream")
+
 	// Recover from any panics
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Printf("[BulkUploadStream] PANIC recovered: %v\n", r)
-			fmt.Printf("[BulkUploadStream] Stack trace:\n%s\n", debug.Stack())
+			logger.Error("panic recovered", "panic", r, "stack", string(debug.Stack()))
 		}
 	}()
 
@@ -318,48 +369,62 @@ func UploadMultipleStreamHandler(c echo.Context) error {
 		return migrationsPendingResponse(c)
 	}
 
-	fmt.Println("[BulkUploadStream] Starting streaming bulk upload request")
-
 	// Get form values
 	state := c.FormValue("state")
+	logger.Info("starting streaming bulk upload", "state", state)
 	if state == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "state is required",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "state is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
+	fieldMapping, err := parseFieldMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	replace := c.FormValue("replace") == "true"
+
 	// Get user ID from context
 	userID, ok := c.Get("user_id").(int)
 	if !ok {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to get user ID",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to get user ID",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
 	// Parse multipart form
 	form, err := c.MultipartForm()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "failed to parse multipart form: " + err.Error(),
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "failed to parse multipart form: " + err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	files := form.File["files"]
 	if len(files) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "no files provided",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "no files provided",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	// Ensure upload directory exists
 	if err := services.EnsureUploadDirectory(); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to create upload directory",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to create upload directory",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -402,12 +467,13 @@ func UploadMultipleStreamHandler(c echo.Context) error {
 		})
 
 		// Process the file
-		result := processUploadedFile(file, state, userID)
+		result := processUploadedFile(logger, file, state, userID, fieldMapping, replace)
 		
 		if result.Success {
 			successCount++
 			if result.Dataset != nil {
 				datasetIDs = append(datasetIDs, result.Dataset.ID)
+				auditDatasetUpload(c, result.Dataset, replace)
 			}
 			sendEvent(UploadProgressEvent{
 				Type:         "file_saved",
@@ -442,7 +508,7 @@ func UploadMultipleStreamHandler(c echo.Context) error {
 			Type:    "processing_started",
 			Message: fmt.Sprintf("Starting background processing for %d datasets", len(datasetIDs)),
 		})
-		go processDatasetsConcurrently(datasetIDs)
+		go processDatasetsConcurrently(logger, datasetIDs)
 	}
 
 	// Send completion event
@@ -455,48 +521,60 @@ func UploadMultipleStreamHandler(c echo.Context) error {
 		Message:      fmt.Sprintf("Upload complete: %d success, %d failed", successCount, failCount),
 	})
 
-	fmt.Printf("[BulkUploadStream] Complete: %d success, %d failed\n", successCount, failCount)
+	logger.Info("streaming bulk upload complete", "success", successCount, "failed", failCount)
 	return nil
 }
 
 // processUploadedFile handles a single file upload in the batch
-func processUploadedFile(file *multipart.FileHeader, state string, userID int) BatchUploadResult {
+func processUploadedFile(logger *slog.Logger, file *multipart.FileHeader, state string, userID int, fieldMapping models.DatasetFieldMapping, replace bool) BatchUploadResult {
 	filename := file.Filename
-	fmt.Printf("[ProcessFile] Processing: %s\n", filename)
+	logger = logger.With("filename", filename)
+
+	if err := services.CheckDatasetExtension(filename); err != nil {
+		return BatchUploadResult{
+			Filename: filename,
+			Success:  false,
+			Error:    err.Error(),
+		}
+	}
 	
 	// Extract county name from filename (e.g., "adams-addresses-county.geojson.gz" -> "Adams")
 	county := extractCountyFromFilename(filename)
 	if county == "" {
-		fmt.Printf("[ProcessFile] ERROR: could not extract county from filename: %s\n", filename)
+		logger.Warn("could not extract county from filename")
 		return BatchUploadResult{
 			Filename: filename,
 			Success:  false,
 			Error:    "could not extract county name from filename",
 		}
 	}
-	fmt.Printf("[ProcessFile] Extracted county: %s from %s\n", county, filename)
 
-	// Check for duplicate dataset
-	datasetService := services.NewDatasetService(services.GetDB())
-	exists, existingDataset, err := datasetService.CheckDatasetExists(state, county)
+	// Check for an in-flight or existing upload of the same county
+	release, conflict, err := claimDatasetUpload(logger, state, county, replace)
 	if err != nil {
-		fmt.Printf("[ProcessFile] Warning: Failed to check for existing dataset: %v\n", err)
-	} else if exists && existingDataset != nil {
-		fmt.Printf("[ProcessFile] DUPLICATE: %s County, %s already exists (ID: %d)\n", county, state, existingDataset.ID)
 		return BatchUploadResult{
 			Filename: filename,
 			Success:  false,
-			Error:    fmt.Sprintf("Dataset already exists (ID: %d, status: %s, %d records)", existingDataset.ID, existingDataset.Status, existingDataset.RecordCount),
+			Error:    err.Error(),
 		}
 	}
+	if conflict != nil {
+		logger.Info("skipping duplicate dataset", "county", county, "state", state)
+		return BatchUploadResult{
+			Filename:        filename,
+			Success:         false,
+			Error:           conflict.Message,
+			ExistingDataset: conflict.Existing,
+		}
+	}
+	defer release()
 
 	// Generate name from filename
 	name := fmt.Sprintf("%s County Addresses", strings.Title(county))
-	fmt.Printf("[ProcessFile] Saving file as: %s\n", name)
 
-	dataset, err := saveUploadedFile(file, name, state, county, userID)
+	dataset, err := saveUploadedFile(logger, file, name, state, county, userID, fieldMapping)
 	if err != nil {
-		fmt.Printf("[ProcessFile] ERROR saving file %s: %v\n", filename, err)
+		logger.Warn("failed to save uploaded file", "error", err)
 		return BatchUploadResult{
 			Filename: filename,
 			Success:  false,
@@ -504,7 +582,6 @@ func processUploadedFile(file *multipart.FileHeader, state string, userID int) B
 		}
 	}
 
-	fmt.Printf("[ProcessFile] SUCCESS: %s saved as dataset ID %d\n", filename, dataset.ID)
 	return BatchUploadResult{
 		Filename: filename,
 		Success:  true,
@@ -512,6 +589,58 @@ func processUploadedFile(file *multipart.FileHeader, state string, userID int) B
 	}
 }
 
+// uploadConflict explains why an upload for a county was refused
+type uploadConflict struct {
+	Message  string
+	Existing *models.Dataset
+}
+
+// claimDatasetUpload reserves state/county for the duration of an upload and
+// checks for an existing dataset. With replace the existing dataset and its
+// addresses are deleted; otherwise it is reported as a conflict. The caller
+// must call release once the new dataset row has been created.
+func claimDatasetUpload(logger *slog.Logger, state, county string, replace bool) (func(), *uploadConflict, error) {
+	release, ok := services.ReserveUpload(state, county)
+	if !ok {
+		return nil, &uploadConflict{
+			Message: fmt.Sprintf("An upload for %s County, %s is already in progress", county, state),
+		}, nil
+	}
+
+	datasetService := services.NewDatasetService(services.GetDB())
+	exists, existingDataset, err := datasetService.CheckDatasetExists(state, county)
+	if err != nil {
+		logger.Warn("failed to check for existing dataset", "state", state, "county", county, "error", err)
+		return release, nil, nil
+	}
+	if !exists || existingDataset == nil {
+		return release, nil, nil
+	}
+
+	if !replace {
+		release()
+		return nil, &uploadConflict{
+			Message:  fmt.Sprintf("Dataset for %s County, %s already exists (ID: %d, status: %s, %d records). Set replace=true to replace it", county, state, existingDataset.ID, existingDataset.Status, existingDataset.RecordCount),
+			Existing: existingDataset,
+		}, nil
+	}
+	if existingDataset.Status == "validating" || existingDataset.Status == "processing" {
+		release()
+		return nil, &uploadConflict{
+			Message:  fmt.Sprintf("Dataset for %s County, %s is still processing (ID: %d) and cannot be replaced yet", county, state, existingDataset.ID),
+			Existing: existingDataset,
+		}, nil
+	}
+
+	if err := datasetService.ReplaceDatasets(state, county); err != nil {
+		release()
+		logger.Error("failed to replace existing dataset", "state", state, "county", county, "dataset_id", existingDataset.ID, "error", err)
+		return nil, nil, fmt.Errorf("failed to replace existing dataset")
+	}
+	logger.Info("replacing existing dataset", "state", state, "county", county, "dataset_id", existingDataset.ID)
+	return release, nil, nil
+}
+
 // extractCountyFromFilename extracts county name from common filename patterns
 func extractCountyFromFilename(filename string) string {
 	// Remove extension(s)
@@ -519,6 +648,10 @@ func extractCountyFromFilename(filename string) string {
 	name = strings.TrimSuffix(name, ".gz")
 	name = strings.TrimSuffix(name, ".geojson")
 	name = strings.TrimSuffix(name, ".json")
+	name = strings.TrimSuffix(name, ".ndjson")
+	name = strings.TrimSuffix(name, ".csv")
+	name = strings.TrimSuffix(name, ".zip")
+	name = strings.TrimSuffix(name, ".gpkg")
 	
 	// Common patterns:
 	// "adams-addresses-county" -> "adams"
@@ -539,29 +672,36 @@ func extractCountyFromFilename(filename string) string {
 	return ""
 }
 
+// parseFieldMapping reads the optional field_mapping form value, a JSON object
+// of address field to property name such as {"house_number":"ADDR_NUM"}
+func parseFieldMapping(c echo.Context) (models.DatasetFieldMapping, error) {
+	raw := c.FormValue("field_mapping")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var mapping models.DatasetFieldMapping
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		return nil, fmt.Errorf("field_mapping must be a JSON object of address field to property name")
+	}
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
 // saveUploadedFile saves a file and creates a dataset record
-func saveUploadedFile(file *multipart.FileHeader, name, state, county string, userID int) (*models.Dataset, error) {
-	fmt.Printf("[SaveFile] Starting save for: %s (state=%s, county=%s)\n", file.Filename, state, county)
+func saveUploadedFile(logger *slog.Logger, file *multipart.FileHeader, name, state, county string, userID int, fieldMapping models.DatasetFieldMapping) (*models.Dataset, error) {
+	logger = logger.With("filename", file.Filename, "state", state, "county", county)
 	
 	// Validate file type
-	allowedExtensions := []string{".geojson", ".json", ".gz"}
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	isValid := false
-	for _, allowed := range allowedExtensions {
-		if ext == allowed || strings.HasSuffix(file.Filename, ".geojson.gz") {
-			isValid = true
-			break
-		}
-	}
-
-	if !isValid {
-		fmt.Printf("[SaveFile] ERROR: invalid file extension: %s\n", ext)
-		return nil, fmt.Errorf("file must be .geojson, .json, or .geojson.gz")
+	if err := services.CheckDatasetExtension(file.Filename); err != nil {
+		return nil, err
 	}
 
 	// Ensure upload directory exists
 	if err := services.EnsureUploadDirectory(); err != nil {
-		fmt.Printf("[SaveFile] ERROR creating upload directory: %v\n", err)
+		logger.Error("failed to create upload directory", "error", err)
 		return nil, fmt.Errorf("failed to create upload directory: %w", err)
 	}
 
@@ -570,19 +710,18 @@ func saveUploadedFile(file *multipart.FileHeader, name, state, county string, us
 	sanitizedName := strings.ReplaceAll(name, " ", "_")
 	filename := fmt.Sprintf("%d_%s_%s_%s%s", timestamp, state, county, sanitizedName, filepath.Ext(file.Filename))
 	destPath := filepath.Join(services.UploadDirectory, filename)
-	fmt.Printf("[SaveFile] Destination path: %s\n", destPath)
 
 	// Save file
 	src, err := file.Open()
 	if err != nil {
-		fmt.Printf("[SaveFile] ERROR opening uploaded file: %v\n", err)
+		logger.Error("failed to open uploaded file", "error", err)
 		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
 	}
 	defer src.Close()
 
 	dest, err := os.Create(destPath)
 	if err != nil {
-		fmt.Printf("[SaveFile] ERROR creating destination file: %v\n", err)
+		logger.Error("failed to create destination file", "path", destPath, "error", err)
 		return nil, fmt.Errorf("failed to create destination file: %w", err)
 	}
 	defer dest.Close()
@@ -592,50 +731,46 @@ func saveUploadedFile(file *multipart.FileHeader, name, state, county string, us
 	written, err := io.CopyBuffer(dest, src, buf)
 	if err != nil {
 		os.Remove(destPath)
-		fmt.Printf("[SaveFile] ERROR copying file: %v\n", err)
+		logger.Error("failed to copy uploaded file", "path", destPath, "error", err)
 		return nil, fmt.Errorf("failed to save file: %w", err)
 	}
 	
 	// Sync to ensure data is written to disk
 	if err := dest.Sync(); err != nil {
-		fmt.Printf("[SaveFile] WARNING: failed to sync file: %v\n", err)
-	}
-	fmt.Printf("[SaveFile] Written %d bytes to %s\n", written, destPath)
-
-	// Determine file type
-	fileType := "geojson"
-	if strings.Contains(file.Filename, ".json") && !strings.Contains(file.Filename, ".geojson") {
-		fileType = "json"
+		logger.Warn("failed to sync file", "path", destPath, "error", err)
 	}
+	logger.Debug("saved uploaded file", "path", destPath, "bytes", written)
 
 	// Create dataset record
-	fmt.Printf("[SaveFile] Creating dataset record in database...\n")
 	datasetService := services.NewDatasetService(services.GetDB())
 	dataset := &models.Dataset{
-		Name:        name,
-		State:       strings.ToUpper(state),
-		County:      strings.Title(strings.ToLower(county)),
-		FileType:    fileType,
-		FilePath:    destPath,
-		FileSize:    written,
-		RecordCount: 0,
-		Status:      "pending",
-		UploadedBy:  userID,
-		UploadedAt:  time.Now(),
+		Name:         name,
+		State:        strings.ToUpper(state),
+		County:       strings.Title(strings.ToLower(county)),
+		FileType:     services.DatasetFileType(file.Filename),
+		FilePath:     destPath,
+		FileSize:     written,
+		RecordCount:  0,
+		Status:       "pending",
+		UploadedBy:   userID,
+		UploadedAt:   time.Now(),
+		FieldMapping: fieldMapping,
 	}
 
 	if err := datasetService.CreateDataset(dataset); err != nil {
 		os.Remove(destPath)
-		fmt.Printf("[SaveFile] ERROR creating dataset record: %v\n", err)
+		logger.Error("failed to create dataset record", "error", err)
 		return nil, fmt.Errorf("failed to create dataset record: %w", err)
 	}
 
-	fmt.Printf("[SaveFile] SUCCESS: Created dataset ID %d for %s\n", dataset.ID, file.Filename)
+	logger.Info("created dataset", "dataset_id", dataset.ID, "bytes", written)
 	return dataset, nil
 }
 
 // processDatasetsConcurrently processes multiple datasets using a worker pool
-func processDatasetsConcurrently(datasetIDs []int) {
+func processDatasetsConcurrently(logger *slog.Logger, datasetIDs []int) {
+	logger.Info("starting background dataset processing", "datasets", len(datasetIDs))
+
 	// Use a worker pool with limited concurrency
 	maxWorkers := 4
 	if len(datasetIDs) < maxWorkers {
@@ -653,11 +788,11 @@ func processDatasetsConcurrently(datasetIDs []int) {
 			datasetService := services.NewDatasetService(services.GetDB())
 			
 			for datasetID := range jobs {
-				fmt.Printf("[Worker %d] Processing dataset %d\n", workerID, datasetID)
+				start := time.Now()
 				if err := datasetService.ProcessGeoJSONDataset(datasetID); err != nil {
-					fmt.Printf("[Worker %d] Error processing dataset %d: %v\n", workerID, datasetID, err)
+					logger.Error("failed to process dataset", "worker", workerID, "dataset_id", datasetID, "error", err)
 				} else {
-					fmt.Printf("[Worker %d] Completed dataset %d\n", workerID, datasetID)
+					logger.Info("processed dataset", "worker", workerID, "dataset_id", datasetID, "duration_ms", time.Since(start).Milliseconds())
 				}
 			}
 		}(i)
@@ -671,7 +806,81 @@ func processDatasetsConcurrently(datasetIDs []int) {
 
 	// Wait for completion
 	wg.Wait()
-	fmt.Printf("All %d datasets processed\n", len(datasetIDs))
+	logger.Info("all datasets processed", "datasets", len(datasetIDs))
+}
+
+// ValidateDatasetHandler inspects an uploaded file without importing it so
+// field-mapping problems surface before a long import. Only the first max_mb
+// megabytes (default 16) are read.
+func ValidateDatasetHandler(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "file is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if err := services.CheckDatasetExtension(file.Filename); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	fieldMapping, err := parseFieldMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	maxBytes := int64(services.DefaultValidationBytes)
+	if maxMBStr := c.FormValue("max_mb"); maxMBStr != "" {
+		maxMB, err := strconv.Atoi(maxMBStr)
+		if err != nil || maxMB <= 0 || int64(maxMB)<<20 > services.MaxValidationBytes {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   fmt.Sprintf("max_mb must be between 1 and %d", services.MaxValidationBytes>>20),
+				Code:    models.ErrCodeInvalidRequest,
+			})
+		}
+		maxBytes = int64(maxMB) << 20
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to open uploaded file",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+	defer src.Close()
+
+	datasetService := services.NewDatasetService(services.GetDB())
+	var validation *models.DatasetValidation
+	if services.NeedsConversion(file.Filename) {
+		validation, err = datasetService.ValidateConvertedFile(src, file.Filename, maxBytes, fieldMapping)
+	} else {
+		validation, err = datasetService.ValidateDatasetFile(src, file.Filename, file.Size, maxBytes, fieldMapping)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    validation,
+	})
 }
 
 // GetDatasetsHandler lists all datasets with optional filtering
@@ -684,39 +893,27 @@ func GetDatasetsHandler(c echo.Context) error {
 	state := c.QueryParam("state")
 	status := c.QueryParam("status")
 	
-	limitStr := c.QueryParam("limit")
-	limit := 50
-	if limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-			limit = parsed
-		}
-	}
-
-	offsetStr := c.QueryParam("offset")
-	offset := 0
-	if offsetStr != "" {
-		if parsed, err := strconv.Atoi(offsetStr); err == nil && parsed >= 0 {
-			offset = parsed
-		}
-	}
+	limit, offset := parsePagination(c, 50, 500)
 
 	datasetService := services.NewDatasetService(services.GetDB())
 	datasets, total, err := datasetService.GetDatasets(state, status, limit, offset)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to get datasets",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to get datasets",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"datasets": datasets,
-			"total":    total,
-			"limit":    limit,
-			"offset":   offset,
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: models.DatasetListResponse{
+			Datasets: datasets,
+			Total:    total,
+			Limit:    limit,
+			Offset:   offset,
 		},
+		Pagination: paginate(c, total, limit, offset),
 	})
 }
 
@@ -725,24 +922,26 @@ func GetDatasetHandler(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "invalid dataset ID",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "invalid dataset ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	datasetService := services.NewDatasetService(services.GetDB())
 	dataset, err := datasetService.GetDatasetByID(id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "dataset not found",
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "dataset not found",
+			Code:    models.ErrCodeNotFound,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    dataset,
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    dataset,
 	})
 }
 
@@ -751,23 +950,27 @@ func DeleteDatasetHandler(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "invalid dataset ID",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "invalid dataset ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	datasetService := services.NewDatasetService(services.GetDB())
 	if err := datasetService.DeleteDataset(id); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to delete dataset",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to delete dataset",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "dataset deleted successfully",
+	recordAudit(c, models.AuditDatasetDeleted, "dataset", idStr, nil)
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "dataset deleted successfully",
 	})
 }
 
@@ -776,38 +979,66 @@ func ReprocessDatasetHandler(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "invalid dataset ID",
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "invalid dataset ID",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
 	datasetService := services.NewDatasetService(services.GetDB())
 	dataset, err := datasetService.GetDatasetByID(id)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"success": false,
-			"error":   "dataset not found",
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "dataset not found",
+			Code:    models.ErrCodeNotFound,
 		})
 	}
 
-	if dataset.Status == "processing" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"success": false,
-			"error":   "dataset is already processing",
+	if dataset.Status == "validating" || dataset.Status == "processing" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "dataset is already processing",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
+	// A new field mapping replaces the stored one before reprocessing
+	fieldMapping, err := parseFieldMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if fieldMapping != nil {
+		if err := datasetService.UpdateDatasetFieldMapping(id, fieldMapping); err != nil {
+			return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+				Success: false,
+				Error:   "failed to update field mapping",
+				Code:    models.ErrCodeInternal,
+			})
+		}
+	}
+
+	recordAudit(c, models.AuditDatasetReprocessed, "dataset", idStr, map[string]interface{}{
+		"name":                  dataset.Name,
+		"field_mapping_changed": fieldMapping != nil,
+	})
+
 	// Process the dataset asynchronously
+	logger := logging.FromContext(c)
 	go func() {
 		if err := datasetService.ProcessGeoJSONDataset(id); err != nil {
-			fmt.Printf("Error reprocessing dataset %d: %v\n", id, err)
+			logger.Error("failed to reprocess dataset", "dataset_id", id, "error", err)
 		}
 	}()
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "dataset reprocessing started",
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "dataset reprocessing started",
 	})
 }
 
@@ -821,14 +1052,15 @@ func GetDatasetStatsHandler(c echo.Context) error {
 	datasetService := services.NewDatasetService(services.GetDB())
 	stats, err := datasetService.GetDatasetStats()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"success": false,
-			"error":   "failed to get dataset statistics",
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "failed to get dataset statistics",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    stats,
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    stats,
 	})
 }
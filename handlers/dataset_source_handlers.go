@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetDatasetSourcesHandler handles GET /api/v1/admin/datasets/sources - the
+// OpenAddresses sources tracked by DATASET_REFRESH_SOURCES, with the outcome
+// of each one's last check and when it is next due (admin endpoint)
+func GetDatasetSourcesHandler(c echo.Context) error {
+	sources, err := services.SourceRefresh.List(c.Request().Context())
+	if err != nil {
+		logging.FromContext(c).Error("failed to list dataset sources", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to list dataset sources",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    sources,
+		Count:   len(sources),
+	})
+}
+
+// RefreshDatasetSourcesHandler handles POST
+// /api/v1/admin/datasets/sources/refresh - check tracked sources for new
+// data now, in the background: one county, every county of a state, or
+// every source when the body is empty (admin endpoint)
+func RefreshDatasetSourcesHandler(c echo.Context) error {
+	var req models.DatasetSourceRefreshRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	if req.County != "" && req.State == "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "state is required with county",
+			Code:    models.ErrCodeValidationFailed,
+			Details: []models.FieldError{{
+				Field:   "state",
+				Rule:    "required_with",
+				Message: "state is required with county",
+			}},
+		})
+	}
+
+	sources, err := services.SourceRefresh.Refresh(c.Request().Context(), req.State, req.County)
+	if errors.Is(err, services.ErrSourceRefreshRunning) {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "A source refresh is already running",
+			Code:    models.ErrCodeConflict,
+		})
+	}
+	if errors.Is(err, services.ErrSourceNotTracked) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "No tracked source matches; add it to DATASET_REFRESH_SOURCES",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to start source refresh", "state", req.State, "county", req.County, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to start source refresh",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	recordAudit(c, models.AuditSourcesRefreshed, "dataset_source", "", map[string]interface{}{
+		"state":   req.State,
+		"county":  req.County,
+		"sources": len(sources),
+	})
+	return c.JSON(http.StatusAccepted, GeocodeResponse{
+		Success: true,
+		Data:    sources,
+		Count:   len(sources),
+		Message: "Source refresh started",
+	})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StartDedupeHandler handles POST /api/v1/admin/datasets/dedupe - find
+// addresses imported by more than one dataset, in the background (admin
+// endpoint). Poll GetDedupeReportHandler for the report.
+func StartDedupeHandler(c echo.Context) error {
+	var req models.DedupeRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	userID, _ := c.Get("user_id").(int)
+	run, err := services.Dedupe.Start(c.Request().Context(), req.DistanceMeters, userID)
+	if errors.Is(err, services.ErrDedupeRunning) {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "Duplicate detection is already running",
+			Code:    models.ErrCodeConflict,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to start duplicate detection", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to start duplicate detection",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	recordAudit(c, models.AuditDedupeStarted, "dedupe_run", strconv.Itoa(run.ID), map[string]interface{}{
+		"distance_meters": run.DistanceMeters,
+	})
+	return c.JSON(http.StatusAccepted, GeocodeResponse{
+		Success: true,
+		Data:    run,
+		Message: "Duplicate detection started",
+	})
+}
+
+// GetDedupeReportHandler handles GET /api/v1/admin/datasets/dedupe - the
+// latest duplicate detection run, or ?run_id=, with its duplicates
+// summarized by dataset pair and a page of the duplicates themselves.
+// status=open or resolved filters the page (admin endpoint).
+func GetDedupeReportHandler(c echo.Context) error {
+	limit, offset := parsePagination(c, 100, 1000)
+
+	var fieldErrs []models.FieldError
+	runID := 0
+	if value := c.QueryParam("run_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   "run_id",
+				Rule:    "numeric",
+				Message: "run_id must be a positive integer",
+			})
+		}
+		runID = id
+	}
+	status := c.QueryParam("status")
+	if status != "" && status != "open" && status != "resolved" {
+		fieldErrs = append(fieldErrs, models.FieldError{
+			Field:   "status",
+			Rule:    "oneof",
+			Message: "status must be open or resolved",
+		})
+	}
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+
+	report, total, err := services.Dedupe.GetReport(c.Request().Context(), runID, status, limit, offset)
+	if errors.Is(err, services.ErrDedupeRunNotFound) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "No duplicate detection run found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to get duplicate report", "run_id", runID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get duplicate report",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       report,
+		Count:      len(report.Duplicates),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
+
+// ResolveDuplicatesHandler handles POST /api/v1/admin/datasets/dedupe/resolve
+// - merge, delete or dismiss the open duplicates listed in ids, or every open
+// duplicate of run_id (admin endpoint)
+func ResolveDuplicatesHandler(c echo.Context) error {
+	var req models.DedupeActionRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	if (len(req.IDs) == 0) == (req.RunID == 0) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Either ids or run_id is required, but not both",
+			Code:    models.ErrCodeValidationFailed,
+			Details: []models.FieldError{{
+				Field:   "ids",
+				Rule:    "required_without",
+				Message: "Either ids or run_id is required, but not both",
+			}},
+		})
+	}
+
+	userID, _ := c.Get("user_id").(int)
+	result, err := services.Dedupe.Resolve(c.Request().Context(), req.Action, req.IDs, req.RunID, userID)
+	if err != nil {
+		logging.FromContext(c).Error("failed to resolve duplicates", "action", req.Action, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to resolve duplicates",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	targetID := strconv.Itoa(req.RunID)
+	if req.RunID == 0 {
+		targetID = ""
+	}
+	recordAudit(c, models.AuditDuplicatesResolved, "dedupe_run", targetID, map[string]interface{}{
+		"action":            req.Action,
+		"ids":               req.IDs,
+		"resolved":          result.Resolved,
+		"addresses_deleted": result.AddressesDeleted,
+	})
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    result,
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveDuplicatesRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no action", `{"ids":[1]}`},
+		{"unknown action", `{"action":"keep_both","ids":[1]}`},
+		{"neither ids nor run", `{"action":"delete"}`},
+		{"both ids and run", `{"action":"delete","ids":[1],"run_id":2}`},
+	}
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/datasets/dedupe/resolve", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, ResolveDuplicatesHandler(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestGetDedupeReportRejectsInvalidFilters(t *testing.T) {
+	e := echo.New()
+	for _, query := range []string{"run_id=abc", "run_id=0", "status=closed"} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/datasets/dedupe?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, GetDedupeReportHandler(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
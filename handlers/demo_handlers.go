@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// demoCacheTTL is how long demo responses are served from memory; the
+	// underlying ZIP and boundary data only changes on reload
+	demoCacheTTL = time.Hour
+	// maxDemoCacheEntries bounds memory use when clients walk many ZIP codes
+	maxDemoCacheEntries = 5000
+	// maxDemoSearchResults caps demo city searches; paging isn't offered
+	maxDemoSearchResults = 10
+	// demoWatermark is attached to every demo response
+	demoWatermark = "Demo data from geocoding-api. Sign up for a free API key to use this data in your application."
+)
+
+var demoZipPattern = regexp.MustCompile(`^\d{5}$`)
+
+// DemoResponse wraps demo payloads with a watermark so screenshots and
+// scraped output are clearly marked as coming from the public demo
+type DemoResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Code      string      `json:"code,omitempty"`
+	Demo      bool        `json:"demo"`
+	Watermark string      `json:"watermark"`
+}
+
+type demoCacheEntry struct {
+	status    int
+	body      DemoResponse
+	expiresAt time.Time
+}
+
+var demoCache = struct {
+	sync.Mutex
+	entries map[string]demoCacheEntry
+}{entries: make(map[string]demoCacheEntry)}
+
+// DemoState returns the single state whose boundary the demo serves (DEMO_STATE, default OH)
+func DemoState() string {
+	if state := os.Getenv("DEMO_STATE"); state != "" {
+		return strings.ToUpper(state)
+	}
+	return "OH"
+}
+
+// demoCached serves key from the demo cache, computing and storing it on a miss
+func demoCached(c echo.Context, key string, compute func() (int, DemoResponse)) error {
+	now := time.Now()
+
+	demoCache.Lock()
+	entry, ok := demoCache.entries[key]
+	demoCache.Unlock()
+
+	if !ok || now.After(entry.expiresAt) {
+		status, body := compute()
+		body.Demo = true
+		body.Watermark = demoWatermark
+		entry = demoCacheEntry{status: status, body: body, expiresAt: now.Add(demoCacheTTL)}
+
+		// Server errors aren't cached so a DB blip doesn't stick for an hour
+		if status < http.StatusInternalServerError {
+			demoCache.Lock()
+			if len(demoCache.entries) >= maxDemoCacheEntries {
+				demoCache.entries = make(map[string]demoCacheEntry)
+			}
+			demoCache.entries[key] = entry
+			demoCache.Unlock()
+		}
+	}
+
+	c.Response().Header().Set("X-Demo-Mode", "true")
+	if entry.status == http.StatusOK {
+		c.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	}
+	return c.JSON(entry.status, entry.body)
+}
+
+// DemoZipCodeHandler handles GET /api/v1/demo/geocode/:zipcode without authentication
+func DemoZipCodeHandler(c echo.Context) error {
+	zipCode := c.Param("zipcode")
+	if !demoZipPattern.MatchString(zipCode) {
+		return c.JSON(http.StatusBadRequest, DemoResponse{
+			Success:   false,
+			Error:     "Demo lookups require a 5-digit ZIP code",
+			Code:      models.ErrCodeInvalidZip,
+			Demo:      true,
+			Watermark: demoWatermark,
+		})
+	}
+
+	return demoCached(c, "zip:"+zipCode, func() (int, DemoResponse) {
+		result, err := services.GetZipCodeByZip(c.Request().Context(), zipCode)
+		if err != nil {
+			return http.StatusInternalServerError, DemoResponse{Error: "Failed to retrieve ZIP code data", Code: models.ErrCodeInternal}
+		}
+		if result == nil {
+			return http.StatusNotFound, DemoResponse{Error: "ZIP code not found", Code: models.ErrCodeNotFound}
+		}
+		return http.StatusOK, DemoResponse{Success: true, Data: result}
+	})
+}
+
+// DemoSearchHandler handles GET /api/v1/demo/search?city=&state= without
+// authentication, returning at most maxDemoSearchResults ZIP codes
+func DemoSearchHandler(c echo.Context) error {
+	city := strings.TrimSpace(c.QueryParam("city"))
+	state := strings.ToUpper(strings.TrimSpace(c.QueryParam("state")))
+	if city == "" || len(city) > 100 || (state != "" && len(state) != 2) {
+		return c.JSON(http.StatusBadRequest, DemoResponse{
+			Success:   false,
+			Error:     "Demo searches require a city and optionally a 2-letter state code",
+			Code:      models.ErrCodeInvalidRequest,
+			Demo:      true,
+			Watermark: demoWatermark,
+		})
+	}
+	limit := maxDemoSearchResults
+	if parsed, err := strconv.Atoi(c.QueryParam("limit")); err == nil && parsed > 0 && parsed < limit {
+		limit = parsed
+	}
+
+	key := fmt.Sprintf("search:%s|%s|%d", strings.ToLower(city), state, limit)
+	return demoCached(c, key, func() (int, DemoResponse) {
+		results, _, _, err := services.SearchZipCodesByCityFuzzy(c.Request().Context(), city, state, limit, 0, false)
+		if err != nil {
+			return http.StatusInternalServerError, DemoResponse{Error: "Failed to search ZIP codes", Code: models.ErrCodeInternal}
+		}
+		if results == nil {
+			results = []*models.ZipCode{}
+		}
+		return http.StatusOK, DemoResponse{Success: true, Data: results}
+	})
+}
+
+// DemoStateBoundaryHandler handles GET /api/v1/demo/states/:identifier/boundary
+// for the configured demo state only
+func DemoStateBoundaryHandler(c echo.Context) error {
+	identifier := strings.ToUpper(c.Param("identifier"))
+	if identifier != DemoState() {
+		return c.JSON(http.StatusForbidden, DemoResponse{
+			Success:   false,
+			Error:     "The demo only serves the " + DemoState() + " boundary. Use an API key for other states.",
+			Code:      models.ErrCodePermissionDenied,
+			Demo:      true,
+			Watermark: demoWatermark,
+		})
+	}
+
+	return demoCached(c, "state:"+identifier, func() (int, DemoResponse) {
+		geoJSON, err := services.State.GetStateBoundaryGeoJSON(c.Request().Context(), identifier, 0, "")
+		if err != nil {
+			return http.StatusNotFound, DemoResponse{Error: "State boundary not found", Code: models.ErrCodeNotFound}
+		}
+		return http.StatusOK, DemoResponse{Success: true, Data: geoJSON}
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LookupDistrictsHandler handles GET /api/v1/districts/lookup?lat=&lng= -
+// every congressional, state legislative and school district containing a
+// point, optionally limited with ?type=congressional,state_upper
+func LookupDistrictsHandler(c echo.Context) error {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Valid 'lat' and 'lng' query parameters are required",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Coordinates out of range",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+
+	var types []string
+	for _, t := range strings.Split(c.QueryParam("type"), ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	districts, err := services.Districts.LookupDistricts(c.Request().Context(), lat, lng, types)
+	if errors.Is(err, services.ErrUnknownDistrictType) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "'type' must be one or more of " + strings.Join(services.DistrictTypes(), ", "),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("district lookup failed", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to look up districts",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    districts,
+		Count:   len(districts),
+	})
+}
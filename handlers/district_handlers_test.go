@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupDistrictsRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"missing coordinates", "", "INVALID_COORDINATES"},
+		{"non-numeric longitude", "lat=39.96&lng=west", "INVALID_COORDINATES"},
+		{"latitude out of range", "lat=-91&lng=-83", "INVALID_COORDINATES"},
+		{"unknown type", "lat=39.96&lng=-83&type=congressional,county", "INVALID_REQUEST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/districts/lookup?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, LookupDistrictsHandler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"`+tt.code+`"`)
+		})
+	}
+}
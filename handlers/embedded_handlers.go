@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// embeddedUnsupportedError answers requests the embedded SQLite database
+// (DB_DRIVER=sqlite) can't serve
+const embeddedUnsupportedError = "This endpoint needs PostgreSQL with PostGIS and isn't available with DB_DRIVER=sqlite"
+
+// EmbeddedUnsupportedHandler answers every API route other than the ZIP code
+// and state lookups when running on the embedded SQLite database: spatial
+// queries need PostGIS, and accounts and API keys aren't stored
+func EmbeddedUnsupportedHandler(c echo.Context) error {
+	return c.JSON(http.StatusNotImplemented, GeocodeResponse{
+		Success: false,
+		Error:   embeddedUnsupportedError,
+		Code:    models.ErrCodeNotImplemented,
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FeatureFlagsContextKey is the echo context key holding flags evaluated for a request
+const FeatureFlagsContextKey = "feature_flags"
+
+// FlagEnabled evaluates a feature flag for the request's API key and records
+// the result on the context so it is stored with the usage record
+func FlagEnabled(c echo.Context, key string) bool {
+	apiKeyID := 0
+	if apiKey, ok := c.Get("api_key").(*models.APIKey); ok {
+		apiKeyID = apiKey.ID
+	}
+
+	enabled := services.Flags.IsEnabled(key, apiKeyID)
+
+	flags, ok := c.Get(FeatureFlagsContextKey).(map[string]bool)
+	if !ok {
+		flags = make(map[string]bool)
+		c.Set(FeatureFlagsContextKey, flags)
+	}
+	flags[key] = enabled
+
+	return enabled
+}
+
+// GetFeatureFlagsHandler lists feature flags with on/off usage comparison (admin only)
+func GetFeatureFlagsHandler(c echo.Context) error {
+	flags, err := services.Flags.ListFlags(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get feature flags",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	days := 7
+	if daysStr := c.QueryParam("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 90 {
+			days = d
+		}
+	}
+
+	result := make([]map[string]interface{}, 0, len(flags))
+	for _, flag := range flags {
+		stats, err := services.Flags.GetFlagStats(c.Request().Context(), flag.Key, days)
+		if err != nil {
+			stats = []models.FeatureFlagStats{}
+		}
+		result = append(result, map[string]interface{}{
+			"flag":  flag,
+			"stats": stats,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    result,
+		Count:   len(result),
+	})
+}
+
+// UpsertFeatureFlagHandler creates or updates a feature flag (admin only)
+func UpsertFeatureFlagHandler(c echo.Context) error {
+	var flag models.FeatureFlag
+	if err := c.Bind(&flag); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request body",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if key := c.Param("key"); key != "" {
+		flag.Key = key
+	}
+
+	saved, err := services.Flags.UpsertFlag(c.Request().Context(), flag)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if !strings.HasPrefix(err.Error(), "failed to") {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(status),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    saved,
+		Message: "Feature flag saved",
+	})
+}
+
+// DeleteFeatureFlagHandler removes a feature flag (admin only)
+func DeleteFeatureFlagHandler(c echo.Context) error {
+	if err := services.Flags.DeleteFlag(c.Request().Context(), c.Param("key")); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(status),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Feature flag deleted",
+	})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRecordsRankedResultsFlag(t *testing.T) {
+	db, err := database.OpenSQLite("")
+	require.NoError(t, err)
+	defer db.Close()
+	original := database.DB
+	database.DB = db
+	defer func() { database.DB = original }()
+
+	ctx := context.Background()
+	require.NoError(t, services.LoadSampleData(ctx))
+	_, err = db.Exec(`CREATE TABLE feature_flags (
+		key TEXT PRIMARY KEY, description TEXT, enabled BOOLEAN NOT NULL DEFAULT false,
+		rollout_percent INTEGER NOT NULL DEFAULT 0, api_key_ids TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	search := func(apiKeyID int) (string, map[string]bool) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/search?city=Dover&state=OH&fuzzy=false&limit=1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("api_key", &models.APIKey{ID: apiKeyID})
+		require.NoError(t, SearchZipCodesHandler(c))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var body struct {
+			Data []models.ZipCode `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Len(t, body.Data, 1)
+		flags, _ := c.Get(FeatureFlagsContextKey).(map[string]bool)
+		return body.Data[0].CityName, flags
+	}
+
+	_, err = services.Flags.UpsertFlag(ctx, models.FeatureFlag{
+		Key:       models.FlagSearchRankedResults,
+		Enabled:   true,
+		APIKeyIDs: []int64{7},
+	})
+	require.NoError(t, err)
+	defer services.Flags.DeleteFlag(ctx, models.FlagSearchRankedResults)
+
+	// The evaluation is recorded either way, so usage can be compared
+	city, flags := search(7)
+	assert.Equal(t, "Dover", city, "the flag ranks the exact match first")
+	assert.Equal(t, map[string]bool{models.FlagSearchRankedResults: true}, flags)
+
+	city, flags = search(8)
+	assert.Equal(t, "Andover", city)
+	assert.Equal(t, map[string]bool{models.FlagSearchRankedResults: false}, flags)
+}
@@ -1,15 +1,16 @@
 package handlers
 
 import (
-	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 
 	"geocoding-api/database"
+	"geocoding-api/logging"
+	"geocoding-api/models"
 	"geocoding-api/services"
 
 	"github.com/labstack/echo/v4"
@@ -17,11 +18,15 @@ import (
 
 // GeocodeResponse represents the standard API response structure
 type GeocodeResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Message string      `json:"message,omitempty"`
-	Count   int         `json:"count,omitempty"`
+	Success bool                `json:"success"`
+	Data    interface{}         `json:"data,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	Code    string              `json:"code,omitempty"`    // models.ErrCode*, set on errors
+	Details []models.FieldError `json:"details,omitempty"` // per-field validation failures
+	Message string              `json:"message,omitempty"`
+	Count   int                 `json:"count,omitempty"`
+	// Pagination is set by list endpoints that page their results
+	Pagination *models.Pagination `json:"pagination,omitempty"`
 }
 
 // GetZipCodeHandler handles GET requests for ZIP code lookup
@@ -31,6 +36,7 @@ func GetZipCodeHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "ZIP code parameter is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -39,14 +45,28 @@ func GetZipCodeHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid ZIP code format",
+			Code:    models.ErrCodeInvalidZip,
 		})
 	}
 
-	result, err := services.GetZipCodeByZip(zipCode)
+	include, ok := parseInclude(c, models.IncludeCensus, models.IncludeDemographics)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   includeError(models.IncludeCensus, models.IncludeDemographics),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if (include[models.IncludeCensus] || include[models.IncludeDemographics]) && database.Embedded() {
+		return EmbeddedUnsupportedHandler(c)
+	}
+
+	result, err := services.GetZipCodeByZip(c.Request().Context(), zipCode)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to retrieve ZIP code data",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -54,9 +74,28 @@ func GetZipCodeHandler(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, GeocodeResponse{
 			Success: false,
 			Error:   "ZIP code not found",
+			Code:    models.ErrCodeNotFound,
 		})
 	}
 
+	if include[models.IncludeCensus] {
+		geography, err := services.Census.GetCensusGeography(c.Request().Context(), result.Latitude, result.Longitude)
+		if err != nil {
+			return censusErrorResponse(c, err)
+		}
+		// result is shared with the lookup cache, so enrich a copy
+		enriched := *result
+		enriched.Census = geography
+		result = &enriched
+	}
+	if include[models.IncludeDemographics] {
+		results := []*models.ZipCode{result}
+		if err := addZipDemographics(c.Request().Context(), results); err != nil {
+			return zipDemographicsErrorResponse(c, err)
+		}
+		result = results[0]
+	}
+
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
 		Data:    result,
@@ -64,46 +103,196 @@ func GetZipCodeHandler(c echo.Context) error {
 	})
 }
 
+// addZipDemographics replaces each ZIP code with a copy carrying its
+// demographics, leaving the originals shared with the lookup cache alone.
+// ZIP codes without loaded demographics are left as they are.
+func addZipDemographics(ctx context.Context, results []*models.ZipCode) error {
+	zipCodes := make([]string, len(results))
+	for i, zc := range results {
+		zipCodes[i] = zc.ZipCode
+	}
+	demographics, err := services.GetZipDemographics(ctx, zipCodes)
+	if err != nil {
+		return err
+	}
+	for i, zc := range results {
+		if d, ok := demographics[zc.ZipCode]; ok {
+			enriched := *zc
+			enriched.Demographics = d
+			results[i] = &enriched
+		}
+	}
+	return nil
+}
+
+// zipDemographicsErrorResponse logs a failed demographics lookup and
+// responds with a 500
+func zipDemographicsErrorResponse(c echo.Context, err error) error {
+	logging.FromContext(c).Error("ZIP demographics lookup failed", "error", err)
+	return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+		Success: false,
+		Error:   "Failed to retrieve ZIP code demographics",
+		Code:    models.ErrCodeInternal,
+	})
+}
+
 // SearchZipCodesHandler handles GET requests for ZIP code search by city
 func SearchZipCodesHandler(c echo.Context) error {
-	cityName := c.QueryParam("city")
+	req, ok := searchParamsFromRequest(c, parseZipCodeSearchRequest, &ZipCodeSearchRequest{})
+	if !ok {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid JSON request body",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	include, ok := parseInclude(c, models.IncludeDemographics)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   includeError(models.IncludeDemographics),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if include[models.IncludeDemographics] && database.Embedded() {
+		return EmbeddedUnsupportedHandler(c)
+	}
+
+	cityName := req.City
 	if cityName == "" {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "City parameter is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	stateCode := c.QueryParam("state")
-	limitStr := c.QueryParam("limit")
+	stateCode := req.State
 	
 	// Default limit is 50, max is 100
 	limit := 50
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
-			if parsedLimit > 0 && parsedLimit <= 100 {
-				limit = parsedLimit
-			}
-		}
+	if req.Limit > 0 {
+		limit = req.Limit
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := 0
+	if req.Offset > 0 {
+		offset = req.Offset
 	}
 
-	results, err := services.SearchZipCodesByCity(cityName, stateCode, limit)
+	var results []*models.ZipCode
+	var total int
+	var correction *models.CorrectedQuery
+	var err error
+	ranked := FlagEnabled(c, models.FlagSearchRankedResults)
+	if req.Fuzzy == nil || *req.Fuzzy {
+		results, total, correction, err = services.SearchZipCodesByCityFuzzy(c.Request().Context(), cityName, stateCode, limit, offset, ranked)
+	} else {
+		results, total, err = services.SearchZipCodesByCity(c.Request().Context(), cityName, stateCode, limit, offset, ranked)
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to search ZIP codes",
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
-	return c.JSON(http.StatusOK, GeocodeResponse{
-		Success: true,
-		Data:    results,
-		Count:   len(results),
+	if results == nil {
+		results = []*models.ZipCode{}
+	}
+	if include[models.IncludeDemographics] && len(results) > 0 {
+		if err := addZipDemographics(c.Request().Context(), results); err != nil {
+			return zipDemographicsErrorResponse(c, err)
+		}
+	}
+	if correction != nil {
+		c.Response().Header().Set("X-Corrected-Query", strings.TrimSuffix(correction.City+", "+correction.State, ", "))
+	}
+
+	if wantsCSV(c) {
+		stream, err := newCSVStream(c, "zipcodes.csv", []string{
+			"zip_code", "city_name", "state_code", "state_name", "primary_county_name",
+			"timezone", "latitude", "longitude",
+		})
+		if err != nil {
+			return err
+		}
+		for _, zc := range results {
+			if err := stream.Write([]string{
+				zc.ZipCode, zc.CityName, zc.StateCode, zc.StateName, zc.PrimaryCountyName, zc.Timezone,
+				strconv.FormatFloat(zc.Latitude, 'f', 6, 64), strconv.FormatFloat(zc.Longitude, 'f', 6, 64),
+			}); err != nil {
+				return err
+			}
+		}
+		return stream.Flush()
+	}
+
+	return c.JSON(http.StatusOK, ZipCodeSearchResponse{
+		Success:        true,
+		Data:           results,
+		Count:          len(results),
+		Pagination:     paginate(c, total, limit, offset),
+		CorrectedQuery: correction,
 	})
 }
 
-// HealthCheckHandler handles health check requests
+// ZipCodeSearchResponse is a ZIP search result. CorrectedQuery is set when
+// a misspelled city or state was corrected, so clients can show
+// "showing results for ..."
+type ZipCodeSearchResponse struct {
+	Success        bool                   `json:"success"`
+	Data           []*models.ZipCode      `json:"data"`
+	Count          int                    `json:"count"`
+	Pagination     *models.Pagination     `json:"pagination"`
+	CorrectedQuery *models.CorrectedQuery `json:"corrected_query,omitempty"`
+}
+
+// ZipCodeSearchRequest holds ZIP code search parameters (query string or JSON body)
+type ZipCodeSearchRequest struct {
+	City   string `json:"city"`
+	State  string `json:"state"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	// Fuzzy enables spelling correction when the city matches nothing (default true)
+	Fuzzy *bool `json:"fuzzy"`
+}
+
+// parseZipCodeSearchRequest reads ZIP code search parameters from the query string
+func parseZipCodeSearchRequest(c echo.Context) *ZipCodeSearchRequest {
+	req := &ZipCodeSearchRequest{
+		City:  c.QueryParam("city"),
+		State: c.QueryParam("state"),
+	}
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = parsedLimit
+		}
+	}
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil {
+			req.Offset = parsedOffset
+		}
+	}
+	if fuzzyStr := c.QueryParam("fuzzy"); fuzzyStr != "" {
+		if fuzzy, err := strconv.ParseBool(fuzzyStr); err == nil {
+			req.Fuzzy = &fuzzy
+		}
+	}
+	return req
+}
+
+// HealthCheckHandler handles health check requests. Besides the circuit
+// breakers it checks each dependency and the reference data, rolled up into
+// status: unhealthy (503) when the database or PostGIS is down, degraded
+// when anything else isn't ok. verbose=true adds latencies, row counts,
+// schema versions and worker heartbeats.
 func HealthCheckHandler(c echo.Context) error {
+	verbose, _ := strconv.ParseBool(c.QueryParam("verbose"))
 	response := map[string]interface{}{
 		"status":        "healthy",
 		"service":       "geocoding-api",
@@ -120,7 +309,38 @@ func HealthCheckHandler(c echo.Context) error {
 		response["migration_error"] = database.MigrationError.Error()
 		response["note"] = "Migration error occurred - check logs"
 	}
-	
+
+	// An open database circuit fails readiness checks so traffic moves to
+	// other instances until the database is back
+	circuit := database.Circuit.Status()
+	response["database"] = circuit
+	if database.Replica != nil {
+		// Reads fall back to the primary while the replica is down, so it
+		// only degrades the status
+		replica := database.ReplicaCircuit.Status()
+		response["replica"] = replica
+		if replica.State != database.CircuitClosed {
+			response["status"] = "degraded"
+		}
+	}
+	if circuit.State != database.CircuitClosed {
+		response["status"] = "degraded"
+	}
+	if circuit.State == database.CircuitOpen {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(circuit.RetryAfterSeconds))
+		return c.JSON(http.StatusServiceUnavailable, response)
+	}
+
+	checks := services.HealthChecks(c.Request().Context(), verbose)
+	response["checks"] = checks
+	switch services.HealthRollup(checks) {
+	case models.HealthUnhealthy:
+		response["status"] = models.HealthUnhealthy
+		return c.JSON(http.StatusServiceUnavailable, response)
+	case models.HealthDegraded:
+		response["status"] = models.HealthDegraded
+	}
+
 	return c.JSON(http.StatusOK, response)
 }
 
@@ -131,70 +351,45 @@ func DocsRedirectHandler(c echo.Context) error {
 
 
 
-// LoadDataHandler handles POST requests to load CSV data (admin endpoint)
-func LoadDataHandler(c echo.Context) error {
-	filePath := c.QueryParam("file")
-	if filePath == "" {
-		filePath = "georef-united-states-of-america-zc-point.csv" // Default file
-	}
+// RefreshZipCodesHandler handles POST requests to refresh zip_codes from the
+// upstream ZIP code dataset (admin endpoint). dry_run=true reports the diff
+// without applying it; force=true skips the row-count safety check.
+func RefreshZipCodesHandler(c echo.Context) error {
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+	force, _ := strconv.ParseBool(c.QueryParam("force"))
 
-	// Check if compressed version exists
-	gzPath := filePath + ".gz"
-	if _, err := os.Stat(gzPath); err == nil && !os.IsNotExist(err) {
-		// Decompress the file
-		decompressedPath := filepath.Join(os.TempDir(), filepath.Base(filePath))
-		
-		if err := decompressFile(gzPath, decompressedPath); err != nil {
-			return c.JSON(http.StatusInternalServerError, GeocodeResponse{
-				Success: false,
-				Error:   "Failed to decompress data file: " + err.Error(),
-			})
-		}
-		defer os.Remove(decompressedPath) // Clean up temp file
-		filePath = decompressedPath
+	summary, err := services.RefreshZipCodes(dryRun, force)
+	if errors.Is(err, services.ErrZipCodeRefreshRunning) {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "A ZIP code refresh is already running",
+			Code:    models.ErrCodeConflict,
+		})
 	}
-
-	err := services.LoadZipCodesFromCSV(filePath)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
-			Error:   "Failed to load CSV data: " + err.Error(),
+			Error:   "Failed to refresh ZIP codes: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
+	message := "ZIP codes refreshed"
+	if dryRun {
+		message = "Dry run: no changes applied"
+	} else {
+		recordAudit(c, models.AuditZipCodesRefreshed, "zip_codes", "", map[string]interface{}{
+			"force":   force,
+			"summary": summary,
+		})
+	}
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
-		Data:    "CSV data loaded successfully",
+		Data:    summary,
+		Message: message,
 	})
 }
 
-// decompressFile decompresses a gzipped file
-func decompressFile(srcPath, destPath string) error {
-	srcFile, err := os.Open(srcPath)
-	if err != nil {
-		return fmt.Errorf("failed to open compressed file: %w", err)
-	}
-	defer srcFile.Close()
-
-	gzReader, err := gzip.NewReader(srcFile)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzReader.Close()
-
-	destFile, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer destFile.Close()
-
-	if _, err := io.Copy(destFile, gzReader); err != nil {
-		return fmt.Errorf("failed to decompress file: %w", err)
-	}
-
-	return nil
-}
-
 // CalculateDistanceHandler handles GET requests to calculate distance between two ZIP codes
 func CalculateDistanceHandler(c echo.Context) error {
 	fromZip := c.Param("from")
@@ -204,6 +399,7 @@ func CalculateDistanceHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Both 'from' and 'to' ZIP code parameters are required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -212,16 +408,63 @@ func CalculateDistanceHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid ZIP code format",
+			Code:    models.ErrCodeInvalidZip,
 		})
 	}
 
-	result, err := services.CalculateDistanceBetweenZipCodes(fromZip, toZip)
-	if err != nil {
+	unit := c.QueryParam("unit")
+	if unit == "" {
+		unit = services.DistanceUnitMiles
+	}
+	if !services.ValidDistanceUnit(unit) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid unit: must be mi, km or nmi",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	var result *services.DistanceResponse
+	var err error
+	switch mode := c.QueryParam("mode"); mode {
+	case "", services.DistanceModeStraightLine:
+		result, err = services.CalculateDistanceBetweenZipCodes(c.Request().Context(), fromZip, toZip)
+	case services.DistanceModeDriving:
+		result, err = services.CalculateDrivingDistanceBetweenZipCodes(c.Request().Context(), fromZip, toZip)
+	default:
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid mode: must be straight_line or driving",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	switch {
+	case errors.Is(err, services.ErrRoutingDisabled):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Driving distance is not enabled on this server",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	case errors.Is(err, services.ErrNoRoute):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "No driving route found between the ZIP codes",
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrRoutingUnavailable):
+		return c.JSON(http.StatusBadGateway, GeocodeResponse{
+			Success: false,
+			Error:   "Routing engine unavailable",
+			Code:    models.ErrCodeUnavailable,
+		})
+	case err != nil:
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to calculate distance: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
+	result.SetUnit(unit)
 
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
@@ -237,6 +480,7 @@ func FindNearbyZipCodesHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Center ZIP code parameter is required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -245,6 +489,7 @@ func FindNearbyZipCodesHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid ZIP code format",
+			Code:    models.ErrCodeInvalidZip,
 		})
 	}
 
@@ -259,6 +504,7 @@ func FindNearbyZipCodesHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid radius parameter (must be between 0 and 100 miles)",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -273,13 +519,76 @@ func FindNearbyZipCodesHandler(c echo.Context) error {
 		}
 	}
 
-	results, err := services.FindZipCodesWithinRadius(centerZip, radius, limit)
+	results, err := services.FindZipCodesWithinRadius(c.Request().Context(), centerZip, radius, limit)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to find nearby ZIP codes: " + err.Error(),
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    results,
+		Count:   len(results),
+	})
+}
+
+// FindZipCodesNearPointHandler handles GET /api/v1/nearby - the ZIP codes
+// whose centers are within a radius of a latitude and longitude, nearest
+// first, for clients that don't have a ZIP code to search around
+func FindZipCodesNearPointHandler(c echo.Context) error {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Valid 'lat' and 'lng' query parameters are required",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Coordinates out of range",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+
+	// Radius in miles, default 1, like /nearby/{zipcode}
+	radius := 1.0
+	if radiusStr := c.QueryParam("radius"); radiusStr != "" {
+		val, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil || val <= 0 || val > 100 {
+			return c.JSON(http.StatusBadRequest, GeocodeResponse{
+				Success: false,
+				Error:   "Invalid radius parameter (must be between 0 and 100 miles)",
+				Code:    models.ErrCodeInvalidRequest,
+			})
+		}
+		radius = val
+	}
+
+	limit := 50
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 && val <= 200 {
+			limit = val
+		}
+	}
+
+	results, err := services.FindZipCodesNearPoint(c.Request().Context(), lat, lng, radius, limit)
+	if err != nil {
+		logging.FromContext(c).Error("failed to find ZIP codes near point", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to find nearby ZIP codes",
+			Code:    models.ErrCodeInternal,
 		})
 	}
+	if results == nil {
+		results = []*services.RadiusSearchResult{}
+	}
 
 	return c.JSON(http.StatusOK, GeocodeResponse{
 		Success: true,
@@ -297,6 +606,7 @@ func CheckZipCodeProximityHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Both 'center' and 'target' ZIP code parameters are required",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
@@ -305,6 +615,7 @@ func CheckZipCodeProximityHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid ZIP code format",
+			Code:    models.ErrCodeInvalidZip,
 		})
 	}
 
@@ -319,14 +630,16 @@ func CheckZipCodeProximityHandler(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, GeocodeResponse{
 			Success: false,
 			Error:   "Invalid radius parameter (must be between 0 and 100 miles)",
+			Code:    models.ErrCodeInvalidRequest,
 		})
 	}
 
-	isWithin, actualDistance, err := services.IsZipCodeWithinRadius(centerZip, targetZip, radius)
+	isWithin, actualDistance, err := services.IsZipCodeWithinRadius(c.Request().Context(), centerZip, targetZip, radius)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
 			Success: false,
 			Error:   "Failed to check ZIP code proximity: " + err.Error(),
+			Code:    models.ErrCodeInternal,
 		})
 	}
 
@@ -344,4 +657,86 @@ func CheckZipCodeProximityHandler(c echo.Context) error {
 		Data:    result,
 		Count:   1,
 	})
-}
\ No newline at end of file
+}
+
+// GetDataCoverageHandler handles GET /api/v1/coverage?state= - the
+// address points loaded per state and county, with their source and how
+// fresh they are. It needs no API key, so prospective customers can check
+// their territory is covered.
+func GetDataCoverageHandler(c echo.Context) error {
+	state := c.QueryParam("state")
+	if len(state) > 10 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Parameter 'state' must be a state code such as OH",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	coverage, err := services.GetDataCoverage(c.Request().Context(), state)
+	if err != nil {
+		logging.FromContext(c).Error("failed to get data coverage", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get data coverage",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    coverage,
+		Count:   coverage.CountyCount,
+	})
+}
+
+// GetCoverageHandler handles GET /api/v1/coverage/drive-time?zipcode=&minutes=
+// - the ZIP codes and counties reachable by car from a ZIP code within a
+// drive time
+func GetCoverageHandler(c echo.Context) error {
+	centerZip := c.QueryParam("zipcode")
+	if len(centerZip) < 5 || len(centerZip) > 10 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "A valid 'zipcode' parameter is required",
+			Code:    models.ErrCodeInvalidZip,
+		})
+	}
+
+	minutes, err := strconv.Atoi(c.QueryParam("minutes"))
+	if err != nil || minutes <= 0 || minutes > services.MaxCoverageMinutes {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid minutes parameter (must be between 1 and %d)", services.MaxCoverageMinutes),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	area, err := services.GetCoverageArea(c.Request().Context(), centerZip, minutes)
+	switch {
+	case errors.Is(err, services.ErrCoverageCenterNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "ZIP code " + centerZip + " not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrRoutingUnavailable):
+		return c.JSON(http.StatusBadGateway, GeocodeResponse{
+			Success: false,
+			Error:   "Routing engine unavailable",
+			Code:    models.ErrCodeUnavailable,
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to calculate coverage",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    area,
+		Count:   len(area.ZipCodes),
+	})
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// geocodeJobResultsPage is how many results a stream event or results page carries
+	geocodeJobResultsPage = 500
+	// geocodeJobKeepAlive is how often an idle stream sends a comment so
+	// proxies keep the connection open, and re-reads the job in case another
+	// instance is processing it
+	geocodeJobKeepAlive = 15 * time.Second
+)
+
+// geocodeJobErrorResponse maps geocode job service errors to responses
+func geocodeJobErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	code := models.ErrCodeInternal
+	switch {
+	case errors.Is(err, services.ErrGeocodeJobNotFound):
+		status, code = http.StatusNotFound, models.ErrCodeNotFound
+	case errors.Is(err, services.ErrGeocodeJobFinished):
+		status, code = http.StatusConflict, models.ErrCodeConflict
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(c).Error("geocode job request failed", "error", err)
+		message = "Geocode job request failed"
+	}
+	return c.JSON(status, GeocodeResponse{
+		Success: false,
+		Error:   message,
+		Code:    code,
+	})
+}
+
+// geocodeJobParams extracts the API key's user and the :id path parameter.
+// ok is false when an error response has been written; the caller returns err.
+func geocodeJobParams(c echo.Context) (userID, jobID int, ok bool, err error) {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return 0, 0, false, c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	jobID, convErr := strconv.Atoi(c.Param("id"))
+	if convErr != nil {
+		return 0, 0, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid job ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	return user.ID, jobID, true, nil
+}
+
+// CreateGeocodeJobHandler handles POST /api/v1/geocode/jobs - upload a file
+// of addresses to geocode in the background. The multipart "file" field is
+// either a CSV with an address column (or address component columns) or a
+// text file with one address per line. Responds 202 with the queued job;
+// follow it at /geocode/jobs/:id/stream or poll /geocode/jobs/:id.
+func CreateGeocodeJobHandler(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "A file of addresses is required in the 'file' form field",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to read uploaded file",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	defer src.Close()
+
+	queries, err := services.GeocodeJobs.ReadQueries(src, file.Filename)
+	if err != nil {
+		message := err.Error()
+		if errors.Is(err, services.ErrGeocodeJobTooLarge) {
+			message = fmt.Sprintf("A job may contain at most %d addresses", services.GeocodeJobs.MaxRows())
+		}
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   message,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	var apiKeyID *int
+	if key, ok := c.Get("api_key").(*models.APIKey); ok {
+		apiKeyID = &key.ID
+	}
+
+	job, err := services.GeocodeJobs.CreateJob(c.Request().Context(), user.ID, apiKeyID, file.Filename, queries)
+	if err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	c.Response().Header().Set("Location", APIPath(c, fmt.Sprintf("/geocode/jobs/%d", job.ID)))
+	return c.JSON(http.StatusAccepted, GeocodeResponse{
+		Success: true,
+		Data:    job,
+		Message: fmt.Sprintf("Queued %d addresses for geocoding", job.TotalRows),
+	})
+}
+
+// GetGeocodeJobsHandler handles GET /api/v1/geocode/jobs - list the user's jobs
+func GetGeocodeJobsHandler(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	limit, offset := parsePagination(c, 20, 100)
+	jobs, total, err := services.GeocodeJobs.ListJobs(c.Request().Context(), user.ID, limit, offset)
+	if err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       jobs,
+		Count:      len(jobs),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
+
+// GetGeocodeJobHandler handles GET /api/v1/geocode/jobs/:id - poll a job's progress
+func GetGeocodeJobHandler(c echo.Context) error {
+	userID, jobID, ok, err := geocodeJobParams(c)
+	if !ok {
+		return err
+	}
+
+	job, err := services.GeocodeJobs.GetJob(c.Request().Context(), userID, jobID)
+	if err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetGeocodeJobResultsHandler handles GET /api/v1/geocode/jobs/:id/results -
+// page through finished rows in row order. Pass the last row seen as
+// ?after= to get the next page.
+func GetGeocodeJobResultsHandler(c echo.Context) error {
+	userID, jobID, ok, err := geocodeJobParams(c)
+	if !ok {
+		return err
+	}
+
+	if _, err := services.GeocodeJobs.GetJob(c.Request().Context(), userID, jobID); err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	after, _ := strconv.Atoi(c.QueryParam("after"))
+	limit, _ := parsePagination(c, 100, geocodeJobResultsPage)
+
+	results, err := services.GeocodeJobs.GetResults(c.Request().Context(), jobID, after, limit)
+	if err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    results,
+		Count:   len(results),
+	})
+}
+
+// CancelGeocodeJobHandler handles DELETE /api/v1/geocode/jobs/:id - stop a
+// queued or running job, keeping the rows already geocoded
+func CancelGeocodeJobHandler(c echo.Context) error {
+	userID, jobID, ok, err := geocodeJobParams(c)
+	if !ok {
+		return err
+	}
+
+	job, err := services.GeocodeJobs.CancelJob(c.Request().Context(), userID, jobID)
+	if err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    job,
+		Message: "Geocode job cancelled",
+	})
+}
+
+// StreamGeocodeJobHandler handles GET /api/v1/geocode/jobs/:id/stream - a
+// Server-Sent Events stream of the job's progress and results. Each event's
+// data is a models.GeocodeJobEvent and its id is the last row it carries,
+// so a client that reconnects with Last-Event-ID (or ?after=) resumes
+// without gaps. The stream ends with a "complete" event once the job stops.
+func StreamGeocodeJobHandler(c echo.Context) error {
+	userID, jobID, ok, err := geocodeJobParams(c)
+	if !ok {
+		return err
+	}
+	logger := logging.FromContext(c).With("geocode_job_id", jobID)
+
+	job, err := services.GeocodeJobs.GetJob(c.Request().Context(), userID, jobID)
+	if err != nil {
+		return geocodeJobErrorResponse(c, err)
+	}
+
+	after, _ := strconv.Atoi(c.QueryParam("after"))
+	if lastID, err := strconv.Atoi(c.Request().Header.Get("Last-Event-ID")); err == nil {
+		after = lastID
+	}
+
+	// Subscribe before the first read so no progress is missed in between
+	updates, unsubscribe := services.GeocodeJobs.Subscribe(jobID)
+	defer unsubscribe()
+
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("X-Accel-Buffering", "no")
+	c.Response().WriteHeader(http.StatusOK)
+
+	sendEvent := func(event models.GeocodeJobEvent) {
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(c.Response(), "id: %d\ndata: %s\n\n", after, data)
+		c.Response().Flush()
+	}
+
+	// sendProgress sends every result finished since the last event, a page
+	// per event, or just the job when only its status changed. It reports
+	// whether the job has stopped.
+	var lastStatus string
+	lastProcessed := -1
+	sendProgress := func() (bool, error) {
+		job, err = services.GeocodeJobs.GetJob(c.Request().Context(), userID, jobID)
+		if err != nil {
+			return false, err
+		}
+		sent := false
+		for {
+			results, err := services.GeocodeJobs.GetResults(c.Request().Context(), jobID, after, geocodeJobResultsPage)
+			if err != nil {
+				return false, err
+			}
+			if len(results) == 0 {
+				break
+			}
+			after = results[len(results)-1].Row
+			sendEvent(models.GeocodeJobEvent{Type: "progress", Job: job, Results: results})
+			sent = true
+		}
+		if job.Done() {
+			sendEvent(models.GeocodeJobEvent{Type: "complete", Job: job})
+			return true, nil
+		}
+		if !sent && (job.Status != lastStatus || job.ProcessedRows != lastProcessed) {
+			sendEvent(models.GeocodeJobEvent{Type: "progress", Job: job})
+		}
+		lastStatus, lastProcessed = job.Status, job.ProcessedRows
+		return false, nil
+	}
+
+	ticker := time.NewTicker(geocodeJobKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		done, err := sendProgress()
+		if err != nil {
+			logger.Error("geocode job stream failed", "error", err)
+			fmt.Fprint(c.Response(), "event: error\ndata: {\"error\":\"failed to read job progress\"}\n\n")
+			c.Response().Flush()
+			return nil
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-services.GeocodeJobs.Stopping():
+			return nil
+		case <-updates:
+		case <-ticker.C:
+			fmt.Fprint(c.Response(), ": keep-alive\n\n")
+			c.Response().Flush()
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// geofenceErrorResponse maps geofence service errors to responses
+func geofenceErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	code := models.ErrCodeInternal
+	switch {
+	case errors.Is(err, services.ErrGeofenceNotFound):
+		status, code = http.StatusNotFound, models.ErrCodeNotFound
+	case errors.Is(err, services.ErrGeofenceInvalid):
+		status, code = http.StatusBadRequest, models.ErrCodeValidationFailed
+	case errors.Is(err, services.ErrGeofenceExists), errors.Is(err, services.ErrGeofenceLimit):
+		status, code = http.StatusConflict, models.ErrCodeConflict
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(c).Error("geofence request failed", "error", err)
+		message = "Geofence request failed"
+	}
+	return c.JSON(status, GeocodeResponse{
+		Success: false,
+		Error:   message,
+		Code:    code,
+	})
+}
+
+// geofenceParams extracts the API key's user and the :id path parameter.
+// ok is false when an error response has been written; the caller returns err.
+func geofenceParams(c echo.Context) (userID, geofenceID int, ok bool, err error) {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return 0, 0, false, c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	geofenceID, convErr := strconv.Atoi(c.Param("id"))
+	if convErr != nil {
+		return 0, 0, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid geofence ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	return user.ID, geofenceID, true, nil
+}
+
+// CreateGeofenceHandler handles POST /api/v1/geofences - save a named
+// GeoJSON Polygon or MultiPolygon
+func CreateGeofenceHandler(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.GeofenceRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	geofence, err := services.Geofences.CreateGeofence(c.Request().Context(), user.ID, req)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	c.Response().Header().Set("Location", APIPath(c, fmt.Sprintf("/geofences/%d", geofence.ID)))
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    geofence,
+		Message: "Geofence created",
+	})
+}
+
+// GetGeofencesHandler handles GET /api/v1/geofences - list the user's
+// geofences without their geometry
+func GetGeofencesHandler(c echo.Context) error {
+	user, ok := c.Get("user").(*models.User)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	geofences, err := services.Geofences.GetUserGeofences(c.Request().Context(), user.ID)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    geofences,
+		Count:   len(geofences),
+	})
+}
+
+// GetGeofenceHandler handles GET /api/v1/geofences/:id - a geofence with its geometry
+func GetGeofenceHandler(c echo.Context) error {
+	userID, geofenceID, ok, err := geofenceParams(c)
+	if !ok {
+		return err
+	}
+
+	geofence, err := services.Geofences.GetGeofence(c.Request().Context(), userID, geofenceID)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    geofence,
+	})
+}
+
+// UpdateGeofenceHandler handles PUT /api/v1/geofences/:id - replace a
+// geofence's name, description and geometry
+func UpdateGeofenceHandler(c echo.Context) error {
+	userID, geofenceID, ok, err := geofenceParams(c)
+	if !ok {
+		return err
+	}
+
+	var req models.GeofenceRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	geofence, err := services.Geofences.UpdateGeofence(c.Request().Context(), userID, geofenceID, req)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    geofence,
+		Message: "Geofence updated",
+	})
+}
+
+// DeleteGeofenceHandler handles DELETE /api/v1/geofences/:id
+func DeleteGeofenceHandler(c echo.Context) error {
+	userID, geofenceID, ok, err := geofenceParams(c)
+	if !ok {
+		return err
+	}
+
+	if err := services.Geofences.DeleteGeofence(c.Request().Context(), userID, geofenceID); err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Geofence deleted",
+	})
+}
+
+// GeofenceContainsHandler handles GET /api/v1/geofences/:id/contains?lat=&lng= -
+// whether a point is inside the geofence and its distance from it
+func GeofenceContainsHandler(c echo.Context) error {
+	userID, geofenceID, ok, err := geofenceParams(c)
+	if !ok {
+		return err
+	}
+
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Valid 'lat' and 'lng' query parameters are required",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Coordinates out of range",
+			Code:    models.ErrCodeInvalidCoordinates,
+		})
+	}
+
+	result, err := services.Geofences.Contains(c.Request().Context(), userID, geofenceID, lat, lng)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// GetGeofenceAddressesHandler handles GET /api/v1/geofences/:id/addresses -
+// page through the address points inside the geofence
+func GetGeofenceAddressesHandler(c echo.Context) error {
+	userID, geofenceID, ok, err := geofenceParams(c)
+	if !ok {
+		return err
+	}
+
+	limit, offset := parsePagination(c, 100, 1000)
+	addresses, total, err := services.Geofences.GetGeofenceAddresses(c.Request().Context(), userID, geofenceID, limit, offset)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       addresses,
+		Count:      len(addresses),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
+
+// GetGeofenceZipCodesHandler handles GET /api/v1/geofences/:id/zipcodes -
+// the ZIP codes whose centers are inside the geofence
+func GetGeofenceZipCodesHandler(c echo.Context) error {
+	userID, geofenceID, ok, err := geofenceParams(c)
+	if !ok {
+		return err
+	}
+
+	zipCodes, err := services.Geofences.GetGeofenceZipCodes(c.Request().Context(), userID, geofenceID)
+	if err != nil {
+		return geofenceErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    zipCodes,
+		Count:   len(zipCodes),
+	})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"geocoding-api/i18n"
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LocalizedJSONSerializer writes JSON responses in the request's negotiated
+// language. Error and status messages, and state and county display names in
+// the response types below, are translated on a copy of the response, so
+// values shared with the lookup caches are never changed. Identifiers such
+// as codes, abbreviations and FIPS codes are left as they are. Endpoints
+// that allow it answer in XML or JSONP instead when the request asks.
+type LocalizedJSONSerializer struct {
+	echo.DefaultJSONSerializer
+}
+
+// Serialize localizes i and encodes it
+func (s LocalizedJSONSerializer) Serialize(c echo.Context, i interface{}, indent string) error {
+	if locale := i18n.FromContext(c); locale != i18n.DefaultLocale {
+		i = localize(locale, i)
+	}
+	if written, err := writeFormatted(c, i, indent); written {
+		return err
+	}
+	return s.DefaultJSONSerializer.Serialize(c, i, indent)
+}
+
+// localize returns a translated copy of a response, or the response itself
+// when it has nothing to translate
+func localize(locale string, i interface{}) interface{} {
+	message := func(m string) string { return i18n.Message(locale, m) }
+
+	switch v := i.(type) {
+	case GeocodeResponse:
+		v.Error = message(v.Error)
+		v.Message = message(v.Message)
+		v.Data = localize(locale, v.Data)
+		return v
+	case *GeocodeResponse:
+		if v != nil {
+			return localize(locale, *v)
+		}
+	case map[string]interface{}:
+		localized := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			switch key {
+			case "error", "message":
+				if text, ok := value.(string); ok {
+					value = message(text)
+				}
+			case "data":
+				value = localize(locale, value)
+			}
+			localized[key] = value
+		}
+		return localized
+
+	case ZipCodeSearchResponse:
+		v.Data = localize(locale, v.Data).([]*models.ZipCode)
+		return v
+	case models.AddressSearchResponse:
+		v.Error = message(v.Error)
+		v.Data = localize(locale, v.Data).([]models.OhioAddress)
+		return v
+	case models.CitySearchResponse:
+		v.Error = message(v.Error)
+		v.Message = message(v.Message)
+		v.Data = localize(locale, v.Data).([]models.City)
+		return v
+	case models.StateErrorResponse:
+		v.Error = message(v.Error)
+		return v
+	case models.StateSearchResponse:
+		v.States = localize(locale, v.States).([]models.State)
+		return v
+	case *models.StateSearchResponse:
+		if v != nil {
+			return localize(locale, *v)
+		}
+	case models.StateResponse:
+		v.State = localizeState(locale, v.State)
+		return v
+	case models.StateLookupResponse:
+		v.State = localizeState(locale, v.State)
+		return v
+	case *models.StateBoundaryFeature:
+		if v != nil {
+			feature := *v
+			feature.Properties.StateName = i18n.StateName(locale, feature.Properties.StateName)
+			return &feature
+		}
+
+	case *models.ZipCode:
+		if v != nil {
+			zc := *v
+			zc.StateName = i18n.StateName(locale, zc.StateName)
+			zc.PrimaryCountyName = i18n.CountyName(locale, zc.PrimaryCountyName)
+			zc.CountyNames = localizeEach(v.CountyNames, func(name string) string {
+				return i18n.CountyName(locale, name)
+			})
+			return &zc
+		}
+	case []*models.ZipCode:
+		return localizeEach(v, func(zc *models.ZipCode) *models.ZipCode {
+			return localize(locale, zc).(*models.ZipCode)
+		})
+	case []models.State:
+		return localizeEach(v, func(state models.State) models.State {
+			return *localizeState(locale, &state)
+		})
+	case []models.City:
+		return localizeEach(v, func(city models.City) models.City {
+			city.StateName = i18n.StateName(locale, city.StateName)
+			city.CountyName = i18n.CountyName(locale, city.CountyName)
+			return city
+		})
+	case []models.OhioAddress:
+		return localizeEach(v, func(address models.OhioAddress) models.OhioAddress {
+			address.County = i18n.CountyName(locale, address.County)
+			return address
+		})
+	case []models.NearbyAddress:
+		return localizeEach(v, func(address models.NearbyAddress) models.NearbyAddress {
+			address.County = i18n.CountyName(locale, address.County)
+			return address
+		})
+	case []models.CountyListResponse:
+		return localizeEach(v, func(county models.CountyListResponse) models.CountyListResponse {
+			county.CountyName = i18n.CountyName(locale, county.CountyName)
+			return county
+		})
+	case *models.OhioCounty:
+		if v != nil {
+			county := *v
+			county.CountyName = i18n.CountyName(locale, county.CountyName)
+			return &county
+		}
+	}
+	return i
+}
+
+// localizeState returns a copy of state with its display name translated
+func localizeState(locale string, state *models.State) *models.State {
+	if state == nil {
+		return nil
+	}
+	localized := *state
+	localized.StateName = i18n.StateName(locale, localized.StateName)
+	return &localized
+}
+
+// localizeEach translates a copy of each element of items, keeping a nil
+// slice nil so it still encodes as null
+func localizeEach[T any](items []T, translate func(T) T) []T {
+	if items == nil {
+		return nil
+	}
+	localized := make([]T, len(items))
+	for n, item := range items {
+		localized[n] = translate(item)
+	}
+	return localized
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/i18n"
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizedJSONSerializer(t *testing.T) {
+	e := echo.New()
+	e.JSONSerializer = LocalizedJSONSerializer{}
+
+	respond := func(locale string, body interface{}) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(i18n.ContextKey, locale)
+		assert.NoError(t, c.JSON(http.StatusOK, body))
+		return rec.Body.String()
+	}
+
+	t.Run("error messages", func(t *testing.T) {
+		body := respond(i18n.Spanish, GeocodeResponse{Error: "ZIP code not found", Code: models.ErrCodeNotFound})
+		assert.Contains(t, body, `"error":"Código postal no encontrado"`)
+		assert.Contains(t, body, `"code":"NOT_FOUND"`)
+	})
+
+	t.Run("place names leave the original unchanged", func(t *testing.T) {
+		zc := &models.ZipCode{
+			ZipCode:           "10001",
+			StateCode:         "NY",
+			StateName:         "New York",
+			PrimaryCountyName: "New York",
+			CountyNames:       models.StringArray{"New York"},
+		}
+		body := respond(i18n.Spanish, GeocodeResponse{Success: true, Data: zc})
+		assert.Contains(t, body, `"state_name":"Nueva York"`)
+		assert.Contains(t, body, `"state_code":"NY"`)
+		assert.Contains(t, body, `"county_names":["Nueva York"]`)
+		assert.Equal(t, "New York", zc.StateName)
+		assert.Equal(t, "New York", zc.CountyNames[0])
+	})
+
+	t.Run("English is untouched", func(t *testing.T) {
+		body := respond(i18n.English, models.StateErrorResponse{Error: "State not found"})
+		assert.Contains(t, body, `"error":"State not found"`)
+	})
+
+	t.Run("nil slices stay null", func(t *testing.T) {
+		body := respond(i18n.Spanish, models.AddressSearchResponse{Error: "Invalid address ID"})
+		assert.Contains(t, body, `"data":null`)
+		assert.Contains(t, body, `"error":"ID de dirección no válido"`)
+	})
+}
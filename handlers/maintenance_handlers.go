@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StartMaintenanceHandler handles POST /api/v1/admin/maintenance - rebuild
+// spatial indexes, vacuum and analyze the address tables and refresh
+// materialized views in the background (admin endpoint). Poll
+// GetMaintenanceHandler for progress.
+func StartMaintenanceHandler(c echo.Context) error {
+	var req models.MaintenanceRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	startedBy, _ := c.Get("user_email").(string)
+	run, err := services.Maintenance.Start(c.Request().Context(), req.Operations, startedBy)
+	if errors.Is(err, services.ErrMaintenanceRunning) {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "Maintenance is already running",
+			Code:    models.ErrCodeConflict,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to start maintenance", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to start maintenance",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	recordAudit(c, models.AuditMaintenanceStarted, "maintenance", strings.Join(req.Operations, ","), nil)
+	return c.JSON(http.StatusAccepted, GeocodeResponse{
+		Success: true,
+		Data:    run,
+		Message: "Maintenance started",
+	})
+}
+
+// GetMaintenanceHandler handles GET /api/v1/admin/maintenance - the status
+// of the latest maintenance run (admin endpoint)
+func GetMaintenanceHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    services.Maintenance.Latest(),
+	})
+}
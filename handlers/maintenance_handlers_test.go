@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartMaintenanceRejectsInvalidOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no operations", `{}`},
+		{"empty operations", `{"operations":[]}`},
+		{"unknown operation", `{"operations":["reindex","drop_tables"]}`},
+	}
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/maintenance", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, StartMaintenanceHandler(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
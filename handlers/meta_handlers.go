@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// callerAPIKeys resolves the caller's API keys from either an API key or a
+// user JWT. Anonymous callers get nil.
+func callerAPIKeys(c echo.Context) []models.APIKey {
+	if apiKey := c.Request().Header.Get("X-API-Key"); apiKey != "" {
+		if user, _, err := services.Auth.ValidateAPIKey(c.Request().Context(), apiKey); err == nil {
+			if keys, err := services.Auth.GetUserAPIKeys(c.Request().Context(), user.ID); err == nil {
+				return keys
+			}
+		}
+		return nil
+	}
+
+	parts := strings.SplitN(c.Request().Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil
+	}
+
+	userID := 0
+	if claims, err := services.Auth.ValidateJWT(parts[1]); err == nil {
+		userID = claims.UserID
+	} else if user, _, err := services.Auth.ValidateAPIKey(c.Request().Context(), parts[1]); err == nil {
+		userID = user.ID
+	}
+	if userID == 0 {
+		return nil
+	}
+
+	keys, err := services.Auth.GetUserAPIKeys(c.Request().Context(), userID)
+	if err != nil {
+		return nil
+	}
+	return keys
+}
+
+// GetPermissionsHandler lists every permission, the routes it grants, and
+// which of the caller's API keys hold it
+func GetPermissionsHandler(c echo.Context) error {
+	keys := callerAPIKeys(c)
+
+	type keyRef struct {
+		ID         int    `json:"id"`
+		Name       string `json:"name"`
+		KeyPreview string `json:"key_preview"`
+	}
+
+	definitions := services.Permissions.Definitions()
+	result := make([]map[string]interface{}, 0, len(definitions))
+	for _, def := range definitions {
+		entry := map[string]interface{}{
+			"name":        def.Name,
+			"description": def.Description,
+			"routes":      def.Routes,
+		}
+		if len(def.Aliases) > 0 {
+			entry["aliases"] = def.Aliases
+		}
+		if keys != nil {
+			holders := []keyRef{}
+			for i := range keys {
+				if services.Auth.HasPermission(&keys[i], def.Name) {
+					holders = append(holders, keyRef{ID: keys[i].ID, Name: keys[i].Name, KeyPreview: keys[i].KeyPreview})
+				}
+			}
+			entry["held_by_keys"] = holders
+		}
+		result = append(result, entry)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"permissions": result,
+			"wildcard":    services.WildcardPermission,
+		},
+		Count: len(result),
+	})
+}
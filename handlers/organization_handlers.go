@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreateOrganizationRequest represents organization creation data
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// InviteMemberRequest invites someone to an organization by email
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner member billing"`
+}
+
+// UpdateMemberRoleRequest changes a member's role
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner member billing"`
+}
+
+// AcceptInvitationRequest carries the token from an invitation email
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// organizationErrorResponse maps organization service errors to responses
+func organizationErrorResponse(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	code := models.ErrCodeInternal
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrOrgMemberNotFound):
+		status, code = http.StatusNotFound, models.ErrCodeNotFound
+	case errors.Is(err, services.ErrOrgPermissionDenied), errors.Is(err, services.ErrInvitationEmailMismatch):
+		status, code = http.StatusForbidden, models.ErrCodePermissionDenied
+	case errors.Is(err, services.ErrLastOrgOwner), errors.Is(err, services.ErrAlreadyOrgMember):
+		status, code = http.StatusConflict, models.ErrCodeConflict
+	case errors.Is(err, services.ErrInvitationInvalid):
+		status, code = http.StatusBadRequest, models.ErrCodeInvalidToken
+	}
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		logging.FromContext(c).Error("organization request failed", "error", err)
+		message = "Organization request failed"
+	}
+	return c.JSON(status, GeocodeResponse{
+		Success: false,
+		Error:   message,
+		Code:    code,
+	})
+}
+
+// organizationParams extracts the authenticated user and the :id path
+// parameter. ok is false when an error response has been written; the
+// caller returns err.
+func organizationParams(c echo.Context) (userID, orgID int, ok bool, err error) {
+	userID, ok = c.Get("user_id").(int)
+	if !ok {
+		return 0, 0, false, c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	orgID, convErr := strconv.Atoi(c.Param("id"))
+	if convErr != nil {
+		return 0, 0, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid organization ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	return userID, orgID, true, nil
+}
+
+// CreateOrganizationHandler creates an organization owned by the authenticated user
+func CreateOrganizationHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req CreateOrganizationRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	org, err := services.Organizations.CreateOrganization(c.Request().Context(), userID, req.Name)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    org,
+		Message: "Organization created",
+	})
+}
+
+// GetOrganizationsHandler lists the organizations the authenticated user belongs to
+func GetOrganizationsHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	orgs, err := services.Organizations.GetUserOrganizations(c.Request().Context(), userID)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    orgs,
+		Count:   len(orgs),
+	})
+}
+
+// GetOrganizationHandler returns an organization and its members
+func GetOrganizationHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+
+	org, err := services.Organizations.GetOrganization(c.Request().Context(), orgID, userID)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+	members, err := services.Organizations.GetMembers(c.Request().Context(), orgID, userID)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"organization": org,
+			"members":      members,
+		},
+	})
+}
+
+// GetOrganizationUsageHandler returns the organization's pooled usage
+// against its plan limits; owners and billing members only
+func GetOrganizationUsageHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+
+	usage, err := services.Organizations.GetUsage(c.Request().Context(), orgID, userID)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    usage,
+	})
+}
+
+// InviteOrganizationMemberHandler emails an invitation to join the organization
+func InviteOrganizationMemberHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+
+	var req InviteMemberRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	invitation, err := services.Organizations.InviteMember(c.Request().Context(), orgID, userID, req.Email, req.Role)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    invitation,
+		Message: "Invitation sent to " + invitation.Email,
+	})
+}
+
+// GetOrganizationInvitationsHandler lists pending invitations; owners only
+func GetOrganizationInvitationsHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+
+	invitations, err := services.Organizations.GetInvitations(c.Request().Context(), orgID, userID)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    invitations,
+		Count:   len(invitations),
+	})
+}
+
+// RevokeOrganizationInvitationHandler cancels a pending invitation; owners only
+func RevokeOrganizationInvitationHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+
+	invitationID, convErr := strconv.Atoi(c.Param("invitation_id"))
+	if convErr != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid invitation ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if err := services.Organizations.RevokeInvitation(c.Request().Context(), orgID, userID, invitationID); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Invitation revoked",
+	})
+}
+
+// AcceptOrganizationInvitationHandler joins the organization an invitation
+// token was issued for
+func AcceptOrganizationInvitationHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req AcceptInvitationRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	org, err := services.Organizations.AcceptInvitation(c.Request().Context(), req.Token, userID)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    org,
+		Message: "Joined " + org.Name,
+	})
+}
+
+// memberIDParam parses the :user_id path parameter
+func memberIDParam(c echo.Context) (int, bool, error) {
+	memberID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return 0, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid user ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	return memberID, true, nil
+}
+
+// UpdateOrganizationMemberHandler changes a member's role; owners only
+func UpdateOrganizationMemberHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+	memberID, ok, err := memberIDParam(c)
+	if !ok {
+		return err
+	}
+
+	var req UpdateMemberRoleRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	if err := services.Organizations.UpdateMemberRole(c.Request().Context(), orgID, userID, memberID, req.Role); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Member role updated",
+	})
+}
+
+// RemoveOrganizationMemberHandler removes a member, or lets a member leave.
+// Organization API keys the member created are deactivated.
+func RemoveOrganizationMemberHandler(c echo.Context) error {
+	userID, orgID, ok, err := organizationParams(c)
+	if !ok {
+		return err
+	}
+	memberID, ok, err := memberIDParam(c)
+	if !ok {
+		return err
+	}
+
+	if err := services.Organizations.RemoveMember(c.Request().Context(), orgID, userID, memberID); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Member removed",
+	})
+}
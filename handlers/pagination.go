@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// parsePagination reads limit and offset from the query string. A missing or
+// non-positive limit uses defaultLimit and larger values are capped at
+// maxLimit; a negative or invalid offset is treated as 0.
+func parsePagination(c echo.Context, defaultLimit, maxLimit int) (limit, offset int) {
+	limit = defaultLimit
+	if val, err := strconv.Atoi(c.QueryParam("limit")); err == nil && val > 0 {
+		limit = val
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if val, err := strconv.Atoi(c.QueryParam("offset")); err == nil && val > 0 {
+		offset = val
+	}
+	return limit, offset
+}
+
+// paginate builds the pagination envelope for one page of a list and sets the
+// matching RFC 5988 Link header (first, prev, next, last). Page URLs keep the
+// request's other query parameters. Links are only produced for GET requests,
+// since a POST search carries its filters in the body.
+func paginate(c echo.Context, total, limit, offset int) *models.Pagination {
+	p := &models.Pagination{Total: total, Limit: limit, Offset: offset}
+	if c.Request().Method != http.MethodGet || limit <= 0 {
+		return p
+	}
+
+	pageURL := func(pageOffset int) string {
+		u := *c.Request().URL
+		q := u.Query()
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(pageOffset))
+		u.RawQuery = q.Encode()
+		u.Scheme = c.Scheme()
+		u.Host = c.Request().Host
+		return u.String()
+	}
+
+	var links []string
+	link := func(rel string, pageOffset int) string {
+		href := pageURL(pageOffset)
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, href, rel))
+		return href
+	}
+
+	link("first", 0)
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		// Past the end, prev points at the last real page
+		if prev >= total && total > 0 {
+			prev = lastPageOffset(total, limit)
+		}
+		href := link("prev", prev)
+		p.Prev = &href
+	}
+	if offset+limit < total {
+		href := link("next", offset+limit)
+		p.Next = &href
+	}
+	link("last", lastPageOffset(total, limit))
+
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+	return p
+}
+
+// lastPageOffset is the offset of the final page, aligned to limit
+func lastPageOffset(total, limit int) int {
+	if total <= 0 {
+		return 0
+	}
+	return (total - 1) / limit * limit
+}
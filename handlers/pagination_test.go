@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		total  int
+		limit  int
+		offset int
+		next   string
+		prev   string
+		link   string
+	}{
+		{
+			name:   "first page",
+			method: http.MethodGet,
+			target: "/api/v1/addresses?city=Akron",
+			total:  120, limit: 50, offset: 0,
+			next: "http://example.com/api/v1/addresses?city=Akron&limit=50&offset=50",
+			link: `<http://example.com/api/v1/addresses?city=Akron&limit=50&offset=0>; rel="first", ` +
+				`<http://example.com/api/v1/addresses?city=Akron&limit=50&offset=50>; rel="next", ` +
+				`<http://example.com/api/v1/addresses?city=Akron&limit=50&offset=100>; rel="last"`,
+		},
+		{
+			name:   "middle page replaces existing offset",
+			method: http.MethodGet,
+			target: "/api/v1/counties?limit=50&offset=50",
+			total:  120, limit: 50, offset: 50,
+			next: "http://example.com/api/v1/counties?limit=50&offset=100",
+			prev: "http://example.com/api/v1/counties?limit=50&offset=0",
+		},
+		{
+			name:   "last page",
+			method: http.MethodGet,
+			target: "/api/v1/counties",
+			total:  120, limit: 50, offset: 100,
+			prev: "http://example.com/api/v1/counties?limit=50&offset=50",
+		},
+		{
+			name:   "offset past the end points prev at the last page",
+			method: http.MethodGet,
+			target: "/api/v1/counties",
+			total:  120, limit: 50, offset: 500,
+			prev: "http://example.com/api/v1/counties?limit=50&offset=100",
+		},
+		{
+			name:   "empty result",
+			method: http.MethodGet,
+			target: "/api/v1/search?city=Nowhere",
+			total:  0, limit: 50, offset: 0,
+			link: `<http://example.com/api/v1/search?city=Nowhere&limit=50&offset=0>; rel="first", ` +
+				`<http://example.com/api/v1/search?city=Nowhere&limit=50&offset=0>; rel="last"`,
+		},
+		{
+			name:   "POST search has no links",
+			method: http.MethodPost,
+			target: "/api/v1/addresses",
+			total:  120, limit: 50, offset: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			p := paginate(c, tt.total, tt.limit, tt.offset)
+			require.NotNil(t, p)
+			assert.Equal(t, tt.total, p.Total)
+			assert.Equal(t, tt.limit, p.Limit)
+			assert.Equal(t, tt.offset, p.Offset)
+
+			if tt.next == "" {
+				assert.Nil(t, p.Next)
+			} else if assert.NotNil(t, p.Next) {
+				assert.Equal(t, tt.next, *p.Next)
+			}
+			if tt.prev == "" {
+				assert.Nil(t, p.Prev)
+			} else if assert.NotNil(t, p.Prev) {
+				assert.Equal(t, tt.prev, *p.Prev)
+			}
+
+			if tt.method != http.MethodGet {
+				assert.Empty(t, rec.Header().Get("Link"))
+			} else if tt.link != "" {
+				assert.Equal(t, tt.link, rec.Header().Get("Link"))
+			}
+		})
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query  string
+		limit  int
+		offset int
+	}{
+		{"", 50, 0},
+		{"limit=10&offset=20", 10, 20},
+		{"limit=0&offset=-5", 50, 0},
+		{"limit=5000", 500, 0},
+		{"limit=abc&offset=xyz", 50, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			e := echo.New()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil), httptest.NewRecorder())
+
+			limit, offset := parsePagination(c, 50, 500)
+			assert.Equal(t, tt.limit, limit)
+			assert.Equal(t, tt.offset, offset)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PlanRequest is the body of plan create and update requests. Limits of -1
+// mean unlimited; prices are in dollars.
+type PlanRequest struct {
+	ID           string   `json:"id" validate:"omitempty,max=50"`
+	Name         string   `json:"name" validate:"required,max=100"`
+	MonthlyLimit int      `json:"monthly_limit" validate:"min=-1"`
+	DailyLimit   int      `json:"daily_limit" validate:"min=-1"`
+	PricePerCall float64  `json:"price_per_call" validate:"min=0"`
+	PriceMonthly float64  `json:"price_monthly" validate:"min=0"`
+	Features     []string `json:"features"`
+	IsPublic     *bool    `json:"is_public"`
+	SortOrder    int      `json:"sort_order"`
+	// CustomAddressLimit caps the rows of a user's private address lists
+	CustomAddressLimit int `json:"custom_address_limit" validate:"min=-1"`
+}
+
+// plan converts the request to a models.Plan; plans are public unless
+// is_public is false
+func (r PlanRequest) plan() models.Plan {
+	isPublic := r.IsPublic == nil || *r.IsPublic
+	return models.Plan{
+		ID:           r.ID,
+		Name:         r.Name,
+		MonthlyLimit: r.MonthlyLimit,
+		DailyLimit:   r.DailyLimit,
+		PricePerCall: r.PricePerCall,
+		PriceMonthly: r.PriceMonthly,
+		Features:     r.Features,
+		IsPublic:     isPublic,
+		SortOrder:    r.SortOrder,
+
+		CustomAddressLimit: r.CustomAddressLimit,
+	}
+}
+
+// planErrorResponse maps plan service errors to responses
+func planErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPlanNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "Plan not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrPlanExists):
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrCodeConflict,
+		})
+	}
+
+	logging.FromContext(c).Error("plan request failed", "error", err)
+	return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+		Success: false,
+		Error:   "Plan request failed",
+		Code:    models.ErrCodeInternal,
+	})
+}
+
+// GetPlansHandler returns the public pricing plans keyed by plan ID
+func GetPlansHandler(c echo.Context) error {
+	plans, err := services.Plans.ListPlans(c.Request().Context(), false)
+	if err != nil {
+		logging.FromContext(c).Error("failed to list plans", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get plans",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	byID := make(map[string]interface{}, len(plans))
+	for _, plan := range plans {
+		byID[plan.ID] = map[string]interface{}{
+			"name":           plan.Name,
+			"monthly_limit":  plan.MonthlyLimit,
+			"daily_limit":    plan.DailyLimit,
+			"price_per_call": plan.PricePerCall,
+			"price_monthly":  plan.PriceMonthly,
+			"features":       plan.Features,
+
+			"custom_address_limit": plan.CustomAddressLimit,
+		}
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"plans": byID,
+		},
+	})
+}
+
+// GetAdminPlansHandler handles GET /api/v1/admin/plans - list every plan,
+// including hidden ones
+func GetAdminPlansHandler(c echo.Context) error {
+	plans, err := services.Plans.ListPlans(c.Request().Context(), true)
+	if err != nil {
+		return planErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    plans,
+		Count:   len(plans),
+	})
+}
+
+// CreatePlanHandler handles POST /api/v1/admin/plans - add a pricing plan
+func CreatePlanHandler(c echo.Context) error {
+	var req PlanRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	if req.ID == "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Plan ID is required",
+			Code:    models.ErrCodeValidationFailed,
+		})
+	}
+
+	plan, err := services.Plans.CreatePlan(c.Request().Context(), req.plan())
+	if err != nil {
+		return planErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditPlanCreated, "plan", plan.ID, plan)
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    plan,
+		Message: "Plan created",
+	})
+}
+
+// UpdatePlanHandler handles PUT /api/v1/admin/plans/:id - replace a plan's
+// name, limits, prices and listing. Changed limits apply to everyone on the
+// plan from their next request.
+func UpdatePlanHandler(c echo.Context) error {
+	var req PlanRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	id := c.Param("id")
+
+	previous, err := services.Plans.GetPlan(c.Request().Context(), id)
+	if err != nil {
+		return planErrorResponse(c, err)
+	}
+
+	plan, err := services.Plans.UpdatePlan(c.Request().Context(), id, req.plan())
+	if err != nil {
+		return planErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditPlanUpdated, "plan", plan.ID, map[string]interface{}{
+		"before": previous,
+		"after":  plan,
+	})
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    plan,
+		Message: "Plan updated",
+	})
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// searchParamsFromRequest returns search parameters from a JSON body for POST
+// requests or from the query string otherwise, so GET and POST variants of a
+// search endpoint share one handler. POST keeps long filter sets and PII out
+// of URLs and access logs. ok is false when the JSON body can't be decoded.
+func searchParamsFromRequest[T any](c echo.Context, fromQuery func(echo.Context) *T, body *T) (*T, bool) {
+	if c.Request().Method != http.MethodPost {
+		return fromQuery(c), true
+	}
+	if err := (&echo.DefaultBinder{}).BindBody(c, body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// maxSimplifyTolerance bounds ?simplify=, in degrees. At 0.1 degrees (about
+// 11 km) a state is already a rough outline.
+const maxSimplifyTolerance = 0.1
+
+var simplifyToleranceError = fmt.Sprintf("'simplify' must be a tolerance in degrees between 0 and %g", maxSimplifyTolerance)
+
+// parseSimplifyTolerance reads ?simplify=, the boundary simplification
+// tolerance in degrees, returning 0 when it is absent. ok is false when it
+// isn't a number between 0 and maxSimplifyTolerance.
+func parseSimplifyTolerance(c echo.Context) (tolerance float64, ok bool) {
+	value := c.QueryParam("simplify")
+	if value == "" {
+		return 0, true
+	}
+	tolerance, err := strconv.ParseFloat(value, 64)
+	if err != nil || tolerance < 0 || tolerance > maxSimplifyTolerance {
+		return 0, false
+	}
+	return tolerance, true
+}
+
+var boundaryResolutionError = fmt.Sprintf("'resolution' must be one of %s, and can't be combined with 'simplify'", strings.Join(services.BoundaryResolutions, ", "))
+
+// parseBoundaryDetail reads how detailed a boundary should be: ?resolution=,
+// one of the pre-computed outlines, or ?simplify=, a tolerance applied per
+// request. Both are empty for the full boundary. ok is false, with the
+// error to report, when either is invalid or both are given.
+func parseBoundaryDetail(c echo.Context) (tolerance float64, resolution string, errMessage string) {
+	tolerance, ok := parseSimplifyTolerance(c)
+	if !ok {
+		return 0, "", simplifyToleranceError
+	}
+	resolution = strings.ToLower(c.QueryParam("resolution"))
+	if resolution == "" {
+		return tolerance, "", ""
+	}
+	if tolerance > 0 || !slices.Contains(services.BoundaryResolutions, resolution) {
+		return 0, "", boundaryResolutionError
+	}
+	return 0, resolution, ""
+}
+
+const bboxError = "'bbox' must be min_lng,min_lat,max_lng,max_lat in degrees, with each minimum below its maximum"
+
+// parseBBox reads ?bbox=min_lng,min_lat,max_lng,max_lat, returning nil when
+// it is absent. A value that isn't four numbers comes back as an empty box,
+// which validBBox rejects.
+func parseBBox(c echo.Context) []float64 {
+	value := c.QueryParam("bbox")
+	if value == "" {
+		return nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return []float64{}
+	}
+	bbox := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return []float64{}
+		}
+		bbox[i] = v
+	}
+	return bbox
+}
+
+// validBBox reports whether a bounding box from the query string or a JSON
+// body is absent or in range with each minimum below its maximum
+func validBBox(bbox []float64) bool {
+	if bbox == nil {
+		return true
+	}
+	if len(bbox) != 4 {
+		return false
+	}
+	minLng, minLat, maxLng, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	return minLng >= -180 && maxLng <= 180 && minLat >= -90 && maxLat <= 90 &&
+		minLng < maxLng && minLat < maxLat
+}
+
+var includeCensusError = includeError(models.IncludeCensus)
+
+// includeError is the message for an ?include= naming something other than
+// the allowed values
+func includeError(allowed ...string) string {
+	quoted := make([]string, len(allowed))
+	for i, value := range allowed {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	if len(quoted) == 1 {
+		return "'include' may only contain " + quoted[0]
+	}
+	return "'include' may only contain " + strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// parseInclude reads ?include=, a comma-separated list of extra data to add
+// to results, and returns the allowed values it names. ok is false when it
+// names anything else.
+func parseInclude(c echo.Context, allowed ...string) (include map[string]bool, ok bool) {
+	include = map[string]bool{}
+	value := c.QueryParam("include")
+	if value == "" {
+		return include, true
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if !slices.Contains(allowed, item) {
+			return nil, false
+		}
+		include[item] = true
+	}
+	return include, true
+}
+
+// includesCensus reads ?include= on endpoints where census is the only
+// extra data. ok is false when it names anything but census.
+func includesCensus(c echo.Context) (census bool, ok bool) {
+	include, ok := parseInclude(c, models.IncludeCensus)
+	return include[models.IncludeCensus], ok
+}
+
+// parseTimeParam parses a query parameter holding an RFC 3339 timestamp or a
+// YYYY-MM-DD date. A bare date used as an exclusive upper bound moves to the
+// following midnight so the whole day is included.
+func parseTimeParam(value string, upperBound bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// timeRangeParams reads an optional [from, to) time range from the named
+// query parameters. Missing parameters are left zero; each one that fails to
+// parse is reported as a field error.
+func timeRangeParams(c echo.Context, fromParam, toParam string) (from, to time.Time, fieldErrs []models.FieldError) {
+	for _, param := range []struct {
+		name       string
+		dest       *time.Time
+		upperBound bool
+	}{{fromParam, &from, false}, {toParam, &to, true}} {
+		value := c.QueryParam(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTimeParam(value, param.upperBound)
+		if err != nil {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   param.name,
+				Rule:    "datetime",
+				Message: param.name + " must be an RFC 3339 timestamp or a YYYY-MM-DD date",
+			})
+			continue
+		}
+		*param.dest = t
+	}
+	return from, to, fieldErrs
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSimplifyTolerance(t *testing.T) {
+	tests := []struct {
+		query     string
+		tolerance float64
+		ok        bool
+	}{
+		{"", 0, true},
+		{"simplify=0", 0, true},
+		{"simplify=0.01", 0.01, true},
+		{"simplify=0.1", 0.1, true},
+		{"simplify=0.5", 0, false},
+		{"simplify=-0.01", 0, false},
+		{"simplify=fine", 0, false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/states/OH/boundary?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			tolerance, ok := parseSimplifyTolerance(c)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.tolerance, tolerance)
+		})
+	}
+}
+
+func TestParseBoundaryDetail(t *testing.T) {
+	tests := []struct {
+		query      string
+		tolerance  float64
+		resolution string
+		valid      bool
+	}{
+		{"", 0, "", true},
+		{"simplify=0.01", 0.01, "", true},
+		{"resolution=low", 0, "low", true},
+		{"resolution=High", 0, "high", true},
+		{"resolution=low&simplify=0", 0, "low", true},
+		{"resolution=full", 0, "", false},
+		{"resolution=low&simplify=0.01", 0, "", false},
+		{"simplify=0.5", 0, "", false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/counties/Franklin/boundary?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			tolerance, resolution, errMessage := parseBoundaryDetail(c)
+			assert.Equal(t, tt.valid, errMessage == "")
+			assert.Equal(t, tt.tolerance, tolerance)
+			assert.Equal(t, tt.resolution, resolution)
+		})
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		query string
+		bbox  []float64
+		valid bool
+	}{
+		{"", nil, true},
+		{"bbox=-84.6,39.0,-84.3,39.3", []float64{-84.6, 39.0, -84.3, 39.3}, true},
+		{"bbox=-84.6,%2039.0,-84.3,39.3", []float64{-84.6, 39.0, -84.3, 39.3}, true},
+		{"bbox=-84.3,39.0,-84.6,39.3", []float64{-84.3, 39.0, -84.6, 39.3}, false},
+		{"bbox=-84.6,39.3,-84.3,39.3", []float64{-84.6, 39.3, -84.3, 39.3}, false},
+		{"bbox=-190,39.0,-84.3,39.3", []float64{-190, 39.0, -84.3, 39.3}, false},
+		{"bbox=-84.6,39.0,-84.3,95", []float64{-84.6, 39.0, -84.3, 95}, false},
+		{"bbox=-84.6,39.0,-84.3", []float64{}, false},
+		{"bbox=-84.6,39.0,east,39.3", []float64{}, false},
+		{"bbox=NaN,39.0,-84.3,39.3", nil, false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/addresses?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			bbox := parseBBox(c)
+			if tt.bbox != nil {
+				assert.Equal(t, tt.bbox, bbox)
+			}
+			assert.Equal(t, tt.valid, validBBox(bbox))
+		})
+	}
+}
+
+func TestIncludesCensus(t *testing.T) {
+	tests := []struct {
+		query  string
+		census bool
+		ok     bool
+	}{
+		{"", false, true},
+		{"include=census", true, true},
+		{"include=Census", true, true},
+		{"include=census,", true, true},
+		{"include=census,demographics", false, false},
+		{"include=tract", false, false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/geocode/43215?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			census, ok := includesCensus(c)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.census, census)
+		})
+	}
+}
+
+func TestParseInclude(t *testing.T) {
+	tests := []struct {
+		query   string
+		include map[string]bool
+		ok      bool
+	}{
+		{"", map[string]bool{}, true},
+		{"include=demographics", map[string]bool{"demographics": true}, true},
+		{"include=census,%20Demographics", map[string]bool{"census": true, "demographics": true}, true},
+		{"include=demographics,tract", nil, false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/geocode/43215?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			include, ok := parseInclude(c, models.IncludeCensus, models.IncludeDemographics)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.include, include)
+		})
+	}
+
+	assert.Equal(t, `'include' may only contain "census"`, includeError(models.IncludeCensus))
+	assert.Equal(t, `'include' may only contain "census" or "demographics"`,
+		includeError(models.IncludeCensus, models.IncludeDemographics))
+}
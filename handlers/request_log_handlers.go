@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// parseStatusFilter reads a status filter: a class such as "4xx" or an exact
+// code such as "404"
+func parseStatusFilter(value string) (min, max int, ok bool) {
+	if class, found := strings.CutSuffix(strings.ToLower(value), "xx"); found {
+		n, err := strconv.Atoi(class)
+		if err != nil || n < 1 || n > 5 || len(class) != 1 {
+			return 0, 0, false
+		}
+		return n * 100, n*100 + 99, true
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil || code < 100 || code > 599 {
+		return 0, 0, false
+	}
+	return code, code, true
+}
+
+// ListRequestsHandler returns recent API calls for the admin request console,
+// newest first (admin only). Filters: user_id, status ("4xx" or "404"),
+// since and until.
+func ListRequestsHandler(c echo.Context) error {
+	limit, offset := parsePagination(c, 100, 1000)
+	filter := models.RequestLogFilter{Limit: limit, Offset: offset}
+
+	var fieldErrs []models.FieldError
+	if value := c.QueryParam("user_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   "user_id",
+				Rule:    "numeric",
+				Message: "user_id must be a positive integer",
+			})
+		}
+		filter.UserID = id
+	}
+	if value := c.QueryParam("status"); value != "" {
+		min, max, ok := parseStatusFilter(value)
+		if !ok {
+			fieldErrs = append(fieldErrs, models.FieldError{
+				Field:   "status",
+				Rule:    "status",
+				Message: "status must be a class such as 4xx or a status code such as 404",
+			})
+		}
+		filter.MinStatus, filter.MaxStatus = min, max
+	}
+	since, until, timeErrs := timeRangeParams(c, "since", "until")
+	filter.Since, filter.Until = since, until
+	fieldErrs = append(fieldErrs, timeErrs...)
+	if len(fieldErrs) > 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fieldErrs[0].Message,
+			Code:    models.ErrCodeValidationFailed,
+			Details: fieldErrs,
+		})
+	}
+
+	records, total, err := services.RequestLog.List(c.Request().Context(), filter)
+	if err != nil {
+		logging.FromContext(c).Error("failed to list requests", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get requests",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success:    true,
+		Data:       records,
+		Count:      len(records),
+		Pagination: paginate(c, total, limit, offset),
+	})
+}
+
+// ReplayRequestHandler re-executes a recorded GET call as the API key that
+// made it and compares the result with the original (admin only). Replays
+// skip rate limits and aren't recorded as usage.
+func ReplayRequestHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	record, err := services.RequestLog.Get(c.Request().Context(), id)
+	if errors.Is(err, services.ErrRequestNotFound) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "Request not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to get request", "id", id, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get request",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	var reason string
+	switch {
+	case record.Method != http.MethodGet:
+		reason = "Only GET requests can be replayed"
+	case record.Path == nil:
+		reason = "Request was recorded without its path; set USAGE_CAPTURE_REQUESTS=true to capture requests for replay"
+	case record.APIKeyID == nil:
+		reason = "Request was not made with an API key"
+	}
+	if reason != "" {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   reason,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	target := *record.Path
+	if record.QueryString != nil {
+		target += "?" + *record.QueryString
+	}
+	ctx := services.WithReplayAPIKey(c.Request().Context(), *record.APIKeyID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Recorded request path is invalid",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	started := time.Now()
+	c.Echo().ServeHTTP(rec, req)
+	result := models.RequestReplayResult{
+		StatusCode:     rec.Code,
+		ResponseTimeMS: int(time.Since(started).Milliseconds()),
+		Body:           replayBody(rec.Body.Bytes()),
+	}
+
+	recordAudit(c, models.AuditRequestReplayed, "usage_record", strconv.FormatInt(id, 10), nil)
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: models.RequestReplay{
+			Original: *record,
+			Replay:   result,
+			Diff:     services.ReplayDiff(*record, result),
+		},
+	})
+}
+
+// replayBody embeds a JSON response as is and anything else as a string
+func replayBody(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return body
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
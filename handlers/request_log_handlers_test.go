@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatusFilter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max int
+		ok       bool
+	}{
+		{"4xx", 400, 499, true},
+		{"5XX", 500, 599, true},
+		{"404", 404, 404, true},
+		{"6xx", 0, 0, false},
+		{"40x", 0, 0, false},
+		{"99", 0, 0, false},
+		{"error", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			min, max, ok := parseStatusFilter(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.min, min)
+			assert.Equal(t, tt.max, max)
+		})
+	}
+}
+
+func TestListRequestsRejectsInvalidFilters(t *testing.T) {
+	for _, query := range []string{"user_id=abc", "status=teapot", "since=yesterday"} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/requests?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, ListRequestsHandler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"VALIDATION_FAILED"`)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonKeys marshals v and returns its top-level keys
+func jsonKeys(t *testing.T, v interface{}) (map[string]json.RawMessage, []string) {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(raw, &fields))
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fields, keys
+}
+
+// TestResponseContracts pins the field set of typed responses so the OpenAPI
+// spec and generated SDKs see the same keys on every response
+func TestResponseContracts(t *testing.T) {
+	lat, lng := 39.1, -84.5
+
+	tests := []struct {
+		name      string
+		value     interface{}
+		keys      []string
+		nullKeys  []string
+		arrayKeys []string
+	}{
+		{
+			name:     "admin user with nullable columns unset",
+			value:    models.AdminUser{ID: 1, Email: "a@example.com", PlanType: "free", CreatedAt: time.Now()},
+			keys:     []string{"active_keys", "company", "created_at", "email", "email_verified", "id", "is_active", "is_admin", "last_active_at", "monthly_usage", "name", "plan_type", "today_usage", "total_usage"},
+			nullKeys: []string{"company", "last_active_at", "name"},
+		},
+		{
+			name:     "admin API key never used",
+			value:    models.AdminAPIKey{ID: 1, CreatedAt: time.Now()},
+			keys:     []string{"created_at", "id", "is_active", "key_preview", "last_used_at", "name", "user_email"},
+			nullKeys: []string{"last_used_at"},
+		},
+		{
+			name:  "admin user status",
+			value: models.AdminUserStatus{ID: 1},
+			keys:  []string{"company", "email", "id", "is_active", "is_admin", "name", "plan_type"},
+		},
+		{
+			name:      "user usage metrics without traffic",
+			value:     models.UserUsageMetrics{Endpoints: []models.EndpointMetrics{}, DailyUsage: []models.UserDailyMetrics{}},
+			keys:      []string{"avg_response_time", "billable_calls", "daily_usage", "email", "endpoints", "error_count", "name", "plan_type", "success_count", "total_calls", "user_id"},
+			nullKeys:  []string{"name"},
+			arrayKeys: []string{"daily_usage", "endpoints"},
+		},
+		{
+			name:      "admin analytics without traffic",
+			value:     models.AdminAnalytics{Endpoints: []models.EndpointMetrics{}, DailyUsage: []models.AnalyticsDailyUsage{}},
+			keys:      []string{"avg_response_time", "billable_calls", "daily_usage", "endpoints", "error_count", "success_count", "total_calls"},
+			arrayKeys: []string{"daily_usage", "endpoints"},
+		},
+		{
+			name:  "system status",
+			value: models.SystemStatus{},
+			keys:  []string{"database_circuit", "database_connected", "migrations_current"},
+		},
+		{
+			name:  "state error without context",
+			value: models.StateErrorResponse{Error: "State identifier is required"},
+			keys:  []string{"error"},
+		},
+		{
+			name:  "state error with coordinates",
+			value: models.StateErrorResponse{Error: "No state found at coordinates", Lat: &lat, Lng: &lng},
+			keys:  []string{"error", "lat", "lng"},
+		},
+		{
+			name:  "state lookup",
+			value: models.StateLookupResponse{State: &models.State{}, Coordinates: models.Coordinates{Lat: lat, Lng: lng}},
+			keys:  []string{"coordinates", "state"},
+		},
+		{
+			name:  "state boundary feature",
+			value: models.StateBoundaryFeature{Type: "Feature", Geometry: json.RawMessage(`{"type":"MultiPolygon","coordinates":[]}`)},
+			keys:  []string{"geometry", "properties", "type"},
+		},
+		{
+			name:      "empty dataset list",
+			value:     models.DatasetListResponse{Datasets: []models.Dataset{}, Limit: 50},
+			keys:      []string{"datasets", "limit", "offset", "total"},
+			arrayKeys: []string{"datasets"},
+		},
+		{
+			name:     "pagination at the last page",
+			value:    models.Pagination{Total: 10, Limit: 50},
+			keys:     []string{"limit", "next", "offset", "prev", "total"},
+			nullKeys: []string{"next", "prev"},
+		},
+		{
+			name:  "validation error",
+			value: GeocodeResponse{Error: "name is required", Code: models.ErrCodeValidationFailed, Details: []models.FieldError{{Field: "name", Rule: "required", Message: "name is required"}}},
+			keys:  []string{"code", "details", "error", "success"},
+		},
+		{
+			name:  "dataset error",
+			value: DatasetErrorResponse{Error: "failed to get dataset"},
+			keys:  []string{"error", "success"},
+		},
+		{
+			name:  "dataset conflict",
+			value: DatasetErrorResponse{Error: "dataset already exists", ExistingDataset: &models.Dataset{}},
+			keys:  []string{"error", "existing_dataset", "success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, keys := jsonKeys(t, tt.value)
+			assert.Equal(t, tt.keys, keys)
+
+			for _, key := range tt.nullKeys {
+				assert.Equal(t, "null", string(fields[key]), "%s should be an explicit null", key)
+			}
+			for _, key := range tt.arrayKeys {
+				assert.Equal(t, "[]", string(fields[key]), "%s should be an empty array, not null", key)
+			}
+		})
+	}
+}
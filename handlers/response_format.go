@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Response formats besides JSON for legacy integrations, chosen per request
+// by middleware.ResponseFormats on the read-only endpoints that allow them
+const (
+	// ResponseFormatKey is the echo context key the chosen format is stored under
+	ResponseFormatKey = "response_format"
+	// JSONPCallbackKey is the echo context key of the JSONP callback name
+	JSONPCallbackKey = "jsonp_callback"
+
+	ResponseFormatXML   = "xml"
+	ResponseFormatJSONP = "jsonp"
+)
+
+// xmlRootElement wraps every XML response
+const xmlRootElement = "response"
+
+// xmlNamePattern matches JSON keys that are usable as XML element names.
+// Other keys, such as ZIP codes in county_weights, are written as
+// <entry key="...">.
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// writeFormatted writes a response in the format the request negotiated,
+// returning false when it is plain JSON and left to the caller
+func writeFormatted(c echo.Context, i interface{}, indent string) (bool, error) {
+	switch c.Get(ResponseFormatKey) {
+	case ResponseFormatXML:
+		body, err := json.Marshal(i)
+		if err != nil {
+			return true, err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+		return true, writeXML(c.Response(), body, indent)
+
+	case ResponseFormatJSONP:
+		callback, _ := c.Get(JSONPCallbackKey).(string)
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJavaScriptCharsetUTF8)
+		// The leading comment stops the callback name being read as anything
+		// but a function call if a browser sniffs the response
+		if _, err := io.WriteString(c.Response(), "/**/"+callback+"("); err != nil {
+			return true, err
+		}
+		if err := (echo.DefaultJSONSerializer{}).Serialize(c, i, indent); err != nil {
+			return true, err
+		}
+		_, err := io.WriteString(c.Response(), ");")
+		return true, err
+	}
+	return false, nil
+}
+
+// writeXML converts a JSON response to XML with the same field names: each
+// object key becomes an element, array items become <item> elements and
+// null becomes an empty element. Going through the JSON encoding keeps the
+// field names, omitted fields and custom encodings of the response structs.
+func writeXML(w io.Writer, body []byte, indent string) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if indent != "" {
+		enc.Indent("", indent)
+	}
+	if err := writeXMLValue(enc, dec, xml.StartElement{Name: xml.Name{Local: xmlRootElement}}); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// writeXMLValue reads the next JSON value and writes it as the element start
+func writeXMLValue(enc *xml.Encoder, dec *json.Decoder, start xml.StartElement) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		for dec.More() {
+			child := xml.StartElement{Name: xml.Name{Local: "item"}}
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child = xmlElement(key.(string))
+			}
+			if err := writeXMLValue(enc, dec, child); err != nil {
+				return err
+			}
+		}
+		// The closing ] or }
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(t))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlElement returns the element for a JSON key
+func xmlElement(key string) xml.StartElement {
+	if xmlNamePattern.MatchString(key) && !strings.HasPrefix(strings.ToLower(key), "xml") {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseFormats(t *testing.T) {
+	response := GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"zip_code":       "43215",
+			"county_weights": map[string]string{"39049": "100"},
+			"county_names":   []string{"Franklin", "Delaware"},
+			"zcta_parent":    nil,
+			"imprecise":      false,
+		},
+		Count: 1,
+	}
+
+	serve := func(t *testing.T, format, callback string) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.JSONSerializer = LocalizedJSONSerializer{}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/geocode/43215", nil), rec)
+		if format != "" {
+			c.Set(ResponseFormatKey, format)
+			c.Set(JSONPCallbackKey, callback)
+		}
+		require.NoError(t, c.JSON(http.StatusOK, response))
+		return rec
+	}
+
+	t.Run("json by default", func(t *testing.T) {
+		rec := serve(t, "", "")
+		assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Body.String(), `"zip_code":"43215"`)
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		rec := serve(t, ResponseFormatXML, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+		body := rec.Body.String()
+		assert.Contains(t, body, `<?xml version="1.0" encoding="UTF-8"?>`)
+		assert.Contains(t, body, `<response><success>true</success><data>`)
+		assert.Contains(t, body, `<county_names><item>Franklin</item><item>Delaware</item></county_names>`)
+		assert.Contains(t, body, `<county_weights><entry key="39049">100</entry></county_weights>`)
+		assert.Contains(t, body, `<zcta_parent></zcta_parent>`)
+		assert.Contains(t, body, `<count>1</count></response>`)
+	})
+
+	t.Run("jsonp", func(t *testing.T) {
+		rec := serve(t, ResponseFormatJSONP, "handle")
+		assert.Equal(t, echo.MIMEApplicationJavaScriptCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+		assert.Regexp(t, `^/\*\*/handle\(\{"success":true,.*\}\n\);$`, rec.Body.String())
+	})
+
+	t.Run("xml errors keep their status", func(t *testing.T) {
+		e := echo.New()
+		e.JSONSerializer = LocalizedJSONSerializer{}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v1/geocode/00000", nil), rec)
+		c.Set(ResponseFormatKey, ResponseFormatXML)
+		require.NoError(t, c.JSON(http.StatusNotFound, GeocodeResponse{Error: "ZIP code not found", Code: models.ErrCodeNotFound}))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<error>ZIP code not found</error><code>NOT_FOUND</code>`)
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSLOStatusHandler reports every endpoint SLO's compliance and burn rates
+// (admin only)
+func GetSLOStatusHandler(c echo.Context) error {
+	statuses, err := services.SLO.GetStatus(c.Request().Context())
+	if err != nil {
+		logging.FromContext(c).Error("failed to get SLO status", "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get SLO status",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    statuses,
+		Count:   len(statuses),
+	})
+}
+
+// UpsertSLOTargetHandler creates or replaces an endpoint's SLO target (admin
+// only)
+func UpsertSLOTargetHandler(c echo.Context) error {
+	var target models.SLOTarget
+	if ok, err := bindAndValidate(c, &target); !ok {
+		return err
+	}
+	target.Endpoint = c.Param("endpoint")
+
+	saved, err := services.SLO.UpsertTarget(c.Request().Context(), target)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if !strings.HasPrefix(err.Error(), "failed to") {
+			status = http.StatusBadRequest
+		}
+		return c.JSON(status, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(status),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    saved,
+		Message: "SLO target saved",
+	})
+}
+
+// DeleteSLOTargetHandler removes an endpoint's SLO target (admin only)
+func DeleteSLOTargetHandler(c echo.Context) error {
+	if err := services.SLO.DeleteTarget(c.Request().Context(), c.Param("endpoint")); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(status),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "SLO target deleted",
+	})
+}
@@ -4,14 +4,75 @@ import (
 	"net/http"
 	"strconv"
 
+	"geocoding-api/database"
 	"geocoding-api/models"
 	"geocoding-api/services"
 
 	"github.com/labstack/echo/v4"
 )
 
-// SearchStatesHandler handles GET /api/v1/states - Search for states
+// SearchStatesHandler handles GET and POST /api/v1/states - Search for states
 func SearchStatesHandler(c echo.Context) error {
+	bound, ok := searchParamsFromRequest(c, parseStateSearchParams, &models.StateSearchParams{})
+	if !ok {
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: "Invalid JSON request body",
+			Code:  models.ErrCodeInvalidRequest,
+		})
+	}
+	params := *bound
+	if !validBBox(params.BBox) {
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: bboxError,
+			Code:  models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = 50
+	}
+
+	// If coordinates are provided, use point-in-polygon lookup
+	if params.Lat != 0 && params.Lng != 0 {
+		if database.Embedded() {
+			return EmbeddedUnsupportedHandler(c)
+		}
+		state, err := services.State.GetStateByCoordinates(c.Request().Context(), params.Lat, params.Lng)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, models.StateErrorResponse{
+				Error: "State not found at coordinates",
+				Code:  models.ErrCodeNotFound,
+				Lat:   &params.Lat,
+				Lng:   &params.Lng,
+			})
+		}
+
+		return c.JSON(http.StatusOK, models.StateSearchResponse{
+			States: []models.State{*state},
+			Total:  1,
+			Limit:  1,
+			Offset: 0,
+		})
+	}
+
+	// Otherwise, use text search. The embedded database has no boundaries
+	// to compare a bounding box against.
+	if params.BBox != nil && database.Embedded() {
+		return EmbeddedUnsupportedHandler(c)
+	}
+	response, err := services.State.SearchStates(c.Request().Context(), params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.StateErrorResponse{
+			Error: "Failed to search states",
+			Code:  models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// parseStateSearchParams reads state search parameters from the query string
+func parseStateSearchParams(c echo.Context) *models.StateSearchParams {
 	var params models.StateSearchParams
 	
 	// Parse query parameters
@@ -30,63 +91,36 @@ func SearchStatesHandler(c echo.Context) error {
 	if limit := c.QueryParam("limit"); limit != "" {
 		params.Limit, _ = strconv.Atoi(limit)
 	}
-	if params.Limit <= 0 {
-		params.Limit = 50
-	}
 
 	if offset := c.QueryParam("offset"); offset != "" {
 		params.Offset, _ = strconv.Atoi(offset)
 	}
+	params.BBox = parseBBox(c)
 
-	// If coordinates are provided, use point-in-polygon lookup
-	if params.Lat != 0 && params.Lng != 0 {
-		state, err := services.State.GetStateByCoordinates(params.Lat, params.Lng)
-		if err != nil {
-			return c.JSON(http.StatusNotFound, map[string]interface{}{
-				"error": "State not found at coordinates",
-				"lat":   params.Lat,
-				"lng":   params.Lng,
-			})
-		}
-
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"states": []models.State{*state},
-			"total":  1,
-			"limit":  1,
-			"offset": 0,
-		})
-	}
-
-	// Otherwise, use text search
-	response, err := services.State.SearchStates(params)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"error": "Failed to search states",
-		})
-	}
-
-	return c.JSON(http.StatusOK, response)
+	return &params
 }
 
 // GetStateHandler handles GET /api/v1/states/:identifier - Get state details
 func GetStateHandler(c echo.Context) error {
 	identifier := c.Param("identifier")
 	if identifier == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error": "State identifier is required",
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: "State identifier is required",
+			Code:  models.ErrCodeInvalidRequest,
 		})
 	}
 
-	state, err := services.State.GetStateByIdentifier(identifier)
+	state, err := services.State.GetStateByIdentifier(c.Request().Context(), identifier)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"error": "State not found",
-			"identifier": identifier,
+		return c.JSON(http.StatusNotFound, models.StateErrorResponse{
+			Error:      "State not found",
+			Code:       models.ErrCodeNotFound,
+			Identifier: identifier,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"state": state,
+	return c.JSON(http.StatusOK, models.StateResponse{
+		State: state,
 	})
 }
 
@@ -94,16 +128,26 @@ func GetStateHandler(c echo.Context) error {
 func GetStateBoundaryHandler(c echo.Context) error {
 	identifier := c.Param("identifier")
 	if identifier == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error": "State identifier is required",
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: "State identifier is required",
+			Code:  models.ErrCodeInvalidRequest,
+		})
+	}
+
+	tolerance, resolution, errMessage := parseBoundaryDetail(c)
+	if errMessage != "" {
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: errMessage,
+			Code:  models.ErrCodeInvalidRequest,
 		})
 	}
 
-	geoJSON, err := services.State.GetStateBoundaryGeoJSON(identifier)
+	geoJSON, err := services.State.GetStateBoundaryGeoJSON(c.Request().Context(), identifier, tolerance, resolution)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"error": "State boundary not found",
-			"identifier": identifier,
+		return c.JSON(http.StatusNotFound, models.StateErrorResponse{
+			Error:      "State boundary not found",
+			Code:       models.ErrCodeNotFound,
+			Identifier: identifier,
 		})
 	}
 
@@ -116,39 +160,40 @@ func GetStateByLocationHandler(c echo.Context) error {
 	lngStr := c.QueryParam("lng")
 
 	if latStr == "" || lngStr == "" {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error": "Both lat and lng parameters are required",
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: "Both lat and lng parameters are required",
+			Code:  models.ErrCodeInvalidRequest,
 		})
 	}
 
 	lat, err := strconv.ParseFloat(latStr, 64)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error": "Invalid latitude value",
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: "Invalid latitude value",
+			Code:  models.ErrCodeInvalidCoordinates,
 		})
 	}
 
 	lng, err := strconv.ParseFloat(lngStr, 64)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{
-			"error": "Invalid longitude value",
+		return c.JSON(http.StatusBadRequest, models.StateErrorResponse{
+			Error: "Invalid longitude value",
+			Code:  models.ErrCodeInvalidCoordinates,
 		})
 	}
 
-	state, err := services.State.GetStateByCoordinates(lat, lng)
+	state, err := services.State.GetStateByCoordinates(c.Request().Context(), lat, lng)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]interface{}{
-			"error": "No state found at coordinates",
-			"lat":   lat,
-			"lng":   lng,
+		return c.JSON(http.StatusNotFound, models.StateErrorResponse{
+			Error: "No state found at coordinates",
+			Code:  models.ErrCodeNotFound,
+			Lat:   &lat,
+			Lng:   &lng,
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"state": state,
-		"coordinates": map[string]float64{
-			"lat": lat,
-			"lng": lng,
-		},
+	return c.JSON(http.StatusOK, models.StateLookupResponse{
+		State:       state,
+		Coordinates: models.Coordinates{Lat: lat, Lng: lng},
 	})
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -33,7 +34,7 @@ func setupStateTestDB(t *testing.T) {
 	}
 
 	if count == 0 {
-		if err := services.InitializeStateData(); err != nil {
+		if _, err := services.DataLoads.Run(context.Background(), models.DataLoadStates, false); err != nil {
 			t.Logf("Warning: Failed to initialize state data: %v", err)
 			t.Skip("Skipping test - state data not available")
 		}
@@ -378,7 +379,7 @@ func TestStateServiceDirectly(t *testing.T) {
 			Limit: 10,
 		}
 		
-		response, err := services.State.SearchStates(params)
+		response, err := services.State.SearchStates(context.Background(), params)
 		assert.NoError(t, err)
 		assert.NotNil(t, response)
 		assert.Greater(t, len(response.States), 0)
@@ -387,43 +388,43 @@ func TestStateServiceDirectly(t *testing.T) {
 
 	t.Run("Get state by various identifiers", func(t *testing.T) {
 		// By abbreviation
-		state, err := services.State.GetStateByIdentifier("CA")
+		state, err := services.State.GetStateByIdentifier(context.Background(), "CA")
 		assert.NoError(t, err)
 		assert.Equal(t, "CA", state.StateAbbr)
 		
 		// By FIPS
-		state, err = services.State.GetStateByIdentifier("06")
+		state, err = services.State.GetStateByIdentifier(context.Background(), "06")
 		assert.NoError(t, err)
 		assert.Equal(t, "CA", state.StateAbbr)
 		
 		// By name
-		state, err = services.State.GetStateByIdentifier("California")
+		state, err = services.State.GetStateByIdentifier(context.Background(), "California")
 		assert.NoError(t, err)
 		assert.Equal(t, "CA", state.StateAbbr)
 	})
 
 	t.Run("Point-in-polygon lookup", func(t *testing.T) {
 		// Los Angeles coordinates
-		state, err := services.State.GetStateByCoordinates(34.0522, -118.2437)
+		state, err := services.State.GetStateByCoordinates(context.Background(), 34.0522, -118.2437)
 		assert.NoError(t, err)
 		assert.Equal(t, "CA", state.StateAbbr)
 		
 		// Miami coordinates
-		state, err = services.State.GetStateByCoordinates(25.7617, -80.1918)
+		state, err = services.State.GetStateByCoordinates(context.Background(), 25.7617, -80.1918)
 		assert.NoError(t, err)
 		assert.Equal(t, "FL", state.StateAbbr)
 	})
 
 	t.Run("Get boundary GeoJSON", func(t *testing.T) {
-		geoJSON, err := services.State.GetStateBoundaryGeoJSON("CA")
+		geoJSON, err := services.State.GetStateBoundaryGeoJSON(context.Background(), "CA", 0, "")
 		assert.NoError(t, err)
 		assert.NotNil(t, geoJSON)
-		assert.Equal(t, "Feature", geoJSON["type"])
+		assert.Equal(t, "Feature", geoJSON.Type)
 		
-		properties := geoJSON["properties"].(map[string]interface{})
-		assert.Equal(t, "CA", properties["state_abbr"])
+		assert.Equal(t, "CA", geoJSON.Properties.StateAbbr)
 		
-		geometry := geoJSON["geometry"].(map[string]interface{})
+		var geometry map[string]interface{}
+		assert.NoError(t, json.Unmarshal(geoJSON.Geometry, &geometry))
 		assert.Equal(t, "MultiPolygon", geometry["type"])
 	})
 }
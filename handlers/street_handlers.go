@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"geocoding-api/models"
+	"geocoding-api/services"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SearchStreetsHandler handles street index search requests
+func SearchStreetsHandler(c echo.Context) error {
+	var params models.StreetSearchParams
+
+	params.Query = c.QueryParam("q")
+	params.City = c.QueryParam("city")
+	params.Postcode = c.QueryParam("postcode")
+	params.County = c.QueryParam("county")
+
+	if params.Query == "" && params.City == "" && params.Postcode == "" && params.County == "" {
+		return c.JSON(http.StatusBadRequest, models.StreetSearchResponse{
+			Success: false,
+			Error:   "Query parameter 'q' or a city, postcode, or county filter is required",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		if val, err := strconv.Atoi(limit); err == nil {
+			params.Limit = val
+		}
+	}
+	if offset := c.QueryParam("offset"); offset != "" {
+		if val, err := strconv.Atoi(offset); err == nil {
+			params.Offset = val
+		}
+	}
+
+	streets, total, err := services.Street.SearchStreets(c.Request().Context(), params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.StreetSearchResponse{
+			Success: false,
+			Error:   "Failed to search streets: " + err.Error(),
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	filters := make(map[string]interface{})
+	if params.City != "" {
+		filters["city"] = params.City
+	}
+	if params.Postcode != "" {
+		filters["postcode"] = params.Postcode
+	}
+	if params.County != "" {
+		filters["county"] = params.County
+	}
+
+	return c.JSON(http.StatusOK, models.StreetSearchResponse{
+		Success: true,
+		Data:    streets,
+		Count:   len(streets),
+		Total:   total,
+		Query:   params.Query,
+		Filters: filters,
+	})
+}
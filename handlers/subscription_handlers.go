@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"geocoding-api/config"
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSubscriptionHandler handles GET /api/v1/user/subscription - the
+// user's plan, limits and usage this billing period
+func GetSubscriptionHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	sub, err := services.Auth.GetSubscription(c.Request().Context(), userID)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to get subscription", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get subscription",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    sub,
+	})
+}
+
+// ChangePlanHandler handles PUT /api/v1/user/subscription - move the user to
+// another public plan. dry_run=true returns the proration and downgrade
+// checks without changing anything. A downgrade is refused while current
+// usage exceeds one of the new plan's limits.
+func ChangePlanHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.PlanChangeRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	ctx := c.Request().Context()
+	if dryRun {
+		preview, err := services.Auth.PreviewPlanChange(ctx, userID, req.PlanType)
+		if err != nil {
+			return planChangeErrorResponse(c, err, req.PlanType)
+		}
+		return c.JSON(http.StatusOK, GeocodeResponse{
+			Success: true,
+			Data:    preview,
+		})
+	}
+
+	preview, err := services.Auth.ChangePlan(ctx, userID, req.PlanType)
+	if errors.Is(err, services.ErrPlanChangeBlocked) {
+		return c.JSON(http.StatusConflict, GeocodeResponse{
+			Success: false,
+			Error:   "Your current usage exceeds the " + req.PlanType + " plan's limits",
+			Code:    models.ErrCodeConflict,
+			Data:    preview,
+		})
+	}
+	if err != nil {
+		return planChangeErrorResponse(c, err, req.PlanType)
+	}
+
+	recordAudit(c, models.AuditUserPlanChanged, "user", strconv.Itoa(userID),
+		map[string]interface{}{"previous_plan": preview.CurrentPlan, "plan_type": preview.NewPlan, "self_service": true})
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    preview,
+		Message: "Plan changed to " + preview.NewPlan,
+	})
+}
+
+// planChangeErrorResponse maps plan change errors to responses
+func planChangeErrorResponse(c echo.Context, err error, planType string) error {
+	switch {
+	case errors.Is(err, services.ErrPlanNotFound):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Unknown plan: " + planType,
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	case errors.Is(err, services.ErrPlanUnchanged):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "You're already on the " + planType + " plan",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	logging.FromContext(c).Error("failed to change plan", "error", err)
+	return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+		Success: false,
+		Error:   "Failed to change plan",
+		Code:    models.ErrCodeInternal,
+	})
+}
+
+// GetOverageHandler handles GET /api/v1/user/overage - the user's overage
+// billing opt-in and overage spend this billing period
+func GetOverageHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	settings, err := services.Auth.GetOverageSettings(c.Request().Context(), userID)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to get overage settings", "user_id", userID, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get overage settings",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    settings,
+	})
+}
+
+// UpdateOverageHandler handles PUT /api/v1/user/overage - opt in to or out
+// of billing for calls past the monthly limit. Opting in needs a spend cap.
+func UpdateOverageHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.OverageSettingsRequest
+	if ok, err := bindAndValidate(c, &req); !ok {
+		return err
+	}
+
+	settings, err := services.Auth.UpdateOverageSettings(c.Request().Context(), userID, *req.Enabled, req.SpendCap)
+	if err != nil {
+		return overageErrorResponse(c, err)
+	}
+
+	recordAudit(c, models.AuditUserOverageChanged, "user", strconv.Itoa(userID),
+		map[string]interface{}{"enabled": settings.Enabled, "spend_cap": settings.SpendCap})
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    settings,
+	})
+}
+
+// overageErrorResponse maps overage settings errors to responses
+func overageErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrOverageUnavailable):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Overage billing isn't available for your plan",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	case errors.Is(err, services.ErrOverageSpendCapRequired):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "A spend_cap is required to enable overage billing",
+			Code:    models.ErrCodeValidationFailed,
+		})
+	case errors.Is(err, services.ErrOverageSpendCapTooHigh):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("spend_cap must not exceed %g", config.Get().Billing.MaxOverageSpendCap),
+			Code:    models.ErrCodeValidationFailed,
+		})
+	case errors.Is(err, services.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   "User not found",
+			Code:    models.ErrCodeNotFound,
+		})
+	}
+	logging.FromContext(c).Error("failed to update overage settings", "error", err)
+	return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+		Success: false,
+		Error:   "Failed to update overage settings",
+		Code:    models.ErrCodeInternal,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePlanRejectsInvalidRequests(t *testing.T) {
+	e := echo.New()
+	e.Validator = NewValidator()
+
+	for _, tt := range []struct {
+		name   string
+		userID interface{}
+		body   string
+		status int
+	}{
+		{"unauthenticated", nil, `{"plan_type":"pro"}`, http.StatusUnauthorized},
+		{"no plan", 3, `{}`, http.StatusBadRequest},
+		{"malformed body", 3, `{"plan_type":`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/user/subscription?dry_run=true", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.userID != nil {
+				c.Set("user_id", tt.userID)
+			}
+
+			assert.NoError(t, ChangePlanHandler(c))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestUpdateOverageRejectsInvalidRequests(t *testing.T) {
+	e := echo.New()
+	e.Validator = NewValidator()
+
+	for _, tt := range []struct {
+		name   string
+		userID interface{}
+		body   string
+		status int
+	}{
+		{"unauthenticated", nil, `{"enabled":true,"spend_cap":50}`, http.StatusUnauthorized},
+		{"no enabled flag", 3, `{"spend_cap":50}`, http.StatusBadRequest},
+		{"negative spend cap", 3, `{"enabled":true,"spend_cap":-5}`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/user/overage", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.userID != nil {
+				c.Set("user_id", tt.userID)
+			}
+
+			assert.NoError(t, UpdateOverageHandler(c))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// mvtContentType is the media type of Mapbox Vector Tiles
+const mvtContentType = "application/vnd.mapbox-vector-tile"
+
+// GetTileHandler handles GET /api/v1/tiles/:layer/:z/:x/:y.mvt - a Mapbox
+// Vector Tile of addresses, counties or states. Tiles without features,
+// including address tiles zoomed out past the layer's minimum zoom, are
+// 204 No Content.
+func GetTileHandler(c echo.Context) error {
+	z, errZ := strconv.Atoi(c.Param("z"))
+	x, errX := strconv.Atoi(c.Param("x"))
+	y, errY := strconv.Atoi(strings.TrimSuffix(c.Param("y"), ".mvt"))
+	if errZ != nil || errX != nil || errY != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Tile coordinates must be integers: /tiles/{layer}/{z}/{x}/{y}.mvt",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	layer := c.Param("layer")
+	tile, err := services.Tiles.GetTile(c.Request().Context(), layer, z, x, y)
+	switch {
+	case errors.Is(err, services.ErrUnknownTileLayer):
+		return c.JSON(http.StatusNotFound, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown tile layer %q; available layers: %s", layer, strings.Join(services.TileLayerNames(), ", ")),
+			Code:    models.ErrCodeNotFound,
+		})
+	case errors.Is(err, services.ErrInvalidTile):
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Tile %d/%d/%d is outside the tile grid (zoom 0-%d)", z, x, y, services.MaxTileZoom),
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	case err != nil:
+		logging.FromContext(c).Error("tile request failed", "layer", layer, "z", z, "x", x, "y", y, "error", err)
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to render tile",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	if len(tile) == 0 {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.Blob(http.StatusOK, mvtContentType, tile)
+}
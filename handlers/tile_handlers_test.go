@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// These cases are answered before any query runs
+func TestGetTileHandlerWithoutQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		layer  string
+		z      string
+		x      string
+		y      string
+		status int
+	}{
+		{"non-numeric zoom", "states", "z", "0", "0.mvt", http.StatusBadRequest},
+		{"unknown layer", "parcels", "5", "8", "12.mvt", http.StatusNotFound},
+		{"x outside grid", "counties", "2", "4", "0.mvt", http.StatusBadRequest},
+		{"zoom too deep", "counties", "23", "0", "0.mvt", http.StatusBadRequest},
+		{"addresses zoomed out", "addresses", "10", "276", "387.mvt", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			c.SetParamNames("layer", "z", "x", "y")
+			c.SetParamValues(tt.layer, tt.z, tt.x, tt.y)
+
+			assert.NoError(t, GetTileHandler(c))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
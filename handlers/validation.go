@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"geocoding-api/logging"
+	"geocoding-api/models"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// requestValidator runs the `validate` struct tags on bound request bodies.
+// Field names in errors use the JSON name so they match what the client sent.
+type requestValidator struct {
+	validate *validator.Validate
+}
+
+// NewValidator returns the echo.Validator used by c.Validate
+func NewValidator() echo.Validator {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return &requestValidator{validate: v}
+}
+
+// Validate implements echo.Validator
+func (rv *requestValidator) Validate(i interface{}) error {
+	return rv.validate.Struct(i)
+}
+
+// bindAndValidate binds the request body into req and runs its validate tags.
+// On failure it writes the error response and returns ok=false; the caller
+// returns err.
+func bindAndValidate(c echo.Context, req interface{}) (ok bool, err error) {
+	if err := c.Bind(req); err != nil {
+		return false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request format",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+	if err := c.Validate(req); err != nil {
+		return false, validationErrorResponse(c, err)
+	}
+	return true, nil
+}
+
+// validationErrorResponse writes a 400 VALIDATION_FAILED response listing
+// each failed field
+func validationErrorResponse(c echo.Context, err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request: " + err.Error(),
+			Code:    models.ErrCodeValidationFailed,
+		})
+	}
+
+	details := make([]models.FieldError, 0, len(fieldErrs))
+	messages := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		detail := models.FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: fieldErrorMessage(fe),
+		}
+		details = append(details, detail)
+		messages = append(messages, detail.Message)
+	}
+
+	return c.JSON(http.StatusBadRequest, GeocodeResponse{
+		Success: false,
+		Error:   strings.Join(messages, "; "),
+		Code:    models.ErrCodeValidationFailed,
+		Details: details,
+	})
+}
+
+// fieldErrorMessage renders a readable message for one failed rule
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "email":
+		return fe.Field() + " must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters long", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters long", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	}
+	return fmt.Sprintf("%s failed the %q rule", fe.Field(), fe.Tag())
+}
+
+// HTTPErrorHandler renders errors returned from handlers and middleware
+// (unknown routes, body limits, panics recovered by echo) in the standard
+// error envelope with a machine-readable code
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+	message := http.StatusText(status)
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status = he.Code
+		message = fmt.Sprint(he.Message)
+		if m, ok := he.Message.(string); ok {
+			message = m
+		}
+	} else {
+		logging.FromContext(c).Error("unhandled error", "error", err)
+	}
+
+	response := GeocodeResponse{
+		Success: false,
+		Error:   message,
+		Code:    models.ErrorCodeForStatus(status),
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, response)
+	}
+	if err != nil {
+		logging.FromContext(c).Error("failed to write error response", "error", err)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindAndValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		ok      bool
+		code    string
+		details []models.FieldError
+	}{
+		{
+			name: "valid registration",
+			body: `{"email":"a@example.com","password":"longenough","name":"A"}`,
+			ok:   true,
+		},
+		{
+			name: "malformed JSON",
+			body: `{"email":`,
+			code: models.ErrCodeInvalidRequest,
+		},
+		{
+			name: "missing fields",
+			body: `{"email":"a@example.com"}`,
+			code: models.ErrCodeValidationFailed,
+			details: []models.FieldError{
+				{Field: "password", Rule: "required", Message: "password is required"},
+				{Field: "name", Rule: "required", Message: "name is required"},
+			},
+		},
+		{
+			name: "bad email and short password",
+			body: `{"email":"not-an-email","password":"short","name":"A"}`,
+			code: models.ErrCodeValidationFailed,
+			details: []models.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+				{Field: "password", Rule: "min", Message: "password must be at least 8 characters long"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var body RegisterRequest
+			ok, err := bindAndValidate(c, &body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				return
+			}
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var resp GeocodeResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.False(t, resp.Success)
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Equal(t, tt.details, resp.Details)
+		})
+	}
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		error  string
+	}{
+		{"unknown route", echo.ErrNotFound, http.StatusNotFound, models.ErrCodeNotFound, "Not Found"},
+		{"body too large", echo.ErrStatusRequestEntityTooLarge, http.StatusRequestEntityTooLarge, models.ErrCodePayloadTooLarge, "Request Entity Too Large"},
+		{"plain error", assert.AnError, http.StatusInternalServerError, models.ErrCodeInternal, "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			HTTPErrorHandler(tt.err, c)
+
+			assert.Equal(t, tt.status, rec.Code)
+			var resp GeocodeResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Equal(t, tt.error, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// webhookErrorStatus maps webhook service errors to HTTP status codes
+func webhookErrorStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "failed to"):
+		return http.StatusInternalServerError
+	case strings.Contains(msg, "limit reached"):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// webhookParams extracts the authenticated user and the :id path parameter.
+// ok is false when an error response has been written; the caller returns err.
+func webhookParams(c echo.Context) (userID, id int, ok bool, err error) {
+	userID, ok = c.Get("user_id").(int)
+	if !ok {
+		return 0, 0, false, c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	id, convErr := strconv.Atoi(c.Param("id"))
+	if convErr != nil {
+		return 0, 0, false, c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid webhook ID",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	return userID, id, true, nil
+}
+
+// CreateWebhookHandler registers a webhook for the authenticated user
+func CreateWebhookHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	var req models.WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request format. Expected url and events",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	webhook, err := services.Webhooks.CreateWebhook(c.Request().Context(), userID, req)
+	if err != nil {
+		return c.JSON(webhookErrorStatus(err), GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(webhookErrorStatus(err)),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, GeocodeResponse{
+		Success: true,
+		Data:    webhook,
+		Message: "Webhook created. Store the secret securely - it won't be shown again.",
+	})
+}
+
+// GetWebhooksHandler lists the authenticated user's webhooks
+func GetWebhooksHandler(c echo.Context) error {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, GeocodeResponse{
+			Success: false,
+			Error:   "User not authenticated",
+			Code:    models.ErrCodeUnauthorized,
+		})
+	}
+
+	webhooks, err := services.Webhooks.GetUserWebhooks(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, GeocodeResponse{
+			Success: false,
+			Error:   "Failed to get webhooks",
+			Code:    models.ErrCodeInternal,
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"webhooks":         webhooks,
+			"available_events": models.WebhookEvents,
+		},
+		Count: len(webhooks),
+	})
+}
+
+// GetWebhookHandler returns one of the authenticated user's webhooks
+func GetWebhookHandler(c echo.Context) error {
+	userID, id, ok, err := webhookParams(c)
+	if !ok {
+		return err
+	}
+
+	webhook, err := services.Webhooks.GetWebhook(c.Request().Context(), userID, id)
+	if err != nil {
+		return c.JSON(webhookErrorStatus(err), GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(webhookErrorStatus(err)),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    webhook,
+	})
+}
+
+// UpdateWebhookHandler replaces a webhook's URL, events and description
+func UpdateWebhookHandler(c echo.Context) error {
+	userID, id, ok, err := webhookParams(c)
+	if !ok {
+		return err
+	}
+
+	var req models.WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, GeocodeResponse{
+			Success: false,
+			Error:   "Invalid request format. Expected url and events",
+			Code:    models.ErrCodeInvalidRequest,
+		})
+	}
+
+	webhook, err := services.Webhooks.UpdateWebhook(c.Request().Context(), userID, id, req)
+	if err != nil {
+		return c.JSON(webhookErrorStatus(err), GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(webhookErrorStatus(err)),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    webhook,
+		Message: "Webhook updated",
+	})
+}
+
+// DeleteWebhookHandler removes one of the authenticated user's webhooks
+func DeleteWebhookHandler(c echo.Context) error {
+	userID, id, ok, err := webhookParams(c)
+	if !ok {
+		return err
+	}
+
+	if err := services.Webhooks.DeleteWebhook(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(webhookErrorStatus(err), GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(webhookErrorStatus(err)),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Message: "Webhook deleted",
+	})
+}
+
+// GetWebhookDeliveriesHandler lists recent delivery attempts for a webhook
+func GetWebhookDeliveriesHandler(c echo.Context) error {
+	userID, id, ok, err := webhookParams(c)
+	if !ok {
+		return err
+	}
+
+	limit := 50
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if val, err := strconv.Atoi(limitStr); err == nil && val > 0 && val <= 200 {
+			limit = val
+		}
+	}
+
+	deliveries, err := services.Webhooks.GetDeliveries(c.Request().Context(), userID, id, limit)
+	if err != nil {
+		return c.JSON(webhookErrorStatus(err), GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(webhookErrorStatus(err)),
+		})
+	}
+
+	return c.JSON(http.StatusOK, GeocodeResponse{
+		Success: true,
+		Data:    deliveries,
+		Count:   len(deliveries),
+	})
+}
+
+// TestWebhookHandler queues a webhook.test event so users can verify their endpoint
+func TestWebhookHandler(c echo.Context) error {
+	userID, id, ok, err := webhookParams(c)
+	if !ok {
+		return err
+	}
+
+	if err := services.Webhooks.SendTestEvent(c.Request().Context(), userID, id); err != nil {
+		return c.JSON(webhookErrorStatus(err), GeocodeResponse{
+			Success: false,
+			Error:   err.Error(),
+			Code:    models.ErrorCodeForStatus(webhookErrorStatus(err)),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, GeocodeResponse{
+		Success: true,
+		Message: "Test event queued for delivery",
+	})
+}
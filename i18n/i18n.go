@@ -0,0 +1,160 @@
+// Package i18n negotiates the response language and translates messages and
+// place names from the catalogs in locales/. English is the source
+// language: catalogs map English text to its translation, and anything a
+// catalog doesn't list is returned in English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Supported languages
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+// DefaultLocale is used when the request asks for no supported language
+const DefaultLocale = English
+
+// ContextKey is the echo context key the negotiated locale is stored under
+const ContextKey = "locale"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalog holds one language's translations, keyed by the English text
+type Catalog struct {
+	Messages map[string]string `json:"messages"`
+	States   map[string]string `json:"states"`
+	Counties map[string]string `json:"counties"`
+}
+
+// catalogs holds every language but English, which needs none
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[string]*Catalog {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read catalogs: %v", err))
+	}
+
+	loaded := make(map[string]*Catalog, len(files))
+	for _, file := range files {
+		data, err := localeFiles.ReadFile("locales/" + file.Name())
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", file.Name(), err))
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", file.Name(), err))
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = &catalog
+	}
+	return loaded
+}
+
+// Supported lists the languages responses can be given in
+func Supported() []string {
+	languages := []string{English}
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages[1:])
+	return languages
+}
+
+// IsSupported reports whether responses can be given in language
+func IsSupported(language string) bool {
+	_, ok := catalogs[language]
+	return ok || language == English
+}
+
+// Negotiate picks the response language: requested (the ?lang= parameter)
+// when it is supported, otherwise the supported language the Accept-Language
+// header prefers most, otherwise DefaultLocale. Regional variants such as
+// es-MX match their base language.
+func Negotiate(requested, acceptLanguage string) string {
+	if language := baseLanguage(requested); IsSupported(language) {
+		return language
+	}
+
+	best, bestQ := DefaultLocale, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if language := baseLanguage(tag); IsSupported(language) && q > bestQ {
+			best, bestQ = language, q
+		}
+	}
+	return best
+}
+
+// baseLanguage lowercases a language tag and drops its region
+func baseLanguage(tag string) string {
+	language, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	return strings.ToLower(language)
+}
+
+// FromContext returns the locale negotiated for the request, or
+// DefaultLocale when none was
+func FromContext(c echo.Context) string {
+	if locale, ok := c.Get(ContextKey).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Message translates an English message. A message with a detail appended
+// after ": " is translated by its leading part, so "Failed to search
+// addresses: timeout" uses the "Failed to search addresses" entry.
+func Message(locale, message string) string {
+	catalog := catalogs[locale]
+	if catalog == nil || message == "" {
+		return message
+	}
+	if translated, ok := catalog.Messages[message]; ok {
+		return translated
+	}
+	if prefix, detail, ok := strings.Cut(message, ": "); ok {
+		if translated, ok := catalog.Messages[prefix]; ok {
+			return translated + ": " + detail
+		}
+	}
+	return message
+}
+
+// StateName returns the display name of a state in locale
+func StateName(locale, name string) string {
+	if catalog := catalogs[locale]; catalog != nil {
+		if translated, ok := catalog.States[name]; ok {
+			return translated
+		}
+	}
+	return name
+}
+
+// CountyName returns the display name of a county in locale. Most county
+// names are proper names that stay as they are.
+func CountyName(locale, name string) string {
+	if catalog := catalogs[locale]; catalog != nil {
+		if translated, ok := catalog.Counties[name]; ok {
+			return translated
+		}
+	}
+	return name
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		requested      string
+		acceptLanguage string
+		want           string
+	}{
+		{"nothing requested", "", "", English},
+		{"lang parameter", "es", "en-US", Spanish},
+		{"lang parameter with region", "es-MX", "", Spanish},
+		{"unsupported lang falls back to header", "fr", "es;q=0.8, en;q=0.5", Spanish},
+		{"header preference order", "", "fr-FR, es-419;q=0.9, en;q=0.8", Spanish},
+		{"header prefers English", "", "en-US, es;q=0.5", English},
+		{"no supported language", "", "de, fr;q=0.9", English},
+		{"malformed quality is ignored", "", "es;q=high, en;q=0.1", English},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.requested, tt.acceptLanguage))
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	assert.Equal(t, "Código postal no encontrado", Message(Spanish, "ZIP code not found"))
+	assert.Equal(t, "No se pudieron buscar direcciones: timeout",
+		Message(Spanish, "Failed to search addresses: timeout"))
+	assert.Equal(t, "Something new", Message(Spanish, "Something new"))
+	assert.Equal(t, "ZIP code not found", Message(English, "ZIP code not found"))
+}
+
+func TestPlaceNames(t *testing.T) {
+	assert.Equal(t, "Nuevo México", StateName(Spanish, "New Mexico"))
+	assert.Equal(t, "Ohio", StateName(Spanish, "Ohio"))
+	assert.Equal(t, "New Mexico", StateName(English, "New Mexico"))
+	assert.Equal(t, "Franklin", CountyName(Spanish, "Franklin"))
+	assert.Equal(t, []string{English, Spanish}, Supported())
+}
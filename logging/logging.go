@@ -0,0 +1,114 @@
+// Package logging configures the process-wide structured logger and builds
+// request-scoped loggers carrying correlation fields.
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Output formats accepted by LOG_FORMAT
+const (
+	FormatJSON  = "json"
+	FormatText  = "text"
+	FormatColor = "color"
+)
+
+var format = FormatColor
+
+// Init installs the default slog logger from the environment. LOG_LEVEL is
+// debug, info (default), warn or error. LOG_FORMAT is json, text or color;
+// it defaults to json when GO_ENV=production and color otherwise. Output
+// from the standard log package is routed through the same handler.
+func Init() {
+	Setup(os.Stdout, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+}
+
+// Setup installs the default logger writing to w with the given level and format
+func Setup(w io.Writer, level, logFormat string) {
+	format = strings.ToLower(logFormat)
+	if format == "" {
+		format = FormatColor
+		if os.Getenv("GO_ENV") == "production" {
+			format = FormatJSON
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	slog.SetDefault(slog.New(handler))
+}
+
+// ParseLevel maps a LOG_LEVEL value to a slog level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Format returns the active output format
+func Format() string {
+	return format
+}
+
+// FromContext returns the default logger annotated with the request ID,
+// method, endpoint and, once authentication has run, the user and API key IDs
+func FromContext(c echo.Context) *slog.Logger {
+	return slog.Default().With(RequestAttrs(c)...)
+}
+
+// RequestAttrs returns the correlation fields for a request
+func RequestAttrs(c echo.Context) []any {
+	req := c.Request()
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("endpoint", endpoint(c)),
+	}
+
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+	if requestID == "" {
+		requestID = req.Header.Get(echo.HeaderXRequestID)
+	}
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+
+	if userID, ok := c.Get("user_id").(int); ok {
+		attrs = append(attrs, slog.Int("user_id", userID))
+	} else if user, ok := c.Get("user").(*models.User); ok && user != nil {
+		attrs = append(attrs, slog.Int("user_id", user.ID))
+	}
+	if key, ok := c.Get("api_key").(*models.APIKey); ok && key != nil {
+		attrs = append(attrs, slog.Int("api_key_id", key.ID))
+	}
+
+	return attrs
+}
+
+// endpoint prefers the matched route pattern so log fields group by route
+// rather than by every distinct ZIP code or ID in the path
+func endpoint(c echo.Context) string {
+	if path := c.Path(); path != "" {
+		return path
+	}
+	return c.Request().URL.Path
+}
@@ -1,20 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"geocoding-api/config"
 	"geocoding-api/database"
+	"geocoding-api/grpcapi"
 	"geocoding-api/handlers"
+	"geocoding-api/logging"
 	"geocoding-api/middleware"
 	"geocoding-api/services"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -22,15 +30,18 @@ func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
+
+	// Structured logging (LOG_LEVEL, LOG_FORMAT); the standard logger is routed through it
+	logging.Init()
 	
-	// Warn about insecure defaults in production
-	if os.Getenv("GO_ENV") == "production" {
-		if os.Getenv("JWT_SECRET") == "change_this_in_production" || os.Getenv("JWT_SECRET") == "" {
-			log.Println("WARNING: Using default JWT_SECRET in production! Set a secure value.")
-		}
-		if os.Getenv("API_SECRET_KEY") == "change_this_in_production" || os.Getenv("API_SECRET_KEY") == "" {
-			log.Println("WARNING: Using default API_SECRET_KEY in production! Set a secure value.")
-		}
+	// Settings from defaults, CONFIG_FILE and the environment. Invalid or
+	// missing required settings (e.g. JWT_SECRET in production) stop startup.
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if cfg.IsProduction() && (cfg.Auth.APISecretKey == "change_this_in_production" || cfg.Auth.APISecretKey == "") {
+		slog.Warn("using default API_SECRET_KEY in production; set a secure value")
 	}
 	
 	// Initialize database connection
@@ -39,101 +50,45 @@ func main() {
 	}
 	defer database.CloseDB()
 
-	// Run database migrations
-	// By default, run migrations asynchronously so server starts immediately
-	// Set RUN_MIGRATIONS_SYNC=true to block until migrations complete
-	if os.Getenv("RUN_MIGRATIONS_SYNC") == "true" {
-		log.Println("Running migrations synchronously - server will wait for completion")
-		if err := database.RunMigrations(); err != nil {
-			log.Fatalf("Failed to run database migrations: %v", err)
+	if database.Embedded() {
+		// DB_DRIVER=sqlite serves the ZIP code and state lookups from the
+		// bundled sample data; everything that needs PostgreSQL is off
+		services.InitLookupCaches()
+		if err := services.LoadSampleData(context.Background()); err != nil {
+			log.Fatalf("Failed to load sample data: %v", err)
 		}
+		slog.Warn("running on the embedded SQLite database: lookups need no API key and other endpoints answer 501")
 	} else {
-		// Default: run async so server starts immediately
-		log.Println("Running migrations asynchronously - server starting immediately")
-		database.RunMigrationsAsync()
+		startServices(cfg)
 	}
 
-	// Initialize services
-	services.InitAddressService(database.DB)
-	
-	// Run data initialization in background to avoid blocking server startup
-	// These can wait for migrations to complete before querying the database
-	go func() {
-		log.Println("Starting background data initialization...")
-		
-		// Initialize ZIP code data if needed
-		if err := services.InitializeData(); err != nil {
-			log.Printf("Warning: Failed to initialize ZIP code data: %v", err)
-			log.Println("You can load data manually using: curl -X POST http://localhost:8080/api/v1/admin/load-data")
-		}
-		
-		// Initialize Ohio address data if needed
-		if err := services.InitializeOhioData(); err != nil {
-			log.Printf("Warning: Failed to initialize Ohio address data: %v", err)
-			log.Println("Ohio addresses can be loaded manually if needed")
-		}
-
-		// Initialize US cities data if needed
-		if err := services.InitializeCityData(); err != nil {
-			log.Printf("Warning: Failed to initialize city data: %v", err)
-			log.Println("City data can be loaded manually if needed")
-		}
-
-		// Initialize US states data if needed
-		if err := services.InitializeStateData(); err != nil {
-			log.Printf("Warning: Failed to initialize state data: %v", err)
-			log.Println("State data can be loaded manually if needed")
-		}
-
-		// Sync admin privileges from ADMIN_EMAILS environment variable
-		authService := &services.AuthService{}
-		if err := authService.SyncAdminUsers(); err != nil {
-			log.Printf("Warning: Failed to sync admin users: %v", err)
-		}
-		
-		log.Println("Background data initialization completed")
-	}()
-
 	// Create Echo instance
 	e := echo.New()
+	e.Validator = handlers.NewValidator()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+	e.JSONSerializer = handlers.LocalizedJSONSerializer{}
+	// Per-IP rate limits key on c.RealIP, so client addresses are only taken
+	// from headers set by TRUSTED_PROXIES
+	e.IPExtractor = middleware.ClientIPExtractor(cfg.Server.TrustedProxies)
 
-	// Configure body limit for file uploads (500MB to handle large GeoJSON files)
-	e.Use(echomiddleware.BodyLimit("500M"))
+	// Configure body limit for file uploads (500MB by default to handle large GeoJSON files)
+	e.Use(echomiddleware.BodyLimit(cfg.Server.MaxBodySize))
 
-	// Middleware
-	e.Use(middleware.ColorizedLogger())
+	// Middleware. Request IDs are assigned first so every log record for a
+	// request carries the same request_id.
+	e.Use(echomiddleware.RequestID())
+	e.Use(middleware.RequestLogger())
+	e.Use(middleware.Locale())
 	e.Use(echomiddleware.Recover())
-	
-	// Configure CORS based on environment
-	var corsOrigins []string
-	
-	// Check for custom CORS origins from environment
-	if customOrigins := os.Getenv("CORS_ORIGINS"); customOrigins != "" {
-		corsOrigins = strings.Split(customOrigins, ",")
-		for i, origin := range corsOrigins {
-			corsOrigins[i] = strings.TrimSpace(origin)
-		}
-		log.Printf("Using custom CORS origins: %v", corsOrigins)
-	} else if os.Getenv("GO_ENV") == "production" {
-		// Production defaults
-		corsOrigins = []string{
-			"https://geocode.jfay.dev",
-			"https://www.geocode.jfay.dev",
-		}
-		log.Printf("Using production CORS origins: %v", corsOrigins)
-	} else {
-		// Development mode - allow localhost variants
-		corsOrigins = []string{
-			"http://localhost:8080",
-			"http://127.0.0.1:8080",
-			"http://localhost:3000", // Common dev ports
-			"http://localhost:3001",
-		}
-		log.Printf("Using development CORS origins: %v", corsOrigins)
+	if cfg.Server.CompressionLevel > 0 {
+		e.Use(middleware.Compress(cfg.Server.CompressionLevel))
 	}
 	
+	// CORS origins come from CORS_ORIGINS, or the production or development defaults
+	log.Printf("Using CORS origins: %v", cfg.CORS.Origins)
+	
 	e.Use(echomiddleware.CORSWithConfig(echomiddleware.CORSConfig{
-		AllowOrigins: corsOrigins,
+		AllowOrigins: cfg.CORS.Origins,
 		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.OPTIONS},
 		AllowHeaders: []string{
 			echo.HeaderOrigin,
@@ -142,14 +97,15 @@ func main() {
 			echo.HeaderAuthorization,
 			"X-API-Key",
 			"X-User-ID",
+			"If-None-Match",
+			"If-Modified-Since",
+			"Idempotency-Key",
 		},
+		ExposeHeaders:    []string{"ETag", "Last-Modified", "Idempotent-Replayed", "Deprecation", "Sunset", "Link"},
 		AllowCredentials: true,
 		MaxAge:          300, // 5 minutes
 	}))
 
-	// Add request ID middleware for tracing
-	e.Use(echomiddleware.RequestID())
-
 	// Determine which frontend to serve
 	staticDir := "static-new"
 	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
@@ -211,145 +167,515 @@ func main() {
 		})
 	})
 
-	// Root-level health check for container orchestration (works without /api/v1 prefix)
+	// Root-level health check for container orchestration (works without /api/v1 prefix).
+	// It is a liveness check, so it stays 200 during a database outage, which
+	// restarting the server wouldn't fix; /api/v1/health answers 503 then.
 	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+		status := "ok"
+		if database.Circuit.Open() {
+			status = "degraded"
+		}
+		return c.JSON(http.StatusOK, map[string]string{"status": status, "database": database.Circuit.Status().State})
+	})
+
+	// API routes, served under every version in apiVersions
+	limits := ipLimits{
+		demo:          middleware.DemoRateLimit(cfg.Demo.RateLimit, cfg.Demo.DailyLimit),
+		passwordReset: middleware.IPRateLimit(5, 15*time.Minute, "Too many password reset attempts. Try again later."),
+		verification:  middleware.IPRateLimit(3, 15*time.Minute, "Too many verification emails requested. Try again later."),
+	}
+	versions := apiVersions(cfg)
+	for i := range versions {
+		registerAPIRoutes(e, cfg, versions[:i+1], limits)
+	}
+	if cfg.Demo.Enabled {
+		log.Printf("Demo mode enabled: %d requests/minute and %d/day per IP, state boundary %s", cfg.Demo.RateLimit, cfg.Demo.DailyLimit, handlers.DemoState())
+	}
+
+	// SPA fallback - MUST be registered AFTER all API routes
+	// This serves the React app for all non-API routes
+	e.GET("/*", func(c echo.Context) error {
+		path := c.Request().URL.Path
+		
+		// Don't handle API routes here - they're already registered above
+		if len(path) >= 4 && path[:4] == "/api" {
+			return echo.ErrNotFound
+		}
+		
+		// Serve static files if they exist
+		filePath := staticDir + path
+		if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
+			return c.File(filePath)
+		}
+		
+		// Otherwise serve index.html for SPA routing
+		return c.File(staticDir + "/index.html")
 	})
 
-	// Routes
-	api := e.Group("/api/v1")
+	log.Printf("=== SERVER STARTUP ===")
+	log.Printf("Environment: GO_ENV=%s", cfg.Env)
+	log.Printf("Binding to: %s", cfg.HTTPAddr())
+	log.Printf("Static directory: %s", staticDir)
+	
+	// Server timeouts are long by default for large file uploads (2.09GB total possible)
+	server := &http.Server{
+		Addr:              cfg.HTTPAddr(),
+		ReadTimeout:       cfg.Server.ReadTimeout,       // Time to read entire request including body
+		WriteTimeout:      cfg.Server.WriteTimeout,      // Time to write response
+		IdleTimeout:       cfg.Server.IdleTimeout,       // Keep-alive timeout
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout, // Time to read request headers
+	}
+	
+	log.Printf("Starting HTTP server...")
+	go func() {
+		if err := e.StartServer(server); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// gRPC on its own port, sharing the service layer and API keys
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled && !database.Embedded() {
+		listener, err := net.Listen("tcp", cfg.GRPCAddr())
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcServer = grpcapi.NewServer()
+		log.Printf("Starting gRPC server on %s", cfg.GRPCAddr())
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
+	// On SIGINT/SIGTERM stop the geocode job workers, which also ends their
+	// progress streams, stop accepting requests, let in-flight ones finish,
+	// then write any usage still buffered
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Printf("Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := services.GeocodeJobs.Stop(ctx); err != nil {
+		log.Printf("Geocode job shutdown error: %v", err)
+	}
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	if err := services.Usage.Stop(ctx); err != nil {
+		log.Printf("Usage writer shutdown error: %v", err)
+	}
+	if err := services.Notifications.Stop(ctx); err != nil {
+		log.Printf("Notification sender shutdown error: %v", err)
+	}
+}
+
+// startServices migrates the PostgreSQL database, initializes the services
+// and starts their background workers and data loads
+func startServices(cfg *config.Config) {
+	// Ping the database in the background so an outage or a database too
+	// slow to answer opens the circuit breaker
+	database.StartHealthMonitor()
+
+	// Run database migrations
+	// By default, run migrations asynchronously so server starts immediately
+	// Set RUN_MIGRATIONS_SYNC=true to block until migrations complete
+	if cfg.Migrations.RunSync {
+		log.Println("Running migrations synchronously - server will wait for completion")
+		if err := database.RunMigrations(); err != nil {
+			log.Fatalf("Failed to run database migrations: %v", err)
+		}
+	} else {
+		// Default: run async so server starts immediately
+		log.Println("Running migrations asynchronously - server starting immediately")
+		database.RunMigrationsAsync()
+	}
+
+	// Initialize services
+	services.InitAddressService(database.DB)
+	services.County = services.NewCountyService()
+	services.InitLookupCaches()
+	if err := services.InitResponseCache(cfg.ResponseCache); err != nil {
+		log.Fatalf("Failed to initialize response cache: %v", err)
+	}
+
+	// Write usage records in batches from a background flusher
+	services.Usage.Start()
+
+	// Process bulk geocoding jobs in the background
+	services.GeocodeJobs.Start()
+
+	// Deliver queued webhook events in the background
+	services.Webhooks.StartDeliveryWorker()
+
+	// Email users about account, quota and dataset events in the background
+	services.Notifications.Start()
+
+	// Refresh the admin dashboard stats views in the background
+	services.Stats.StartRefresher()
+
+	// Alert admins when an endpoint burns through its SLO budget
+	services.SLO.StartAlerter()
+
+	// Drop expired Idempotency-Key responses hourly
+	services.Idempotency.StartCleanup()
+
+	// Re-import tracked OpenAddresses counties when they change upstream
+	services.SourceRefresh.StartScheduler()
+
+	// Compare address counts with dataset record counts nightly
+	services.Integrity.StartScheduler()
+	
+	// Run data initialization in background to avoid blocking server startup
+	// These can wait for migrations to complete before querying the database
+	go func() {
+		log.Println("Starting background data initialization...")
+		
+		// Load reference datasets whose tables are empty; failed loads can
+		// be retried with POST /api/v1/admin/load/:dataset
+		services.DataLoads.InitializeDatasets(context.Background())
+
+		// Answer distance, nearby and proximity queries from memory
+		if err := services.ZipCentroids.Load(context.Background()); err != nil {
+			slog.Warn("failed to load ZIP centroid index", "error", err)
+		}
+
+		// Report Ohio address data, which is uploaded from the Data Manager
+		if err := services.InitializeOhioData(); err != nil {
+			slog.Warn("failed to initialize data", "dataset", "ohio_addresses", "error", err)
+		}
+
+		// Sync admin privileges from ADMIN_EMAILS environment variable
+		authService := &services.AuthService{}
+		if err := authService.SyncAdminUsers(); err != nil {
+			slog.Warn("failed to sync admin users", "error", err)
+		}
+		
+		log.Println("Background data initialization completed")
+	}()
+}
+
+// apiVersions lists the REST API versions, oldest first. Each serves every
+// route; a later version differs only where versionedHandlers says so.
+func apiVersions(cfg *config.Config) []middleware.APIVersion {
+	return []middleware.APIVersion{
+		{Name: "v1", Deprecation: cfg.API.V1Deprecation, Sunset: cfg.API.V1Sunset, Successor: "v2"},
+		{Name: "v2"},
+	}
+}
+
+// versionedHandlers replace an API key route's handler from a version on,
+// keyed by version and then "METHOD path". When a response has to change
+// incompatibly, register the new handler here instead of changing the
+// original, so older versions keep their response.
+var versionedHandlers = map[string]map[string]echo.HandlerFunc{
+	"v2": {},
+}
+
+// versionedHandler returns the handler a route uses in the last of versions:
+// the override from the newest of them that has one, or h
+func versionedHandler(versions []middleware.APIVersion, method, path string, h echo.HandlerFunc) echo.HandlerFunc {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if override, ok := versionedHandlers[versions[i].Name][method+" "+path]; ok {
+			return override
+		}
+	}
+	return h
+}
+
+// ipLimits are the per-IP limits on unauthenticated routes. They are shared
+// by every version so that switching versions doesn't reset a client's count.
+type ipLimits struct {
+	demo          echo.MiddlewareFunc
+	passwordReset echo.MiddlewareFunc
+	verification  echo.MiddlewareFunc
+}
+
+// registerAPIRoutes mounts the routes of the last of versions under its
+// prefix. The earlier versions decide which handler overrides it inherits.
+func registerAPIRoutes(e *echo.Echo, cfg *config.Config, versions []middleware.APIVersion, limits ipLimits) {
+	version := versions[len(versions)-1]
+	api := e.Group(version.Prefix())
+	api.Use(middleware.Version(version))
+	api.Use(middleware.RequestTimeout(cfg.Server.RequestTimeout))
+	api.Use(middleware.DatabaseAvailable())
 	
 	// Health check endpoint (no auth required)
 	api.GET("/health", handlers.HealthCheckHandler)
+
+	// The embedded SQLite database serves only the ZIP code and state lookups
+	if database.Embedded() {
+		registerEmbeddedRoutes(api)
+		return
+	}
+	
+	// Self-describing metadata (auth optional)
+	api.GET("/meta/permissions", handlers.GetPermissionsHandler)
+	
+	// Address data coverage (no auth required)
+	api.GET("/coverage", handlers.GetDataCoverageHandler)
+	
+	// Public demo endpoints (no auth required, DEMO_MODE=true to enable).
+	// Only ZIP lookups, city searches and one state boundary are exposed,
+	// cached and throttled per IP to DEMO_RATE_LIMIT requests per minute
+	// (default 10) and DEMO_DAILY_LIMIT per day (default 100).
+	if cfg.Demo.Enabled {
+		demo := api.Group("/demo")
+		demo.Use(limits.demo)
+		demo.GET("/geocode/:zipcode", handlers.DemoZipCodeHandler)
+		demo.GET("/search", handlers.DemoSearchHandler)
+		demo.GET("/states/:identifier/boundary", handlers.DemoStateBoundaryHandler)
+	}
 	
 	// Authentication routes (no auth required)
 	auth := api.Group("/auth")
 	auth.POST("/register", handlers.RegisterHandler)
 	auth.POST("/login", handlers.LoginHandler)
+	auth.POST("/refresh", handlers.RefreshTokenHandler)
+	auth.POST("/logout", handlers.RefreshLogoutHandler)
+	auth.POST("/forgot-password", handlers.ForgotPasswordHandler, limits.passwordReset)
+	auth.POST("/reset-password", handlers.ResetPasswordHandler, limits.passwordReset)
+	auth.GET("/verify", handlers.VerifyEmailHandler)
 	auth.GET("/plans", handlers.GetPlansHandler)
 	
 	// User management routes (require user auth)
 	user := api.Group("/user")
 	user.Use(middleware.RequireUserAuth())
 	user.GET("/profile", handlers.GetUserProfileHandler)
-	user.POST("/api-keys", handlers.CreateAPIKeyHandler)
+	user.POST("/logout", handlers.LogoutHandler)
+	user.POST("/resend-verification", handlers.ResendVerificationHandler, limits.verification)
+	user.POST("/api-keys", handlers.CreateAPIKeyHandler, middleware.Idempotency())
 	user.GET("/api-keys", handlers.GetAPIKeysHandler)
 	user.DELETE("/api-keys/:id", handlers.DeleteAPIKeyHandler)
 	user.GET("/usage", handlers.GetUsageHandler)
 	user.GET("/usage/daily", handlers.GetDailyUsageHandler)
 	user.GET("/usage/endpoints", handlers.GetEndpointUsageHandler)
+	user.GET("/usage/compare", handlers.GetUsageComparisonHandler)
+	user.GET("/usage/timeseries", handlers.GetUsageTimeSeriesHandler)
+	user.GET("/subscription", handlers.GetSubscriptionHandler)
+	user.PUT("/subscription", handlers.ChangePlanHandler)
+	user.GET("/overage", handlers.GetOverageHandler)
+	user.PUT("/overage", handlers.UpdateOverageHandler)
+	user.POST("/burst-requests", handlers.CreateBurstRequestHandler)
+	user.GET("/burst-requests", handlers.GetUserBurstRequestsHandler)
+	user.GET("/webhooks", handlers.GetWebhooksHandler)
+	user.POST("/webhooks", handlers.CreateWebhookHandler)
+	user.GET("/webhooks/:id", handlers.GetWebhookHandler)
+	user.PUT("/webhooks/:id", handlers.UpdateWebhookHandler)
+	user.DELETE("/webhooks/:id", handlers.DeleteWebhookHandler)
+	user.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveriesHandler)
+	user.POST("/webhooks/:id/test", handlers.TestWebhookHandler)
+	user.POST("/organizations", handlers.CreateOrganizationHandler)
+	user.GET("/organizations", handlers.GetOrganizationsHandler)
+	user.GET("/organizations/:id", handlers.GetOrganizationHandler)
+	user.GET("/organizations/:id/usage", handlers.GetOrganizationUsageHandler)
+	user.POST("/organizations/:id/invitations", handlers.InviteOrganizationMemberHandler)
+	user.GET("/organizations/:id/invitations", handlers.GetOrganizationInvitationsHandler)
+	user.DELETE("/organizations/:id/invitations/:invitation_id", handlers.RevokeOrganizationInvitationHandler)
+	user.PUT("/organizations/:id/members/:user_id", handlers.UpdateOrganizationMemberHandler)
+	user.DELETE("/organizations/:id/members/:user_id", handlers.RemoveOrganizationMemberHandler)
+	user.POST("/invitations/accept", handlers.AcceptOrganizationInvitationHandler)
+	user.POST("/datasets", handlers.CreateCustomDatasetHandler)
+	user.GET("/datasets", handlers.GetCustomDatasetsHandler)
+	user.DELETE("/datasets/:id", handlers.DeleteCustomDatasetHandler)
+	user.POST("/address-subscriptions", handlers.CreateAddressSubscriptionHandler)
+	user.GET("/address-subscriptions", handlers.GetAddressSubscriptionsHandler)
+	user.GET("/address-subscriptions/:id", handlers.GetAddressSubscriptionHandler)
+	user.PUT("/address-subscriptions/:id", handlers.UpdateAddressSubscriptionHandler)
+	user.DELETE("/address-subscriptions/:id", handlers.DeleteAddressSubscriptionHandler)
+	user.GET("/address-subscriptions/:id/changes", handlers.GetAddressSubscriptionChangesHandler)
 	
 	// Protected API endpoints (require API key)
 	protected := api.Group("")
 	protected.Use(middleware.APIKeyAuth())
 	protected.Use(middleware.UsageHeader())
+
+	// protectedRoute registers an API key route together with the permission it
+	// requires so the permission registry always matches the routing table.
+	// h is the route's original handler; versionedHandlers may replace it.
+	protectedRoute := func(method, path, permission string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+		services.Permissions.RegisterRoute(method, version.Prefix()+path, permission)
+		protected.Add(method, path, versionedHandler(versions, method, path, h), m...)
+	}
+	// Reference data changes only when it is reloaded, so clients can revalidate
+	// with If-None-Match instead of downloading it again
+	referenceData := middleware.ConditionalGet(middleware.CacheReferenceData)
+	boundaries := middleware.ConditionalGet(middleware.CacheBoundaries)
+	// Read-only geocoding endpoints also answer in XML or JSONP for legacy
+	// integrations that can't consume JSON
+	legacyFormats := middleware.ResponseFormats()
+	// Repeated lookups are answered from the response cache (RESPONSE_CACHE)
+	// for a TTL that matches how often each route's data changes. It runs
+	// last, inside ConditionalGet, so cached responses still get an ETag.
+	cached := middleware.ResponseCache
 	
 	// Geocoding endpoints
-	protected.GET("/geocode/:zipcode", handlers.GetZipCodeHandler)
-	protected.GET("/search", handlers.SearchZipCodesHandler)
+	protectedRoute(http.MethodGet, "/geocode/:zipcode", "geocode", handlers.GetZipCodeHandler, referenceData, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/geocode/:zipcode/counties", "geocode", handlers.ZipCodeCountiesHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/search", "search", handlers.SearchZipCodesHandler, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodPost, "/search", "search", handlers.SearchZipCodesHandler)
+
+	// Async bulk geocoding jobs
+	protectedRoute(http.MethodPost, "/geocode/jobs", "geocode", handlers.CreateGeocodeJobHandler, middleware.Idempotency())
+	protectedRoute(http.MethodGet, "/geocode/jobs", "geocode", handlers.GetGeocodeJobsHandler)
+	protectedRoute(http.MethodGet, "/geocode/jobs/:id", "geocode", handlers.GetGeocodeJobHandler)
+	protectedRoute(http.MethodGet, "/geocode/jobs/:id/results", "geocode", handlers.GetGeocodeJobResultsHandler)
+	protectedRoute(http.MethodGet, "/geocode/jobs/:id/stream", "geocode", handlers.StreamGeocodeJobHandler)
+	protectedRoute(http.MethodDelete, "/geocode/jobs/:id", "geocode", handlers.CancelGeocodeJobHandler)
 	
 	// Distance and proximity endpoints
-	protected.GET("/distance/:from/:to", handlers.CalculateDistanceHandler)
-	protected.GET("/nearby/:zipcode", handlers.FindNearbyZipCodesHandler)
-	protected.GET("/proximity/:center/:target", handlers.CheckZipCodeProximityHandler)
+	protectedRoute(http.MethodGet, "/distance/:from/:to", "distance", handlers.CalculateDistanceHandler, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/nearby", "distance", handlers.FindZipCodesNearPointHandler, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/nearby/:zipcode", "distance", handlers.FindNearbyZipCodesHandler, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/coverage/drive-time", "distance", handlers.GetCoverageHandler)
+	protectedRoute(http.MethodPost, "/corridor", "distance", handlers.SearchCorridorHandler)
+	protectedRoute(http.MethodGet, "/proximity/:center/:target", "distance", handlers.CheckZipCodeProximityHandler, legacyFormats, cached(time.Hour))
 	
 	// Ohio address endpoints
-	protected.GET("/addresses", handlers.SearchOhioAddressesHandler)
-	protected.GET("/addresses/search", handlers.FullTextSearchAddressesHandler)
-	protected.GET("/addresses/:id", handlers.GetOhioAddressHandler)
+	protectedRoute(http.MethodGet, "/addresses", "addresses", handlers.SearchOhioAddressesHandler, legacyFormats, cached(5*time.Minute))
+	protectedRoute(http.MethodPost, "/addresses", "addresses", handlers.SearchOhioAddressesHandler)
+	protectedRoute(http.MethodGet, "/addresses/search", "addresses", handlers.FullTextSearchAddressesHandler, legacyFormats, cached(5*time.Minute))
+	protectedRoute(http.MethodGet, "/addresses/nearby", "addresses", handlers.FindNearbyAddressesHandler, legacyFormats, cached(5*time.Minute))
+	protectedRoute(http.MethodPost, "/addresses/validate", "addresses", handlers.ValidateAddressHandler)
+	protectedRoute(http.MethodPost, "/addresses/within", "addresses", handlers.SearchAddressesWithinHandler)
+	protectedRoute(http.MethodGet, "/streets", "addresses", handlers.SearchStreetsHandler)
+	protectedRoute(http.MethodGet, "/parse", "addresses", handlers.ParseAddressHandler)
+	protectedRoute(http.MethodGet, "/addresses/:id", "addresses", handlers.GetOhioAddressHandler, legacyFormats, cached(5*time.Minute))
 	
 	// Ohio county boundary endpoints
-	protected.GET("/counties", handlers.GetCountiesHandler)
-	protected.GET("/counties/:name", handlers.GetCountyDetailHandler)
-	protected.GET("/counties/:name/boundary", handlers.GetCountyBoundaryHandler)
-	protected.GET("/counties/bounds/search", handlers.GetCountiesInBoundsHandler)
+	protectedRoute(http.MethodGet, "/counties", "counties", handlers.GetCountiesHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/counties/:name", "counties", handlers.GetCountyDetailHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/counties/:name/boundary", "counties", handlers.GetCountyBoundaryHandler, boundaries)
+	protectedRoute(http.MethodGet, "/counties/:fips/zipcodes", "counties", handlers.CountyZipCodesHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/counties/bounds/search", "counties", handlers.GetCountiesInBoundsHandler)
+	protectedRoute(http.MethodPost, "/counties/bounds/search", "counties", handlers.GetCountiesInBoundsHandler)
+	protectedRoute(http.MethodPost, "/counties/contains/batch", "counties", handlers.ContainsPointsBatchHandler)
 	
 	// City endpoints
-	protected.GET("/cities", handlers.SearchCitiesHandler)
-	protected.GET("/cities/:id", handlers.GetCityHandler)
-	protected.GET("/cities/zips", handlers.GetCityZIPCodesHandler)
+	protectedRoute(http.MethodGet, "/cities", "cities", handlers.SearchCitiesHandler)
+	protectedRoute(http.MethodPost, "/cities", "cities", handlers.SearchCitiesHandler)
+	protectedRoute(http.MethodGet, "/cities/lookup", "cities", handlers.GetCityByLocationHandler, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/cities/:id", "cities", handlers.GetCityHandler)
+	protectedRoute(http.MethodGet, "/cities/zips", "cities", handlers.GetCityZIPCodesHandler)
 	
 	// State endpoints
-	protected.GET("/states", handlers.SearchStatesHandler)
-	protected.GET("/states/lookup", handlers.GetStateByLocationHandler)
-	protected.GET("/states/:identifier", handlers.GetStateHandler)
-	protected.GET("/states/:identifier/boundary", handlers.GetStateBoundaryHandler)
+	protectedRoute(http.MethodGet, "/states", "states", handlers.SearchStatesHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodPost, "/states", "states", handlers.SearchStatesHandler)
+	protectedRoute(http.MethodGet, "/states/lookup", "states", handlers.GetStateByLocationHandler, legacyFormats, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/states/:identifier", "states", handlers.GetStateHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/states/:identifier/boundary", "states", handlers.GetStateBoundaryHandler, boundaries)
+
+	// ZIP code crosswalks
+	protectedRoute(http.MethodGet, "/crosswalk/zip-to-county", "crosswalk", handlers.ZipToCountyHandler, referenceData, cached(time.Hour))
+	protectedRoute(http.MethodGet, "/crosswalk/zip-to-cbsa", "crosswalk", handlers.ZipToCBSAHandler, referenceData, cached(time.Hour))
+
+	// Congressional, state legislative and school districts
+	protectedRoute(http.MethodGet, "/districts/lookup", "districts", handlers.LookupDistrictsHandler, cached(time.Hour))
+
+	// H3 and geohash cells
+	protectedRoute(http.MethodGet, "/encode", "cells", handlers.EncodeCellHandler)
+	protectedRoute(http.MethodGet, "/decode", "cells", handlers.DecodeCellHandler)
+
+	// Vector tiles
+	protectedRoute(http.MethodGet, "/tiles/:layer/:z/:x/:y", "tiles", handlers.GetTileHandler, referenceData)
+
+	// User-defined geofences
+	protectedRoute(http.MethodPost, "/geofences", "geofences", handlers.CreateGeofenceHandler)
+	protectedRoute(http.MethodGet, "/geofences", "geofences", handlers.GetGeofencesHandler)
+	protectedRoute(http.MethodGet, "/geofences/:id", "geofences", handlers.GetGeofenceHandler)
+	protectedRoute(http.MethodPut, "/geofences/:id", "geofences", handlers.UpdateGeofenceHandler)
+	protectedRoute(http.MethodDelete, "/geofences/:id", "geofences", handlers.DeleteGeofenceHandler)
+	protectedRoute(http.MethodGet, "/geofences/:id/contains", "geofences", handlers.GeofenceContainsHandler)
+	protectedRoute(http.MethodGet, "/geofences/:id/addresses", "geofences", handlers.GetGeofenceAddressesHandler)
+	protectedRoute(http.MethodGet, "/geofences/:id/zipcodes", "geofences", handlers.GetGeofenceZipCodesHandler)
 	
 	// Admin routes (require admin auth)
 	admin := api.Group("/admin")
 	admin.Use(middleware.RequireAdminAuth())
 	admin.GET("/user/status", handlers.GetUserStatusHandler)
-	admin.POST("/load-data", handlers.LoadDataHandler)
+	admin.GET("/load", handlers.ListDataLoadsHandler)
+	admin.POST("/load/:dataset", handlers.StartDataLoadHandler)
+	admin.GET("/load/:dataset", handlers.GetDataLoadHandler)
+	admin.POST("/refresh-zipcodes", handlers.RefreshZipCodesHandler)
+	admin.GET("/maintenance", handlers.GetMaintenanceHandler)
+	admin.POST("/maintenance", handlers.StartMaintenanceHandler)
 	admin.GET("/stats", handlers.GetAdminStatsHandler)
+	admin.POST("/stats/refresh", handlers.RefreshAdminStatsHandler)
 	admin.GET("/users", handlers.GetAllUsersHandler)
+	admin.POST("/users/export", handlers.ExportUsersHandler)
+	admin.PUT("/users/bulk-status", handlers.BulkUpdateUserStatusHandler)
 	admin.GET("/users/:id/metrics", handlers.GetUserUsageMetricsHandler)
 	admin.PUT("/users/:id/status", handlers.UpdateUserStatusHandler)
 	admin.PUT("/users/:id/admin", handlers.UpdateUserAdminHandler)
+	admin.PUT("/users/:id/plan", handlers.UpdateUserPlanHandler)
+	admin.DELETE("/users/:id", handlers.DeleteUserHandler)
 	admin.GET("/api-keys", handlers.GetAllAPIKeysHandler)
+	admin.PUT("/api-keys/:id/revoke", handlers.RevokeAPIKeyHandler)
+	admin.GET("/audit-log", handlers.GetAuditLogHandler)
+	admin.GET("/requests", handlers.ListRequestsHandler)
+	admin.POST("/requests/:id/replay", handlers.ReplayRequestHandler)
 	admin.GET("/system-status", handlers.GetSystemStatusHandler)
+	admin.GET("/cache", handlers.GetCacheStatsHandler)
+	admin.DELETE("/cache", handlers.PurgeCacheHandler)
+	admin.DELETE("/cache/responses", handlers.PurgeResponseCacheHandler)
 	admin.GET("/counties", handlers.GetCountyStatsHandler)
 	admin.GET("/analytics", handlers.GetAdminAnalyticsHandler)
+	admin.GET("/usage/compare", handlers.GetAdminUsageComparisonHandler)
+	admin.GET("/burst-requests", handlers.GetBurstRequestsHandler)
+	admin.PUT("/burst-requests/:id", handlers.ReviewBurstRequestHandler)
+	admin.GET("/flags", handlers.GetFeatureFlagsHandler)
+	admin.PUT("/flags/:key", handlers.UpsertFeatureFlagHandler)
+	admin.DELETE("/flags/:key", handlers.DeleteFeatureFlagHandler)
+	admin.GET("/slo", handlers.GetSLOStatusHandler)
+	admin.PUT("/slo/:endpoint", handlers.UpsertSLOTargetHandler)
+	admin.DELETE("/slo/:endpoint", handlers.DeleteSLOTargetHandler)
+	admin.GET("/plans", handlers.GetAdminPlansHandler)
+	admin.POST("/plans", handlers.CreatePlanHandler)
+	admin.PUT("/plans/:id", handlers.UpdatePlanHandler)
+	admin.GET("/data-quality", handlers.GetDataQualityReportHandler)
+	admin.GET("/data-quality/runs", handlers.GetIntegrityRunsHandler)
+	admin.POST("/data-quality/check", handlers.RunIntegrityCheckHandler)
 	
 	// Dataset management routes (admin only)
-	admin.POST("/datasets/upload", handlers.UploadDatasetHandler)
-	admin.POST("/datasets/upload-bulk", handlers.UploadMultipleHandler)
+	admin.POST("/datasets/upload", handlers.UploadDatasetHandler, middleware.Idempotency())
+	admin.POST("/datasets/upload-bulk", handlers.UploadMultipleHandler, middleware.Idempotency())
 	admin.POST("/datasets/upload-bulk-stream", handlers.UploadMultipleStreamHandler)
+	admin.POST("/datasets/validate", handlers.ValidateDatasetHandler)
+	admin.GET("/datasets/dedupe", handlers.GetDedupeReportHandler)
+	admin.POST("/datasets/dedupe", handlers.StartDedupeHandler)
+	admin.POST("/datasets/dedupe/resolve", handlers.ResolveDuplicatesHandler)
+	admin.GET("/datasets/sources", handlers.GetDatasetSourcesHandler)
+	admin.POST("/datasets/sources/refresh", handlers.RefreshDatasetSourcesHandler)
 	admin.GET("/datasets", handlers.GetDatasetsHandler)
 	admin.GET("/datasets/stats", handlers.GetDatasetStatsHandler)
 	admin.GET("/datasets/:id", handlers.GetDatasetHandler)
 	admin.POST("/datasets/:id/reprocess", handlers.ReprocessDatasetHandler)
 	admin.DELETE("/datasets/:id", handlers.DeleteDatasetHandler)
+}
 
-	// SPA fallback - MUST be registered AFTER all API routes
-	// This serves the React app for all non-API routes
-	e.GET("/*", func(c echo.Context) error {
-		path := c.Request().URL.Path
-		
-		// Don't handle API routes here - they're already registered above
-		if len(path) >= 4 && path[:4] == "/api" {
-			return echo.ErrNotFound
-		}
-		
-		// Serve static files if they exist
-		filePath := staticDir + path
-		if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
-			return c.File(filePath)
-		}
-		
-		// Otherwise serve index.html for SPA routing
-		return c.File(staticDir + "/index.html")
-	})
-
-	// Get port from environment variable or default to 8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+// registerEmbeddedRoutes mounts the lookups the embedded SQLite database
+// (DB_DRIVER=sqlite) can answer. They need no API key, since it stores no
+// accounts; every other API route answers 501.
+func registerEmbeddedRoutes(api *echo.Group) {
+	api.GET("/geocode/:zipcode", handlers.GetZipCodeHandler)
+	api.GET("/geocode/:zipcode/counties", handlers.ZipCodeCountiesHandler)
+	api.GET("/search", handlers.SearchZipCodesHandler)
+	api.POST("/search", handlers.SearchZipCodesHandler)
+	api.GET("/states", handlers.SearchStatesHandler)
+	api.POST("/states", handlers.SearchStatesHandler)
+	api.GET("/states/:identifier", handlers.GetStateHandler)
 
-	// Start server with custom timeouts for large file uploads
-	// Use 0.0.0.0 in production/Docker to accept external connections
-	// Use 127.0.0.1 locally to avoid macOS IPv6 socket issues
-	bindAddr := "127.0.0.1"
-	if os.Getenv("GO_ENV") == "production" || os.Getenv("BIND_ALL_INTERFACES") == "true" {
-		bindAddr = "0.0.0.0"
-	}
-	
-	log.Printf("=== SERVER STARTUP ===")
-	log.Printf("Environment: GO_ENV=%s", os.Getenv("GO_ENV"))
-	log.Printf("Binding to: %s:%s", bindAddr, port)
-	log.Printf("Static directory: %s", staticDir)
-	
-	// Configure server with extended timeouts for large file uploads (2.09GB total possible)
-	server := &http.Server{
-		Addr:              bindAddr + ":" + port,
-		ReadTimeout:       30 * time.Minute,  // Time to read entire request including body
-		WriteTimeout:      30 * time.Minute,  // Time to write response
-		IdleTimeout:       5 * time.Minute,   // Keep-alive timeout
-		ReadHeaderTimeout: 60 * time.Second,  // Time to read request headers
-	}
-	
-	log.Printf("Starting HTTP server...")
-	if err := e.StartServer(server); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
-}
\ No newline at end of file
+	// Routes that the lookups above would otherwise take for a ZIP code or
+	// state name
+	api.Any("/geocode/jobs", handlers.EmbeddedUnsupportedHandler)
+	api.Any("/geocode/jobs/*", handlers.EmbeddedUnsupportedHandler)
+	api.Any("/states/lookup", handlers.EmbeddedUnsupportedHandler)
+	api.Any("/states/:identifier/boundary", handlers.EmbeddedUnsupportedHandler)
+	api.Any("/*", handlers.EmbeddedUnsupportedHandler)
+}
@@ -1,14 +1,14 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"geocoding-api/config"
 	"geocoding-api/handlers"
+	"geocoding-api/logging"
 	"geocoding-api/models"
 	"geocoding-api/services"
 
@@ -19,18 +19,18 @@ import (
 func APIKeyAuth() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// Skip authentication for certain endpoints
-			path := c.Request().URL.Path
+			// Skip authentication for certain endpoints, in every API version
+			path := unversionedPath(c.Request().URL.Path)
 			skipPaths := []string{
 				"/docs",
 				"/api-docs",
 				"/openapi",
 				"/swagger",
 				"/spec",
-				"/api/v1/auth/register",
-				"/api/v1/auth/login",
-				"/api/v1/auth/plans",
-				"/api/v1/health",
+				"/api/auth/register",
+				"/api/auth/login",
+				"/api/auth/plans",
+				"/api/health",
 			}
 
 			for _, skipPath := range skipPaths {
@@ -39,6 +39,12 @@ func APIKeyAuth() echo.MiddlewareFunc {
 				}
 			}
 
+			// An admin replay runs as the recorded API key without its secret,
+			// and neither counts toward limits nor records usage
+			if apiKeyID, ok := services.ReplayAPIKeyFromContext(c.Request().Context()); ok {
+				return replayAuth(c, next, apiKeyID)
+			}
+
 			// Extract API key from either X-API-Key or Authorization header
 			var apiKey string
 			
@@ -52,6 +58,7 @@ func APIKeyAuth() echo.MiddlewareFunc {
 					return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 						Success: false,
 						Error:   "Invalid authorization format. Use 'Authorization: Bearer your-api-key' or 'X-API-Key: your-api-key'",
+						Code:    models.ErrCodeUnauthorized,
 					})
 				}
 				apiKey = parts[1]
@@ -59,6 +66,7 @@ func APIKeyAuth() echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "API key required. Include 'Authorization: Bearer your-api-key' or 'X-API-Key: your-api-key' header",
+					Code:    models.ErrCodeUnauthorized,
 				})
 			}
 
@@ -66,64 +74,113 @@ func APIKeyAuth() echo.MiddlewareFunc {
 			startTime := time.Now()
 
 			// Validate API key
-			user, keyRecord, err := services.Auth.ValidateAPIKey(apiKey)
+			user, keyRecord, err := services.Auth.ValidateAPIKey(c.Request().Context(), apiKey)
 			if err != nil {
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Invalid API key",
+					Code:    models.ErrCodeInvalidAPIKey,
 				})
 			}
 
+			// Heavy endpoints count as several calls against quotas
+			cost := services.EndpointCost(c.Request().Method, strings.TrimPrefix(unversionedPath(c.Path()), "/api"))
+			c.Response().Header().Set("X-API-Usage-Cost", strconv.Itoa(cost))
+
 			// Check rate limits
-			withinLimit, currentUsage, monthlyLimit, err := services.Auth.CheckRateLimit(user.ID)
+			withinLimit, currentUsage, monthlyLimit, err := services.Auth.CheckRateLimit(c.Request().Context(), user.ID, keyRecord)
 			if err != nil {
 				return c.JSON(http.StatusInternalServerError, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Failed to check rate limit",
+					Code:    models.ErrCodeInternal,
 				})
 			}
 
+			// An approved burst window lets over-limit requests through for
+			// its duration, up to its QPS
 			if !withinLimit {
-				// Record over-limit usage (non-billable)
-				overLimitEndpoint := getEndpointName(path)
-				method := c.Request().Method
-				statusCode := http.StatusTooManyRequests
-				responseTime := int(time.Since(startTime).Milliseconds())
-				ipAddress := c.RealIP()
-				userAgent := c.Request().UserAgent()
-				
-				go func() {
-					err := services.Auth.RecordUsage(
-						user.ID, keyRecord.ID, overLimitEndpoint, method,
-						statusCode, responseTime, ipAddress, userAgent, false,
-					)
-					if err != nil {
-						log.Printf("Failed to record over-limit usage: %v", err)
+				if window, allowed := services.Burst.Admit(c.Request().Context(), user.ID); window != nil {
+					if !allowed {
+						c.Response().Header().Set("Retry-After", "1")
+						return c.JSON(http.StatusTooManyRequests, handlers.GeocodeResponse{
+							Success: false,
+							Error:   "Burst window request rate exceeded",
+							Code:    models.ErrCodeRateLimited,
+							Data: map[string]interface{}{
+								"burst_window_id": window.ID,
+								"requested_qps":   window.RequestedQPS,
+								"ends_at":         window.EndsAt,
+							},
+						})
 					}
-				}()
+					withinLimit = true
+				}
+			}
+
+			// Users who opted in to overage billing keep going past their
+			// monthly limit, up to their spend cap
+			overage := false
+			if !withinLimit {
+				overage, err = services.Auth.AllowOverage(c.Request().Context(), user.ID, keyRecord, cost)
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, handlers.GeocodeResponse{
+						Success: false,
+						Error:   "Failed to check rate limit",
+						Code:    models.ErrCodeInternal,
+					})
+				}
+			}
+
+			if !withinLimit && !overage {
+				// Record over-limit usage (non-billable)
+				event := services.UsageEvent{
+					UserID:         user.ID,
+					APIKeyID:       keyRecord.ID,
+					Endpoint:       getEndpointName(path),
+					Method:         c.Request().Method,
+					StatusCode:     http.StatusTooManyRequests,
+					ResponseTimeMs: int(time.Since(startTime).Milliseconds()),
+					IPAddress:      c.RealIP(),
+					UserAgent:      c.Request().UserAgent(),
+					Billable:       false,
+					Cost:           cost,
+				}
+				captureRequest(c, &event)
+				services.Usage.Record(event)
 				
+				data := map[string]interface{}{
+					"current_usage":  currentUsage,
+					"monthly_limit":  monthlyLimit,
+					"plan_type":      user.PlanType,
+					"upgrade_info":   "Consider upgrading your plan for higher limits",
+				}
+				// A capped key may have hit its own limit rather than the plan's
+				if keyRecord.MonthlyLimit != nil || keyRecord.DailyLimit != nil {
+					data["api_key_monthly_limit"] = keyRecord.MonthlyLimit
+					data["api_key_daily_limit"] = keyRecord.DailyLimit
+				}
 				return c.JSON(http.StatusTooManyRequests, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Monthly API limit exceeded",
-					Data: map[string]interface{}{
-						"current_usage":  currentUsage,
-						"monthly_limit":  monthlyLimit,
-						"plan_type":      user.PlanType,
-						"upgrade_info":   "Consider upgrading your plan for higher limits",
-					},
+					Code:    models.ErrCodeQuotaExceeded,
+					Data:    data,
 				})
 			}
 
-			// Check endpoint permissions
+			// Check endpoint permissions against the route's registered permission
 			endpoint := getEndpointName(path)
-			if !services.Auth.HasPermission(keyRecord, endpoint) {
+			requiredPermission, registered := services.Permissions.PermissionForRoute(c.Request().Method, c.Path())
+			if !registered || !services.Auth.HasPermission(keyRecord, requiredPermission) {
 				return c.JSON(http.StatusForbidden, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "API key does not have permission for this endpoint",
+					Code:    models.ErrCodePermissionDenied,
 					Data: map[string]interface{}{
 						"endpoint":          endpoint,
-						"required_permission": endpoint,
+						"required_permission": requiredPermission,
 						"available_permissions": keyRecord.Permissions,
+						"permissions_help":  handlers.APIPath(c, "/meta/permissions"),
 					},
 				})
 			}
@@ -136,29 +193,66 @@ func APIKeyAuth() echo.MiddlewareFunc {
 			// Call next handler
 			err = next(c)
 
-			// Capture needed values before goroutine (don't pass context to goroutine)
-			responseTime := int(time.Since(startTime).Milliseconds())
-			method := c.Request().Method
-			statusCode := c.Response().Status
-			ipAddress := c.RealIP()
-			userAgent := c.Request().UserAgent()
-
-			// Record usage after request completes
-			go func() {
-				err := services.Auth.RecordUsage(
-					user.ID, keyRecord.ID, endpoint, method,
-					statusCode, responseTime, ipAddress, userAgent, true,
-				)
-				if err != nil {
-					log.Printf("Failed to record usage: %v", err)
-				}
-			}()
+			// Record usage after request completes; the writer batches it in the background
+			flags, _ := c.Get(handlers.FeatureFlagsContextKey).(map[string]bool)
+			event := services.UsageEvent{
+				UserID:         user.ID,
+				APIKeyID:       keyRecord.ID,
+				Endpoint:       endpoint,
+				Method:         c.Request().Method,
+				StatusCode:     c.Response().Status,
+				ResponseTimeMs: int(time.Since(startTime).Milliseconds()),
+				IPAddress:      c.RealIP(),
+				UserAgent:      c.Request().UserAgent(),
+				Billable:       true,
+				Overage:        overage,
+				Cost:           cost,
+				FeatureFlags:   flags,
+			}
+			captureRequest(c, &event)
+			services.Usage.Record(event)
 
 			return err
 		}
 	}
 }
 
+// replayAuth authenticates an admin replay as the recorded API key. The
+// route permission is still checked so the replay behaves like the original.
+func replayAuth(c echo.Context, next echo.HandlerFunc, apiKeyID int) error {
+	user, keyRecord, err := services.Auth.GetActiveAPIKeyByID(c.Request().Context(), apiKeyID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
+			Success: false,
+			Error:   "Invalid API key",
+			Code:    models.ErrCodeInvalidAPIKey,
+		})
+	}
+
+	requiredPermission, registered := services.Permissions.PermissionForRoute(c.Request().Method, c.Path())
+	if !registered || !services.Auth.HasPermission(keyRecord, requiredPermission) {
+		return c.JSON(http.StatusForbidden, handlers.GeocodeResponse{
+			Success: false,
+			Error:   "API key does not have permission for this endpoint",
+			Code:    models.ErrCodePermissionDenied,
+		})
+	}
+
+	c.Set("user", user)
+	c.Set("api_key", keyRecord)
+	c.Set("start_time", time.Now())
+	return next(c)
+}
+
+// captureRequest adds the path and sanitized query string to a usage event
+// when USAGE_CAPTURE_REQUESTS is on
+func captureRequest(c echo.Context, event *services.UsageEvent) {
+	if config.Get().Workers.UsageCaptureRequests {
+		event.Path = c.Request().URL.Path
+		event.QueryString = services.SanitizeQuery(c.Request().URL.RawQuery)
+	}
+}
+
 // getEndpointName extracts the endpoint name from the path for categorization
 func getEndpointName(path string) string {
 	if strings.Contains(path, "/geocode/") {
@@ -176,9 +270,22 @@ func getEndpointName(path string) string {
 	if strings.Contains(path, "/search") {
 		return "search"
 	}
+	if strings.HasSuffix(path, "/encode") || strings.HasSuffix(path, "/decode") {
+		return "cells"
+	}
+	if strings.Contains(path, "/tiles/") {
+		return "tiles"
+	}
+	// Before /addresses, which also appears in geofence and tile paths
+	if strings.Contains(path, "/geofences") {
+		return "geofences"
+	}
 	if strings.Contains(path, "/addresses") {
 		return "addresses"
 	}
+	if strings.Contains(path, "/streets") {
+		return "streets"
+	}
 	if strings.Contains(path, "/counties") {
 		return "counties"
 	}
@@ -188,6 +295,12 @@ func getEndpointName(path string) string {
 	if strings.Contains(path, "/states") {
 		return "states"
 	}
+	if strings.Contains(path, "/districts") {
+		return "districts"
+	}
+	if strings.Contains(path, "/crosswalk/") {
+		return "crosswalk"
+	}
 	if strings.Contains(path, "/admin/") {
 		return "admin"
 	}
@@ -204,6 +317,7 @@ func RequireUserAuth() echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Authorization header required",
+					Code:    models.ErrCodeUnauthorized,
 				})
 			}
 
@@ -213,6 +327,7 @@ func RequireUserAuth() echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Invalid authorization format. Use 'Bearer <token>'",
+					Code:    models.ErrCodeUnauthorized,
 				})
 			}
 
@@ -224,6 +339,7 @@ func RequireUserAuth() echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Invalid or expired token",
+					Code:    models.ErrCodeInvalidToken,
 				})
 			}
 
@@ -247,7 +363,8 @@ func UsageHeader() echo.MiddlewareFunc {
 			// Add usage info to headers if user is authenticated
 			if user, ok := c.Get("user").(*models.User); ok {
 				// Get current usage for the user
-				if _, currentUsage, monthlyLimit, err := services.Auth.CheckRateLimit(user.ID); err == nil {
+				apiKey, _ := c.Get("api_key").(*models.APIKey)
+				if _, currentUsage, monthlyLimit, err := services.Auth.CheckRateLimit(c.Request().Context(), user.ID, apiKey); err == nil {
 					c.Response().Header().Set("X-API-Usage-Current", strconv.Itoa(currentUsage))
 					c.Response().Header().Set("X-API-Usage-Limit", strconv.Itoa(monthlyLimit))
 					c.Response().Header().Set("X-API-Plan", user.PlanType)
@@ -259,45 +376,36 @@ func UsageHeader() echo.MiddlewareFunc {
 	}
 }
 
-// isAdminEmail checks if the given email is in the ADMIN_EMAILS environment variable
+// isAdminEmail checks if the given email is listed in ADMIN_EMAILS
 func isAdminEmail(email string) bool {
-	adminEmails := os.Getenv("ADMIN_EMAILS")
-	if adminEmails == "" {
-		return false
-	}
-	
-	emails := strings.Split(adminEmails, ",")
-	for _, adminEmail := range emails {
-		if strings.TrimSpace(adminEmail) == email {
-			return true
-		}
-	}
-	return false
+	return config.Get().IsAdminEmail(email)
 }
 
 // RequireAdminAuth middleware ensures user is authenticated via JWT and has admin privileges
 func RequireAdminAuth() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			log.Printf("[AdminAuth] Request: %s %s", c.Request().Method, c.Request().URL.Path)
+			logger := logging.FromContext(c).With("component", "admin_auth")
 			
 			// Use JWT authentication for admin routes
 			authHeader := c.Request().Header.Get("Authorization")
 			if authHeader == "" {
-				log.Println("[AdminAuth] No Authorization header")
+				logger.Warn("admin request without Authorization header")
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Authorization header required",
+					Code:    models.ErrCodeUnauthorized,
 				})
 			}
 
 			// Parse Bearer token
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || parts[0] != "Bearer" {
-				log.Println("[AdminAuth] Invalid authorization format")
+				logger.Warn("invalid admin authorization format")
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Invalid authorization format. Use 'Bearer <token>'",
+					Code:    models.ErrCodeUnauthorized,
 				})
 			}
 
@@ -306,35 +414,36 @@ func RequireAdminAuth() echo.MiddlewareFunc {
 			// Validate JWT token
 			claims, err := services.Auth.ValidateJWT(tokenString)
 			if err != nil {
-				log.Printf("[AdminAuth] Invalid token: %v", err)
+				logger.Warn("invalid admin token", "error", err)
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Invalid or expired token",
+					Code:    models.ErrCodeInvalidToken,
 				})
 			}
 
-			log.Printf("[AdminAuth] Token valid for user ID: %d", claims.UserID)
-
 			// Get user from database to check admin status
-			user, err := services.Auth.GetUserByID(claims.UserID)
+			user, err := services.Auth.GetUserByID(c.Request().Context(), claims.UserID)
 			if err != nil {
-				log.Printf("[AdminAuth] User not found: %v", err)
+				logger.Warn("admin token user not found", "user_id", claims.UserID, "error", err)
 				return c.JSON(http.StatusUnauthorized, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "User not found",
+					Code:    models.ErrCodeUnauthorized,
 				})
 			}
 
 			// Check if user has admin privileges
 			if !user.IsAdmin && !isAdminEmail(user.Email) {
-				log.Printf("[AdminAuth] User %s is not admin", user.Email)
+				logger.Warn("admin privileges required", "user_id", user.ID)
 				return c.JSON(http.StatusForbidden, handlers.GeocodeResponse{
 					Success: false,
 					Error:   "Admin privileges required",
+					Code:    models.ErrCodePermissionDenied,
 				})
 			}
 
-			log.Printf("[AdminAuth] Admin access granted for user: %s (ID: %d)", user.Email, user.ID)
+			logger.Debug("admin access granted", "user_id", user.ID)
 
 			// Store user info in context
 			c.Set("user_id", user.ID)
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// compressMinLength is the smallest body worth compressing; below it the
+// gzip framing outweighs the savings
+const compressMinLength = 1024
+
+// Compress gzips responses for clients that accept it. Progress streams are
+// left alone so each event reaches the client as soon as it is written.
+func Compress(level int) echo.MiddlewareFunc {
+	return echomiddleware.GzipWithConfig(echomiddleware.GzipConfig{
+		Skipper: func(c echo.Context) bool {
+			return strings.HasSuffix(c.Path(), "/stream")
+		},
+		Level:     level,
+		MinLength: compressMinLength,
+	})
+}
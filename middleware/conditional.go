@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Cache-Control policies for reference data. Responses are per API key, so
+// they're private; boundaries change least and are the most costly to resend.
+const (
+	CacheReferenceData = "private, max-age=3600"
+	CacheBoundaries    = "private, max-age=86400"
+)
+
+// bufferedResponse holds a response back so its ETag can be computed before
+// anything is sent
+type bufferedResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponse) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponse) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// ConditionalGet adds ETag, Last-Modified and Cache-Control headers to
+// successful GET responses and answers 304 Not Modified when the client
+// already has the current body. The ETag is a hash of the body, so it is the
+// same on every instance; Last-Modified is when the reference data was last
+// loaded.
+func ConditionalGet(cacheControl string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next(c)
+			}
+
+			res := c.Response()
+			original := res.Writer
+			buffered := &bufferedResponse{ResponseWriter: original}
+			res.Writer = buffered
+			err := next(c)
+			res.Writer = original
+
+			if buffered.status == 0 {
+				// Nothing was written; the error handler will respond
+				return err
+			}
+			if buffered.status != http.StatusOK {
+				original.WriteHeader(buffered.status)
+				original.Write(buffered.body.Bytes())
+				return err
+			}
+
+			sum := sha256.Sum256(buffered.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			modified := services.ReferenceDataModified()
+
+			header := res.Header()
+			header.Set("ETag", etag)
+			header.Set("Last-Modified", modified.Format(http.TimeFormat))
+			header.Set(echo.HeaderCacheControl, cacheControl)
+
+			if notModified(req, etag, modified) {
+				header.Del(echo.HeaderContentType)
+				header.Del(echo.HeaderContentLength)
+				res.Status = http.StatusNotModified
+				original.WriteHeader(http.StatusNotModified)
+				return err
+			}
+
+			original.WriteHeader(http.StatusOK)
+			original.Write(buffered.body.Bytes())
+			return err
+		}
+	}
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no
+// If-None-Match, as RFC 9110 requires
+func notModified(req *http.Request, etag string, modified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		return err == nil && !modified.After(since)
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalGet(t *testing.T) {
+	e := echo.New()
+	e.GET("/states/:identifier", func(c echo.Context) error {
+		if c.Param("identifier") == "XX" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+		}
+		return c.JSON(http.StatusOK, map[string]string{"state": c.Param("identifier")})
+	}, ConditionalGet(CacheReferenceData))
+
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/states/OH", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, CacheReferenceData, first.Header().Get("Cache-Control"))
+	assert.Equal(t, services.ReferenceDataModified().Format(http.TimeFormat), first.Header().Get("Last-Modified"))
+	assert.JSONEq(t, `{"state":"OH"}`, first.Body.String())
+
+	t.Run("matching ETag", func(t *testing.T) {
+		rec := get("/states/OH", map[string]string{"If-None-Match": `"other", W/` + etag})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("stale ETag", func(t *testing.T) {
+		rec := get("/states/OH", map[string]string{"If-None-Match": `"other"`})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"state":"OH"}`, rec.Body.String())
+	})
+
+	t.Run("different body", func(t *testing.T) {
+		rec := get("/states/IN", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		later := services.ReferenceDataModified().Add(time.Hour).Format(http.TimeFormat)
+		assert.Equal(t, http.StatusNotModified, get("/states/OH", map[string]string{"If-Modified-Since": later}).Code)
+
+		earlier := services.ReferenceDataModified().Add(-time.Hour).Format(http.TimeFormat)
+		assert.Equal(t, http.StatusOK, get("/states/OH", map[string]string{"If-Modified-Since": earlier}).Code)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		rec := get("/states/XX", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("ETag"))
+		assert.JSONEq(t, `{"error":"not found"}`, rec.Body.String())
+	})
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"geocoding-api/database"
+	"geocoding-api/handlers"
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DatabaseAvailable turns API requests away with 503 Service Unavailable
+// while the database circuit breaker is open, so an outage fails fast instead
+// of piling requests up waiting for connections. Retry-After says when the
+// breaker next tries the database. The health check is exempt so it can
+// report the outage.
+func DatabaseAvailable() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !database.Circuit.Open() || unversionedPath(c.Request().URL.Path) == "/api/health" {
+				return next(c)
+			}
+
+			retryAfter := int(database.Circuit.RetryAfter().Seconds()) + 1
+			c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			return c.JSON(http.StatusServiceUnavailable, handlers.GeocodeResponse{
+				Success: false,
+				Error:   "The database is unavailable. Try again later.",
+				Code:    models.ErrCodeUnavailable,
+			})
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"geocoding-api/database"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseAvailable(t *testing.T) {
+	original := database.Circuit
+	t.Cleanup(func() { database.Circuit = original })
+	database.Circuit = database.NewCircuitBreaker(1, time.Minute)
+
+	e := echo.New()
+	api := e.Group("/api/v2", DatabaseAvailable())
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	api.GET("/geocode/:zipcode", ok)
+	api.GET("/health", ok)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, get("/api/v2/geocode/43215").Code)
+
+	database.Circuit.Failure(errors.New("connection refused"))
+	rec := get("/api/v2/geocode/43215")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "SERVICE_UNAVAILABLE")
+
+	assert.Equal(t, http.StatusOK, get("/api/v2/health").Code, "health reports the outage itself")
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"geocoding-api/handlers"
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// demoIPCounter tracks requests from one client IP in the current window
+type demoIPCounter struct {
+	windowStart time.Time
+	count       int
+}
+
+// demoBucket is one client IP's token bucket and daily request count
+type demoBucket struct {
+	tokens   float64
+	updated  time.Time
+	day      string
+	dayCount int
+}
+
+// take refills the bucket for the time since its last request and spends
+// one token. It reports whether the request is allowed and, when it isn't,
+// how long until it would be.
+func (b *demoBucket) take(now time.Time, perMinute, dailyLimit int) (bool, time.Duration) {
+	if day := now.UTC().Format("2006-01-02"); day != b.day {
+		b.day, b.dayCount = day, 0
+	}
+	if b.dayCount >= dailyLimit {
+		tomorrow := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day()+1, 0, 0, 0, 0, time.UTC)
+		return false, tomorrow.Sub(now)
+	}
+
+	rate := float64(perMinute) / float64(time.Minute)
+	b.tokens = math.Min(float64(perMinute), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate)
+	}
+	b.tokens--
+	b.dayCount++
+	return true, 0
+}
+
+// DemoRateLimit throttles the unauthenticated demo endpoints per client IP
+// with a token bucket that holds perMinute requests and refills at that rate,
+// so short bursts are fine but sustained use is not, plus a strict cap of
+// dailyLimit requests per IP per UTC day
+func DemoRateLimit(perMinute, dailyLimit int) echo.MiddlewareFunc {
+	var mu sync.Mutex
+	buckets := make(map[string]*demoBucket)
+	lastSweep := time.Now()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ip := c.RealIP()
+			now := time.Now()
+
+			mu.Lock()
+			// Drop IPs whose bucket has refilled and whose daily count has
+			// expired, so the map doesn't grow without bound
+			if now.Sub(lastSweep) > time.Minute {
+				today := now.UTC().Format("2006-01-02")
+				for key, bucket := range buckets {
+					if bucket.day != today && now.Sub(bucket.updated) > time.Minute {
+						delete(buckets, key)
+					}
+				}
+				lastSweep = now
+			}
+
+			bucket, ok := buckets[ip]
+			if !ok {
+				bucket = &demoBucket{tokens: float64(perMinute), updated: now}
+				buckets[ip] = bucket
+			}
+			allowed, retryAfter := bucket.take(now, perMinute, dailyLimit)
+			remaining := int(bucket.tokens)
+			dailyRemaining := dailyLimit - bucket.dayCount
+			mu.Unlock()
+
+			c.Response().Header().Set("X-RateLimit-Limit", strconv.Itoa(perMinute))
+			c.Response().Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			c.Response().Header().Set("X-RateLimit-Daily-Limit", strconv.Itoa(dailyLimit))
+			c.Response().Header().Set("X-RateLimit-Daily-Remaining", strconv.Itoa(dailyRemaining))
+
+			if !allowed {
+				message := "Demo rate limit exceeded. Sign up for a free API key for higher limits."
+				if dailyRemaining <= 0 {
+					message = "Daily demo limit reached. Sign up for a free API key to keep going."
+				}
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return c.JSON(http.StatusTooManyRequests, handlers.GeocodeResponse{
+					Success: false,
+					Error:   message,
+					Code:    models.ErrCodeRateLimited,
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// IPRateLimit enforces a fixed-window per-IP request limit on
+// unauthenticated endpoints, answering with message once it is exceeded
+func IPRateLimit(limit int, window time.Duration, message string) echo.MiddlewareFunc {
+	var mu sync.Mutex
+	counters := make(map[string]*demoIPCounter)
+	lastSweep := time.Now()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ip := c.RealIP()
+			now := time.Now()
+
+			mu.Lock()
+			// Drop idle IPs periodically so the map doesn't grow without bound
+			if now.Sub(lastSweep) > window {
+				for key, counter := range counters {
+					if now.Sub(counter.windowStart) > window {
+						delete(counters, key)
+					}
+				}
+				lastSweep = now
+			}
+
+			counter, ok := counters[ip]
+			if !ok || now.Sub(counter.windowStart) > window {
+				counter = &demoIPCounter{windowStart: now}
+				counters[ip] = counter
+			}
+			counter.count++
+			count := counter.count
+			resetAt := counter.windowStart.Add(window)
+			mu.Unlock()
+
+			remaining := limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			c.Response().Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			c.Response().Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+			if count > limit {
+				retryAfter := int(time.Until(resetAt).Seconds()) + 1
+				c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				return c.JSON(http.StatusTooManyRequests, handlers.GeocodeResponse{
+					Success: false,
+					Error:   message,
+					Code:    models.ErrCodeRateLimited,
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
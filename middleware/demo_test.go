@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemoBucket(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	bucket := &demoBucket{tokens: 3, updated: now}
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := bucket.take(now, 3, 100)
+		require.True(t, allowed, "a full bucket allows a burst")
+	}
+	allowed, retryAfter := bucket.take(now, 3, 100)
+	assert.False(t, allowed)
+	assert.Equal(t, 20*time.Second, retryAfter, "3 per minute refills a token every 20s")
+
+	allowed, _ = bucket.take(now.Add(20*time.Second), 3, 100)
+	assert.True(t, allowed)
+
+	// The daily cap holds however full the bucket is
+	bucket = &demoBucket{tokens: 3, updated: now, day: "2026-05-04", dayCount: 100}
+	allowed, retryAfter = bucket.take(now.Add(time.Hour), 3, 100)
+	assert.False(t, allowed)
+	assert.Equal(t, 11*time.Hour, retryAfter, "the daily cap resets at midnight UTC")
+
+	allowed, _ = bucket.take(time.Date(2026, 5, 5, 0, 0, 1, 0, time.UTC), 3, 100)
+	assert.True(t, allowed)
+}
+
+func TestDemoRateLimit(t *testing.T) {
+	e := echo.New()
+	e.IPExtractor = ClientIPExtractor(nil)
+	limit := DemoRateLimit(2, 3)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/demo/geocode/43215", nil)
+		req.RemoteAddr = ip + ":50000"
+		rec := httptest.NewRecorder()
+		require.NoError(t, limit(e.NewContext(req, rec)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("203.0.113.1").Code)
+	rec := request("203.0.113.1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Daily-Remaining"))
+
+	rec = request("203.0.113.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, request("203.0.113.2").Code, "each IP has its own bucket")
+}
+
+func TestDemoRateLimitIgnoresSpoofedHeaders(t *testing.T) {
+	e := echo.New()
+	e.IPExtractor = ClientIPExtractor(nil)
+	limit := DemoRateLimit(2, 100)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := func(spoofed string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/demo/geocode/43215", nil)
+		req.RemoteAddr = "203.0.113.1:50000"
+		req.Header.Set(echo.HeaderXForwardedFor, spoofed)
+		req.Header.Set(echo.HeaderXRealIP, spoofed)
+		rec := httptest.NewRecorder()
+		require.NoError(t, limit(e.NewContext(req, rec)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("198.51.100.1"))
+	assert.Equal(t, http.StatusOK, request("198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.3"), "a fresh header value doesn't get a fresh bucket")
+}
+
+func TestClientIPExtractor(t *testing.T) {
+	extract := func(extractor echo.IPExtractor, remoteAddr, xff string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, xff)
+		}
+		return extractor(req)
+	}
+
+	direct := ClientIPExtractor(nil)
+	assert.Equal(t, "203.0.113.1", extract(direct, "203.0.113.1:50000", "198.51.100.1"))
+	assert.Equal(t, "10.0.0.2", extract(direct, "10.0.0.2:50000", "198.51.100.1"), "private peers aren't trusted by default")
+
+	proxied := ClientIPExtractor([]string{"10.0.0.0/24", "192.0.2.10"})
+	assert.Equal(t, "198.51.100.1", extract(proxied, "10.0.0.2:50000", "198.51.100.1"))
+	assert.Equal(t, "198.51.100.1", extract(proxied, "192.0.2.10:50000", "1.2.3.4, 198.51.100.1"), "only the entry the proxy added is used")
+	assert.Equal(t, "203.0.113.1", extract(proxied, "203.0.113.1:50000", "198.51.100.1"), "clients outside the proxies can't set the header")
+	assert.Equal(t, "10.1.0.5", extract(proxied, "10.1.0.5:50000", "198.51.100.1"), "only the listed private ranges are trusted")
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+
+	"geocoding-api/handlers"
+	"geocoding-api/logging"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// IdempotencyKeyHeader names the client's key for a mutating request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength matches the idempotency_keys column
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentResponse is the largest response stored for replay;
+	// larger responses aren't stored, so a retry runs the request again
+	maxIdempotentResponse = 1 << 20
+)
+
+// capturedResponse passes a response through while keeping a copy of its
+// body, up to maxIdempotentResponse
+type capturedResponse struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *capturedResponse) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.body.Len()+len(b) > maxIdempotentResponse {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// hashingBody hashes a request body as the handler reads it
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	return n, err
+}
+
+// newRequestHash starts a request fingerprint with the method and route, so
+// reusing a key on another endpoint is caught like reusing it with another
+// body
+func newRequestHash(c echo.Context) hash.Hash {
+	h := sha256.New()
+	io.WriteString(h, c.Request().Method+" "+c.Path()+"\n")
+	return h
+}
+
+// idempotencyUserID returns the user behind an API key or a JWT
+func idempotencyUserID(c echo.Context) (int, bool) {
+	if user, ok := c.Get("user").(*models.User); ok && user != nil {
+		return user.ID, true
+	}
+	userID, ok := c.Get("user_id").(int)
+	return userID, ok
+}
+
+// replayBody returns the body to store for retries: the handler's
+// replacement when it set one, otherwise the response it sent
+func replayBody(c echo.Context, captured *capturedResponse) ([]byte, error) {
+	if replay, ok := c.Get(handlers.IdempotentReplayContextKey).(handlers.GeocodeResponse); ok {
+		return json.Marshal(replay)
+	}
+	return captured.body.Bytes(), nil
+}
+
+// Idempotency honors an Idempotency-Key header on mutating routes. The first
+// request with a key runs normally and its response is stored for
+// services.IdempotencyTTL; retries with the same key and body get that
+// response back, with Idempotent-Replayed: true, instead of running again.
+// Reusing a key for a different request is rejected, as is a retry while the
+// first request is still running. Server errors aren't stored, so they can
+// be retried. Handlers whose response carries a secret set
+// handlers.IdempotentReplayContextKey so retries get a response without it.
+// It must run after authentication, since keys are per user.
+func Idempotency() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, handlers.GeocodeResponse{
+					Success: false,
+					Error:   "Idempotency-Key must be at most 255 characters",
+					Code:    models.ErrCodeInvalidRequest,
+				})
+			}
+			userID, ok := idempotencyUserID(c)
+			if !ok {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			stored, err := services.Idempotency.Begin(ctx, userID, key)
+			if errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+				return c.JSON(http.StatusConflict, handlers.GeocodeResponse{
+					Success: false,
+					Error:   "A request with this Idempotency-Key is still in progress",
+					Code:    models.ErrCodeConflict,
+				})
+			}
+			if err != nil {
+				logging.FromContext(c).Error("failed to check idempotency key", "error", err)
+				return c.JSON(http.StatusInternalServerError, handlers.GeocodeResponse{
+					Success: false,
+					Error:   "Failed to check Idempotency-Key",
+					Code:    models.ErrCodeInternal,
+				})
+			}
+
+			requestHash := newRequestHash(c)
+			if stored != nil {
+				// Hash the retry without buffering it; uploads can be large
+				if _, err := io.Copy(requestHash, c.Request().Body); err != nil {
+					return c.JSON(http.StatusBadRequest, handlers.GeocodeResponse{
+						Success: false,
+						Error:   "Failed to read request body",
+						Code:    models.ErrCodeInvalidRequest,
+					})
+				}
+				if hex.EncodeToString(requestHash.Sum(nil)) != stored.RequestHash {
+					return c.JSON(http.StatusUnprocessableEntity, handlers.GeocodeResponse{
+						Success: false,
+						Error:   "Idempotency-Key was already used for a different request",
+						Code:    models.ErrCodeInvalidRequest,
+					})
+				}
+				c.Response().Header().Set(IdempotentReplayedHeader, "true")
+				return c.Blob(stored.StatusCode, stored.ContentType, stored.Body)
+			}
+
+			body := &hashingBody{ReadCloser: c.Request().Body, hash: requestHash}
+			c.Request().Body = body
+			res := c.Response()
+			original := res.Writer
+			captured := &capturedResponse{ResponseWriter: original}
+			res.Writer = captured
+			err = next(c)
+			res.Writer = original
+
+			// The client may have gone away, which is when it will retry, so
+			// the outcome is recorded even if the request was canceled
+			ctx = context.WithoutCancel(ctx)
+
+			// A returned error is answered by the error handler, unstored
+			if err != nil || !res.Committed || res.Status >= http.StatusInternalServerError || captured.overflow {
+				if releaseErr := services.Idempotency.Release(ctx, userID, key); releaseErr != nil {
+					logging.FromContext(c).Warn("failed to release idempotency key", "error", releaseErr)
+				}
+				return err
+			}
+
+			// Hash whatever the handler left unread so the fingerprint
+			// covers the whole body
+			io.Copy(io.Discard, body)
+			replay, storeErr := replayBody(c, captured)
+			if storeErr == nil {
+				storeErr = services.Idempotency.Complete(ctx, userID, key, services.IdempotentResponse{
+					RequestHash: hex.EncodeToString(requestHash.Sum(nil)),
+					StatusCode:  res.Status,
+					ContentType: res.Header().Get(echo.HeaderContentType),
+					Body:        replay,
+				})
+			}
+			if storeErr != nil {
+				logging.FromContext(c).Warn("failed to store idempotent response", "error", storeErr)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"geocoding-api/handlers"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		userID     int
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "no key runs the handler", userID: 1, wantStatus: http.StatusCreated, wantCalled: true},
+		{name: "no user runs the handler", key: "retry-1", wantStatus: http.StatusCreated, wantCalled: true},
+		{name: "over-long key is rejected", key: strings.Repeat("k", 256), userID: 1, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/geocode/jobs", strings.NewReader(`{}`))
+			if tt.key != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.userID != 0 {
+				c.Set("user_id", tt.userID)
+			}
+
+			called := false
+			err := Idempotency()(func(c echo.Context) error {
+				called = true
+				return c.NoContent(http.StatusCreated)
+			})(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCalled, called)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		})
+	}
+}
+
+func TestIdempotentReplayBody(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/user/api-keys", nil), httptest.NewRecorder())
+	captured := &capturedResponse{ResponseWriter: httptest.NewRecorder()}
+	captured.Write([]byte(`{"success":true,"data":{"key_string":"gk_secret"}}`))
+
+	body, err := replayBody(c, captured)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "gk_secret", "the sent response is stored by default")
+
+	// A handler's replacement is stored instead, so the secret never is
+	c.Set(handlers.IdempotentReplayContextKey, handlers.GeocodeResponse{
+		Success: true,
+		Data:    map[string]interface{}{"api_key": map[string]int{"id": 3}},
+	})
+	body, err = replayBody(c, captured)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "gk_secret")
+	assert.JSONEq(t, `{"success":true,"data":{"api_key":{"id":3}}}`, string(body))
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"geocoding-api/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Locale negotiates the response language from ?lang= and Accept-Language
+// and stores it for the response serializer. Responses vary by
+// Accept-Language, so shared caches keep the languages apart.
+func Locale() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			locale := i18n.Negotiate(c.QueryParam("lang"), req.Header.Get("Accept-Language"))
+			c.Set(i18n.ContextKey, locale)
+
+			header := c.Response().Header()
+			header.Set("Content-Language", locale)
+			header.Add(echo.HeaderVary, "Accept-Language")
+			return next(c)
+		}
+	}
+}
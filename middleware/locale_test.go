@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/i18n"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocale(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/states?lang=es", nil)
+	req.Header.Set("Accept-Language", "en-US")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	var locale string
+	err := Locale()(func(c echo.Context) error {
+		locale = i18n.FromContext(c)
+		return nil
+	})(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, i18n.Spanish, locale)
+	assert.Equal(t, "es", rec.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", rec.Header().Get(echo.HeaderVary))
+}
@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
+	"geocoding-api/logging"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -20,6 +23,49 @@ const (
 	White   = "\033[97m"
 )
 
+// RequestLogger logs one record per request with its correlation fields,
+// status and latency. In color format it falls back to ColorizedLogger for a
+// readable development console.
+func RequestLogger() echo.MiddlewareFunc {
+	if logging.Format() == logging.FormatColor {
+		return ColorizedLogger()
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			res := c.Response()
+			level := slog.LevelInfo
+			switch {
+			case res.Status >= 500:
+				level = slog.LevelError
+			case res.Status >= 400:
+				level = slog.LevelWarn
+			}
+
+			attrs := append(logging.RequestAttrs(c),
+				slog.String("path", c.Request().URL.Path),
+				slog.Int("status", res.Status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int64("bytes_out", res.Size),
+				slog.String("remote_ip", c.RealIP()),
+			)
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			slog.Log(c.Request().Context(), level, "request", attrs...)
+
+			return err
+		}
+	}
+}
+
 // ColorizedLogger returns a middleware that logs HTTP requests with colors
 func ColorizedLogger() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -57,7 +103,7 @@ func ColorizedLogger() echo.MiddlewareFunc {
 			latencyColor := getLatencyColor(latency)
 			
 			// Build log message
-			fmt.Printf("%s%s%s %s%3d%s %s%-7s%s %s%s\n",
+			fmt.Printf("%s%s%s %s%3d%s %s%-7s%s %s%s%s %s\n",
 				Gray, start.Format("15:04:05"), Reset,
 				statusColor, status, Reset,
 				methodColor, method, Reset,
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/i18n"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ResponseCacheHeader reports whether a response came from the response
+// cache: HIT or MISS
+const ResponseCacheHeader = "X-Cache"
+
+// ResponseCache serves repeated GET requests to a read-only route from the
+// response cache (RESPONSE_CACHE) for ttl, or the route's RESPONSE_CACHE_TTLS
+// override, so a customer repeating the same lookup reaches Postgres once per
+// ttl. Responses are cached per user, path, query and locale; only 200
+// responses up to 1 MiB are stored, along with the headers the handler set,
+// such as pagination's Link. It must run after authentication, and
+// inside ConditionalGet so cached bodies still get an ETag.
+func ResponseCache(ttl time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if (req.Method != http.MethodGet && req.Method != http.MethodHead) || !services.ResponseCacheEnabled() {
+				return next(c)
+			}
+			route := strings.TrimPrefix(unversionedPath(c.Path()), "/api")
+			routeTTL := services.ResponseCacheTTL(route, ttl)
+			if routeTTL <= 0 {
+				return next(c)
+			}
+
+			key := responseCacheKey(c)
+			res := c.Response()
+			if cached := services.GetCachedResponse(req.Context(), route, key); cached != nil {
+				for name, values := range cached.Header {
+					res.Header()[name] = values
+				}
+				res.Header().Set(ResponseCacheHeader, "HIT")
+				return c.Blob(cached.Status, cached.ContentType, cached.Body)
+			}
+
+			res.Header().Set(ResponseCacheHeader, "MISS")
+			before := res.Header().Clone()
+			original := res.Writer
+			captured := &capturedResponse{ResponseWriter: original}
+			res.Writer = captured
+			err := next(c)
+			res.Writer = original
+
+			if err != nil || res.Status != http.StatusOK || captured.overflow || req.Method == http.MethodHead {
+				return err
+			}
+			services.CacheResponse(req.Context(), route, key, &services.CachedResponse{
+				Status:      res.Status,
+				ContentType: res.Header().Get(echo.HeaderContentType),
+				Body:        captured.body.Bytes(),
+				Header:      handlerHeaders(before, res.Header()),
+			}, routeTTL)
+			return nil
+		}
+	}
+}
+
+// uncachedHeaders are left out of cached responses: the content type is kept
+// separately, the length is set again when the body is written and cookies
+// are never replayed
+var uncachedHeaders = map[string]bool{
+	echo.HeaderContentType:   true,
+	echo.HeaderContentLength: true,
+	echo.HeaderSetCookie:     true,
+	ResponseCacheHeader:      true,
+}
+
+// handlerHeaders returns the headers added or changed between before and
+// after, i.e. by the handler rather than by the middleware around the cache,
+// which sets its own again on a hit
+func handlerHeaders(before, after http.Header) http.Header {
+	var set http.Header
+	for name, values := range after {
+		if uncachedHeaders[name] || slices.Equal(before[name], values) {
+			continue
+		}
+		if set == nil {
+			set = http.Header{}
+		}
+		set[name] = slices.Clone(values)
+	}
+	return set
+}
+
+// responseCacheKey fingerprints what a read-only response depends on: the
+// caller, since results can include their own data, the full path with its
+// version, the query with its parameters sorted and blank ones dropped, and
+// the locale messages are translated into
+func responseCacheKey(c echo.Context) string {
+	caller := "anonymous"
+	if userID, ok := idempotencyUserID(c); ok {
+		caller = strconv.Itoa(userID)
+	}
+	locale, _ := c.Get(i18n.ContextKey).(string)
+
+	h := sha256.New()
+	io.WriteString(h, caller+"\n"+c.Request().URL.Path+"\n"+normalizedQuery(c.QueryParams())+"\n"+locale)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizedQuery encodes a query with its keys sorted and blank values
+// dropped, so parameter order and empty parameters don't split the cache
+func normalizedQuery(query url.Values) string {
+	normalized := url.Values{}
+	for name, values := range query {
+		for _, value := range values {
+			if strings.TrimSpace(value) != "" {
+				normalized.Add(name, value)
+			}
+		}
+	}
+	return normalized.Encode()
+}
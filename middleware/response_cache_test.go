@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/models"
+	"geocoding-api/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	require.NoError(t, services.InitResponseCache(config.ResponseCacheConfig{Backend: "memory", MaxEntries: 100}))
+	t.Cleanup(func() { services.InitResponseCache(config.ResponseCacheConfig{}) })
+
+	calls := 0
+	e := echo.New()
+	e.GET("/api/v1/geocode/:zipcode", func(c echo.Context) error {
+		calls++
+		if c.Param("zipcode") == "00000" {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+		}
+		return c.JSON(http.StatusOK, map[string]any{"zip": c.Param("zipcode"), "calls": calls})
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if userID, err := strconv.Atoi(c.Request().Header.Get("X-User")); err == nil {
+				c.Set("user", &models.User{ID: userID})
+			}
+			return next(c)
+		}
+	}, ResponseCache(time.Hour))
+
+	get := func(path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/api/v1/geocode/43215?fields=city&lang=", "1")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get(ResponseCacheHeader))
+
+	second := get("/api/v1/geocode/43215?fields=city", "1")
+	assert.Equal(t, "HIT", second.Header().Get(ResponseCacheHeader), "blank parameter dropped")
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, first.Header().Get(echo.HeaderContentType), second.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, "MISS", get("/api/v1/geocode/43215?fields=city", "2").Header().Get(ResponseCacheHeader), "another user")
+	assert.Equal(t, "MISS", get("/api/v1/geocode/43215?fields=state", "1").Header().Get(ResponseCacheHeader), "another query")
+
+	get("/api/v1/geocode/00000", "1")
+	assert.Equal(t, "MISS", get("/api/v1/geocode/00000", "1").Header().Get(ResponseCacheHeader), "errors aren't cached")
+
+	purged, err := services.PurgeResponseCache(context.Background(), "/geocode/:zipcode")
+	require.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	assert.Equal(t, "MISS", get("/api/v1/geocode/43215?fields=city", "1").Header().Get(ResponseCacheHeader))
+}
+
+func TestResponseCacheKeepsHandlerHeaders(t *testing.T) {
+	require.NoError(t, services.InitResponseCache(config.ResponseCacheConfig{Backend: "memory", MaxEntries: 100}))
+	t.Cleanup(func() { services.InitResponseCache(config.ResponseCacheConfig{}) })
+
+	requests := 0
+	e := echo.New()
+	e.GET("/api/v1/addresses", func(c echo.Context) error {
+		c.Response().Header().Set("Link", `</api/v1/addresses?offset=10>; rel="next"`)
+		c.Response().Header().Set("X-Total-Count", "42")
+		return c.JSON(http.StatusOK, map[string]any{"count": 10})
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requests++
+			c.Response().Header().Set(echo.HeaderXRequestID, strconv.Itoa(requests))
+			return next(c)
+		}
+	}, ResponseCache(time.Hour))
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/addresses?limit=10", nil))
+		return rec
+	}
+
+	first := get()
+	require.Equal(t, "MISS", first.Header().Get(ResponseCacheHeader))
+	second := get()
+	require.Equal(t, "HIT", second.Header().Get(ResponseCacheHeader))
+	assert.Equal(t, first.Header().Get("Link"), second.Header().Get("Link"))
+	assert.Equal(t, "42", second.Header().Get("X-Total-Count"))
+	assert.Equal(t, "2", second.Header().Get(echo.HeaderXRequestID), "headers set around the cache aren't replayed")
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	require.NoError(t, services.InitResponseCache(config.ResponseCacheConfig{}))
+
+	e := echo.New()
+	e.GET("/states", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"state": "OH"})
+	}, ResponseCache(time.Hour))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/states", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(ResponseCacheHeader))
+}
+
+func TestNormalizedQuery(t *testing.T) {
+	query := url.Values{"zip": {"43215"}, "city": {"Columbus"}, "lang": {""}, "fields": {"a", " ", "b"}}
+	assert.Equal(t, "city=Columbus&fields=a&fields=b&zip=43215", normalizedQuery(query))
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"geocoding-api/handlers"
+	"geocoding-api/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxCallbackLength bounds a JSONP callback name
+const maxCallbackLength = 128
+
+// callbackPattern is what a JSONP callback may be: a JavaScript identifier or
+// a dotted path of them ("jQuery123.handle"), so it can't inject script
+var callbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// ResponseFormats lets a read-only endpoint answer in XML (?format=xml) or as
+// JSONP (?callback=fn) for legacy clients that can't consume JSON. The
+// response serializer writes the same response structs in that format.
+// Other format values are left for the handler, e.g. format=csv.
+func ResponseFormats() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next(c)
+			}
+
+			format := strings.ToLower(c.QueryParam("format"))
+			callback := c.QueryParam("callback")
+			switch {
+			case callback != "":
+				if len(callback) > maxCallbackLength || !callbackPattern.MatchString(callback) {
+					return c.JSON(http.StatusBadRequest, handlers.GeocodeResponse{
+						Success: false,
+						Error:   "Parameter 'callback' must be a JavaScript function name",
+						Code:    models.ErrCodeInvalidRequest,
+					})
+				}
+				if format == handlers.ResponseFormatXML {
+					return c.JSON(http.StatusBadRequest, handlers.GeocodeResponse{
+						Success: false,
+						Error:   "Parameter 'callback' can't be combined with format=xml",
+						Code:    models.ErrCodeInvalidRequest,
+					})
+				}
+				c.Set(handlers.ResponseFormatKey, handlers.ResponseFormatJSONP)
+				c.Set(handlers.JSONPCallbackKey, callback)
+			case format == handlers.ResponseFormatXML:
+				c.Set(handlers.ResponseFormatKey, handlers.ResponseFormatXML)
+			}
+			return next(c)
+		}
+	}
+}
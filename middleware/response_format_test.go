@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/handlers"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseFormatsMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		query        string
+		wantStatus   int
+		wantFormat   interface{}
+		wantCallback interface{}
+	}{
+		{name: "json by default", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "xml", method: http.MethodGet, query: "?format=XML", wantStatus: http.StatusOK, wantFormat: handlers.ResponseFormatXML},
+		{name: "csv is left to the handler", method: http.MethodGet, query: "?format=csv", wantStatus: http.StatusOK},
+		{name: "jsonp", method: http.MethodGet, query: "?callback=jQuery1.done", wantStatus: http.StatusOK,
+			wantFormat: handlers.ResponseFormatJSONP, wantCallback: "jQuery1.done"},
+		{name: "script in callback is rejected", method: http.MethodGet, query: "?callback=alert(1)", wantStatus: http.StatusBadRequest},
+		{name: "callback with xml is rejected", method: http.MethodGet, query: "?callback=cb&format=xml", wantStatus: http.StatusBadRequest},
+		{name: "posts are left alone", method: http.MethodPost, query: "?format=xml", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(tt.method, "/api/v1/addresses"+tt.query, nil), rec)
+
+			err := ResponseFormats()(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantFormat, c.Get(handlers.ResponseFormatKey))
+			assert.Equal(t, tt.wantCallback, c.Get(handlers.JSONPCallbackKey))
+		})
+	}
+}
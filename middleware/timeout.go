@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// RequestTimeout puts a deadline on the request context. Services run their
+// queries with that context, so a slow search is cancelled once the deadline
+// passes or the client disconnects instead of holding a pooled connection.
+// Requests that legitimately run long are left without a deadline.
+func RequestTimeout(timeout time.Duration) echo.MiddlewareFunc {
+	return echomiddleware.ContextTimeoutWithConfig(echomiddleware.ContextTimeoutConfig{
+		Skipper: isLongRunningRequest,
+		Timeout: timeout,
+	})
+}
+
+// csvExportRoutes are the routes whose ?format=csv streams every matching
+// row rather than one page, by unversioned path. Other routes either ignore
+// format or return a single page as CSV, so it doesn't lift their deadline.
+var csvExportRoutes = map[string]bool{
+	"/api/addresses":  true,
+	"/api/user/usage": true,
+}
+
+// isLongRunningRequest reports whether a request is a progress stream, a file
+// upload, a CSV export or an admin data operation such as a dataset load
+func isLongRunningRequest(c echo.Context) bool {
+	req := c.Request()
+	path := c.Path()
+	return strings.HasSuffix(path, "/stream") ||
+		strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) ||
+		(csvExportRoutes[unversionedPath(path)] && strings.EqualFold(c.QueryParam("format"), "csv")) ||
+		(req.Method == http.MethodPost && strings.HasPrefix(unversionedPath(path), "/api/admin/"))
+}
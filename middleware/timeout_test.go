@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLongRunningRequest(t *testing.T) {
+	e := echo.New()
+	tests := []struct {
+		method, route, target string
+		want                  bool
+	}{
+		{http.MethodGet, "/api/v1/addresses", "/api/v1/addresses?format=csv", true},
+		{http.MethodGet, "/api/v2/addresses", "/api/v2/addresses?format=CSV", true},
+		{http.MethodGet, "/api/v1/user/usage", "/api/v1/user/usage?format=csv", true},
+		{http.MethodGet, "/api/v1/addresses", "/api/v1/addresses", false},
+		// format=csv doesn't lift the deadline of routes that don't export
+		{http.MethodGet, "/api/v1/addresses/search", "/api/v1/addresses/search?format=csv", false},
+		{http.MethodGet, "/api/v1/nearby/:zipcode", "/api/v1/nearby/43215?format=csv", false},
+		{http.MethodGet, "/api/v1/geocode/jobs/:id/stream", "/api/v1/geocode/jobs/1/stream", true},
+		{http.MethodPost, "/api/v1/admin/load/:dataset", "/api/v1/admin/load/zip_codes", true},
+		{http.MethodGet, "/api/v1/admin/load", "/api/v1/admin/load", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			c := e.NewContext(httptest.NewRequest(tt.method, tt.target, nil), httptest.NewRecorder())
+			c.SetPath(tt.route)
+			assert.Equal(t, tt.want, isLongRunningRequest(c))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIVersion is one version of the REST API, served under /api/<Name>
+type APIVersion struct {
+	Name string
+	// Deprecation and Sunset announce the version's retirement in the
+	// Deprecation (RFC 9745) and Sunset (RFC 8594) headers; zero when not
+	// scheduled
+	Deprecation time.Time
+	Sunset      time.Time
+	// Successor is the version clients are pointed to once this one is
+	// deprecated
+	Successor string
+}
+
+// Prefix is the path the version's routes are mounted under
+func (v APIVersion) Prefix() string {
+	return "/api/" + v.Name
+}
+
+// retiring reports whether the version's deprecation or sunset is scheduled
+func (v APIVersion) retiring() bool {
+	return !v.Deprecation.IsZero() || !v.Sunset.IsZero()
+}
+
+// Version records the API version a request was routed to for handlers
+// building links. Once the version's retirement is scheduled its responses
+// carry Deprecation and Sunset headers and a successor-version Link to the
+// same path in the newer version.
+func Version(v APIVersion) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(handlers.APIVersionKey, v.Name)
+
+			header := c.Response().Header()
+			if !v.Deprecation.IsZero() {
+				header.Set("Deprecation", "@"+strconv.FormatInt(v.Deprecation.Unix(), 10))
+			}
+			if !v.Sunset.IsZero() {
+				header.Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if v.Successor != "" && v.retiring() {
+				successor := "/api/" + v.Successor + strings.TrimPrefix(c.Request().URL.Path, v.Prefix())
+				header.Add("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+			return next(c)
+		}
+	}
+}
+
+// versionPattern matches the version segment of an API path
+var versionPattern = regexp.MustCompile(`^/api/v[0-9]+(/|$)`)
+
+// unversionedPath drops the version from an API path, so "/api/v2/health"
+// becomes "/api/health", letting path checks apply to every version
+func unversionedPath(path string) string {
+	if loc := versionPattern.FindStringIndex(path); loc != nil {
+		return "/api/" + path[loc[1]:]
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"geocoding-api/handlers"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionHeaders(t *testing.T) {
+	deprecated := APIVersion{
+		Name:        "v1",
+		Deprecation: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC),
+		Successor:   "v2",
+	}
+
+	tests := []struct {
+		name            string
+		version         APIVersion
+		path            string
+		wantDeprecation string
+		wantSunset      string
+		wantLink        string
+	}{
+		{name: "current version", version: APIVersion{Name: "v2"}, path: "/api/v2/geocode/43215"},
+		{name: "unscheduled version has no headers", version: APIVersion{Name: "v1", Successor: "v2"}, path: "/api/v1/geocode/43215"},
+		{
+			name:            "deprecated version",
+			version:         deprecated,
+			path:            "/api/v1/geocode/43215",
+			wantDeprecation: "@1796083200",
+			wantSunset:      "Wed, 30 Jun 2027 00:00:00 GMT",
+			wantLink:        `</api/v2/geocode/43215>; rel="successor-version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, tt.path, nil), rec)
+
+			err := Version(tt.version)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.version.Name, c.Get(handlers.APIVersionKey))
+			assert.Equal(t, tt.wantDeprecation, rec.Header().Get("Deprecation"))
+			assert.Equal(t, tt.wantSunset, rec.Header().Get("Sunset"))
+			assert.Equal(t, tt.wantLink, rec.Header().Get("Link"))
+		})
+	}
+}
+
+func TestUnversionedPath(t *testing.T) {
+	assert.Equal(t, "/api/health", unversionedPath("/api/v1/health"))
+	assert.Equal(t, "/api/admin/load/zip_codes", unversionedPath("/api/v2/admin/load/zip_codes"))
+	assert.Equal(t, "/api/", unversionedPath("/api/v12"))
+	assert.Equal(t, "/api/versions", unversionedPath("/api/versions"))
+	assert.Equal(t, "/docs", unversionedPath("/docs"))
+}
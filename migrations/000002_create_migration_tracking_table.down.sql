@@ -0,0 +1 @@
+-- schema_migrations is owned by the migration runner and is never dropped
@@ -0,0 +1,7 @@
+-- The migration runner creates and upgrades schema_migrations itself before
+-- applying anything; this version is kept so existing databases line up
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    description TEXT NOT NULL
+);
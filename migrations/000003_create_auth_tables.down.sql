@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS update_subscriptions_updated_at ON subscriptions;
+DROP TRIGGER IF EXISTS update_api_keys_updated_at ON api_keys;
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS usage_records;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS users;
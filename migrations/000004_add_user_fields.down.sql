@@ -0,0 +1,3 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS name,
+DROP COLUMN IF EXISTS company;
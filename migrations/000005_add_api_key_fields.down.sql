@@ -0,0 +1,3 @@
+ALTER TABLE api_keys
+DROP COLUMN IF EXISTS key_preview,
+DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,3 @@
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS key_preview VARCHAR(50),
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
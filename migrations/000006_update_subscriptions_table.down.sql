@@ -0,0 +1,20 @@
+-- Remove indexes
+DROP INDEX IF EXISTS idx_subscriptions_stripe_subscription;
+DROP INDEX IF EXISTS idx_subscriptions_stripe_customer;
+DROP INDEX IF EXISTS idx_subscriptions_current_period;
+DROP INDEX IF EXISTS idx_subscriptions_status;
+
+-- Add back old columns
+ALTER TABLE subscriptions
+ADD COLUMN IF NOT EXISTS billing_period_start DATE,
+ADD COLUMN IF NOT EXISTS billing_period_end DATE,
+ADD COLUMN IF NOT EXISTS current_usage INTEGER DEFAULT 0;
+
+-- Remove new columns
+ALTER TABLE subscriptions
+DROP COLUMN IF EXISTS stripe_subscription_id,
+DROP COLUMN IF EXISTS stripe_customer_id,
+DROP COLUMN IF EXISTS price_per_call,
+DROP COLUMN IF EXISTS current_period_end,
+DROP COLUMN IF EXISTS current_period_start,
+DROP COLUMN IF EXISTS status;
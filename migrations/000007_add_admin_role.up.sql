@@ -0,0 +1,11 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE;
+
+-- Create index for admin queries
+CREATE INDEX IF NOT EXISTS idx_users_is_admin ON users(is_admin);
+
+-- Set first user as admin if no admins exist
+UPDATE users
+SET is_admin = TRUE
+WHERE id = (SELECT MIN(id) FROM users)
+AND NOT EXISTS (SELECT 1 FROM users WHERE is_admin = TRUE);
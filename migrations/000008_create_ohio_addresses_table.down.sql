@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ohio_addresses;
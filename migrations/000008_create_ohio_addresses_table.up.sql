@@ -0,0 +1,29 @@
+-- Enable PostGIS for the geometry column
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+-- Create ohio_addresses table with PostGIS geometry
+CREATE TABLE IF NOT EXISTS ohio_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    hash VARCHAR(255) UNIQUE NOT NULL,
+    house_number VARCHAR(50),
+    street VARCHAR(255),
+    unit VARCHAR(50),
+    city VARCHAR(255),
+    district VARCHAR(10), -- County abbreviation
+    region VARCHAR(2), -- State code
+    postcode VARCHAR(10),
+    county VARCHAR(255), -- Full county name from filename
+    geom GEOMETRY(POINT, 4326) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create spatial index for better query performance
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_geom ON ohio_addresses USING GIST (geom);
+
+-- Create indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_hash ON ohio_addresses(hash);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_county ON ohio_addresses(county);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_district ON ohio_addresses(district);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_city ON ohio_addresses(city);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_postcode ON ohio_addresses(postcode);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_street ON ohio_addresses(street);
@@ -0,0 +1,19 @@
+-- Create ohio_counties table with PostGIS geometry
+CREATE TABLE IF NOT EXISTS ohio_counties (
+    id SERIAL PRIMARY KEY,
+    county_name VARCHAR(255) UNIQUE NOT NULL,
+    source_name VARCHAR(255) NOT NULL,
+    layer VARCHAR(100) NOT NULL,
+    address_count INTEGER DEFAULT 0,
+    stats JSONB,
+    bounds_geometry GEOMETRY(POLYGON, 4326) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create spatial index for better query performance
+CREATE INDEX IF NOT EXISTS idx_ohio_counties_bounds ON ohio_counties USING GIST (bounds_geometry);
+
+-- Create indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_ohio_counties_name ON ohio_counties(county_name);
+CREATE INDEX IF NOT EXISTS idx_ohio_counties_address_count ON ohio_counties(address_count);
@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions
+ADD CONSTRAINT subscriptions_user_id_unique UNIQUE (user_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_ohio_addresses_street_trgm;
+DROP INDEX IF EXISTS idx_ohio_addresses_city_trgm;
+DROP INDEX IF EXISTS idx_ohio_addresses_house_number_trgm;
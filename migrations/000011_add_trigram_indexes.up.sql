@@ -0,0 +1,6 @@
+-- Trigram indexes speed up ILIKE searches on address components
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_street_trgm ON ohio_addresses USING gin (street gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_city_trgm ON ohio_addresses USING gin (city gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_house_number_trgm ON ohio_addresses USING gin (house_number gin_trgm_ops);
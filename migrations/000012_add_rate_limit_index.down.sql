@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_usage_records_rate_limit;
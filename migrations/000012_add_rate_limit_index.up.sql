@@ -0,0 +1,3 @@
+-- Composite index for the rate limit query: user_id + billable + created_at
+CREATE INDEX IF NOT EXISTS idx_usage_records_rate_limit
+    ON usage_records(user_id, billable, created_at DESC);
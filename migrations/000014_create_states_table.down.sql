@@ -0,0 +1 @@
+DROP TABLE IF EXISTS us_states;
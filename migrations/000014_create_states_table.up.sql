@@ -0,0 +1,31 @@
+-- Enable PostGIS for the geometry column
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+-- Create states table for US state boundary data
+CREATE TABLE IF NOT EXISTS us_states (
+    id BIGSERIAL PRIMARY KEY,
+    state_fips VARCHAR(2) NOT NULL UNIQUE,
+    state_abbr VARCHAR(2) NOT NULL UNIQUE,
+    state_name VARCHAR(255) NOT NULL UNIQUE,
+    state_ns VARCHAR(50),
+    geoid VARCHAR(10),
+    region VARCHAR(10),
+    division VARCHAR(10),
+    lsad VARCHAR(10),
+    mtfcc VARCHAR(10),
+    funcstat VARCHAR(10),
+    area_land BIGINT,
+    area_water BIGINT,
+    internal_lat DECIMAL(10, 7),
+    internal_lng DECIMAL(11, 7),
+    geometry GEOMETRY(MULTIPOLYGON, 4326),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for efficient lookups
+CREATE INDEX idx_states_fips ON us_states (state_fips);
+CREATE INDEX idx_states_abbr ON us_states (state_abbr);
+CREATE INDEX idx_states_name ON us_states (state_name);
+
+-- Create spatial index for geometry queries
+CREATE INDEX idx_states_geometry ON us_states USING GIST (geometry);
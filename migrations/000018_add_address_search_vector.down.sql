@@ -0,0 +1,5 @@
+-- Rollback Migration 18: Remove tsvector search column
+DROP INDEX IF EXISTS idx_ohio_addresses_search_vector;
+DROP TRIGGER IF EXISTS trg_ohio_addresses_search_vector ON ohio_addresses;
+DROP FUNCTION IF EXISTS ohio_addresses_search_vector_update();
+ALTER TABLE ohio_addresses DROP COLUMN IF EXISTS search_vector;
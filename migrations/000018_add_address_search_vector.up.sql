@@ -0,0 +1,33 @@
+-- Migration 18: Add tsvector search column to ohio_addresses maintained by trigger
+ALTER TABLE ohio_addresses ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION ohio_addresses_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.house_number, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.street, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.city, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.postcode, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(NEW.county, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(NEW.full_address, '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ohio_addresses_search_vector ON ohio_addresses;
+CREATE TRIGGER trg_ohio_addresses_search_vector
+    BEFORE INSERT OR UPDATE OF house_number, street, city, postcode, county, full_address
+    ON ohio_addresses
+    FOR EACH ROW EXECUTE FUNCTION ohio_addresses_search_vector_update();
+
+-- Backfill existing rows
+UPDATE ohio_addresses SET search_vector =
+    setweight(to_tsvector('simple', coalesce(house_number, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(street, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(city, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(postcode, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(county, '')), 'C') ||
+    setweight(to_tsvector('simple', coalesce(full_address, '')), 'D')
+WHERE search_vector IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_search_vector ON ohio_addresses USING GIN (search_vector);
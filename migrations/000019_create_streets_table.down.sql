@@ -0,0 +1,6 @@
+-- Rollback Migration 19: Drop streets table
+DROP INDEX IF EXISTS idx_streets_street_trgm;
+DROP INDEX IF EXISTS idx_streets_postcode;
+DROP INDEX IF EXISTS idx_streets_county;
+DROP INDEX IF EXISTS idx_streets_geom;
+DROP TABLE IF EXISTS streets;
@@ -0,0 +1,37 @@
+-- Migration 19: Create streets table aggregated from ohio_addresses
+CREATE TABLE IF NOT EXISTS streets (
+    id BIGSERIAL PRIMARY KEY,
+    street VARCHAR(255) NOT NULL,
+    city VARCHAR(255) NOT NULL DEFAULT '',
+    postcode VARCHAR(10) NOT NULL DEFAULT '',
+    county VARCHAR(255),
+    region VARCHAR(2),
+    geom GEOMETRY(POINT, 4326) NOT NULL,
+    address_count INTEGER NOT NULL DEFAULT 0,
+    min_house_number INTEGER,
+    max_house_number INTEGER,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (street, city, postcode)
+);
+
+CREATE INDEX IF NOT EXISTS idx_streets_geom ON streets USING GIST (geom);
+CREATE INDEX IF NOT EXISTS idx_streets_county ON streets(county);
+CREATE INDEX IF NOT EXISTS idx_streets_postcode ON streets(postcode);
+CREATE INDEX IF NOT EXISTS idx_streets_street_trgm ON streets USING gin (street gin_trgm_ops);
+
+-- Populate from addresses already loaded
+INSERT INTO streets (street, city, postcode, county, region, geom, address_count, min_house_number, max_house_number)
+SELECT
+    street,
+    COALESCE(city, ''),
+    COALESCE(postcode, ''),
+    MAX(county),
+    MAX(region),
+    ST_Centroid(ST_Collect(geom)),
+    COUNT(*),
+    MIN(substring(house_number from '^[0-9]+')::int),
+    MAX(substring(house_number from '^[0-9]+')::int)
+FROM ohio_addresses
+WHERE street IS NOT NULL AND street <> ''
+GROUP BY street, COALESCE(city, ''), COALESCE(postcode, '')
+ON CONFLICT (street, city, postcode) DO NOTHING;
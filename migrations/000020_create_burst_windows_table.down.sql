@@ -0,0 +1,4 @@
+-- Rollback Migration 20: Drop burst_windows table
+DROP INDEX IF EXISTS idx_burst_windows_status;
+DROP INDEX IF EXISTS idx_burst_windows_user_status;
+DROP TABLE IF EXISTS burst_windows;
@@ -0,0 +1,18 @@
+-- Migration 20: Create burst_windows table for pre-approved rate limit exemptions
+CREATE TABLE IF NOT EXISTS burst_windows (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    requested_qps INTEGER NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at),
+    CHECK (requested_qps > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_burst_windows_user_status ON burst_windows(user_id, status, starts_at, ends_at);
+CREATE INDEX IF NOT EXISTS idx_burst_windows_status ON burst_windows(status);
@@ -0,0 +1,4 @@
+-- Rollback Migration 21: Drop feature_flags table
+DROP INDEX IF EXISTS idx_usage_records_feature_flags;
+ALTER TABLE usage_records DROP COLUMN IF EXISTS feature_flags;
+DROP TABLE IF EXISTS feature_flags;
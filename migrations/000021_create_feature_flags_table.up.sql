@@ -0,0 +1,13 @@
+-- Migration 21: Create feature_flags table and record flag evaluations on usage
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key VARCHAR(100) PRIMARY KEY,
+    description TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    rollout_percent INTEGER NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+    api_key_ids INTEGER[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS feature_flags JSONB;
+CREATE INDEX IF NOT EXISTS idx_usage_records_feature_flags ON usage_records USING GIN (feature_flags);
@@ -0,0 +1,6 @@
+-- Rollback Migration 22: Remove county seat, centroid and area columns
+DROP INDEX IF EXISTS idx_ohio_counties_centroid;
+ALTER TABLE ohio_counties DROP COLUMN IF EXISTS water_area_sqm;
+ALTER TABLE ohio_counties DROP COLUMN IF EXISTS land_area_sqm;
+ALTER TABLE ohio_counties DROP COLUMN IF EXISTS centroid;
+ALTER TABLE ohio_counties DROP COLUMN IF EXISTS county_seat;
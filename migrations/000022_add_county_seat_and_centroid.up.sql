@@ -0,0 +1,108 @@
+-- Migration 22: Add county seat, centroid and area columns to ohio_counties
+ALTER TABLE ohio_counties ADD COLUMN IF NOT EXISTS county_seat VARCHAR(255);
+ALTER TABLE ohio_counties ADD COLUMN IF NOT EXISTS centroid GEOMETRY(POINT, 4326);
+ALTER TABLE ohio_counties ADD COLUMN IF NOT EXISTS land_area_sqm DOUBLE PRECISION;
+ALTER TABLE ohio_counties ADD COLUMN IF NOT EXISTS water_area_sqm DOUBLE PRECISION;
+
+-- Centroid and area computed from the stored boundary polygon. Water area is
+-- left NULL until a source with land/water split (e.g. Census TIGER) is loaded,
+-- so land_area_sqm is the total polygon area until then.
+UPDATE ohio_counties SET
+    centroid = ST_Centroid(bounds_geometry),
+    land_area_sqm = ST_Area(bounds_geometry::geography)
+WHERE bounds_geometry IS NOT NULL;
+
+UPDATE ohio_counties oc SET county_seat = seats.seat
+FROM (VALUES
+    ('Adams', 'West Union'),
+    ('Allen', 'Lima'),
+    ('Ashland', 'Ashland'),
+    ('Ashtabula', 'Jefferson'),
+    ('Athens', 'Athens'),
+    ('Auglaize', 'Wapakoneta'),
+    ('Belmont', 'St. Clairsville'),
+    ('Brown', 'Georgetown'),
+    ('Butler', 'Hamilton'),
+    ('Carroll', 'Carrollton'),
+    ('Champaign', 'Urbana'),
+    ('Clark', 'Springfield'),
+    ('Clermont', 'Batavia'),
+    ('Clinton', 'Wilmington'),
+    ('Columbiana', 'Lisbon'),
+    ('Coshocton', 'Coshocton'),
+    ('Crawford', 'Bucyrus'),
+    ('Cuyahoga', 'Cleveland'),
+    ('Darke', 'Greenville'),
+    ('Defiance', 'Defiance'),
+    ('Delaware', 'Delaware'),
+    ('Erie', 'Sandusky'),
+    ('Fairfield', 'Lancaster'),
+    ('Fayette', 'Washington Court House'),
+    ('Franklin', 'Columbus'),
+    ('Fulton', 'Wauseon'),
+    ('Gallia', 'Gallipolis'),
+    ('Geauga', 'Chardon'),
+    ('Greene', 'Xenia'),
+    ('Guernsey', 'Cambridge'),
+    ('Hamilton', 'Cincinnati'),
+    ('Hancock', 'Findlay'),
+    ('Hardin', 'Kenton'),
+    ('Harrison', 'Cadiz'),
+    ('Henry', 'Napoleon'),
+    ('Highland', 'Hillsboro'),
+    ('Hocking', 'Logan'),
+    ('Holmes', 'Millersburg'),
+    ('Huron', 'Norwalk'),
+    ('Jackson', 'Jackson'),
+    ('Jefferson', 'Steubenville'),
+    ('Knox', 'Mount Vernon'),
+    ('Lake', 'Painesville'),
+    ('Lawrence', 'Ironton'),
+    ('Licking', 'Newark'),
+    ('Logan', 'Bellefontaine'),
+    ('Lorain', 'Elyria'),
+    ('Lucas', 'Toledo'),
+    ('Madison', 'London'),
+    ('Mahoning', 'Youngstown'),
+    ('Marion', 'Marion'),
+    ('Medina', 'Medina'),
+    ('Meigs', 'Pomeroy'),
+    ('Mercer', 'Celina'),
+    ('Miami', 'Troy'),
+    ('Monroe', 'Woodsfield'),
+    ('Montgomery', 'Dayton'),
+    ('Morgan', 'McConnelsville'),
+    ('Morrow', 'Mount Gilead'),
+    ('Muskingum', 'Zanesville'),
+    ('Noble', 'Caldwell'),
+    ('Ottawa', 'Port Clinton'),
+    ('Paulding', 'Paulding'),
+    ('Perry', 'New Lexington'),
+    ('Pickaway', 'Circleville'),
+    ('Pike', 'Waverly'),
+    ('Portage', 'Ravenna'),
+    ('Preble', 'Eaton'),
+    ('Putnam', 'Ottawa'),
+    ('Richland', 'Mansfield'),
+    ('Ross', 'Chillicothe'),
+    ('Sandusky', 'Fremont'),
+    ('Scioto', 'Portsmouth'),
+    ('Seneca', 'Tiffin'),
+    ('Shelby', 'Sidney'),
+    ('Stark', 'Canton'),
+    ('Summit', 'Akron'),
+    ('Trumbull', 'Warren'),
+    ('Tuscarawas', 'New Philadelphia'),
+    ('Union', 'Marysville'),
+    ('Van Wert', 'Van Wert'),
+    ('Vinton', 'McArthur'),
+    ('Warren', 'Lebanon'),
+    ('Washington', 'Marietta'),
+    ('Wayne', 'Wooster'),
+    ('Williams', 'Bryan'),
+    ('Wood', 'Bowling Green'),
+    ('Wyandot', 'Upper Sandusky')
+) AS seats(county, seat)
+WHERE LOWER(oc.county_name) = LOWER(seats.county);
+
+CREATE INDEX IF NOT EXISTS idx_ohio_counties_centroid ON ohio_counties USING GIST (centroid);
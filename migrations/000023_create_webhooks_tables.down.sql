@@ -0,0 +1,6 @@
+-- Rollback Migration 23: Drop webhooks tables
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+DROP INDEX IF EXISTS idx_webhook_deliveries_pending;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhooks_user_active;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,5 @@
+-- Rollback Migration 24: Drop integrity check and data-quality tables
+DROP INDEX IF EXISTS idx_data_quality_issues_run;
+DROP TABLE IF EXISTS data_quality_issues;
+DROP INDEX IF EXISTS idx_integrity_check_runs_started;
+DROP TABLE IF EXISTS integrity_check_runs;
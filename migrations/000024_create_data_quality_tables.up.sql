@@ -0,0 +1,31 @@
+-- Migration 24: Create integrity check runs and data-quality issues tables
+CREATE TABLE IF NOT EXISTS integrity_check_runs (
+    id SERIAL PRIMARY KEY,
+    triggered_by VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    counties_checked INTEGER NOT NULL DEFAULT 0,
+    untracked_counties INTEGER NOT NULL DEFAULT 0,
+    discrepancies INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_check_runs_started ON integrity_check_runs(started_at DESC);
+
+-- One row per discrepancy found by a run; the latest run's rows form the
+-- current data-quality report
+CREATE TABLE IF NOT EXISTS data_quality_issues (
+    id SERIAL PRIMARY KEY,
+    run_id INTEGER NOT NULL REFERENCES integrity_check_runs(id) ON DELETE CASCADE,
+    check_type VARCHAR(50) NOT NULL,
+    state VARCHAR(10) NOT NULL,
+    county VARCHAR(255) NOT NULL,
+    expected_count BIGINT NOT NULL,
+    actual_count BIGINT NOT NULL,
+    difference BIGINT NOT NULL,
+    dataset_ids INTEGER[] NOT NULL DEFAULT '{}',
+    detected_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_quality_issues_run ON data_quality_issues(run_id);
@@ -0,0 +1,2 @@
+-- Rollback Migration 25: Remove dataset field mapping
+ALTER TABLE datasets DROP COLUMN IF EXISTS field_mapping;
@@ -0,0 +1,2 @@
+-- Migration 25: Store per-dataset property name mapping used by the importer
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS field_mapping JSONB;
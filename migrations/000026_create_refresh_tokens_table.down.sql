@@ -0,0 +1,4 @@
+-- Rollback Migration 26: Drop refresh_tokens table
+DROP INDEX IF EXISTS idx_refresh_tokens_user;
+DROP INDEX IF EXISTS idx_refresh_tokens_session;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,3 @@
+-- Rollback Migration 27: Drop password_reset_tokens table
+DROP INDEX IF EXISTS idx_password_reset_tokens_user;
+DROP TABLE IF EXISTS password_reset_tokens;
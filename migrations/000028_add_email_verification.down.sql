@@ -0,0 +1,4 @@
+-- Rollback Migration 28: Drop email verification tokens and column
+DROP INDEX IF EXISTS idx_email_verification_tokens_user;
+DROP TABLE IF EXISTS email_verification_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,4 @@
+-- Rollback Migration 29: Drop per-API-key caps
+DROP INDEX IF EXISTS idx_usage_records_api_key_created;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS daily_limit;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS monthly_limit;
@@ -0,0 +1,8 @@
+-- Migration 29: Optional per-API-key monthly/daily caps below the plan limit
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS monthly_limit INTEGER;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_limit INTEGER;
+
+-- Per-key usage counts for capped keys
+CREATE INDEX IF NOT EXISTS idx_usage_records_api_key_created
+    ON usage_records(api_key_id, created_at)
+    WHERE billable = true;
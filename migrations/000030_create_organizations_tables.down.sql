@@ -0,0 +1,10 @@
+-- Rollback Migration 30: Drop organizations
+DROP INDEX IF EXISTS idx_usage_records_organization_created;
+DROP INDEX IF EXISTS idx_api_keys_organization;
+ALTER TABLE usage_records DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+DROP INDEX IF EXISTS idx_organization_invitations_org;
+DROP TABLE IF EXISTS organization_invitations;
+DROP INDEX IF EXISTS idx_organization_members_user;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,6 @@
+-- Rollback Migration 31: Drop audit log
+DROP INDEX IF EXISTS idx_audit_log_target;
+DROP INDEX IF EXISTS idx_audit_log_action;
+DROP INDEX IF EXISTS idx_audit_log_actor;
+DROP INDEX IF EXISTS idx_audit_log_created;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,3 @@
+-- Rollback Migration 32: Drop hourly usage rollup
+DROP INDEX IF EXISTS idx_usage_hourly_bucket;
+DROP TABLE IF EXISTS usage_hourly;
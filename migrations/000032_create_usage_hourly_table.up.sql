@@ -0,0 +1,48 @@
+-- Migration 32: Hourly usage rollup for time-series analytics
+-- Rows are upserted as usage is recorded. latency_buckets is a histogram of
+-- response times using the bounds in services/usage_timeseries.go
+-- (<=5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000 ms, then overflow).
+CREATE TABLE IF NOT EXISTS usage_hourly (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    total_calls INTEGER NOT NULL DEFAULT 0,
+    billable_calls INTEGER NOT NULL DEFAULT 0,
+    error_calls INTEGER NOT NULL DEFAULT 0,
+    total_response_ms BIGINT NOT NULL DEFAULT 0,
+    max_response_ms INTEGER NOT NULL DEFAULT 0,
+    latency_buckets INTEGER[] NOT NULL DEFAULT '{0,0,0,0,0,0,0,0,0,0,0,0}',
+    PRIMARY KEY (user_id, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_hourly_bucket ON usage_hourly(bucket);
+
+-- Backfill from existing usage. Hours already written by live traffic while
+-- this runs are left alone.
+INSERT INTO usage_hourly (user_id, bucket, total_calls, billable_calls, error_calls,
+    total_response_ms, max_response_ms, latency_buckets)
+SELECT
+    user_id,
+    date_trunc('hour', created_at),
+    COUNT(*),
+    COUNT(*) FILTER (WHERE billable),
+    COUNT(*) FILTER (WHERE status_code >= 400),
+    COALESCE(SUM(response_time_ms), 0),
+    COALESCE(MAX(response_time_ms), 0),
+    ARRAY[
+        COUNT(*) FILTER (WHERE response_time_ms <= 5),
+        COUNT(*) FILTER (WHERE response_time_ms > 5 AND response_time_ms <= 10),
+        COUNT(*) FILTER (WHERE response_time_ms > 10 AND response_time_ms <= 25),
+        COUNT(*) FILTER (WHERE response_time_ms > 25 AND response_time_ms <= 50),
+        COUNT(*) FILTER (WHERE response_time_ms > 50 AND response_time_ms <= 100),
+        COUNT(*) FILTER (WHERE response_time_ms > 100 AND response_time_ms <= 250),
+        COUNT(*) FILTER (WHERE response_time_ms > 250 AND response_time_ms <= 500),
+        COUNT(*) FILTER (WHERE response_time_ms > 500 AND response_time_ms <= 1000),
+        COUNT(*) FILTER (WHERE response_time_ms > 1000 AND response_time_ms <= 2500),
+        COUNT(*) FILTER (WHERE response_time_ms > 2500 AND response_time_ms <= 5000),
+        COUNT(*) FILTER (WHERE response_time_ms > 5000 AND response_time_ms <= 10000),
+        COUNT(*) FILTER (WHERE response_time_ms > 10000)
+    ]::INTEGER[]
+FROM usage_records
+WHERE user_id IS NOT NULL
+GROUP BY user_id, date_trunc('hour', created_at)
+ON CONFLICT (user_id, bucket) DO NOTHING;
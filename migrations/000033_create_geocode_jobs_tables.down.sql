@@ -0,0 +1,6 @@
+-- Rollback Migration 33: Drop async bulk geocoding jobs
+DROP INDEX IF EXISTS idx_geocode_job_items_pending;
+DROP TABLE IF EXISTS geocode_job_items;
+DROP INDEX IF EXISTS idx_geocode_jobs_queued;
+DROP INDEX IF EXISTS idx_geocode_jobs_user;
+DROP TABLE IF EXISTS geocode_jobs;
@@ -0,0 +1,39 @@
+-- Migration 33: Async bulk geocoding jobs
+-- Each uploaded address becomes a geocode_job_items row. Workers fill in the
+-- match as they go, so a job interrupted by a restart resumes where it left off.
+CREATE TABLE IF NOT EXISTS geocode_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL,
+    filename VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'completed', 'failed', 'cancelled')),
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    matched_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_geocode_jobs_user ON geocode_jobs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_geocode_jobs_queued ON geocode_jobs(created_at) WHERE status = 'queued';
+
+CREATE TABLE IF NOT EXISTS geocode_job_items (
+    job_id INTEGER NOT NULL REFERENCES geocode_jobs(id) ON DELETE CASCADE,
+    row_number INTEGER NOT NULL,
+    query TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'matched', 'not_found', 'error')),
+    match_level VARCHAR(20),
+    address_id BIGINT,
+    full_address TEXT,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    error TEXT,
+    PRIMARY KEY (job_id, row_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_geocode_job_items_pending ON geocode_job_items(job_id, row_number) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+-- Rollback Migration 34: Drop user soft-delete column
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
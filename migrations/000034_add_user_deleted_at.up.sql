@@ -0,0 +1,4 @@
+-- Migration 34: Soft-delete users
+-- Deleted accounts keep their row so usage history and audit references stay
+-- intact, but their personal details are scrubbed and they can't sign in.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
@@ -0,0 +1,11 @@
+-- Rollback Migration 35: Drop database-stored plans
+UPDATE subscriptions s SET monthly_limit = COALESCE(s.monthly_limit, NULLIF(p.monthly_limit, -1), 1000000),
+    price_per_call = COALESCE(s.price_per_call, p.price_per_call)
+FROM plans p WHERE p.id = s.plan_type;
+UPDATE subscriptions SET monthly_limit = 3000 WHERE monthly_limit IS NULL;
+ALTER TABLE subscriptions ALTER COLUMN price_per_call SET DEFAULT 0.0;
+ALTER TABLE subscriptions ALTER COLUMN monthly_limit SET NOT NULL;
+
+ALTER TABLE organizations DROP CONSTRAINT IF EXISTS organizations_plan_type_fkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_plan_type_fkey;
+DROP TABLE IF EXISTS plans;
@@ -0,0 +1,56 @@
+-- Migration 35: Pricing plans stored in the database
+-- Replaces the limits hard-coded in rate limiting SQL, models.PlanLimits and
+-- the /auth/plans handler. -1 means unlimited; prices are in dollars.
+CREATE TABLE IF NOT EXISTS plans (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    monthly_limit INTEGER NOT NULL CHECK (monthly_limit >= -1),
+    daily_limit INTEGER NOT NULL CHECK (daily_limit >= -1),
+    price_per_call DECIMAL(10,6) NOT NULL DEFAULT 0,
+    price_monthly DECIMAL(10,2) NOT NULL DEFAULT 0,
+    features TEXT[] NOT NULL DEFAULT '{}',
+    is_public BOOLEAN NOT NULL DEFAULT true,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The limits rate limiting enforced before this migration
+INSERT INTO plans (id, name, monthly_limit, daily_limit, price_per_call, price_monthly, features, sort_order) VALUES
+    ('free', 'Free', 3000, 500, 0, 0,
+        ARRAY['Basic geocoding', 'City search', 'Community support'], 10),
+    ('starter', 'Starter', 30000, 5000, 0.001, 10,
+        ARRAY['All Free features', 'Distance calculations', 'Email support'], 20),
+    ('pro', 'Pro', 500000, 100000, 0.0008, 80,
+        ARRAY['All Starter features', 'Bulk operations', 'Priority support', 'SLA'], 30),
+    ('enterprise', 'Enterprise', -1, -1, 0.0005, 500,
+        ARRAY['Unlimited usage', 'All Pro features', 'Custom integrations', 'Dedicated support', '99.9% SLA'], 40)
+ON CONFLICT (id) DO NOTHING;
+
+-- Any other plan already assigned (e.g. 'basic') keeps the free limits it
+-- fell back to, as a hidden plan
+INSERT INTO plans (id, name, monthly_limit, daily_limit, is_public, sort_order)
+SELECT DISTINCT plan_type, initcap(plan_type), 3000, 500, false, 100
+FROM (
+    SELECT plan_type FROM users
+    UNION SELECT plan_type FROM organizations
+    UNION SELECT plan_type FROM subscriptions
+) assigned
+WHERE plan_type IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_plan_type_check;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_plan_type_fkey;
+ALTER TABLE users ADD CONSTRAINT users_plan_type_fkey
+    FOREIGN KEY (plan_type) REFERENCES plans(id) ON UPDATE CASCADE;
+ALTER TABLE organizations DROP CONSTRAINT IF EXISTS organizations_plan_type_fkey;
+ALTER TABLE organizations ADD CONSTRAINT organizations_plan_type_fkey
+    FOREIGN KEY (plan_type) REFERENCES plans(id) ON UPDATE CASCADE;
+
+-- A subscription's monthly limit and price now override its plan's only
+-- when set. Values copied from the old hard-coded defaults are cleared so
+-- the plan applies.
+ALTER TABLE subscriptions ALTER COLUMN monthly_limit DROP NOT NULL;
+ALTER TABLE subscriptions ALTER COLUMN price_per_call DROP DEFAULT;
+UPDATE subscriptions SET monthly_limit = NULL, price_per_call = NULL
+WHERE (plan_type, monthly_limit) IN (('free', 100000), ('starter', 10000), ('pro', 100000), ('enterprise', 1000000));
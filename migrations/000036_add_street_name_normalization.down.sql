@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ohio_addresses_street_normalized_trgm;
+DROP FUNCTION IF EXISTS normalize_street_name(TEXT);
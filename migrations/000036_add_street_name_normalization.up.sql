@@ -0,0 +1,80 @@
+-- Normalized street names for fuzzy address matching. normalize_street_name
+-- mirrors utils.NormalizeStreetName: lowercase, drop periods and apostrophes,
+-- split on anything else that isn't a letter or digit, and replace street
+-- types, directionals and ordinal words with one canonical form, so
+-- "North First Street" and "N 1ST ST." both become "n 1st st". Change the
+-- two together.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS fuzzystrmatch;
+
+CREATE OR REPLACE FUNCTION normalize_street_name(street TEXT) RETURNS TEXT AS $$
+    SELECT COALESCE(string_agg(COALESCE(m.canonical, w.word), ' ' ORDER BY w.pos), '')
+    FROM regexp_split_to_table(
+        btrim(regexp_replace(regexp_replace(lower(street), '[.'']', '', 'g'), '[^a-z0-9]+', ' ', 'g')),
+        ' '
+    ) WITH ORDINALITY AS w(word, pos)
+    LEFT JOIN (VALUES
+        ('alley', 'aly'),
+        ('annex', 'anx'),
+        ('av', 'ave'), ('avenue', 'ave'),
+        ('boulevard', 'blvd'), ('bvd', 'blvd'),
+        ('circle', 'cir'),
+        ('court', 'ct'),
+        ('drive', 'dr'),
+        ('expressway', 'expy'),
+        ('extension', 'ext'),
+        ('freeway', 'fwy'),
+        ('grove', 'grv'),
+        ('heights', 'hts'),
+        ('highway', 'hwy'),
+        ('junction', 'jct'),
+        ('lane', 'ln'),
+        ('landing', 'lndg'),
+        ('loop', 'lp'),
+        ('pike', 'pk'),
+        ('parkway', 'pkwy'),
+        ('place', 'pl'),
+        ('point', 'pt'),
+        ('road', 'rd'),
+        ('square', 'sq'),
+        ('street', 'st'),
+        ('terrace', 'ter'),
+        ('trace', 'trce'),
+        ('tr', 'trl'), ('trail', 'trl'),
+        ('view', 'vw'),
+        ('way', 'wy'),
+        ('east', 'e'),
+        ('north', 'n'),
+        ('northeast', 'ne'),
+        ('northwest', 'nw'),
+        ('south', 's'),
+        ('southeast', 'se'),
+        ('southwest', 'sw'),
+        ('west', 'w'),
+        ('first', '1st'),
+        ('second', '2nd'),
+        ('third', '3rd'),
+        ('fourth', '4th'),
+        ('fifth', '5th'),
+        ('sixth', '6th'),
+        ('seventh', '7th'),
+        ('eighth', '8th'),
+        ('ninth', '9th'),
+        ('tenth', '10th'),
+        ('eleventh', '11th'),
+        ('twelfth', '12th'),
+        ('thirteenth', '13th'),
+        ('fourteenth', '14th'),
+        ('fifteenth', '15th'),
+        ('sixteenth', '16th'),
+        ('seventeenth', '17th'),
+        ('eighteenth', '18th'),
+        ('nineteenth', '19th'),
+        ('twentieth', '20th')
+    ) AS m(word, canonical) ON m.word = w.word
+    WHERE w.word <> ''
+$$ LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE;
+
+-- Serves both normalized equality and trigram similarity (%) lookups
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_street_normalized_trgm
+    ON ohio_addresses USING gin (normalize_street_name(street) gin_trgm_ops);
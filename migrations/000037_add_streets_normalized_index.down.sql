@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_streets_street_normalized_trgm;
@@ -0,0 +1,3 @@
+-- Address validation matches the street index by normalized name
+CREATE INDEX IF NOT EXISTS idx_streets_street_normalized_trgm
+    ON streets USING gin (normalize_street_name(street) gin_trgm_ops);
@@ -0,0 +1,3 @@
+-- Radius searches and nearest-city lookups measure distance on the geography
+CREATE INDEX IF NOT EXISTS idx_cities_geography
+    ON cities USING gist ((ST_SetSRID(ST_MakePoint(lng::float8, lat::float8), 4326)::geography));
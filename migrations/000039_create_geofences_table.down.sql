@@ -0,0 +1,4 @@
+-- Rollback Migration 39: Drop user-defined geofences
+DROP INDEX IF EXISTS idx_geofences_geom;
+DROP INDEX IF EXISTS idx_geofences_user;
+DROP TABLE IF EXISTS geofences;
@@ -0,0 +1,15 @@
+-- Migration 39: User-defined geofences
+-- Polygons are stored as MultiPolygons so both GeoJSON geometry types fit
+CREATE TABLE IF NOT EXISTS geofences (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    geom GEOMETRY(MULTIPOLYGON, 4326) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_geofences_user ON geofences(user_id);
+CREATE INDEX IF NOT EXISTS idx_geofences_geom ON geofences USING GIST (geom);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_ohio_addresses_h3_index ON ohio_addresses;
+DROP FUNCTION IF EXISTS set_address_h3_index();
+DROP INDEX IF EXISTS idx_ohio_addresses_h3_index;
+ALTER TABLE ohio_addresses DROP COLUMN IF EXISTS h3_index;
@@ -0,0 +1,34 @@
+-- H3 cell of each address point at resolution 9 (about 0.1 km²), so
+-- addresses can be bucketed by cell with an index instead of computing cells
+-- per row; coarser cells come from h3_cell_to_parent. The h3 and h3_postgis
+-- extensions (h3-pg) are optional: where they aren't installed the column
+-- stays NULL and /encode reports H3 as unavailable.
+ALTER TABLE ohio_addresses ADD COLUMN IF NOT EXISTS h3_index BIGINT;
+
+DO $$
+BEGIN
+    IF (SELECT COUNT(*) FROM pg_available_extensions WHERE name IN ('h3', 'h3_postgis')) < 2 THEN
+        RAISE NOTICE 'h3 extensions not available; ohio_addresses.h3_index left empty';
+        RETURN;
+    END IF;
+
+    CREATE EXTENSION IF NOT EXISTS h3;
+    CREATE EXTENSION IF NOT EXISTS h3_postgis CASCADE;
+
+    CREATE OR REPLACE FUNCTION set_address_h3_index() RETURNS TRIGGER AS $fn$
+    BEGIN
+        NEW.h3_index := h3_lat_lng_to_cell(NEW.geom, 9)::bigint;
+        RETURN NEW;
+    END;
+    $fn$ LANGUAGE plpgsql;
+
+    DROP TRIGGER IF EXISTS trg_ohio_addresses_h3_index ON ohio_addresses;
+    CREATE TRIGGER trg_ohio_addresses_h3_index
+        BEFORE INSERT OR UPDATE OF geom ON ohio_addresses
+        FOR EACH ROW EXECUTE FUNCTION set_address_h3_index();
+
+    UPDATE ohio_addresses SET h3_index = h3_lat_lng_to_cell(geom, 9)::bigint WHERE geom IS NOT NULL;
+END $$;
+
+-- Built after the backfill so the update doesn't maintain it row by row
+CREATE INDEX IF NOT EXISTS idx_ohio_addresses_h3_index ON ohio_addresses (h3_index);
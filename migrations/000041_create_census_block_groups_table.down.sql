@@ -0,0 +1,4 @@
+-- Rollback Migration 41: Drop Census block group boundaries
+DROP INDEX IF EXISTS idx_census_block_groups_geom;
+DROP INDEX IF EXISTS idx_census_block_groups_tract;
+DROP TABLE IF EXISTS census_block_groups;
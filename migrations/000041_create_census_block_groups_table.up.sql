@@ -0,0 +1,19 @@
+-- Migration 41: Census block group boundaries
+-- Block group GEOIDs are state (2) + county (3) + tract (6) + block group (1)
+-- digits, so the tract and county are prefixes of the GEOID
+CREATE TABLE IF NOT EXISTS census_block_groups (
+    geoid VARCHAR(12) PRIMARY KEY,
+    state_fips VARCHAR(2) NOT NULL,
+    county_fips VARCHAR(3) NOT NULL,
+    tract_code VARCHAR(6) NOT NULL,
+    block_group VARCHAR(1) NOT NULL,
+    tract_geoid VARCHAR(11) NOT NULL,
+    area_land BIGINT,
+    area_water BIGINT,
+    geom GEOMETRY(MULTIPOLYGON, 4326) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_census_block_groups_tract ON census_block_groups(tract_geoid);
+CREATE INDEX IF NOT EXISTS idx_census_block_groups_geom ON census_block_groups USING GIST (geom);
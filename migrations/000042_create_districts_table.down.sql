@@ -0,0 +1,3 @@
+-- Rollback Migration 42: Drop district boundaries
+DROP INDEX IF EXISTS idx_districts_geom;
+DROP TABLE IF EXISTS districts;
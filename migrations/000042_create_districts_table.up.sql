@@ -0,0 +1,18 @@
+-- Migration 42: Political and school district boundaries
+-- One table for every TIGER/Line district layer; GEOIDs are only unique
+-- within a district type
+CREATE TABLE IF NOT EXISTS districts (
+    district_type VARCHAR(32) NOT NULL,
+    geoid VARCHAR(16) NOT NULL,
+    state_fips VARCHAR(2) NOT NULL,
+    code VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    area_land BIGINT,
+    area_water BIGINT,
+    geom GEOMETRY(MULTIPOLYGON, 4326) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (district_type, geoid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_districts_geom ON districts USING GIST (geom);
@@ -0,0 +1,3 @@
+-- Rollback Migration 43: Drop the ZIP to CBSA crosswalk
+DROP INDEX IF EXISTS idx_zip_cbsa_crosswalk_cbsa;
+DROP TABLE IF EXISTS zip_cbsa_crosswalk;
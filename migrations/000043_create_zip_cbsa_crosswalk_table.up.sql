@@ -0,0 +1,16 @@
+-- Migration 43: HUD USPS ZIP to CBSA crosswalk
+-- Ratios are the share of the ZIP's residential, business, other and total
+-- addresses that fall in the CBSA; CBSA 99999 is outside any CBSA
+CREATE TABLE IF NOT EXISTS zip_cbsa_crosswalk (
+    zip_code VARCHAR(5) NOT NULL,
+    cbsa_code VARCHAR(5) NOT NULL,
+    res_ratio NUMERIC(12, 10) NOT NULL DEFAULT 0,
+    bus_ratio NUMERIC(12, 10) NOT NULL DEFAULT 0,
+    oth_ratio NUMERIC(12, 10) NOT NULL DEFAULT 0,
+    tot_ratio NUMERIC(12, 10) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (zip_code, cbsa_code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_zip_cbsa_crosswalk_cbsa ON zip_cbsa_crosswalk(cbsa_code);
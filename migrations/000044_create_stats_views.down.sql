@@ -0,0 +1,3 @@
+-- Rollback Migration 44: Drop the admin dashboard stats views
+DROP MATERIALIZED VIEW IF EXISTS county_address_counts;
+DROP MATERIALIZED VIEW IF EXISTS admin_stats_summary;
@@ -0,0 +1,22 @@
+-- Migration 44: Materialized views behind the admin dashboard counts
+-- Refreshed in the background every STATS_REFRESH_INTERVAL and by
+-- POST /api/v1/admin/stats/refresh. The unique indexes let them refresh
+-- CONCURRENTLY, without blocking readers.
+CREATE MATERIALIZED VIEW IF NOT EXISTS admin_stats_summary AS
+SELECT
+    1 AS id,
+    (SELECT COUNT(*) FROM users WHERE deleted_at IS NULL) AS total_users,
+    (SELECT COUNT(*) FROM api_keys WHERE is_active = true) AS active_keys,
+    (SELECT COUNT(*) FROM usage_records WHERE created_at >= CURRENT_DATE) AS calls_today,
+    (SELECT COUNT(*) FROM zip_codes) AS zip_codes,
+    NOW() AS refreshed_at;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_stats_summary_id ON admin_stats_summary(id);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS county_address_counts AS
+SELECT county, COUNT(*) AS address_count
+FROM ohio_addresses
+WHERE county IS NOT NULL
+GROUP BY county;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_county_address_counts_county ON county_address_counts(county);
@@ -0,0 +1,4 @@
+-- Rollback Migration 45: Drop the SLO tables
+DROP INDEX IF EXISTS idx_usage_records_endpoint_created;
+DROP TABLE IF EXISTS slo_alerts;
+DROP TABLE IF EXISTS slo_targets;
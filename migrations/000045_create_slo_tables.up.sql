@@ -0,0 +1,23 @@
+-- Migration 45: Per-endpoint latency and error rate SLOs
+-- Compliance is computed from usage_records; slo_alerts records each alert
+-- once per endpoint and alert window so every API instance doesn't send it
+CREATE TABLE IF NOT EXISTS slo_targets (
+    endpoint VARCHAR(100) PRIMARY KEY,
+    p95_latency_ms INTEGER NOT NULL CHECK (p95_latency_ms > 0),
+    max_error_rate NUMERIC(6, 5) NOT NULL CHECK (max_error_rate > 0 AND max_error_rate < 1),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS slo_alerts (
+    endpoint VARCHAR(100) NOT NULL REFERENCES slo_targets(endpoint) ON DELETE CASCADE,
+    window_start TIMESTAMP NOT NULL,
+    latency_burn_rate DOUBLE PRECISION NOT NULL,
+    error_burn_rate DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (endpoint, window_start)
+);
+
+-- Compliance scans one endpoint's recent usage
+CREATE INDEX IF NOT EXISTS idx_usage_records_endpoint_created
+    ON usage_records(endpoint, created_at DESC);
@@ -0,0 +1,3 @@
+-- Rollback Migration 46: Drop captured request paths and query strings
+ALTER TABLE usage_records DROP COLUMN IF EXISTS query_string;
+ALTER TABLE usage_records DROP COLUMN IF EXISTS path;
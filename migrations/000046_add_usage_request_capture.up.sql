@@ -0,0 +1,5 @@
+-- Migration 46: Optionally capture the path and sanitized query string of
+-- each API call, for the admin request console and replay
+-- (USAGE_CAPTURE_REQUESTS=true). NULL when capture was off.
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS path TEXT;
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS query_string TEXT;
@@ -0,0 +1,3 @@
+-- Rollback Migration 47: Drop stored Idempotency-Key responses
+DROP INDEX IF EXISTS idx_idempotency_keys_expires_at;
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,2 @@
+-- Rollback Migration 48: Remove dataset validation reports
+ALTER TABLE datasets DROP COLUMN IF EXISTS validation_report;
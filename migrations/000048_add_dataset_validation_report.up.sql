@@ -0,0 +1,3 @@
+-- Migration 48: Store the validation pass run over each dataset before it
+-- is imported. NULL until the dataset has been validated.
+ALTER TABLE datasets ADD COLUMN IF NOT EXISTS validation_report JSONB;
@@ -0,0 +1,3 @@
+-- Rollback Migration 49: Drop duplicate address detection tables
+DROP TABLE IF EXISTS address_duplicates;
+DROP TABLE IF EXISTS address_dedupe_runs;
@@ -0,0 +1,38 @@
+-- Migration 49: Cross-dataset duplicate address detection. Each run records
+-- pairs of addresses from different datasets (counties, or a county and a
+-- statewide upload) that look like the same point, for admins to merge,
+-- delete or dismiss.
+CREATE TABLE IF NOT EXISTS address_dedupe_runs (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    distance_meters DOUBLE PRECISION NOT NULL,
+    started_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    duplicates INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_dedupe_runs_started ON address_dedupe_runs(started_at DESC);
+
+-- Addresses and datasets aren't foreign keys: the report outlives deleting
+-- or replacing them. An address is a duplicate at most once per run.
+CREATE TABLE IF NOT EXISTS address_duplicates (
+    id BIGSERIAL PRIMARY KEY,
+    run_id INTEGER NOT NULL REFERENCES address_dedupe_runs(id) ON DELETE CASCADE,
+    match_type VARCHAR(20) NOT NULL,
+    distance_meters DOUBLE PRECISION NOT NULL,
+    state VARCHAR(10) NOT NULL,
+    keep_address_id BIGINT NOT NULL,
+    keep_county VARCHAR(255) NOT NULL,
+    keep_dataset_id INTEGER,
+    duplicate_address_id BIGINT NOT NULL,
+    duplicate_county VARCHAR(255) NOT NULL,
+    duplicate_dataset_id INTEGER,
+    resolution VARCHAR(20),
+    resolved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    UNIQUE (run_id, duplicate_address_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_duplicates_run ON address_duplicates(run_id, id);
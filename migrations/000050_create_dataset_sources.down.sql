@@ -0,0 +1,2 @@
+-- Rollback Migration 50: Drop tracked dataset sources
+DROP TABLE IF EXISTS dataset_sources;
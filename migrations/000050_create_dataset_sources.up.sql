@@ -0,0 +1,22 @@
+-- Migration 50: Upstream sources tracked for automatic dataset refresh. Each
+-- row remembers what was last downloaded for a county, so a check only
+-- re-imports the county when OpenAddresses publishes new data.
+CREATE TABLE IF NOT EXISTS dataset_sources (
+    id SERIAL PRIMARY KEY,
+    state VARCHAR(10) NOT NULL,
+    county VARCHAR(255) NOT NULL,
+    config_url TEXT NOT NULL,
+    data_url TEXT,
+    etag TEXT,
+    last_modified TEXT,
+    content_sha256 VARCHAR(64),
+    -- unchanged, updated or failed
+    last_status VARCHAR(20),
+    last_error TEXT,
+    dataset_id INTEGER REFERENCES datasets(id) ON DELETE SET NULL,
+    last_checked_at TIMESTAMP,
+    last_changed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dataset_sources_county ON dataset_sources(UPPER(state), LOWER(county));
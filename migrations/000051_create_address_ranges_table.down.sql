@@ -0,0 +1,2 @@
+-- Rollback Migration 51: Drop TIGER/Line address ranges
+DROP TABLE IF EXISTS address_ranges;
@@ -0,0 +1,25 @@
+-- Migration 51: TIGER/Line address ranges. Each row is one side of a street
+-- edge with the house numbers along it, so an address with no point of its
+-- own can be placed by interpolating between the ends of the range.
+CREATE TABLE IF NOT EXISTS address_ranges (
+    id BIGSERIAL PRIMARY KEY,
+    tlid BIGINT NOT NULL,
+    -- L or R: the side of the edge, walking from its first vertex
+    side CHAR(1) NOT NULL,
+    county_geoid VARCHAR(5) NOT NULL,
+    street VARCHAR(255) NOT NULL,
+    -- from_number is at the edge's first vertex, to_number at its last
+    from_number INTEGER NOT NULL,
+    to_number INTEGER NOT NULL,
+    -- O (odd), E (even) or B (both)
+    parity CHAR(1) NOT NULL DEFAULT 'B',
+    postcode VARCHAR(10),
+    geom geometry(LineString, 4326) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tlid, side)
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_ranges_street ON address_ranges (normalize_street_name(street));
+CREATE INDEX IF NOT EXISTS idx_address_ranges_postcode ON address_ranges (postcode);
+CREATE INDEX IF NOT EXISTS idx_address_ranges_geom ON address_ranges USING GIST (geom);
@@ -0,0 +1,4 @@
+-- Rollback Migration 52: Drop custom address lists and the plan limit on them
+DROP TABLE IF EXISTS custom_addresses;
+DROP TABLE IF EXISTS custom_datasets;
+ALTER TABLE plans DROP COLUMN IF EXISTS custom_address_limit;
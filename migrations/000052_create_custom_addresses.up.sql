@@ -0,0 +1,57 @@
+-- Migration 52: Private address lists users upload to be geocoded alongside
+-- the public address points. Only the owner's API keys search them, and
+-- each plan caps how many rows a user may store (-1 means unlimited).
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS custom_address_limit INTEGER NOT NULL DEFAULT 0
+    CHECK (custom_address_limit >= -1);
+UPDATE plans SET custom_address_limit = CASE id
+    WHEN 'free' THEN 100
+    WHEN 'starter' THEN 1000
+    WHEN 'pro' THEN 25000
+    WHEN 'enterprise' THEN -1
+    ELSE 0
+END;
+
+CREATE TABLE IF NOT EXISTS custom_datasets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    filename VARCHAR(255),
+    row_count INTEGER NOT NULL DEFAULT 0,
+    skipped_rows INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_datasets_user ON custom_datasets(user_id);
+
+-- Same address columns as ohio_addresses so the address search runs on
+-- either table
+CREATE TABLE IF NOT EXISTS custom_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    dataset_id INTEGER NOT NULL REFERENCES custom_datasets(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hash VARCHAR(255) NOT NULL,
+    house_number VARCHAR(50) NOT NULL DEFAULT '',
+    street VARCHAR(255) NOT NULL DEFAULT '',
+    unit VARCHAR(50) NOT NULL DEFAULT '',
+    city VARCHAR(255) NOT NULL DEFAULT '',
+    district VARCHAR(10) NOT NULL DEFAULT '',
+    region VARCHAR(2) NOT NULL DEFAULT '',
+    postcode VARCHAR(10) NOT NULL DEFAULT '',
+    county VARCHAR(255) NOT NULL DEFAULT '',
+    full_address TEXT NOT NULL DEFAULT '',
+    geom GEOMETRY(POINT, 4326) NOT NULL,
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', house_number), 'A') ||
+        setweight(to_tsvector('simple', street), 'A') ||
+        setweight(to_tsvector('simple', city), 'B') ||
+        setweight(to_tsvector('simple', postcode), 'B') ||
+        setweight(to_tsvector('simple', county), 'C') ||
+        setweight(to_tsvector('simple', full_address), 'D')
+    ) STORED,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_custom_addresses_user ON custom_addresses(user_id);
+CREATE INDEX IF NOT EXISTS idx_custom_addresses_dataset ON custom_addresses(dataset_id);
+CREATE INDEX IF NOT EXISTS idx_custom_addresses_geom ON custom_addresses USING GIST (geom);
+CREATE INDEX IF NOT EXISTS idx_custom_addresses_search_vector ON custom_addresses USING GIN (search_vector);
@@ -0,0 +1,4 @@
+-- Rollback Migration 53: Drop address change subscriptions and detected changes
+DROP TABLE IF EXISTS address_changes;
+DROP TABLE IF EXISTS replaced_addresses;
+DROP TABLE IF EXISTS address_subscriptions;
@@ -0,0 +1,54 @@
+-- Migration 53: Address change subscriptions. Replacing a county's dataset
+-- keeps its old addresses as a baseline; once the new import completes the
+-- two are diffed into address_changes, and users subscribed to a ZIP code,
+-- county or polygon covering a change are notified.
+CREATE TABLE IF NOT EXISTS address_subscriptions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('zip', 'county', 'polygon')),
+    zip_code VARCHAR(5),
+    state VARCHAR(10),
+    county VARCHAR(255),
+    geom GEOMETRY(MULTIPOLYGON, 4326),
+    notify_email BOOLEAN NOT NULL DEFAULT true,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_subscriptions_user ON address_subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_address_subscriptions_geom ON address_subscriptions USING GIST (geom);
+
+-- The addresses a replaced county had, until the replacement is diffed
+-- against them
+CREATE TABLE IF NOT EXISTS replaced_addresses (
+    state VARCHAR(10) NOT NULL,
+    county VARCHAR(255) NOT NULL,
+    hash VARCHAR(255) NOT NULL,
+    full_address TEXT,
+    postcode VARCHAR(10),
+    geom GEOMETRY(POINT, 4326),
+    replaced_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (state, county, hash)
+);
+
+-- Datasets and addresses aren't foreign keys: changes outlive the import
+-- that found them, and removed addresses no longer exist
+CREATE TABLE IF NOT EXISTS address_changes (
+    id BIGSERIAL PRIMARY KEY,
+    dataset_id INTEGER NOT NULL,
+    change_type VARCHAR(10) NOT NULL CHECK (change_type IN ('added', 'removed')),
+    address_id BIGINT,
+    hash VARCHAR(255) NOT NULL,
+    full_address TEXT,
+    postcode VARCHAR(10),
+    state VARCHAR(10) NOT NULL,
+    county VARCHAR(255) NOT NULL,
+    geom GEOMETRY(POINT, 4326),
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_changes_dataset ON address_changes(dataset_id, id);
+CREATE INDEX IF NOT EXISTS idx_address_changes_detected ON address_changes(detected_at);
+CREATE INDEX IF NOT EXISTS idx_address_changes_geom ON address_changes USING GIST (geom);
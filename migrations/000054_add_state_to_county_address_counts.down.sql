@@ -0,0 +1,10 @@
+-- Rollback Migration 54: Count addresses per county name only
+DROP MATERIALIZED VIEW IF EXISTS county_address_counts;
+
+CREATE MATERIALIZED VIEW county_address_counts AS
+SELECT county, COUNT(*) AS address_count
+FROM ohio_addresses
+WHERE county IS NOT NULL
+GROUP BY county;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_county_address_counts_county ON county_address_counts(county);
@@ -0,0 +1,12 @@
+-- Migration 54: Count addresses per state and county, with when each was
+-- last loaded, for the public data coverage report. Counties of the same
+-- name in different states were counted together before.
+DROP MATERIALIZED VIEW IF EXISTS county_address_counts;
+
+CREATE MATERIALIZED VIEW county_address_counts AS
+SELECT COALESCE(region, '') AS state, county, COUNT(*) AS address_count, MAX(created_at) AS last_loaded_at
+FROM ohio_addresses
+WHERE county IS NOT NULL
+GROUP BY COALESCE(region, ''), county;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_county_address_counts_state_county ON county_address_counts(state, county);
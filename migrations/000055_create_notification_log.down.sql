@@ -0,0 +1,2 @@
+-- Rollback Migration 55: Drop the notification email log
+DROP TABLE IF EXISTS notification_log;
@@ -0,0 +1,13 @@
+-- Migration 55: Notification emails sent to users. A notification with a
+-- dedupe key, such as a quota warning for one month, is sent at most once
+-- per user.
+CREATE TABLE IF NOT EXISTS notification_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    dedupe_key VARCHAR(255),
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_log_dedupe ON notification_log(user_id, dedupe_key);
+CREATE INDEX IF NOT EXISTS idx_notification_log_sent_at ON notification_log(sent_at);
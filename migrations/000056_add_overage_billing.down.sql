@@ -0,0 +1,5 @@
+-- Rollback Migration 56: Drop overage billing columns
+DROP INDEX IF EXISTS idx_usage_records_overage;
+ALTER TABLE usage_records DROP COLUMN IF EXISTS overage;
+ALTER TABLE users DROP COLUMN IF EXISTS overage_spend_cap;
+ALTER TABLE users DROP COLUMN IF EXISTS overage_enabled;
@@ -0,0 +1,10 @@
+-- Migration 56: Overage billing
+-- Users who opt in keep calling past their plan's monthly limit; those calls
+-- are billed at the plan's price per call up to the user's spend cap.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS overage_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS overage_spend_cap NUMERIC(10,2);
+
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS overage BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_overage
+    ON usage_records (user_id, created_at) WHERE overage = true;
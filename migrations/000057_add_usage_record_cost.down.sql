@@ -0,0 +1,3 @@
+-- Rollback Migration 57: Drop usage record cost
+ALTER TABLE usage_records DROP CONSTRAINT IF EXISTS usage_records_cost_check;
+ALTER TABLE usage_records DROP COLUMN IF EXISTS cost;
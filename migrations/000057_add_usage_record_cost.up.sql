@@ -0,0 +1,6 @@
+-- Migration 57: Per-endpoint usage cost
+-- Heavy endpoints count as several calls against quotas. cost is how many
+-- calls a record counts as; usage totals sum it instead of counting rows.
+ALTER TABLE usage_records ADD COLUMN IF NOT EXISTS cost INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE usage_records DROP CONSTRAINT IF EXISTS usage_records_cost_check;
+ALTER TABLE usage_records ADD CONSTRAINT usage_records_cost_check CHECK (cost > 0);
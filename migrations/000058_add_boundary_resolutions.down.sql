@@ -0,0 +1,18 @@
+-- Rollback Migration 58: Drop pre-computed boundary resolutions
+DROP TRIGGER IF EXISTS trg_us_states_boundary_resolutions ON us_states;
+DROP TRIGGER IF EXISTS trg_ohio_counties_boundary_resolutions ON ohio_counties;
+DROP FUNCTION IF EXISTS set_state_boundary_resolutions();
+DROP FUNCTION IF EXISTS set_county_boundary_resolutions();
+DROP FUNCTION IF EXISTS simplify_boundary(GEOMETRY, VARCHAR);
+
+ALTER TABLE us_states
+    DROP COLUMN IF EXISTS geometry_high,
+    DROP COLUMN IF EXISTS geometry_medium,
+    DROP COLUMN IF EXISTS geometry_low;
+
+ALTER TABLE ohio_counties
+    DROP COLUMN IF EXISTS bounds_geometry_high,
+    DROP COLUMN IF EXISTS bounds_geometry_medium,
+    DROP COLUMN IF EXISTS bounds_geometry_low;
+
+DROP TABLE IF EXISTS boundary_resolutions;
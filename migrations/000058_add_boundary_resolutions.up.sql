@@ -0,0 +1,64 @@
+-- Migration 58: Pre-computed boundary resolutions
+-- County and state boundaries are stored simplified at fixed tolerance tiers
+-- so map clients can fetch a light outline with ?resolution= instead of
+-- simplifying the full geometry on every request. Triggers keep the tiers in
+-- step with the full geometry whenever a boundary is loaded or replaced.
+CREATE TABLE IF NOT EXISTS boundary_resolutions (
+    resolution VARCHAR(10) PRIMARY KEY,
+    -- ST_SimplifyPreserveTopology tolerance in degrees
+    tolerance DOUBLE PRECISION NOT NULL CHECK (tolerance > 0)
+);
+
+INSERT INTO boundary_resolutions (resolution, tolerance) VALUES
+    ('low', 0.01),      -- about 1.1 km, a rough outline for small-scale maps
+    ('medium', 0.001),  -- about 110 m
+    ('high', 0.0001)    -- about 11 m
+ON CONFLICT (resolution) DO NOTHING;
+
+ALTER TABLE ohio_counties
+    ADD COLUMN IF NOT EXISTS bounds_geometry_low GEOMETRY(GEOMETRY, 4326),
+    ADD COLUMN IF NOT EXISTS bounds_geometry_medium GEOMETRY(GEOMETRY, 4326),
+    ADD COLUMN IF NOT EXISTS bounds_geometry_high GEOMETRY(GEOMETRY, 4326);
+
+ALTER TABLE us_states
+    ADD COLUMN IF NOT EXISTS geometry_low GEOMETRY(GEOMETRY, 4326),
+    ADD COLUMN IF NOT EXISTS geometry_medium GEOMETRY(GEOMETRY, 4326),
+    ADD COLUMN IF NOT EXISTS geometry_high GEOMETRY(GEOMETRY, 4326);
+
+-- simplify_boundary simplifies geom at a stored resolution tier
+CREATE OR REPLACE FUNCTION simplify_boundary(geom GEOMETRY, tier VARCHAR) RETURNS GEOMETRY AS $$
+    SELECT ST_SimplifyPreserveTopology(geom, tolerance)
+    FROM boundary_resolutions WHERE resolution = tier
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION set_county_boundary_resolutions() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.bounds_geometry_low := simplify_boundary(NEW.bounds_geometry, 'low');
+    NEW.bounds_geometry_medium := simplify_boundary(NEW.bounds_geometry, 'medium');
+    NEW.bounds_geometry_high := simplify_boundary(NEW.bounds_geometry, 'high');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION set_state_boundary_resolutions() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.geometry_low := simplify_boundary(NEW.geometry, 'low');
+    NEW.geometry_medium := simplify_boundary(NEW.geometry, 'medium');
+    NEW.geometry_high := simplify_boundary(NEW.geometry, 'high');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ohio_counties_boundary_resolutions ON ohio_counties;
+CREATE TRIGGER trg_ohio_counties_boundary_resolutions
+    BEFORE INSERT OR UPDATE OF bounds_geometry ON ohio_counties
+    FOR EACH ROW EXECUTE FUNCTION set_county_boundary_resolutions();
+
+DROP TRIGGER IF EXISTS trg_us_states_boundary_resolutions ON us_states;
+CREATE TRIGGER trg_us_states_boundary_resolutions
+    BEFORE INSERT OR UPDATE OF geometry ON us_states
+    FOR EACH ROW EXECUTE FUNCTION set_state_boundary_resolutions();
+
+-- Backfill boundaries loaded before this migration
+UPDATE ohio_counties SET bounds_geometry = bounds_geometry WHERE bounds_geometry IS NOT NULL;
+UPDATE us_states SET geometry = geometry WHERE geometry IS NOT NULL;
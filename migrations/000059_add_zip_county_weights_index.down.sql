@@ -0,0 +1,3 @@
+-- Rollback Migration 59: Drop ZIP code county weights indexes
+DROP INDEX IF EXISTS idx_zip_codes_primary_county_code;
+DROP INDEX IF EXISTS idx_zip_codes_county_weights;
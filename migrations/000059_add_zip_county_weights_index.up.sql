@@ -0,0 +1,5 @@
+-- Migration 59: Index ZIP code county weights
+-- Listing the ZIP codes of a county tests county_weights ? fips, which a GIN
+-- index answers without scanning every ZIP code.
+CREATE INDEX IF NOT EXISTS idx_zip_codes_county_weights ON zip_codes USING GIN (county_weights);
+CREATE INDEX IF NOT EXISTS idx_zip_codes_primary_county_code ON zip_codes (primary_county_code);
@@ -0,0 +1,2 @@
+-- Rollback Migration 60: Drop the ZIP demographics table
+DROP TABLE IF EXISTS zip_demographics;
@@ -0,0 +1,12 @@
+-- Migration 60: Census ZIP demographics
+-- ACS median household income and household counts per ZCTA, with the
+-- population-weighted centroid, served with ?include=demographics
+CREATE TABLE IF NOT EXISTS zip_demographics (
+    zip_code VARCHAR(5) PRIMARY KEY,
+    households INTEGER,
+    median_household_income INTEGER,
+    population_latitude NUMERIC(10, 7),
+    population_longitude NUMERIC(10, 7),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
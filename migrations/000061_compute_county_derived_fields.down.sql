@@ -0,0 +1,4 @@
+-- Rollback Migration 61: Stop computing county centroids, areas and seats on load
+DROP TRIGGER IF EXISTS trg_ohio_counties_derived_fields ON ohio_counties;
+DROP FUNCTION IF EXISTS set_county_derived_fields();
+DROP TABLE IF EXISTS ohio_county_seats;
@@ -0,0 +1,125 @@
+-- Migration 61: Compute county centroids, areas and seats on load
+-- Migration 22 filled these once from the counties loaded at the time, but
+-- counties are loaded by the data loader after migrations, so a fresh install
+-- never got them and reloads left them stale. A trigger now derives them from
+-- bounds_geometry whenever a county is loaded or its boundary replaced, and
+-- seats come from a reference table.
+CREATE TABLE IF NOT EXISTS ohio_county_seats (
+    county_name VARCHAR(255) PRIMARY KEY,
+    county_seat VARCHAR(255) NOT NULL
+);
+
+INSERT INTO ohio_county_seats (county_name, county_seat) VALUES
+    ('Adams', 'West Union'),
+    ('Allen', 'Lima'),
+    ('Ashland', 'Ashland'),
+    ('Ashtabula', 'Jefferson'),
+    ('Athens', 'Athens'),
+    ('Auglaize', 'Wapakoneta'),
+    ('Belmont', 'St. Clairsville'),
+    ('Brown', 'Georgetown'),
+    ('Butler', 'Hamilton'),
+    ('Carroll', 'Carrollton'),
+    ('Champaign', 'Urbana'),
+    ('Clark', 'Springfield'),
+    ('Clermont', 'Batavia'),
+    ('Clinton', 'Wilmington'),
+    ('Columbiana', 'Lisbon'),
+    ('Coshocton', 'Coshocton'),
+    ('Crawford', 'Bucyrus'),
+    ('Cuyahoga', 'Cleveland'),
+    ('Darke', 'Greenville'),
+    ('Defiance', 'Defiance'),
+    ('Delaware', 'Delaware'),
+    ('Erie', 'Sandusky'),
+    ('Fairfield', 'Lancaster'),
+    ('Fayette', 'Washington Court House'),
+    ('Franklin', 'Columbus'),
+    ('Fulton', 'Wauseon'),
+    ('Gallia', 'Gallipolis'),
+    ('Geauga', 'Chardon'),
+    ('Greene', 'Xenia'),
+    ('Guernsey', 'Cambridge'),
+    ('Hamilton', 'Cincinnati'),
+    ('Hancock', 'Findlay'),
+    ('Hardin', 'Kenton'),
+    ('Harrison', 'Cadiz'),
+    ('Henry', 'Napoleon'),
+    ('Highland', 'Hillsboro'),
+    ('Hocking', 'Logan'),
+    ('Holmes', 'Millersburg'),
+    ('Huron', 'Norwalk'),
+    ('Jackson', 'Jackson'),
+    ('Jefferson', 'Steubenville'),
+    ('Knox', 'Mount Vernon'),
+    ('Lake', 'Painesville'),
+    ('Lawrence', 'Ironton'),
+    ('Licking', 'Newark'),
+    ('Logan', 'Bellefontaine'),
+    ('Lorain', 'Elyria'),
+    ('Lucas', 'Toledo'),
+    ('Madison', 'London'),
+    ('Mahoning', 'Youngstown'),
+    ('Marion', 'Marion'),
+    ('Medina', 'Medina'),
+    ('Meigs', 'Pomeroy'),
+    ('Mercer', 'Celina'),
+    ('Miami', 'Troy'),
+    ('Monroe', 'Woodsfield'),
+    ('Montgomery', 'Dayton'),
+    ('Morgan', 'McConnelsville'),
+    ('Morrow', 'Mount Gilead'),
+    ('Muskingum', 'Zanesville'),
+    ('Noble', 'Caldwell'),
+    ('Ottawa', 'Port Clinton'),
+    ('Paulding', 'Paulding'),
+    ('Perry', 'New Lexington'),
+    ('Pickaway', 'Circleville'),
+    ('Pike', 'Waverly'),
+    ('Portage', 'Ravenna'),
+    ('Preble', 'Eaton'),
+    ('Putnam', 'Ottawa'),
+    ('Richland', 'Mansfield'),
+    ('Ross', 'Chillicothe'),
+    ('Sandusky', 'Fremont'),
+    ('Scioto', 'Portsmouth'),
+    ('Seneca', 'Tiffin'),
+    ('Shelby', 'Sidney'),
+    ('Stark', 'Canton'),
+    ('Summit', 'Akron'),
+    ('Trumbull', 'Warren'),
+    ('Tuscarawas', 'New Philadelphia'),
+    ('Union', 'Marysville'),
+    ('Van Wert', 'Van Wert'),
+    ('Vinton', 'McArthur'),
+    ('Warren', 'Lebanon'),
+    ('Washington', 'Marietta'),
+    ('Wayne', 'Wooster'),
+    ('Williams', 'Bryan'),
+    ('Wood', 'Bowling Green'),
+    ('Wyandot', 'Upper Sandusky')
+ON CONFLICT (county_name) DO NOTHING;
+
+-- Water area stays NULL until a source with a land/water split is loaded, so
+-- land_area_sqm is the whole polygon's area
+CREATE OR REPLACE FUNCTION set_county_derived_fields() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.centroid := ST_Centroid(NEW.bounds_geometry);
+    NEW.land_area_sqm := ST_Area(NEW.bounds_geometry::geography);
+    IF NEW.county_seat IS NULL THEN
+        NEW.county_seat := (
+            SELECT county_seat FROM ohio_county_seats
+            WHERE LOWER(county_name) = LOWER(NEW.county_name)
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ohio_counties_derived_fields ON ohio_counties;
+CREATE TRIGGER trg_ohio_counties_derived_fields
+    BEFORE INSERT OR UPDATE OF bounds_geometry, county_name ON ohio_counties
+    FOR EACH ROW EXECUTE FUNCTION set_county_derived_fields();
+
+-- Backfill counties loaded before this migration
+UPDATE ohio_counties SET bounds_geometry = bounds_geometry;
@@ -0,0 +1,12 @@
+// Package migrations holds the versioned SQL schema migrations. Each version
+// is a pair of files, NNNNNN_name.up.sql and NNNNNN_name.down.sql, applied in
+// version order by the database package and cmd/migrate.
+package migrations
+
+import "embed"
+
+// Files contains every migration, embedded so the binaries don't depend on
+// the working directory
+//
+//go:embed *.sql
+var Files embed.FS
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -20,29 +21,109 @@ type OhioAddress struct {
 	Latitude     float64   `json:"latitude" db:"latitude"`
 	Longitude    float64   `json:"longitude" db:"longitude"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	// Census is the geography of the address point, with ?include=census
+	Census       *CensusGeography `json:"census,omitempty" db:"-"`
+	// Match describes how a geocode, reverse or search result matched
+	Match        *Match           `json:"match,omitempty" db:"-"`
+	// UnitCount is how many units of the building a deduplicated search
+	// collapsed into this row
+	UnitCount    int              `json:"unit_count,omitempty" db:"-"`
+}
+
+// NearbyAddress is an address point with its distance from a search location
+type NearbyAddress struct {
+	OhioAddress
+	DistanceMeters float64 `json:"distance_meters"`
 }
 
 // AddressSearchParams represents search parameters for address queries
 type AddressSearchParams struct {
-	Query    string  `json:"query" form:"query"`       // General search query
-	County   string  `json:"county" form:"county"`     // Filter by county
-	City     string  `json:"city" form:"city"`         // Filter by city
-	Postcode string  `json:"postcode" form:"postcode"` // Filter by postal code
-	Street   string  `json:"street" form:"street"`     // Filter by street name
-	Lat      float64 `json:"lat" form:"lat"`           // Latitude for proximity search
-	Lng      float64 `json:"lng" form:"lng"`           // Longitude for proximity search
-	Radius   float64 `json:"radius" form:"radius"`     // Radius in kilometers for proximity search
-	Limit    int     `json:"limit" form:"limit"`       // Number of results to return (default: 50, max: 500)
-	Offset   int     `json:"offset" form:"offset"`     // Offset for pagination
+	Query       string  `json:"query" form:"query"`               // General search query
+	HouseNumber string  `json:"house_number" form:"house_number"` // Filter by exact house number
+	Unit        string  `json:"unit" form:"unit"`                 // Filter by unit/apartment
+	County      string  `json:"county" form:"county"`             // Filter by county
+	City        string  `json:"city" form:"city"`                 // Filter by city
+	State       string  `json:"state" form:"state"`               // Filter by 2-letter state code (region)
+	Postcode    string  `json:"postcode" form:"postcode"`         // Filter by postal code
+	Street      string  `json:"street" form:"street"`             // Filter by street name
+	Lat         float64 `json:"lat" form:"lat"`                   // Latitude for proximity search
+	Lng         float64 `json:"lng" form:"lng"`                   // Longitude for proximity search
+	Radius      float64 `json:"radius" form:"radius"`             // Radius in kilometers for proximity search
+	Limit       int     `json:"limit" form:"limit"`               // Number of results to return (default: 50, max: 500)
+	Offset      int     `json:"offset" form:"offset"`             // Offset for pagination
+	Sample      float64 `json:"sample" form:"sample"`             // Deterministic sample fraction (0 < sample <= 1)
+	Dedupe      bool    `json:"dedupe" form:"dedupe"`             // Collapse the units of a building into one row
+	Cluster     string  `json:"cluster" form:"cluster"`           // "distance" returns clusters of nearby results instead of rows
+	// ClusterRadius is how close, in meters, results must be to share a cluster (default: 100, max: 5000)
+	ClusterRadius float64 `json:"cluster_radius" form:"cluster_radius"`
+	// BBox keeps to addresses inside [min_lng, min_lat, max_lng, max_lat]
+	BBox []float64 `json:"bbox,omitempty" form:"bbox"`
+}
+
+// AddressWithinRequest is the body of POST /addresses/within. Geometry is
+// a GeoJSON Polygon or MultiPolygon, or a Feature holding one.
+type AddressWithinRequest struct {
+	Geometry json.RawMessage `json:"geometry" validate:"required"`
+	Limit    int             `json:"limit" validate:"min=0,max=1000"`  // default: 100
+	Offset   int             `json:"offset" validate:"min=0"`
+	// CountOnly returns the number of addresses inside without any rows
+	CountOnly bool `json:"count_only"`
+}
+
+// AddressCluster is a group of address search results within the cluster
+// radius of one another, for drawing dense results on a map
+type AddressCluster struct {
+	Latitude  float64   `json:"latitude"` // centroid of the cluster
+	Longitude float64   `json:"longitude"`
+	Count     int       `json:"count"`
+	Bounds    []float64 `json:"bounds"`               // [min_lng, min_lat, max_lng, max_lat]
+	AddressID *int64    `json:"address_id,omitempty"` // the address, when the cluster holds only one
 }
 
 // AddressSearchResponse represents the response for address search
 type AddressSearchResponse struct {
-	Success   bool            `json:"success"`
-	Data      []OhioAddress   `json:"data"`
-	Count     int             `json:"count"`
-	Total     int             `json:"total,omitempty"`
-	Error     string          `json:"error,omitempty"`
-	Query     string          `json:"query,omitempty"`
-	Filters   map[string]any  `json:"filters,omitempty"`
-}
\ No newline at end of file
+	Success    bool           `json:"success"`
+	Data       []OhioAddress  `json:"data"`
+	Clusters   []AddressCluster `json:"clusters,omitempty"` // with cluster=distance, in place of data
+	// CustomCount is how many of the leading rows of data come from the API
+	// key owner's custom address lists; they aren't included in total
+	CustomCount int           `json:"custom_count,omitempty"`
+	Count      int            `json:"count"`
+	Total      int            `json:"total,omitempty"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Code       string         `json:"code,omitempty"`
+	Query      string         `json:"query,omitempty"`
+	Filters    map[string]any `json:"filters,omitempty"`
+}
+
+// Address validation verdicts, from most to least certain
+const (
+	AddressVerified    = "verified"       // the house number exists on the street
+	AddressStreetMatch = "partial_street" // the street exists but not the house number
+	AddressZipOnly     = "zip_only"       // only the ZIP code is known
+	AddressUnknown     = "unknown"        // nothing matched
+)
+
+// AddressValidationRequest is the body of POST /addresses/validate: a
+// free-form address, components, or both (components override the parse)
+type AddressValidationRequest struct {
+	Address     string `json:"address"`
+	HouseNumber string `json:"house_number"`
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	Postcode    string `json:"postcode"`
+}
+
+// AddressValidationResult is the verdict for a submitted address with the
+// canonical record it matched. Corrected lists the submitted components that
+// differ from the matched address, e.g. a misspelled street or wrong ZIP.
+type AddressValidationResult struct {
+	Verdict     string       `json:"verdict"`
+	Deliverable bool         `json:"deliverable"`
+	Address     *OhioAddress `json:"address,omitempty"`
+	Street      *Street      `json:"street,omitempty"`
+	ZipCode     *ZipCode     `json:"zip_code,omitempty"`
+	Corrected   []string     `json:"corrected,omitempty"`
+}
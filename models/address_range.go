@@ -0,0 +1,19 @@
+package models
+
+// InterpolatedAddress is a position estimated along a TIGER/Line street
+// edge from the house number range on one side of it, returned when no
+// address point matches a query
+type InterpolatedAddress struct {
+	HouseNumber string  `json:"house_number"`
+	Street      string  `json:"street"`
+	Postcode    string  `json:"postcode,omitempty"`
+	CountyGEOID string  `json:"county_geoid"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	// FromNumber and ToNumber are the ends of the range the number fell in
+	FromNumber int    `json:"from_number"`
+	ToNumber   int    `json:"to_number"`
+	Side       string `json:"side"` // L or R of the edge
+	TLID       int64  `json:"tlid"` // TIGER/Line edge ID
+	Match      *Match `json:"match,omitempty"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// What an address subscription watches
+const (
+	AddressSubscriptionZip     = "zip"
+	AddressSubscriptionCounty  = "county"
+	AddressSubscriptionPolygon = "polygon"
+)
+
+// How a dataset import changed an address
+const (
+	AddressChangeAdded   = "added"
+	AddressChangeRemoved = "removed"
+)
+
+// AddressSubscription watches a ZIP code, county or polygon for addresses
+// that replacement dataset imports add or remove
+type AddressSubscription struct {
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	Name       string `json:"name"`
+	TargetType string `json:"target_type"` // zip, county or polygon
+	ZipCode    string `json:"zip_code,omitempty"`
+	State      string `json:"state,omitempty"`
+	County     string `json:"county,omitempty"`
+	// Geometry is the GeoJSON MultiPolygon of a polygon subscription
+	Geometry    json.RawMessage `json:"geometry,omitempty"`
+	NotifyEmail bool            `json:"notify_email"`
+	IsActive    bool            `json:"is_active"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// AddressSubscriptionRequest is the payload for creating or replacing an
+// address subscription. Exactly one of ZipCode, County (optionally with
+// State) or Geometry, a GeoJSON Polygon or MultiPolygon, is given.
+type AddressSubscriptionRequest struct {
+	Name        string          `json:"name" validate:"required,max=255"`
+	ZipCode     string          `json:"zip_code"`
+	State       string          `json:"state" validate:"max=10"`
+	County      string          `json:"county" validate:"max=255"`
+	Geometry    json.RawMessage `json:"geometry"`
+	NotifyEmail *bool           `json:"notify_email,omitempty"`
+	IsActive    *bool           `json:"is_active,omitempty"`
+}
+
+// AddressChange is an address a dataset import added or removed
+type AddressChange struct {
+	ID          int64     `json:"id"`
+	DatasetID   int       `json:"dataset_id"`
+	ChangeType  string    `json:"change_type"` // added or removed
+	AddressID   *int64    `json:"address_id,omitempty"`
+	FullAddress string    `json:"full_address"`
+	Postcode    string    `json:"postcode,omitempty"`
+	State       string    `json:"state"`
+	County      string    `json:"county"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// AddressChangeSummary counts the changes one import made inside a
+// subscription; it is the data of the address.changed webhook event
+type AddressChangeSummary struct {
+	SubscriptionID int    `json:"subscription_id"`
+	Name           string `json:"name"`
+	DatasetID      int    `json:"dataset_id"`
+	State          string `json:"state"`
+	County         string `json:"county"`
+	Added          int    `json:"added"`
+	Removed        int    `json:"removed"`
+}
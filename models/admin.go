@@ -0,0 +1,153 @@
+package models
+
+import "time"
+
+// Admin dashboard response models. Nullable database columns are pointers
+// without omitempty so they always appear, as null when unset; list fields are
+// always arrays, never null.
+
+// AdminStats summarizes system-wide counts for the admin dashboard
+type AdminStats struct {
+	TotalUsers int `json:"total_users"`
+	ActiveKeys int `json:"active_keys"`
+	CallsToday int `json:"calls_today"`
+	ZipCodes   int `json:"zip_codes"`
+	// RefreshedAt is when the counts were computed
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+// AdminUser is a user row in the admin user list, with usage counts
+type AdminUser struct {
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	Name          *string   `json:"name"`
+	Company       *string   `json:"company"`
+	PlanType      string    `json:"plan_type"`
+	IsActive      bool      `json:"is_active"`
+	IsAdmin       bool      `json:"is_admin"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	MonthlyUsage  int       `json:"monthly_usage"`
+	TodayUsage    int       `json:"today_usage"`
+	TotalUsage    int       `json:"total_usage"`
+	ActiveKeys    int       `json:"active_keys"`
+	// LastActiveAt is when any of the user's API keys was last used
+	LastActiveAt *time.Time `json:"last_active_at"`
+}
+
+// AdminUserFilter narrows the admin user list and export. Zero values are
+// ignored.
+type AdminUserFilter struct {
+	// Email matches any part of the address, ignoring case
+	Email string
+	Plan  string
+	// ActiveSince and ActiveBefore bound the user's last API key use; users
+	// who never used a key match ActiveBefore but not ActiveSince
+	ActiveSince  time.Time
+	ActiveBefore time.Time
+	Limit        int
+	Offset       int
+}
+
+// BulkUserStatusRequest activates or deactivates several users at once
+type BulkUserStatusRequest struct {
+	UserIDs  []int `json:"user_ids" validate:"required,min=1,max=1000,dive,gt=0"`
+	IsActive *bool `json:"is_active" validate:"required"`
+}
+
+// BulkUserStatusResult lists which users a bulk status change updated
+type BulkUserStatusResult struct {
+	IsActive bool  `json:"is_active"`
+	Updated  []int `json:"updated"`
+	// NotFound lists requested IDs with no matching user, including deleted ones
+	NotFound []int `json:"not_found"`
+}
+
+// UserDeletionResult summarizes what deleting a user changed
+type UserDeletionResult struct {
+	UserID                 int `json:"user_id"`
+	APIKeysDeactivated     int `json:"api_keys_deactivated"`
+	UsageRecordsAnonymized int `json:"usage_records_anonymized"`
+	// OrganizationsTransferred counts organizations whose ownership passed to
+	// another member because the user was their only owner
+	OrganizationsTransferred int `json:"organizations_transferred"`
+}
+
+// AdminUserStatus describes the authenticated admin's own account
+type AdminUserStatus struct {
+	ID       int     `json:"id"`
+	Email    string  `json:"email"`
+	Name     string  `json:"name"`
+	Company  *string `json:"company"`
+	IsAdmin  bool    `json:"is_admin"`
+	PlanType string  `json:"plan_type"`
+	IsActive bool    `json:"is_active"`
+}
+
+// AdminAPIKey is an API key row in the admin API key list
+type AdminAPIKey struct {
+	ID         int        `json:"id"`
+	UserEmail  string     `json:"user_email"`
+	Name       string     `json:"name"`
+	KeyPreview string     `json:"key_preview"`
+	IsActive   bool       `json:"is_active"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// EndpointMetrics is per-endpoint call volume and latency
+type EndpointMetrics struct {
+	Endpoint string  `json:"endpoint"`
+	Total    int     `json:"total"`
+	Billable int     `json:"billable"`
+	AvgTime  float64 `json:"avg_time"`
+}
+
+// UserDailyMetrics is one day of a user's call volume
+type UserDailyMetrics struct {
+	Date     string `json:"date"`
+	Total    int    `json:"total"`
+	Billable int    `json:"billable"`
+}
+
+// UserUsageMetrics is the admin view of one user's usage over a period
+type UserUsageMetrics struct {
+	UserID          int                `json:"user_id"`
+	Email           string             `json:"email"`
+	Name            *string            `json:"name"`
+	PlanType        string             `json:"plan_type"`
+	TotalCalls      int                `json:"total_calls"`
+	BillableCalls   int                `json:"billable_calls"`
+	AvgResponseTime float64            `json:"avg_response_time"`
+	SuccessCount    int                `json:"success_count"`
+	ErrorCount      int                `json:"error_count"`
+	Endpoints       []EndpointMetrics  `json:"endpoints"`
+	DailyUsage      []UserDailyMetrics `json:"daily_usage"`
+}
+
+// AnalyticsDailyUsage is one day of system-wide call volume
+type AnalyticsDailyUsage struct {
+	Date          string `json:"date"`
+	TotalCalls    int    `json:"total_calls"`
+	BillableCalls int    `json:"billable_calls"`
+}
+
+// AdminAnalytics is system-wide usage over a period
+type AdminAnalytics struct {
+	TotalCalls      int                   `json:"total_calls"`
+	BillableCalls   int                   `json:"billable_calls"`
+	AvgResponseTime float64               `json:"avg_response_time"`
+	SuccessCount    int                   `json:"success_count"`
+	ErrorCount      int                   `json:"error_count"`
+	Endpoints       []EndpointMetrics     `json:"endpoints"`
+	DailyUsage      []AnalyticsDailyUsage `json:"daily_usage"`
+}
+
+// SystemStatus reports database health for the admin dashboard
+type SystemStatus struct {
+	DatabaseConnected bool `json:"database_connected"`
+	MigrationsCurrent bool `json:"migrations_current"`
+	// DatabaseCircuit is the database circuit breaker's state: closed,
+	// open or half_open
+	DatabaseCircuit string `json:"database_circuit"`
+}
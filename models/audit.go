@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditUserStatusChanged  = "user.status_changed"
+	AuditUserAdminChanged   = "user.admin_changed"
+	AuditUserPlanChanged    = "user.plan_changed"
+	AuditUserOverageChanged = "user.overage_changed"
+	AuditUserDeleted        = "user.deleted"
+	AuditUsersExported      = "user.exported"
+	AuditAPIKeyCreated      = "api_key.created"
+	AuditAPIKeyDeleted      = "api_key.deleted"
+	AuditAPIKeyRevoked      = "api_key.revoked" // by an admin
+	AuditBurstReviewed      = "plan.burst_reviewed"
+	AuditPlanCreated        = "plan.created"
+	AuditPlanUpdated        = "plan.updated"
+	AuditDataLoaded         = "data.loaded"
+	AuditZipCodesRefreshed  = "data.zipcodes_refreshed"
+	AuditDatasetUploaded    = "data.dataset_uploaded"
+	AuditDatasetReprocessed = "data.dataset_reprocessed"
+	AuditDatasetDeleted     = "data.dataset_deleted"
+	AuditMaintenanceStarted = "data.maintenance_started"
+	AuditDedupeStarted      = "data.dedupe_started"
+	AuditDuplicatesResolved = "data.duplicates_resolved"
+	AuditSourcesRefreshed   = "data.sources_refreshed"
+	AuditRequestReplayed    = "request.replayed"
+)
+
+// AuditLogEntry records who did what to which target, and from where
+type AuditLogEntry struct {
+	ID          int64           `json:"id"`
+	ActorUserID *int            `json:"actor_user_id,omitempty"`
+	ActorEmail  string          `json:"actor_email,omitempty"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type,omitempty"`
+	TargetID    string          `json:"target_id,omitempty"`
+	Details     json.RawMessage `json:"details,omitempty"`
+	IPAddress   string          `json:"ip_address,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// AuditLogFilter narrows an audit log query. Zero values are ignored.
+type AuditLogFilter struct {
+	Action      string
+	ActorUserID int
+	TargetType  string
+	TargetID    string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+	Offset      int
+}
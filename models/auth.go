@@ -10,16 +10,25 @@ import (
 
 // User represents a registered API user
 type User struct {
-	ID           int       `json:"id" db:"id"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"` // Hidden from JSON
-	Name         string    `json:"name" db:"name"`
-	Company      *string   `json:"company,omitempty" db:"company"`
-	PlanType     string    `json:"plan_type" db:"plan_type"`
-	IsActive     bool      `json:"is_active" db:"is_active"`
-	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	ID            int       `json:"id" db:"id"`
+	Email         string    `json:"email" db:"email"`
+	PasswordHash  string    `json:"-" db:"password_hash"` // Hidden from JSON
+	Name          string    `json:"name" db:"name"`
+	Company       *string   `json:"company,omitempty" db:"company"`
+	PlanType      string    `json:"plan_type" db:"plan_type"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	IsAdmin       bool      `json:"is_admin" db:"is_admin"`
+	EmailVerified bool      `json:"email_verified" db:"email_verified"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AuthTokens is the access/refresh token pair issued at login and on refresh
+type AuthTokens struct {
+	AccessToken           string    `json:"token"`
+	AccessTokenExpiresAt  time.Time `json:"token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
 // APIKey represents an API key for a user
@@ -34,6 +43,12 @@ type APIKey struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	ExpiresAt   *time.Time `json:"expires_at" db:"expires_at"`
 	Permissions JSONArray `json:"permissions" db:"permissions"` // ["geocode", "distance", "search"]
+	// Optional caps below the plan limit so a leaked or test key can't use
+	// the whole account quota; nil means only the plan limit applies
+	MonthlyLimit *int `json:"monthly_limit" db:"monthly_limit"`
+	DailyLimit   *int `json:"daily_limit" db:"daily_limit"`
+	// OrganizationID is set for keys that bill to an organization's pooled quota
+	OrganizationID *int `json:"organization_id" db:"organization_id"`
 }
 
 // UsageRecord represents API usage tracking
@@ -73,7 +88,14 @@ type UsageSummary struct {
 	Month        string  `json:"month"` // YYYY-MM format
 	TotalCalls   int     `json:"total_calls"`
 	BillableCalls int    `json:"billable_calls"`
+	// BillableUnits is what the billable calls count as against quotas,
+	// with heavy endpoints weighted by their cost; it's what's priced
+	BillableUnits int     `json:"billable_units"`
 	TotalCost    float64 `json:"total_cost"` // in dollars
+	// OverageCalls are the billable calls made past the monthly limit under
+	// overage billing; OverageCost is their share of TotalCost
+	OverageCalls int     `json:"overage_calls"`
+	OverageCost  float64 `json:"overage_cost"`
 	EndpointBreakdown map[string]int `json:"endpoint_breakdown"`
 }
 
@@ -127,31 +149,3 @@ func (ja *JSONArray) Scan(value interface{}) error {
 	
 	return json.Unmarshal(bytes, ja)
 }
-
-// Plan types and limits
-var PlanLimits = map[string]struct {
-	MonthlyLimit int
-	PricePerCall float64 // in cents
-	Features     []string
-}{
-	"free": {
-		MonthlyLimit: 100000,
-		PricePerCall: 0,
-		Features:     []string{"geocode", "search"},
-	},
-	"starter": {
-		MonthlyLimit: 10000,
-		PricePerCall: 0.001, // $0.001 per call
-		Features:     []string{"geocode", "search", "distance"},
-	},
-	"pro": {
-		MonthlyLimit: 100000,
-		PricePerCall: 0.0008,
-		Features:     []string{"geocode", "search", "distance", "bulk"},
-	},
-	"enterprise": {
-		MonthlyLimit: 1000000,
-		PricePerCall: 0.0005,
-		Features:     []string{"geocode", "search", "distance", "bulk", "priority"},
-	},
-}
\ No newline at end of file
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// BurstWindow is a pre-approved time range during which a user may exceed
+// their plan, organization and API key limits, up to RequestedQPS requests
+// per second
+type BurstWindow struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	UserEmail    string     `json:"user_email,omitempty"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	RequestedQPS int        `json:"requested_qps"`
+	Reason       string     `json:"reason,omitempty"`
+	Status       string     `json:"status"` // pending, approved, rejected
+	ReviewedBy   *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// BurstWindowRequest is the payload for requesting a burst window
+type BurstWindowRequest struct {
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	RequestedQPS int       `json:"requested_qps"`
+	Reason       string    `json:"reason"`
+}
+
+// MaxBurstWindowDuration caps how long a single burst window may last
+const MaxBurstWindowDuration = 24 * time.Hour
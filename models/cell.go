@@ -0,0 +1,21 @@
+package models
+
+import "encoding/json"
+
+// Cell systems for /encode and /decode
+const (
+	CellSystemH3      = "h3"
+	CellSystemGeohash = "geohash"
+)
+
+// Cell is a point's H3 or geohash cell, with the cell's center and outline
+type Cell struct {
+	System     string `json:"system"`
+	Cell       string `json:"cell"`
+	Resolution int    `json:"resolution"`
+	// Lat and Lng are the center of the cell
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+	// Boundary is the cell as a GeoJSON Polygon
+	Boundary json.RawMessage `json:"boundary"`
+}
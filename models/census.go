@@ -0,0 +1,18 @@
+package models
+
+// IncludeCensus is the ?include= value that adds Census geography to
+// geocode and reverse-geocode results
+const IncludeCensus = "census"
+
+// CensusGeography is the Census block group, tract and county a point falls
+// in. GEOIDs nest: the tract GEOID is the first 11 digits of the block group
+// GEOID and the county GEOID the first 5.
+type CensusGeography struct {
+	BlockGroupGEOID string `json:"block_group_geoid"`
+	BlockGroup      string `json:"block_group"`
+	TractGEOID      string `json:"tract_geoid"`
+	Tract           string `json:"tract"`
+	StateFIPS       string `json:"state_fips"`
+	CountyFIPS      string `json:"county_fips"`
+	CountyGEOID     string `json:"county_geoid"`
+}
@@ -20,6 +20,8 @@ type City struct {
 	Ranking      int     `json:"ranking,omitempty"`
 	Zips         string  `json:"zips,omitempty"`
 	ExternalID   string  `json:"external_id,omitempty"`
+	// DistanceKm is set for searches and lookups from a location
+	DistanceKm *float64 `json:"distance_km,omitempty"`
 }
 
 // CitySearchParams represents search parameters for city lookups
@@ -32,8 +34,11 @@ type CitySearchParams struct {
 	Lng        float64 `json:"lng"`
 	Radius     float64 `json:"radius"`
 	MinPop     int     `json:"min_population"`
+	MaxPop     int     `json:"max_population"`
 	Limit      int     `json:"limit"`
 	Offset     int     `json:"offset"`
+	// BBox keeps to cities inside [min_lng, min_lat, max_lng, max_lat]
+	BBox []float64 `json:"bbox,omitempty"`
 }
 
 // CitySearchResponse represents the response for city search requests
@@ -46,4 +51,5 @@ type CitySearchResponse struct {
 	Filters map[string]interface{} `json:"filters,omitempty"`
 	Message string                 `json:"message,omitempty"`
 	Error   string                 `json:"error,omitempty"`
+	Code    string                 `json:"code,omitempty"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import "encoding/json"
+
+// Result types for CorridorRequest.Include
+const (
+	CorridorZipCodes  = "zip_codes"
+	CorridorAddresses = "addresses"
+)
+
+// CorridorRequest is the body of POST /corridor. Geometry is a GeoJSON
+// LineString, or a Feature holding one.
+type CorridorRequest struct {
+	Geometry json.RawMessage `json:"geometry" validate:"required"`
+	// BufferMiles is how far either side of the route to search
+	BufferMiles float64 `json:"buffer_miles" validate:"gt=0,max=10"`
+	// Include lists the result types to return, both by default
+	Include []string `json:"include" validate:"omitempty,dive,oneof=zip_codes addresses"`
+	Limit   int      `json:"limit" validate:"min=0,max=1000"` // per result type, default: 100
+}
+
+// CorridorZipCode is a ZIP code whose center is within a corridor
+type CorridorZipCode struct {
+	ZipCode *ZipCode `json:"zip_code"`
+	// DistanceMiles is how far the ZIP's center is from the route
+	DistanceMiles float64 `json:"distance_miles"`
+	// AlongRouteMiles is how far along the route its nearest point is
+	AlongRouteMiles float64 `json:"along_route_miles"`
+}
+
+// CorridorAddress is an address point within a corridor
+type CorridorAddress struct {
+	Address         OhioAddress `json:"address"`
+	DistanceMiles   float64     `json:"distance_miles"`
+	AlongRouteMiles float64     `json:"along_route_miles"`
+}
+
+// CorridorResult is what lies within the buffer of a route, ordered by
+// distance along it. Result types that weren't requested are null.
+type CorridorResult struct {
+	RouteMiles  float64           `json:"route_miles"`
+	BufferMiles float64           `json:"buffer_miles"`
+	ZipCodes    []CorridorZipCode `json:"zip_codes"`
+	Addresses   []CorridorAddress `json:"addresses"`
+}
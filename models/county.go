@@ -13,6 +13,11 @@ type OhioCounty struct {
 	AddressCount   int                    `json:"address_count" db:"address_count"`
 	Stats          map[string]interface{} `json:"stats,omitempty" db:"stats"`
 	BoundsGeometry string                 `json:"bounds_geometry,omitempty" db:"bounds_geometry"` // WKT format
+	CountySeat     string                 `json:"county_seat,omitempty" db:"county_seat"`
+	CentroidLat    *float64               `json:"centroid_lat,omitempty" db:"centroid_lat"`
+	CentroidLng    *float64               `json:"centroid_lng,omitempty" db:"centroid_lng"`
+	LandAreaSqM    *float64               `json:"land_area_sqm,omitempty" db:"land_area_sqm"`
+	WaterAreaSqM   *float64               `json:"water_area_sqm,omitempty" db:"water_area_sqm"`
 	CreatedAt      time.Time              `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at" db:"updated_at"`
 }
@@ -47,9 +52,25 @@ type CountyGeometryGeoJSON struct {
 
 // CountyListResponse represents a simplified list of counties
 type CountyListResponse struct {
-	ID           int    `json:"id"`
-	CountyName   string `json:"county_name"`
-	AddressCount int    `json:"address_count"`
+	ID           int      `json:"id"`
+	CountyName   string   `json:"county_name"`
+	AddressCount int      `json:"address_count"`
+	CountySeat   string   `json:"county_seat,omitempty"`
+	CentroidLat  *float64 `json:"centroid_lat,omitempty"`
+	CentroidLng  *float64 `json:"centroid_lng,omitempty"`
+	LandAreaSqM  *float64 `json:"land_area_sqm,omitempty"`
+	WaterAreaSqM *float64 `json:"water_area_sqm,omitempty"`
+}
+
+// CountyCentroidMatch is a county-level geocode result used when a query
+// names only a county ("match_type=county_centroid")
+type CountyCentroidMatch struct {
+	CountyName string  `json:"county_name"`
+	CountySeat string  `json:"county_seat,omitempty"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	MatchType  string  `json:"match_type"`
+	Match      *Match  `json:"match,omitempty"`
 }
 
 // CountySearchParams represents parameters for searching counties
@@ -59,4 +80,32 @@ type CountySearchParams struct {
 	MaxAddresses int    `query:"max_addresses"`
 	Limit        int    `query:"limit"`
 	Offset       int    `query:"offset"`
-}
\ No newline at end of file
+	// BBox keeps to counties overlapping [min_lng, min_lat, max_lng, max_lat]
+	BBox []float64
+}
+
+// MaxCountyContainsBatch caps the number of points in one batch county lookup
+const MaxCountyContainsBatch = 10000
+
+// CountyContainsPoint is one point in a batch county lookup. ID is an
+// optional caller-supplied identifier echoed back in the result.
+type CountyContainsPoint struct {
+	ID  string  `json:"id,omitempty"`
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// CountyContainsBatchRequest is the body of POST /counties/contains/batch
+type CountyContainsBatchRequest struct {
+	Points []CountyContainsPoint `json:"points"`
+}
+
+// CountyContainsResult is the county assignment for one input point, in input
+// order. CountyName is null when the point falls outside every county.
+type CountyContainsResult struct {
+	Index      int     `json:"index"`
+	ID         string  `json:"id,omitempty"`
+	Lat        float64 `json:"lat"`
+	Lng        float64 `json:"lng"`
+	CountyName *string `json:"county_name"`
+}
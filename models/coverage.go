@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Coverage methods for CoverageArea.Method
+const (
+	CoverageSpeedModel = "speed_model" // drive times estimated from straight-line distance
+	CoverageRouting    = "routing"     // drive times from the routing engine
+)
+
+// CoverageArea approximates the area reachable by car from a ZIP code
+// within a number of minutes, as the ZIP codes and counties it reaches
+type CoverageArea struct {
+	Center   string `json:"center_zip_code"`
+	Minutes  int    `json:"minutes"`
+	Method   string `json:"method"`
+	SpeedMPH int    `json:"speed_mph,omitempty"`
+	// RadiusMiles is the straight-line distance searched for candidates
+	RadiusMiles float64           `json:"radius_miles"`
+	ZipCodes    []CoverageZipCode `json:"zip_codes"`
+	Counties    []CoverageCounty  `json:"counties"`
+}
+
+// CoverageZipCode is a ZIP code inside a coverage area
+type CoverageZipCode struct {
+	ZipCode       string  `json:"zip_code"`
+	CityName      string  `json:"city_name"`
+	StateCode     string  `json:"state_code"`
+	DistanceMiles float64 `json:"distance_miles"`
+	DriveMinutes  float64 `json:"drive_minutes"`
+}
+
+// CoverageCounty is a county with at least one ZIP code in a coverage area
+type CoverageCounty struct {
+	Name      string `json:"name"`
+	Code      string `json:"code,omitempty"`
+	StateCode string `json:"state_code"`
+	ZipCount  int    `json:"zip_count"`
+}
+
+// Where a county's address points came from, for CountyDataCoverage.Source
+const (
+	DataSourceOpenAddresses = "openaddresses" // a dataset refreshed automatically from OpenAddresses
+	DataSourceUpload        = "upload"        // a dataset uploaded by an admin
+	DataSourceBundled       = "bundled"       // the county files loaded with the server's data
+)
+
+// DataCoverage reports which counties have address points loaded, so
+// customers can check an area is covered before integrating
+type DataCoverage struct {
+	AddressCount int      `json:"address_count"`
+	CountyCount  int      `json:"county_count"`
+	States       []string `json:"states"`
+	// CountsRefreshedAt is when the address counts were last recomputed
+	CountsRefreshedAt *time.Time           `json:"counts_refreshed_at,omitempty"`
+	Counties          []CountyDataCoverage `json:"counties"`
+}
+
+// CountyDataCoverage is the address data loaded for one county
+type CountyDataCoverage struct {
+	State        string `json:"state"`
+	County       string `json:"county"`
+	AddressCount int    `json:"address_count"`
+	Source       string `json:"source"`
+	SourceName   string `json:"source_name,omitempty"`
+	SourceURL    string `json:"source_url,omitempty"`
+	DatasetID    *int   `json:"dataset_id,omitempty"`
+	// DataVintage is when newer source data last replaced the county's
+	// addresses: when OpenAddresses published it, or else the last import
+	DataVintage    *time.Time `json:"data_vintage,omitempty"`
+	LastImportedAt *time.Time `json:"last_imported_at,omitempty"`
+}
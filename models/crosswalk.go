@@ -0,0 +1,23 @@
+package models
+
+// ZipCountyMapping is the share of a ZIP code's population living in one of
+// the counties it overlaps. Weights for a ZIP sum to about 1.
+type ZipCountyMapping struct {
+	ZipCode    string  `json:"zip_code"`
+	CountyFIPS string  `json:"county_fips"`
+	CountyName string  `json:"county_name"`
+	StateCode  string  `json:"state_code"`
+	Weight     float64 `json:"weight"`
+}
+
+// ZipCBSAMapping is a row of the HUD USPS ZIP to CBSA crosswalk: the share
+// of a ZIP code's residential, business, other and total addresses in a Core
+// Based Statistical Area. CBSA 99999 collects addresses outside any CBSA.
+type ZipCBSAMapping struct {
+	ZipCode          string  `json:"zip_code"`
+	CBSACode         string  `json:"cbsa_code"`
+	ResidentialRatio float64 `json:"residential_ratio"`
+	BusinessRatio    float64 `json:"business_ratio"`
+	OtherRatio       float64 `json:"other_ratio"`
+	TotalRatio       float64 `json:"total_ratio"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CustomDataset is a private address list a user uploaded. Its addresses are
+// searched only with that user's API keys and come back with match source
+// "custom".
+type CustomDataset struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Filename string `json:"filename,omitempty"`
+	RowCount int    `json:"row_count"`
+	// SkippedRows lacked a point or both a house number and street
+	SkippedRows int       `json:"skipped_rows"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CustomAddressUsage is how many custom addresses a user stores against their
+// plan's limit; a Limit of -1 means unlimited
+type CustomAddressUsage struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
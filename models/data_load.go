@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Reference datasets that can be loaded from bundled or downloaded files
+const (
+	DataLoadZipCodes         = "zip_codes"
+	DataLoadCities           = "cities"
+	DataLoadStates           = "states"
+	DataLoadCountyBoundaries = "county_boundaries"
+	// DataLoadCensusBlockGroups isn't bundled; the TIGER/Line file is
+	// downloaded and converted to GeoJSON before loading
+	DataLoadCensusBlockGroups = "census_block_groups"
+	// DataLoadDistricts loads whichever TIGER/Line district layers have been
+	// downloaded and converted to GeoJSON
+	DataLoadDistricts = "districts"
+	// DataLoadZipCBSA is the HUD USPS ZIP to CBSA crosswalk, exported to CSV
+	DataLoadZipCBSA = "zip_cbsa"
+	// DataLoadZipDemographics is ACS income and household counts per ZCTA
+	// with population-weighted centroids, exported to CSV
+	DataLoadZipDemographics = "zip_demographics"
+	// DataLoadAddressRanges loads the TIGER/Line address range (ADDRFEAT)
+	// files that have been downloaded and converted to GeoJSON
+	DataLoadAddressRanges = "address_ranges"
+)
+
+// Data load statuses
+const (
+	DataLoadIdle      = "idle"
+	DataLoadRunning   = "running"
+	DataLoadCompleted = "completed"
+	DataLoadFailed    = "failed"
+)
+
+// DataLoadProgress reports a reference data load. Loads are idempotent, so
+// rows that are already present are counted as updated or skipped rather
+// than duplicated. A dry run reads and writes everything in a transaction
+// that is rolled back, so the counts show what a real load would change.
+type DataLoadProgress struct {
+	Dataset     string     `json:"dataset"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	DryRun      bool       `json:"dry_run"`
+	Source      string     `json:"source,omitempty"`
+	Processed   int        `json:"processed"`
+	Inserted    int        `json:"inserted"`
+	Updated     int        `json:"updated"`
+	Skipped     int        `json:"skipped"`
+	Invalid     int        `json:"invalid"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Data-quality check types
+const (
+	// DataQualityMissingRows means a county has fewer address rows than its
+	// completed datasets imported (manual deletes, failed partial loads)
+	DataQualityMissingRows = "missing_rows"
+	// DataQualityExtraRows means a county has more address rows than its
+	// completed datasets imported
+	DataQualityExtraRows = "extra_rows"
+)
+
+// IntegrityCheckRun is one execution of the address count integrity check
+type IntegrityCheckRun struct {
+	ID                int        `json:"id"`
+	TriggeredBy       string     `json:"triggered_by"` // "scheduled" or "manual"
+	Status            string     `json:"status"`       // "running", "completed" or "failed"
+	CountiesChecked   int        `json:"counties_checked"`
+	UntrackedCounties int        `json:"untracked_counties"`
+	Discrepancies     int        `json:"discrepancies"`
+	ErrorMessage      *string    `json:"error_message"`
+	StartedAt         time.Time  `json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at"`
+}
+
+// DataQualityIssue is a discrepancy found by an integrity check run
+type DataQualityIssue struct {
+	ID            int       `json:"id"`
+	RunID         int       `json:"run_id"`
+	CheckType     string    `json:"check_type"`
+	State         string    `json:"state"`
+	County        string    `json:"county"`
+	ExpectedCount int64     `json:"expected_count"`
+	ActualCount   int64     `json:"actual_count"`
+	Difference    int64     `json:"difference"` // actual - expected
+	DatasetIDs    []int64   `json:"dataset_ids"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+// DataQualityReport is a run together with the discrepancies it found
+type DataQualityReport struct {
+	Run    IntegrityCheckRun  `json:"run"`
+	Issues []DataQualityIssue `json:"issues"`
+}
@@ -1,22 +1,133 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Dataset represents an uploaded county address dataset
 type Dataset struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	State        string    `json:"state"`
-	County       string    `json:"county"`
-	FileType     string    `json:"file_type"` // geojson, shapefile, csv
-	FilePath     string    `json:"file_path"`
-	FileSize     int64     `json:"file_size"`
-	RecordCount  int       `json:"record_count"`
-	Status       string    `json:"status"` // pending, processing, completed, failed
-	ErrorMessage string    `json:"error_message,omitempty"`
-	UploadedBy   int       `json:"uploaded_by"`
-	UploadedAt   time.Time `json:"uploaded_at"`
-	ProcessedAt  *time.Time `json:"processed_at,omitempty"`
+	ID           int                 `json:"id"`
+	Name         string              `json:"name"`
+	State        string              `json:"state"`
+	County       string              `json:"county"`
+	FileType     string              `json:"file_type"` // geojson, shapefile, csv
+	FilePath     string              `json:"file_path"`
+	FileSize     int64               `json:"file_size"`
+	RecordCount  int                 `json:"record_count"`
+	Status       string              `json:"status"` // pending, validating, processing, completed, failed
+	ErrorMessage string              `json:"error_message,omitempty"`
+	UploadedBy   int                 `json:"uploaded_by"`
+	UploadedAt   time.Time           `json:"uploaded_at"`
+	ProcessedAt  *time.Time          `json:"processed_at,omitempty"`
+	FieldMapping DatasetFieldMapping `json:"field_mapping,omitempty"`
+	// ValidationReport is set once the dataset has been validated for import
+	ValidationReport *DatasetValidationReport `json:"validation_report,omitempty"`
+}
+
+// DatasetFieldMapping maps address fields (house_number, street, ...) to the
+// property names a county file uses. Unmapped fields are auto-detected.
+type DatasetFieldMapping map[string]string
+
+// DatasetMappableFields are the address fields a field mapping may set.
+// latitude and longitude name the coordinate columns of CSV uploads.
+var DatasetMappableFields = []string{"house_number", "street", "unit", "city", "postcode", "district", "latitude", "longitude"}
+
+// Validate rejects unknown address fields and empty property names
+func (m DatasetFieldMapping) Validate() error {
+	for field, property := range m {
+		known := false
+		for _, allowed := range DatasetMappableFields {
+			if field == allowed {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown field %q in field_mapping", field)
+		}
+		if property == "" {
+			return fmt.Errorf("field_mapping for %q must name a property", field)
+		}
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface for DatasetFieldMapping
+func (m DatasetFieldMapping) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for DatasetFieldMapping
+func (m *DatasetFieldMapping) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("unexpected field_mapping type %T", value)
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+// DatasetValidationReport records the validation pass run over a whole
+// dataset before it is imported. A dataset that doesn't pass is failed
+// without importing anything.
+type DatasetValidationReport struct {
+	Passed bool   `json:"passed"`
+	Format string `json:"format,omitempty"` // FeatureCollection, NDJSON or CSV
+	// FeatureCount is how many features or CSV rows the file holds
+	FeatureCount        int `json:"feature_count"`
+	AddressableFeatures int `json:"addressable_features"` // valid points with a house number and street
+	// InvalidRows is NoGeometry plus OutOfBounds
+	InvalidRows       int     `json:"invalid_rows"`
+	NoGeometry        int     `json:"no_geometry"`   // not a Point, or coordinates missing or out of range
+	OutOfBounds       int     `json:"out_of_bounds"` // outside the declared state's bounding box
+	InvalidPercent    float64 `json:"invalid_percent"`
+	MaxInvalidPercent float64 `json:"max_invalid_percent"`
+	// StateBounds is the declared state's [min_lng, min_lat, max_lng, max_lat];
+	// it is empty when the state's boundary isn't loaded
+	StateBounds []float64 `json:"state_bounds,omitempty"`
+	// InvalidExamples are the first few invalid rows, to show what's wrong
+	InvalidExamples []DatasetInvalidRow `json:"invalid_examples"`
+	// Errors say why the dataset didn't pass
+	Errors      []string  `json:"errors"`
+	Warnings    []string  `json:"warnings"`
+	ValidatedAt time.Time `json:"validated_at"`
+}
+
+// DatasetInvalidRow is a feature or CSV row, numbered from 1, that can't be imported
+type DatasetInvalidRow struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// Value implements the driver.Valuer interface for DatasetValidationReport
+func (r *DatasetValidationReport) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements the sql.Scanner interface for DatasetValidationReport
+func (r *DatasetValidationReport) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("unexpected validation_report type %T", value)
+	}
+	return json.Unmarshal(bytes, r)
 }
 
 // DatasetUploadRequest represents a request to upload a dataset
@@ -30,6 +141,8 @@ type DatasetUploadRequest struct {
 type DatasetListResponse struct {
 	Datasets []Dataset `json:"datasets"`
 	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
 }
 
 // DatasetStats represents statistics about uploaded datasets
@@ -40,3 +153,39 @@ type DatasetStats struct {
 	StatusBreakdown  map[string]int `json:"status_breakdown"`
 	TotalStorageSize int64          `json:"total_storage_size"`
 }
+
+// DatasetValidation is the result of inspecting an upload without importing it
+type DatasetValidation struct {
+	Filename             string                 `json:"filename"`
+	FileSize             int64                  `json:"file_size"`
+	Compressed           bool                   `json:"compressed"`
+	Format               string                 `json:"format"` // FeatureCollection, NDJSON or CSV
+	FieldMapping         DatasetFieldMapping    `json:"field_mapping,omitempty"`
+	BytesInspected       int64                  `json:"bytes_inspected"`
+	Truncated            bool                   `json:"truncated"` // inspection stopped at the byte limit
+	FeaturesInspected    int                    `json:"features_inspected"`
+	PointFeatures        int                    `json:"point_features"`
+	AddressableFeatures  int                    `json:"addressable_features"` // points with a house number and street
+	EstimatedRecordCount int                    `json:"estimated_record_count"`
+	Properties           []DatasetPropertyStat  `json:"properties"`
+	SampleAddresses      []DatasetSampleAddress `json:"sample_addresses"`
+	Warnings             []string               `json:"warnings"`
+}
+
+// DatasetPropertyStat counts how many inspected features carry a property
+type DatasetPropertyStat struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// DatasetSampleAddress is an address as the importer would parse it
+type DatasetSampleAddress struct {
+	HouseNumber string  `json:"house_number"`
+	Street      string  `json:"street"`
+	Unit        string  `json:"unit"`
+	City        string  `json:"city"`
+	Postcode    string  `json:"postcode"`
+	District    string  `json:"district"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
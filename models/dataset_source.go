@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Dataset source refresh outcomes
+const (
+	SourceUnchanged = "unchanged" // upstream data is the same as the last download
+	SourceUpdated   = "updated"   // new data was downloaded and imported
+	SourceFailed    = "failed"    // the check, download or import failed
+)
+
+// DatasetSource is an upstream OpenAddresses source kept up to date by the
+// dataset refresh scheduler
+type DatasetSource struct {
+	ID            int        `json:"id"`
+	State         string     `json:"state"`
+	County        string     `json:"county"`
+	ConfigURL     string     `json:"config_url"`
+	DataURL       *string    `json:"data_url"`
+	ETag          *string    `json:"etag"`
+	LastModified  *string    `json:"last_modified"`
+	ContentSHA256 *string    `json:"content_sha256"`
+	LastStatus    *string    `json:"last_status"` // unchanged, updated or failed; null until first checked
+	LastError     *string    `json:"last_error"`
+	DatasetID     *int       `json:"dataset_id"` // the dataset imported by the last update
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	LastChangedAt *time.Time `json:"last_changed_at"`
+	NextCheckAt   time.Time  `json:"next_check_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// DatasetSourceRefreshRequest checks sources now instead of waiting for the
+// schedule: one county, every county of a state, or every tracked source
+type DatasetSourceRefreshRequest struct {
+	State  string `json:"state" validate:"omitempty,len=2"`
+	County string `json:"county" validate:"omitempty,max=255"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// Duplicate match types: the same normalized address near each other, or
+// points with the same unit within the run's distance
+const (
+	DedupeMatchAddress  = "address"
+	DedupeMatchGeometry = "geometry"
+)
+
+// Duplicate resolutions
+const (
+	DedupeMerge   = "merge"   // copy fields the kept address lacks, then delete the duplicate
+	DedupeDelete  = "delete"  // delete the duplicate
+	DedupeDismiss = "dismiss" // not a duplicate; keep both
+)
+
+// DedupeRequest starts a duplicate detection run
+type DedupeRequest struct {
+	// DistanceMeters is how close two points from different datasets must be
+	// to count as the same point; 0 uses the default of 1
+	DistanceMeters float64 `json:"distance_meters" validate:"gte=0,lte=100"`
+}
+
+// DedupeActionRequest resolves open duplicates, either those listed in IDs
+// or every one found by run RunID
+type DedupeActionRequest struct {
+	Action string  `json:"action" validate:"required,oneof=merge delete dismiss"`
+	IDs    []int64 `json:"ids" validate:"max=1000"`
+	RunID  int     `json:"run_id" validate:"gte=0"`
+}
+
+// DedupeRun is one execution of duplicate detection
+type DedupeRun struct {
+	ID             int        `json:"id"`
+	Status         string     `json:"status"` // running, completed or failed
+	DistanceMeters float64    `json:"distance_meters"`
+	StartedBy      *int       `json:"started_by"`
+	Duplicates     int        `json:"duplicates"`
+	ErrorMessage   *string    `json:"error_message"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+}
+
+// DedupeAddress is one side of a duplicate. Address fields are null once
+// the address has been deleted.
+type DedupeAddress struct {
+	AddressID   int64    `json:"address_id"`
+	DatasetID   *int     `json:"dataset_id"` // null for addresses not loaded from a dataset
+	State       string   `json:"state"`
+	County      string   `json:"county"`
+	HouseNumber *string  `json:"house_number"`
+	Street      *string  `json:"street"`
+	Unit        *string  `json:"unit"`
+	City        *string  `json:"city"`
+	Postcode    *string  `json:"postcode"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+}
+
+// AddressDuplicate is a pair of addresses from different datasets that look
+// like the same point. Keep is from the smaller, more specific dataset.
+type AddressDuplicate struct {
+	ID             int64         `json:"id"`
+	RunID          int           `json:"run_id"`
+	MatchType      string        `json:"match_type"`
+	DistanceMeters float64       `json:"distance_meters"`
+	Keep           DedupeAddress `json:"keep"`
+	Duplicate      DedupeAddress `json:"duplicate"`
+	Resolution     *string       `json:"resolution"` // merged, deleted or dismissed; null while open
+	ResolvedAt     *time.Time    `json:"resolved_at"`
+}
+
+// DedupeDatasetOverlap counts the duplicates between two datasets
+type DedupeDatasetOverlap struct {
+	State           string `json:"state"`
+	KeepCounty      string `json:"keep_county"`
+	DuplicateCounty string `json:"duplicate_county"`
+	Duplicates      int    `json:"duplicates"`
+	Open            int    `json:"open"`
+}
+
+// DedupeReport is a run, its duplicates summarized by dataset pair, and one
+// page of the duplicates themselves
+type DedupeReport struct {
+	Run        DedupeRun              `json:"run"`
+	Overlaps   []DedupeDatasetOverlap `json:"overlaps"`
+	Duplicates []AddressDuplicate     `json:"duplicates"`
+}
+
+// DedupeActionResult reports what a resolution changed
+type DedupeActionResult struct {
+	Action           string `json:"action"`
+	Resolved         int    `json:"resolved"`
+	AddressesDeleted int    `json:"addresses_deleted"`
+	AddressesMerged  int    `json:"addresses_merged"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// District types, one per TIGER/Line district layer
+const (
+	DistrictCongressional    = "congressional"
+	DistrictStateUpper       = "state_upper"
+	DistrictStateLower       = "state_lower"
+	DistrictSchoolUnified    = "school_unified"
+	DistrictSchoolElementary = "school_elementary"
+	DistrictSchoolSecondary  = "school_secondary"
+)
+
+// District is a congressional, state legislative or school district. Code
+// is the district number, or the NCES LEA ID for school districts.
+type District struct {
+	Type      string `json:"type"`
+	GEOID     string `json:"geoid"`
+	StateFIPS string `json:"state_fips"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+}
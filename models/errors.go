@@ -0,0 +1,61 @@
+package models
+
+import "net/http"
+
+// Machine-readable error codes sent in the "code" field of error responses.
+// Clients should branch on these rather than on the free-text error message.
+const (
+	ErrCodeInvalidRequest     = "INVALID_REQUEST"
+	ErrCodeValidationFailed   = "VALIDATION_FAILED"
+	ErrCodeInvalidZip         = "INVALID_ZIP"
+	ErrCodeInvalidCoordinates = "INVALID_COORDINATES"
+	ErrCodeUnauthorized       = "UNAUTHORIZED"
+	ErrCodeInvalidAPIKey      = "INVALID_API_KEY"
+	ErrCodeInvalidToken       = "INVALID_TOKEN"
+	ErrCodePermissionDenied   = "PERMISSION_DENIED"
+	ErrCodeEmailNotVerified   = "EMAIL_NOT_VERIFIED"
+	ErrCodeNotFound           = "NOT_FOUND"
+	ErrCodeConflict           = "CONFLICT"
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	ErrCodeQuotaExceeded      = "QUOTA_EXCEEDED"
+	ErrCodeRateLimited        = "RATE_LIMITED"
+	ErrCodeInternal           = "INTERNAL_ERROR"
+	ErrCodeUnavailable        = "SERVICE_UNAVAILABLE"
+	ErrCodeNotImplemented     = "NOT_IMPLEMENTED"
+)
+
+// ErrorCodeForStatus returns the generic error code for an HTTP status, for
+// errors that have no more specific code
+func ErrorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		return ErrCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodePermissionDenied
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return ErrCodeNotFound
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return ErrCodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	case http.StatusNotImplemented:
+		return ErrCodeNotImplemented
+	}
+	if status >= 500 {
+		return ErrCodeInternal
+	}
+	return ErrCodeInvalidRequest
+}
+
+// FieldError describes one request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
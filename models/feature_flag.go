@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// FlagSearchRankedResults orders ZIP code city searches by how closely the
+// city name matches, exact matches first, instead of alphabetically
+const FlagSearchRankedResults = "search_ranked_results"
+
+// FeatureFlag controls rollout of an alternate implementation. A flag is on for
+// a request when it is enabled and either the API key is explicitly listed or
+// the key falls inside the rollout percentage bucket.
+type FeatureFlag struct {
+	Key            string    `json:"key"`
+	Description    string    `json:"description,omitempty"`
+	Enabled        bool      `json:"enabled"`
+	RolloutPercent int       `json:"rollout_percent"`
+	APIKeyIDs      []int64   `json:"api_key_ids"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// FeatureFlagStats summarizes usage recorded with a flag on versus off
+type FeatureFlagStats struct {
+	Variant           string  `json:"variant"` // "on" or "off"
+	Requests          int     `json:"requests"`
+	AvgResponseTimeMs float64 `json:"avg_response_time_ms"`
+	ErrorRate         float64 `json:"error_rate"`
+}
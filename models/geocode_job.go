@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Geocode job statuses
+const (
+	GeocodeJobQueued    = "queued"
+	GeocodeJobRunning   = "running"
+	GeocodeJobCompleted = "completed"
+	GeocodeJobFailed    = "failed"
+	GeocodeJobCancelled = "cancelled"
+)
+
+// Geocode job item statuses
+const (
+	GeocodeItemPending  = "pending"
+	GeocodeItemMatched  = "matched"
+	GeocodeItemNotFound = "not_found"
+	GeocodeItemError    = "error"
+)
+
+// Geocode job match levels, from most to least precise
+const (
+	GeocodeMatchAddress      = "address"      // rooftop address matched the query
+	GeocodeMatchNearby       = "nearby"       // another address on the same street
+	GeocodeMatchInterpolated = "interpolated" // estimated from a TIGER/Line address range
+	GeocodeMatchStreet       = "street"       // street centroid from the street index
+	GeocodeMatchCounty       = "county"       // county centroid
+)
+
+// GeocodeJob is an asynchronous bulk geocoding request
+type GeocodeJob struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	APIKeyID      *int       `json:"api_key_id,omitempty"`
+	Filename      string     `json:"filename,omitempty"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	MatchedRows   int        `json:"matched_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+// Done reports whether the job has stopped processing
+func (j *GeocodeJob) Done() bool {
+	return j.Status == GeocodeJobCompleted || j.Status == GeocodeJobFailed || j.Status == GeocodeJobCancelled
+}
+
+// GeocodeJobResult is the outcome of geocoding one row of a job
+type GeocodeJobResult struct {
+	Row         int      `json:"row"`
+	Query       string   `json:"query"`
+	Status      string   `json:"status"`
+	MatchLevel  string   `json:"match_level,omitempty"`
+	AddressID   *int64   `json:"address_id,omitempty"`
+	FullAddress string   `json:"full_address,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// GeocodeJobEvent is a progress update published while a job runs. Results
+// holds the rows finished since the previous event.
+type GeocodeJobEvent struct {
+	Type    string             `json:"type"` // "progress" or "complete"
+	Job     *GeocodeJob        `json:"job"`
+	Results []GeocodeJobResult `json:"results,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Geofence is a named polygon owned by a user, used to test whether points
+// fall inside a delivery zone or service area
+type Geofence struct {
+	ID          int    `json:"id"`
+	UserID      int    `json:"user_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Geometry is the GeoJSON MultiPolygon, omitted from listings
+	Geometry    json.RawMessage `json:"geometry,omitempty"`
+	AreaSqMiles float64         `json:"area_sq_miles"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// GeofenceRequest is the payload for creating or replacing a geofence.
+// Geometry is a GeoJSON Polygon or MultiPolygon, or a Feature holding one.
+type GeofenceRequest struct {
+	Name        string          `json:"name" validate:"required,max=255"`
+	Description string          `json:"description"`
+	Geometry    json.RawMessage `json:"geometry" validate:"required"`
+}
+
+// GeofenceContainment reports whether a point is inside a geofence and, if
+// not, how far it is from the boundary
+type GeofenceContainment struct {
+	GeofenceID     int     `json:"geofence_id"`
+	Lat            float64 `json:"lat"`
+	Lng            float64 `json:"lng"`
+	Inside         bool    `json:"inside"`
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// GeofenceZipCode is a ZIP code whose center lies inside a geofence
+type GeofenceZipCode struct {
+	ZipCode   string  `json:"zip_code"`
+	CityName  string  `json:"city_name"`
+	StateCode string  `json:"state_code"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Health check statuses, for single dependencies and the service overall
+const (
+	HealthOK        = "ok"
+	HealthDegraded  = "degraded"
+	HealthDown      = "down"
+	HealthHealthy   = "healthy"
+	HealthUnhealthy = "unhealthy"
+)
+
+// DependencyHealth is the result of one health check. Details are only
+// filled in for verbose health checks.
+type DependencyHealth struct {
+	Status  string                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// WorkerStatus is the liveness of a polling background worker. A worker is
+// alive until it misses several polls in a row.
+type WorkerStatus struct {
+	Name            string    `json:"name"`
+	Alive           bool      `json:"alive"`
+	LastHeartbeat   time.Time `json:"last_heartbeat"`
+	IntervalSeconds float64   `json:"interval_seconds"`
+}
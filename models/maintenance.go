@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Maintenance operations run by POST /admin/maintenance
+const (
+	MaintenanceReindex       = "reindex"
+	MaintenanceVacuumAnalyze = "vacuum_analyze"
+	MaintenanceRefreshViews  = "refresh_views"
+)
+
+// Maintenance run and step statuses
+const (
+	MaintenanceIdle      = "idle"
+	MaintenancePending   = "pending"
+	MaintenanceRunning   = "running"
+	MaintenanceCompleted = "completed"
+	MaintenanceFailed    = "failed"
+)
+
+// MaintenanceRequest names the operations to run, in order
+type MaintenanceRequest struct {
+	Operations []string `json:"operations" validate:"required,min=1,dive,oneof=reindex vacuum_analyze refresh_views"`
+}
+
+// MaintenanceStep is one statement of a maintenance run, e.g. reindexing one
+// index
+type MaintenanceStep struct {
+	Operation  string `json:"operation"`
+	Target     string `json:"target"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// MaintenanceRun reports a maintenance run. Steps are listed up front and
+// run in order; a failed step is recorded and the rest still run.
+type MaintenanceRun struct {
+	Status     string            `json:"status"`
+	Operations []string          `json:"operations,omitempty"`
+	Steps      []MaintenanceStep `json:"steps"`
+	Error      string            `json:"error,omitempty"`
+	StartedBy  string            `json:"started_by,omitempty"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Match types, from most to least precise
+const (
+	MatchTypeRooftop        = "rooftop"         // an address point with the house number
+	MatchTypeInterpolated   = "interpolated"    // estimated along a TIGER/Line address range
+	MatchTypeStreet         = "street"          // the right street, not the house number
+	MatchTypePostcode       = "postcode"        // an address point in the right ZIP code
+	MatchTypeLocality       = "locality"        // an address point in the right city
+	MatchTypeZipCentroid    = "zip_centroid"    // the centroid of a ZIP code
+	MatchTypeCountyCentroid = "county_centroid" // the centroid of a county
+)
+
+// Match sources: the data a result was drawn from
+const (
+	MatchSourceAddresses     = "addresses"            // uploaded county address points
+	MatchSourceStreets       = "streets"              // the street index built from address points
+	MatchSourceAddressRanges = "tiger_address_ranges" // TIGER/Line ADDRFEAT ranges
+	MatchSourceZipCodes      = "zip_codes"
+	MatchSourceCounties      = "county_boundaries"
+	MatchSourceCustom        = "custom" // the user's own uploaded address list
+)
+
+// Match describes how a geocode result was found and how far to trust it.
+// Score runs from 0 to 100: 100 is a rooftop match on every component
+// given, while centroids of a ZIP code or county score lowest.
+type Match struct {
+	Score             int      `json:"score"`
+	MatchType         string   `json:"match_type"`
+	MatchedComponents []string `json:"matched_components"` // house_number, street, city, state, postcode, county or location
+	Source            string   `json:"source"`
+	// DatasetID is the uploaded dataset an address point came from, if known
+	DatasetID *int `json:"dataset_id,omitempty"`
+	// DataVintage is when the source data was last imported or loaded
+	DataVintage *time.Time `json:"data_vintage"`
+}
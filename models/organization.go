@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Organization member roles. Owners manage members and keys, members create
+// and use API keys, billing members see usage and limits.
+const (
+	OrgRoleOwner   = "owner"
+	OrgRoleMember  = "member"
+	OrgRoleBilling = "billing"
+)
+
+// OrgRoles lists the valid organization member roles
+var OrgRoles = []string{OrgRoleOwner, OrgRoleMember, OrgRoleBilling}
+
+// Organization is a team account whose members share API keys and quota
+type Organization struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	PlanType     string    `json:"plan_type"`
+	MonthlyLimit *int      `json:"monthly_limit,omitempty"` // overrides the plan's monthly limit
+	CreatedBy    *int      `json:"created_by,omitempty"`
+	Role         string    `json:"role,omitempty"` // the requesting user's role
+	MemberCount  int       `json:"member_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// OrganizationMember is a user's membership in an organization
+type OrganizationMember struct {
+	UserID   int       `json:"user_id"`
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// OrganizationInvitation is a pending invitation sent by email
+type OrganizationInvitation struct {
+	ID             int        `json:"id"`
+	OrganizationID int        `json:"organization_id"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	InvitedBy      *int       `json:"invited_by,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+}
+
+// OrganizationUsage is an organization's pooled usage against its plan limits
+type OrganizationUsage struct {
+	OrganizationID int                 `json:"organization_id"`
+	PlanType       string              `json:"plan_type"`
+	MonthlyUsage   int                 `json:"monthly_usage"`
+	MonthlyLimit   int                 `json:"monthly_limit"` // -1 means unlimited
+	DailyUsage     int                 `json:"daily_usage"`
+	DailyLimit     int                 `json:"daily_limit"` // -1 means unlimited
+	ByMember       []MemberUsageSample `json:"by_member"`
+}
+
+// MemberUsageSample is one member's share of an organization's monthly usage
+type MemberUsageSample struct {
+	UserID   int    `json:"user_id"`
+	Email    string `json:"email"`
+	Requests int    `json:"requests"`
+}
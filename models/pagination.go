@@ -0,0 +1,12 @@
+package models
+
+// Pagination is the paging envelope shared by list endpoints. Next and Prev
+// are absolute URLs for the neighbouring pages and are null at either end, or
+// when the list was requested with a JSON body rather than a query string.
+type Pagination struct {
+	Total  int     `json:"total"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Next   *string `json:"next"`
+	Prev   *string `json:"prev"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// DefaultPlanID is the plan new accounts and organizations start on
+const DefaultPlanID = "free"
+
+// Plan is a pricing plan. Limits of -1 mean unlimited; prices are in dollars.
+type Plan struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	MonthlyLimit int       `json:"monthly_limit"`
+	DailyLimit   int       `json:"daily_limit"`
+	PricePerCall float64   `json:"price_per_call"`
+	PriceMonthly float64   `json:"price_monthly"`
+	Features     []string  `json:"features"`
+	IsPublic     bool      `json:"is_public"` // listed by /auth/plans
+	SortOrder    int       `json:"sort_order"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// CustomAddressLimit caps the rows of the private address lists a user
+	// on the plan may upload
+	CustomAddressLimit int `json:"custom_address_limit"`
+}
+
+// UserSubscription is a user's current plan with its effective limits and the
+// usage counted against them. Limits of -1 mean unlimited.
+type UserSubscription struct {
+	Plan   Plan   `json:"plan"`
+	Status string `json:"status"`
+	// PeriodStart and PeriodEnd bound the current billing period, the
+	// calendar month (UTC) that monthly usage is counted over
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	MonthlyLimit int       `json:"monthly_limit"`
+	DailyLimit   int       `json:"daily_limit"`
+	MonthlyUsage int       `json:"monthly_usage"`
+	DailyUsage   int       `json:"daily_usage"`
+	// CustomAddresses counts the rows of the user's private address lists
+	CustomAddresses int `json:"custom_addresses"`
+}
+
+// PlanChangeRequest asks to move the authenticated user to another plan
+type PlanChangeRequest struct {
+	PlanType string `json:"plan_type" validate:"required,max=50"`
+}
+
+// PlanChangePreview describes what moving to another plan would cost and
+// whether it's allowed now
+type PlanChangePreview struct {
+	CurrentPlan string        `json:"current_plan"`
+	NewPlan     string        `json:"new_plan"`
+	Proration   PlanProration `json:"proration"`
+	Allowed     bool          `json:"allowed"`
+	// Blockers explain why a downgrade isn't allowed yet; empty when it is
+	Blockers []PlanChangeBlocker `json:"blockers"`
+}
+
+// PlanProration splits the monthly prices of the current and new plans at
+// the moment of the change. Per-call charges are billed on usage and aren't
+// included. Amounts are in dollars.
+type PlanProration struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// RemainingFraction is the share of the billing period still to come
+	RemainingFraction float64 `json:"remaining_fraction"`
+	// Credit is the unused part of the current plan's monthly price
+	Credit float64 `json:"credit"`
+	// Charge is the new plan's monthly price for the rest of the period
+	Charge float64 `json:"charge"`
+	// AmountDue is Charge less Credit; a negative amount is credited
+	AmountDue float64 `json:"amount_due"`
+}
+
+// PlanChangeBlocker is usage that doesn't fit under a lower limit of the
+// new plan
+type PlanChangeBlocker struct {
+	// Limit is monthly_limit, daily_limit or custom_address_limit
+	Limit    string `json:"limit"`
+	Usage    int    `json:"usage"`
+	NewLimit int    `json:"new_limit"`
+	Message  string `json:"message"`
+}
+
+// OverageSettings is a user's opt-in to keep calling past their plan's
+// monthly limit, with what they've spent on overage this billing period
+type OverageSettings struct {
+	// Available is false while overage billing is turned off for the
+	// deployment or the user's plan has no per-call price
+	Available bool `json:"available"`
+	Enabled   bool `json:"enabled"`
+	// SpendCap is the most overage the user will pay for in a billing
+	// period, in dollars
+	SpendCap     *float64 `json:"spend_cap"`
+	PricePerCall float64  `json:"price_per_call"`
+	OverageCalls int      `json:"overage_calls"`
+	// OverageSpend prices the overage calls at their endpoint costs
+	OverageSpend float64 `json:"overage_spend"`
+}
+
+// OverageSettingsRequest opts the authenticated user in to or out of overage
+// billing. A spend cap is required to opt in.
+type OverageSettingsRequest struct {
+	Enabled  *bool    `json:"enabled" validate:"required"`
+	SpendCap *float64 `json:"spend_cap" validate:"omitempty,gt=0"`
+}
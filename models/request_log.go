@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RequestRecord is a recorded API call in the admin request console. Path
+// and QueryString are null for calls recorded without request capture.
+type RequestRecord struct {
+	ID             int64     `json:"id"`
+	UserID         *int      `json:"user_id"`
+	APIKeyID       *int      `json:"api_key_id"`
+	Endpoint       string    `json:"endpoint"`
+	Method         string    `json:"method"`
+	Path           *string   `json:"path"`
+	QueryString    *string   `json:"query_string"`
+	StatusCode     *int      `json:"status_code"`
+	ResponseTimeMS *int      `json:"response_time_ms"`
+	IPAddress      *string   `json:"ip_address"`
+	UserAgent      *string   `json:"user_agent"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// RequestLogFilter selects recorded calls, newest first. MinStatus and
+// MaxStatus bound the status code when set.
+type RequestLogFilter struct {
+	UserID    int
+	MinStatus int
+	MaxStatus int
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+// RequestReplayResult is the response to a replayed call
+type RequestReplayResult struct {
+	StatusCode     int             `json:"status_code"`
+	ResponseTimeMS int             `json:"response_time_ms"`
+	Body           json.RawMessage `json:"body"`
+}
+
+// RequestDifference is a field whose value changed on replay
+type RequestDifference struct {
+	Field    string      `json:"field"`
+	Original interface{} `json:"original"`
+	Replay   interface{} `json:"replay"`
+}
+
+// RequestReplay compares a recorded call with its replay
+type RequestReplay struct {
+	Original RequestRecord       `json:"original"`
+	Replay   RequestReplayResult `json:"replay"`
+	Diff     []RequestDifference `json:"diff"`
+}
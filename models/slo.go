@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// SLOLatencyBudget is the share of requests an endpoint may serve slower
+// than its p95 latency target
+const SLOLatencyBudget = 0.05
+
+// SLOTarget is the latency and error rate objective for one endpoint, named
+// as in usage records (e.g. "geocode", "search")
+type SLOTarget struct {
+	Endpoint     string    `json:"endpoint"`
+	P95LatencyMS int       `json:"p95_latency_ms" validate:"required,min=1"`
+	MaxErrorRate float64   `json:"max_error_rate" validate:"gt=0,lt=1"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SLOWindow is an endpoint's performance over a rolling window. A burn rate
+// of 1 spends the error budget exactly as fast as the target allows; server
+// errors (5xx) count against the error rate, client errors don't.
+type SLOWindow struct {
+	Window          string  `json:"window"`
+	Requests        int64   `json:"requests"`
+	P95LatencyMS    float64 `json:"p95_latency_ms"`
+	ErrorRate       float64 `json:"error_rate"`
+	SlowRate        float64 `json:"slow_rate"`
+	LatencyBurnRate float64 `json:"latency_burn_rate"`
+	ErrorBurnRate   float64 `json:"error_burn_rate"`
+}
+
+// SLOStatus reports an endpoint against its target: compliance over the
+// reporting window and burn rates over the shorter alert window
+type SLOStatus struct {
+	Target      SLOTarget  `json:"target"`
+	Compliant   bool       `json:"compliant"`
+	Alerting    bool       `json:"alerting"`
+	Window      SLOWindow  `json:"window"`
+	AlertWindow SLOWindow  `json:"alert_window"`
+	LastAlertAt *time.Time `json:"last_alert_at"`
+}
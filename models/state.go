@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // State represents a US state with boundary geometry
 type State struct {
@@ -30,14 +33,16 @@ type StateWithGeometry struct {
 
 // StateSearchParams represents search parameters for states
 type StateSearchParams struct {
-	Name       string  `query:"name"`
-	Abbr       string  `query:"abbr"`
-	Region     string  `query:"region"`
-	Division   string  `query:"division"`
-	Lat        float64 `query:"lat"`
-	Lng        float64 `query:"lng"`
-	Limit      int     `query:"limit"`
-	Offset     int     `query:"offset"`
+	Name       string  `query:"name" json:"name"`
+	Abbr       string  `query:"abbr" json:"abbr"`
+	Region     string  `query:"region" json:"region"`
+	Division   string  `query:"division" json:"division"`
+	Lat        float64 `query:"lat" json:"lat"`
+	Lng        float64 `query:"lng" json:"lng"`
+	Limit      int     `query:"limit" json:"limit"`
+	Offset     int     `query:"offset" json:"offset"`
+	// BBox keeps to states overlapping [min_lng, min_lat, max_lng, max_lat]
+	BBox []float64 `json:"bbox,omitempty"`
 }
 
 // StateResponse wraps state data for API responses
@@ -53,3 +58,41 @@ type StateSearchResponse struct {
 	Limit  int     `json:"limit"`
 	Offset int     `json:"offset"`
 }
+
+// StateLookupResponse is the reverse-geocode result for a coordinate pair
+type StateLookupResponse struct {
+	State       *State      `json:"state"`
+	Coordinates Coordinates `json:"coordinates"`
+}
+
+// Coordinates is a lat/lng pair echoed back in lookup responses
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// StateErrorResponse reports a failed state request. Identifier and the
+// coordinates are only present when the request supplied them.
+type StateErrorResponse struct {
+	Error      string   `json:"error"`
+	Code       string   `json:"code,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+	Lat        *float64 `json:"lat,omitempty"`
+	Lng        *float64 `json:"lng,omitempty"`
+}
+
+// StateBoundaryFeature is a state boundary as a GeoJSON Feature
+type StateBoundaryFeature struct {
+	Type       string                  `json:"type"`
+	Properties StateBoundaryProperties `json:"properties"`
+	Geometry   json.RawMessage         `json:"geometry"`
+}
+
+// StateBoundaryProperties are the GeoJSON properties of a state boundary
+type StateBoundaryProperties struct {
+	StateAbbr string `json:"state_abbr"`
+	StateName string `json:"state_name"`
+	StateFIPS string `json:"state_fips"`
+	AreaLand  int64  `json:"area_land"`
+	AreaWater int64  `json:"area_water"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Street represents a street segment aggregated from addresses (street + city + ZIP)
+type Street struct {
+	ID             int64     `json:"id"`
+	Street         string    `json:"street"`
+	City           string    `json:"city"`
+	Postcode       string    `json:"postcode"`
+	County         string    `json:"county,omitempty"`
+	Region         string    `json:"region,omitempty"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	AddressCount   int       `json:"address_count"`
+	MinHouseNumber *int      `json:"min_house_number,omitempty"`
+	MaxHouseNumber *int      `json:"max_house_number,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Match          *Match    `json:"match,omitempty"`
+}
+
+// StreetSearchParams represents search parameters for street lookups
+type StreetSearchParams struct {
+	Query    string `json:"q"`
+	City     string `json:"city"`
+	Postcode string `json:"postcode"`
+	County   string `json:"county"`
+	Limit    int    `json:"limit"`
+	Offset   int    `json:"offset"`
+}
+
+// StreetSearchResponse represents the response for street search requests
+type StreetSearchResponse struct {
+	Success bool                   `json:"success"`
+	Data    []Street               `json:"data,omitempty"`
+	Count   int                    `json:"count,omitempty"`
+	Total   int                    `json:"total,omitempty"`
+	Query   string                 `json:"query,omitempty"`
+	Filters map[string]interface{} `json:"filters,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+	Code    string                 `json:"code,omitempty"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Usage comparison periods
+const (
+	UsagePeriodDay   = "day"
+	UsagePeriodWeek  = "week"
+	UsagePeriodMonth = "month"
+)
+
+// UsagePeriodTotals is the call volume within one period window
+type UsagePeriodTotals struct {
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	TotalCalls    int       `json:"total_calls"`
+	BillableCalls int       `json:"billable_calls"`
+}
+
+// EndpointUsageDelta compares one endpoint's calls across two periods.
+// GrowthPercent is null when the endpoint had no calls in the previous period.
+type EndpointUsageDelta struct {
+	Endpoint      string   `json:"endpoint"`
+	CurrentCalls  int      `json:"current_calls"`
+	PreviousCalls int      `json:"previous_calls"`
+	Delta         int      `json:"delta"`
+	GrowthPercent *float64 `json:"growth_percent"`
+}
+
+// UsageComparison compares the current period to date with the same elapsed
+// span of the previous period, so a comparison made mid-month is like for
+// like. PreviousFullPeriodCalls is the whole previous period for reference.
+type UsageComparison struct {
+	Period                  string               `json:"period"`
+	UserID                  *int                 `json:"user_id"` // null when covering all users
+	Current                 UsagePeriodTotals    `json:"current"`
+	Previous                UsagePeriodTotals    `json:"previous"`
+	PreviousFullPeriodCalls int                  `json:"previous_full_period_calls"`
+	Delta                   int                  `json:"delta"`
+	GrowthPercent           *float64             `json:"growth_percent"`
+	BillableGrowthPercent   *float64             `json:"billable_growth_percent"`
+	Endpoints               []EndpointUsageDelta `json:"endpoints"`
+}
+
+// Usage time-series granularities
+const (
+	UsageGranularityHour = "hour"
+	UsageGranularityDay  = "day"
+	UsageGranularityWeek = "week"
+)
+
+// UsageTimeSeriesPoint is the usage within one time bucket. Latency
+// percentiles are approximate, read from a response time histogram.
+type UsageTimeSeriesPoint struct {
+	Start             time.Time `json:"start"`
+	TotalCalls        int       `json:"total_calls"`
+	BillableCalls     int       `json:"billable_calls"`
+	ErrorCalls        int       `json:"error_calls"`
+	ErrorRate         float64   `json:"error_rate"`
+	AvgResponseTimeMs float64   `json:"avg_response_time_ms"`
+	P50ResponseTimeMs int       `json:"p50_response_time_ms"`
+	P95ResponseTimeMs int       `json:"p95_response_time_ms"`
+	P99ResponseTimeMs int       `json:"p99_response_time_ms"`
+}
+
+// UsageTimeSeries is a user's usage bucketed by granularity over [From, To).
+// Buckets are aligned to UTC and every bucket in range is present, including
+// empty ones.
+type UsageTimeSeries struct {
+	Granularity string                 `json:"granularity"`
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to"`
+	Points      []UsageTimeSeriesPoint `json:"points"`
+}
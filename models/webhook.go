@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook event types
+const (
+	WebhookEventQuotaWarning        = "quota.warning"  // 80% of the daily or monthly limit used
+	WebhookEventQuotaExceeded       = "quota.exceeded" // 100% of the daily or monthly limit used
+	WebhookEventAPIKeyCreated       = "api_key.created"
+	WebhookEventAPIKeyDeleted       = "api_key.deleted"
+	WebhookEventDatasetCompleted    = "dataset.completed"
+	WebhookEventDataQualityDrift    = "data_quality.drift" // admins only; integrity check found discrepancies
+	WebhookEventGeocodeJobCompleted = "geocode_job.completed"
+	WebhookEventSLOBurnRate         = "slo.burn_rate"            // admins only; an endpoint is spending its SLO budget too fast
+	WebhookEventSourceRefreshed     = "dataset_source.refreshed" // admins only; a tracked source changed upstream, or its refresh failed
+	WebhookEventAddressChanged      = "address.changed"          // an import added or removed addresses inside an address subscription
+	WebhookEventTest                = "webhook.test"
+)
+
+// WebhookEvents lists the events a webhook may subscribe to; "*" subscribes to all
+var WebhookEvents = []string{
+	WebhookEventQuotaWarning,
+	WebhookEventQuotaExceeded,
+	WebhookEventAPIKeyCreated,
+	WebhookEventAPIKeyDeleted,
+	WebhookEventDatasetCompleted,
+	WebhookEventDataQualityDrift,
+	WebhookEventGeocodeJobCompleted,
+	WebhookEventSLOBurnRate,
+	WebhookEventSourceRefreshed,
+	WebhookEventAddressChanged,
+}
+
+// Webhook is a user-registered endpoint that receives signed event notifications.
+// Each delivery is POSTed with an X-Webhook-Signature header of the form
+// "t=<unix timestamp>,v1=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed by Secret>".
+type Webhook struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"` // only returned on creation
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookRequest is the payload for creating or updating a webhook
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// WebhookDelivery records one event queued for a webhook and its delivery attempts
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"` // pending, delivered, failed
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook URL
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -29,6 +29,36 @@ type ZipCode struct {
 	Timezone            string         `json:"timezone" db:"timezone"`
 	Latitude            float64        `json:"latitude" db:"latitude"`
 	Longitude           float64        `json:"longitude" db:"longitude"`
+	// Census is the geography of the ZIP's center, with ?include=census
+	Census              *CensusGeography `json:"census,omitempty" db:"-"`
+	// Demographics are the ZIP's ACS figures, with ?include=demographics
+	Demographics        *ZipDemographics `json:"demographics,omitempty" db:"-"`
+	// Match describes the ZIP's centroid as a geocode result, on single ZIP lookups
+	Match               *Match           `json:"match,omitempty" db:"-"`
+}
+
+// IncludeDemographics is the ?include= value that adds Census demographics
+// to ZIP code results
+const IncludeDemographics = "demographics"
+
+// ZipDemographics are American Community Survey figures for a ZIP code's
+// ZCTA. Fields the Census suppressed for the ZCTA are null.
+type ZipDemographics struct {
+	Households            *int `json:"households"`
+	MedianHouseholdIncome *int `json:"median_household_income"`
+	// PopulationCentroid is the population-weighted center, which can sit
+	// well away from the geographic latitude and longitude in rural ZIPs
+	PopulationCentroid *Coordinates `json:"population_centroid"`
+}
+
+// CorrectedQuery reports the spelling a ZIP search fell back to when the
+// requested city or state matched nothing, e.g. "Cincinatti" -> "Cincinnati"
+type CorrectedQuery struct {
+	City          string  `json:"city"`
+	State         string  `json:"state,omitempty"`
+	OriginalCity  string  `json:"original_city"`
+	OriginalState string  `json:"original_state,omitempty"`
+	Similarity    float64 `json:"similarity"`
 }
 
 // CountyWeights represents the JSON structure for county weights
@@ -144,4 +174,17 @@ func ParseStringArray(str string) StringArray {
 		return StringArray{}
 	}
 	return strings.Split(str, ",")
-}
\ No newline at end of file
+}
+
+// ZipCodeRefreshSummary reports what a refresh from the upstream source changed
+type ZipCodeRefreshSummary struct {
+	SourceURL   string `json:"source_url"`
+	RecordsRead int    `json:"records_read"`
+	ParseErrors int    `json:"parse_errors"`
+	Added       int    `json:"added"`
+	Updated     int    `json:"updated"`
+	Removed     int    `json:"removed"`
+	Unchanged   int    `json:"unchanged"`
+	DryRun      bool   `json:"dry_run"`
+	DurationMs  int64  `json:"duration_ms"`
+}
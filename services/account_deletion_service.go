@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+var (
+	// ErrUserNotFound is returned when a user doesn't exist or was already deleted
+	ErrUserNotFound = errors.New("user not found")
+	// ErrAPIKeyNotFound is returned when an API key doesn't exist
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAPIKeyAlreadyRevoked is returned when revoking an inactive API key
+	ErrAPIKeyAlreadyRevoked = errors.New("API key is already revoked")
+)
+
+// DeleteUser deletes an account on behalf of an admin. The users row is kept
+// as an anonymous tombstone so usage history, billing totals and audit
+// references survive, but its email, name, company and password are
+// scrubbed and it can no longer sign in. In the same transaction the user's
+// API keys are deactivated, the IP address and user agent are cleared from
+// their usage records, their tokens, webhooks, geocode jobs, geofences,
+// address subscriptions and private address datasets are removed, and they
+// leave their organizations, handing any they solely own to another member.
+// Active sessions are revoked afterwards.
+func (as *AuthService) DeleteUser(ctx context.Context, userID int) (*models.UserDeletionResult, error) {
+	result := &models.UserDeletionResult{UserID: userID}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET email = 'deleted-' || id || '@deleted.invalid',
+			name = NULL,
+			company = NULL,
+			password_hash = '',
+			is_active = false,
+			is_admin = false,
+			deleted_at = NOW(),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE api_keys SET is_active = false, updated_at = NOW()
+		WHERE user_id = $1 AND is_active = true
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate API keys: %w", err)
+	}
+	keys, _ := res.RowsAffected()
+	result.APIKeysDeactivated = int(keys)
+
+	res, err = tx.ExecContext(ctx, `
+		UPDATE usage_records SET ip_address = NULL, user_agent = NULL
+		WHERE user_id = $1 AND (ip_address IS NOT NULL OR user_agent IS NOT NULL)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize usage records: %w", err)
+	}
+	records, _ := res.RowsAffected()
+	result.UsageRecordsAnonymized = int(records)
+
+	for _, stmt := range []string{
+		`DELETE FROM password_reset_tokens WHERE user_id = $1`,
+		`DELETE FROM email_verification_tokens WHERE user_id = $1`,
+		`DELETE FROM webhooks WHERE user_id = $1`,
+		`DELETE FROM geocode_jobs WHERE user_id = $1`,
+		`DELETE FROM geofences WHERE user_id = $1`,
+		`DELETE FROM address_subscriptions WHERE user_id = $1`,
+		// Custom addresses cascade from their datasets
+		`DELETE FROM custom_datasets WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, userID); err != nil {
+			return nil, fmt.Errorf("failed to remove user data: %w", err)
+		}
+	}
+
+	transferred, err := leaveOrganizations(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.OrganizationsTransferred = transferred
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user deletion: %w", err)
+	}
+
+	if err := as.RevokeUserSessions(ctx, userID); err != nil {
+		slog.Warn("failed to revoke sessions of deleted user", "user_id", userID, "error", err)
+	}
+
+	return result, nil
+}
+
+// leaveOrganizations removes a user from every organization, first handing
+// ownership of any organization they solely own to its longest-standing
+// other member. Organizations left without members are kept, since deleting
+// one would also delete its keys' usage history. Returns how many
+// organizations changed owner.
+func leaveOrganizations(ctx context.Context, tx *sql.Tx, userID int) (int, error) {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE organization_members m SET role = 'owner'
+		FROM (
+			SELECT DISTINCT ON (om.organization_id) om.organization_id, om.user_id
+			FROM organization_members om
+			WHERE om.user_id <> $1
+				AND om.organization_id IN (
+					SELECT organization_id FROM organization_members WHERE user_id = $1 AND role = 'owner'
+				)
+				AND NOT EXISTS (
+					SELECT 1 FROM organization_members other
+					WHERE other.organization_id = om.organization_id AND other.user_id <> $1 AND other.role = 'owner'
+				)
+			ORDER BY om.organization_id, om.created_at, om.user_id
+		) heir
+		WHERE m.organization_id = heir.organization_id AND m.user_id = heir.user_id
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to transfer organization ownership: %w", err)
+	}
+	transferred, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_members WHERE user_id = $1`, userID); err != nil {
+		return 0, fmt.Errorf("failed to leave organizations: %w", err)
+	}
+
+	return int(transferred), nil
+}
+
+// RevokeAPIKey deactivates any user's API key on behalf of an admin and
+// returns the key's owner
+func (as *AuthService) RevokeAPIKey(ctx context.Context, keyID int) (int, error) {
+	var userID int
+	var isActive bool
+	err := database.DB.QueryRowContext(ctx, `SELECT user_id, is_active FROM api_keys WHERE id = $1`, keyID).Scan(&userID, &isActive)
+	if err == sql.ErrNoRows {
+		return 0, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if !isActive {
+		return 0, ErrAPIKeyAlreadyRevoked
+	}
+
+	res, err := database.DB.ExecContext(ctx, `
+		UPDATE api_keys SET is_active = false, updated_at = NOW()
+		WHERE id = $1 AND is_active = true
+	`, keyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, ErrAPIKeyAlreadyRevoked
+	}
+
+	if err := Webhooks.Emit(userID, models.WebhookEventAPIKeyDeleted, "", map[string]interface{}{
+		"api_key_id": keyID,
+		"revoked":    true,
+	}); err != nil {
+		slog.Warn("failed to queue webhook", "event", models.WebhookEventAPIKeyDeleted, "user_id", userID, "error", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"geocoding-api/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteUserRemovesUserData(t *testing.T) {
+	if err := database.InitDB(); err != nil {
+		t.Skipf("database not available: %v", err)
+	}
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	var userID int
+	email := fmt.Sprintf("deletion-test-%d@example.com", time.Now().UnixNano())
+	require.NoError(t, database.DB.QueryRowContext(ctx, `
+		INSERT INTO users (email, password_hash, is_active, plan_type)
+		VALUES ($1, 'x', true, 'free') RETURNING id
+	`, email).Scan(&userID))
+	t.Cleanup(func() { database.DB.Exec(`DELETE FROM users WHERE id = $1`, userID) })
+
+	var datasetID int
+	require.NoError(t, database.DB.QueryRowContext(ctx, `
+		INSERT INTO custom_datasets (user_id, name, row_count) VALUES ($1, 'Sites', 1) RETURNING id
+	`, userID).Scan(&datasetID))
+	_, err := database.DB.ExecContext(ctx, `
+		INSERT INTO custom_addresses (dataset_id, user_id, hash, street, geom)
+		VALUES ($1, $2, 'deletion-test', 'Main St', ST_SetSRID(ST_MakePoint(-83, 40), 4326))
+	`, datasetID, userID)
+	require.NoError(t, err)
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO geofences (user_id, name, geom)
+		VALUES ($1, 'Depot', ST_Multi(ST_MakeEnvelope(-83.1, 39.9, -82.9, 40.1, 4326)))
+	`, userID)
+	require.NoError(t, err)
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO address_subscriptions (user_id, name, target_type, zip_code) VALUES ($1, 'Home', 'zip', '43215')
+	`, userID)
+	require.NoError(t, err)
+
+	_, err = Auth.DeleteUser(ctx, userID)
+	require.NoError(t, err)
+
+	for _, table := range []string{"custom_datasets", "custom_addresses", "geofences", "address_subscriptions"} {
+		var remaining int
+		require.NoError(t, database.DB.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM `+table+` WHERE user_id = $1`, userID,
+		).Scan(&remaining))
+		assert.Zero(t, remaining, table)
+	}
+}
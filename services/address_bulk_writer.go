@@ -0,0 +1,131 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// defaultAddressBatchSize is how many rows are buffered before a COPY is issued
+const defaultAddressBatchSize = 10000
+
+// addressBulkWriter buffers addresses and loads them with COPY into a staging
+// table, then moves them into ohio_addresses skipping rows whose hash already
+// exists. Addresses without a Hash are hashed with addressHash. It replaces
+// row-at-a-time inserts for dataset imports and the county GeoJSON loader.
+type addressBulkWriter struct {
+	db        *sql.DB
+	batchSize int
+	batch     []models.OhioAddress
+
+	inserted   int
+	duplicates int
+}
+
+// newAddressBulkWriter creates a writer that flushes every batchSize rows
+func newAddressBulkWriter(db *sql.DB, batchSize int) *addressBulkWriter {
+	if batchSize <= 0 {
+		batchSize = defaultAddressBatchSize
+	}
+	return &addressBulkWriter{
+		db:        db,
+		batchSize: batchSize,
+		batch:     make([]models.OhioAddress, 0, batchSize),
+	}
+}
+
+// Add queues an address, flushing the batch when it is full
+func (w *addressBulkWriter) Add(address models.OhioAddress) error {
+	w.batch = append(w.batch, address)
+	if len(w.batch) >= w.batchSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered addresses in a single transaction
+func (w *addressBulkWriter) Flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		CREATE TEMP TABLE ohio_addresses_staging (
+			hash TEXT,
+			house_number TEXT,
+			street TEXT,
+			unit TEXT,
+			city TEXT,
+			district TEXT,
+			region TEXT,
+			postcode TEXT,
+			county TEXT,
+			longitude DOUBLE PRECISION,
+			latitude DOUBLE PRECISION
+		) ON COMMIT DROP
+	`); err != nil {
+		return fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("ohio_addresses_staging",
+		"hash", "house_number", "street", "unit", "city", "district",
+		"region", "postcode", "county", "longitude", "latitude"))
+	if err != nil {
+		return fmt.Errorf("failed to prepare copy: %w", err)
+	}
+
+	for _, a := range w.batch {
+		hash := a.Hash
+		if hash == "" {
+			hash = addressHash(&a)
+		}
+		if _, err := stmt.Exec(hash, a.HouseNumber, a.Street, a.Unit, a.City,
+			a.District, a.Region, a.Postcode, a.County, a.Longitude, a.Latitude); err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to copy address: %w", err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to finish copy: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("failed to close copy: %w", err)
+	}
+
+	// DISTINCT ON drops duplicates within the batch, ON CONFLICT drops rows
+	// already imported by earlier batches or datasets
+	result, err := tx.Exec(`
+		INSERT INTO ohio_addresses (
+			hash, house_number, street, unit, city, district, region, postcode, county, geom
+		)
+		SELECT DISTINCT ON (hash)
+			hash, house_number, street, unit, city, district, region, postcode, county,
+			ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)
+		FROM ohio_addresses_staging
+		ORDER BY hash
+		ON CONFLICT (hash) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to insert addresses: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit addresses: %w", err)
+	}
+
+	inserted, _ := result.RowsAffected()
+	w.inserted += int(inserted)
+	w.duplicates += len(w.batch) - int(inserted)
+	w.batch = w.batch[:0]
+	return nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// addressRangePattern matches the TIGER/Line address range (ADDRFEAT)
+// files, one per county, converted to GeoJSON, e.g. with
+// ogr2ogr -f GeoJSON tl_2025_39049_addrfeat.geojson tl_2025_39049_addrfeat.shp
+const addressRangePattern = "tl_*_addrfeat"
+
+// addressRangeCountyGEOID reads the county GEOID from an ADDRFEAT file name
+var addressRangeCountyGEOID = regexp.MustCompile(`tl_\d{4}_(\d{5})_addrfeat`)
+
+// AddressRangeService estimates address positions from TIGER/Line house
+// number ranges, for addresses with no point of their own
+type AddressRangeService struct{}
+
+var AddressRanges = &AddressRangeService{}
+
+// addressRange is one side of a TIGER/Line edge parsed from ADDRFEAT
+// properties
+type addressRange struct {
+	TLID     int64
+	Side     string
+	Street   string
+	From     int
+	To       int
+	Parity   string
+	Postcode string
+}
+
+// houseNumberValue returns the leading digits of a house number, so "12A"
+// and "12-14" interpolate as 12. ok is false when it doesn't start with one.
+func houseNumberValue(houseNumber string) (int, bool) {
+	houseNumber = strings.TrimSpace(houseNumber)
+	end := 0
+	for end < len(houseNumber) && houseNumber[end] >= '0' && houseNumber[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(houseNumber[:end])
+	return n, err == nil
+}
+
+// addressRangesFromProperties reads the left and right house number ranges
+// of an ADDRFEAT edge. A side without a numeric range is left out; an edge
+// with neither is an error.
+func addressRangesFromProperties(props map[string]interface{}) ([]addressRange, error) {
+	text := func(key string) string {
+		value, _ := props[key].(string)
+		return strings.TrimSpace(value)
+	}
+
+	var tlid int64
+	switch value := props["TLID"].(type) {
+	case float64:
+		tlid = int64(value)
+	case string:
+		tlid, _ = strconv.ParseInt(value, 10, 64)
+	}
+	street := text("FULLNAME")
+	if tlid == 0 || street == "" {
+		return nil, fmt.Errorf("edge %v is missing TLID or FULLNAME", props["TLID"])
+	}
+
+	var ranges []addressRange
+	for _, side := range []string{"L", "R"} {
+		from, fromOK := strconv.Atoi(text(side + "FROMHN"))
+		to, toOK := strconv.Atoi(text(side + "TOHN"))
+		if fromOK != nil || toOK != nil {
+			continue
+		}
+		parity := text("PARITY" + side)
+		if parity != "O" && parity != "E" {
+			parity = "B"
+		}
+		ranges = append(ranges, addressRange{
+			TLID:     tlid,
+			Side:     side,
+			Street:   street,
+			From:     from,
+			To:       to,
+			Parity:   parity,
+			Postcode: text("ZIP" + side),
+		})
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("edge %d (%s) has no numeric house number range", tlid, street)
+	}
+	return ranges, nil
+}
+
+// loadAddressRanges upserts the ranges in every ADDRFEAT file, and fails
+// only when there are none
+func loadAddressRanges(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	files, err := globDataFiles(addressRangePattern)
+	if err != nil {
+		return fmt.Errorf("failed to find address range files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no address range files found (looked for %s.geojson)", addressRangePattern)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO address_ranges (
+			tlid, side, county_geoid, street, from_number, to_number, parity, postcode, geom
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''),
+			ST_SetSRID(ST_GeomFromGeoJSON($9), 4326)
+		)
+		ON CONFLICT (tlid, side) DO UPDATE SET
+			county_geoid = EXCLUDED.county_geoid,
+			street = EXCLUDED.street,
+			from_number = EXCLUDED.from_number,
+			to_number = EXCLUDED.to_number,
+			parity = EXCLUDED.parity,
+			postcode = EXCLUDED.postcode,
+			geom = EXCLUDED.geom,
+			updated_at = NOW()
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, file := range files {
+		if err := loadAddressRangeFile(ctx, stmt, run, file); err != nil {
+			return err
+		}
+	}
+	run.setSource(strings.Join(files, ", "))
+	return nil
+}
+
+// loadAddressRangeFile upserts the ranges in one county's ADDRFEAT file
+func loadAddressRangeFile(ctx context.Context, stmt *sql.Stmt, run *loadRun, file string) error {
+	match := addressRangeCountyGEOID.FindStringSubmatch(filepath.Base(file))
+	if match == nil {
+		return fmt.Errorf("%s is not named like tl_2025_39049_addrfeat.geojson, so its county is unknown", file)
+	}
+	countyGEOID := match[1]
+
+	reader, _, err := openDataFile(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	features, err := newGeoJSONFeatureReader(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		ranges, err := addressRangesFromProperties(feature.Properties)
+		if err != nil {
+			slog.Debug("skipping invalid address range", "file", file, "error", err)
+			run.record(rowInvalid)
+			continue
+		}
+		if feature.Geometry.Type != "LineString" {
+			slog.Warn("skipping address range without a line", "file", file, "tlid", ranges[0].TLID, "type", feature.Geometry.Type)
+			run.record(rowInvalid)
+			continue
+		}
+		geometryJSON, err := json.Marshal(feature.Geometry)
+		if err != nil {
+			run.record(rowInvalid)
+			continue
+		}
+
+		for _, r := range ranges {
+			outcome, err := upsertRow(ctx, stmt,
+				r.TLID, r.Side, countyGEOID, r.Street, r.From, r.To, r.Parity, r.Postcode, string(geometryJSON),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert address range %d%s: %w", r.TLID, r.Side, err)
+			}
+			run.record(outcome)
+		}
+	}
+}
+
+// Interpolate places houseNumber on street by interpolating along the
+// loaded address ranges that contain it, narrowest range first. A street
+// name alone is ambiguous across a state, so the ranges must be in postcode
+// or in a ZIP code of city; with neither, nothing is returned.
+func (s *AddressRangeService) Interpolate(ctx context.Context, houseNumber, street, city, postcode string, limit int) ([]models.InterpolatedAddress, error) {
+	number, ok := houseNumberValue(houseNumber)
+	if !ok || street == "" || (city == "" && postcode == "") {
+		return []models.InterpolatedAddress{}, nil
+	}
+
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, `
+		SELECT r.tlid, r.side, r.county_geoid, r.street, COALESCE(r.postcode, ''),
+		       r.from_number, r.to_number, ST_Y(p.point), ST_X(p.point), r.updated_at
+		FROM address_ranges r
+		CROSS JOIN LATERAL (
+			SELECT ST_LineInterpolatePoint(r.geom, CASE
+				WHEN r.to_number = r.from_number THEN 0.5
+				ELSE ($1 - r.from_number)::float8 / (r.to_number - r.from_number)
+			END) AS point
+		) p
+		WHERE normalize_street_name(r.street) = normalize_street_name($2)
+		  AND $1 BETWEEN LEAST(r.from_number, r.to_number) AND GREATEST(r.from_number, r.to_number)
+		  AND (r.parity = 'B' OR r.parity = CASE WHEN $1 % 2 = 0 THEN 'E' ELSE 'O' END)
+		  AND ($3 = '' OR r.postcode = $3)
+		  AND ($4 = '' OR r.postcode IN (SELECT zip_code FROM zip_codes WHERE city_name ILIKE $4))
+		ORDER BY ABS(r.to_number - r.from_number), r.tlid
+		LIMIT $5
+	`, number, street, postcode, city, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpolate address: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []models.InterpolatedAddress{}
+	for rows.Next() {
+		m := models.InterpolatedAddress{HouseNumber: strings.TrimSpace(houseNumber)}
+		var updatedAt time.Time
+		if err := rows.Scan(&m.TLID, &m.Side, &m.CountyGEOID, &m.Street, &m.Postcode,
+			&m.FromNumber, &m.ToNumber, &m.Latitude, &m.Longitude, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan interpolated address: %w", err)
+		}
+		m.Match = interpolatedMatch(city, postcode, updatedAt)
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
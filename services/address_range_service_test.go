@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHouseNumberValue(t *testing.T) {
+	tests := []struct {
+		houseNumber string
+		want        int
+		ok          bool
+	}{
+		{"123", 123, true},
+		{" 12A ", 12, true},
+		{"12-14", 12, true},
+		{"N123", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := houseNumberValue(tt.houseNumber)
+		assert.Equal(t, tt.ok, ok, tt.houseNumber)
+		assert.Equal(t, tt.want, got, tt.houseNumber)
+	}
+}
+
+func TestAddressRangesFromProperties(t *testing.T) {
+	t.Run("both sides", func(t *testing.T) {
+		ranges, err := addressRangesFromProperties(map[string]interface{}{
+			"TLID": float64(88563421), "FULLNAME": "N High St",
+			"LFROMHN": "101", "LTOHN": "199", "PARITYL": "O", "ZIPL": "43215",
+			"RFROMHN": "100", "RTOHN": "198", "PARITYR": "E", "ZIPR": "43215",
+		})
+		require.NoError(t, err)
+		require.Len(t, ranges, 2)
+		assert.Equal(t, addressRange{TLID: 88563421, Side: "L", Street: "N High St", From: 101, To: 199, Parity: "O", Postcode: "43215"}, ranges[0])
+		assert.Equal(t, "R", ranges[1].Side)
+		assert.Equal(t, "E", ranges[1].Parity)
+	})
+
+	t.Run("one side with unknown parity", func(t *testing.T) {
+		ranges, err := addressRangesFromProperties(map[string]interface{}{
+			"TLID": "42", "FULLNAME": "Main St",
+			"RFROMHN": "250", "RTOHN": "200",
+		})
+		require.NoError(t, err)
+		require.Len(t, ranges, 1)
+		assert.Equal(t, addressRange{TLID: 42, Side: "R", Street: "Main St", From: 250, To: 200, Parity: "B"}, ranges[0])
+	})
+
+	t.Run("non-numeric ranges are rejected", func(t *testing.T) {
+		_, err := addressRangesFromProperties(map[string]interface{}{
+			"TLID": float64(7), "FULLNAME": "Main St", "LFROMHN": "12-01", "LTOHN": "12-99",
+		})
+		assert.ErrorContains(t, err, "no numeric house number range")
+	})
+
+	t.Run("missing street name is rejected", func(t *testing.T) {
+		_, err := addressRangesFromProperties(map[string]interface{}{"TLID": float64(7), "LFROMHN": "1", "LTOHN": "9"})
+		assert.ErrorContains(t, err, "missing TLID or FULLNAME")
+	})
+}
+
+func TestAddressRangeCountyGEOID(t *testing.T) {
+	assert.Equal(t, []string{"tl_2025_39049_addrfeat", "39049"}, addressRangeCountyGEOID.FindStringSubmatch("tl_2025_39049_addrfeat.geojson.gz"))
+	assert.Nil(t, addressRangeCountyGEOID.FindStringSubmatch("tl_2025_39_addrfeat.geojson"))
+}
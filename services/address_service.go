@@ -1,11 +1,17 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"geocoding-api/database"
 	"geocoding-api/models"
 	"geocoding-api/utils"
-	"strings"
 )
 
 // AddressService handles Ohio address-related operations
@@ -18,16 +24,125 @@ func NewAddressService(db *sql.DB) *AddressService {
 	return &AddressService{db: db}
 }
 
+// reader returns the connection for the service's searches and lookups: the
+// read replica when the service runs on the primary and a replica is
+// reachable, otherwise the service's own connection
+func (s *AddressService) reader(ctx context.Context) *sql.DB {
+	if s.db != database.DB {
+		return s.db
+	}
+	return database.ReadDB(ctx)
+}
+
+// addressSearchQuery holds the SQL fragments built from AddressSearchParams
+type addressSearchQuery struct {
+	baseQuery         string
+	from              string // ohio_addresses, or the deduplicated rows of it
+	whereClause       string
+	orderBy           string
+	args              []interface{} // WHERE clause args
+	orderByArgs       []interface{}
+	argIndex          int // next free placeholder index
+	hasRelevanceScore bool
+	hasUnitCount      bool
+}
+
+// AddressSearchLimit applies the address search page size default (50) and cap (500)
+func AddressSearchLimit(limit int) int {
+	if limit <= 0 {
+		return 50
+	}
+	if limit > 500 {
+		return 500
+	}
+	return limit
+}
+
 // SearchAddresses searches for addresses based on the provided parameters
-func (s *AddressService) SearchAddresses(params models.AddressSearchParams) ([]models.OhioAddress, int, error) {
-	// Set default limit
-	if params.Limit <= 0 {
-		params.Limit = 50
+func (s *AddressService) SearchAddresses(ctx context.Context, params models.AddressSearchParams) ([]models.OhioAddress, int, error) {
+	params.Limit = AddressSearchLimit(params.Limit)
+
+	q := buildAddressSearchQuery(params)
+	baseQuery, whereClause, orderBy := q.baseQuery, q.whereClause, q.orderBy
+	args, orderByArgs, argIndex, hasRelevanceScore := q.args, q.orderByArgs, q.argIndex, q.hasRelevanceScore
+
+	// Get total count for pagination (only use args for WHERE clause, not ORDER BY)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", q.from, whereClause)
+
+	var total int
+	err := s.reader(ctx).QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	// Main query with pagination - now add ORDER BY args
+	fullQueryArgs := make([]interface{}, len(args))
+	copy(fullQueryArgs, args)
+	fullQueryArgs = append(fullQueryArgs, orderByArgs...)
+
+	fullQuery := fmt.Sprintf(`
+		%s %s %s 
+		LIMIT $%d OFFSET $%d
+	`, baseQuery, whereClause, orderBy, argIndex, argIndex+1)
+
+	fullQueryArgs = append(fullQueryArgs, params.Limit, params.Offset)
+
+	rows, err := s.reader(ctx).QueryContext(ctx, fullQuery, fullQueryArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to execute address search query: %w", err)
 	}
-	if params.Limit > 500 {
-		params.Limit = 500
+	defer rows.Close()
+
+	var addresses []models.OhioAddress
+	for rows.Next() {
+		addr, err := scanAddressSearchRow(rows, hasRelevanceScore, q.hasUnitCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		addresses = append(addresses, *addr)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating address rows: %w", err)
+	}
+
+	// Every row matched all of the filters given; a bare browse has no match
+	if components := searchParamComponents(params); len(components) > 0 {
+		for i := range addresses {
+			setAddressMatch(ctx, &addresses[i], addressPointMatch(components, false))
+		}
+	}
+
+	return addresses, total, nil
+}
+
+// searchParamComponents lists the address components an address search
+// filters on, including those parsed from its free-text query
+func searchParamComponents(params models.AddressSearchParams) []string {
+	components := queryComponents(params.HouseNumber, params.Street, params.City, params.County, params.State, params.Postcode)
+	if strings.TrimSpace(params.Query) != "" {
+		parsed := utils.ParseAddressQuery(utils.StripUnitDesignator(params.Query))
+		components = append(components, queryComponents(parsed.HouseNumber, parsed.Street, parsed.City, "", parsed.State, parsed.Zip)...)
+	}
+	return sortComponents(components)
+}
+
+// unitFilterPattern matches a unit column holding unit on its own or at the
+// end after a designator such as "APT " or "#"
+func unitFilterPattern(unit string) string {
+	return `^(.*[\s#])?` + regexp.QuoteMeta(strings.TrimSpace(unit)) + `$`
+}
+
+// buildAddressSearchQuery builds the filtered, ranked search of the public
+// address points
+func buildAddressSearchQuery(params models.AddressSearchParams) *addressSearchQuery {
+	return buildTableSearchQuery(params, "ohio_addresses", 0)
+}
+
+// buildTableSearchQuery builds the address search over table, which has the
+// address columns of ohio_addresses. A userID other than 0 keeps to that
+// user's rows of a table of user-owned addresses.
+func buildTableSearchQuery(params models.AddressSearchParams, table string, userID int) *addressSearchQuery {
 	// Build the base query (will add relevance_score if needed)
 	baseFields := `id, hash, house_number, street, unit, city, district, region, postcode, county, full_address,
 			ST_Y(geom) as latitude, ST_X(geom) as longitude, created_at`
@@ -39,57 +154,26 @@ func (s *AddressService) SearchAddresses(params models.AddressSearchParams) ([]m
 	argIndex := 1
 	hasRelevanceScore := false
 
+	if userID != 0 {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argIndex))
+		args = append(args, userID)
+		argIndex++
+	}
+
 	// Text search with relevance scoring (Google-style search)
 	if params.Query != "" {
 		// Strip unit designators (#F, Apt 2B, Suite 100, etc.) to avoid
 		// search terms that won't match any database fields
 		params.Query = utils.StripUnitDesignator(params.Query)
-		queryWords := strings.Fields(params.Query)
-		if len(queryWords) > 0 {
-			// Build relevance score for ranking results
-			var scoreComponents []string
-			var searchConditions []string
-			
-			for _, word := range queryWords {
-				wordPattern := "%" + word + "%"
-				
-				// Score: full_address match gets highest priority, then specific fields
-				scoreComponents = append(scoreComponents, fmt.Sprintf(`
-					CASE 
-						WHEN full_address ILIKE $%d THEN 150
-						WHEN street ILIKE $%d THEN 100
-						WHEN (house_number || ' ' || street) ILIKE $%d THEN 90
-						WHEN house_number ILIKE $%d THEN 80
-						WHEN city ILIKE $%d THEN 60
-						WHEN postcode ILIKE $%d THEN 50
-						WHEN county ILIKE $%d THEN 30
-						ELSE 0
-					END`, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex))
-				
-				// Search condition: word must appear in SOME field (each word required via AND)
-				searchConditions = append(searchConditions, fmt.Sprintf(`(
-					full_address ILIKE $%d OR
-					house_number ILIKE $%d OR
-					street ILIKE $%d OR
-					city ILIKE $%d OR
-					county ILIKE $%d OR
-					postcode ILIKE $%d
-				)`, argIndex, argIndex, argIndex, argIndex, argIndex, argIndex))
-				
-				args = append(args, wordPattern)
-				argIndex++
-			}
-			
-			// Every word must match at least one field (AND logic for precision)
-			if len(searchConditions) > 0 {
-				conditions = append(conditions, "("+strings.Join(searchConditions, " AND ")+")")
-			}
-			
-			// Add relevance score to select
-			if len(scoreComponents) > 0 {
-				selectFields = append(selectFields, "("+strings.Join(scoreComponents, " + ")+") as relevance_score")
-				hasRelevanceScore = true
-			}
+		tsQuery := buildPrefixTSQuery(strings.Fields(params.Query))
+		if tsQuery != "" {
+			// Every word must prefix-match a token in the trigger-maintained
+			// search_vector column (GIN indexed), ranked by ts_rank
+			conditions = append(conditions, fmt.Sprintf("search_vector @@ to_tsquery('simple', $%d)", argIndex))
+			selectFields = append(selectFields, fmt.Sprintf("ts_rank(search_vector, to_tsquery('simple', $%d)) as relevance_score", argIndex))
+			args = append(args, tsQuery)
+			argIndex++
+			hasRelevanceScore = true
 		}
 	}
 
@@ -114,10 +198,53 @@ func (s *AddressService) SearchAddresses(params models.AddressSearchParams) ([]m
 		argIndex++
 	}
 
-	// Street filter
+	// Street filter (matches abbreviation variants, e.g. "Ave" and "Avenue")
 	if params.Street != "" {
-		conditions = append(conditions, fmt.Sprintf("street ILIKE $%d", argIndex))
-		args = append(args, "%"+params.Street+"%")
+		var streetConditions []string
+		for _, variant := range utils.GetAddressQueryVariants(params.Street) {
+			streetConditions = append(streetConditions, fmt.Sprintf("street ILIKE $%d", argIndex))
+			args = append(args, "%"+variant+"%")
+			argIndex++
+		}
+		conditions = append(conditions, "("+strings.Join(streetConditions, " OR ")+")")
+	}
+
+	// House number filter
+	if params.HouseNumber != "" {
+		conditions = append(conditions, fmt.Sprintf("house_number = $%d", argIndex))
+		args = append(args, params.HouseNumber)
+		argIndex++
+	}
+
+	// Unit filter: the unit as stored, or its identifier after a designator,
+	// so "3" matches "3", "APT 3" and "#3"
+	if params.Unit != "" {
+		conditions = append(conditions, fmt.Sprintf("unit ~* $%d", argIndex))
+		args = append(args, unitFilterPattern(params.Unit))
+		argIndex++
+	}
+
+	// State filter
+	if params.State != "" {
+		conditions = append(conditions, fmt.Sprintf("region = $%d", argIndex))
+		args = append(args, strings.ToUpper(params.State))
+		argIndex++
+	}
+
+	// Bounding box: && compares against the envelope using the GIST index on
+	// geom, which for points is the exact containment test
+	if params.BBox != nil {
+		conditions = append(conditions, "geom && "+bboxEnvelope(argIndex))
+		args = append(args, bboxArgs(params.BBox)...)
+		argIndex += 4
+	}
+
+	// Deterministic sample: keep rows whose hashed id falls in the first
+	// sample fraction of buckets, so the same rows come back on every call and
+	// the sample isn't skewed toward the ORDER BY prefix
+	if params.Sample > 0 && params.Sample < 1 {
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", sampleBucketExpr("id"), argIndex))
+		args = append(args, int(params.Sample*sampleBuckets))
 		argIndex++
 	}
 
@@ -146,9 +273,10 @@ func (s *AddressService) SearchAddresses(params models.AddressSearchParams) ([]m
 		argIndex += 2
 	} else if hasRelevanceScore {
 		// Order by relevance score (highest first)
-		orderBy = "ORDER BY relevance_score DESC, county, city, street, house_number"
+		orderBy = "ORDER BY relevance_score DESC, county, city, street, house_number, id"
 	} else {
-		orderBy = "ORDER BY county, city, street, house_number"
+		// id breaks ties between units so pages don't overlap
+		orderBy = "ORDER BY county, city, street, house_number, id"
 	}
 
 	// Construct the full query
@@ -157,77 +285,279 @@ func (s *AddressService) SearchAddresses(params models.AddressSearchParams) ([]m
 		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	// Dedupe: filter first, then keep one row per building (the one without
+	// a unit if there is one) with the number of units it stands for. The
+	// subquery keeps every column, so the ranking and ordering above apply
+	// unchanged to its rows.
+	from := table
+	if params.Dedupe {
+		from = fmt.Sprintf(`(
+			SELECT *, COUNT(*) OVER building AS unit_count,
+				ROW_NUMBER() OVER (building ORDER BY NULLIF(unit, '') NULLS FIRST, id) AS building_rank
+			FROM %s %s
+			WINDOW building AS (PARTITION BY %s)
+		) %s`, table, whereClause, buildingKey, table)
+		whereClause = "WHERE building_rank = 1"
+		selectFields = append(selectFields, "unit_count")
+	}
+
 	// Build SELECT clause
 	selectClause := baseFields
 	if len(selectFields) > 0 {
 		selectClause = baseFields + ", " + strings.Join(selectFields, ", ")
 	}
-	
-	baseQuery := fmt.Sprintf("SELECT %s FROM ohio_addresses", selectClause)
 
-	// Get total count for pagination (only use args for WHERE clause, not ORDER BY)
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM ohio_addresses %s", whereClause)
-	
-	var total int
-	err := s.db.QueryRow(countQuery, args...).Scan(&total)
+	baseQuery := fmt.Sprintf("SELECT %s FROM %s", selectClause, from)
+
+	return &addressSearchQuery{
+		baseQuery:         baseQuery,
+		from:              from,
+		whereClause:       whereClause,
+		orderBy:           orderBy,
+		args:              args,
+		orderByArgs:       orderByArgs,
+		argIndex:          argIndex,
+		hasRelevanceScore: hasRelevanceScore,
+		hasUnitCount:      params.Dedupe,
+	}
+}
+
+// buildingKey groups the units of a building: the same house number on the
+// same street in the same ZIP code. Addresses without a house number are
+// never grouped.
+const buildingKey = "COALESCE(NULLIF(house_number, ''), id::text), LOWER(street), COALESCE(postcode, '')"
+
+// StreamAddresses runs the same search as SearchAddresses but hands each row
+// to fn as it is read instead of collecting results, for large exports.
+// maxRows caps the number of rows returned (0 means no cap).
+func (s *AddressService) StreamAddresses(ctx context.Context, params models.AddressSearchParams, maxRows int, fn func(*models.OhioAddress) error) error {
+	q := buildAddressSearchQuery(params)
+
+	queryArgs := append(append([]interface{}{}, q.args...), q.orderByArgs...)
+	query := fmt.Sprintf("%s %s %s OFFSET $%d", q.baseQuery, q.whereClause, q.orderBy, q.argIndex)
+	queryArgs = append(queryArgs, params.Offset)
+	if maxRows > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", q.argIndex+1)
+		queryArgs = append(queryArgs, maxRows)
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, queryArgs...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+		return fmt.Errorf("failed to execute address search query: %w", err)
 	}
+	defer rows.Close()
 
-	// Main query with pagination - now add ORDER BY args
-	fullQueryArgs := make([]interface{}, len(args))
-	copy(fullQueryArgs, args)
-	fullQueryArgs = append(fullQueryArgs, orderByArgs...)
-	
-	fullQuery := fmt.Sprintf(`
-		%s %s %s 
+	for rows.Next() {
+		addr, err := scanAddressSearchRow(rows, q.hasRelevanceScore, q.hasUnitCount)
+		if err != nil {
+			return err
+		}
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating address rows: %w", err)
+	}
+	return nil
+}
+
+// AddressClusterDistance clusters address search results that lie within the
+// cluster radius of one another
+const AddressClusterDistance = "distance"
+
+// Address cluster radius default and cap, in meters
+const (
+	DefaultAddressClusterRadius = 100
+	MaxAddressClusterRadius     = 5000
+)
+
+// maxClusterAddresses bounds how many matching addresses are clustered, so a
+// statewide search can't cluster millions of points
+const maxClusterAddresses = 50000
+
+// AddressClusterRadius applies the cluster radius default and cap
+func AddressClusterRadius(radius float64) float64 {
+	if radius <= 0 {
+		return DefaultAddressClusterRadius
+	}
+	return min(radius, MaxAddressClusterRadius)
+}
+
+// ClusterAddresses runs an address search and returns one page of clusters
+// of its results, largest first, with the total number of clusters. Points
+// are clustered with DBSCAN in Web Mercator, with the radius scaled by the
+// latitude of the results so it stays close to meters on the ground.
+func (s *AddressService) ClusterAddresses(ctx context.Context, params models.AddressSearchParams) ([]models.AddressCluster, int, error) {
+	params.Limit = AddressSearchLimit(params.Limit)
+	q := buildAddressSearchQuery(params)
+
+	query := fmt.Sprintf(`
+		WITH matched AS (
+			SELECT id, geom FROM %s %s
+			LIMIT %d
+		), scale AS (
+			SELECT GREATEST(COS(RADIANS(ST_Y(ST_Centroid(ST_Extent(geom))))), 0.01) AS k FROM matched
+		), clustered AS (
+			SELECT id, geom,
+				ST_ClusterDBSCAN(ST_Transform(geom, 3857), eps := (SELECT $%d / k FROM scale), minpoints := 1) OVER () AS cluster
+			FROM matched
+		)
+		SELECT ST_Y(ST_Centroid(ST_Collect(geom))), ST_X(ST_Centroid(ST_Collect(geom))), COUNT(*),
+			ST_XMin(ST_Extent(geom)), ST_YMin(ST_Extent(geom)), ST_XMax(ST_Extent(geom)), ST_YMax(ST_Extent(geom)),
+			MIN(id), COUNT(*) OVER ()
+		FROM clustered
+		GROUP BY cluster
+		ORDER BY COUNT(*) DESC, MIN(id)
 		LIMIT $%d OFFSET $%d
-	`, baseQuery, whereClause, orderBy, argIndex, argIndex+1)
-	
-	fullQueryArgs = append(fullQueryArgs, params.Limit, params.Offset)
+	`, q.from, q.whereClause, maxClusterAddresses, q.argIndex, q.argIndex+1, q.argIndex+2)
 
-	rows, err := s.db.Query(fullQuery, fullQueryArgs...)
+	args := append(append([]interface{}{}, q.args...), AddressClusterRadius(params.ClusterRadius), params.Limit, params.Offset)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to execute address search query: %w", err)
+		return nil, 0, fmt.Errorf("failed to cluster addresses: %w", err)
 	}
 	defer rows.Close()
 
-	var addresses []models.OhioAddress
+	clusters := []models.AddressCluster{}
+	total := 0
 	for rows.Next() {
-		var addr models.OhioAddress
-		var relevanceScore *int // May or may not be present
-		
-		if hasRelevanceScore {
-			err := rows.Scan(
-				&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
-				&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
-				&addr.Latitude, &addr.Longitude, &addr.CreatedAt, &relevanceScore,
-			)
-			if err != nil {
-				return nil, 0, fmt.Errorf("failed to scan address row with score: %w", err)
-			}
-		} else {
-			err := rows.Scan(
-				&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
-				&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
-				&addr.Latitude, &addr.Longitude, &addr.CreatedAt,
-			)
-			if err != nil {
-				return nil, 0, fmt.Errorf("failed to scan address row: %w", err)
+		var cluster models.AddressCluster
+		var minLng, minLat, maxLng, maxLat float64
+		var firstID int64
+		if err := rows.Scan(&cluster.Latitude, &cluster.Longitude, &cluster.Count,
+			&minLng, &minLat, &maxLng, &maxLat, &firstID, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan address cluster: %w", err)
+		}
+		cluster.Bounds = []float64{minLng, minLat, maxLng, maxLat}
+		if cluster.Count == 1 {
+			cluster.AddressID = &firstID
+		}
+		clusters = append(clusters, cluster)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating address clusters: %w", err)
+	}
+
+	return clusters, total, nil
+}
+
+// scanAddressSearchRow scans one row of an address search query, with the
+// relevance score and unit count columns when the query selects them
+func scanAddressSearchRow(rows *sql.Rows, hasRelevanceScore, hasUnitCount bool) (*models.OhioAddress, error) {
+	var addr models.OhioAddress
+	var relevanceScore *float64 // May or may not be present
+
+	dest := []interface{}{
+		&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
+		&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
+		&addr.Latitude, &addr.Longitude, &addr.CreatedAt,
+	}
+	if hasRelevanceScore {
+		dest = append(dest, &relevanceScore)
+	}
+	if hasUnitCount {
+		dest = append(dest, &addr.UnitCount)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to scan address row: %w", err)
+	}
+	return &addr, nil
+}
+
+// sampleBuckets is the resolution of deterministic sampling (0.01% steps)
+const sampleBuckets = 10000
+
+// sampleBucketExpr maps an id column to a stable pseudo-random bucket in [0, sampleBuckets)
+func sampleBucketExpr(column string) string {
+	return fmt.Sprintf("((hashint8(%s::bigint) & 2147483647) %% %d)", column, sampleBuckets)
+}
+
+// buildPrefixTSQuery converts free-form search words into a tsquery string
+// where every word must prefix-match ("oak:* & 2525:*"). Characters that carry
+// meaning in tsquery syntax are stripped so user input can't break the query.
+func buildPrefixTSQuery(words []string) string {
+	var terms []string
+	for _, word := range words {
+		cleaned := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
 			}
+			return -1
+		}, word)
+		if cleaned != "" {
+			terms = append(terms, cleaned+":*")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// FindNearbyAddresses returns the address points closest to a location, ordered
+// by distance. The KNN operator (<->) and the bounding-box prefilter both use
+// the GIST index on geom; exact distances are computed on the geography.
+func (s *AddressService) FindNearbyAddresses(ctx context.Context, lat, lng, radiusMeters float64, limit int) ([]models.NearbyAddress, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	// Degrees of longitude shrink with latitude; expand by the wider of the two
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if cosLat < 0.01 {
+		cosLat = 0.01
+	}
+	expandDegrees := radiusMeters / (111320 * cosLat)
+
+	query := `
+		WITH origin AS (
+			SELECT ST_SetSRID(ST_MakePoint($1, $2), 4326) AS pt
+		)
+		SELECT a.id, a.hash, a.house_number, a.street, COALESCE(a.unit, ''), a.city, COALESCE(a.district, ''),
+			a.region, a.postcode, a.county, COALESCE(a.full_address, ''),
+			ST_Y(a.geom), ST_X(a.geom), a.created_at,
+			ST_Distance(a.geom::geography, origin.pt::geography) AS distance_meters
+		FROM ohio_addresses a, origin
+		WHERE a.geom && ST_Expand(origin.pt, $3)
+		AND ST_DWithin(a.geom::geography, origin.pt::geography, $4)
+		ORDER BY a.geom <-> origin.pt
+		LIMIT $5
+	`
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, lng, lat, expandDegrees, radiusMeters, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.NearbyAddress{}
+	for rows.Next() {
+		var addr models.NearbyAddress
+		if err := rows.Scan(
+			&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
+			&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
+			&addr.Latitude, &addr.Longitude, &addr.CreatedAt, &addr.DistanceMeters,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan nearby address: %w", err)
 		}
 		addresses = append(addresses, addr)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating address rows: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating nearby addresses: %w", err)
 	}
 
-	return addresses, total, nil
+	for i := range addresses {
+		setAddressMatch(ctx, &addresses[i].OhioAddress, reverseMatch(addresses[i].DistanceMeters))
+	}
+
+	return addresses, nil
 }
 
 // GetAddressByID retrieves a specific address by ID
-func (s *AddressService) GetAddressByID(id int64) (*models.OhioAddress, error) {
+func (s *AddressService) GetAddressByID(ctx context.Context, id int64) (*models.OhioAddress, error) {
 	query := `
 		SELECT 
 			id, hash, house_number, street, unit, city, district, region, postcode, county, full_address,
@@ -237,7 +567,7 @@ func (s *AddressService) GetAddressByID(id int64) (*models.OhioAddress, error) {
 	`
 
 	var addr models.OhioAddress
-	err := s.db.QueryRow(query, id).Scan(
+	err := s.reader(ctx).QueryRowContext(ctx, query, id).Scan(
 		&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
 		&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
 		&addr.Latitude, &addr.Longitude, &addr.CreatedAt,
@@ -253,16 +583,17 @@ func (s *AddressService) GetAddressByID(id int64) (*models.OhioAddress, error) {
 	return &addr, nil
 }
 
-// GetCountyStats returns statistics about loaded counties
-func (s *AddressService) GetCountyStats() (map[string]int, error) {
+// GetCountyStats returns the number of loaded addresses in each county, as
+// of the last refresh of the county_address_counts view
+func (s *AddressService) GetCountyStats(ctx context.Context) (map[string]int, error) {
 	query := `
-		SELECT county, COUNT(*) as count 
-		FROM ohio_addresses 
-		GROUP BY county 
-		ORDER BY count DESC
+		SELECT county, SUM(address_count)
+		FROM county_address_counts
+		GROUP BY county
+		ORDER BY 2 DESC
 	`
 
-	rows, err := s.db.Query(query)
+	rows, err := s.reader(ctx).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get county stats: %w", err)
 	}
@@ -283,18 +614,21 @@ func (s *AddressService) GetCountyStats() (map[string]int, error) {
 
 // AddressSearchResult contains search results along with metadata about the search
 type AddressSearchResult struct {
-	Addresses       []models.OhioAddress
-	ExactCount      int                  // Number of exact matches
-	FallbackCount   int                  // Number of fallback (street-only) matches
-	FallbackQuery   string               // The query used for fallback (empty if no fallback)
-	OriginalQuery   string
-	ParsedQuery     *utils.ParsedAddress // Parsed address components (nil if not parsed)
-	SearchMethod    string               // "component" or "fulltext"
+	Addresses     []models.OhioAddress
+	ExactCount    int    // Number of exact matches
+	FallbackCount int    // Number of fallback (street-only) matches
+	FallbackQuery string // The query used for fallback (empty if no fallback)
+	OriginalQuery string
+	ParsedQuery   *utils.ParsedAddress         // Parsed address components (nil if not parsed)
+	SearchMethod  string                       // "component", "interpolated", "street" or "fulltext"
+	Interpolated  []models.InterpolatedAddress // Positions estimated from address ranges when no address point matched
+	Streets       []models.Street              // Street-level matches when no rooftop address matched
+	CountyMatch   *models.CountyCentroidMatch  // County centroid when the query only names a county
 }
 
 // FullTextSearchAddresses performs a simple full-text search on the full_address column
 // Returns exact matches first, followed by street-level matches (fallback) with lower priority
-func (s *AddressService) FullTextSearchAddresses(query string, limit int) (*AddressSearchResult, error) {
+func (s *AddressService) FullTextSearchAddresses(ctx context.Context, query string, limit int) (*AddressSearchResult, error) {
 	result := &AddressSearchResult{
 		OriginalQuery: query,
 	}
@@ -324,8 +658,18 @@ func (s *AddressService) FullTextSearchAddresses(query string, limit int) (*Addr
 	parsed := utils.ParseAddressQuery(query)
 	result.ParsedQuery = parsed
 
+	// "Hamilton County, OH" names only a county: answer with its centroid
+	if parsed.HouseNumber == "" && strings.Contains(strings.ToLower(query), "county") {
+		if match, err := County.GetCountyCentroid(ctx, query); err == nil {
+			result.Addresses = []models.OhioAddress{}
+			result.CountyMatch = match
+			result.SearchMethod = "county_centroid"
+			return result, nil
+		}
+	}
+
 	if parsed.Street != "" || parsed.City != "" || parsed.Zip != "" {
-		componentResult, err := s.searchByComponents(parsed, limit)
+		componentResult, err := s.searchByComponents(ctx, parsed, limit)
 		if err == nil && componentResult != nil && len(componentResult.Addresses) > 0 {
 			result.Addresses = componentResult.Addresses
 			result.ExactCount = componentResult.ExactCount
@@ -336,10 +680,35 @@ func (s *AddressService) FullTextSearchAddresses(query string, limit int) (*Addr
 			}
 			return result, nil
 		}
+
+		// No address point: estimate the position from a TIGER/Line address
+		// range, which also covers counties without uploaded addresses
+		if parsed.HouseNumber != "" && parsed.Street != "" {
+			interpolated, err := AddressRanges.Interpolate(ctx, parsed.HouseNumber, parsed.Street, parsed.City, parsed.Zip, limit)
+			if err == nil && len(interpolated) > 0 {
+				result.Addresses = []models.OhioAddress{}
+				result.Interpolated = interpolated
+				result.SearchMethod = "interpolated"
+				return result, nil
+			}
+		}
+
+		// No rooftop match: try the precomputed street index before falling
+		// back to the much broader full_address search
+		if parsed.Street != "" {
+			streets, err := Street.MatchStreet(ctx, parsed.Street, parsed.City, parsed.Zip, limit)
+			if err == nil && len(streets) > 0 {
+				result.Addresses = []models.OhioAddress{}
+				result.Streets = streets
+				result.SearchMethod = "street"
+				return result, nil
+			}
+		}
 	}
 
 	// Fall back to full_address ILIKE search if component search found nothing
 	result.SearchMethod = "fulltext"
+	components := queryComponents(parsed.HouseNumber, parsed.Street, parsed.City, "", parsed.State, parsed.Zip)
 
 	// Get the street-only version of the query for fallback
 	fallbackQuery := extractStreetFromQuery(query)
@@ -347,18 +716,29 @@ func (s *AddressService) FullTextSearchAddresses(query string, limit int) (*Addr
 
 	// If there's no fallback possible (query has no house number), just do a simple search
 	if !hasFallback {
-		addresses, err := s.searchAddressesWithVariants(query, limit)
+		addresses, err := s.searchAddressesWithVariants(ctx, query, limit)
 		if err != nil {
 			return nil, err
 		}
 		result.Addresses = addresses
 		result.ExactCount = len(addresses)
+		for i := range addresses {
+			setAddressMatch(ctx, &addresses[i], addressPointMatch(components, false))
+		}
+
+		// Last resort: a bare county name ("Hamilton") geocodes to the county centroid
+		if len(addresses) == 0 {
+			if match, err := County.GetCountyCentroid(ctx, query); err == nil {
+				result.CountyMatch = match
+				result.SearchMethod = "county_centroid"
+			}
+		}
 		return result, nil
 	}
 
 	// Build a combined query that returns exact matches first, then street matches
 	// This uses a single query with UNION to get both result sets in priority order
-	addresses, exactCount, fallbackCount, err := s.searchWithFallback(query, fallbackQuery, limit)
+	addresses, exactCount, fallbackCount, err := s.searchWithFallback(ctx, query, fallbackQuery, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -370,11 +750,26 @@ func (s *AddressService) FullTextSearchAddresses(query string, limit int) (*Addr
 		result.FallbackQuery = fallbackQuery
 	}
 
+	// Fallback matches come after the exact ones and dropped the house number
+	var streetComponents []string
+	for _, component := range components {
+		if component != "house_number" {
+			streetComponents = append(streetComponents, component)
+		}
+	}
+	for i := range addresses {
+		if i < exactCount {
+			setAddressMatch(ctx, &addresses[i], addressPointMatch(components, false))
+		} else {
+			setAddressMatch(ctx, &addresses[i], addressPointMatch(streetComponents, false))
+		}
+	}
+
 	return result, nil
 }
 
 // searchWithFallback performs a search that returns exact matches first, then street-level fallback matches
-func (s *AddressService) searchWithFallback(exactQuery, fallbackQuery string, limit int) ([]models.OhioAddress, int, int, error) {
+func (s *AddressService) searchWithFallback(ctx context.Context, exactQuery, fallbackQuery string, limit int) ([]models.OhioAddress, int, int, error) {
 	// Get variants for both queries
 	exactVariants := utils.GetAddressQueryVariants(exactQuery)
 	fallbackVariants := utils.GetAddressQueryVariants(fallbackQuery)
@@ -434,7 +829,7 @@ func (s *AddressService) searchWithFallback(exactQuery, fallbackQuery string, li
 
 	args = append(args, limit)
 
-	rows, err := s.db.Query(searchQuery, args...)
+	rows, err := s.reader(ctx).QueryContext(ctx, searchQuery, args...)
 	if err != nil {
 		return nil, 0, 0, fmt.Errorf("failed to execute search with fallback: %w", err)
 	}
@@ -485,10 +880,17 @@ func (s *AddressService) searchWithFallback(exactQuery, fallbackQuery string, li
 
 // componentSearchResult holds addresses with exact vs nearby counts from tiered search.
 type componentSearchResult struct {
-	Addresses    []models.OhioAddress
-	ExactCount   int // Tiers that matched the house number (exact address)
-	NearbyCount  int // Tiers that dropped the house number (same street/city)
-	BestTier     int // The most specific tier that returned results
+	Addresses   []models.OhioAddress
+	ExactCount  int // Tiers that matched the house number (exact address)
+	NearbyCount int // Tiers that dropped the house number (same street/city)
+	BestTier    int // The most specific tier that returned results
+}
+
+// componentTier records what a component search tier matched on, to
+// describe the match of the addresses it returns
+type componentTier struct {
+	components []string
+	fuzzy      bool // the street matched as a misspelling
 }
 
 // searchByComponents searches using parsed address components against individual fields.
@@ -496,13 +898,16 @@ type componentSearchResult struct {
 // relaxes conditions to find nearby results.
 //
 // Tiers with house number matching are "exact"; tiers without are "nearby" fallbacks.
-func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit int) (*componentSearchResult, error) {
+func (s *AddressService) searchByComponents(ctx context.Context, parsed *utils.ParsedAddress, limit int) (*componentSearchResult, error) {
 	var args []interface{}
 	argNum := 1
 
-	// Build street ILIKE conditions using abbreviation variants
+	// Build street ILIKE conditions using abbreviation variants, plus an exact
+	// match on the normalized name so "N 1st St" matches "NORTH FIRST STREET"
 	hasStreet := parsed.Street != ""
 	streetClause := ""
+	fuzzyStreetClause := ""
+	fuzzyScore := ""
 	if hasStreet {
 		streetVariants := utils.GetAddressQueryVariants(parsed.Street)
 		var streetConditions []string
@@ -511,6 +916,19 @@ func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit i
 			args = append(args, "%"+variant+"%")
 			argNum++
 		}
+
+		if normalized := utils.NormalizeStreetName(parsed.Street); normalized != "" {
+			streetConditions = append(streetConditions, fmt.Sprintf("normalize_street_name(street) = $%d", argNum))
+
+			// Misspelled streets: trigram similarity (%, served by the
+			// normalized street index) within a few edits of the query
+			fuzzyStreetClause = fmt.Sprintf(
+				"normalize_street_name(street) %% $%[1]d AND levenshtein(normalize_street_name(street), $%[1]d) <= %[2]d",
+				argNum, maxStreetEdits(normalized))
+			fuzzyScore = fmt.Sprintf("similarity(normalize_street_name(street), $%d)", argNum)
+			args = append(args, normalized)
+			argNum++
+		}
 		streetClause = "(" + strings.Join(streetConditions, " OR ") + ")"
 	}
 
@@ -547,8 +965,11 @@ func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit i
 	tierNum := 0
 	// Track which tiers include the house number (exact) vs not (nearby)
 	exactTiers := make(map[int]bool)
+	tiers := make(map[int]componentTier)
 
-	addTier := func(whereClause string, isExact bool) {
+	// score orders results within a tier; only fuzzy street tiers vary it.
+	// components are what the tier matched on.
+	addTier := func(whereClause, score string, components []string, fuzzy bool) {
 		tierNum++
 		tierName := fmt.Sprintf("tier%d", tierNum)
 		exclusionClause := ""
@@ -556,55 +977,77 @@ func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit i
 			exclusionClause = " AND " + strings.Join(exclusions, " AND ")
 		}
 		tierCTEs = append(tierCTEs, fmt.Sprintf(`%s AS (
-			SELECT %s, %d as tier FROM ohio_addresses
+			SELECT %s, %d as tier, %s as score FROM ohio_addresses
 			WHERE %s%s
+			ORDER BY score DESC
 			LIMIT %d
-		)`, tierName, selectFields, tierNum, whereClause, exclusionClause, limit))
+		)`, tierName, selectFields, tierNum, score, whereClause, exclusionClause, limit))
 		tierSelects = append(tierSelects, fmt.Sprintf("SELECT * FROM %s", tierName))
 		exclusions = append(exclusions, fmt.Sprintf("id NOT IN (SELECT id FROM %s)", tierName))
-		if isExact {
+		if hasComponent(components, "house_number") {
 			exactTiers[tierNum] = true
 		}
+		tiers[tierNum] = componentTier{components: components, fuzzy: fuzzy}
 	}
 
 	// Location anchor: always constrain to the provided city/zip/both.
 	// What relaxes is the street-level detail, not the location.
 	locationClause := ""
+	var location []string
 	switch {
 	case cityArg > 0 && zipArg > 0:
 		locationClause = fmt.Sprintf("city ILIKE $%d AND postcode = $%d", cityArg, zipArg)
+		location = []string{"city", "postcode"}
 	case zipArg > 0:
 		locationClause = fmt.Sprintf("postcode = $%d", zipArg)
+		location = []string{"postcode"}
 	case cityArg > 0:
 		locationClause = fmt.Sprintf("city ILIKE $%d", cityArg)
+		location = []string{"city"}
 	}
+	houseAndStreet := append([]string{"house_number", "street"}, location...)
+	streetOnly := append([]string{"street"}, location...)
 
 	if hasStreet && locationClause != "" {
 		// Tier 1: house + street in location (exact address)
 		if houseArg > 0 {
 			addTier(fmt.Sprintf("house_number = $%d AND %s AND %s",
-				houseArg, streetClause, locationClause), true)
+				houseArg, streetClause, locationClause), "1", houseAndStreet, false)
 		}
 
 		// Tier 2: street in location (right street, any house number)
-		addTier(fmt.Sprintf("%s AND %s", streetClause, locationClause), false)
+		addTier(fmt.Sprintf("%s AND %s", streetClause, locationClause), "1", streetOnly, false)
+
+		// Tiers 2a/2b: the same with a misspelled street, best match first
+		if fuzzyStreetClause != "" {
+			if houseArg > 0 {
+				addTier(fmt.Sprintf("house_number = $%d AND %s AND %s",
+					houseArg, fuzzyStreetClause, locationClause), fuzzyScore, houseAndStreet, true)
+			}
+			addTier(fmt.Sprintf("%s AND %s", fuzzyStreetClause, locationClause), fuzzyScore, streetOnly, true)
+		}
 	} else if hasStreet {
 		// No city/zip provided — match on street alone
 		if houseArg > 0 {
 			addTier(fmt.Sprintf("house_number = $%d AND %s",
-				houseArg, streetClause), true)
+				houseArg, streetClause), "1", houseAndStreet, false)
+		}
+		addTier(streetClause, "1", streetOnly, false)
+
+		if fuzzyStreetClause != "" && houseArg > 0 {
+			addTier(fmt.Sprintf("house_number = $%d AND %s",
+				houseArg, fuzzyStreetClause), fuzzyScore, houseAndStreet, true)
 		}
-		addTier(streetClause, false)
 	}
 
 	// Tier 3: zip only (right area, any street)
 	if zipArg > 0 {
-		addTier(fmt.Sprintf("postcode = $%d", zipArg), false)
+		addTier(fmt.Sprintf("postcode = $%d", zipArg), "1", []string{"postcode"}, false)
 	}
 
 	// Tier 4: city only (broadest location match)
 	if cityArg > 0 {
-		addTier(fmt.Sprintf("city ILIKE $%d", cityArg), false)
+		addTier(fmt.Sprintf("city ILIKE $%d", cityArg), "1", []string{"city"}, false)
 	}
 
 	if len(tierCTEs) == 0 {
@@ -620,17 +1063,18 @@ func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit i
 		SELECT id, hash, house_number, street, unit, city, district, region, postcode, county, full_address,
 			latitude, longitude, created_at, tier
 		FROM (%s) combined
-		ORDER BY tier, full_address
+		ORDER BY tier, score DESC, full_address
 		LIMIT $%d
 	`, strings.Join(tierCTEs, ",\n"), strings.Join(tierSelects, " UNION ALL "), limitArg)
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute component search: %w", err)
 	}
 	defer rows.Close()
 
 	result := &componentSearchResult{}
+	var rowTiers []int
 	for rows.Next() {
 		var addr models.OhioAddress
 		var unit, district sql.NullString
@@ -653,6 +1097,7 @@ func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit i
 		}
 
 		result.Addresses = append(result.Addresses, addr)
+		rowTiers = append(rowTiers, tier)
 
 		if exactTiers[tier] {
 			result.ExactCount++
@@ -668,20 +1113,33 @@ func (s *AddressService) searchByComponents(parsed *utils.ParsedAddress, limit i
 		return nil, fmt.Errorf("error iterating component search rows: %w", err)
 	}
 
+	for i, tier := range rowTiers {
+		setAddressMatch(ctx, &result.Addresses[i], addressPointMatch(tiers[tier].components, tiers[tier].fuzzy))
+	}
+
 	return result, nil
 }
 
+// maxStreetEdits is how many edits a fuzzy street match may be from the
+// normalized query: one per four characters, at least one
+func maxStreetEdits(normalized string) int {
+	if edits := len(normalized) / 4; edits > 1 {
+		return edits
+	}
+	return 1
+}
+
 // searchAddressesWithVariants performs the actual search with abbreviation variants
-func (s *AddressService) searchAddressesWithVariants(query string, limit int) ([]models.OhioAddress, error) {
+func (s *AddressService) searchAddressesWithVariants(ctx context.Context, query string, limit int) ([]models.OhioAddress, error) {
 	// Get all variants of the query (handles both abbreviations and full forms)
 	// This allows "dr" to match "drive" and "drive" to match "dr"
 	queryVariants := utils.GetAddressQueryVariants(query)
-	
+
 	// Build OR conditions for all variants
 	var conditions []string
 	var args []interface{}
 	argNum := 1
-	
+
 	for _, variant := range queryVariants {
 		pattern := "%" + variant + "%"
 		conditions = append(conditions, fmt.Sprintf("full_address ILIKE $%d", argNum))
@@ -709,7 +1167,7 @@ func (s *AddressService) searchAddressesWithVariants(query string, limit int) ([
 	exactPattern := "%" + query + "%"
 	args = append(args, exactPattern, limit)
 
-	rows, err := s.db.Query(searchQuery, args...)
+	rows, err := s.reader(ctx).QueryContext(ctx, searchQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute full-text search: %w", err)
 	}
@@ -754,20 +1212,20 @@ func (s *AddressService) searchAddressesWithVariants(query string, limit int) ([
 func extractStreetFromQuery(query string) string {
 	query = strings.TrimSpace(query)
 	words := strings.Fields(query)
-	
+
 	if len(words) < 2 {
 		return query
 	}
-	
+
 	// Check if the first word looks like a house number
 	firstWord := words[0]
-	
+
 	// House numbers are typically:
 	// - Pure digits: "123"
 	// - Digits with letter suffix: "123A", "456B"
 	// - Digit ranges: "100-102"
 	isHouseNumber := false
-	
+
 	// Check if it starts with a digit
 	if len(firstWord) > 0 && firstWord[0] >= '0' && firstWord[0] <= '9' {
 		isHouseNumber = true
@@ -783,12 +1241,12 @@ func extractStreetFromQuery(query string) string {
 			isHouseNumber = false
 		}
 	}
-	
+
 	if isHouseNumber {
 		// Return everything after the house number
 		return strings.Join(words[1:], " ")
 	}
-	
+
 	return query
 }
 
@@ -803,14 +1261,10 @@ func (s *AddressService) CreateAddress(address *models.OhioAddress) (int, error)
 		RETURNING id
 	`
 
-	// Generate hash for deduplication
-	hash := fmt.Sprintf("%s|%s|%s|%s|%s",
-		address.HouseNumber, address.Street, address.Unit, address.City, address.Postcode)
-
 	var id int
 	err := s.db.QueryRow(
 		query,
-		hash,
+		addressHash(address),
 		address.HouseNumber,
 		address.Street,
 		address.Unit,
@@ -826,6 +1280,12 @@ func (s *AddressService) CreateAddress(address *models.OhioAddress) (int, error)
 	return id, err
 }
 
+// addressHash builds the deduplication key stored in ohio_addresses.hash
+func addressHash(address *models.OhioAddress) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s",
+		address.HouseNumber, address.Street, address.Unit, address.City, address.Postcode)
+}
+
 // Global address service instance
 var Address *AddressService
 
@@ -840,4 +1300,4 @@ func GetDB() *sql.DB {
 		return Address.db
 	}
 	return nil
-}
\ No newline at end of file
+}
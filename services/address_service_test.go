@@ -0,0 +1,53 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAddressSearchQueryDedupe(t *testing.T) {
+	plain := buildAddressSearchQuery(models.AddressSearchParams{City: "Cincinnati"})
+	assert.Equal(t, "ohio_addresses", plain.from)
+	assert.False(t, plain.hasUnitCount)
+
+	q := buildAddressSearchQuery(models.AddressSearchParams{City: "Cincinnati", Query: "oakley", Dedupe: true})
+	assert.True(t, q.hasUnitCount)
+	assert.Equal(t, "WHERE building_rank = 1", q.whereClause)
+	// The filters move into the subquery that ranks the units of each building
+	assert.Contains(t, q.from, "search_vector @@ to_tsquery('simple', $1) AND city ILIKE $2")
+	assert.Contains(t, q.from, "PARTITION BY "+buildingKey)
+	assert.True(t, strings.HasSuffix(q.baseQuery, "relevance_score, unit_count FROM "+q.from))
+	assert.Len(t, q.args, 2)
+}
+
+func TestBuildAddressSearchQueryBBox(t *testing.T) {
+	q := buildAddressSearchQuery(models.AddressSearchParams{City: "Cincinnati", BBox: []float64{-84.6, 39.0, -84.3, 39.3}, Sample: 0.5})
+	assert.Contains(t, q.whereClause, "city ILIKE $1 AND geom && ST_MakeEnvelope($2, $3, $4, $5, 4326) AND ")
+	assert.Equal(t, []interface{}{"%Cincinnati%", -84.6, 39.0, -84.3, 39.3, 5000}, q.args)
+	assert.Equal(t, 7, q.argIndex)
+}
+
+func TestBuildAddressSearchQueryUnit(t *testing.T) {
+	q := buildAddressSearchQuery(models.AddressSearchParams{HouseNumber: "2525", Unit: "3"})
+	assert.Contains(t, q.whereClause, "house_number = $1 AND unit ~* $2")
+
+	// Go's regexp reads the pattern the way Postgres does
+	pattern := regexp.MustCompile("(?i)" + q.args[1].(string))
+	for _, unit := range []string{"3", "APT 3", "Apt #3", "#3"} {
+		assert.True(t, pattern.MatchString(unit), unit)
+	}
+	for _, unit := range []string{"13", "3B", "APT 30"} {
+		assert.False(t, pattern.MatchString(unit), unit)
+	}
+}
+
+func TestAddressClusterRadius(t *testing.T) {
+	assert.Equal(t, float64(DefaultAddressClusterRadius), AddressClusterRadius(0))
+	assert.Equal(t, 250.0, AddressClusterRadius(250))
+	assert.Equal(t, float64(MaxAddressClusterRadius), AddressClusterRadius(1e6))
+}
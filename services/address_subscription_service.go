@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+const (
+	// maxAddressSubscriptionsPerUser caps how many areas one user may watch
+	maxAddressSubscriptionsPerUser = 25
+	// addressChangeRetention is how long detected changes can be listed
+	addressChangeRetention = 90 * 24 * time.Hour
+)
+
+var (
+	// ErrAddressSubscriptionNotFound is returned when a subscription doesn't exist or belongs to another user
+	ErrAddressSubscriptionNotFound = errors.New("address subscription not found")
+	// ErrAddressSubscriptionLimit is returned when the user has as many subscriptions as allowed
+	ErrAddressSubscriptionLimit = fmt.Errorf("address subscription limit reached (%d per user)", maxAddressSubscriptionsPerUser)
+	// ErrAddressSubscriptionInvalid wraps problems with the submitted target
+	ErrAddressSubscriptionInvalid = errors.New("invalid address subscription")
+)
+
+// subscriptionZipPattern is a five-digit ZIP code
+var subscriptionZipPattern = regexp.MustCompile(`^\d{5}$`)
+
+// AddressSubscriptionService stores the areas users watch for address
+// changes, diffs replacement imports against the addresses they replaced,
+// and notifies the subscribers of the areas that changed
+type AddressSubscriptionService struct{}
+
+var AddressSubscriptions = &AddressSubscriptionService{}
+
+const addressSubscriptionFields = `id, user_id, name, target_type, COALESCE(zip_code, ''), COALESCE(state, ''),
+	COALESCE(county, ''), COALESCE(ST_AsGeoJSON(geom), ''), notify_email, is_active, created_at, updated_at`
+
+// addressChangeMatches is true when change c lies inside subscription s.
+// ZIP+4 postcodes match their five-digit ZIP code.
+const addressChangeMatches = `(
+	(s.target_type = 'zip' AND LEFT(c.postcode, 5) = s.zip_code)
+	OR (s.target_type = 'county' AND UPPER(c.county) = UPPER(s.county)
+		AND (s.state IS NULL OR UPPER(c.state) = UPPER(s.state)))
+	OR (s.target_type = 'polygon' AND ST_Intersects(s.geom, c.geom))
+)`
+
+func scanAddressSubscription(scanner interface{ Scan(...interface{}) error }) (*models.AddressSubscription, error) {
+	var sub models.AddressSubscription
+	var geometry string
+	err := scanner.Scan(&sub.ID, &sub.UserID, &sub.Name, &sub.TargetType, &sub.ZipCode, &sub.State,
+		&sub.County, &geometry, &sub.NotifyEmail, &sub.IsActive, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if geometry != "" {
+		sub.Geometry = json.RawMessage(geometry)
+	}
+	return &sub, nil
+}
+
+// addressSubscriptionTarget is the validated area a subscription watches
+type addressSubscriptionTarget struct {
+	name       string
+	targetType string
+	zipCode    string
+	state      string
+	county     string
+	geometry   string
+}
+
+// validateAddressSubscriptionRequest checks that a request names exactly one
+// of a ZIP code, a county or a polygon
+func validateAddressSubscriptionRequest(req models.AddressSubscriptionRequest) (addressSubscriptionTarget, error) {
+	target := addressSubscriptionTarget{
+		name:    strings.TrimSpace(req.Name),
+		zipCode: strings.TrimSpace(req.ZipCode),
+		state:   strings.ToUpper(strings.TrimSpace(req.State)),
+		county:  strings.TrimSpace(req.County),
+	}
+	if target.name == "" || len(target.name) > 255 {
+		return target, fmt.Errorf("%w: name is required and must be at most 255 characters", ErrAddressSubscriptionInvalid)
+	}
+	hasGeometry := len(req.Geometry) > 0 && string(req.Geometry) != "null"
+
+	targets := 0
+	for _, given := range []bool{target.zipCode != "", target.county != "", hasGeometry} {
+		if given {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return target, fmt.Errorf("%w: give exactly one of zip_code, county or geometry", ErrAddressSubscriptionInvalid)
+	}
+	if target.state != "" && target.county == "" {
+		return target, fmt.Errorf("%w: state is only used with county", ErrAddressSubscriptionInvalid)
+	}
+
+	switch {
+	case target.zipCode != "":
+		if !subscriptionZipPattern.MatchString(target.zipCode) {
+			return target, fmt.Errorf("%w: zip_code must be a five-digit ZIP code", ErrAddressSubscriptionInvalid)
+		}
+		target.targetType = models.AddressSubscriptionZip
+	case target.county != "":
+		target.county = strings.TrimSpace(strings.TrimSuffix(target.county, " County"))
+		target.targetType = models.AddressSubscriptionCounty
+	default:
+		geometry, err := polygonGeometry(req.Geometry, ErrAddressSubscriptionInvalid)
+		if err != nil {
+			return target, err
+		}
+		target.geometry = geometry
+		target.targetType = models.AddressSubscriptionPolygon
+	}
+	return target, nil
+}
+
+// CreateSubscription stores a new address subscription for userID
+func (s *AddressSubscriptionService) CreateSubscription(ctx context.Context, userID int, req models.AddressSubscriptionRequest) (*models.AddressSubscription, error) {
+	target, err := validateAddressSubscriptionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if target.geometry != "" {
+		if err := checkGeometry(ctx, target.geometry, maxGeofenceVertices, ErrAddressSubscriptionInvalid); err != nil {
+			return nil, err
+		}
+	}
+
+	var count int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM address_subscriptions WHERE user_id = $1", userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count address subscriptions: %w", err)
+	}
+	if count >= maxAddressSubscriptionsPerUser {
+		return nil, ErrAddressSubscriptionLimit
+	}
+
+	notifyEmail := req.NotifyEmail == nil || *req.NotifyEmail
+	isActive := req.IsActive == nil || *req.IsActive
+	sub, err := scanAddressSubscription(database.DB.QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO address_subscriptions (user_id, name, target_type, zip_code, state, county, geom, notify_email, is_active)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''),
+			CASE WHEN $7 = '' THEN NULL ELSE ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($7), 4326)) END, $8, $9)
+		RETURNING %s
+	`, addressSubscriptionFields), userID, target.name, target.targetType, target.zipCode, target.state,
+		target.county, target.geometry, notifyEmail, isActive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create address subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// UpdateSubscription replaces a subscription's name, target and settings
+func (s *AddressSubscriptionService) UpdateSubscription(ctx context.Context, userID, id int, req models.AddressSubscriptionRequest) (*models.AddressSubscription, error) {
+	target, err := validateAddressSubscriptionRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if target.geometry != "" {
+		if err := checkGeometry(ctx, target.geometry, maxGeofenceVertices, ErrAddressSubscriptionInvalid); err != nil {
+			return nil, err
+		}
+	}
+
+	notifyEmail := req.NotifyEmail == nil || *req.NotifyEmail
+	isActive := req.IsActive == nil || *req.IsActive
+	sub, err := scanAddressSubscription(database.DB.QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE address_subscriptions
+		SET name = $3, target_type = $4, zip_code = NULLIF($5, ''), state = NULLIF($6, ''), county = NULLIF($7, ''),
+			geom = CASE WHEN $8 = '' THEN NULL ELSE ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($8), 4326)) END,
+			notify_email = $9, is_active = $10, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING %s
+	`, addressSubscriptionFields), id, userID, target.name, target.targetType, target.zipCode, target.state,
+		target.county, target.geometry, notifyEmail, isActive))
+	if err == sql.ErrNoRows {
+		return nil, ErrAddressSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update address subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// GetUserSubscriptions lists userID's address subscriptions by name
+func (s *AddressSubscriptionService) GetUserSubscriptions(ctx context.Context, userID int) ([]*models.AddressSubscription, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM address_subscriptions WHERE user_id = $1 ORDER BY name, id", addressSubscriptionFields), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list address subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []*models.AddressSubscription{}
+	for rows.Next() {
+		sub, err := scanAddressSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// GetSubscription returns one of userID's address subscriptions
+func (s *AddressSubscriptionService) GetSubscription(ctx context.Context, userID, id int) (*models.AddressSubscription, error) {
+	sub, err := scanAddressSubscription(database.DB.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM address_subscriptions WHERE id = $1 AND user_id = $2", addressSubscriptionFields),
+		id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAddressSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteSubscription removes one of userID's address subscriptions
+func (s *AddressSubscriptionService) DeleteSubscription(ctx context.Context, userID, id int) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM address_subscriptions WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete address subscription: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAddressSubscriptionNotFound
+	}
+	return nil
+}
+
+// GetChanges pages through the detected changes inside one of userID's
+// subscriptions, newest first, optionally for one import (datasetID) or
+// change type
+func (s *AddressSubscriptionService) GetChanges(ctx context.Context, userID, id, datasetID int, changeType string, limit, offset int) ([]models.AddressChange, int, error) {
+	if _, err := s.GetSubscription(ctx, userID, id); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT c.id, c.dataset_id, c.change_type, c.address_id, COALESCE(c.full_address, ''),
+			COALESCE(c.postcode, ''), c.state, c.county, COALESCE(ST_Y(c.geom), 0), COALESCE(ST_X(c.geom), 0),
+			c.detected_at, COUNT(*) OVER()
+		FROM address_changes c
+		JOIN address_subscriptions s ON s.id = $1
+		WHERE %s AND ($2 = 0 OR c.dataset_id = $2) AND ($3 = '' OR c.change_type = $3)
+		ORDER BY c.id DESC
+		LIMIT $4 OFFSET $5
+	`, addressChangeMatches), id, datasetID, changeType, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list address changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.AddressChange{}
+	total := 0
+	for rows.Next() {
+		var change models.AddressChange
+		var addressID sql.NullInt64
+		if err := rows.Scan(&change.ID, &change.DatasetID, &change.ChangeType, &addressID, &change.FullAddress,
+			&change.Postcode, &change.State, &change.County, &change.Latitude, &change.Longitude,
+			&change.DetectedAt, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan address change: %w", err)
+		}
+		if addressID.Valid {
+			change.AddressID = &addressID.Int64
+		}
+		changes = append(changes, change)
+	}
+	return changes, total, rows.Err()
+}
+
+// DetectChanges diffs a completed import of state/county against the
+// addresses ReplaceDatasets set aside for it, records what was added and
+// removed, and notifies the subscribers of the areas that changed. A first
+// import of a county has nothing to compare with and is skipped.
+func (s *AddressSubscriptionService) DetectChanges(ctx context.Context, datasetID int, state, county string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var replaced bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM replaced_addresses WHERE state = UPPER($1) AND county = UPPER($2))
+	`, state, county).Scan(&replaced); err != nil {
+		return fmt.Errorf("failed to check replaced addresses: %w", err)
+	}
+	if !replaced {
+		return nil
+	}
+
+	added, err := tx.ExecContext(ctx, `
+		INSERT INTO address_changes (dataset_id, change_type, address_id, hash, full_address, postcode, state, county, geom)
+		SELECT $3, 'added', a.id, a.hash, a.full_address, a.postcode, $1, $2, a.geom
+		FROM ohio_addresses a
+		WHERE UPPER(COALESCE(a.region, '')) = UPPER($1) AND UPPER(a.county) = UPPER($2)
+			AND NOT EXISTS (
+				SELECT 1 FROM replaced_addresses r
+				WHERE r.state = UPPER($1) AND r.county = UPPER($2) AND r.hash = a.hash
+			)
+	`, state, county, datasetID)
+	if err != nil {
+		return fmt.Errorf("failed to record added addresses: %w", err)
+	}
+	removed, err := tx.ExecContext(ctx, `
+		INSERT INTO address_changes (dataset_id, change_type, hash, full_address, postcode, state, county, geom)
+		SELECT $3, 'removed', r.hash, r.full_address, r.postcode, $1, $2, r.geom
+		FROM replaced_addresses r
+		WHERE r.state = UPPER($1) AND r.county = UPPER($2)
+			AND NOT EXISTS (SELECT 1 FROM ohio_addresses a WHERE a.hash = r.hash)
+	`, state, county, datasetID)
+	if err != nil {
+		return fmt.Errorf("failed to record removed addresses: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM replaced_addresses WHERE state = UPPER($1) AND county = UPPER($2)
+	`, state, county); err != nil {
+		return fmt.Errorf("failed to clear replaced addresses: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM address_changes WHERE detected_at < $1
+	`, time.Now().Add(-addressChangeRetention)); err != nil {
+		return fmt.Errorf("failed to prune address changes: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address changes: %w", err)
+	}
+
+	addedCount, _ := added.RowsAffected()
+	removedCount, _ := removed.RowsAffected()
+	slog.Info("detected address changes", "dataset_id", datasetID, "state", state, "county", county,
+		"added", addedCount, "removed", removedCount)
+	if addedCount+removedCount == 0 {
+		return nil
+	}
+	return s.notify(ctx, datasetID, state, county)
+}
+
+// notify queues an address.changed webhook for, and optionally emails, the
+// owner of every active subscription with changes from the import
+func (s *AddressSubscriptionService) notify(ctx context.Context, datasetID int, state, county string) error {
+	rows, err := database.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT s.id, s.user_id, s.name, s.notify_email, u.email,
+			COUNT(*) FILTER (WHERE c.change_type = 'added'),
+			COUNT(*) FILTER (WHERE c.change_type = 'removed')
+		FROM address_subscriptions s
+		JOIN users u ON u.id = s.user_id AND u.is_active = true AND u.deleted_at IS NULL
+		JOIN address_changes c ON c.dataset_id = $1 AND %s
+		WHERE s.is_active = true
+		GROUP BY s.id, u.email
+	`, addressChangeMatches), datasetID)
+	if err != nil {
+		return fmt.Errorf("failed to match address subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	mailer := NewMailer()
+	for rows.Next() {
+		var userID int
+		var notifyEmail bool
+		var email string
+		summary := models.AddressChangeSummary{DatasetID: datasetID, State: state, County: county}
+		if err := rows.Scan(&summary.SubscriptionID, &userID, &summary.Name, &notifyEmail, &email,
+			&summary.Added, &summary.Removed); err != nil {
+			return fmt.Errorf("failed to scan address subscription: %w", err)
+		}
+
+		dedupeKey := fmt.Sprintf("address_changes:%d:%d", summary.SubscriptionID, datasetID)
+		if err := Webhooks.Emit(userID, models.WebhookEventAddressChanged, dedupeKey, summary); err != nil {
+			slog.Warn("failed to queue webhook", "event", models.WebhookEventAddressChanged, "user_id", userID, "error", err)
+		}
+		if notifyEmail {
+			message := addressChangeEmail(summary)
+			message.To = email
+			if err := mailer.Send(message); err != nil {
+				slog.Warn("failed to send address change email", "user_id", userID, "subscription_id", summary.SubscriptionID, "error", err)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// addressChangeEmail describes the changes an import made inside a
+// subscription; To is filled in by the caller
+func addressChangeEmail(summary models.AddressChangeSummary) EmailMessage {
+	return EmailMessage{
+		Subject: fmt.Sprintf("Address changes in %s: %d added, %d removed", summary.Name, summary.Added, summary.Removed),
+		Body: fmt.Sprintf(`New address data for %s County, %s changed addresses inside your subscription "%s":
+
+Added: %d
+Removed: %d
+
+List them with GET /api/v1/user/address-subscriptions/%d/changes?dataset_id=%d
+
+Details: %s/dashboard
+`,
+			summary.County, summary.State, summary.Name, summary.Added, summary.Removed,
+			summary.SubscriptionID, summary.DatasetID, AppURL()),
+	}
+}
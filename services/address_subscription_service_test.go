@@ -0,0 +1,63 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAddressSubscriptionRequest(t *testing.T) {
+	polygon := `{"type":"Polygon","coordinates":[[[-84.53,39.09],[-84.49,39.09],[-84.49,39.12],[-84.53,39.09]]]}`
+
+	tests := []struct {
+		name       string
+		req        models.AddressSubscriptionRequest
+		wantType   string
+		wantCounty string
+		wantErr    bool
+	}{
+		{"zip", models.AddressSubscriptionRequest{Name: "Downtown", ZipCode: "43215"}, models.AddressSubscriptionZip, "", false},
+		{"county", models.AddressSubscriptionRequest{Name: "Franklin", County: "Franklin County", State: "oh"},
+			models.AddressSubscriptionCounty, "Franklin", false},
+		{"polygon", models.AddressSubscriptionRequest{Name: "Service area", Geometry: json.RawMessage(polygon)},
+			models.AddressSubscriptionPolygon, "", false},
+		{"zip+4", models.AddressSubscriptionRequest{Name: "Downtown", ZipCode: "43215-1234"}, "", "", true},
+		{"no target", models.AddressSubscriptionRequest{Name: "Nothing"}, "", "", true},
+		{"null geometry", models.AddressSubscriptionRequest{Name: "Nothing", Geometry: json.RawMessage(`null`)}, "", "", true},
+		{"two targets", models.AddressSubscriptionRequest{Name: "Both", ZipCode: "43215", County: "Franklin"}, "", "", true},
+		{"state without county", models.AddressSubscriptionRequest{Name: "Zip", ZipCode: "43215", State: "OH"}, "", "", true},
+		{"point", models.AddressSubscriptionRequest{Name: "Point", Geometry: json.RawMessage(`{"type":"Point","coordinates":[-84.5,39.1]}`)},
+			"", "", true},
+		{"blank name", models.AddressSubscriptionRequest{Name: " ", ZipCode: "43215"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := validateAddressSubscriptionRequest(tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrAddressSubscriptionInvalid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, target.targetType)
+			assert.Equal(t, tt.wantCounty, target.county)
+		})
+	}
+
+	target, err := validateAddressSubscriptionRequest(models.AddressSubscriptionRequest{Name: "Franklin", County: "Franklin", State: "oh"})
+	require.NoError(t, err)
+	assert.Equal(t, "OH", target.state)
+}
+
+func TestAddressChangeEmail(t *testing.T) {
+	msg := addressChangeEmail(models.AddressChangeSummary{
+		SubscriptionID: 7, Name: "Downtown", DatasetID: 42, State: "OH", County: "Franklin", Added: 12, Removed: 3,
+	})
+	assert.Equal(t, "Address changes in Downtown: 12 added, 3 removed", msg.Subject)
+	assert.Contains(t, msg.Body, "Franklin County, OH")
+	assert.Contains(t, msg.Body, "/user/address-subscriptions/7/changes?dataset_id=42")
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"geocoding-api/models"
+	"geocoding-api/utils"
+)
+
+// ValidateAddress checks a parsed address against the loaded address points
+// and reports the most specific level that could be verified: the exact
+// address, its street, or only its ZIP code
+func (s *AddressService) ValidateAddress(ctx context.Context, parsed *utils.ParsedAddress) (*models.AddressValidationResult, error) {
+	normalized := utils.NormalizeStreetName(parsed.Street)
+
+	if parsed.HouseNumber != "" && normalized != "" {
+		address, err := s.matchAddressPoint(ctx, parsed, normalized)
+		if err != nil {
+			return nil, err
+		}
+		if address != nil {
+			return &models.AddressValidationResult{
+				Verdict:     models.AddressVerified,
+				Deliverable: true,
+				Address:     address,
+				Corrected:   correctedComponents(parsed, normalized, address.Street, address.City, address.Postcode),
+			}, nil
+		}
+	}
+
+	if normalized != "" {
+		street, err := s.matchStreet(ctx, parsed, normalized)
+		if err != nil {
+			return nil, err
+		}
+		if street != nil {
+			return &models.AddressValidationResult{
+				Verdict:   models.AddressStreetMatch,
+				Street:    street,
+				Corrected: correctedComponents(parsed, normalized, street.Street, street.City, street.Postcode),
+			}, nil
+		}
+	}
+
+	if parsed.Zip != "" {
+		zipCode, err := GetZipCodeByZip(ctx, parsed.Zip)
+		if err != nil {
+			return nil, err
+		}
+		if zipCode != nil {
+			return &models.AddressValidationResult{Verdict: models.AddressZipOnly, ZipCode: zipCode}, nil
+		}
+	}
+
+	return &models.AddressValidationResult{Verdict: models.AddressUnknown}, nil
+}
+
+// matchAddressPoint finds the address point with the submitted house number
+// on the submitted street, tolerating a misspelled street. Without a city or
+// ZIP the match must be unique, since the same address exists in many towns.
+func (s *AddressService) matchAddressPoint(ctx context.Context, parsed *utils.ParsedAddress, normalized string) (*models.OhioAddress, error) {
+	args := []interface{}{normalized, maxStreetEdits(normalized), parsed.HouseNumber}
+	location, locationOrder := validationLocation(parsed, &args)
+
+	query := fmt.Sprintf(`
+		SELECT id, hash, house_number, street, COALESCE(unit, ''), COALESCE(city, ''),
+			COALESCE(district, ''), COALESCE(region, ''), COALESCE(postcode, ''), COALESCE(county, ''),
+			COALESCE(full_address, ''), ST_Y(geom), ST_X(geom), created_at
+		FROM ohio_addresses
+		WHERE house_number = $3 AND %s%s
+		ORDER BY %snormalize_street_name(street) = $1 DESC, similarity(normalize_street_name(street), $1) DESC, id
+		LIMIT 2
+	`, streetMatchClause, location, locationOrder)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match address: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []models.OhioAddress
+	for rows.Next() {
+		var addr models.OhioAddress
+		if err := rows.Scan(
+			&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
+			&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
+			&addr.Latitude, &addr.Longitude, &addr.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		matches = append(matches, addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating address rows: %w", err)
+	}
+
+	if len(matches) == 0 || (len(matches) > 1 && location == "") {
+		return nil, nil
+	}
+	return &matches[0], nil
+}
+
+// matchStreet finds the street index entry for the submitted street,
+// preferring one in the submitted city or ZIP
+func (s *AddressService) matchStreet(ctx context.Context, parsed *utils.ParsedAddress, normalized string) (*models.Street, error) {
+	args := []interface{}{normalized, maxStreetEdits(normalized)}
+	location, locationOrder := validationLocation(parsed, &args)
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM streets
+		WHERE %s%s
+		ORDER BY %snormalize_street_name(street) = $1 DESC, similarity(normalize_street_name(street), $1) DESC, address_count DESC
+		LIMIT 1
+	`, streetFields, streetMatchClause, location, locationOrder)
+
+	street, err := scanStreet(s.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to match street: %w", err)
+	}
+	return &street, nil
+}
+
+// streetMatchClause matches a street whose normalized name ($1) is the same
+// or, by trigram similarity, within $2 edits
+const streetMatchClause = `(normalize_street_name(street) = $1 OR
+	(normalize_street_name(street) % $1 AND levenshtein(normalize_street_name(street), $1) <= $2))`
+
+// validationLocation returns a WHERE fragment requiring the submitted city
+// or ZIP and an ORDER BY prefix preferring rows matching both, appending
+// their arguments to args. Both are empty when neither was submitted.
+func validationLocation(parsed *utils.ParsedAddress, args *[]interface{}) (where, order string) {
+	var conditions []string
+	for _, component := range []struct {
+		value, condition string
+	}{
+		{parsed.Zip, "postcode = $%d"},
+		{parsed.City, "city ILIKE $%d"},
+	} {
+		if component.value == "" {
+			continue
+		}
+		*args = append(*args, component.value)
+		conditions = append(conditions, fmt.Sprintf(component.condition, len(*args)))
+	}
+
+	switch len(conditions) {
+	case 0:
+		return "", ""
+	case 1:
+		return " AND " + conditions[0], ""
+	default:
+		return " AND (" + strings.Join(conditions, " OR ") + ")",
+			"(" + strings.Join(conditions, " AND ") + ") DESC, "
+	}
+}
+
+// correctedComponents lists the submitted components that differ from the
+// matched street, city and ZIP
+func correctedComponents(parsed *utils.ParsedAddress, normalized, street, city, postcode string) []string {
+	var corrected []string
+	if utils.NormalizeStreetName(street) != normalized {
+		corrected = append(corrected, "street")
+	}
+	if parsed.City != "" && !strings.EqualFold(parsed.City, city) {
+		corrected = append(corrected, "city")
+	}
+	if parsed.Zip != "" && parsed.Zip != postcode {
+		corrected = append(corrected, "postcode")
+	}
+	return corrected
+}
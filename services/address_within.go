@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"geocoding-api/models"
+)
+
+// maxWithinPolygonVertices caps the polygons of an address search by area,
+// which is tested against every candidate point
+const maxWithinPolygonVertices = 5000
+
+// ErrAddressPolygonInvalid wraps problems with the polygon of an address
+// search by area
+var ErrAddressPolygonInvalid = errors.New("invalid polygon")
+
+// withinPolygon parses the search polygon once for the whole query
+const withinPolygon = `WITH area AS (SELECT ST_SetSRID(ST_GeomFromGeoJSON($1), 4326) AS shape)`
+
+// AddressesWithin returns one page of the address points inside a GeoJSON
+// Polygon or MultiPolygon and the total number inside. With countOnly no
+// rows are read. ST_Contains uses the GIST index on geom through the
+// polygon's bounding box before the exact test.
+func (s *AddressService) AddressesWithin(ctx context.Context, geometry []byte, limit, offset int, countOnly bool) ([]models.OhioAddress, int, error) {
+	polygon, err := polygonGeometry(geometry, ErrAddressPolygonInvalid)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := checkGeometry(ctx, polygon, maxWithinPolygonVertices, ErrAddressPolygonInvalid); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := s.reader(ctx).QueryRowContext(ctx, withinPolygon+`
+		SELECT COUNT(*) FROM ohio_addresses, area
+		WHERE ST_Contains(area.shape, geom)
+	`, polygon).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count addresses within polygon: %w", err)
+	}
+	addresses := []models.OhioAddress{}
+	if countOnly || total <= offset {
+		return addresses, total, nil
+	}
+
+	rows, err := s.reader(ctx).QueryContext(ctx, withinPolygon+`
+		SELECT id, hash, house_number, street, COALESCE(unit, ''), COALESCE(city, ''),
+			COALESCE(district, ''), COALESCE(region, ''), COALESCE(postcode, ''), COALESCE(county, ''),
+			COALESCE(full_address, ''), ST_Y(geom), ST_X(geom), created_at
+		FROM ohio_addresses, area
+		WHERE ST_Contains(area.shape, geom)
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`, polygon, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query addresses within polygon: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		addr, err := scanAddressSearchRow(rows, false, false)
+		if err != nil {
+			return nil, 0, err
+		}
+		addresses = append(addresses, *addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating address rows: %w", err)
+	}
+	return addresses, total, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// AuditService records admin and security-sensitive actions for compliance review
+type AuditService struct{}
+
+var Audit = &AuditService{}
+
+// Record appends an entry to the audit log. Entries are never updated or deleted.
+func (a *AuditService) Record(ctx context.Context, entry models.AuditLogEntry) error {
+	var details interface{}
+	if len(entry.Details) > 0 {
+		details = string(entry.Details)
+	}
+
+	_, err := database.DB.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_user_id, actor_email, action, target_type, target_id, details, ip_address, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), NULLIF($5, ''), $6, NULLIF($7, ''), NOW())
+	`, entry.ActorUserID, entry.ActorEmail, entry.Action, entry.TargetType, entry.TargetID, details, entry.IPAddress)
+	if err != nil {
+		return fmt.Errorf("failed to record audit log entry: %w", err)
+	}
+	return nil
+}
+
+// List returns a page of audit log entries matching filter, newest first,
+// along with the total number of matches
+func (a *AuditService) List(ctx context.Context, filter models.AuditLogFilter) ([]models.AuditLogEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.Action != "" {
+		// A trailing ".*" matches every action in a group, e.g. "api_key.*"
+		if group := strings.TrimSuffix(filter.Action, "*"); group != filter.Action {
+			conditions = append(conditions, fmt.Sprintf("action LIKE $%d", argIndex))
+			args = append(args, group+"%")
+		} else {
+			conditions = append(conditions, fmt.Sprintf("action = $%d", argIndex))
+			args = append(args, filter.Action)
+		}
+		argIndex++
+	}
+	if filter.ActorUserID > 0 {
+		conditions = append(conditions, fmt.Sprintf("actor_user_id = $%d", argIndex))
+		args = append(args, filter.ActorUserID)
+		argIndex++
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, fmt.Sprintf("target_type = $%d", argIndex))
+		args = append(args, filter.TargetType)
+		argIndex++
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, fmt.Sprintf("target_id = $%d", argIndex))
+		args = append(args, filter.TargetID)
+		argIndex++
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argIndex))
+		args = append(args, filter.Since)
+		argIndex++
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argIndex))
+		args = append(args, filter.Until)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_user_id, COALESCE(actor_email, ''), action, COALESCE(target_type, ''),
+			COALESCE(target_id, ''), details, COALESCE(ip_address, ''), created_at
+		FROM audit_log %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+	rows, err := database.DB.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditLogEntry{}
+	for rows.Next() {
+		var entry models.AuditLogEntry
+		var details []byte
+		if err := rows.Scan(&entry.ID, &entry.ActorUserID, &entry.ActorEmail, &entry.Action, &entry.TargetType,
+			&entry.TargetID, &details, &entry.IPAddress, &entry.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+		if len(details) > 0 {
+			entry.Details = details
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, total, rows.Err()
+}
@@ -1,16 +1,20 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"geocoding-api/config"
 	"geocoding-api/database"
 	"geocoding-api/models"
 
@@ -27,25 +31,74 @@ type JWTClaims struct {
 	UserID   int    `json:"user_id"`
 	Email    string `json:"email"`
 	IsAdmin  bool   `json:"is_admin"`
+	// SessionID identifies the login session so its tokens can be revoked together
+	SessionID string `json:"sid"`
 	jwt.StandardClaims
 }
 
-// GenerateJWT creates a new JWT token for a user
+// jwtSettings holds the signing secret and scoping claims for tokens
+type jwtSettings struct {
+	secret   string
+	lifetime time.Duration
+	issuer   string
+	audience string
+}
+
+// loadJWTSettings returns the configured JWT secret, lifetime, issuer and
+// audience. Distinct issuer/audience values per environment keep staging
+// tokens from being accepted in production.
+func loadJWTSettings() jwtSettings {
+	auth := config.Get().Auth
+	return jwtSettings{
+		secret:   auth.JWTSecret,
+		lifetime: auth.JWTLifetime,
+		issuer:   auth.JWTIssuer,
+		audience: auth.JWTAudience,
+	}
+}
+
+// generateSessionID returns a random identifier for a login session
+func generateSessionID() (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// GenerateJWT creates a new JWT token for a user, starting a new session
 func (as *AuthService) GenerateJWT(user *models.User) (string, error) {
-	// Get JWT secret from environment or use default for development
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production"
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return "", err
+	}
+	return as.GenerateSessionJWT(user, sessionID)
+}
+
+// GenerateSessionJWT creates a JWT token for a user within an existing session
+func (as *AuthService) GenerateSessionJWT(user *models.User, sessionID string) (string, error) {
+	settings := loadJWTSettings()
+
+	tokenID, err := generateSessionID()
+	if err != nil {
+		return "", err
 	}
 
 	// Create claims with user data
+	now := time.Now()
 	claims := JWTClaims{
-		UserID:  user.ID,
-		Email:   user.Email,
-		IsAdmin: user.IsAdmin,
+		UserID:    user.ID,
+		Email:     user.Email,
+		IsAdmin:   user.IsAdmin,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(), // Token expires in 24 hours
-			IssuedAt:  time.Now().Unix(),
+			Id:        tokenID,
+			Subject:   strconv.Itoa(user.ID),
+			Issuer:    settings.issuer,
+			Audience:  settings.audience,
+			ExpiresAt: now.Add(settings.lifetime).Unix(),
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
 		},
 	}
 
@@ -53,7 +106,7 @@ func (as *AuthService) GenerateJWT(user *models.User) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign token with secret
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := token.SignedString([]byte(settings.secret))
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -61,13 +114,10 @@ func (as *AuthService) GenerateJWT(user *models.User) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateJWT validates a JWT token and returns the claims
+// ValidateJWT validates a JWT token's signature, lifetime, issuer, audience
+// and session and returns the claims
 func (as *AuthService) ValidateJWT(tokenString string) (*JWTClaims, error) {
-	// Get JWT secret from environment or use default for development
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production"
-	}
+	settings := loadJWTSettings()
 
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -75,7 +125,7 @@ func (as *AuthService) ValidateJWT(tokenString string) (*JWTClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
+		return []byte(settings.secret), nil
 	})
 
 	if err != nil {
@@ -83,20 +133,107 @@ func (as *AuthService) ValidateJWT(tokenString string) (*JWTClaims, error) {
 	}
 
 	// Extract claims
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if !claims.VerifyIssuer(settings.issuer, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+	if !claims.VerifyAudience(settings.audience, true) {
+		return nil, fmt.Errorf("invalid token audience")
+	}
+	if claims.SessionID == "" {
+		return nil, fmt.Errorf("token has no session")
+	}
+	revoked, err := as.IsSessionRevoked(context.Background(), claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("session has been revoked")
+	}
+
+	return claims, nil
+}
+
+// sessionCheckTTL is how long a session found live in the database is
+// trusted before it is checked again, which bounds how long a logout on
+// another instance takes to apply here
+const sessionCheckTTL = 30 * time.Second
+
+type sessionState struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// sessionStates caches session revocation checks: revoked sessions until
+// every access token in them has expired, live ones for sessionCheckTTL
+var sessionStates = struct {
+	sync.Mutex
+	entries  map[string]sessionState
+	prunedAt time.Time
+}{entries: make(map[string]sessionState)}
+
+// cacheSessionState records whether sessionID is revoked
+func cacheSessionState(sessionID string, revoked bool) {
+	sessionStates.Lock()
+	defer sessionStates.Unlock()
+
+	now := time.Now()
+	if now.Sub(sessionStates.prunedAt) > sessionCheckTTL {
+		for id, state := range sessionStates.entries {
+			if now.After(state.expiresAt) {
+				delete(sessionStates.entries, id)
+			}
+		}
+		sessionStates.prunedAt = now
 	}
+	ttl := sessionCheckTTL
+	if revoked {
+		ttl = loadJWTSettings().lifetime
+	}
+	sessionStates.entries[sessionID] = sessionState{revoked: revoked, expiresAt: now.Add(ttl)}
+}
+
+// RevokeSession invalidates every token issued for sessionID on this
+// instance at once; others see it from the session's revoked refresh tokens
+// within sessionCheckTTL
+func (as *AuthService) RevokeSession(sessionID string) {
+	cacheSessionState(sessionID, true)
+}
 
-	return nil, fmt.Errorf("invalid token")
+// IsSessionRevoked reports whether sessionID has been revoked, i.e. every
+// refresh token in it is. The answer comes from refresh_tokens, so it holds
+// across restarts and instances, and is cached briefly.
+func (as *AuthService) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	sessionStates.Lock()
+	state, ok := sessionStates.entries[sessionID]
+	sessionStates.Unlock()
+	if ok && time.Now().Before(state.expiresAt) {
+		return state.revoked, nil
+	}
+
+	var revoked bool
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(bool_and(revoked_at IS NOT NULL), false)
+		FROM refresh_tokens WHERE session_id = $1
+	`, sessionID).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	cacheSessionState(sessionID, revoked)
+	return revoked, nil
 }
 
 var Auth = &AuthService{}
 
 // RegisterUser creates a new user account
-func (as *AuthService) RegisterUser(email, password, name string, company *string) (*models.User, error) {
+func (as *AuthService) RegisterUser(ctx context.Context, email, password, name string, company *string) (*models.User, error) {
 	// Check if user already exists
 	var exists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)", email).Scan(&exists)
+	err := database.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)", email).Scan(&exists)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check user existence: %w", err)
 	}
@@ -112,38 +249,40 @@ func (as *AuthService) RegisterUser(email, password, name string, company *strin
 
 	// Insert user
 	var user models.User
-	err = database.DB.QueryRow(`
+	err = database.DB.QueryRowContext(ctx, `
 		INSERT INTO users (email, name, company, password_hash, is_active, is_admin, plan_type, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, true, false, 'free', NOW(), NOW())
-		RETURNING id, email, name, company, is_active, is_admin, plan_type, created_at, updated_at
-	`, email, name, company, string(hashedPassword)).Scan(
+		VALUES ($1, $2, $3, $4, true, false, $5, NOW(), NOW())
+		RETURNING id, email, name, company, is_active, is_admin, plan_type, email_verified_at IS NOT NULL, created_at, updated_at
+	`, email, name, company, string(hashedPassword), models.DefaultPlanID).Scan(
 		&user.ID, &user.Email, &user.Name, &user.Company, 
-		&user.IsActive, &user.IsAdmin, &user.PlanType, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsActive, &user.IsAdmin, &user.PlanType, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	// Create default subscription
-	err = as.CreateSubscription(user.ID, "free")
+	err = as.CreateSubscription(ctx, user.ID, models.DefaultPlanID)
 	if err != nil {
-		log.Printf("Warning: failed to create subscription for user %d: %v", user.ID, err)
+		slog.Warn("failed to create subscription", "user_id", user.ID, "error", err)
 	}
 
+	Notifications.Notify(user.ID, NotificationWelcome, "welcome", map[string]interface{}{"Plan": user.PlanType})
+
 	return &user, nil
 }
 
 // AuthenticateUser validates user credentials
-func (as *AuthService) AuthenticateUser(email, password string) (*models.User, error) {
+func (as *AuthService) AuthenticateUser(ctx context.Context, email, password string) (*models.User, error) {
 	var user models.User
 	var passwordHash string
 
-	err := database.DB.QueryRow(`
-		SELECT id, email, name, company, password_hash, is_active, is_admin, plan_type, created_at, updated_at
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, email, name, company, password_hash, is_active, is_admin, plan_type, email_verified_at IS NOT NULL, created_at, updated_at
 		FROM users WHERE email = $1 AND is_active = true
 	`, email).Scan(
 		&user.ID, &user.Email, &user.Name, &user.Company, &passwordHash,
-		&user.IsActive, &user.IsAdmin, &user.PlanType, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsActive, &user.IsAdmin, &user.PlanType, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("invalid email or password")
@@ -162,15 +301,15 @@ func (as *AuthService) AuthenticateUser(email, password string) (*models.User, e
 }
 
 // GetUserByID retrieves a user by their ID
-func (as *AuthService) GetUserByID(userID int) (*models.User, error) {
+func (as *AuthService) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
 	var user models.User
 
-	err := database.DB.QueryRow(`
-		SELECT id, email, name, company, is_active, is_admin, plan_type, created_at, updated_at
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT id, email, name, company, is_active, is_admin, plan_type, email_verified_at IS NOT NULL, created_at, updated_at
 		FROM users WHERE id = $1
 	`, userID).Scan(
 		&user.ID, &user.Email, &user.Name, &user.Company,
-		&user.IsActive, &user.IsAdmin, &user.PlanType, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsActive, &user.IsAdmin, &user.PlanType, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("user not found")
@@ -182,8 +321,10 @@ func (as *AuthService) GetUserByID(userID int) (*models.User, error) {
 	return &user, nil
 }
 
-// GenerateAPIKey creates a new API key for a user
-func (as *AuthService) GenerateAPIKey(userID int, name string, permissions []string) (*models.APIKey, string, error) {
+// GenerateAPIKey creates a new API key for a user. monthlyLimit and
+// dailyLimit optionally cap the key below the plan limit; organizationID
+// bills the key to an organization the user belongs to.
+func (as *AuthService) GenerateAPIKey(ctx context.Context, userID int, name string, permissions []string, monthlyLimit, dailyLimit, organizationID *int) (*models.APIKey, string, error) {
 	// Generate random API key
 	keyBytes := make([]byte, 32)
 	_, err := rand.Read(keyBytes)
@@ -205,13 +346,13 @@ func (as *AuthService) GenerateAPIKey(userID int, name string, permissions []str
 	// Insert API key
 	var key models.APIKey
 	var permissionsArray pq.StringArray
-	err = database.DB.QueryRow(`
-		INSERT INTO api_keys (user_id, name, key_hash, key_preview, is_active, permissions, created_at)
-		VALUES ($1, $2, $3, $4, true, $5, NOW())
-		RETURNING id, user_id, name, key_preview, is_active, permissions, created_at
-	`, userID, name, keyHash, keyPreview, pq.Array(permissions)).Scan(
+	err = database.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, name, key_hash, key_preview, is_active, permissions, monthly_limit, daily_limit, organization_id, created_at)
+		VALUES ($1, $2, $3, $4, true, $5, $6, $7, $8, NOW())
+		RETURNING id, user_id, name, key_preview, is_active, permissions, monthly_limit, daily_limit, organization_id, created_at
+	`, userID, name, keyHash, keyPreview, pq.Array(permissions), monthlyLimit, dailyLimit, organizationID).Scan(
 		&key.ID, &key.UserID, &key.Name, &key.KeyPreview,
-		&key.IsActive, &permissionsArray, &key.CreatedAt,
+		&key.IsActive, &permissionsArray, &key.MonthlyLimit, &key.DailyLimit, &key.OrganizationID, &key.CreatedAt,
 	)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create API key: %w", err)
@@ -220,29 +361,66 @@ func (as *AuthService) GenerateAPIKey(userID int, name string, permissions []str
 	// Convert pq.StringArray to JSONArray
 	key.Permissions = models.JSONArray(permissionsArray)
 
+	if err := Webhooks.Emit(userID, models.WebhookEventAPIKeyCreated, "", map[string]interface{}{
+		"api_key_id":  key.ID,
+		"name":        key.Name,
+		"key_preview": key.KeyPreview,
+		"permissions": key.Permissions,
+	}); err != nil {
+		slog.Warn("failed to queue webhook", "event", models.WebhookEventAPIKeyCreated, "user_id", userID, "error", err)
+	}
+	Notifications.Notify(userID, NotificationAPIKeyCreated, "", map[string]interface{}{
+		"KeyName":    key.Name,
+		"KeyPreview": key.KeyPreview,
+	})
+
 	return &key, apiKey, nil
 }
 
 // ValidateAPIKey checks if an API key is valid and returns user and key info
-func (as *AuthService) ValidateAPIKey(apiKey string) (*models.User, *models.APIKey, error) {
+func (as *AuthService) ValidateAPIKey(ctx context.Context, apiKey string) (*models.User, *models.APIKey, error) {
 	// Hash the provided key to compare with stored hash
 	hasher := sha256.New()
 	hasher.Write([]byte(apiKey))
 	keyHash := hex.EncodeToString(hasher.Sum(nil))
 
-	// Query for API key and associated user
+	user, key, err := as.activeAPIKey(ctx, "k.key_hash = $1", keyHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Update last used timestamp
+	_, err = database.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1", key.ID)
+	if err != nil {
+		// Log error but don't fail validation
+		slog.Warn("failed to update API key last_used_at", "api_key_id", key.ID, "error", err)
+	}
+
+	return user, key, nil
+}
+
+// GetActiveAPIKeyByID returns an active API key and its user, for running a
+// request as that key without its secret (admin request replay)
+func (as *AuthService) GetActiveAPIKeyByID(ctx context.Context, id int) (*models.User, *models.APIKey, error) {
+	return as.activeAPIKey(ctx, "k.id = $1", id)
+}
+
+// activeAPIKey loads the active key of an active user matching condition
+func (as *AuthService) activeAPIKey(ctx context.Context, condition string, arg interface{}) (*models.User, *models.APIKey, error) {
 	var key models.APIKey
 	var user models.User
 	var permissionsArray pq.StringArray
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT 
 			k.id, k.user_id, k.name, k.key_preview, k.is_active, k.permissions, k.created_at, k.expires_at,
+			k.monthly_limit, k.daily_limit, k.organization_id,
 			u.id, u.email, u.name, u.company, u.is_active, u.plan_type, u.created_at, u.updated_at
 		FROM api_keys k
 		JOIN users u ON k.user_id = u.id
-		WHERE k.key_hash = $1 AND k.is_active = true AND u.is_active = true
-	`, keyHash).Scan(
+		WHERE `+condition+` AND k.is_active = true AND u.is_active = true
+	`, arg).Scan(
 		&key.ID, &key.UserID, &key.Name, &key.KeyPreview, &key.IsActive, &permissionsArray, &key.CreatedAt, &key.ExpiresAt,
+		&key.MonthlyLimit, &key.DailyLimit, &key.OrganizationID,
 		&user.ID, &user.Email, &user.Name, &user.Company, &user.IsActive, &user.PlanType, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
@@ -254,77 +432,110 @@ func (as *AuthService) ValidateAPIKey(apiKey string) (*models.User, *models.APIK
 
 	// Convert PostgreSQL array to JSONArray
 	key.Permissions = models.JSONArray(permissionsArray)
+	return &user, &key, nil
+}
 
-	// Update last used timestamp
-	_, err = database.DB.Exec("UPDATE api_keys SET last_used_at = NOW() WHERE id = $1", key.ID)
+// CheckRateLimit verifies if user has exceeded their monthly or daily limit
+// and, when the request came in on apiKey, whether that key's own caps are
+// used up. Organization keys are checked against the organization's pooled
+// limits instead of the user's. When a key cap is the one exceeded, the
+// usage and limit returned are the key's.
+func (as *AuthService) CheckRateLimit(ctx context.Context, userID int, apiKey *models.APIKey) (bool, int, int, error) {
+	var withinLimit bool
+	var currentUsage, monthlyLimit int
+	var err error
+	if apiKey != nil && apiKey.OrganizationID != nil {
+		withinLimit, currentUsage, monthlyLimit, err = Organizations.CheckRateLimit(ctx, *apiKey.OrganizationID)
+	} else {
+		withinLimit, currentUsage, monthlyLimit, err = as.checkAccountLimit(ctx, userID)
+	}
+	if err != nil || !withinLimit || apiKey == nil {
+		return withinLimit, currentUsage, monthlyLimit, err
+	}
+
+	keyWithinLimit, keyUsage, keyLimit, err := as.checkAPIKeyLimit(ctx, apiKey)
 	if err != nil {
-		// Log error but don't fail validation
-		log.Printf("Failed to update last_used_at for API key %d: %v", key.ID, err)
+		return false, 0, 0, err
+	}
+	if !keyWithinLimit {
+		return false, keyUsage, keyLimit, nil
+	}
+	return true, currentUsage, monthlyLimit, nil
+}
+
+// checkAPIKeyLimit compares a key's billable usage this month and today
+// against its own caps
+func (as *AuthService) checkAPIKeyLimit(ctx context.Context, apiKey *models.APIKey) (bool, int, int, error) {
+	if apiKey.MonthlyLimit == nil && apiKey.DailyLimit == nil {
+		return true, 0, -1, nil
 	}
 
-	return &user, &key, nil
+	var monthlyUsage, dailyUsage int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(cost), 0),
+			COALESCE(SUM(cost) FILTER (WHERE created_at >= CURRENT_DATE), 0)
+		FROM usage_records
+		WHERE api_key_id = $1 AND billable = true
+		AND created_at >= date_trunc('month', CURRENT_DATE)
+	`, apiKey.ID).Scan(&monthlyUsage, &dailyUsage)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("failed to get API key usage count: %w", err)
+	}
+
+	if apiKey.MonthlyLimit != nil && monthlyUsage >= *apiKey.MonthlyLimit {
+		return false, monthlyUsage, *apiKey.MonthlyLimit, nil
+	}
+	if apiKey.DailyLimit != nil && dailyUsage >= *apiKey.DailyLimit {
+		return false, dailyUsage, *apiKey.DailyLimit, nil
+	}
+	return true, monthlyUsage, -1, nil
+}
+
+// GetPlanLimits returns the monthly and daily request limits of the user's
+// plan, with the monthly limit overridden by their active subscription when
+// it sets one; -1 means unlimited
+func (as *AuthService) GetPlanLimits(ctx context.Context, userID int) (int, int, error) {
+	var monthlyLimit, dailyLimit int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(s.monthly_limit, p.monthly_limit), p.daily_limit
+		FROM users u
+		JOIN plans p ON p.id = u.plan_type
+		LEFT JOIN subscriptions s ON u.id = s.user_id AND s.is_active = true
+		WHERE u.id = $1
+	`, userID).Scan(&monthlyLimit, &dailyLimit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get user plan: %w", err)
+	}
+	return monthlyLimit, dailyLimit, nil
 }
 
-// CheckRateLimit verifies if user has exceeded their monthly limit
-func (as *AuthService) CheckRateLimit(userID int) (bool, int, int, error) {
+// checkAccountLimit verifies if user has exceeded their plan's monthly or
+// daily limit
+func (as *AuthService) checkAccountLimit(ctx context.Context, userID int) (bool, int, int, error) {
 	// Check if user is admin - admins get unlimited usage
 	var isAdmin bool
 	var email string
-	err := database.DB.QueryRow(`SELECT is_admin, email FROM users WHERE id = $1`, userID).Scan(&isAdmin, &email)
+	err := database.DB.QueryRowContext(ctx, `SELECT is_admin, email FROM users WHERE id = $1`, userID).Scan(&isAdmin, &email)
 	if err != nil {
 		return false, 0, 0, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	// Check if user is in ADMIN_EMAILS environment variable
-	adminEmails := os.Getenv("ADMIN_EMAILS")
-	isAdminEmail := false
-	if adminEmails != "" {
-		emails := strings.Split(adminEmails, ",")
-		for _, adminEmail := range emails {
-			if strings.TrimSpace(adminEmail) == email {
-				isAdminEmail = true
-				break
-			}
-		}
-	}
-
-	// Admins get unlimited usage
-	if isAdmin || isAdminEmail {
+	// Admins, including those listed in ADMIN_EMAILS, get unlimited usage
+	if isAdmin || config.Get().IsAdminEmail(email) {
 		return true, 0, -1, nil // -1 indicates unlimited
 	}
 
 	// Get user's plan type from users table if no subscription exists
-	var monthlyLimit, dailyLimit int
-	err = database.DB.QueryRow(`
-		SELECT 
-			COALESCE(s.monthly_limit, 
-				CASE 
-					WHEN u.plan_type = 'free' THEN 3000
-					WHEN u.plan_type = 'starter' THEN 30000
-					WHEN u.plan_type = 'pro' THEN 500000
-					WHEN u.plan_type = 'enterprise' THEN -1
-					ELSE 3000
-				END
-			) as monthly_limit,
-			CASE 
-				WHEN u.plan_type = 'free' THEN 500
-				WHEN u.plan_type = 'starter' THEN 5000
-				WHEN u.plan_type = 'pro' THEN 100000
-				WHEN u.plan_type = 'enterprise' THEN -1
-				ELSE 500
-			END as daily_limit
-		FROM users u
-		LEFT JOIN subscriptions s ON u.id = s.user_id AND s.is_active = true
-		WHERE u.id = $1
-	`, userID).Scan(&monthlyLimit, &dailyLimit)
+	monthlyLimit, dailyLimit, err := as.GetPlanLimits(ctx, userID)
 	if err != nil {
-		return false, 0, 0, fmt.Errorf("failed to get user plan: %w", err)
+		return false, 0, 0, err
 	}
 
 	// Count current month's usage
 	var currentUsage int
-	err = database.DB.QueryRow(`
-		SELECT COUNT(*) FROM usage_records 
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(cost), 0) FROM usage_records 
 		WHERE user_id = $1 AND billable = true 
 		AND created_at >= date_trunc('month', CURRENT_DATE)
 	`, userID).Scan(&currentUsage)
@@ -334,8 +545,8 @@ func (as *AuthService) CheckRateLimit(userID int) (bool, int, int, error) {
 
 	// Count today's usage
 	var dailyUsage int
-	err = database.DB.QueryRow(`
-		SELECT COUNT(*) FROM usage_records 
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(cost), 0) FROM usage_records 
 		WHERE user_id = $1 AND billable = true 
 		AND created_at >= CURRENT_DATE
 	`, userID).Scan(&dailyUsage)
@@ -348,27 +559,32 @@ func (as *AuthService) CheckRateLimit(userID int) (bool, int, int, error) {
 		return true, currentUsage, monthlyLimit, nil
 	}
 
+	// Let the user's webhooks know before they hit a limit
+	Webhooks.CheckQuotaThresholds(userID, currentUsage, monthlyLimit, "monthly")
+	Webhooks.CheckQuotaThresholds(userID, dailyUsage, dailyLimit, "daily")
+
 	// Check both monthly and daily limits
 	withinMonthlyLimit := currentUsage < monthlyLimit
 	withinDailyLimit := dailyUsage < dailyLimit
 	withinLimit := withinMonthlyLimit && withinDailyLimit
-	
+
 	return withinLimit, currentUsage, monthlyLimit, nil
 }
 
 // GetUserAPIKeys retrieves all API keys for a user
-func (a *AuthService) GetUserAPIKeys(userID int) ([]models.APIKey, error) {
+func (a *AuthService) GetUserAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
 	var apiKeys []models.APIKey
 	
 	query := `
 		SELECT id, user_id, name, key_preview, permissions, 
-		       is_active, last_used_at, created_at, expires_at
+		       is_active, last_used_at, created_at, expires_at,
+		       monthly_limit, daily_limit, organization_id
 		FROM api_keys 
 		WHERE user_id = $1 AND is_active = true
 		ORDER BY created_at DESC
 	`
 	
-	rows, err := database.DB.Query(query, userID)
+	rows, err := database.DB.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query API keys: %w", err)
 	}
@@ -382,6 +598,7 @@ func (a *AuthService) GetUserAPIKeys(userID int) ([]models.APIKey, error) {
 			&key.ID, &key.UserID, &key.Name, &key.KeyPreview,
 			&permissionsJSON, &key.IsActive, &key.LastUsedAt,
 			&key.CreatedAt, &key.ExpiresAt,
+			&key.MonthlyLimit, &key.DailyLimit, &key.OrganizationID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan API key: %w", err)
@@ -401,10 +618,10 @@ func (a *AuthService) GetUserAPIKeys(userID int) ([]models.APIKey, error) {
 }
 
 // DeleteAPIKey soft deletes an API key (marks as inactive)
-func (a *AuthService) DeleteAPIKey(userID, keyID int) error {
+func (a *AuthService) DeleteAPIKey(ctx context.Context, userID, keyID int) error {
 	// First verify the key belongs to the user
 	var exists bool
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx, 
 		"SELECT EXISTS(SELECT 1 FROM api_keys WHERE id = $1 AND user_id = $2 AND is_active = true)",
 		keyID, userID,
 	).Scan(&exists)
@@ -418,7 +635,7 @@ func (a *AuthService) DeleteAPIKey(userID, keyID int) error {
 	}
 	
 	// Soft delete by marking as inactive
-	_, err = database.DB.Exec(
+	_, err = database.DB.ExecContext(ctx, 
 		"UPDATE api_keys SET is_active = false, updated_at = NOW() WHERE id = $1 AND user_id = $2",
 		keyID, userID,
 	)
@@ -426,85 +643,44 @@ func (a *AuthService) DeleteAPIKey(userID, keyID int) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete API key: %w", err)
 	}
-	
-	return nil
-}
 
-// RecordUsage logs an API call for billing and analytics
-func (as *AuthService) RecordUsage(userID, apiKeyID int, endpoint, method string, statusCode, responseTime int, ipAddress, userAgent string, billable bool) error {
-	log.Printf("Recording usage: UserID=%d, APIKeyID=%d, Endpoint=%s, Method=%s, Billable=%t", 
-		userID, apiKeyID, endpoint, method, billable)
-	
-	_, err := database.DB.Exec(`
-		INSERT INTO usage_records (user_id, api_key_id, endpoint, method, status_code, response_time_ms, ip_address, user_agent, billable, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
-	`, userID, apiKeyID, endpoint, method, statusCode, responseTime, ipAddress, userAgent, billable)
-	
-	if err != nil {
-		log.Printf("Failed to record usage: %v", err)
-	} else {
-		log.Printf("Successfully recorded usage for user %d", userID)
+	if err := Webhooks.Emit(userID, models.WebhookEventAPIKeyDeleted, "", map[string]interface{}{
+		"api_key_id": keyID,
+	}); err != nil {
+		slog.Warn("failed to queue webhook", "event", models.WebhookEventAPIKeyDeleted, "user_id", userID, "error", err)
 	}
 	
-	return err
+	return nil
 }
 
 // IsUserAdmin checks if a user has admin privileges
-func (as *AuthService) IsUserAdmin(userID int) bool {
+func (as *AuthService) IsUserAdmin(ctx context.Context, userID int) bool {
 	var isAdmin bool
-	err := database.DB.QueryRow("SELECT is_admin FROM users WHERE id = $1", userID).Scan(&isAdmin)
+	err := database.DB.QueryRowContext(ctx, "SELECT is_admin FROM users WHERE id = $1", userID).Scan(&isAdmin)
 	if err != nil {
-		log.Printf("Error checking admin status: %v", err)
+		slog.Warn("failed to check admin status", "user_id", userID, "error", err)
 		return false
 	}
 	return isAdmin
 }
 
-// GetAdminStats returns statistics for admin dashboard
-func (as *AuthService) GetAdminStats() (map[string]interface{}, error) {
-	stats := make(map[string]interface{})
-	
-	// Total users
-	var totalUsers int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&totalUsers)
-	if err != nil {
-		return nil, err
-	}
-	stats["total_users"] = totalUsers
-	
-	// Active API keys
-	var activeKeys int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM api_keys WHERE is_active = true").Scan(&activeKeys)
-	if err != nil {
-		return nil, err
-	}
-	stats["active_keys"] = activeKeys
-	
-	// API calls today
-	var callsToday int
-	err = database.DB.QueryRow(`
-		SELECT COUNT(*) FROM usage_records 
-		WHERE DATE(created_at) = CURRENT_DATE
-	`).Scan(&callsToday)
+// GetAdminStats returns statistics for admin dashboard from the
+// admin_stats_summary view, as of its last refresh
+func (as *AuthService) GetAdminStats(ctx context.Context) (*models.AdminStats, error) {
+	stats := &models.AdminStats{}
+	err := database.ReadDB(ctx).QueryRowContext(ctx, `
+		SELECT total_users, active_keys, calls_today, zip_codes, refreshed_at
+		FROM admin_stats_summary
+	`).Scan(&stats.TotalUsers, &stats.ActiveKeys, &stats.CallsToday, &stats.ZipCodes, &stats.RefreshedAt)
 	if err != nil {
 		return nil, err
 	}
-	stats["calls_today"] = callsToday
-	
-	// ZIP codes count
-	var zipCodes int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM zip_codes").Scan(&zipCodes)
-	if err != nil {
-		return nil, err
-	}
-	stats["zip_codes"] = zipCodes
-	
 	return stats, nil
 }
 
-// GetAllUsers returns all users for admin dashboard with usage metrics
-func (as *AuthService) GetAllUsers() ([]map[string]interface{}, error) {
-	rows, err := database.DB.Query(`
+// adminUserSelect is the admin user list row with usage metrics. The last
+// activity is the most recent use of any of the user's API keys.
+const adminUserSelect = `
 		SELECT 
 			u.id, 
 			u.email, 
@@ -513,6 +689,7 @@ func (as *AuthService) GetAllUsers() ([]map[string]interface{}, error) {
 			u.plan_type, 
 			u.is_active, 
 			u.is_admin, 
+			u.email_verified_at IS NOT NULL,
 			u.created_at,
 			COALESCE(
 				(SELECT COUNT(*) 
@@ -541,114 +718,163 @@ func (as *AuthService) GetAllUsers() ([]map[string]interface{}, error) {
 				 WHERE ak.user_id = u.id 
 				 AND ak.is_active = true),
 				0
-			) as active_keys
-		FROM users u
-		ORDER BY u.created_at DESC
-	`)
+			) as active_keys,
+			(SELECT MAX(ak.last_used_at) FROM api_keys ak WHERE ak.user_id = u.id) as last_active_at
+		FROM users u`
+
+// adminUserConditions builds the WHERE clause and arguments for filter.
+// Deleted users are always left out.
+func adminUserConditions(filter models.AdminUserFilter) (string, []interface{}) {
+	conditions := []string{"u.deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.Email != "" {
+		conditions = append(conditions, fmt.Sprintf("u.email ILIKE '%%' || $%d || '%%'", argIndex))
+		args = append(args, escapeLikePattern(filter.Email))
+		argIndex++
+	}
+	if filter.Plan != "" {
+		conditions = append(conditions, fmt.Sprintf("u.plan_type = $%d", argIndex))
+		args = append(args, filter.Plan)
+		argIndex++
+	}
+	if !filter.ActiveSince.IsZero() {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM api_keys ak WHERE ak.user_id = u.id AND ak.last_used_at >= $%d)", argIndex))
+		args = append(args, filter.ActiveSince)
+		argIndex++
+	}
+	if !filter.ActiveBefore.IsZero() {
+		// Users who never used a key count as inactive
+		conditions = append(conditions, fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM api_keys ak WHERE ak.user_id = u.id AND ak.last_used_at >= $%d)", argIndex))
+		args = append(args, filter.ActiveBefore)
+		argIndex++
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// escapeLikePattern escapes LIKE wildcards so value matches literally
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// scanAdminUser scans one adminUserSelect row
+func scanAdminUser(rows *sql.Rows) (models.AdminUser, error) {
+	var user models.AdminUser
+	err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.Company, &user.PlanType, &user.IsActive, &user.IsAdmin, &user.EmailVerified, &user.CreatedAt,
+		&user.MonthlyUsage, &user.TodayUsage, &user.TotalUsage, &user.ActiveKeys, &user.LastActiveAt)
+	return user, err
+}
+
+// GetAllUsers returns a page of users matching filter for the admin
+// dashboard with usage metrics, newest first, and the total number of
+// matches. Deleted users are left out.
+func (as *AuthService) GetAllUsers(ctx context.Context, filter models.AdminUserFilter) ([]models.AdminUser, int, error) {
+	whereClause, args := adminUserConditions(filter)
+
+	var total int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users u "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`%s
+		%s
+		ORDER BY u.created_at DESC, u.id DESC
+		LIMIT $%d OFFSET $%d
+	`, adminUserSelect, whereClause, len(args)+1, len(args)+2)
+	rows, err := database.DB.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 	
-	var users []map[string]interface{}
+	users := []models.AdminUser{}
 	for rows.Next() {
-		var id int
-		var email, planType string
-		var name, company *string
-		var isActive, isAdmin bool
-		var createdAt time.Time
-		var monthlyUsage, todayUsage, totalUsage, activeKeys int
-		
-		err := rows.Scan(&id, &email, &name, &company, &planType, &isActive, &isAdmin, &createdAt,
-			&monthlyUsage, &todayUsage, &totalUsage, &activeKeys)
+		user, err := scanAdminUser(rows)
 		if err != nil {
-			return nil, err
-		}
-		
-		user := map[string]interface{}{
-			"id":            id,
-			"email":         email,
-			"name":          name,
-			"company":       company,
-			"plan_type":     planType,
-			"is_active":     isActive,
-			"is_admin":      isAdmin,
-			"created_at":    createdAt,
-			"monthly_usage": monthlyUsage,
-			"today_usage":   todayUsage,
-			"total_usage":   totalUsage,
-			"active_keys":   activeKeys,
+			return nil, 0, err
 		}
 		users = append(users, user)
 	}
 	
-	return users, nil
+	return users, total, rows.Err()
+}
+
+// ExportUsers calls fn with every user matching filter, oldest first, so a
+// CSV export can stream without holding the list in memory. Limit and
+// Offset are ignored.
+func (as *AuthService) ExportUsers(ctx context.Context, filter models.AdminUserFilter, fn func(models.AdminUser) error) error {
+	whereClause, args := adminUserConditions(filter)
+	rows, err := database.DB.QueryContext(ctx, adminUserSelect+"\n\t\t"+whereClause+"\n\t\tORDER BY u.id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // GetUserUsageMetrics returns detailed usage metrics for a specific user
-func (as *AuthService) GetUserUsageMetrics(userID int, days int) (map[string]interface{}, error) {
-	metrics := make(map[string]interface{})
+func (as *AuthService) GetUserUsageMetrics(ctx context.Context, userID int, days int) (*models.UserUsageMetrics, error) {
+	metrics := &models.UserUsageMetrics{UserID: userID}
 	
 	// Get user info
-	var email, planType string
-	var name *string
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT email, name, plan_type FROM users WHERE id = $1
-	`, userID).Scan(&email, &name, &planType)
+	`, userID).Scan(&metrics.Email, &metrics.Name, &metrics.PlanType)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
 	
-	metrics["user_id"] = userID
-	metrics["email"] = email
-	metrics["name"] = name
-	metrics["plan_type"] = planType
-	
 	// Total calls
-	var totalCalls, billableCalls int
-	err = database.DB.QueryRow(`
+	err = database.DB.QueryRowContext(ctx, `
 		SELECT 
 			COUNT(*),
 			COUNT(*) FILTER (WHERE billable = true)
 		FROM usage_records 
 		WHERE user_id = $1 AND created_at >= CURRENT_DATE - INTERVAL '1 day' * $2
-	`, userID, days).Scan(&totalCalls, &billableCalls)
+	`, userID, days).Scan(&metrics.TotalCalls, &metrics.BillableCalls)
 	if err != nil {
 		return nil, err
 	}
-	metrics["total_calls"] = totalCalls
-	metrics["billable_calls"] = billableCalls
 	
 	// Average response time
 	var avgResponseTime sql.NullFloat64
-	err = database.DB.QueryRow(`
+	err = database.DB.QueryRowContext(ctx, `
 		SELECT AVG(response_time_ms)
 		FROM usage_records 
 		WHERE user_id = $1 AND created_at >= CURRENT_DATE - INTERVAL '1 day' * $2
 	`, userID, days).Scan(&avgResponseTime)
 	if err == nil && avgResponseTime.Valid {
-		metrics["avg_response_time"] = avgResponseTime.Float64
-	} else {
-		metrics["avg_response_time"] = 0
+		metrics.AvgResponseTime = avgResponseTime.Float64
 	}
 	
 	// Success/Error rate
-	var successCount, errorCount int
-	err = database.DB.QueryRow(`
+	err = database.DB.QueryRowContext(ctx, `
 		SELECT 
 			COUNT(*) FILTER (WHERE status_code >= 200 AND status_code < 400),
 			COUNT(*) FILTER (WHERE status_code >= 400)
 		FROM usage_records 
 		WHERE user_id = $1 AND created_at >= CURRENT_DATE - INTERVAL '1 day' * $2
-	`, userID, days).Scan(&successCount, &errorCount)
+	`, userID, days).Scan(&metrics.SuccessCount, &metrics.ErrorCount)
 	if err != nil {
 		return nil, err
 	}
-	metrics["success_count"] = successCount
-	metrics["error_count"] = errorCount
 	
 	// Endpoint breakdown
-	endpointRows, err := database.DB.Query(`
+	endpointRows, err := database.DB.QueryContext(ctx, `
 		SELECT 
 			endpoint,
 			COUNT(*) as total,
@@ -664,33 +890,23 @@ func (as *AuthService) GetUserUsageMetrics(userID int, days int) (map[string]int
 	}
 	defer endpointRows.Close()
 	
-	var endpoints []map[string]interface{}
+	metrics.Endpoints = []models.EndpointMetrics{}
 	for endpointRows.Next() {
-		var endpoint string
-		var total, billable int
+		var endpoint models.EndpointMetrics
 		var avgTime sql.NullFloat64
 		
-		if err := endpointRows.Scan(&endpoint, &total, &billable, &avgTime); err != nil {
+		if err := endpointRows.Scan(&endpoint.Endpoint, &endpoint.Total, &endpoint.Billable, &avgTime); err != nil {
 			continue
 		}
-		
-		endpointData := map[string]interface{}{
-			"endpoint":       endpoint,
-			"total":          total,
-			"billable":       billable,
-			"avg_time":       0.0,
-		}
-		
 		if avgTime.Valid {
-			endpointData["avg_time"] = avgTime.Float64
+			endpoint.AvgTime = avgTime.Float64
 		}
 		
-		endpoints = append(endpoints, endpointData)
+		metrics.Endpoints = append(metrics.Endpoints, endpoint)
 	}
-	metrics["endpoints"] = endpoints
 	
 	// Daily breakdown
-	dailyRows, err := database.DB.Query(`
+	dailyRows, err := database.DB.QueryContext(ctx, `
 		SELECT 
 			DATE(created_at) as date,
 			COUNT(*) as total,
@@ -705,29 +921,25 @@ func (as *AuthService) GetUserUsageMetrics(userID int, days int) (map[string]int
 	}
 	defer dailyRows.Close()
 	
-	var dailyUsage []map[string]interface{}
+	metrics.DailyUsage = []models.UserDailyMetrics{}
 	for dailyRows.Next() {
 		var date time.Time
-		var total, billable int
+		var day models.UserDailyMetrics
 		
-		if err := dailyRows.Scan(&date, &total, &billable); err != nil {
+		if err := dailyRows.Scan(&date, &day.Total, &day.Billable); err != nil {
 			continue
 		}
+		day.Date = date.Format("2006-01-02")
 		
-		dailyUsage = append(dailyUsage, map[string]interface{}{
-			"date":     date.Format("2006-01-02"),
-			"total":    total,
-			"billable": billable,
-		})
+		metrics.DailyUsage = append(metrics.DailyUsage, day)
 	}
-	metrics["daily_usage"] = dailyUsage
 	
 	return metrics, nil
 }
 
 // GetAllAPIKeys returns all API keys for admin dashboard
-func (as *AuthService) GetAllAPIKeys() ([]map[string]interface{}, error) {
-	rows, err := database.DB.Query(`
+func (as *AuthService) GetAllAPIKeys(ctx context.Context) ([]models.AdminAPIKey, error) {
+	rows, err := database.DB.QueryContext(ctx, `
 		SELECT ak.id, u.email, ak.name, ak.key_preview, ak.is_active, ak.last_used_at, ak.created_at
 		FROM api_keys ak
 		JOIN users u ON ak.user_id = u.id
@@ -738,124 +950,220 @@ func (as *AuthService) GetAllAPIKeys() ([]map[string]interface{}, error) {
 	}
 	defer rows.Close()
 	
-	var apiKeys []map[string]interface{}
+	apiKeys := []models.AdminAPIKey{}
 	for rows.Next() {
-		var id int
-		var userEmail, name, keyPreview string
-		var isActive bool
-		var lastUsedAt *time.Time
-		var createdAt time.Time
-		
-		err := rows.Scan(&id, &userEmail, &name, &keyPreview, &isActive, &lastUsedAt, &createdAt)
+		var apiKey models.AdminAPIKey
+		err := rows.Scan(&apiKey.ID, &apiKey.UserEmail, &apiKey.Name, &apiKey.KeyPreview, &apiKey.IsActive, &apiKey.LastUsedAt, &apiKey.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
-		
-		apiKey := map[string]interface{}{
-			"id":           id,
-			"user_email":   userEmail,
-			"name":         name,
-			"key_preview":  keyPreview,
-			"is_active":    isActive,
-			"last_used_at": lastUsedAt,
-			"created_at":   createdAt,
-		}
 		apiKeys = append(apiKeys, apiKey)
 	}
 	
-	return apiKeys, nil
+	return apiKeys, rows.Err()
 }
 
 // UpdateUserStatus updates a user's active status
-func (as *AuthService) UpdateUserStatus(userID int, isActive bool) error {
-	_, err := database.DB.Exec(`
+func (as *AuthService) UpdateUserStatus(ctx context.Context, userID int, isActive bool) error {
+	_, err := database.DB.ExecContext(ctx, `
 		UPDATE users SET is_active = $1, updated_at = CURRENT_TIMESTAMP 
 		WHERE id = $2
 	`, isActive, userID)
 	return err
 }
 
+// UpdateUsersStatus sets the active status of every listed user and returns
+// the IDs it changed. Unknown and deleted users are skipped.
+func (as *AuthService) UpdateUsersStatus(ctx context.Context, userIDs []int, isActive bool) ([]int, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		UPDATE users SET is_active = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($2) AND deleted_at IS NULL
+		RETURNING id
+	`, isActive, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updated := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		updated = append(updated, id)
+	}
+	return updated, rows.Err()
+}
+
 // UpdateUserAdmin updates a user's admin status
-func (as *AuthService) UpdateUserAdmin(userID int, isAdmin bool) error {
-	_, err := database.DB.Exec(`
+func (as *AuthService) UpdateUserAdmin(ctx context.Context, userID int, isAdmin bool) error {
+	_, err := database.DB.ExecContext(ctx, `
 		UPDATE users SET is_admin = $1, updated_at = CURRENT_TIMESTAMP 
 		WHERE id = $2
 	`, isAdmin, userID)
 	return err
 }
 
+// UpdateUserPlan moves a user to another plan, resetting their subscription
+// to the plan's limits, and emails them about the change. It returns the
+// previous plan, ErrUserNotFound for unknown or deleted users, and
+// ErrPlanNotFound for unknown plans.
+func (as *AuthService) UpdateUserPlan(ctx context.Context, userID int, planType string) (string, error) {
+	if _, err := Plans.GetPlan(ctx, planType); err != nil {
+		return "", err
+	}
+
+	var previous string
+	err := database.DB.QueryRowContext(ctx, `
+		UPDATE users u SET plan_type = $1, updated_at = CURRENT_TIMESTAMP
+		FROM (SELECT id, plan_type FROM users WHERE id = $2 FOR UPDATE) old
+		WHERE u.id = old.id AND u.deleted_at IS NULL
+		RETURNING old.plan_type
+	`, planType, userID).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := as.CreateSubscription(ctx, userID, planType); err != nil {
+		return previous, fmt.Errorf("failed to update subscription: %w", err)
+	}
+	if previous != planType {
+		Notifications.Notify(userID, NotificationPlanChanged, "", map[string]interface{}{
+			"PreviousPlan": previous,
+			"Plan":         planType,
+		})
+	}
+	return previous, nil
+}
+
 // GetSystemStatus returns system health information
-func (as *AuthService) GetSystemStatus() (map[string]interface{}, error) {
-	status := make(map[string]interface{})
+func (as *AuthService) GetSystemStatus(ctx context.Context) (*models.SystemStatus, error) {
+	status := &models.SystemStatus{}
 	
 	// Check database connection
 	err := database.DB.Ping()
-	status["database_connected"] = err == nil
+	status.DatabaseConnected = err == nil
+	status.DatabaseCircuit = database.Circuit.Status().State
 	
 	// Check if migrations are current (simplified check)
 	var migrationCount int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&migrationCount)
-	status["migrations_current"] = err == nil && migrationCount >= 7 // Expected number of migrations
+	err = database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&migrationCount)
+	status.MigrationsCurrent = err == nil && migrationCount >= 7 // Expected number of migrations
 	
 	return status, nil
 }
 
 // CreateSubscription creates a subscription for a user
-func (as *AuthService) CreateSubscription(userID int, planType string) error {
-	plan, exists := models.PlanLimits[planType]
-	if !exists {
-		return fmt.Errorf("invalid plan type: %s", planType)
+func (as *AuthService) CreateSubscription(ctx context.Context, userID int, planType string) error {
+	if _, err := Plans.GetPlan(ctx, planType); err != nil {
+		if errors.Is(err, ErrPlanNotFound) {
+			return fmt.Errorf("invalid plan type: %s", planType)
+		}
+		return err
 	}
 
-	_, err := database.DB.Exec(`
+	// Limit and price are left NULL so the plan's current values apply
+	_, err := database.DB.ExecContext(ctx, `
 		INSERT INTO subscriptions (user_id, plan_type, status, current_period_start, current_period_end, monthly_limit, price_per_call, created_at, updated_at)
-		VALUES ($1, $2, 'active', date_trunc('month', CURRENT_DATE), date_trunc('month', CURRENT_DATE) + interval '1 month', $3, $4, NOW(), NOW())
+		VALUES ($1, $2, 'active', date_trunc('month', CURRENT_DATE), date_trunc('month', CURRENT_DATE) + interval '1 month', NULL, NULL, NOW(), NOW())
 		ON CONFLICT (user_id) DO UPDATE SET
 			plan_type = EXCLUDED.plan_type,
-			monthly_limit = EXCLUDED.monthly_limit,
-			price_per_call = EXCLUDED.price_per_call,
+			monthly_limit = NULL,
+			price_per_call = NULL,
 			updated_at = NOW()
-	`, userID, planType, plan.MonthlyLimit, plan.PricePerCall)
+	`, userID, planType)
 
 	return err
 }
 
+// StreamUsageRecords hands each of a user's usage records since the given time
+// to fn in chronological order without loading them all into memory. A sample
+// fraction between 0 and 1 returns a deterministic subset of records.
+func (as *AuthService) StreamUsageRecords(ctx context.Context, userID int, since time.Time, sample float64, fn func(*models.UsageRecord) error) error {
+	sampleFilter := ""
+	args := []interface{}{userID, since}
+	if sample > 0 && sample < 1 {
+		sampleFilter = "AND " + sampleBucketExpr("id") + " < $3"
+		args = append(args, int(sample*sampleBuckets))
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, user_id, COALESCE(api_key_id, 0), endpoint, method, COALESCE(status_code, 0),
+		       COALESCE(response_time_ms, 0), COALESCE(host(ip_address), ''), COALESCE(user_agent, ''),
+		       billable, created_at
+		FROM usage_records
+		WHERE user_id = $1 AND created_at >= $2 `+sampleFilter+`
+		ORDER BY created_at
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query usage records: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.UsageRecord
+		if err := rows.Scan(&r.ID, &r.UserID, &r.APIKeyID, &r.Endpoint, &r.Method, &r.StatusCode,
+			&r.ResponseTime, &r.IPAddress, &r.UserAgent, &r.Billable, &r.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan usage record: %w", err)
+		}
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // GetUsageSummary returns usage statistics for a user
-func (as *AuthService) GetUsageSummary(userID int, month string) (*models.UsageSummary, error) {
+func (as *AuthService) GetUsageSummary(ctx context.Context, userID int, month string) (*models.UsageSummary, error) {
 	// If no month specified, use current month
 	if month == "" {
 		month = time.Now().Format("2006-01")
 	}
 
 	var summary models.UsageSummary
+	var overageUnits int
 	summary.UserID = userID
 	summary.Month = month
 
 	// Get total and billable calls
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT 
 			COUNT(*) as total_calls,
-			COUNT(*) FILTER (WHERE billable = true) as billable_calls
+			COUNT(*) FILTER (WHERE billable = true) as billable_calls,
+			COALESCE(SUM(cost) FILTER (WHERE billable = true), 0) as billable_units,
+			COUNT(*) FILTER (WHERE billable = true AND overage = true) as overage_calls,
+			COALESCE(SUM(cost) FILTER (WHERE billable = true AND overage = true), 0) as overage_units
 		FROM usage_records 
 		WHERE user_id = $1 AND to_char(created_at, 'YYYY-MM') = $2
-	`, userID, month).Scan(&summary.TotalCalls, &summary.BillableCalls)
+	`, userID, month).Scan(&summary.TotalCalls, &summary.BillableCalls, &summary.BillableUnits,
+		&summary.OverageCalls, &overageUnits)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get usage summary: %w", err)
 	}
 
 	// Get price per call for cost calculation
 	var pricePerCall float64
-	err = database.DB.QueryRow(`
-		SELECT price_per_call FROM subscriptions WHERE user_id = $1
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(s.price_per_call, p.price_per_call)
+		FROM users u
+		JOIN plans p ON p.id = u.plan_type
+		LEFT JOIN subscriptions s ON s.user_id = u.id
+		WHERE u.id = $1
 	`, userID).Scan(&pricePerCall)
 	if err != nil {
 		pricePerCall = 0 // Default for free plan
 	}
 
-	summary.TotalCost = float64(summary.BillableCalls) * pricePerCall / 100 // Convert cents to dollars
+	// Heavy endpoints are priced at their cost in calls
+	summary.TotalCost = float64(summary.BillableUnits) * pricePerCall
+	summary.OverageCost = float64(overageUnits) * pricePerCall
 
 	// Get endpoint breakdown
-	rows, err := database.DB.Query(`
+	rows, err := database.DB.QueryContext(ctx, `
 		SELECT endpoint, COUNT(*) 
 		FROM usage_records 
 		WHERE user_id = $1 AND to_char(created_at, 'YYYY-MM') = $2
@@ -881,7 +1189,7 @@ func (as *AuthService) GetUsageSummary(userID int, month string) (*models.UsageS
 }
 
 // GetDailyUsage returns daily usage statistics for a user over a date range
-func (as *AuthService) GetDailyUsage(userID int, days int) ([]models.DailyUsage, error) {
+func (as *AuthService) GetDailyUsage(ctx context.Context, userID int, days int) ([]models.DailyUsage, error) {
 	if days <= 0 {
 		days = 30 // Default to 30 days
 	}
@@ -899,7 +1207,7 @@ func (as *AuthService) GetDailyUsage(userID int, days int) ([]models.DailyUsage,
 		ORDER BY date DESC
 	`
 
-	rows, err := database.DB.Query(query, userID, days)
+	rows, err := database.DB.QueryContext(ctx, query, userID, days)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily usage: %w", err)
 	}
@@ -919,7 +1227,7 @@ func (as *AuthService) GetDailyUsage(userID int, days int) ([]models.DailyUsage,
 }
 
 // GetEndpointUsage returns usage statistics by endpoint for a user
-func (as *AuthService) GetEndpointUsage(userID int, days int) ([]models.EndpointUsage, error) {
+func (as *AuthService) GetEndpointUsage(ctx context.Context, userID int, days int) ([]models.EndpointUsage, error) {
 	if days <= 0 {
 		days = 30 // Default to 30 days
 	}
@@ -939,7 +1247,7 @@ func (as *AuthService) GetEndpointUsage(userID int, days int) ([]models.Endpoint
 		ORDER BY total_calls DESC
 	`
 
-	rows, err := database.DB.Query(query, userID, days)
+	rows, err := database.DB.QueryContext(ctx, query, userID, days)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get endpoint usage: %w", err)
 	}
@@ -965,23 +1273,11 @@ func (as *AuthService) GetEndpointUsage(userID int, days int) ([]models.Endpoint
 	return endpointUsage, nil
 }
 
-// SyncAdminUsers updates admin status for users listed in ADMIN_EMAILS environment variable
+// SyncAdminUsers updates admin status for users listed in ADMIN_EMAILS
 func (as *AuthService) SyncAdminUsers() error {
-	adminEmails := os.Getenv("ADMIN_EMAILS")
-	if adminEmails == "" {
-		log.Println("No ADMIN_EMAILS configured, skipping admin sync")
-		return nil
-	}
-
-	// Split and trim email addresses
-	emails := []string{}
-	for _, email := range splitAndTrim(adminEmails, ",") {
-		if email != "" {
-			emails = append(emails, email)
-		}
-	}
-
+	emails := config.Get().Auth.AdminEmails
 	if len(emails) == 0 {
+		slog.Info("no ADMIN_EMAILS configured, skipping admin sync")
 		return nil
 	}
 
@@ -1009,78 +1305,20 @@ func (as *AuthService) SyncAdminUsers() error {
 	}
 
 	if len(updatedEmails) > 0 {
-		log.Printf("✅ Granted admin privileges and enterprise plan to: %v", updatedEmails)
+		slog.Info("granted admin privileges and enterprise plan", "emails", updatedEmails)
 	} else {
-		log.Println("No admin users to sync")
+		slog.Info("no admin users to sync")
 	}
 
 	return nil
 }
 
-// Helper function to split and trim strings
-func splitAndTrim(s, sep string) []string {
-	parts := []string{}
-	for _, part := range splitString(s, sep) {
-		trimmed := trimSpace(part)
-		if trimmed != "" {
-			parts = append(parts, trimmed)
-		}
-	}
-	return parts
-}
-
-func splitString(s, sep string) []string {
-	if s == "" {
-		return []string{}
-	}
-	result := []string{}
-	start := 0
-	for i := 0; i < len(s); i++ {
-		if s[i:i+1] == sep {
-			result = append(result, s[start:i])
-			start = i + 1
-		}
-	}
-	result = append(result, s[start:])
-	return result
-}
-
-func trimSpace(s string) string {
-	start := 0
-	end := len(s)
-	for start < end && (s[start] == ' ' || s[start] == '\t' || s[start] == '\n' || s[start] == '\r') {
-		start++
-	}
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t' || s[end-1] == '\n' || s[end-1] == '\r') {
-		end--
-	}
-	return s[start:end]
-}
-
-// HasPermission checks if an API key has permission for a specific endpoint
-func (as *AuthService) HasPermission(apiKey *models.APIKey, endpoint string) bool {
-	// Map endpoints to required permissions
-	permissionMap := map[string]string{
-		"geocode":   "geocode",
-		"search":    "search", 
-		"distance":  "distance",
-		"nearby":    "distance",
-		"proximity": "distance",
-		"addresses": "addresses",
-		"counties":  "counties",
-		"cities":    "cities",
-		"states":    "states",
-		"admin":     "admin",
-	}
-
-	requiredPermission, exists := permissionMap[endpoint]
-	if !exists {
-		return false // Unknown endpoint
-	}
-
-	// Check if API key has the required permission
-	for _, permission := range apiKey.Permissions {
-		if permission == requiredPermission || permission == "*" {
+// HasPermission checks if an API key holds a permission from the registry.
+// Legacy aliases held by a key (e.g. "nearby") resolve to their canonical permission.
+func (as *AuthService) HasPermission(apiKey *models.APIKey, permission string) bool {
+	required := Permissions.Canonical(permission)
+	for _, held := range apiKey.Permissions {
+		if held == WildcardPermission || Permissions.Canonical(held) == required {
 			return true
 		}
 	}
@@ -1089,54 +1327,46 @@ func (as *AuthService) HasPermission(apiKey *models.APIKey, endpoint string) boo
 }
 
 // GetAdminAnalytics returns system-wide analytics data
-func (as *AuthService) GetAdminAnalytics(days int) (map[string]interface{}, error) {
-	analytics := make(map[string]interface{})
+func (as *AuthService) GetAdminAnalytics(ctx context.Context, days int) (*models.AdminAnalytics, error) {
+	analytics := &models.AdminAnalytics{}
 	
 	// Total calls across all users
-	var totalCalls, billableCalls int
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT 
 			COUNT(*),
 			COUNT(*) FILTER (WHERE billable = true)
 		FROM usage_records 
 		WHERE created_at >= CURRENT_DATE - INTERVAL '1 day' * $1
-	`, days).Scan(&totalCalls, &billableCalls)
+	`, days).Scan(&analytics.TotalCalls, &analytics.BillableCalls)
 	if err != nil {
 		return nil, err
 	}
-	analytics["total_calls"] = totalCalls
-	analytics["billable_calls"] = billableCalls
 	
 	// Average response time
 	var avgResponseTime sql.NullFloat64
-	err = database.DB.QueryRow(`
+	err = database.DB.QueryRowContext(ctx, `
 		SELECT AVG(response_time_ms)
 		FROM usage_records 
 		WHERE created_at >= CURRENT_DATE - INTERVAL '1 day' * $1
 	`, days).Scan(&avgResponseTime)
 	if err == nil && avgResponseTime.Valid {
-		analytics["avg_response_time"] = avgResponseTime.Float64
-	} else {
-		analytics["avg_response_time"] = 0
+		analytics.AvgResponseTime = avgResponseTime.Float64
 	}
 	
 	// Success/Error rate
-	var successCount, errorCount int
-	err = database.DB.QueryRow(`
+	err = database.DB.QueryRowContext(ctx, `
 		SELECT 
 			COUNT(*) FILTER (WHERE status_code >= 200 AND status_code < 400),
 			COUNT(*) FILTER (WHERE status_code >= 400)
 		FROM usage_records 
 		WHERE created_at >= CURRENT_DATE - INTERVAL '1 day' * $1
-	`, days).Scan(&successCount, &errorCount)
+	`, days).Scan(&analytics.SuccessCount, &analytics.ErrorCount)
 	if err != nil {
 		return nil, err
 	}
-	analytics["success_count"] = successCount
-	analytics["error_count"] = errorCount
 	
 	// Endpoint breakdown
-	endpointRows, err := database.DB.Query(`
+	endpointRows, err := database.DB.QueryContext(ctx, `
 		SELECT 
 			endpoint,
 			COUNT(*) as total,
@@ -1152,33 +1382,23 @@ func (as *AuthService) GetAdminAnalytics(days int) (map[string]interface{}, erro
 	}
 	defer endpointRows.Close()
 	
-	var endpoints []map[string]interface{}
+	analytics.Endpoints = []models.EndpointMetrics{}
 	for endpointRows.Next() {
-		var endpoint string
-		var total, billable int
-		var avgTime sql.NullFloat64	
+		var endpoint models.EndpointMetrics
+		var avgTime sql.NullFloat64
 		
-		if err := endpointRows.Scan(&endpoint, &total, &billable, &avgTime); err != nil {
+		if err := endpointRows.Scan(&endpoint.Endpoint, &endpoint.Total, &endpoint.Billable, &avgTime); err != nil {
 			continue
 		}
-		
-		endpointData := map[string]interface{}{
-			"endpoint":       endpoint,
-			"total":          total,
-			"billable":       billable,
-			"avg_time":       0.0,
-		}
-		
 		if avgTime.Valid {
-			endpointData["avg_time"] = avgTime.Float64
+			endpoint.AvgTime = avgTime.Float64
 		}
 		
-		endpoints = append(endpoints, endpointData)
+		analytics.Endpoints = append(analytics.Endpoints, endpoint)
 	}
-	analytics["endpoints"] = endpoints
 	
 	// Daily breakdown
-	dailyRows, err := database.DB.Query(`
+	dailyRows, err := database.DB.QueryContext(ctx, `
 		SELECT 
 			DATE(created_at) as date,
 			COUNT(*) as total,
@@ -1193,22 +1413,18 @@ func (as *AuthService) GetAdminAnalytics(days int) (map[string]interface{}, erro
 	}
 	defer dailyRows.Close()
 	
-	var dailyUsage []map[string]interface{}
+	analytics.DailyUsage = []models.AnalyticsDailyUsage{}
 	for dailyRows.Next() {
 		var date time.Time
-		var total, billable int
+		var day models.AnalyticsDailyUsage
 		
-		if err := dailyRows.Scan(&date, &total, &billable); err != nil {
+		if err := dailyRows.Scan(&date, &day.TotalCalls, &day.BillableCalls); err != nil {
 			continue
 		}
+		day.Date = date.Format("2006-01-02")
 		
-		dailyUsage = append(dailyUsage, map[string]interface{}{
-			"date":           date.Format("2006-01-02"),
-			"total_calls":    total,
-			"billable_calls": billable,
-		})
+		analytics.DailyUsage = append(analytics.DailyUsage, day)
 	}
-	analytics["daily_usage"] = dailyUsage
 	
 	return analytics, nil
 }
\ No newline at end of file
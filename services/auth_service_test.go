@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminUserConditions(t *testing.T) {
+	where, args := adminUserConditions(models.AdminUserFilter{Limit: 10, Offset: 20})
+	assert.Equal(t, "WHERE u.deleted_at IS NULL", where)
+	assert.Empty(t, args)
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	where, args = adminUserConditions(models.AdminUserFilter{
+		Email:        "50%_off",
+		Plan:         "free",
+		ActiveSince:  since,
+		ActiveBefore: before,
+	})
+	assert.Contains(t, where, "u.email ILIKE '%' || $1 || '%'")
+	assert.Contains(t, where, "u.plan_type = $2")
+	assert.Contains(t, where, "ak.last_used_at >= $3)")
+	assert.Contains(t, where, "NOT EXISTS (SELECT 1 FROM api_keys ak WHERE ak.user_id = u.id AND ak.last_used_at >= $4)")
+	assert.Equal(t, []interface{}{`50\%\_off`, "free", since, before}, args)
+}
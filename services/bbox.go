@@ -0,0 +1,15 @@
+package services
+
+import "fmt"
+
+// bboxEnvelope is the polygon of a [min_lng, min_lat, max_lng, max_lat]
+// bounding box passed as the four parameters from $argIndex. Filtering with
+// && against it compares bounding boxes, which GIST indexes answer directly.
+func bboxEnvelope(argIndex int) string {
+	return fmt.Sprintf("ST_MakeEnvelope($%d, $%d, $%d, $%d, 4326)", argIndex, argIndex+1, argIndex+2, argIndex+3)
+}
+
+// bboxArgs are the parameters for bboxEnvelope
+func bboxArgs(bbox []float64) []interface{} {
+	return []interface{}{bbox[0], bbox[1], bbox[2], bbox[3]}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// burstCacheTTL controls how long an active-window lookup is cached per user
+const burstCacheTTL = 30 * time.Second
+
+// BurstService manages pre-approved burst windows, which let requests past
+// the limits they'd otherwise hit, up to the window's QPS
+type BurstService struct {
+	mu       sync.Mutex
+	windows  map[int]burstCacheEntry
+	counters map[int]*qpsCounter
+}
+
+type burstCacheEntry struct {
+	window    *models.BurstWindow
+	fetchedAt time.Time
+}
+
+type qpsCounter struct {
+	second int64
+	count  int
+}
+
+var Burst = &BurstService{
+	windows:  make(map[int]burstCacheEntry),
+	counters: make(map[int]*qpsCounter),
+}
+
+const burstWindowFields = `bw.id, bw.user_id, u.email, bw.starts_at, bw.ends_at, bw.requested_qps,
+		COALESCE(bw.reason, ''), bw.status, bw.reviewed_by, bw.reviewed_at, bw.created_at`
+
+func scanBurstWindow(scanner interface{ Scan(...interface{}) error }) (*models.BurstWindow, error) {
+	var w models.BurstWindow
+	err := scanner.Scan(&w.ID, &w.UserID, &w.UserEmail, &w.StartsAt, &w.EndsAt, &w.RequestedQPS,
+		&w.Reason, &w.Status, &w.ReviewedBy, &w.ReviewedAt, &w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateBurstRequest records a pending burst window request for an enterprise user
+func (b *BurstService) CreateBurstRequest(ctx context.Context, user *models.User, req models.BurstWindowRequest) (*models.BurstWindow, error) {
+	if user.PlanType != "enterprise" {
+		return nil, fmt.Errorf("burst windows are only available on the enterprise plan")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+	if req.EndsAt.Before(time.Now()) {
+		return nil, fmt.Errorf("burst window must end in the future")
+	}
+	if req.EndsAt.Sub(req.StartsAt) > models.MaxBurstWindowDuration {
+		return nil, fmt.Errorf("burst window cannot exceed %s", models.MaxBurstWindowDuration)
+	}
+	if req.RequestedQPS <= 0 {
+		return nil, fmt.Errorf("requested_qps must be greater than 0")
+	}
+
+	var id int
+	err := database.DB.QueryRowContext(ctx, `
+		INSERT INTO burst_windows (user_id, starts_at, ends_at, requested_qps, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, user.ID, req.StartsAt.UTC(), req.EndsAt.UTC(), req.RequestedQPS, req.Reason).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create burst request: %w", err)
+	}
+
+	return b.GetBurstWindowByID(ctx, id)
+}
+
+// GetBurstWindowByID retrieves a single burst window
+func (b *BurstService) GetBurstWindowByID(ctx context.Context, id int) (*models.BurstWindow, error) {
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+burstWindowFields+`
+		FROM burst_windows bw
+		JOIN users u ON u.id = bw.user_id
+		WHERE bw.id = $1
+	`, id)
+	w, err := scanBurstWindow(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("burst window not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get burst window: %w", err)
+	}
+	return w, nil
+}
+
+// GetBurstWindows lists burst windows, optionally filtered by user and status
+func (b *BurstService) GetBurstWindows(ctx context.Context, userID int, status string) ([]models.BurstWindow, error) {
+	query := `
+		SELECT ` + burstWindowFields + `
+		FROM burst_windows bw
+		JOIN users u ON u.id = bw.user_id
+		WHERE ($1 = 0 OR bw.user_id = $1) AND ($2 = '' OR bw.status = $2)
+		ORDER BY bw.starts_at DESC
+		LIMIT 200`
+
+	rows, err := database.DB.QueryContext(ctx, query, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list burst windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := []models.BurstWindow{}
+	for rows.Next() {
+		w, err := scanBurstWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan burst window: %w", err)
+		}
+		windows = append(windows, *w)
+	}
+	return windows, rows.Err()
+}
+
+// ReviewBurstRequest approves or rejects a pending burst window
+func (b *BurstService) ReviewBurstRequest(ctx context.Context, id, adminID int, status string) (*models.BurstWindow, error) {
+	if status != "approved" && status != "rejected" {
+		return nil, fmt.Errorf("status must be 'approved' or 'rejected'")
+	}
+
+	result, err := database.DB.ExecContext(ctx, `
+		UPDATE burst_windows
+		SET status = $1, reviewed_by = $2, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'pending'
+	`, status, adminID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review burst request: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, fmt.Errorf("burst window not found or already reviewed")
+	}
+
+	w, err := b.GetBurstWindowByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	delete(b.windows, w.UserID)
+	b.mu.Unlock()
+
+	return w, nil
+}
+
+// GetActiveWindow returns the approved burst window covering the current time
+// for a user, or nil. Lookups are cached briefly since this runs per request.
+func (b *BurstService) GetActiveWindow(ctx context.Context, userID int) (*models.BurstWindow, error) {
+	now := time.Now()
+
+	b.mu.Lock()
+	entry, ok := b.windows[userID]
+	b.mu.Unlock()
+	if ok && now.Sub(entry.fetchedAt) < burstCacheTTL {
+		if entry.window == nil || now.Before(entry.window.EndsAt) {
+			return entry.window, nil
+		}
+	}
+
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+burstWindowFields+`
+		FROM burst_windows bw
+		JOIN users u ON u.id = bw.user_id
+		WHERE bw.user_id = $1 AND bw.status = 'approved'
+		AND bw.starts_at <= (NOW() AT TIME ZONE 'UTC') AND bw.ends_at > (NOW() AT TIME ZONE 'UTC')
+		ORDER BY bw.requested_qps DESC
+		LIMIT 1
+	`, userID)
+	w, err := scanBurstWindow(row)
+	if err == sql.ErrNoRows {
+		w, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active burst window: %w", err)
+	}
+
+	b.mu.Lock()
+	b.windows[userID] = burstCacheEntry{window: w, fetchedAt: now}
+	b.mu.Unlock()
+
+	return w, nil
+}
+
+// AllowRequest counts a request against the user's per-second burst cap and
+// reports whether it is allowed
+func (b *BurstService) AllowRequest(userID, qps int) bool {
+	sec := time.Now().Unix()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	counter, ok := b.counters[userID]
+	if !ok || counter.second != sec {
+		counter = &qpsCounter{second: sec}
+		b.counters[userID] = counter
+	}
+	if counter.count >= qps {
+		return false
+	}
+	counter.count++
+	return true
+}
+
+// Admit decides a request that is over its plan, organization or API key
+// limits. During an approved window it is let through unless the window's
+// QPS is used up; window is nil when none is active, and the request is
+// refused. Lookup errors count as no window.
+func (b *BurstService) Admit(ctx context.Context, userID int) (window *models.BurstWindow, allowed bool) {
+	window, err := b.GetActiveWindow(ctx, userID)
+	if err != nil || window == nil {
+		return nil, false
+	}
+	return window, b.AllowRequest(userID, window.RequestedQPS)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBurstAdmit(t *testing.T) {
+	b := &BurstService{
+		windows:  make(map[int]burstCacheEntry),
+		counters: make(map[int]*qpsCounter),
+	}
+	now := time.Now()
+	ctx := context.Background()
+
+	// Without a window an over-limit request is refused
+	b.windows[1] = burstCacheEntry{fetchedAt: now}
+	window, allowed := b.Admit(ctx, 1)
+	assert.Nil(t, window)
+	assert.False(t, allowed)
+
+	// An approved window lets it through, up to its QPS
+	approved := &models.BurstWindow{ID: 9, UserID: 2, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), RequestedQPS: 1000, Status: "approved"}
+	b.windows[2] = burstCacheEntry{window: approved, fetchedAt: now}
+	window, allowed = b.Admit(ctx, 2)
+	assert.Equal(t, approved, window)
+	assert.True(t, allowed)
+
+	// Use up this second's QPS; unless the clock ticked over, it's refused
+	b.counters[2] = &qpsCounter{second: time.Now().Unix(), count: 1000}
+	window, allowed = b.Admit(ctx, 2)
+	assert.Equal(t, approved, window)
+	if b.counters[2].count == 1000 {
+		assert.False(t, allowed)
+	}
+}
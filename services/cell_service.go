@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+	"geocoding-api/utils"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultCellResolution is used when no resolution is requested: H3
+	// resolution 9 (about 0.1 km²) and a 9-character geohash (about 5 m)
+	DefaultCellResolution = 9
+	// maxH3Resolution is H3's finest resolution
+	maxH3Resolution = 15
+)
+
+var (
+	// ErrUnknownCellSystem is returned for a system other than h3 or geohash
+	ErrUnknownCellSystem = errors.New("system must be h3 or geohash")
+	// ErrInvalidCellResolution is returned for a resolution the system doesn't have
+	ErrInvalidCellResolution = errors.New("invalid resolution")
+	// ErrInvalidCell is returned when a cell to decode is malformed
+	ErrInvalidCell = errors.New("invalid cell")
+	// ErrH3Unavailable is returned when the database lacks the h3 extensions
+	ErrH3Unavailable = errors.New("H3 is not available on this server")
+)
+
+// CellService converts points to and from H3 and geohash cells. Geohashes
+// are computed in Go; H3 comes from the h3-pg extensions.
+type CellService struct{}
+
+var Cells = &CellService{}
+
+// Encode returns the cell containing a point. A negative resolution means
+// DefaultCellResolution.
+func (s *CellService) Encode(ctx context.Context, system string, lat, lng float64, resolution int) (*models.Cell, error) {
+	if resolution < 0 {
+		resolution = DefaultCellResolution
+	}
+
+	switch system {
+	case models.CellSystemGeohash:
+		if resolution < 1 || resolution > utils.MaxGeohashPrecision {
+			return nil, fmt.Errorf("%w: geohash precision must be 1 to %d", ErrInvalidCellResolution, utils.MaxGeohashPrecision)
+		}
+		return s.Decode(ctx, system, utils.EncodeGeohash(lat, lng, resolution))
+	case models.CellSystemH3:
+		if resolution > maxH3Resolution {
+			return nil, fmt.Errorf("%w: H3 resolution must be 0 to %d", ErrInvalidCellResolution, maxH3Resolution)
+		}
+		var cell string
+		err := database.ReadDB(ctx).QueryRowContext(ctx,
+			"SELECT h3_lat_lng_to_cell(ST_SetSRID(ST_MakePoint($1, $2), 4326), $3)::text", lng, lat, resolution,
+		).Scan(&cell)
+		if err != nil {
+			return nil, h3Error(err)
+		}
+		return s.Decode(ctx, system, cell)
+	default:
+		return nil, ErrUnknownCellSystem
+	}
+}
+
+// Decode returns the center, resolution and outline of a cell
+func (s *CellService) Decode(ctx context.Context, system, cell string) (*models.Cell, error) {
+	cell = strings.ToLower(strings.TrimSpace(cell))
+
+	switch system {
+	case models.CellSystemGeohash:
+		bounds, err := utils.DecodeGeohash(cell)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCell, err)
+		}
+		lat, lng := bounds.Center()
+		boundary, err := json.Marshal(map[string]interface{}{
+			"type": "Polygon",
+			"coordinates": [][][2]float64{{
+				{bounds.MinLng, bounds.MinLat}, {bounds.MaxLng, bounds.MinLat},
+				{bounds.MaxLng, bounds.MaxLat}, {bounds.MinLng, bounds.MaxLat},
+				{bounds.MinLng, bounds.MinLat},
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &models.Cell{System: system, Cell: cell, Resolution: len(cell), Lat: lat, Lng: lng, Boundary: boundary}, nil
+
+	case models.CellSystemH3:
+		result := &models.Cell{System: system, Cell: cell}
+		var valid bool
+		var boundary string
+		err := database.ReadDB(ctx).QueryRowContext(ctx, `
+			SELECT h3_is_valid_cell(c), h3_get_resolution(c),
+				   ST_Y(h3_cell_to_geometry(c)), ST_X(h3_cell_to_geometry(c)),
+				   ST_AsGeoJSON(h3_cell_to_boundary_geometry(c))
+			FROM (SELECT $1::h3index AS c) AS cell
+		`, cell).Scan(&valid, &result.Resolution, &result.Lat, &result.Lng, &boundary)
+		if err != nil {
+			return nil, h3Error(err)
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %s is not an H3 cell", ErrInvalidCell, cell)
+		}
+		result.Boundary = json.RawMessage(boundary)
+		return result, nil
+
+	default:
+		return nil, ErrUnknownCellSystem
+	}
+}
+
+// h3Error maps a failed H3 query to ErrH3Unavailable when the extension
+// functions or type are missing and to ErrInvalidCell for malformed input
+func h3Error(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		// undefined_function, undefined_object (the h3index type)
+		case pqErr.Code == "42883" || pqErr.Code == "42704":
+			return ErrH3Unavailable
+		// data_exception, e.g. an unparseable h3index, or an error raised by
+		// the H3 library (external_routine_exception)
+		case pqErr.Code.Class() == "22" || pqErr.Code.Class() == "38":
+			return fmt.Errorf("%w: %s", ErrInvalidCell, pqErr.Message)
+		}
+	}
+	return fmt.Errorf("H3 query failed: %w", err)
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// censusBlockGroupFiles are the TIGER/Line block group files the census
+// dataset is loaded from, converted from shapefile to GeoJSON, e.g. with
+// ogr2ogr -f GeoJSON tl_2025_39_bg.geojson tl_2025_39_bg.shp
+var censusBlockGroupFiles = []string{"tl_2025_39_bg.geojson", "tl_2025_us_bg.geojson"}
+
+// CensusService looks up the Census geography of points
+type CensusService struct{}
+
+var Census = &CensusService{}
+
+// censusBlockGroup is one block group row parsed from TIGER/Line properties
+type censusBlockGroup struct {
+	GEOID      string
+	StateFIPS  string
+	CountyFIPS string
+	TractCode  string
+	BlockGroup string
+	AreaLand   int64
+	AreaWater  int64
+}
+
+// censusBlockGroupFromProperties reads the TIGER/Line attributes of a block
+// group feature, checking that the GEOID is made up of its parts
+func censusBlockGroupFromProperties(props map[string]interface{}) (censusBlockGroup, error) {
+	text := func(key string) string {
+		value, _ := props[key].(string)
+		return value
+	}
+	number := func(key string) int64 {
+		value, _ := props[key].(float64)
+		return int64(value)
+	}
+
+	bg := censusBlockGroup{
+		GEOID:      text("GEOID"),
+		StateFIPS:  text("STATEFP"),
+		CountyFIPS: text("COUNTYFP"),
+		TractCode:  text("TRACTCE"),
+		BlockGroup: text("BLKGRPCE"),
+		AreaLand:   number("ALAND"),
+		AreaWater:  number("AWATER"),
+	}
+	if len(bg.StateFIPS) != 2 || len(bg.CountyFIPS) != 3 || len(bg.TractCode) != 6 || len(bg.BlockGroup) != 1 {
+		return censusBlockGroup{}, fmt.Errorf("block group %q has malformed STATEFP, COUNTYFP, TRACTCE or BLKGRPCE", bg.GEOID)
+	}
+	if bg.GEOID != bg.StateFIPS+bg.CountyFIPS+bg.TractCode+bg.BlockGroup {
+		return censusBlockGroup{}, fmt.Errorf("block group GEOID %q does not match its state, county, tract and block group", bg.GEOID)
+	}
+	return bg, nil
+}
+
+// loadCensusBlockGroups upserts the block groups in the TIGER/Line GeoJSON
+func loadCensusBlockGroups(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	file, source, err := openDataFile(censusBlockGroupFiles...)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	run.setSource(source)
+
+	features, err := newGeoJSONFeatureReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO census_block_groups (
+			geoid, state_fips, county_fips, tract_code, block_group, tract_geoid,
+			area_land, area_water, geom
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8,
+			ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($9), 4326))
+		)
+		ON CONFLICT (geoid) DO UPDATE SET
+			area_land = EXCLUDED.area_land,
+			area_water = EXCLUDED.area_water,
+			geom = EXCLUDED.geom,
+			updated_at = NOW()
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+
+		bg, err := censusBlockGroupFromProperties(feature.Properties)
+		if err != nil {
+			slog.Warn("skipping invalid block group", "error", err)
+			run.record(rowInvalid)
+			continue
+		}
+		if feature.Geometry.Type != "Polygon" && feature.Geometry.Type != "MultiPolygon" {
+			slog.Warn("skipping block group without a polygon", "geoid", bg.GEOID, "type", feature.Geometry.Type)
+			run.record(rowInvalid)
+			continue
+		}
+		geometryJSON, err := json.Marshal(feature.Geometry)
+		if err != nil {
+			run.record(rowInvalid)
+			continue
+		}
+
+		outcome, err := upsertRow(ctx, stmt,
+			bg.GEOID, bg.StateFIPS, bg.CountyFIPS, bg.TractCode, bg.BlockGroup,
+			bg.GEOID[:11], bg.AreaLand, bg.AreaWater, string(geometryJSON),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert block group %s: %w", bg.GEOID, err)
+		}
+		run.record(outcome)
+	}
+}
+
+// censusGeographyFields are the columns scanCensusGeography reads, from
+// census_block_groups aliased bg
+const censusGeographyFields = `bg.geoid, bg.block_group, bg.tract_geoid, bg.tract_code, bg.state_fips, bg.county_fips`
+
+// scanCensusGeography scans censusGeographyFields, after any extra columns
+func scanCensusGeography(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.CensusGeography, error) {
+	var geo models.CensusGeography
+	dest := append(extra,
+		&geo.BlockGroupGEOID, &geo.BlockGroup, &geo.TractGEOID, &geo.Tract, &geo.StateFIPS, &geo.CountyFIPS,
+	)
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, err
+	}
+	geo.CountyGEOID = geo.StateFIPS + geo.CountyFIPS
+	return &geo, nil
+}
+
+// GetCensusGeography returns the block group containing a point, or nil
+// when the point is outside every loaded block group
+func (s *CensusService) GetCensusGeography(ctx context.Context, lat, lng float64) (*models.CensusGeography, error) {
+	query := `
+		SELECT ` + censusGeographyFields + `
+		FROM census_block_groups bg
+		WHERE ST_Covers(bg.geom, ST_SetSRID(ST_MakePoint($2, $1), 4326))
+		LIMIT 1
+	`
+
+	geo, err := scanCensusGeography(database.ReadDB(ctx).QueryRowContext(ctx, query, lat, lng))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up census geography: %w", err)
+	}
+	return geo, nil
+}
+
+// GetAddressCensusGeography returns the block group containing each address
+// point, keyed by address ID. Addresses outside every block group are left out.
+func (s *CensusService) GetAddressCensusGeography(ctx context.Context, addressIDs []int64) (map[int64]*models.CensusGeography, error) {
+	result := make(map[int64]*models.CensusGeography, len(addressIDs))
+	if len(addressIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		SELECT DISTINCT ON (a.id) a.id, ` + censusGeographyFields + `
+		FROM ohio_addresses a
+		JOIN census_block_groups bg ON ST_Covers(bg.geom, a.geom)
+		WHERE a.id = ANY($1)
+		ORDER BY a.id, bg.geoid
+	`
+
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, pq.Array(addressIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up census geography: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		geo, err := scanCensusGeography(rows, &id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan census geography: %w", err)
+		}
+		result[id] = geo
+	}
+	return result, rows.Err()
+}
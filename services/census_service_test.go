@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCensusBlockGroupFromProperties(t *testing.T) {
+	props := map[string]interface{}{
+		"STATEFP":  "39",
+		"COUNTYFP": "049",
+		"TRACTCE":  "001000",
+		"BLKGRPCE": "2",
+		"GEOID":    "390490010002",
+		"ALAND":    float64(412345),
+		"AWATER":   float64(0),
+	}
+
+	bg, err := censusBlockGroupFromProperties(props)
+	require.NoError(t, err)
+	assert.Equal(t, censusBlockGroup{
+		GEOID:      "390490010002",
+		StateFIPS:  "39",
+		CountyFIPS: "049",
+		TractCode:  "001000",
+		BlockGroup: "2",
+		AreaLand:   412345,
+	}, bg)
+
+	t.Run("mismatched GEOID", func(t *testing.T) {
+		bad := map[string]interface{}{}
+		for k, v := range props {
+			bad[k] = v
+		}
+		bad["GEOID"] = "390490010003"
+		_, err := censusBlockGroupFromProperties(bad)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing tract", func(t *testing.T) {
+		bad := map[string]interface{}{}
+		for k, v := range props {
+			bad[k] = v
+		}
+		delete(bad, "TRACTCE")
+		_, err := censusBlockGroupFromProperties(bad)
+		assert.Error(t, err)
+	})
+}
@@ -1,13 +1,12 @@
 package services
 
 import (
-	"compress/gzip"
+	"context"
 	"database/sql"
 	"encoding/csv"
 	"fmt"
 	"io"
-	"log"
-	"os"
+	"log/slog"
 	"strconv"
 	"strings"
 
@@ -20,73 +19,53 @@ type CityService struct{}
 
 var City = &CityService{}
 
-// InitializeCityData loads city data from CSV if the table is empty
-func InitializeCityData() error {
-	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM cities").Scan(&count)
+// loadCities inserts the cities in the bundled simplemaps CSV, skipping
+// cities that are already present
+func loadCities(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	file, source, err := openDataFile("uscities.csv")
 	if err != nil {
-		return fmt.Errorf("failed to check cities table: %w", err)
-	}
-
-	if count > 0 {
-		log.Printf("Cities table already contains %d records, skipping initialization", count)
-		return nil
-	}
-
-	log.Println("Cities table is empty, loading data from uscities.csv.gz...")
-	
-	file, err := os.Open("uscities.csv.gz")
-	if err != nil {
-		return fmt.Errorf("failed to open uscities.csv.gz: %w", err)
+		return err
 	}
 	defer file.Close()
+	run.setSource(source)
 
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzReader.Close()
+	csvReader := csv.NewReader(file)
 
-	csvReader := csv.NewReader(gzReader)
-	
 	// Read header
 	header, err := csvReader.Read()
 	if err != nil {
 		return fmt.Errorf("failed to read CSV header: %w", err)
 	}
-	log.Printf("CSV columns: %v", header)
+	slog.Debug("city CSV columns", "columns", header)
 
-	// Prepare insert statement
-	stmt, err := database.DB.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO cities (
 			city, city_ascii, state_id, state_name, county_fips, county_name,
 			lat, lng, population, density, source, military, incorporated,
 			timezone, ranking, zips, external_id
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		ON CONFLICT (city_ascii, state_id) DO NOTHING
+		RETURNING (xmax = 0)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer stmt.Close()
 
-	count = 0
-	skipped := 0
-	
 	for {
 		record, err := csvReader.Read()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			log.Printf("Error reading CSV row: %v", err)
-			skipped++
+			slog.Warn("failed to read city CSV row", "error", err)
+			run.record(rowInvalid)
 			continue
 		}
 
 		if len(record) < 17 {
-			log.Printf("Skipping row with insufficient columns: %v", record)
-			skipped++
+			slog.Warn("skipping city row with insufficient columns", "row", record)
+			run.record(rowInvalid)
 			continue
 		}
 
@@ -99,43 +78,57 @@ func InitializeCityData() error {
 		military := strings.ToUpper(record[11]) == "TRUE"
 		incorporated := strings.ToUpper(record[12]) == "TRUE"
 
-		_, err = stmt.Exec(
-			record[0],  // city
-			record[1],  // city_ascii
-			record[2],  // state_id
-			record[3],  // state_name
-			record[4],  // county_fips
-			record[5],  // county_name
-			lat,        // lat
-			lng,        // lng
-			population, // population
-			density,    // density
-			record[10], // source
-			military,   // military
+		outcome, err := upsertRow(ctx, stmt,
+			record[0],    // city
+			record[1],    // city_ascii
+			record[2],    // state_id
+			record[3],    // state_name
+			record[4],    // county_fips
+			record[5],    // county_name
+			lat,          // lat
+			lng,          // lng
+			population,   // population
+			density,      // density
+			record[10],   // source
+			military,     // military
 			incorporated, // incorporated
-			record[13], // timezone
-			ranking,    // ranking
-			record[15], // zips
-			record[16], // external_id
+			record[13],   // timezone
+			ranking,      // ranking
+			record[15],   // zips
+			record[16],   // external_id
 		)
 		if err != nil {
-			log.Printf("Error inserting city %s, %s: %v", record[0], record[2], err)
-			skipped++
-			continue
-		}
-
-		count++
-		if count%1000 == 0 {
-			log.Printf("Loaded %d cities...", count)
+			return fmt.Errorf("failed to insert city %s, %s: %w", record[0], record[2], err)
 		}
+		run.record(outcome)
 	}
+}
+
+// cityFields is the column list scanned by scanCity
+const cityFields = `id, city, city_ascii, state_id, state_name, COALESCE(county_fips, ''), COALESCE(county_name, ''),
+		lat, lng, COALESCE(population, 0), COALESCE(density, 0), COALESCE(source, ''), military, incorporated,
+		COALESCE(timezone, ''), COALESCE(ranking, 0), COALESCE(zips, ''), COALESCE(external_id, '')`
 
-	log.Printf("✅ Successfully loaded %d cities (skipped %d)", count, skipped)
-	return nil
+// cityGeography is a city's location as a geography, matching the
+// expression indexed by idx_cities_geography
+const cityGeography = `ST_SetSRID(ST_MakePoint(lng::float8, lat::float8), 4326)::geography`
+
+// scanCity scans a row selected with cityFields followed by any extra columns
+func scanCity(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (models.City, error) {
+	var city models.City
+	dest := append([]interface{}{
+		&city.ID, &city.City, &city.CityAscii, &city.StateID, &city.StateName,
+		&city.CountyFIPS, &city.CountyName, &city.Lat, &city.Lng,
+		&city.Population, &city.Density, &city.Source, &city.Military, &city.Incorporated,
+		&city.Timezone, &city.Ranking, &city.Zips, &city.ExternalID,
+	}, extra...)
+	err := scanner.Scan(dest...)
+	return city, err
 }
 
-// SearchCities searches for cities based on various parameters
-func (cs *CityService) SearchCities(params models.CitySearchParams) ([]models.City, int, error) {
+// SearchCities searches for cities based on various parameters. With a
+// location, results are ordered nearest first and carry their distance.
+func (cs *CityService) SearchCities(ctx context.Context, params models.CitySearchParams) ([]models.City, int, error) {
 	if params.Limit <= 0 {
 		params.Limit = 10
 	}
@@ -187,20 +180,38 @@ func (cs *CityService) SearchCities(params models.CitySearchParams) ([]models.Ci
 		args = append(args, params.MinPop)
 	}
 
-	// Location-based search
+	if params.MaxPop > 0 {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("population <= $%d", argCount))
+		args = append(args, params.MaxPop)
+	}
+
+	// Bounding box: the geography && uses idx_cities_geography but compares
+	// geocentric extents, so the planar && on the point keeps to the box
+	if params.BBox != nil {
+		envelope := bboxEnvelope(argCount + 1)
+		conditions = append(conditions, fmt.Sprintf("%s && %s::geography AND ST_SetSRID(ST_MakePoint(lng::float8, lat::float8), 4326) && %s",
+			cityGeography, envelope, envelope))
+		args = append(args, bboxArgs(params.BBox)...)
+		argCount += 4
+	}
+
+	// Location-based search: distances are computed on the geography so the
+	// radius is in kilometers anywhere in the country
+	distance := "NULL::float8"
+	orderBy := "CASE WHEN ranking > 0 THEN ranking ELSE 999999 END, population DESC NULLS LAST, id"
 	if params.Lat != 0 && params.Lng != 0 {
+		argCount += 2
+		origin := fmt.Sprintf("ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography", argCount, argCount-1)
+		args = append(args, params.Lat, params.Lng)
+
 		if params.Radius > 0 {
-			// Using simple distance calculation (good enough for most cases)
-			argCount += 3
-			conditions = append(conditions, fmt.Sprintf(`
-				(6371 * acos(
-					cos(radians($%d)) * cos(radians(lat)) * 
-					cos(radians(lng) - radians($%d)) + 
-					sin(radians($%d)) * sin(radians(lat))
-				)) <= $%d
-			`, argCount-2, argCount-1, argCount-2, argCount))
-			args = append(args, params.Lat, params.Lng, params.Radius)
+			argCount++
+			conditions = append(conditions, fmt.Sprintf("ST_DWithin(%s, %s, $%d * 1000)", cityGeography, origin, argCount))
+			args = append(args, params.Radius)
 		}
+		distance = fmt.Sprintf("ST_Distance(%s, %s) / 1000", cityGeography, origin)
+		orderBy = "distance_km, " + orderBy
 	}
 
 	whereClause := ""
@@ -211,27 +222,23 @@ func (cs *CityService) SearchCities(params models.CitySearchParams) ([]models.Ci
 	// Get total count
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM cities %s", whereClause)
 	var total int
-	err := database.DB.QueryRow(countQuery, args...).Scan(&total)
+	err := database.ReadDB(ctx).QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count cities: %w", err)
 	}
 
 	// Build main query
 	query := fmt.Sprintf(`
-		SELECT id, city, city_ascii, state_id, state_name, county_fips, county_name,
-		       lat, lng, population, density, source, military, incorporated,
-		       timezone, ranking, zips, external_id
+		SELECT %s, %s AS distance_km
 		FROM cities
 		%s
-		ORDER BY 
-			CASE WHEN ranking > 0 THEN ranking ELSE 999999 END,
-			population DESC NULLS LAST
+		ORDER BY %s
 		LIMIT $%d OFFSET $%d
-	`, whereClause, argCount+1, argCount+2)
+	`, cityFields, distance, whereClause, orderBy, argCount+1, argCount+2)
 
 	args = append(args, params.Limit, params.Offset)
 
-	rows, err := database.DB.Query(query, args...)
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query cities: %w", err)
 	}
@@ -239,48 +246,14 @@ func (cs *CityService) SearchCities(params models.CitySearchParams) ([]models.Ci
 
 	var cities []models.City
 	for rows.Next() {
-		var city models.City
-		var countyFIPS, countyName, source, timezone, zips, externalID sql.NullString
-		var population, ranking sql.NullInt64
-		var density sql.NullFloat64
-
-		err := rows.Scan(
-			&city.ID, &city.City, &city.CityAscii, &city.StateID, &city.StateName,
-			&countyFIPS, &countyName, &city.Lat, &city.Lng,
-			&population, &density, &source, &city.Military, &city.Incorporated,
-			&timezone, &ranking, &zips, &externalID,
-		)
+		var distanceKm sql.NullFloat64
+		city, err := scanCity(rows, &distanceKm)
 		if err != nil {
-			log.Printf("Error scanning city: %v", err)
+			slog.Warn("failed to scan city", "error", err)
 			continue
 		}
-
-		if countyFIPS.Valid {
-			city.CountyFIPS = countyFIPS.String
-		}
-		if countyName.Valid {
-			city.CountyName = countyName.String
-		}
-		if population.Valid {
-			city.Population = int(population.Int64)
-		}
-		if density.Valid {
-			city.Density = density.Float64
-		}
-		if source.Valid {
-			city.Source = source.String
-		}
-		if timezone.Valid {
-			city.Timezone = timezone.String
-		}
-		if ranking.Valid {
-			city.Ranking = int(ranking.Int64)
-		}
-		if zips.Valid {
-			city.Zips = zips.String
-		}
-		if externalID.Valid {
-			city.ExternalID = externalID.String
+		if distanceKm.Valid {
+			city.DistanceKm = &distanceKm.Float64
 		}
 
 		cities = append(cities, city)
@@ -290,27 +263,10 @@ func (cs *CityService) SearchCities(params models.CitySearchParams) ([]models.Ci
 }
 
 // GetCityByID retrieves a specific city by ID
-func (cs *CityService) GetCityByID(id int64) (*models.City, error) {
-	var city models.City
-	var countyFIPS, countyName, source, timezone, zips, externalID sql.NullString
-	var population, ranking sql.NullInt64
-	var density sql.NullFloat64
-
-	query := `
-		SELECT id, city, city_ascii, state_id, state_name, county_fips, county_name,
-		       lat, lng, population, density, source, military, incorporated,
-		       timezone, ranking, zips, external_id
-		FROM cities
-		WHERE id = $1
-	`
-
-	err := database.DB.QueryRow(query, id).Scan(
-		&city.ID, &city.City, &city.CityAscii, &city.StateID, &city.StateName,
-		&countyFIPS, &countyName, &city.Lat, &city.Lng,
-		&population, &density, &source, &city.Military, &city.Incorporated,
-		&timezone, &ranking, &zips, &externalID,
-	)
+func (cs *CityService) GetCityByID(ctx context.Context, id int64) (*models.City, error) {
+	query := fmt.Sprintf("SELECT %s FROM cities WHERE id = $1", cityFields)
 
+	city, err := scanCity(database.ReadDB(ctx).QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("city not found")
 	}
@@ -318,39 +274,37 @@ func (cs *CityService) GetCityByID(id int64) (*models.City, error) {
 		return nil, fmt.Errorf("failed to get city: %w", err)
 	}
 
-	if countyFIPS.Valid {
-		city.CountyFIPS = countyFIPS.String
-	}
-	if countyName.Valid {
-		city.CountyName = countyName.String
-	}
-	if population.Valid {
-		city.Population = int(population.Int64)
-	}
-	if density.Valid {
-		city.Density = density.Float64
-	}
-	if source.Valid {
-		city.Source = source.String
-	}
-	if timezone.Valid {
-		city.Timezone = timezone.String
-	}
-	if ranking.Valid {
-		city.Ranking = int(ranking.Int64)
-	}
-	if zips.Valid {
-		city.Zips = zips.String
+	return &city, nil
+}
+
+// GetNearestCity returns the closest city to a point with at least minPop
+// residents, or nil if none is within maxKm
+func (cs *CityService) GetNearestCity(ctx context.Context, lat, lng float64, minPop int, maxKm float64) (*models.City, error) {
+	query := fmt.Sprintf(`
+		WITH origin AS (SELECT ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography AS pt)
+		SELECT %s, ST_Distance(%s, origin.pt) / 1000 AS distance_km
+		FROM cities, origin
+		WHERE COALESCE(population, 0) >= $3
+		AND ST_DWithin(%s, origin.pt, $4 * 1000)
+		ORDER BY %s <-> origin.pt
+		LIMIT 1
+	`, cityFields, cityGeography, cityGeography, cityGeography)
+
+	var distanceKm float64
+	city, err := scanCity(database.ReadDB(ctx).QueryRowContext(ctx, query, lat, lng, minPop, maxKm), &distanceKm)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	if externalID.Valid {
-		city.ExternalID = externalID.String
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearest city: %w", err)
 	}
 
+	city.DistanceKm = &distanceKm
 	return &city, nil
 }
 
 // GetZIPCodesForCity returns the list of ZIP codes for a city
-func (cs *CityService) GetZIPCodesForCity(cityAscii, state string) ([]string, error) {
+func (cs *CityService) GetZIPCodesForCity(ctx context.Context, cityAscii, state string) ([]string, error) {
 	var zips sql.NullString
 	var query string
 	
@@ -364,7 +318,7 @@ func (cs *CityService) GetZIPCodesForCity(cityAscii, state string) ([]string, er
 		query = "SELECT zips FROM cities WHERE city_ascii ILIKE $1 AND (state_id = $2 OR state_name ILIKE $2)"
 	}
 	
-	err := database.DB.QueryRow(query, cityAscii, stateUpper).Scan(&zips)
+	err := database.ReadDB(ctx).QueryRowContext(ctx, query, cityAscii, stateUpper).Scan(&zips)
 	if err == sql.ErrNoRows {
 		return []string{}, nil
 	}
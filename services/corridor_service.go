@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// maxCorridorVertices caps the routes of a corridor search
+const maxCorridorVertices = 10000
+
+// metersPerMile converts PostGIS geography distances to miles
+const metersPerMile = 1609.344
+
+// ErrCorridorRouteInvalid wraps problems with the route of a corridor search
+var ErrCorridorRouteInvalid = errors.New("invalid route")
+
+// corridorRoute parses the route once for the whole query, taking the buffer
+// in meters as $2. reach is the buffer in degrees at the route's highest
+// latitude, where a degree is shortest, padded by 1%: a bounding test with
+// it keeps every point the exact geography test keeps, but can use the
+// planar indexes.
+const corridorRoute = `WITH route AS (
+	SELECT line, ST_Length(line::geography) AS length,
+		$2 * 1.01 / (110574 * cos(radians(LEAST(GREATEST(abs(ST_YMin(line)), abs(ST_YMax(line))), 89)))) AS reach
+	FROM (SELECT ST_SetSRID(ST_GeomFromGeoJSON($1), 4326) AS line) AS parsed
+)`
+
+// lineGeometry returns the GeoJSON LineString in raw, unwrapping a Feature
+func lineGeometry(raw json.RawMessage) (string, error) {
+	var object struct {
+		Type     string          `json:"type"`
+		Geometry json.RawMessage `json:"geometry"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &object) != nil {
+		return "", fmt.Errorf("%w: geometry must be a GeoJSON object", ErrCorridorRouteInvalid)
+	}
+	if object.Type == "Feature" {
+		return lineGeometry(object.Geometry)
+	}
+	if object.Type != "LineString" {
+		return "", fmt.Errorf("%w: geometry must be a LineString, got %q", ErrCorridorRouteInvalid, object.Type)
+	}
+	return string(raw), nil
+}
+
+// SearchCorridor returns the ZIP codes and address points within
+// bufferMiles of a GeoJSON LineString, up to limit of each, ordered by how
+// far along the route they are. include names the result types to look up.
+func SearchCorridor(ctx context.Context, geometry []byte, bufferMiles float64, include map[string]bool, limit int) (*models.CorridorResult, error) {
+	route, err := lineGeometry(geometry)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGeometry(ctx, route, maxCorridorVertices, ErrCorridorRouteInvalid); err != nil {
+		return nil, err
+	}
+
+	result := &models.CorridorResult{BufferMiles: bufferMiles}
+	var routeMeters float64
+	if err := database.ReadDB(ctx).QueryRowContext(ctx,
+		`SELECT ST_Length(ST_SetSRID(ST_GeomFromGeoJSON($1), 4326)::geography)`, route,
+	).Scan(&routeMeters); err != nil {
+		return nil, fmt.Errorf("failed to measure route: %w", err)
+	}
+	result.RouteMiles = routeMeters / metersPerMile
+
+	bufferMeters := bufferMiles * metersPerMile
+	if include[models.CorridorZipCodes] {
+		if result.ZipCodes, err = corridorZipCodes(ctx, route, bufferMeters, limit); err != nil {
+			return nil, err
+		}
+	}
+	if include[models.CorridorAddresses] {
+		if result.Addresses, err = corridorAddresses(ctx, route, bufferMeters, limit); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// corridorZipCodes finds the ZIP codes whose centers are within bufferMeters
+// of the route. The latitude range narrows them through the location index.
+func corridorZipCodes(ctx context.Context, route string, bufferMeters float64, limit int) ([]models.CorridorZipCode, error) {
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, corridorRoute+`
+		SELECT z.zip_code, z.city_name, z.state_code, z.state_name, z.zcta, z.zcta_parent,
+			   z.population, z.density, z.primary_county_code, z.primary_county_name,
+			   z.county_weights, z.county_names, z.county_codes, z.imprecise, z.military,
+			   z.timezone, z.latitude, z.longitude,
+			   ST_Distance(route.line::geography, p.pt::geography) AS distance,
+			   ST_LineLocatePoint(route.line, p.pt) * route.length AS along
+		FROM route, zip_codes z
+		CROSS JOIN LATERAL (SELECT ST_SetSRID(ST_MakePoint(z.longitude, z.latitude), 4326) AS pt) AS p
+		WHERE z.latitude BETWEEN ST_YMin(route.line) - route.reach AND ST_YMax(route.line) + route.reach
+		  AND z.longitude BETWEEN ST_XMin(route.line) - route.reach AND ST_XMax(route.line) + route.reach
+		  AND ST_DWithin(route.line::geography, p.pt::geography, $2)
+		ORDER BY along, distance, z.zip_code
+		LIMIT $3
+	`, route, bufferMeters, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ZIP codes along route: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.CorridorZipCode{}
+	for rows.Next() {
+		zc := &models.ZipCode{}
+		var distance, along float64
+		err := rows.Scan(
+			&zc.ZipCode, &zc.CityName, &zc.StateCode, &zc.StateName, &zc.ZCTA, &zc.ZCTAParent,
+			&zc.Population, &zc.Density, &zc.PrimaryCountyCode, &zc.PrimaryCountyName,
+			&zc.CountyWeights, &zc.CountyNames, &zc.CountyCodes, &zc.Imprecise, &zc.Military,
+			&zc.Timezone, &zc.Latitude, &zc.Longitude, &distance, &along,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ZIP code: %w", err)
+		}
+		results = append(results, models.CorridorZipCode{
+			ZipCode:         zc,
+			DistanceMiles:   distance / metersPerMile,
+			AlongRouteMiles: along / metersPerMile,
+		})
+	}
+	return results, rows.Err()
+}
+
+// corridorAddresses finds the address points within bufferMeters of the
+// route. The planar ST_DWithin on reach uses the GIST index on geom before
+// the exact geography test.
+func corridorAddresses(ctx context.Context, route string, bufferMeters float64, limit int) ([]models.CorridorAddress, error) {
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, corridorRoute+`
+		SELECT a.id, a.hash, a.house_number, a.street, COALESCE(a.unit, ''), COALESCE(a.city, ''),
+			COALESCE(a.district, ''), COALESCE(a.region, ''), COALESCE(a.postcode, ''), COALESCE(a.county, ''),
+			COALESCE(a.full_address, ''), ST_Y(a.geom), ST_X(a.geom), a.created_at,
+			ST_Distance(route.line::geography, a.geom::geography) AS distance,
+			ST_LineLocatePoint(route.line, a.geom) * route.length AS along
+		FROM route, ohio_addresses a
+		WHERE ST_DWithin(a.geom, route.line, route.reach)
+		  AND ST_DWithin(route.line::geography, a.geom::geography, $2)
+		ORDER BY along, distance, a.id
+		LIMIT $3
+	`, route, bufferMeters, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query addresses along route: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.CorridorAddress{}
+	for rows.Next() {
+		var addr models.OhioAddress
+		var distance, along float64
+		if err := rows.Scan(
+			&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
+			&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
+			&addr.Latitude, &addr.Longitude, &addr.CreatedAt, &distance, &along,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan address row: %w", err)
+		}
+		results = append(results, models.CorridorAddress{
+			Address:         addr,
+			DistanceMiles:   distance / metersPerMile,
+			AlongRouteMiles: along / metersPerMile,
+		})
+	}
+	return results, rows.Err()
+}
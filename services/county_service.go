@@ -1,28 +1,64 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"geocoding-api/database"
 	"geocoding-api/models"
+	"geocoding-api/utils"
+
+	"github.com/lib/pq"
 )
 
 type CountyService struct {
 	db *sql.DB
 }
 
+// countyListFields are the columns scanned by scanCountyListRow
+const countyListFields = `id, county_name, address_count, COALESCE(county_seat, ''),
+		ST_Y(centroid), ST_X(centroid), land_area_sqm, water_area_sqm`
+
+// scanCountyListRow scans a row selected with countyListFields
+func scanCountyListRow(rows *sql.Rows) (*models.CountyListResponse, error) {
+	var county models.CountyListResponse
+	err := rows.Scan(&county.ID, &county.CountyName, &county.AddressCount, &county.CountySeat,
+		&county.CentroidLat, &county.CentroidLng, &county.LandAreaSqM, &county.WaterAreaSqM)
+	if err != nil {
+		return nil, err
+	}
+	return &county, nil
+}
+
 func NewCountyService() *CountyService {
 	return &CountyService{
 		db: database.DB,
 	}
 }
 
-// GetAllCounties returns a list of all Ohio counties with basic information
-func (cs *CountyService) GetAllCounties(params models.CountySearchParams) ([]models.CountyListResponse, error) {
+// reader returns the connection for county lookups: the read replica when
+// the service runs on the primary and a replica is reachable, otherwise the
+// service's own connection
+func (cs *CountyService) reader(ctx context.Context) *sql.DB {
+	if cs.db != database.DB {
+		return cs.db
+	}
+	return database.ReadDB(ctx)
+}
+
+// GetAllCounties returns a page of Ohio counties with basic information and
+// the total number of counties matching the filters
+func (cs *CountyService) GetAllCounties(ctx context.Context, params models.CountySearchParams) ([]models.CountyListResponse, int, error) {
 	query := `
-		SELECT id, county_name, address_count 
+		SELECT ` + countyListFields + `
 		FROM ohio_counties 
 		WHERE 1=1
 	`
@@ -50,11 +86,29 @@ func (cs *CountyService) GetAllCounties(params models.CountySearchParams) ([]mod
 		argIndex++
 	}
 
+	// The && prefilter narrows to counties whose extent overlaps the box
+	// using the boundary's GIST index before the exact intersection test
+	if params.BBox != nil {
+		envelope := bboxEnvelope(argIndex)
+		conditions = append(conditions, fmt.Sprintf("bounds_geometry && %s AND ST_Intersects(bounds_geometry, %s)", envelope, envelope))
+		args = append(args, bboxArgs(params.BBox)...)
+		argIndex += 4
+	}
+
 	// Add conditions to query
 	if len(conditions) > 0 {
 		query += " AND " + strings.Join(conditions, " AND ")
 	}
 
+	countQuery := "SELECT COUNT(*) FROM ohio_counties WHERE 1=1"
+	if len(conditions) > 0 {
+		countQuery += " AND " + strings.Join(conditions, " AND ")
+	}
+	var total int
+	if err := cs.reader(ctx).QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count counties: %w", err)
+	}
+
 	// Add ordering
 	query += " ORDER BY address_count DESC, county_name ASC"
 
@@ -70,30 +124,31 @@ func (cs *CountyService) GetAllCounties(params models.CountySearchParams) ([]mod
 		args = append(args, params.Offset)
 	}
 
-	rows, err := cs.db.Query(query, args...)
+	rows, err := cs.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query counties: %w", err)
+		return nil, 0, fmt.Errorf("failed to query counties: %w", err)
 	}
 	defer rows.Close()
 
 	var counties []models.CountyListResponse
 	for rows.Next() {
-		var county models.CountyListResponse
-		err := rows.Scan(&county.ID, &county.CountyName, &county.AddressCount)
+		county, err := scanCountyListRow(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan county: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan county: %w", err)
 		}
-		counties = append(counties, county)
+		counties = append(counties, *county)
 	}
 
-	return counties, nil
+	return counties, total, nil
 }
 
 // GetCountyByName returns detailed information about a specific county
-func (cs *CountyService) GetCountyByName(name string) (*models.OhioCounty, error) {
+func (cs *CountyService) GetCountyByName(ctx context.Context, name string) (*models.OhioCounty, error) {
 	query := `
 		SELECT id, county_name, source_name, layer, address_count, stats, 
-			   ST_AsText(bounds_geometry) as bounds_wkt, created_at, updated_at
+			   ST_AsText(bounds_geometry) as bounds_wkt, COALESCE(county_seat, ''),
+			   ST_Y(centroid), ST_X(centroid), land_area_sqm, water_area_sqm,
+			   created_at, updated_at
 		FROM ohio_counties 
 		WHERE LOWER(county_name) = LOWER($1)
 	`
@@ -101,9 +156,10 @@ func (cs *CountyService) GetCountyByName(name string) (*models.OhioCounty, error
 	var county models.OhioCounty
 	var statsJSON sql.NullString
 
-	err := cs.db.QueryRow(query, name).Scan(
+	err := cs.reader(ctx).QueryRowContext(ctx, query, name).Scan(
 		&county.ID, &county.CountyName, &county.SourceName, &county.Layer,
-		&county.AddressCount, &statsJSON, &county.BoundsGeometry,
+		&county.AddressCount, &statsJSON, &county.BoundsGeometry, &county.CountySeat,
+		&county.CentroidLat, &county.CentroidLng, &county.LandAreaSqM, &county.WaterAreaSqM,
 		&county.CreatedAt, &county.UpdatedAt,
 	)
 
@@ -124,11 +180,22 @@ func (cs *CountyService) GetCountyByName(name string) (*models.OhioCounty, error
 	return &county, nil
 }
 
-// GetCountyBoundaryGeoJSON returns the county boundary in GeoJSON format
-func (cs *CountyService) GetCountyBoundaryGeoJSON(name string) (*models.CountyBoundaryGeoJSON, error) {
+// GetCountyBoundaryGeoJSON returns the county boundary in GeoJSON format. A
+// resolution from BoundaryResolutions returns that pre-computed outline;
+// otherwise a positive tolerance, in degrees, simplifies the boundary with
+// ST_SimplifyPreserveTopology.
+func (cs *CountyService) GetCountyBoundaryGeoJSON(ctx context.Context, name string, tolerance float64, resolution string) (*models.CountyBoundaryGeoJSON, error) {
+	key := fmt.Sprintf("%s|%g|%s", strings.ToLower(name), tolerance, resolution)
+	return lookupCaches.county.GetOrLoad(key, func() (*models.CountyBoundaryGeoJSON, error) {
+		return cs.queryCountyBoundaryGeoJSON(ctx, name, tolerance, resolution)
+	})
+}
+
+// queryCountyBoundaryGeoJSON reads a county boundary from the database
+func (cs *CountyService) queryCountyBoundaryGeoJSON(ctx context.Context, name string, tolerance float64, resolution string) (*models.CountyBoundaryGeoJSON, error) {
 	query := `
 		SELECT county_name, source_name, layer, address_count, stats,
-			   ST_AsGeoJSON(bounds_geometry) as bounds_geojson
+			   ST_AsGeoJSON(` + boundaryGeometry("bounds_geometry", resolution, "$2") + `) as bounds_geojson
 		FROM ohio_counties 
 		WHERE LOWER(county_name) = LOWER($1)
 	`
@@ -137,7 +204,7 @@ func (cs *CountyService) GetCountyBoundaryGeoJSON(name string) (*models.CountyBo
 	var addressCount int
 	var statsJSON sql.NullString
 
-	err := cs.db.QueryRow(query, name).Scan(
+	err := cs.reader(ctx).QueryRowContext(ctx, query, name, tolerance).Scan(
 		&countyName, &sourceName, &layer, &addressCount, &statsJSON, &boundsGeoJSON,
 	)
 
@@ -148,8 +215,12 @@ func (cs *CountyService) GetCountyBoundaryGeoJSON(name string) (*models.CountyBo
 		return nil, fmt.Errorf("failed to query county boundary: %w", err)
 	}
 
-	// Parse the geometry from PostGIS GeoJSON output
 	// PostGIS ST_AsGeoJSON returns just the geometry part, we need to wrap it in a Feature
+	var geometry models.CountyGeometryGeoJSON
+	if err := json.Unmarshal([]byte(boundsGeoJSON), &geometry); err != nil {
+		return nil, fmt.Errorf("failed to parse county boundary: %w", err)
+	}
+
 	geoJSON := &models.CountyBoundaryGeoJSON{
 		Type: "FeatureCollection",
 		Features: []models.CountyFeatureGeoJSON{
@@ -162,12 +233,7 @@ func (cs *CountyService) GetCountyBoundaryGeoJSON(name string) (*models.CountyBo
 					AddressCount: addressCount,
 					Stats:        make(map[string]interface{}),
 				},
-				Geometry: models.CountyGeometryGeoJSON{
-					Type: "Polygon",
-					// Note: In a real implementation, you'd parse the boundsGeoJSON properly
-					// For now, we'll return an empty coordinates array
-					Coordinates: [][][]float64{},
-				},
+				Geometry: geometry,
 			},
 		},
 	}
@@ -175,8 +241,72 @@ func (cs *CountyService) GetCountyBoundaryGeoJSON(name string) (*models.CountyBo
 	return geoJSON, nil
 }
 
+// BoundaryResolutions are the pre-computed boundary outlines, coarsest first.
+// Their tolerances are stored in the boundary_resolutions table.
+var BoundaryResolutions = []string{"low", "medium", "high"}
+
+// boundaryGeometry selects a boundary column's pre-computed resolution, or
+// the full geometry simplified by the tolerance parameter when resolution
+// is empty. Resolutions are stored as <column>_<resolution>; rows not yet
+// simplified fall back to the full geometry.
+func boundaryGeometry(column, resolution, tolerance string) string {
+	if slices.Contains(BoundaryResolutions, resolution) {
+		return fmt.Sprintf("COALESCE(%[1]s_%[2]s, %[1]s)", column, resolution)
+	}
+	return simplifiedGeometry(column, tolerance)
+}
+
+// simplifiedGeometry wraps a geometry column in ST_SimplifyPreserveTopology
+// when the tolerance parameter is positive
+func simplifiedGeometry(column, tolerance string) string {
+	return fmt.Sprintf("CASE WHEN %[2]s::float8 > 0 THEN ST_SimplifyPreserveTopology(%[1]s, %[2]s::float8) ELSE %[1]s END",
+		column, tolerance)
+}
+
+// GetCountyCentroid returns the centroid match for a county name, tolerating a
+// trailing "County" and state suffix ("Hamilton County, OH")
+func (cs *CountyService) GetCountyCentroid(ctx context.Context, name string) (*models.CountyCentroidMatch, error) {
+	name = normalizeCountyName(name)
+	if name == "" {
+		return nil, fmt.Errorf("county not found: %s", name)
+	}
+
+	match := &models.CountyCentroidMatch{MatchType: models.MatchTypeCountyCentroid}
+	var updatedAt sql.NullTime
+	err := cs.reader(ctx).QueryRowContext(ctx, `
+		SELECT county_name, COALESCE(county_seat, ''), ST_Y(centroid), ST_X(centroid), updated_at
+		FROM ohio_counties
+		WHERE LOWER(county_name) = LOWER($1) AND centroid IS NOT NULL
+	`, name).Scan(&match.CountyName, &match.CountySeat, &match.Latitude, &match.Longitude, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("county not found: %s", name)
+		}
+		return nil, fmt.Errorf("failed to query county centroid: %w", err)
+	}
+
+	var vintage *time.Time
+	if updatedAt.Valid {
+		vintage = &updatedAt.Time
+	}
+	match.Match = countyCentroidMatch(vintage)
+	return match, nil
+}
+
+// normalizeCountyName strips "County", state suffixes and punctuation from a county query
+func normalizeCountyName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimSuffix(name, ", ohio")
+	name = strings.TrimSuffix(name, ", oh")
+	name = strings.TrimSuffix(name, " ohio")
+	name = strings.TrimSuffix(name, " oh")
+	name = strings.TrimSuffix(strings.TrimSpace(name), ",")
+	name = strings.TrimSuffix(strings.TrimSpace(name), " county")
+	return strings.TrimSpace(name)
+}
+
 // GetCountyStats returns summary statistics about all counties
-func (cs *CountyService) GetCountyStats() (map[string]interface{}, error) {
+func (cs *CountyService) GetCountyStats(ctx context.Context) (map[string]interface{}, error) {
 	query := `
 		SELECT 
 			COUNT(*) as total_counties,
@@ -190,7 +320,7 @@ func (cs *CountyService) GetCountyStats() (map[string]interface{}, error) {
 	var totalCounties, totalAddresses, maxAddresses, minAddresses int
 	var avgAddresses float64
 
-	err := cs.db.QueryRow(query).Scan(
+	err := cs.reader(ctx).QueryRowContext(ctx, query).Scan(
 		&totalCounties, &totalAddresses, &avgAddresses, &maxAddresses, &minAddresses,
 	)
 
@@ -210,9 +340,9 @@ func (cs *CountyService) GetCountyStats() (map[string]interface{}, error) {
 }
 
 // GetCountiesWithinBounds returns counties that intersect with the given bounding box
-func (cs *CountyService) GetCountiesWithinBounds(minLat, minLon, maxLat, maxLon float64) ([]models.CountyListResponse, error) {
+func (cs *CountyService) GetCountiesWithinBounds(ctx context.Context, minLat, minLon, maxLat, maxLon float64) ([]models.CountyListResponse, error) {
 	query := `
-		SELECT id, county_name, address_count 
+		SELECT ` + countyListFields + `
 		FROM ohio_counties 
 		WHERE ST_Intersects(
 			bounds_geometry, 
@@ -221,7 +351,7 @@ func (cs *CountyService) GetCountiesWithinBounds(minLat, minLon, maxLat, maxLon
 		ORDER BY address_count DESC
 	`
 
-	rows, err := cs.db.Query(query, minLon, minLat, maxLon, maxLat)
+	rows, err := cs.reader(ctx).QueryContext(ctx, query, minLon, minLat, maxLon, maxLat)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query counties within bounds: %w", err)
 	}
@@ -229,20 +359,176 @@ func (cs *CountyService) GetCountiesWithinBounds(minLat, minLon, maxLat, maxLon
 
 	var counties []models.CountyListResponse
 	for rows.Next() {
-		var county models.CountyListResponse
-		err := rows.Scan(&county.ID, &county.CountyName, &county.AddressCount)
+		county, err := scanCountyListRow(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan county: %w", err)
 		}
-		counties = append(counties, county)
+		counties = append(counties, *county)
 	}
 
 	return counties, nil
 }
 
+// ContainsPointsBatch assigns each point to the county containing it using a
+// single spatial join. Points are passed as parallel arrays and unnested with
+// their ordinal, which keeps the query parameterized regardless of batch size.
+func (cs *CountyService) ContainsPointsBatch(ctx context.Context, points []models.CountyContainsPoint) ([]models.CountyContainsResult, error) {
+	lats := make([]float64, len(points))
+	lngs := make([]float64, len(points))
+	for i, p := range points {
+		lats[i] = p.Lat
+		lngs[i] = p.Lng
+	}
+
+	rows, err := cs.reader(ctx).QueryContext(ctx, `
+		SELECT p.idx, c.county_name
+		FROM unnest($1::float8[], $2::float8[]) WITH ORDINALITY AS p(lat, lng, idx)
+		LEFT JOIN LATERAL (
+			SELECT county_name
+			FROM ohio_counties
+			WHERE ST_Contains(bounds_geometry, ST_SetSRID(ST_MakePoint(p.lng, p.lat), 4326))
+			LIMIT 1
+		) c ON true
+		ORDER BY p.idx
+	`, pq.Array(lats), pq.Array(lngs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query counties for points: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.CountyContainsResult, len(points))
+	for i, p := range points {
+		results[i] = models.CountyContainsResult{Index: i, ID: p.ID, Lat: p.Lat, Lng: p.Lng}
+	}
+	for rows.Next() {
+		var idx int
+		var countyName sql.NullString
+		if err := rows.Scan(&idx, &countyName); err != nil {
+			return nil, fmt.Errorf("failed to scan county point: %w", err)
+		}
+		// WITH ORDINALITY is 1-based
+		if idx >= 1 && idx <= len(results) && countyName.Valid {
+			name := countyName.String
+			results[idx-1].CountyName = &name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read county points: %w", err)
+	}
+
+	return results, nil
+}
+
 // Global county service instance
 var County *CountyService
 
 func init() {
 	County = NewCountyService()
-}
\ No newline at end of file
+}
+
+// countyMetaPattern matches the county address meta files, whose bounds are
+// used as each county's boundary polygon
+const countyMetaPattern = "oh/*-addresses-county.geojson.meta"
+
+// loadCountyBoundaries upserts a boundary polygon for every county address
+// meta file, downloading the Ohio data first if none are present
+func loadCountyBoundaries(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	files, err := filepath.Glob(countyMetaPattern)
+	if err != nil {
+		return fmt.Errorf("failed to find GeoJSON meta files: %w", err)
+	}
+	if len(files) == 0 {
+		slog.Info("no county meta files found, downloading Ohio data")
+		if err := utils.NewFileDownloader("./cache").DownloadOhioData("."); err != nil {
+			return fmt.Errorf("failed to download Ohio data: %w", err)
+		}
+		if files, err = filepath.Glob(countyMetaPattern); err != nil {
+			return fmt.Errorf("failed to find GeoJSON meta files: %w", err)
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no county meta files found (looked for %s)", countyMetaPattern)
+	}
+	run.setSource(filepath.Dir(countyMetaPattern))
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ohio_counties (county_name, source_name, layer, address_count, stats, bounds_geometry)
+		VALUES ($1, $2, $3, $4, $5, ST_SetSRID(ST_GeomFromText($6), 4326))
+		ON CONFLICT (county_name) DO UPDATE SET
+			source_name = EXCLUDED.source_name,
+			layer = EXCLUDED.layer,
+			address_count = EXCLUDED.address_count,
+			stats = EXCLUDED.stats,
+			bounds_geometry = EXCLUDED.bounds_geometry,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, filePath := range files {
+		// Extract county name from filename
+		countyName := strings.TrimSuffix(filepath.Base(filePath), "-addresses-county.geojson.meta")
+		countyName = strings.ReplaceAll(countyName, "_", " ")
+		countyName = strings.ReplaceAll(countyName, "-", " ")
+		countyName = strings.Title(strings.ToLower(strings.TrimSpace(countyName)))
+
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+
+		var metaData struct {
+			SourceName string                 `json:"source_name"`
+			Layer      string                 `json:"layer"`
+			Count      int                    `json:"count"`
+			Stats      map[string]interface{} `json:"stats"`
+			Bounds     struct {
+				Type        string        `json:"type"`
+				Coordinates [][][]float64 `json:"coordinates"`
+			} `json:"bounds"`
+		}
+		if err := json.Unmarshal(data, &metaData); err != nil {
+			slog.Warn("skipping county meta file with invalid JSON", "file", filePath, "error", err)
+			run.record(rowInvalid)
+			continue
+		}
+
+		// Skip if not a valid polygon
+		if metaData.Bounds.Type != "Polygon" || len(metaData.Bounds.Coordinates) == 0 {
+			slog.Warn("skipping county with invalid polygon bounds", "file", filePath)
+			run.record(rowInvalid)
+			continue
+		}
+
+		// Convert the first ring of the polygon to WKT for PostGIS
+		var wktCoords []string
+		for _, coord := range metaData.Bounds.Coordinates[0] {
+			if len(coord) >= 2 {
+				wktCoords = append(wktCoords, fmt.Sprintf("%f %f", coord[0], coord[1]))
+			}
+		}
+		if len(wktCoords) < 4 {
+			slog.Warn("skipping county with invalid polygon coordinates", "file", filePath)
+			run.record(rowInvalid)
+			continue
+		}
+		polygonWKT := fmt.Sprintf("POLYGON((%s))", strings.Join(wktCoords, ", "))
+
+		statsJSON, err := json.Marshal(metaData.Stats)
+		if err != nil {
+			slog.Warn("failed to marshal county stats", "file", filePath, "error", err)
+			statsJSON = []byte("{}")
+		}
+
+		outcome, err := upsertRow(ctx, stmt, countyName, metaData.SourceName, metaData.Layer,
+			metaData.Count, string(statsJSON), polygonWKT)
+		if err != nil {
+			return fmt.Errorf("failed to insert county %s: %w", countyName, err)
+		}
+		run.record(outcome)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"geocoding-api/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCountyBoundariesSetsDerivedFields(t *testing.T) {
+	if err := database.InitDB(); err != nil {
+		t.Skipf("database not available: %v", err)
+	}
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "oh"), 0o755))
+	meta := `{"source_name": "test", "layer": "addresses", "count": 3,
+		"bounds": {"type": "Polygon", "coordinates": [[[-83.2, 39.8], [-82.8, 39.8], [-82.8, 40.2], [-83.2, 40.2], [-83.2, 39.8]]]}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "oh", "franklin-addresses-county.geojson.meta"), []byte(meta), 0o644))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	// Load into an empty table, as on a fresh install, and roll back after
+	ctx := context.Background()
+	tx, err := database.DB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `DELETE FROM ohio_counties`)
+	require.NoError(t, err)
+
+	require.NoError(t, loadCountyBoundaries(ctx, tx, &loadRun{}))
+
+	var lat, lng, area sql.NullFloat64
+	var seat sql.NullString
+	require.NoError(t, tx.QueryRowContext(ctx, `
+		SELECT ST_Y(centroid), ST_X(centroid), land_area_sqm, county_seat
+		FROM ohio_counties WHERE county_name = 'Franklin'
+	`).Scan(&lat, &lng, &area, &seat))
+	require.True(t, lat.Valid && lng.Valid, "centroid is set on load")
+	assert.InDelta(t, 40.0, lat.Float64, 1e-6)
+	assert.InDelta(t, -83.0, lng.Float64, 1e-6)
+	assert.Greater(t, area.Float64, 0.0)
+	assert.Equal(t, "Columbus", seat.String)
+
+	// Replacing the boundary moves the centroid
+	_, err = tx.ExecContext(ctx, `
+		UPDATE ohio_counties SET bounds_geometry = ST_Translate(bounds_geometry, 1, 0)
+		WHERE county_name = 'Franklin'
+	`)
+	require.NoError(t, err)
+	require.NoError(t, tx.QueryRowContext(ctx,
+		`SELECT ST_X(centroid) FROM ohio_counties WHERE county_name = 'Franklin'`,
+	).Scan(&lng))
+	assert.InDelta(t, -82.0, lng.Float64, 1e-6)
+}
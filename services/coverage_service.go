@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+const (
+	// MaxCoverageMinutes is the longest drive a coverage area may cover
+	MaxCoverageMinutes = 120
+	// coverageCircuity is how much longer a road trip is than the straight
+	// line between its ends; typical US road networks are 1.2-1.4
+	coverageCircuity = 1.3
+	// coverageMaxSpeedMPH bounds how far a routing engine could reach, so
+	// only ZIP codes within that distance are sent to it
+	coverageMaxSpeedMPH = 70
+)
+
+// ErrCoverageCenterNotFound is returned when the center ZIP code is unknown
+var ErrCoverageCenterNotFound = errors.New("center ZIP code not found")
+
+// GetCoverageArea returns the ZIP codes and counties reachable by car from
+// centerZip within minutes. Drive times come from the routing engine when
+// one is configured and are otherwise estimated at COVERAGE_SPEED_MPH.
+func GetCoverageArea(ctx context.Context, centerZip string, minutes int) (*models.CoverageArea, error) {
+	center, err := GetZipCodeByZip(ctx, centerZip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get center ZIP code: %w", err)
+	}
+	if center == nil {
+		return nil, ErrCoverageCenterNotFound
+	}
+
+	cfg := config.Get().Routing
+	area := &models.CoverageArea{
+		Center:   centerZip,
+		Minutes:  minutes,
+		ZipCodes: []models.CoverageZipCode{},
+		Counties: []models.CoverageCounty{},
+	}
+	if cfg.Engine != "" {
+		area.Method = models.CoverageRouting
+		area.RadiusMiles = float64(minutes) / 60 * coverageMaxSpeedMPH
+	} else {
+		area.Method = models.CoverageSpeedModel
+		area.SpeedMPH = cfg.CoverageSpeedMPH
+		area.RadiusMiles = float64(minutes) / 60 * float64(cfg.CoverageSpeedMPH) / coverageCircuity
+	}
+
+	candidates, err := zipCodesWithinMiles(ctx, center, area.RadiusMiles)
+	if err != nil {
+		return nil, err
+	}
+
+	driveMinutes := make([]float64, len(candidates))
+	if area.Method == models.CoverageRouting {
+		destinations := make([][2]float64, len(candidates))
+		for i, zc := range candidates {
+			destinations[i] = [2]float64{zc.zip.Latitude, zc.zip.Longitude}
+		}
+		durations, err := Routing.DriveDurations(ctx, center.Latitude, center.Longitude, destinations)
+		if err != nil {
+			return nil, err
+		}
+		for i, seconds := range durations {
+			driveMinutes[i] = -1
+			if seconds >= 0 {
+				driveMinutes[i] = seconds / 60
+			}
+		}
+	} else {
+		for i, zc := range candidates {
+			driveMinutes[i] = zc.distance * coverageCircuity / float64(cfg.CoverageSpeedMPH) * 60
+		}
+	}
+
+	var reached []*coverageCandidate
+	for i, zc := range candidates {
+		if driveMinutes[i] < 0 || driveMinutes[i] > float64(minutes) {
+			continue
+		}
+		area.ZipCodes = append(area.ZipCodes, models.CoverageZipCode{
+			ZipCode:       zc.zip.ZipCode,
+			CityName:      zc.zip.CityName,
+			StateCode:     zc.zip.StateCode,
+			DistanceMiles: math.Round(zc.distance*100) / 100,
+			DriveMinutes:  math.Round(driveMinutes[i]*10) / 10,
+		})
+		reached = append(reached, zc)
+	}
+	sort.SliceStable(area.ZipCodes, func(i, j int) bool {
+		return area.ZipCodes[i].DriveMinutes < area.ZipCodes[j].DriveMinutes
+	})
+	area.Counties = coverageCounties(reached)
+
+	return area, nil
+}
+
+// coverageCandidate is a ZIP code with its straight-line distance from the center
+type coverageCandidate struct {
+	zip      *models.ZipCode
+	distance float64
+}
+
+// zipCodesWithinMiles returns the ZIP codes, including center, whose
+// centers are within radiusMiles of center, nearest first
+func zipCodesWithinMiles(ctx context.Context, center *models.ZipCode, radiusMiles float64) ([]*coverageCandidate, error) {
+	latDelta := radiusMiles / 69.0
+	lngDelta := radiusMiles / (69.0 * math.Cos(center.Latitude*math.Pi/180.0))
+
+	query := `
+		SELECT zip_code, city_name, state_code, primary_county_code, primary_county_name,
+			   county_names, county_codes, latitude, longitude
+		FROM zip_codes
+		WHERE latitude BETWEEN $1 AND $2
+		  AND longitude BETWEEN $3 AND $4
+	`
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query,
+		center.Latitude-latDelta, center.Latitude+latDelta,
+		center.Longitude-lngDelta, center.Longitude+lngDelta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ZIP codes: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*coverageCandidate
+	for rows.Next() {
+		zc := &models.ZipCode{}
+		if err := rows.Scan(
+			&zc.ZipCode, &zc.CityName, &zc.StateCode, &zc.PrimaryCountyCode, &zc.PrimaryCountyName,
+			&zc.CountyNames, &zc.CountyCodes, &zc.Latitude, &zc.Longitude,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ZIP code: %w", err)
+		}
+
+		distance := haversineDistance(center.Latitude, center.Longitude, zc.Latitude, zc.Longitude)
+		if distance <= radiusMiles {
+			candidates = append(candidates, &coverageCandidate{zip: zc, distance: distance})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ZIP codes: %w", err)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	return candidates, nil
+}
+
+// coverageCounties lists every county the ZIP codes fall in, by how many
+// of the ZIP codes it contains. A ZIP code spanning counties counts for each.
+func coverageCounties(zipCodes []*coverageCandidate) []models.CoverageCounty {
+	counties := []models.CoverageCounty{}
+	index := make(map[string]int)
+	add := func(name, code, stateCode string) {
+		if name == "" {
+			return
+		}
+		key := stateCode + "|" + name
+		if i, ok := index[key]; ok {
+			counties[i].ZipCount++
+			return
+		}
+		index[key] = len(counties)
+		counties = append(counties, models.CoverageCounty{Name: name, Code: code, StateCode: stateCode, ZipCount: 1})
+	}
+
+	for _, candidate := range zipCodes {
+		zc := candidate.zip
+		if len(zc.CountyNames) == 0 {
+			add(zc.PrimaryCountyName, zc.PrimaryCountyCode, zc.StateCode)
+			continue
+		}
+		for i, name := range zc.CountyNames {
+			code := ""
+			if i < len(zc.CountyCodes) {
+				code = zc.CountyCodes[i]
+			}
+			add(name, code, zc.StateCode)
+		}
+	}
+
+	sort.SliceStable(counties, func(i, j int) bool { return counties[i].ZipCount > counties[j].ZipCount })
+	return counties
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageCounties(t *testing.T) {
+	zipCodes := []*coverageCandidate{
+		{zip: &models.ZipCode{StateCode: "OH", CountyNames: models.StringArray{"Hamilton"}, CountyCodes: models.StringArray{"39061"}}},
+		{zip: &models.ZipCode{StateCode: "OH", CountyNames: models.StringArray{"Hamilton", "Butler"}, CountyCodes: models.StringArray{"39061", "39017"}}},
+		{zip: &models.ZipCode{StateCode: "KY", PrimaryCountyName: "Kenton", PrimaryCountyCode: "21117"}},
+		{zip: &models.ZipCode{StateCode: "OH"}},
+	}
+
+	assert.Equal(t, []models.CoverageCounty{
+		{Name: "Hamilton", Code: "39061", StateCode: "OH", ZipCount: 2},
+		{Name: "Butler", Code: "39017", StateCode: "OH", ZipCount: 1},
+		{Name: "Kenton", Code: "21117", StateCode: "KY", ZipCount: 1},
+	}, coverageCounties(zipCodes))
+
+	assert.Empty(t, coverageCounties(nil))
+}
+
+func TestCountyDataCoverage(t *testing.T) {
+	imported := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	loaded := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	published := time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)
+	datasetID := 12
+
+	tracked := countyDataCoverage(dataCoverageRow{
+		state: "OH", county: "Franklin", addressCount: 500, datasetID: &datasetID, datasetName: "Franklin County OpenAddresses",
+		datasetImportedAt: &imported, sourceURL: "https://data.openaddresses.io/franklin.zip", sourceChangedAt: &published,
+	})
+	assert.Equal(t, models.DataSourceOpenAddresses, tracked.Source)
+	assert.Equal(t, &published, tracked.DataVintage)
+	assert.Equal(t, &imported, tracked.LastImportedAt)
+
+	uploaded := countyDataCoverage(dataCoverageRow{state: "OH", county: "Hamilton", datasetID: &datasetID, datasetImportedAt: &imported})
+	assert.Equal(t, models.DataSourceUpload, uploaded.Source)
+	assert.Equal(t, &imported, uploaded.DataVintage)
+
+	bundled := countyDataCoverage(dataCoverageRow{state: "OH", county: "Adams", lastLoadedAt: &loaded, bundledSource: "LBRS"})
+	assert.Equal(t, models.DataSourceBundled, bundled.Source)
+	assert.Equal(t, "LBRS", bundled.SourceName)
+	assert.Equal(t, &loaded, bundled.LastImportedAt)
+	assert.Equal(t, &loaded, bundled.DataVintage)
+}
+
+func TestSummarizeDataCoverage(t *testing.T) {
+	coverage := summarizeDataCoverage([]models.CountyDataCoverage{
+		{State: "KY", County: "Boone", AddressCount: 10},
+		{State: "OH", County: "Adams", AddressCount: 5},
+		{State: "OH", County: "Butler", AddressCount: 7},
+	})
+	assert.Equal(t, 22, coverage.AddressCount)
+	assert.Equal(t, 3, coverage.CountyCount)
+	assert.Equal(t, []string{"KY", "OH"}, coverage.States)
+
+	empty := summarizeDataCoverage(nil)
+	assert.NotNil(t, empty.Counties)
+	assert.Empty(t, empty.States)
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// MaxCrosswalkZipCodes caps the ZIP codes one crosswalk request can map
+const MaxCrosswalkZipCodes = 100
+
+// zipCBSAFiles are the names the HUD ZIP_CBSA workbook is looked for under
+// once exported to CSV
+var zipCBSAFiles = []string{"ZIP_CBSA.csv", "zip_cbsa.csv"}
+
+// CrosswalkService maps ZIP codes to counties and CBSAs
+type CrosswalkService struct{}
+
+var Crosswalk = &CrosswalkService{}
+
+// zipCountyMappings turns a ZIP code's county weights, stored as population
+// percentages, into mappings ordered by descending weight. A ZIP code without
+// weights maps entirely to its primary county.
+func zipCountyMappings(zc *models.ZipCode) []models.ZipCountyMapping {
+	names := make(map[string]string, len(zc.CountyCodes))
+	for i, code := range zc.CountyCodes {
+		if i < len(zc.CountyNames) {
+			names[code] = zc.CountyNames[i]
+		}
+	}
+	names[zc.PrimaryCountyCode] = zc.PrimaryCountyName
+
+	mappings := make([]models.ZipCountyMapping, 0, len(zc.CountyWeights))
+	for code, percent := range zc.CountyWeights {
+		weight, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			continue
+		}
+		mappings = append(mappings, models.ZipCountyMapping{
+			ZipCode:    zc.ZipCode,
+			CountyFIPS: code,
+			CountyName: names[code],
+			StateCode:  zc.StateCode,
+			Weight:     weight / 100,
+		})
+	}
+	if len(mappings) == 0 && zc.PrimaryCountyCode != "" {
+		mappings = append(mappings, models.ZipCountyMapping{
+			ZipCode:    zc.ZipCode,
+			CountyFIPS: zc.PrimaryCountyCode,
+			CountyName: zc.PrimaryCountyName,
+			StateCode:  zc.StateCode,
+			Weight:     1,
+		})
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].Weight != mappings[j].Weight {
+			return mappings[i].Weight > mappings[j].Weight
+		}
+		return mappings[i].CountyFIPS < mappings[j].CountyFIPS
+	})
+	return mappings
+}
+
+// ZipToCounty returns the county mappings of each ZIP code, in request
+// order. Unknown ZIP codes have no mappings.
+func (s *CrosswalkService) ZipToCounty(ctx context.Context, zipCodes []string) ([]models.ZipCountyMapping, error) {
+	mappings := []models.ZipCountyMapping{}
+	for _, zipCode := range zipCodes {
+		zc, err := GetZipCodeByZip(ctx, zipCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ZIP code %s: %w", zipCode, err)
+		}
+		if zc != nil {
+			mappings = append(mappings, zipCountyMappings(zc)...)
+		}
+	}
+	return mappings, nil
+}
+
+// CountiesForZip returns the counties a ZIP code overlaps, heaviest first,
+// or nil when the ZIP code is unknown
+func (s *CrosswalkService) CountiesForZip(ctx context.Context, zipCode string) ([]models.ZipCountyMapping, error) {
+	zc, err := GetZipCodeByZip(ctx, zipCode)
+	if err != nil || zc == nil {
+		return nil, err
+	}
+	return zipCountyMappings(zc), nil
+}
+
+// ZipCodesInCounty returns one page of the ZIP codes overlapping a county,
+// by descending share of their population in it, and the total number. A
+// ZIP code without county weights counts only toward its primary county,
+// with all of its weight, as in zipCountyMappings.
+func (s *CrosswalkService) ZipCodesInCounty(ctx context.Context, countyFIPS string, limit, offset int) ([]models.ZipCountyMapping, int, error) {
+	const inCounty = `county_weights ? $1 OR (primary_county_code = $1 AND COALESCE(county_weights, '{}') = '{}')`
+
+	var total int
+	if err := database.ReadDB(ctx).QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM zip_codes WHERE `+inCounty, countyFIPS).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count ZIP codes in county: %w", err)
+	}
+
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, `
+		SELECT zip_code, state_code, primary_county_code, primary_county_name,
+			   county_weights, county_names, county_codes
+		FROM zip_codes
+		WHERE `+inCounty+`
+		ORDER BY COALESCE((county_weights->>$1)::float8, 100) DESC, zip_code
+		LIMIT $2 OFFSET $3
+	`, countyFIPS, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query ZIP codes in county: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []models.ZipCountyMapping{}
+	for rows.Next() {
+		zc := &models.ZipCode{}
+		if err := rows.Scan(&zc.ZipCode, &zc.StateCode, &zc.PrimaryCountyCode, &zc.PrimaryCountyName,
+			&zc.CountyWeights, &zc.CountyNames, &zc.CountyCodes); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan ZIP code: %w", err)
+		}
+		for _, m := range zipCountyMappings(zc) {
+			if m.CountyFIPS == countyFIPS {
+				mappings = append(mappings, m)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read ZIP codes in county: %w", err)
+	}
+	return mappings, total, nil
+}
+
+// ZipToCBSA returns the HUD CBSA mappings of the ZIP codes, ordered by ZIP
+// code and descending share of total addresses
+func (s *CrosswalkService) ZipToCBSA(ctx context.Context, zipCodes []string) ([]models.ZipCBSAMapping, error) {
+	query := `
+		SELECT zip_code, cbsa_code, res_ratio, bus_ratio, oth_ratio, tot_ratio
+		FROM zip_cbsa_crosswalk
+		WHERE zip_code = ANY($1)
+		ORDER BY zip_code, tot_ratio DESC, cbsa_code
+	`
+
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, pq.Array(zipCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ZIP to CBSA crosswalk: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []models.ZipCBSAMapping{}
+	for rows.Next() {
+		var m models.ZipCBSAMapping
+		if err := rows.Scan(&m.ZipCode, &m.CBSACode, &m.ResidentialRatio, &m.BusinessRatio, &m.OtherRatio, &m.TotalRatio); err != nil {
+			return nil, fmt.Errorf("failed to scan ZIP to CBSA mapping: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// padCode restores leading zeros a spreadsheet export dropped from a code
+func padCode(code string, width int) string {
+	if len(code) >= width {
+		return code
+	}
+	return strings.Repeat("0", width-len(code)) + code
+}
+
+// loadZipCBSACrosswalk upserts the rows of the HUD ZIP_CBSA CSV. Columns are
+// found by name, so the file can keep HUD's column order or not.
+func loadZipCBSACrosswalk(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	file, source, err := openDataFile(zipCBSAFiles...)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	run.setSource(source)
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := map[string]int{}
+	for _, name := range []string{"ZIP", "CBSA", "RES_RATIO", "BUS_RATIO", "OTH_RATIO", "TOT_RATIO"} {
+		index := csvColumn(header, "", []string{name})
+		if index < 0 {
+			return fmt.Errorf("%s has no %s column", source, name)
+		}
+		columns[name] = index
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO zip_cbsa_crosswalk (zip_code, cbsa_code, res_ratio, bus_ratio, oth_ratio, tot_ratio)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (zip_code, cbsa_code) DO UPDATE SET
+			res_ratio = EXCLUDED.res_ratio,
+			bus_ratio = EXCLUDED.bus_ratio,
+			oth_ratio = EXCLUDED.oth_ratio,
+			tot_ratio = EXCLUDED.tot_ratio,
+			updated_at = NOW()
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			slog.Warn("failed to read ZIP to CBSA row", "error", err)
+			run.record(rowInvalid)
+			continue
+		}
+
+		field := func(name string) string {
+			if index := columns[name]; index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		}
+		ratios := make([]float64, 0, 4)
+		for _, name := range []string{"RES_RATIO", "BUS_RATIO", "OTH_RATIO", "TOT_RATIO"} {
+			ratio, err := strconv.ParseFloat(field(name), 64)
+			if err != nil {
+				break
+			}
+			ratios = append(ratios, ratio)
+		}
+		zipCode, cbsa := padCode(field("ZIP"), 5), padCode(field("CBSA"), 5)
+		if len(ratios) != 4 || field("ZIP") == "" || field("CBSA") == "" || len(zipCode) != 5 || len(cbsa) != 5 {
+			slog.Warn("skipping invalid ZIP to CBSA row", "row", record)
+			run.record(rowInvalid)
+			continue
+		}
+
+		outcome, err := upsertRow(ctx, stmt, zipCode, cbsa, ratios[0], ratios[1], ratios[2], ratios[3])
+		if err != nil {
+			return fmt.Errorf("failed to insert ZIP %s CBSA %s: %w", zipCode, cbsa, err)
+		}
+		run.record(outcome)
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZipCountyMappings(t *testing.T) {
+	zc := &models.ZipCode{
+		ZipCode:           "61744",
+		StateCode:         "IL",
+		PrimaryCountyCode: "17113",
+		PrimaryCountyName: "McLean",
+		CountyWeights:     models.CountyWeights{"17113": "89.22", "17105": "9.8", "17203": "0.98"},
+		CountyNames:       models.StringArray{"McLean", "Livingston", "Woodford"},
+		CountyCodes:       models.StringArray{"17113", "17105", "17203"},
+	}
+
+	mappings := zipCountyMappings(zc)
+	assert.Len(t, mappings, 3)
+	assert.Equal(t, models.ZipCountyMapping{
+		ZipCode: "61744", CountyFIPS: "17113", CountyName: "McLean", StateCode: "IL", Weight: 0.8922,
+	}, mappings[0])
+	assert.Equal(t, "Livingston", mappings[1].CountyName)
+	assert.InDelta(t, 0.098, mappings[1].Weight, 1e-9)
+	assert.Equal(t, "17203", mappings[2].CountyFIPS)
+
+	t.Run("no weights falls back to the primary county", func(t *testing.T) {
+		mappings := zipCountyMappings(&models.ZipCode{
+			ZipCode: "43215", StateCode: "OH", PrimaryCountyCode: "39049", PrimaryCountyName: "Franklin",
+		})
+		assert.Equal(t, []models.ZipCountyMapping{{
+			ZipCode: "43215", CountyFIPS: "39049", CountyName: "Franklin", StateCode: "OH", Weight: 1,
+		}}, mappings)
+	})
+}
+
+func TestPadCode(t *testing.T) {
+	assert.Equal(t, "01001", padCode("1001", 5))
+	assert.Equal(t, "43215", padCode("43215", 5))
+	assert.Equal(t, "10420", padCode("10420", 5))
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"geocoding-api/models"
+)
+
+// GeoJSONFormatCSV identifies delimited-text uploads alongside the GeoJSON layouts
+const GeoJSONFormatCSV = "CSV"
+
+// featureReader yields address features one at a time from an upload
+type featureReader interface {
+	Format() string
+	Next() (*geoJSONFeature, error)
+}
+
+// isCSVFile reports whether filename is a CSV upload, optionally gzipped
+func isCSVFile(filename string) bool {
+	name := strings.TrimSuffix(strings.ToLower(filename), ".gz")
+	return strings.HasSuffix(name, ".csv")
+}
+
+// newFeatureReader picks the CSV or GeoJSON reader based on filename
+func newFeatureReader(r io.Reader, filename string, mapping models.DatasetFieldMapping) (featureReader, error) {
+	if isCSVFile(filename) {
+		return newCSVFeatureReader(r, mapping)
+	}
+	return newGeoJSONFeatureReader(r)
+}
+
+// Column names tried for coordinates when the field mapping doesn't name them
+var (
+	csvLatitudeColumns  = []string{"latitude", "lat", "y", "point_y"}
+	csvLongitudeColumns = []string{"longitude", "lon", "lng", "long", "x", "point_x"}
+)
+
+// csvFeatureReader turns CSV rows into point features whose properties are
+// the row's columns, so the GeoJSON field mapping applies unchanged
+type csvFeatureReader struct {
+	r         *csv.Reader
+	header    []string
+	latColumn int
+	lngColumn int
+}
+
+// newCSVFeatureReader reads the header row and locates the coordinate columns
+func newCSVFeatureReader(r io.Reader, mapping models.DatasetFieldMapping) (*csvFeatureReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	latColumn := csvColumn(header, mapping["latitude"], csvLatitudeColumns)
+	lngColumn := csvColumn(header, mapping["longitude"], csvLongitudeColumns)
+	if latColumn < 0 || lngColumn < 0 {
+		return nil, fmt.Errorf("CSV needs latitude and longitude columns; name them with field_mapping")
+	}
+
+	return &csvFeatureReader{r: reader, header: header, latColumn: latColumn, lngColumn: lngColumn}, nil
+}
+
+// csvColumn returns the index of the mapped column, or of the first
+// candidate present (case-insensitive) when nothing is mapped
+func csvColumn(header []string, mapped string, candidates []string) int {
+	if mapped != "" {
+		for i, name := range header {
+			if name == mapped {
+				return i
+			}
+		}
+		return -1
+	}
+	for _, candidate := range candidates {
+		for i, name := range header {
+			if strings.EqualFold(name, candidate) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Format reports the upload layout
+func (cr *csvFeatureReader) Format() string {
+	return GeoJSONFormatCSV
+}
+
+// Next returns the next row as a feature, or io.EOF when there are none left.
+// Rows without parseable coordinates come back with no geometry.
+func (cr *csvFeatureReader) Next() (*geoJSONFeature, error) {
+	record, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	feature := &geoJSONFeature{Type: "Feature", Properties: make(map[string]interface{}, len(cr.header))}
+	for i, name := range cr.header {
+		if i >= len(record) {
+			break
+		}
+		// Empty cells are left out so auto-detection falls through to the next candidate
+		if value := strings.TrimSpace(record[i]); value != "" {
+			feature.Properties[name] = value
+		}
+	}
+
+	if cr.latColumn >= len(record) || cr.lngColumn >= len(record) {
+		return feature, nil
+	}
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[cr.latColumn]), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(record[cr.lngColumn]), 64)
+	if latErr != nil || lngErr != nil {
+		return feature, nil
+	}
+
+	coords, err := json.Marshal([]float64{lng, lat})
+	if err != nil {
+		return nil, err
+	}
+	feature.Geometry.Type = "Point"
+	feature.Geometry.Coordinates = coords
+	return feature, nil
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCustomDatasetNotFound is returned when a custom dataset doesn't exist
+	// or belongs to another user
+	ErrCustomDatasetNotFound = errors.New("custom dataset not found")
+	// ErrCustomDatasetEmpty is returned when an upload has no usable addresses
+	ErrCustomDatasetEmpty = errors.New("no addresses with a house number, street and coordinates found in upload")
+	// ErrCustomAddressLimit is returned when an upload would take the user
+	// past their plan's custom address limit
+	ErrCustomAddressLimit = errors.New("upload exceeds your plan's custom address limit")
+)
+
+// CustomDatasetService stores the private address lists users upload and
+// searches them for the owner's API keys, so their own sites are geocoded
+// alongside the public address points
+type CustomDatasetService struct{}
+
+var CustomDatasets = &CustomDatasetService{}
+
+// customAddressOwners caches whether a user has any custom addresses, so the
+// searches of users without any skip the extra query. It is purged on upload
+// and delete; other instances catch up within the TTL.
+var customAddressOwners = newLookupCache[bool]("custom_address_owners", time.Minute, 10000)
+
+const customDatasetFields = `id, name, COALESCE(filename, ''), row_count, skipped_rows, created_at`
+
+func scanCustomDataset(scanner interface{ Scan(...interface{}) error }) (*models.CustomDataset, error) {
+	var dataset models.CustomDataset
+	if err := scanner.Scan(&dataset.ID, &dataset.Name, &dataset.Filename, &dataset.RowCount,
+		&dataset.SkippedRows, &dataset.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// Usage returns how many custom addresses the user stores and their plan's limit
+func (s *CustomDatasetService) Usage(ctx context.Context, userID int) (*models.CustomAddressUsage, error) {
+	var usage models.CustomAddressUsage
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT p.custom_address_limit, (SELECT COUNT(*) FROM custom_addresses WHERE user_id = u.id)
+		FROM users u
+		JOIN plans p ON p.id = u.plan_type
+		WHERE u.id = $1
+	`, userID).Scan(&usage.Limit, &usage.Used)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom address usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// ReadAddresses reads the addresses of an uploaded CSV or GeoJSON file.
+// Address columns are detected as for county dataset uploads, with state and
+// county also read from the file; mapping names any that aren't detected.
+// Rows without a point, house number or street are skipped and counted.
+// More than maxRows addresses fail with ErrCustomAddressLimit; a negative
+// maxRows means no cap.
+func (s *CustomDatasetService) ReadAddresses(r io.Reader, filename string, mapping models.DatasetFieldMapping, maxRows int) ([]models.OhioAddress, int, error) {
+	features, err := newFeatureReader(r, filename, mapping)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var addresses []models.OhioAddress
+	skipped := 0
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read upload: %w", err)
+		}
+
+		address, ok := customAddressFromFeature(feature, mapping)
+		if !ok {
+			skipped++
+			continue
+		}
+		if maxRows >= 0 && len(addresses) >= maxRows {
+			return nil, 0, ErrCustomAddressLimit
+		}
+		addresses = append(addresses, address)
+	}
+
+	if len(addresses) == 0 {
+		return nil, skipped, ErrCustomDatasetEmpty
+	}
+	return addresses, skipped, nil
+}
+
+// customAddressFromFeature maps an uploaded feature to an address, reporting
+// false when it has no valid point or lacks a house number or street. Values
+// are cut to the column widths of custom_addresses.
+func customAddressFromFeature(feature *geoJSONFeature, mapping models.DatasetFieldMapping) (models.OhioAddress, bool) {
+	lng, lat, ok := feature.point()
+	if !ok || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return models.OhioAddress{}, false
+	}
+
+	address := addressFromProperties(feature.Properties, mapping)
+	if address.HouseNumber == "" || address.Street == "" {
+		return models.OhioAddress{}, false
+	}
+	address.Latitude, address.Longitude = lat, lng
+	if _, mapped := mapping["postcode"]; address.Postcode == "" && !mapped {
+		address.Postcode = getStringProp(feature.Properties, "zip", "zipcode", "zip_code")
+	}
+	address.County = getStringProp(feature.Properties, "county", "county_name", "COUNTY_NAME")
+	// Only two-letter state codes fit the region column
+	if state := strings.ToUpper(getStringProp(feature.Properties, "state", "STATE", "region", "REGION")); len(state) == 2 {
+		address.Region = state
+	}
+
+	address.HouseNumber = truncateRunes(address.HouseNumber, 50)
+	address.Street = truncateRunes(address.Street, 255)
+	address.Unit = truncateRunes(address.Unit, 50)
+	address.City = truncateRunes(address.City, 255)
+	address.District = truncateRunes(address.District, 10)
+	address.Postcode = truncateRunes(address.Postcode, 10)
+	address.County = truncateRunes(address.County, 255)
+	address.FullAddress = customFullAddress(address)
+	address.Hash = addressHash(&address)
+	return address, true
+}
+
+// customFullAddress formats an address as "123 Main St Apt 4, Columbus, OH 43215"
+func customFullAddress(address models.OhioAddress) string {
+	line := address.HouseNumber + " " + address.Street
+	if address.Unit != "" {
+		line += " " + address.Unit
+	}
+	parts := []string{line}
+	if address.City != "" {
+		parts = append(parts, address.City)
+	}
+	if region := strings.TrimSpace(address.Region + " " + address.Postcode); region != "" {
+		parts = append(parts, region)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// truncateRunes cuts s to at most n characters
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// CreateDataset stores an address list for the user, refusing it with
+// ErrCustomAddressLimit when it would take them past their plan's limit
+func (s *CustomDatasetService) CreateDataset(ctx context.Context, userID int, name, filename string, addresses []models.OhioAddress, skipped int) (*models.CustomDataset, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the user keeps concurrent uploads from both fitting under the limit
+	var limit, used int
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.custom_address_limit
+		FROM users u
+		JOIN plans p ON p.id = u.plan_type
+		WHERE u.id = $1
+		FOR UPDATE OF u
+	`, userID).Scan(&limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom address limit: %w", err)
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM custom_addresses WHERE user_id = $1`, userID).Scan(&used); err != nil {
+		return nil, fmt.Errorf("failed to count custom addresses: %w", err)
+	}
+	if limit >= 0 && used+len(addresses) > limit {
+		return nil, ErrCustomAddressLimit
+	}
+
+	dataset, err := scanCustomDataset(tx.QueryRowContext(ctx, `
+		INSERT INTO custom_datasets (user_id, name, filename, row_count, skipped_rows)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		RETURNING `+customDatasetFields,
+		userID, name, filename, len(addresses), skipped))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create custom dataset: %w", err)
+	}
+
+	n := len(addresses)
+	hashes, houseNumbers, streets, units := make([]string, n), make([]string, n), make([]string, n), make([]string, n)
+	cities, districts, regions, postcodes := make([]string, n), make([]string, n), make([]string, n), make([]string, n)
+	counties, fullAddresses := make([]string, n), make([]string, n)
+	longitudes, latitudes := make([]float64, n), make([]float64, n)
+	for i, a := range addresses {
+		hashes[i], houseNumbers[i], streets[i], units[i] = a.Hash, a.HouseNumber, a.Street, a.Unit
+		cities[i], districts[i], regions[i], postcodes[i] = a.City, a.District, a.Region, a.Postcode
+		counties[i], fullAddresses[i] = a.County, a.FullAddress
+		longitudes[i], latitudes[i] = a.Longitude, a.Latitude
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO custom_addresses (dataset_id, user_id, hash, house_number, street, unit, city, district,
+			region, postcode, county, full_address, geom)
+		SELECT $1, $2, a.hash, a.house_number, a.street, a.unit, a.city, a.district,
+			a.region, a.postcode, a.county, a.full_address, ST_SetSRID(ST_MakePoint(a.lng, a.lat), 4326)
+		FROM unnest($3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::text[],
+			$10::text[], $11::text[], $12::text[], $13::float8[], $14::float8[])
+			AS a(hash, house_number, street, unit, city, district, region, postcode, county, full_address, lng, lat)
+	`, dataset.ID, userID, pq.Array(hashes), pq.Array(houseNumbers), pq.Array(streets), pq.Array(units),
+		pq.Array(cities), pq.Array(districts), pq.Array(regions), pq.Array(postcodes), pq.Array(counties),
+		pq.Array(fullAddresses), pq.Array(longitudes), pq.Array(latitudes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store custom addresses: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit custom dataset: %w", err)
+	}
+	customAddressOwners.Purge()
+	return dataset, nil
+}
+
+// ListDatasets returns the user's address lists, newest first
+func (s *CustomDatasetService) ListDatasets(ctx context.Context, userID int) ([]models.CustomDataset, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+customDatasetFields+`
+		FROM custom_datasets
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom datasets: %w", err)
+	}
+	defer rows.Close()
+
+	datasets := []models.CustomDataset{}
+	for rows.Next() {
+		dataset, err := scanCustomDataset(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom dataset: %w", err)
+		}
+		datasets = append(datasets, *dataset)
+	}
+	return datasets, rows.Err()
+}
+
+// DeleteDataset removes one of the user's address lists with its addresses
+func (s *CustomDatasetService) DeleteDataset(ctx context.Context, userID, id int) error {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM custom_datasets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom dataset: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrCustomDatasetNotFound
+	}
+	customAddressOwners.Purge()
+	return nil
+}
+
+// hasCustomAddresses reports whether the user has uploaded any addresses
+func (s *CustomDatasetService) hasCustomAddresses(ctx context.Context, userID int) (bool, error) {
+	return customAddressOwners.GetOrLoad(strconv.Itoa(userID), func() (bool, error) {
+		var exists bool
+		err := database.DB.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM custom_addresses WHERE user_id = $1)`, userID).Scan(&exists)
+		return exists, err
+	})
+}
+
+// SearchAddresses runs an address search over the user's custom addresses,
+// returning up to params.Limit of them ranked as the public search ranks
+// address points. Searches that filter on no address component, such as a
+// bare browse, return none.
+func (s *CustomDatasetService) SearchAddresses(ctx context.Context, userID int, params models.AddressSearchParams) ([]models.OhioAddress, error) {
+	components := searchParamComponents(params)
+	if len(components) == 0 {
+		return nil, nil
+	}
+	if has, err := s.hasCustomAddresses(ctx, userID); err != nil || !has {
+		return nil, err
+	}
+
+	params.Limit = AddressSearchLimit(params.Limit)
+	q := buildTableSearchQuery(params, "custom_addresses", userID)
+	query := fmt.Sprintf("%s %s %s LIMIT $%d", q.baseQuery, q.whereClause, q.orderBy, q.argIndex)
+	args := append(append(append([]interface{}{}, q.args...), q.orderByArgs...), params.Limit)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search custom addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.OhioAddress{}
+	for rows.Next() {
+		addr, err := scanAddressSearchRow(rows, q.hasRelevanceScore, q.hasUnitCount)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, *addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating custom address rows: %w", err)
+	}
+
+	ids := make([]int64, len(addresses))
+	for i := range addresses {
+		ids[i] = addresses[i].ID
+	}
+	datasetIDs, err := customDatasetIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addresses {
+		setCustomMatch(&addresses[i], addressPointMatch(components, false), datasetIDs[addresses[i].ID])
+	}
+	return addresses, nil
+}
+
+// NearbyAddresses returns the user's custom addresses within radiusMeters of
+// a location, nearest first
+func (s *CustomDatasetService) NearbyAddresses(ctx context.Context, userID int, lat, lng, radiusMeters float64, limit int) ([]models.NearbyAddress, error) {
+	if has, err := s.hasCustomAddresses(ctx, userID); err != nil || !has {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		WITH origin AS (
+			SELECT ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography AS pt
+		)
+		SELECT a.id, a.hash, a.house_number, a.street, a.unit, a.city, a.district, a.region, a.postcode,
+			a.county, a.full_address, ST_Y(a.geom), ST_X(a.geom), a.created_at, a.dataset_id,
+			ST_Distance(a.geom::geography, origin.pt) AS distance_meters
+		FROM custom_addresses a, origin
+		WHERE a.user_id = $1 AND ST_DWithin(a.geom::geography, origin.pt, $4)
+		ORDER BY distance_meters
+		LIMIT $5
+	`, userID, lng, lat, radiusMeters, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nearby custom addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.NearbyAddress{}
+	for rows.Next() {
+		var addr models.NearbyAddress
+		var datasetID int
+		if err := rows.Scan(
+			&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
+			&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
+			&addr.Latitude, &addr.Longitude, &addr.CreatedAt, &datasetID, &addr.DistanceMeters,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan nearby custom address: %w", err)
+		}
+		setCustomMatch(&addr.OhioAddress, reverseMatch(addr.DistanceMeters), datasetID)
+		addresses = append(addresses, addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating nearby custom addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// customDatasetIDs maps custom address IDs to the list each was uploaded in
+func customDatasetIDs(ctx context.Context, ids []int64) (map[int64]int, error) {
+	datasetIDs := make(map[int64]int, len(ids))
+	if len(ids) == 0 {
+		return datasetIDs, nil
+	}
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, dataset_id FROM custom_addresses WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up custom datasets: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var datasetID int
+		if err := rows.Scan(&id, &datasetID); err != nil {
+			return nil, fmt.Errorf("failed to scan custom dataset ID: %w", err)
+		}
+		datasetIDs[id] = datasetID
+	}
+	return datasetIDs, rows.Err()
+}
+
+// setCustomMatch attaches match to a custom address with source "custom",
+// the list it came from and its upload time as the data vintage
+func setCustomMatch(addr *models.OhioAddress, match *models.Match, datasetID int) {
+	match.Source = models.MatchSourceCustom
+	if datasetID != 0 {
+		match.DatasetID = &datasetID
+	}
+	vintage := addr.CreatedAt
+	match.DataVintage = &vintage
+	addr.Match = match
+}
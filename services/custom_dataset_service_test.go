@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const customAddressCSV = `number,street,unit,city,state,zip,lat,lon
+100,Main St,Apt 4,Columbus,oh,43215,39.96,-83.0
+200,High St,,Columbus,Ohio,43215,39.97,-83.01
+,Broad St,,Columbus,OH,43215,39.98,-83.02
+300,Long St,,Columbus,OH,43215,,
+`
+
+func TestReadCustomAddresses(t *testing.T) {
+	addresses, skipped, err := CustomDatasets.ReadAddresses(strings.NewReader(customAddressCSV), "stores.csv", nil, -1)
+	require.NoError(t, err)
+	require.Len(t, addresses, 2)
+	// No house number, and no point
+	assert.Equal(t, 2, skipped)
+
+	first := addresses[0]
+	assert.Equal(t, "100 Main St Apt 4, Columbus, OH 43215", first.FullAddress)
+	assert.Equal(t, 39.96, first.Latitude)
+	assert.Equal(t, -83.0, first.Longitude)
+	assert.NotEmpty(t, first.Hash)
+	// Only two-letter states fit the region column
+	assert.Equal(t, "", addresses[1].Region)
+	assert.Equal(t, "200 High St, Columbus, 43215", addresses[1].FullAddress)
+}
+
+func TestReadCustomAddressesLimits(t *testing.T) {
+	_, _, err := CustomDatasets.ReadAddresses(strings.NewReader(customAddressCSV), "stores.csv", nil, 1)
+	assert.ErrorIs(t, err, ErrCustomAddressLimit)
+
+	_, _, err = CustomDatasets.ReadAddresses(strings.NewReader(customAddressCSV), "stores.csv", nil, 2)
+	assert.NoError(t, err)
+
+	header := strings.SplitN(customAddressCSV, "\n", 2)[0]
+	_, skipped, err := CustomDatasets.ReadAddresses(strings.NewReader(header+"\n,Broad St,,Columbus,OH,43215,39.98,-83.02\n"), "stores.csv", nil, -1)
+	assert.ErrorIs(t, err, ErrCustomDatasetEmpty)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestCustomAddressFieldMapping(t *testing.T) {
+	csv := "store_no,addr_num,road,lat,lon\n7,12,Elm St,40.1,-82.9\n"
+	mapping := models.DatasetFieldMapping{"house_number": "addr_num", "street": "road"}
+
+	addresses, _, err := CustomDatasets.ReadAddresses(strings.NewReader(csv), "stores.csv", mapping, -1)
+	require.NoError(t, err)
+	require.Len(t, addresses, 1)
+	assert.Equal(t, "12 Elm St", addresses[0].FullAddress)
+}
+
+func TestBuildTableSearchQueryScopesUser(t *testing.T) {
+	q := buildTableSearchQuery(models.AddressSearchParams{City: "Columbus"}, "custom_addresses", 42)
+	assert.Equal(t, "custom_addresses", q.from)
+	assert.Equal(t, "WHERE user_id = $1 AND city ILIKE $2", q.whereClause)
+	assert.Equal(t, 42, q.args[0])
+
+	deduped := buildTableSearchQuery(models.AddressSearchParams{City: "Columbus", Dedupe: true}, "custom_addresses", 42)
+	assert.Contains(t, deduped.from, "FROM custom_addresses WHERE user_id = $1")
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// dataCoverageCache caches the coverage report by state filter. Counts come
+// from the county_address_counts view, so they are already as old as its
+// last refresh.
+var dataCoverageCache = newLookupCache[*models.DataCoverage]("data_coverage", 10*time.Minute, 100)
+
+// dataCoverageRow is one county's address count, latest completed dataset,
+// tracked upstream source and bundled county file
+type dataCoverageRow struct {
+	state             string
+	county            string
+	addressCount      int
+	lastLoadedAt      *time.Time
+	datasetID         *int
+	datasetName       string
+	datasetImportedAt *time.Time
+	sourceURL         string
+	sourceChangedAt   *time.Time
+	bundledSource     string
+}
+
+// GetDataCoverage reports, for every county with address data (optionally
+// only in state), how many address points are loaded, where they came from
+// and how fresh they are
+func GetDataCoverage(ctx context.Context, state string) (*models.DataCoverage, error) {
+	state = strings.ToUpper(strings.TrimSpace(state))
+	return dataCoverageCache.GetOrLoad(state, func() (*models.DataCoverage, error) {
+		rows, err := database.ReadDB(ctx).QueryContext(ctx, `
+			WITH latest AS (
+				SELECT DISTINCT ON (UPPER(state), LOWER(county))
+					id, name, state, county, COALESCE(processed_at, uploaded_at) AS imported_at
+				FROM datasets
+				WHERE status = 'completed'
+				ORDER BY UPPER(state), LOWER(county), processed_at DESC NULLS LAST, id DESC
+			),
+			counties AS (
+				SELECT COALESCE(NULLIF(c.state, ''), l.state, '') AS state, COALESCE(c.county, l.county) AS county,
+					COALESCE(c.address_count, 0) AS address_count, c.last_loaded_at,
+					l.id AS dataset_id, l.name AS dataset_name, l.imported_at
+				FROM county_address_counts c
+				FULL OUTER JOIN latest l ON UPPER(c.state) = UPPER(l.state) AND LOWER(c.county) = LOWER(l.county)
+			)
+			SELECT k.state, k.county, k.address_count, k.last_loaded_at, k.dataset_id, COALESCE(k.dataset_name, ''),
+				k.imported_at, COALESCE(s.data_url, s.config_url, ''), s.last_changed_at, COALESCE(oc.source_name, '')
+			FROM counties k
+			LEFT JOIN dataset_sources s ON UPPER(s.state) = UPPER(k.state) AND LOWER(s.county) = LOWER(k.county)
+			LEFT JOIN ohio_counties oc ON LOWER(oc.county_name) = LOWER(k.county) AND UPPER(k.state) IN ('OH', '')
+			WHERE $1 = '' OR UPPER(k.state) = $1
+			ORDER BY k.state, k.county
+		`, state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query data coverage: %w", err)
+		}
+		defer rows.Close()
+
+		var counties []models.CountyDataCoverage
+		for rows.Next() {
+			var row dataCoverageRow
+			var datasetID sql.NullInt64
+			if err := rows.Scan(&row.state, &row.county, &row.addressCount, &row.lastLoadedAt, &datasetID,
+				&row.datasetName, &row.datasetImportedAt, &row.sourceURL, &row.sourceChangedAt, &row.bundledSource); err != nil {
+				return nil, fmt.Errorf("failed to scan data coverage: %w", err)
+			}
+			if datasetID.Valid {
+				id := int(datasetID.Int64)
+				row.datasetID = &id
+			}
+			counties = append(counties, countyDataCoverage(row))
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read data coverage: %w", err)
+		}
+
+		coverage := summarizeDataCoverage(counties)
+		var refreshedAt time.Time
+		err = database.ReadDB(ctx).QueryRowContext(ctx, "SELECT refreshed_at FROM admin_stats_summary").Scan(&refreshedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get stats refresh time: %w", err)
+		}
+		if err == nil {
+			coverage.CountsRefreshedAt = &refreshedAt
+		}
+		return coverage, nil
+	})
+}
+
+// countyDataCoverage decides where a county's addresses came from: a
+// tracked OpenAddresses source, an uploaded dataset, or otherwise the
+// bundled county files
+func countyDataCoverage(row dataCoverageRow) models.CountyDataCoverage {
+	coverage := models.CountyDataCoverage{
+		State:          row.state,
+		County:         row.county,
+		AddressCount:   row.addressCount,
+		DatasetID:      row.datasetID,
+		LastImportedAt: row.datasetImportedAt,
+	}
+	if coverage.LastImportedAt == nil {
+		coverage.LastImportedAt = row.lastLoadedAt
+	}
+
+	switch {
+	case row.sourceURL != "":
+		coverage.Source = models.DataSourceOpenAddresses
+		coverage.SourceName = row.datasetName
+		coverage.SourceURL = row.sourceURL
+		coverage.DataVintage = row.sourceChangedAt
+	case row.datasetID != nil:
+		coverage.Source = models.DataSourceUpload
+		coverage.SourceName = row.datasetName
+	default:
+		coverage.Source = models.DataSourceBundled
+		coverage.SourceName = row.bundledSource
+	}
+	if coverage.DataVintage == nil {
+		coverage.DataVintage = coverage.LastImportedAt
+	}
+	return coverage
+}
+
+// summarizeDataCoverage totals the counties' addresses and lists their states
+func summarizeDataCoverage(counties []models.CountyDataCoverage) *models.DataCoverage {
+	coverage := &models.DataCoverage{
+		CountyCount: len(counties),
+		States:      []string{},
+		Counties:    counties,
+	}
+	if coverage.Counties == nil {
+		coverage.Counties = []models.CountyDataCoverage{}
+	}
+
+	seen := make(map[string]bool)
+	for _, county := range counties {
+		coverage.AddressCount += county.AddressCount
+		if county.State != "" && !seen[county.State] {
+			seen[county.State] = true
+			coverage.States = append(coverage.States, county.State)
+		}
+	}
+	return coverage
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+var (
+	// ErrUnknownDataset is returned for a dataset name with no loader
+	ErrUnknownDataset = errors.New("unknown dataset")
+	// ErrDataLoadRunning is returned when the dataset is already being loaded
+	ErrDataLoadRunning = errors.New("dataset load already running")
+)
+
+// dataLoader loads one reference dataset. load writes through tx and records
+// every source row on run; it must be safe to repeat, upserting or skipping
+// rows that already exist.
+type dataLoader struct {
+	description string
+	// table is loaded at startup while it is empty
+	table string
+	load  func(ctx context.Context, tx *sql.Tx, run *loadRun) error
+	// after runs once a real load has committed, e.g. to purge caches
+	after func()
+	// manual datasets have no bundled file and are only loaded from the
+	// admin API, never at startup
+	manual bool
+}
+
+// dataLoadOrder is the order datasets are loaded in at startup
+var dataLoadOrder = []string{
+	models.DataLoadStates,
+	models.DataLoadZipCodes,
+	models.DataLoadCities,
+	models.DataLoadCountyBoundaries,
+	models.DataLoadCensusBlockGroups,
+	models.DataLoadDistricts,
+	models.DataLoadZipCBSA,
+	models.DataLoadZipDemographics,
+	models.DataLoadAddressRanges,
+}
+
+var dataLoaders = map[string]dataLoader{
+	models.DataLoadStates: {
+		description: "US state boundaries from the Census TIGER/Line GeoJSON",
+		table:       "us_states",
+		load:        loadStates,
+		after:       func() { lookupCaches.state.Purge() },
+	},
+	models.DataLoadZipCodes: {
+		description: "US ZIP codes from the opendatasoft CSV export",
+		table:       "zip_codes",
+		load:        loadZipCodes,
+		after:       zipCodesChanged,
+	},
+	models.DataLoadCities: {
+		description: "US cities from the simplemaps CSV",
+		table:       "cities",
+		load:        loadCities,
+	},
+	models.DataLoadCountyBoundaries: {
+		description: "Ohio county bounding polygons from the address GeoJSON meta files",
+		table:       "ohio_counties",
+		load:        loadCountyBoundaries,
+		after: func() {
+			lookupCaches.county.Purge()
+			if err := cleanupGeoJSONFiles(); err != nil {
+				slog.Warn("failed to clean up GeoJSON files", "error", err)
+			}
+		},
+	},
+	models.DataLoadCensusBlockGroups: {
+		description: "Census block group boundaries from the TIGER/Line GeoJSON",
+		table:       "census_block_groups",
+		load:        loadCensusBlockGroups,
+		manual:      true,
+	},
+	models.DataLoadDistricts: {
+		description: "Congressional, state legislative and school district boundaries from TIGER/Line GeoJSON",
+		table:       "districts",
+		load:        loadDistricts,
+		manual:      true,
+	},
+	models.DataLoadZipCBSA: {
+		description: "HUD USPS ZIP to CBSA crosswalk from the CSV export",
+		table:       "zip_cbsa_crosswalk",
+		load:        loadZipCBSACrosswalk,
+		manual:      true,
+	},
+	models.DataLoadZipDemographics: {
+		description: "ZIP code income, households and population centroids from a Census CSV",
+		table:       "zip_demographics",
+		load:        loadZipDemographics,
+		manual:      true,
+	},
+	models.DataLoadAddressRanges: {
+		description: "Street address ranges from TIGER/Line ADDRFEAT GeoJSON, for interpolated geocoding",
+		table:       "address_ranges",
+		load:        loadAddressRanges,
+		manual:      true,
+	},
+}
+
+// rowOutcome is what a load did with one source row
+type rowOutcome int
+
+const (
+	rowInserted rowOutcome = iota
+	rowUpdated
+	rowSkipped
+	rowInvalid
+)
+
+// loadRun tracks the progress of one load; it is read by progress requests
+// while the load is writing it
+type loadRun struct {
+	mu       sync.Mutex
+	progress models.DataLoadProgress
+}
+
+// record counts one processed source row
+func (r *loadRun) record(outcome rowOutcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.progress.Processed++
+	switch outcome {
+	case rowInserted:
+		r.progress.Inserted++
+	case rowUpdated:
+		r.progress.Updated++
+	case rowSkipped:
+		r.progress.Skipped++
+	case rowInvalid:
+		r.progress.Invalid++
+	}
+	if r.progress.Processed%5000 == 0 {
+		slog.Info("loading dataset", "dataset", r.progress.Dataset, "processed", r.progress.Processed)
+	}
+}
+
+// setSource records the file the load reads from
+func (r *loadRun) setSource(source string) {
+	r.mu.Lock()
+	r.progress.Source = source
+	r.mu.Unlock()
+}
+
+// finish marks the run completed or failed
+func (r *loadRun) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.progress.FinishedAt = &now
+	r.progress.Status = models.DataLoadCompleted
+	if err != nil {
+		r.progress.Status = models.DataLoadFailed
+		r.progress.Error = err.Error()
+	}
+}
+
+func (r *loadRun) snapshot() models.DataLoadProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress
+}
+
+// DataLoadService runs reference data loads. Only the latest run of each
+// dataset is kept, in memory.
+type DataLoadService struct {
+	mu   sync.Mutex
+	runs map[string]*loadRun
+}
+
+var DataLoads = &DataLoadService{runs: make(map[string]*loadRun)}
+
+// Start begins loading dataset in the background and returns the new run
+func (s *DataLoadService) Start(dataset string, dryRun bool) (*models.DataLoadProgress, error) {
+	loader, run, err := s.begin(dataset, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := run.snapshot()
+	go s.execute(context.Background(), loader, run)
+	return &progress, nil
+}
+
+// Run loads dataset and waits for the load to finish
+func (s *DataLoadService) Run(ctx context.Context, dataset string, dryRun bool) (*models.DataLoadProgress, error) {
+	loader, run, err := s.begin(dataset, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.execute(ctx, loader, run)
+	progress := run.snapshot()
+	return &progress, err
+}
+
+// Progress returns the latest run of dataset, or an idle status if it has
+// not been loaded since the server started
+func (s *DataLoadService) Progress(dataset string) (*models.DataLoadProgress, error) {
+	loader, ok := dataLoaders[dataset]
+	if !ok {
+		return nil, ErrUnknownDataset
+	}
+
+	s.mu.Lock()
+	run := s.runs[dataset]
+	s.mu.Unlock()
+
+	if run == nil {
+		return &models.DataLoadProgress{
+			Dataset:     dataset,
+			Description: loader.description,
+			Status:      models.DataLoadIdle,
+		}, nil
+	}
+	progress := run.snapshot()
+	return &progress, nil
+}
+
+// List returns the latest run of every dataset
+func (s *DataLoadService) List() []models.DataLoadProgress {
+	list := make([]models.DataLoadProgress, 0, len(dataLoadOrder))
+	for _, dataset := range dataLoadOrder {
+		progress, _ := s.Progress(dataset)
+		list = append(list, *progress)
+	}
+	return list
+}
+
+// InitializeDatasets loads every dataset whose table is empty, in order,
+// except manual ones. Failures are logged; the load can be retried from the
+// admin API.
+func (s *DataLoadService) InitializeDatasets(ctx context.Context) {
+	for _, dataset := range dataLoadOrder {
+		if dataLoaders[dataset].manual {
+			continue
+		}
+		var loaded bool
+		query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", dataLoaders[dataset].table)
+		if err := database.DB.QueryRowContext(ctx, query).Scan(&loaded); err != nil {
+			slog.Warn("failed to check dataset", "dataset", dataset, "error", err)
+			continue
+		}
+		if loaded {
+			slog.Info("dataset already loaded", "dataset", dataset)
+			continue
+		}
+
+		slog.Info("dataset is empty, loading", "dataset", dataset)
+		if _, err := s.Run(ctx, dataset, false); err != nil {
+			slog.Warn("failed to initialize data", "dataset", dataset, "error", err,
+				"retry", "POST /api/v1/admin/load/"+dataset)
+		}
+	}
+}
+
+// begin registers a new run of dataset unless one is already running
+func (s *DataLoadService) begin(dataset string, dryRun bool) (dataLoader, *loadRun, error) {
+	loader, ok := dataLoaders[dataset]
+	if !ok {
+		return dataLoader{}, nil, ErrUnknownDataset
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if run := s.runs[dataset]; run != nil && run.snapshot().Status == models.DataLoadRunning {
+		return dataLoader{}, nil, ErrDataLoadRunning
+	}
+
+	now := time.Now()
+	run := &loadRun{progress: models.DataLoadProgress{
+		Dataset:     dataset,
+		Description: loader.description,
+		Status:      models.DataLoadRunning,
+		DryRun:      dryRun,
+		StartedAt:   &now,
+	}}
+	s.runs[dataset] = run
+	return loader, run, nil
+}
+
+// execute runs a load in one transaction, rolling it back for dry runs, so
+// a failed load leaves the table as it was
+func (s *DataLoadService) execute(ctx context.Context, loader dataLoader, run *loadRun) error {
+	err := func() error {
+		tx, err := database.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := loader.load(ctx, tx, run); err != nil {
+			return err
+		}
+		if run.snapshot().DryRun {
+			return nil
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit load: %w", err)
+		}
+		markReferenceDataModified()
+		if loader.after != nil {
+			loader.after()
+		}
+		return nil
+	}()
+
+	run.finish(err)
+	progress := run.snapshot()
+	if err != nil {
+		slog.Error("data load failed", "dataset", progress.Dataset, "dry_run", progress.DryRun, "error", err)
+		return err
+	}
+	slog.Info("data load completed", "dataset", progress.Dataset, "dry_run", progress.DryRun,
+		"inserted", progress.Inserted, "updated", progress.Updated, "skipped", progress.Skipped, "invalid", progress.Invalid)
+	return nil
+}
+
+// upsertRow runs an INSERT ... RETURNING (xmax = 0) statement and reports
+// whether the row was inserted, updated, or skipped by ON CONFLICT DO NOTHING
+func upsertRow(ctx context.Context, stmt *sql.Stmt, args ...interface{}) (rowOutcome, error) {
+	var inserted bool
+	err := stmt.QueryRowContext(ctx, args...).Scan(&inserted)
+	switch {
+	case err == sql.ErrNoRows:
+		return rowSkipped, nil
+	case err != nil:
+		return 0, err
+	case inserted:
+		return rowInserted, nil
+	default:
+		return rowUpdated, nil
+	}
+}
+
+// gzipFile closes both the decompressor and the file underneath it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// globDataFiles returns the GeoJSON files matching pattern, given without
+// the .geojson extension, preferring an uncompressed file over its .gz
+// sibling
+func globDataFiles(pattern string) ([]string, error) {
+	plain, err := filepath.Glob(pattern + ".geojson")
+	if err != nil {
+		return nil, err
+	}
+	gzipped, err := filepath.Glob(pattern + ".geojson.gz")
+	if err != nil {
+		return nil, err
+	}
+
+	files := plain
+	for _, file := range gzipped {
+		if _, err := os.Stat(strings.TrimSuffix(file, ".gz")); err != nil {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// openDataFile opens the first of paths that exists, trying each path and
+// then its .gz sibling. Gzipped files are decompressed as they are read.
+func openDataFile(paths ...string) (io.ReadCloser, string, error) {
+	for _, path := range paths {
+		for _, candidate := range []string{path, path + ".gz"} {
+			file, err := os.Open(candidate)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to open %s: %w", candidate, err)
+			}
+			if !strings.HasSuffix(candidate, ".gz") {
+				return file, candidate, nil
+			}
+
+			gzReader, err := gzip.NewReader(file)
+			if err != nil {
+				file.Close()
+				return nil, "", fmt.Errorf("failed to create gzip reader for %s: %w", candidate, err)
+			}
+			return gzipFile{Reader: gzReader, file: file}, candidate, nil
+		}
+	}
+	return nil, "", fmt.Errorf("data file not found (looked for %s)", strings.Join(paths, ", "))
+}
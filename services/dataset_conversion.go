@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"geocoding-api/models"
+	"geocoding-api/utils"
+)
+
+// NeedsConversion reports whether an upload is a zipped shapefile or a
+// GeoPackage that must be converted to GeoJSON before import
+func NeedsConversion(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".zip" || ext == ".gpkg"
+}
+
+// CheckDatasetExtension rejects files the dataset importer can't read
+func CheckDatasetExtension(filename string) error {
+	if NeedsConversion(filename) {
+		return CheckConverterAvailable()
+	}
+
+	allowedExtensions := []string{".geojson", ".json", ".ndjson", ".csv", ".gz"}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range allowedExtensions {
+		if ext == allowed || strings.HasSuffix(filename, ".geojson.gz") {
+			return nil
+		}
+	}
+	return fmt.Errorf("file must be .geojson, .json, .ndjson, .csv, .gz, a zipped shapefile (.zip) or a GeoPackage (.gpkg)")
+}
+
+// DatasetFileType returns the file_type recorded for a dataset file
+func DatasetFileType(filename string) string {
+	switch ext := strings.ToLower(filepath.Ext(filename)); {
+	case ext == ".zip":
+		return "shapefile"
+	case ext == ".gpkg":
+		return "geopackage"
+	case strings.Contains(filename, ".csv"):
+		return "csv"
+	case strings.Contains(filename, ".ndjson"):
+		return "ndjson"
+	case strings.Contains(filename, ".json") && !strings.Contains(filename, ".geojson"):
+		return "json"
+	}
+	return "geojson"
+}
+
+// CheckConverterAvailable returns an error if ogr2ogr, used to convert
+// shapefiles and GeoPackages, is not installed
+func CheckConverterAvailable() error {
+	if _, err := exec.LookPath("ogr2ogr"); err != nil {
+		return fmt.Errorf("shapefile and GeoPackage uploads require GDAL (ogr2ogr) on the server")
+	}
+	return nil
+}
+
+// convertToGeoJSONSeq converts a zipped shapefile or GeoPackage to
+// newline-delimited GeoJSON in WGS84 and returns the path of the new file.
+// The caller removes the file when done.
+func convertToGeoJSONSeq(path string) (string, error) {
+	if err := CheckConverterAvailable(); err != nil {
+		return "", err
+	}
+
+	source := path
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		tempDir, err := os.MkdirTemp(UploadDirectory, "shapefile_")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		if err := utils.NewFileDownloader(tempDir).ExtractZip(path, tempDir); err != nil {
+			return "", err
+		}
+		if source, err = findShapefile(tempDir); err != nil {
+			return "", err
+		}
+	}
+
+	outputPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".geojsonl"
+	cmd := exec.Command("ogr2ogr",
+		"-f", "GeoJSONSeq",
+		"-t_srs", "EPSG:4326", // Ensure WGS84 coordinate system
+		outputPath,
+		source,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(outputPath)
+		return "", fmt.Errorf("ogr2ogr failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	slog.Debug("converted dataset file", "source", path, "output", outputPath)
+	return outputPath, nil
+}
+
+// findShapefile returns the first .shp file under dir
+func findShapefile(dir string) (string, error) {
+	var shpFile string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if shpFile == "" && !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".shp") {
+			shpFile = path
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if shpFile == "" {
+		return "", fmt.Errorf("no .shp file found in ZIP")
+	}
+	return shpFile, nil
+}
+
+// ValidateConvertedFile saves a shapefile or GeoPackage upload to a temp file,
+// converts it and validates the result like ValidateDatasetFile
+func (s *DatasetService) ValidateConvertedFile(r io.Reader, filename string, maxBytes int64, mapping models.DatasetFieldMapping) (*models.DatasetValidation, error) {
+	if err := EnsureUploadDirectory(); err != nil {
+		return nil, err
+	}
+
+	temp, err := os.CreateTemp(UploadDirectory, "validate_*"+filepath.Ext(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := io.Copy(temp, r); err != nil {
+		temp.Close()
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+	temp.Close()
+
+	converted, err := convertToGeoJSONSeq(temp.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(converted)
+
+	file, err := os.Open(converted)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.ValidateDatasetFile(file, filepath.Base(converted), info.Size(), maxBytes, mapping)
+	if err != nil {
+		return nil, err
+	}
+	result.Filename = filename
+	return result, nil
+}
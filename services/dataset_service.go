@@ -2,16 +2,16 @@ package services
 
 import (
 	"compress/gzip"
+	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
-	"geocoding-api/database"
 	"geocoding-api/models"
 )
 
@@ -37,8 +37,8 @@ func EnsureUploadDirectory() error {
 func (s *DatasetService) CreateDataset(dataset *models.Dataset) error {
 	query := `
 		INSERT INTO datasets (name, state, county, file_type, file_path, file_size, 
-			record_count, status, uploaded_by, uploaded_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			record_count, status, uploaded_by, uploaded_at, field_mapping)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -54,6 +54,7 @@ func (s *DatasetService) CreateDataset(dataset *models.Dataset) error {
 		dataset.Status,
 		dataset.UploadedBy,
 		dataset.UploadedAt,
+		dataset.FieldMapping,
 	).Scan(&dataset.ID, &dataset.UploadedAt, &dataset.UploadedAt)
 }
 
@@ -91,7 +92,8 @@ func (s *DatasetService) GetDatasets(state, status string, limit, offset int) ([
 	// Get datasets
 	query := fmt.Sprintf(`
 		SELECT id, name, state, county, file_type, file_path, file_size, 
-			record_count, status, error_message, uploaded_by, uploaded_at, processed_at
+			record_count, status, error_message, uploaded_by, uploaded_at, processed_at,
+			field_mapping, validation_report
 		FROM datasets
 		%s
 		ORDER BY uploaded_at DESC
@@ -126,6 +128,8 @@ func (s *DatasetService) GetDatasets(state, status string, limit, offset int) ([
 			&dataset.UploadedBy,
 			&dataset.UploadedAt,
 			&processedAt,
+			&dataset.FieldMapping,
+			&dataset.ValidationReport,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -147,7 +151,8 @@ func (s *DatasetService) GetDatasets(state, status string, limit, offset int) ([
 func (s *DatasetService) GetDatasetByID(id int) (*models.Dataset, error) {
 	query := `
 		SELECT id, name, state, county, file_type, file_path, file_size, 
-			record_count, status, error_message, uploaded_by, uploaded_at, processed_at
+			record_count, status, error_message, uploaded_by, uploaded_at, processed_at,
+			field_mapping, validation_report
 		FROM datasets
 		WHERE id = $1
 	`
@@ -170,6 +175,8 @@ func (s *DatasetService) GetDatasetByID(id int) (*models.Dataset, error) {
 		&dataset.UploadedBy,
 		&dataset.UploadedAt,
 		&processedAt,
+		&dataset.FieldMapping,
+		&dataset.ValidationReport,
 	)
 
 	if err != nil {
@@ -199,6 +206,22 @@ func (s *DatasetService) UpdateDatasetStatus(id int, status, errorMessage string
 	return err
 }
 
+// UpdateDatasetFieldMapping replaces the property mapping used when the dataset is (re)processed
+func (s *DatasetService) UpdateDatasetFieldMapping(id int, mapping models.DatasetFieldMapping) error {
+	_, err := s.db.Exec(`
+		UPDATE datasets SET field_mapping = $1, updated_at = $2 WHERE id = $3
+	`, mapping, time.Now(), id)
+	return err
+}
+
+// UpdateDatasetValidationReport records the dataset's latest validation pass
+func (s *DatasetService) UpdateDatasetValidationReport(id int, report *models.DatasetValidationReport) error {
+	_, err := s.db.Exec(`
+		UPDATE datasets SET validation_report = $1, updated_at = $2 WHERE id = $3
+	`, report, time.Now(), id)
+	return err
+}
+
 // DeleteDataset deletes a dataset and its file
 func (s *DatasetService) DeleteDataset(id int) error {
 	// Get dataset to find file path
@@ -210,7 +233,7 @@ func (s *DatasetService) DeleteDataset(id int) error {
 	// Delete file if it exists
 	if dataset.FilePath != "" {
 		if err := os.Remove(dataset.FilePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("Warning: Failed to delete file %s: %v", dataset.FilePath, err)
+			slog.Warn("failed to delete dataset file", "dataset_id", dataset.ID, "path", dataset.FilePath, "error", err)
 		}
 	}
 
@@ -283,20 +306,77 @@ func (s *DatasetService) GetDatasetStats() (*models.DatasetStats, error) {
 	return stats, nil
 }
 
-// ProcessGeoJSONDataset processes an uploaded GeoJSON file and imports addresses
+// ProcessGeoJSONDataset processes an uploaded GeoJSON, CSV, shapefile or
+// GeoPackage file and imports addresses, then emails the uploader whether
+// the import completed or failed
 func (s *DatasetService) ProcessGeoJSONDataset(datasetID int) error {
+	err := s.processGeoJSONDataset(datasetID)
+	s.notifyUploader(datasetID)
+	return err
+}
+
+// notifyUploader emails the uploader of a dataset that finished processing
+func (s *DatasetService) notifyUploader(datasetID int) {
+	dataset, err := s.GetDatasetByID(datasetID)
+	if err != nil {
+		return
+	}
+	data := map[string]interface{}{
+		"DatasetID":   dataset.ID,
+		"DatasetName": dataset.Name,
+		"State":       dataset.State,
+		"County":      dataset.County,
+	}
+	switch dataset.Status {
+	case "completed":
+		data["RecordCount"] = dataset.RecordCount
+		Notifications.Notify(dataset.UploadedBy, NotificationDatasetCompleted, "", data)
+	case "failed":
+		data["Error"] = dataset.ErrorMessage
+		Notifications.Notify(dataset.UploadedBy, NotificationDatasetFailed, "", data)
+	}
+}
+
+func (s *DatasetService) processGeoJSONDataset(datasetID int) error {
 	dataset, err := s.GetDatasetByID(datasetID)
 	if err != nil {
 		return fmt.Errorf("failed to get dataset: %w", err)
 	}
 
-	// Update status to processing
+	if err := s.UpdateDatasetStatus(datasetID, "validating", "", 0); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	// Shapefiles and GeoPackages are converted to GeoJSON first
+	filePath := dataset.FilePath
+	if NeedsConversion(filePath) {
+		converted, err := convertToGeoJSONSeq(filePath)
+		if err != nil {
+			s.UpdateDatasetStatus(datasetID, "failed", err.Error(), 0)
+			return fmt.Errorf("failed to convert file: %w", err)
+		}
+		defer os.Remove(converted)
+		filePath = converted
+	}
+
+	// Check the whole file before importing any of it, so a bad upload fails
+	// with a report instead of partway through the import
+	report := s.validateStoredDataset(dataset, filePath)
+	if err := s.UpdateDatasetValidationReport(datasetID, report); err != nil {
+		slog.Warn("failed to save dataset validation report", "dataset_id", datasetID, "error", err)
+	}
+	if !report.Passed {
+		message := "validation failed: " + strings.Join(report.Errors, "; ")
+		s.UpdateDatasetStatus(datasetID, "failed", message, 0)
+		return fmt.Errorf("dataset %d %s", datasetID, message)
+	}
+
 	if err := s.UpdateDatasetStatus(datasetID, "processing", "", 0); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
 
 	// Open file (handle both .gz and plain files)
-	file, err := os.Open(dataset.FilePath)
+	file, err := os.Open(filePath)
 	if err != nil {
 		s.UpdateDatasetStatus(datasetID, "failed", err.Error(), 0)
 		return fmt.Errorf("failed to open file: %w", err)
@@ -306,7 +386,7 @@ func (s *DatasetService) ProcessGeoJSONDataset(datasetID int) error {
 	var reader io.Reader = file
 
 	// If file is gzipped, decompress it
-	if strings.HasSuffix(dataset.FilePath, ".gz") {
+	if strings.HasSuffix(filePath, ".gz") {
 		gzReader, err := gzip.NewReader(file)
 		if err != nil {
 			s.UpdateDatasetStatus(datasetID, "failed", err.Error(), 0)
@@ -316,106 +396,137 @@ func (s *DatasetService) ProcessGeoJSONDataset(datasetID int) error {
 		reader = gzReader
 	}
 
-	// Parse GeoJSON
-	var geojson struct {
-		Type     string `json:"type"`
-		Features []struct {
-			Type       string `json:"type"`
-			Properties map[string]interface{} `json:"properties"`
-			Geometry   struct {
-				Type        string    `json:"type"`
-				Coordinates []float64 `json:"coordinates"`
-			} `json:"geometry"`
-		} `json:"features"`
-	}
-
-	if err := json.NewDecoder(reader).Decode(&geojson); err != nil {
+	// Stream features one at a time so memory stays flat regardless of file size
+	features, err := newFeatureReader(reader, filePath, dataset.FieldMapping)
+	if err != nil {
 		s.UpdateDatasetStatus(datasetID, "failed", err.Error(), 0)
 		return fmt.Errorf("failed to parse GeoJSON: %w", err)
 	}
 
-	// Process features and insert into database
-	recordCount := 0
-	skippedDuplicates := 0
-	
-	for _, feature := range geojson.Features {
-		if feature.Geometry.Type != "Point" {
-			continue
-		}
+	// Process features and bulk load them into the database
+	writer := newAddressBulkWriter(s.db, defaultAddressBatchSize)
 
-		// Extract address components from properties
-		// Supports multiple property naming conventions:
-		// - Ohio LBRS format (HOUSENUM, ST_NAME, USPS_CITY, ZIPCODE)
-		// - Generic format (HOUSE_NUMB, STREET, CITY, ZIP)
-		// - Lowercase format (house_number, street, city, postcode)
-		props := feature.Properties
-		
-		address := models.OhioAddress{
-			Longitude: feature.Geometry.Coordinates[0],
-			Latitude:  feature.Geometry.Coordinates[1],
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.UpdateDatasetStatus(datasetID, "failed", err.Error(), writer.inserted)
+			return fmt.Errorf("failed to parse GeoJSON: %w", err)
 		}
 
-		// House Number - try multiple field names and types
-		address.HouseNumber = getStringProp(props, "HOUSENUM", "HOUSE_NUMB", "house_number", "LHN")
-		
-		// Street Name - Ohio LBRS uses ST_NAME or LSN (full street with number)
-		address.Street = getStringProp(props, "ST_NAME", "STREET", "street")
-		if address.Street == "" {
-			// Try LSN but remove the house number prefix
-			if lsn := getStringProp(props, "LSN"); lsn != "" && address.HouseNumber != "" {
-				// LSN format is "16551 STATE RTE 247" - remove the number prefix
-				address.Street = strings.TrimSpace(strings.TrimPrefix(lsn, address.HouseNumber))
-			}
+		lng, lat, ok := feature.point()
+		if !ok {
+			continue
 		}
-		
-		// City - USPS_CITY or MUNI for Ohio LBRS
-		address.City = getStringProp(props, "USPS_CITY", "CITY", "city", "MUNI", "COMM")
-		
-		// ZIP Code
-		address.Postcode = getStringProp(props, "ZIPCODE", "ZIP", "postcode", "postal_code")
-		
-		// Unit/Apartment
-		address.Unit = getStringProp(props, "UNITNUM", "UNIT", "unit", "UNITEXTRA")
-		
-		// District (county abbreviation like "ADA")
-		address.District = getStringProp(props, "COUNTY", "district")
+
+		address := addressFromProperties(feature.Properties, dataset.FieldMapping)
+		address.Longitude = lng
+		address.Latitude = lat
 
 		// Set county and state from dataset metadata (full names)
 		address.County = dataset.County
 		address.Region = dataset.State
 
-		// Insert address into database (using existing service)
-		if address.HouseNumber != "" && address.Street != "" {
-			// Use the existing address service to insert
-			addressService := NewAddressService(database.DB)
-			if _, err := addressService.CreateAddress(&address); err != nil {
-				// Check if it's a duplicate (unique constraint violation)
-				if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "unique constraint") {
-					skippedDuplicates++
-				} else {
-					log.Printf("Warning: Failed to insert address: %v", err)
-				}
-				continue
-			}
-			recordCount++
+		if address.HouseNumber == "" || address.Street == "" {
+			continue
 		}
+		if err := writer.Add(address); err != nil {
+			s.UpdateDatasetStatus(datasetID, "failed", err.Error(), writer.inserted)
+			return fmt.Errorf("failed to import addresses: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		s.UpdateDatasetStatus(datasetID, "failed", err.Error(), writer.inserted)
+		return fmt.Errorf("failed to import addresses: %w", err)
 	}
+	recordCount := writer.inserted
+	skippedDuplicates := writer.duplicates
 
 	// Update dataset status to completed
 	if err := s.UpdateDatasetStatus(datasetID, "completed", "", recordCount); err != nil {
 		return fmt.Errorf("failed to update completion status: %w", err)
 	}
+	// County details include address counts
+	markReferenceDataModified()
+
+	// Rebuild the street index for this county so street-level matches include the new data
+	if err := Street.RefreshStreets(dataset.County); err != nil {
+		slog.Warn("failed to refresh street index", "dataset_id", datasetID, "county", dataset.County, "error", err)
+	}
+
+	// Notify address subscribers of what the import changed
+	if err := AddressSubscriptions.DetectChanges(context.Background(), dataset.ID, dataset.State, dataset.County); err != nil {
+		slog.Warn("failed to detect address changes", "dataset_id", datasetID, "error", err)
+	}
+
+	if err := Webhooks.Emit(dataset.UploadedBy, models.WebhookEventDatasetCompleted, "", map[string]interface{}{
+		"dataset_id":         dataset.ID,
+		"name":               dataset.Name,
+		"state":              dataset.State,
+		"county":             dataset.County,
+		"record_count":       recordCount,
+		"skipped_duplicates": skippedDuplicates,
+	}); err != nil {
+		slog.Warn("failed to queue webhook", "event", models.WebhookEventDatasetCompleted, "dataset_id", datasetID, "error", err)
+	}
 
 	// Delete the uploaded file after successful processing to save disk space
 	if err := s.cleanupUploadedFile(dataset.FilePath); err != nil {
-		log.Printf("Warning: Failed to cleanup uploaded file: %v", err)
+		slog.Warn("failed to clean up uploaded file", "dataset_id", datasetID, "error", err)
 		// Don't fail the operation, data is already imported
 	}
 
-	log.Printf("Successfully processed dataset %d: %d records imported, %d duplicates skipped", datasetID, recordCount, skippedDuplicates)
+	slog.Info("processed dataset", "dataset_id", datasetID, "records", recordCount, "duplicates_skipped", skippedDuplicates)
 	return nil
 }
 
+// addressFromProperties extracts address components from feature properties.
+// Fields named in mapping are read from that property only; the rest are
+// auto-detected from multiple property naming conventions:
+// - Ohio LBRS format (HOUSENUM, ST_NAME, USPS_CITY, ZIPCODE)
+// - Generic format (HOUSE_NUMB, STREET, CITY, ZIP)
+// - Lowercase format (house_number, street, city, postcode)
+func addressFromProperties(props map[string]interface{}, mapping models.DatasetFieldMapping) models.OhioAddress {
+	field := func(name string, fallbacks ...string) string {
+		if property, ok := mapping[name]; ok {
+			return getStringProp(props, property)
+		}
+		return getStringProp(props, fallbacks...)
+	}
+
+	var address models.OhioAddress
+
+	// House Number - try multiple field names and types
+	address.HouseNumber = field("house_number", "HOUSENUM", "HOUSE_NUMB", "house_number", "LHN", "NUMBER", "number")
+
+	// Street Name - Ohio LBRS uses ST_NAME or LSN (full street with number)
+	address.Street = field("street", "ST_NAME", "STREET", "street")
+	if _, mapped := mapping["street"]; address.Street == "" && !mapped {
+		// Try LSN but remove the house number prefix
+		if lsn := getStringProp(props, "LSN"); lsn != "" && address.HouseNumber != "" {
+			// LSN format is "16551 STATE RTE 247" - remove the number prefix
+			address.Street = strings.TrimSpace(strings.TrimPrefix(lsn, address.HouseNumber))
+		}
+	}
+
+	// City - USPS_CITY or MUNI for Ohio LBRS
+	address.City = field("city", "USPS_CITY", "CITY", "city", "MUNI", "COMM")
+
+	// ZIP Code
+	address.Postcode = field("postcode", "ZIPCODE", "ZIP", "postcode", "postal_code", "POSTCODE")
+
+	// Unit/Apartment
+	address.Unit = field("unit", "UNITNUM", "UNIT", "unit", "UNITEXTRA")
+
+	// District (county abbreviation like "ADA")
+	address.District = field("district", "COUNTY", "district")
+
+	return address
+}
+
 // cleanupUploadedFile removes the uploaded file after processing
 func (s *DatasetService) cleanupUploadedFile(filePath string) error {
 	if filePath == "" {
@@ -426,7 +537,7 @@ func (s *DatasetService) cleanupUploadedFile(filePath string) error {
 		return fmt.Errorf("failed to delete file %s: %w", filePath, err)
 	}
 	
-	log.Printf("Cleaned up uploaded file: %s", filePath)
+	slog.Debug("cleaned up uploaded file", "path", filePath)
 	return nil
 }
 
@@ -454,6 +565,94 @@ func getStringProp(props map[string]interface{}, fieldNames ...string) string {
 	return ""
 }
 
+// inFlightUploads holds the state/county pairs with an upload being saved, so
+// two concurrent requests for one county can't both pass CheckDatasetExists
+// before either dataset row is written
+var inFlightUploads = struct {
+	sync.Mutex
+	keys map[string]bool
+}{keys: make(map[string]bool)}
+
+// ReserveUpload claims state/county until the returned release func is
+// called. ok is false if another upload for the same county is in flight.
+func ReserveUpload(state, county string) (release func(), ok bool) {
+	key := strings.ToUpper(state) + "|" + strings.ToUpper(county)
+
+	inFlightUploads.Lock()
+	defer inFlightUploads.Unlock()
+	if inFlightUploads.keys[key] {
+		return nil, false
+	}
+	inFlightUploads.keys[key] = true
+
+	return func() {
+		inFlightUploads.Lock()
+		delete(inFlightUploads.keys, key)
+		inFlightUploads.Unlock()
+	}, true
+}
+
+// ReplaceDatasets deletes every dataset for state/county along with the
+// addresses imported for that county, so a new upload can take their place.
+// The county has no address data until the new dataset finishes processing.
+// The deleted addresses are kept in replaced_addresses for the new import to
+// be diffed against.
+func (s *DatasetService) ReplaceDatasets(state, county string) error {
+	rows, err := s.db.Query(`
+		SELECT file_path FROM datasets WHERE UPPER(state) = UPPER($1) AND UPPER(county) = UPPER($2)
+	`, state, county)
+	if err != nil {
+		return err
+	}
+	var filePaths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		filePaths = append(filePaths, path)
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		WITH deleted AS (
+			DELETE FROM ohio_addresses WHERE UPPER(COALESCE(region, '')) = UPPER($1) AND UPPER(county) = UPPER($2)
+			RETURNING hash, full_address, postcode, geom
+		)
+		INSERT INTO replaced_addresses (state, county, hash, full_address, postcode, geom)
+		SELECT UPPER($1), UPPER($2), hash, full_address, postcode, geom FROM deleted
+		ON CONFLICT (state, county, hash) DO NOTHING
+	`, state, county)
+	if err != nil {
+		return fmt.Errorf("failed to delete addresses: %w", err)
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM datasets WHERE UPPER(state) = UPPER($1) AND UPPER(county) = UPPER($2)
+	`, state, county); err != nil {
+		return fmt.Errorf("failed to delete datasets: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, path := range filePaths {
+		if err := s.cleanupUploadedFile(path); err != nil {
+			slog.Warn("failed to delete replaced dataset file", "path", path, "error", err)
+		}
+	}
+
+	deleted, _ := result.RowsAffected()
+	slog.Info("replaced datasets", "state", state, "county", county, "datasets", len(filePaths), "addresses_deleted", deleted)
+	return nil
+}
+
 // CheckDatasetExists checks if a dataset with the same state and county already exists
 func (s *DatasetService) CheckDatasetExists(state, county string) (bool, *models.Dataset, error) {
 	query := `
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/models"
+)
+
+const (
+	// DefaultValidationBytes is how much of an upload is inspected by default
+	DefaultValidationBytes = 16 << 20
+	// MaxValidationBytes caps the inspection window a caller can ask for
+	MaxValidationBytes = 256 << 20
+	// validationSampleSize is how many parsed addresses are returned
+	validationSampleSize = 5
+)
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ValidateDatasetFile inspects up to maxBytes of an upload without importing
+// it, reporting the layout, property names and a sample of parsed addresses.
+// size is the full file size and is used to extrapolate the record count when
+// the file is larger than the inspection window. mapping is applied the same
+// way the importer would.
+func (s *DatasetService) ValidateDatasetFile(r io.Reader, filename string, size, maxBytes int64, mapping models.DatasetFieldMapping) (*models.DatasetValidation, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultValidationBytes
+	}
+
+	limited := &io.LimitedReader{R: r, N: maxBytes}
+	counter := &countingReader{r: limited}
+
+	result := &models.DatasetValidation{
+		Filename:        filename,
+		FileSize:        size,
+		FieldMapping:    mapping,
+		Compressed:      strings.HasSuffix(strings.ToLower(filename), ".gz"),
+		Properties:      []models.DatasetPropertyStat{},
+		SampleAddresses: []models.DatasetSampleAddress{},
+		Warnings:        []string{},
+	}
+
+	var reader io.Reader = counter
+	if result.Compressed {
+		gzReader, err := gzip.NewReader(counter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip file: %w", err)
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
+	features, err := newFeatureReader(reader, filename, mapping)
+	if err != nil {
+		return nil, err
+	}
+	result.Format = features.Format()
+
+	propertyCounts := map[string]int{}
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Running out of window mid-feature is expected for large files
+			if limited.N == 0 {
+				result.Truncated = true
+				break
+			}
+			return nil, fmt.Errorf("invalid feature after %d features: %w", result.FeaturesInspected, err)
+		}
+
+		result.FeaturesInspected++
+		for name, value := range feature.Properties {
+			if value != nil {
+				propertyCounts[name]++
+			}
+		}
+
+		lng, lat, ok := feature.point()
+		if !ok {
+			continue
+		}
+		result.PointFeatures++
+
+		address := addressFromProperties(feature.Properties, mapping)
+		if address.HouseNumber == "" || address.Street == "" {
+			continue
+		}
+		result.AddressableFeatures++
+
+		if len(result.SampleAddresses) < validationSampleSize {
+			result.SampleAddresses = append(result.SampleAddresses, models.DatasetSampleAddress{
+				HouseNumber: address.HouseNumber,
+				Street:      address.Street,
+				Unit:        address.Unit,
+				City:        address.City,
+				Postcode:    address.Postcode,
+				District:    address.District,
+				Latitude:    lat,
+				Longitude:   lng,
+			})
+		}
+	}
+	if limited.N == 0 && size > maxBytes {
+		result.Truncated = true
+	}
+	result.BytesInspected = counter.n
+
+	result.EstimatedRecordCount = result.AddressableFeatures
+	if result.Truncated && counter.n > 0 && size > 0 {
+		result.EstimatedRecordCount = int(int64(result.AddressableFeatures) * size / counter.n)
+	}
+
+	for name, count := range propertyCounts {
+		result.Properties = append(result.Properties, models.DatasetPropertyStat{Name: name, Count: count})
+	}
+	sort.Slice(result.Properties, func(i, j int) bool {
+		if result.Properties[i].Count != result.Properties[j].Count {
+			return result.Properties[i].Count > result.Properties[j].Count
+		}
+		return result.Properties[i].Name < result.Properties[j].Name
+	})
+
+	switch {
+	case result.FeaturesInspected == 0:
+		result.Warnings = append(result.Warnings, "no features found")
+	case result.PointFeatures == 0:
+		result.Warnings = append(result.Warnings, "no Point features found; only Point geometries are imported")
+	case result.AddressableFeatures == 0:
+		result.Warnings = append(result.Warnings, "no features have a recognized house number and street property")
+	}
+	if skipped := result.FeaturesInspected - result.PointFeatures; skipped > 0 && result.PointFeatures > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d of %d inspected features are not Points and will be skipped", skipped, result.FeaturesInspected))
+	}
+	if missing := result.PointFeatures - result.AddressableFeatures; missing > 0 && result.AddressableFeatures > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d of %d point features lack a house number or street and will be skipped", missing, result.PointFeatures))
+	}
+
+	return result, nil
+}
+
+// maxInvalidExamples is how many invalid rows a validation report lists
+const maxInvalidExamples = 10
+
+// stateBounds returns the bounding box of state's boundary as [min_lng,
+// min_lat, max_lng, max_lat], or nil when the boundary isn't loaded
+func (s *DatasetService) stateBounds(state string) ([]float64, error) {
+	bounds := make([]float64, 4)
+	err := s.db.QueryRow(`
+		SELECT ST_XMin(geometry), ST_YMin(geometry), ST_XMax(geometry), ST_YMax(geometry)
+		FROM us_states
+		WHERE (state_abbr = UPPER($1) OR UPPER(state_name) = UPPER($1)) AND geometry IS NOT NULL
+		LIMIT 1
+	`, state).Scan(&bounds[0], &bounds[1], &bounds[2], &bounds[3])
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state bounds: %w", err)
+	}
+	return bounds, nil
+}
+
+// validateStoredDataset runs the validation pass over the whole file at
+// filePath, which is the upload or its conversion to GeoJSON
+func (s *DatasetService) validateStoredDataset(dataset *models.Dataset, filePath string) *models.DatasetValidationReport {
+	bounds, err := s.stateBounds(dataset.State)
+	if err != nil {
+		slog.Warn("failed to get state bounds for dataset validation", "dataset_id", dataset.ID, "state", dataset.State, "error", err)
+	}
+
+	report := validateFile(filePath, dataset.FieldMapping, bounds, config.Get().Datasets.MaxInvalidPercent)
+	if bounds == nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("no boundary is loaded for state %s, so coordinates were not checked against it", dataset.State))
+	}
+	report.ValidatedAt = time.Now()
+	return report
+}
+
+// validateFile opens filePath, decompressing .gz files, and validates every
+// feature in it. A file that can't be read fails validation.
+func validateFile(filePath string, mapping models.DatasetFieldMapping, bounds []float64, maxInvalidPercent float64) *models.DatasetValidationReport {
+	fail := func(err error) *models.DatasetValidationReport {
+		report := newValidationReport("", bounds, maxInvalidPercent)
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fail(fmt.Errorf("failed to open file: %w", err))
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(filePath, ".gz") {
+		gzReader, err := gzip.NewReader(file)
+		if err != nil {
+			return fail(fmt.Errorf("file is not valid gzip: %w", err))
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
+	features, err := newFeatureReader(reader, filePath, mapping)
+	if err != nil {
+		return fail(err)
+	}
+	return validateFeatures(features, mapping, bounds, maxInvalidPercent)
+}
+
+func newValidationReport(format string, bounds []float64, maxInvalidPercent float64) *models.DatasetValidationReport {
+	return &models.DatasetValidationReport{
+		Format:            format,
+		StateBounds:       bounds,
+		MaxInvalidPercent: maxInvalidPercent,
+		InvalidExamples:   []models.DatasetInvalidRow{},
+		Errors:            []string{},
+		Warnings:          []string{},
+	}
+}
+
+// validateFeatures reads every feature and counts the rows the importer
+// can't use: ones without a Point in range and, when bounds are known, ones
+// outside them. The dataset passes when it is well-formed, has addressable
+// features and no more than maxInvalidPercent of its rows are invalid.
+func validateFeatures(features featureReader, mapping models.DatasetFieldMapping, bounds []float64, maxInvalidPercent float64) *models.DatasetValidationReport {
+	report := newValidationReport(features.Format(), bounds, maxInvalidPercent)
+	invalid := func(reason string) {
+		report.InvalidRows++
+		if len(report.InvalidExamples) < maxInvalidExamples {
+			report.InvalidExamples = append(report.InvalidExamples, models.DatasetInvalidRow{Row: report.FeatureCount, Reason: reason})
+		}
+	}
+	inBounds := func(lng, lat float64) bool {
+		return lng >= bounds[0] && lat >= bounds[1] && lng <= bounds[2] && lat <= bounds[3]
+	}
+
+	pointFeatures := 0
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The rest of the file can't be located after a syntax error
+			report.Errors = append(report.Errors, fmt.Sprintf("malformed %s after %d rows: %v", report.Format, report.FeatureCount, err))
+			break
+		}
+		report.FeatureCount++
+
+		lng, lat, ok := feature.point()
+		switch {
+		case !ok:
+			report.NoGeometry++
+			invalid("no Point geometry")
+		case lng < -180 || lng > 180 || lat < -90 || lat > 90:
+			report.NoGeometry++
+			invalid(fmt.Sprintf("coordinates %g, %g are out of range", lng, lat))
+		case bounds != nil && !inBounds(lng, lat):
+			report.OutOfBounds++
+			if inBounds(lat, lng) {
+				invalid(fmt.Sprintf("coordinates %g, %g look swapped; GeoJSON is longitude first", lng, lat))
+			} else {
+				invalid(fmt.Sprintf("coordinates %g, %g are outside the state", lng, lat))
+			}
+		default:
+			pointFeatures++
+			address := addressFromProperties(feature.Properties, mapping)
+			if address.HouseNumber != "" && address.Street != "" {
+				report.AddressableFeatures++
+			}
+		}
+	}
+
+	if report.FeatureCount > 0 {
+		report.InvalidPercent = math.Round(float64(report.InvalidRows)/float64(report.FeatureCount)*10000) / 100
+	}
+	if len(report.Errors) == 0 {
+		switch {
+		case report.FeatureCount == 0:
+			report.Errors = append(report.Errors, "no features found")
+		case float64(report.InvalidRows)*100 > maxInvalidPercent*float64(report.FeatureCount):
+			report.Errors = append(report.Errors, fmt.Sprintf("%d of %d rows (%g%%) are invalid, more than the %g%% allowed", report.InvalidRows, report.FeatureCount, report.InvalidPercent, maxInvalidPercent))
+		case report.AddressableFeatures == 0:
+			report.Errors = append(report.Errors, "no features have a recognized house number and street property")
+		}
+	}
+	if missing := pointFeatures - report.AddressableFeatures; missing > 0 && report.AddressableFeatures > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d of %d point features lack a house number or street and will be skipped", missing, pointFeatures))
+	}
+	report.Passed = len(report.Errors) == 0
+	return report
+}
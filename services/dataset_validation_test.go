@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ohioBounds is roughly Ohio's bounding box
+var ohioBounds = []float64{-84.82, 38.40, -80.52, 41.98}
+
+func ndjsonFeature(coordinates string) string {
+	return `{"type":"Feature","properties":{"HOUSENUM":"12","ST_NAME":"MAIN ST"},"geometry":{"type":"Point","coordinates":` + coordinates + `}}`
+}
+
+func TestValidateFeatures(t *testing.T) {
+	validate := func(t *testing.T, lines []string, maxInvalidPercent float64) *models.DatasetValidationReport {
+		features, err := newFeatureReader(strings.NewReader(strings.Join(lines, "\n")), "county.ndjson", nil)
+		require.NoError(t, err)
+		return validateFeatures(features, nil, ohioBounds, maxInvalidPercent)
+	}
+
+	t.Run("valid points pass", func(t *testing.T) {
+		r := validate(t, []string{ndjsonFeature("[-83.0, 40.0]"), ndjsonFeature("[-82.5, 39.9]")}, 5)
+		assert.True(t, r.Passed)
+		assert.Equal(t, GeoJSONFormatNDJSON, r.Format)
+		assert.Equal(t, 2, r.FeatureCount)
+		assert.Equal(t, 2, r.AddressableFeatures)
+		assert.Zero(t, r.InvalidRows)
+		assert.Empty(t, r.Errors)
+	})
+
+	t.Run("out of bounds rows over the limit fail", func(t *testing.T) {
+		r := validate(t, []string{
+			ndjsonFeature("[-83.0, 40.0]"),
+			ndjsonFeature("[40.0, -83.0]"),
+			ndjsonFeature("[-118.2, 34.0]"),
+			`{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[]}}`,
+		}, 50)
+		assert.False(t, r.Passed)
+		assert.Equal(t, 4, r.FeatureCount)
+		assert.Equal(t, 3, r.InvalidRows)
+		assert.Equal(t, 2, r.OutOfBounds)
+		assert.Equal(t, 1, r.NoGeometry)
+		assert.Equal(t, 75.0, r.InvalidPercent)
+		require.Len(t, r.InvalidExamples, 3)
+		assert.Equal(t, 2, r.InvalidExamples[0].Row)
+		assert.Contains(t, r.InvalidExamples[0].Reason, "swapped")
+		assert.Contains(t, r.InvalidExamples[1].Reason, "outside the state")
+		assert.Contains(t, r.Errors[0], "more than the 50% allowed")
+	})
+
+	t.Run("invalid rows within the limit pass", func(t *testing.T) {
+		r := validate(t, []string{ndjsonFeature("[-83.0, 40.0]"), ndjsonFeature("[-118.2, 34.0]")}, 50)
+		assert.True(t, r.Passed)
+		assert.Equal(t, 50.0, r.InvalidPercent)
+	})
+
+	t.Run("malformed file fails", func(t *testing.T) {
+		r := validate(t, []string{ndjsonFeature("[-83.0, 40.0]"), `{"type":"Feature",`}, 5)
+		assert.False(t, r.Passed)
+		require.Len(t, r.Errors, 1)
+		assert.Contains(t, r.Errors[0], "malformed NDJSON after 1 rows")
+	})
+}
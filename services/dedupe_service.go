@@ -0,0 +1,470 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// dedupeLockKey is the Postgres advisory lock that keeps multiple API
+	// instances from detecting duplicates at the same time
+	dedupeLockKey = 2273002
+	// DefaultDedupeDistanceMeters is how close two points must be to match
+	// when the run doesn't say
+	DefaultDedupeDistanceMeters = 1.0
+	// dedupeAddressMaxMeters bounds address matches, so the same house
+	// number and street in two towns isn't taken for one address
+	dedupeAddressMaxMeters = 1000.0
+	// dedupeMetersPerDegree sizes the bounding box prefilter for point
+	// matches; a degree of longitude is at least this long below 60° latitude
+	dedupeMetersPerDegree = 55000.0
+)
+
+var (
+	// ErrDedupeRunning is returned when duplicate detection is already running
+	ErrDedupeRunning = errors.New("duplicate detection already running")
+	// ErrDedupeRunNotFound is returned for a run that doesn't exist
+	ErrDedupeRunNotFound = errors.New("duplicate detection run not found")
+)
+
+// dedupeResolutions maps each action to the resolution it records
+var dedupeResolutions = map[string]string{
+	models.DedupeMerge:   "merged",
+	models.DedupeDelete:  "deleted",
+	models.DedupeDismiss: "dismissed",
+}
+
+// DedupeService finds addresses imported twice by overlapping datasets, such
+// as a county upload and a statewide one. Addresses belong to the dataset of
+// their state and county, as in the integrity check.
+type DedupeService struct{}
+
+var Dedupe = &DedupeService{}
+
+// dedupeGroupsSQL sizes each state/county group of addresses and finds the
+// completed dataset it was imported from, if any
+const dedupeGroupsSQL = `
+	CREATE TEMP TABLE dedupe_groups ON COMMIT DROP AS
+	SELECT g.state, g.county_key, g.county, g.size, d.id AS dataset_id
+	FROM (
+		SELECT UPPER(COALESCE(region, '')) AS state, LOWER(county) AS county_key,
+		       MIN(county) AS county, COUNT(*) AS size
+		FROM ohio_addresses
+		WHERE county IS NOT NULL
+		GROUP BY 1, 2
+	) g
+	LEFT JOIN LATERAL (
+		SELECT id FROM datasets
+		WHERE status = 'completed' AND UPPER(state) = g.state AND LOWER(county) = g.county_key
+		ORDER BY processed_at DESC NULLS LAST, id DESC
+		LIMIT 1
+	) d ON true
+`
+
+// dedupeInsertSQL records the pairs matched by the join condition filled in
+// for %s. a and b come from different groups; the side from the smaller
+// group is kept, since a county upload is more specific than a statewide
+// one. Nearest pairs are inserted first, so an address that matches several
+// others is recorded against the closest.
+const dedupeInsertSQL = `
+	INSERT INTO address_duplicates (run_id, match_type, distance_meters, state,
+		keep_address_id, keep_county, keep_dataset_id,
+		duplicate_address_id, duplicate_county, duplicate_dataset_id)
+	SELECT $1, $2, p.distance,
+		CASE WHEN p.keep_a THEN p.a_state ELSE p.b_state END,
+		CASE WHEN p.keep_a THEN p.a_id ELSE p.b_id END,
+		CASE WHEN p.keep_a THEN p.a_county ELSE p.b_county END,
+		CASE WHEN p.keep_a THEN p.a_dataset_id ELSE p.b_dataset_id END,
+		CASE WHEN p.keep_a THEN p.b_id ELSE p.a_id END,
+		CASE WHEN p.keep_a THEN p.b_county ELSE p.a_county END,
+		CASE WHEN p.keep_a THEN p.b_dataset_id ELSE p.a_dataset_id END
+	FROM (
+		SELECT a.id AS a_id, b.id AS b_id,
+		       ST_Distance(a.geom::geography, b.geom::geography) AS distance,
+		       ga.state AS a_state, ga.county AS a_county, ga.dataset_id AS a_dataset_id,
+		       gb.state AS b_state, gb.county AS b_county, gb.dataset_id AS b_dataset_id,
+		       (ga.size, a.id) < (gb.size, b.id) AS keep_a
+		FROM ohio_addresses a
+		JOIN ohio_addresses b ON b.id > a.id AND %s
+		JOIN dedupe_groups ga ON ga.state = UPPER(COALESCE(a.region, '')) AND ga.county_key = LOWER(a.county)
+		JOIN dedupe_groups gb ON gb.state = UPPER(COALESCE(b.region, '')) AND gb.county_key = LOWER(b.county)
+		WHERE (ga.state, ga.county_key) <> (gb.state, gb.county_key)
+		  AND ST_DWithin(a.geom::geography, b.geom::geography, $3::float8)
+	) p
+	ORDER BY p.distance
+	ON CONFLICT (run_id, duplicate_address_id) DO NOTHING
+`
+
+// Join conditions for each match type. Both require the same unit, so
+// apartments sharing a building point aren't matched with each other.
+const (
+	dedupeSameUnit = `LOWER(BTRIM(COALESCE(b.unit, ''))) = LOWER(BTRIM(COALESCE(a.unit, '')))`
+
+	dedupeAddressJoin = `LOWER(BTRIM(b.house_number)) = LOWER(BTRIM(a.house_number))
+		AND BTRIM(a.house_number) <> ''
+		AND normalize_street_name(b.street) = normalize_street_name(a.street)
+		AND ` + dedupeSameUnit
+
+	dedupeGeometryJoin = `b.geom && ST_Expand(a.geom, $4::float8) AND ` + dedupeSameUnit
+)
+
+// Start records a new run and detects duplicates in the background. Points
+// from different datasets within distanceMeters (0 uses the default) are
+// duplicates, as are the same normalized address within 1 km.
+func (s *DedupeService) Start(ctx context.Context, distanceMeters float64, startedBy int) (*models.DedupeRun, error) {
+	if distanceMeters <= 0 {
+		distanceMeters = DefaultDedupeDistanceMeters
+	}
+
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", dedupeLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire duplicate detection lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrDedupeRunning
+	}
+
+	var runID int
+	err = conn.QueryRowContext(ctx, `
+		INSERT INTO address_dedupe_runs (distance_meters, started_by) VALUES ($1, $2) RETURNING id
+	`, distanceMeters, startedBy).Scan(&runID)
+	if err != nil {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", dedupeLockKey)
+		conn.Close()
+		return nil, fmt.Errorf("failed to start duplicate detection run: %w", err)
+	}
+
+	// The lock is held on conn until the run finishes
+	go s.detect(conn, runID, distanceMeters)
+	return s.GetRun(ctx, runID)
+}
+
+// detect fills in the run's duplicates and marks it completed or failed
+func (s *DedupeService) detect(conn *sql.Conn, runID int, distanceMeters float64) {
+	ctx := context.Background()
+	defer conn.Close()
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", dedupeLockKey)
+
+	started := time.Now()
+	found, err := s.findDuplicates(ctx, conn, runID, distanceMeters)
+	if err != nil {
+		slog.Error("duplicate detection failed", "run_id", runID, "error", err)
+		conn.ExecContext(ctx, `
+			UPDATE address_dedupe_runs SET status = 'failed', error_message = $2, finished_at = NOW() WHERE id = $1
+		`, runID, err.Error())
+		return
+	}
+
+	if _, err := conn.ExecContext(ctx, `
+		UPDATE address_dedupe_runs SET status = 'completed', duplicates = $2, finished_at = NOW() WHERE id = $1
+	`, runID, found); err != nil {
+		slog.Error("failed to complete duplicate detection run", "run_id", runID, "error", err)
+		return
+	}
+	slog.Info("duplicate detection completed", "run_id", runID, "duplicates", found, "duration", time.Since(started))
+}
+
+// findDuplicates inserts the run's address matches, then its point matches,
+// and returns how many duplicates were recorded
+func (s *DedupeService) findDuplicates(ctx context.Context, conn *sql.Conn, runID int, distanceMeters float64) (int, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, dedupeGroupsSQL); err != nil {
+		return 0, fmt.Errorf("failed to group addresses by dataset: %w", err)
+	}
+
+	byAddress, err := tx.ExecContext(ctx, fmt.Sprintf(dedupeInsertSQL, dedupeAddressJoin),
+		runID, models.DedupeMatchAddress, dedupeAddressMaxMeters)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find duplicate addresses: %w", err)
+	}
+	byGeometry, err := tx.ExecContext(ctx, fmt.Sprintf(dedupeInsertSQL, dedupeGeometryJoin),
+		runID, models.DedupeMatchGeometry, distanceMeters, distanceMeters/dedupeMetersPerDegree)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find duplicate points: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to save duplicates: %w", err)
+	}
+	addresses, _ := byAddress.RowsAffected()
+	points, _ := byGeometry.RowsAffected()
+	return int(addresses + points), nil
+}
+
+// GetRun returns a run by ID, or the latest run when runID is 0
+func (s *DedupeService) GetRun(ctx context.Context, runID int) (*models.DedupeRun, error) {
+	query := `
+		SELECT id, status, distance_meters, started_by, duplicates, error_message, started_at, finished_at
+		FROM address_dedupe_runs
+	`
+	args := []interface{}{}
+	if runID > 0 {
+		query += ` WHERE id = $1`
+		args = append(args, runID)
+	} else {
+		query += ` ORDER BY started_at DESC, id DESC LIMIT 1`
+	}
+
+	var run models.DedupeRun
+	err := database.DB.QueryRowContext(ctx, query, args...).Scan(&run.ID, &run.Status, &run.DistanceMeters,
+		&run.StartedBy, &run.Duplicates, &run.ErrorMessage, &run.StartedAt, &run.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrDedupeRunNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicate detection run: %w", err)
+	}
+	return &run, nil
+}
+
+// GetReport returns a run (the latest when runID is 0), its duplicates
+// summarized by dataset pair, and one page of the duplicates in the order
+// they were found. status is "open", "resolved" or "" for all. The total
+// counts the duplicates matching status.
+func (s *DedupeService) GetReport(ctx context.Context, runID int, status string, limit, offset int) (*models.DedupeReport, int, error) {
+	run, err := s.GetRun(ctx, runID)
+	if err != nil {
+		return nil, 0, err
+	}
+	report := &models.DedupeReport{Run: *run, Overlaps: []models.DedupeDatasetOverlap{}, Duplicates: []models.AddressDuplicate{}}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT state, keep_county, duplicate_county, COUNT(*), COUNT(*) FILTER (WHERE resolution IS NULL)
+		FROM address_duplicates
+		WHERE run_id = $1
+		GROUP BY state, keep_county, duplicate_county
+		ORDER BY COUNT(*) DESC, state, keep_county, duplicate_county
+	`, run.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to summarize duplicates: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o models.DedupeDatasetOverlap
+		if err := rows.Scan(&o.State, &o.KeepCounty, &o.DuplicateCounty, &o.Duplicates, &o.Open); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan duplicate summary: %w", err)
+		}
+		report.Overlaps = append(report.Overlaps, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to summarize duplicates: %w", err)
+	}
+
+	where := `d.run_id = $1`
+	switch status {
+	case "open":
+		where += ` AND d.resolution IS NULL`
+	case "resolved":
+		where += ` AND d.resolution IS NOT NULL`
+	}
+
+	var total int
+	if err := database.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM address_duplicates d WHERE `+where, run.ID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count duplicates: %w", err)
+	}
+
+	rows, err = database.DB.QueryContext(ctx, `
+		SELECT d.id, d.run_id, d.match_type, d.distance_meters, d.resolution, d.resolved_at,
+		       d.keep_address_id, d.keep_dataset_id, d.state, d.keep_county,
+		       k.house_number, k.street, k.unit, k.city, k.postcode, ST_Y(k.geom), ST_X(k.geom),
+		       d.duplicate_address_id, d.duplicate_dataset_id, d.state, d.duplicate_county,
+		       x.house_number, x.street, x.unit, x.city, x.postcode, ST_Y(x.geom), ST_X(x.geom)
+		FROM address_duplicates d
+		LEFT JOIN ohio_addresses k ON k.id = d.keep_address_id
+		LEFT JOIN ohio_addresses x ON x.id = d.duplicate_address_id
+		WHERE `+where+`
+		ORDER BY d.id
+		LIMIT $2 OFFSET $3
+	`, run.ID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get duplicates: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d models.AddressDuplicate
+		addressFields := func(a *models.DedupeAddress) []interface{} {
+			return []interface{}{&a.AddressID, &a.DatasetID, &a.State, &a.County,
+				&a.HouseNumber, &a.Street, &a.Unit, &a.City, &a.Postcode, &a.Latitude, &a.Longitude}
+		}
+		dest := []interface{}{&d.ID, &d.RunID, &d.MatchType, &d.DistanceMeters, &d.Resolution, &d.ResolvedAt}
+		dest = append(dest, addressFields(&d.Keep)...)
+		dest = append(dest, addressFields(&d.Duplicate)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan duplicate: %w", err)
+		}
+		report.Duplicates = append(report.Duplicates, d)
+	}
+	return report, total, rows.Err()
+}
+
+// Resolve applies action to the open duplicates in ids, or to every open
+// duplicate of run runID when ids is empty. Merging and deleting skip
+// duplicates whose kept address is gone, so an address is never deleted in
+// favor of one that no longer exists. Deleted addresses are taken off the
+// record count of the dataset they came from.
+func (s *DedupeService) Resolve(ctx context.Context, action string, ids []int64, runID int, resolvedBy int) (*models.DedupeActionResult, error) {
+	resolution, ok := dedupeResolutions[action]
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q", action)
+	}
+	result := &models.DedupeActionResult{Action: action}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT d.id, d.keep_address_id, d.duplicate_address_id FROM address_duplicates d WHERE d.resolution IS NULL`
+	var scope interface{} = pq.Array(ids)
+	if len(ids) > 0 {
+		query += ` AND d.id = ANY($1)`
+	} else {
+		query += ` AND d.run_id = $1`
+		scope = runID
+	}
+	if action != models.DedupeDismiss {
+		query += ` AND EXISTS (SELECT 1 FROM ohio_addresses WHERE id = d.keep_address_id)`
+	}
+	rows, err := tx.QueryContext(ctx, query+` ORDER BY d.id FOR UPDATE OF d`, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duplicates: %w", err)
+	}
+	var duplicateIDs, keepAddressIDs, duplicateAddressIDs pq.Int64Array
+	for rows.Next() {
+		var id, keepID, duplicateID int64
+		if err := rows.Scan(&id, &keepID, &duplicateID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan duplicate: %w", err)
+		}
+		duplicateIDs = append(duplicateIDs, id)
+		keepAddressIDs = append(keepAddressIDs, keepID)
+		duplicateAddressIDs = append(duplicateAddressIDs, duplicateID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get duplicates: %w", err)
+	}
+	if len(duplicateIDs) == 0 {
+		return result, nil
+	}
+
+	if action == models.DedupeMerge {
+		merged, err := tx.ExecContext(ctx, `
+			UPDATE ohio_addresses k SET
+				unit = COALESCE(NULLIF(k.unit, ''), x.unit),
+				city = COALESCE(NULLIF(k.city, ''), x.city),
+				postcode = COALESCE(NULLIF(k.postcode, ''), x.postcode),
+				district = COALESCE(NULLIF(k.district, ''), x.district)
+			FROM unnest($1::bigint[], $2::bigint[]) AS pair(keep_id, duplicate_id)
+			JOIN ohio_addresses x ON x.id = pair.duplicate_id
+			WHERE k.id = pair.keep_id
+			  AND ((COALESCE(k.unit, '') = '' AND COALESCE(x.unit, '') <> '')
+			    OR (COALESCE(k.city, '') = '' AND COALESCE(x.city, '') <> '')
+			    OR (COALESCE(k.postcode, '') = '' AND COALESCE(x.postcode, '') <> '')
+			    OR (COALESCE(k.district, '') = '' AND COALESCE(x.district, '') <> ''))
+		`, keepAddressIDs, duplicateAddressIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge addresses: %w", err)
+		}
+		n, _ := merged.RowsAffected()
+		result.AddressesMerged = int(n)
+	}
+
+	if action != models.DedupeDismiss {
+		deleted, err := s.deleteAddresses(ctx, tx, duplicateAddressIDs)
+		if err != nil {
+			return nil, err
+		}
+		result.AddressesDeleted = deleted
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE address_duplicates SET resolution = $2, resolved_by = $3, resolved_at = NOW() WHERE id = ANY($1)
+	`, duplicateIDs, resolution, resolvedBy); err != nil {
+		return nil, fmt.Errorf("failed to resolve duplicates: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to resolve duplicates: %w", err)
+	}
+	result.Resolved = len(duplicateIDs)
+
+	if result.AddressesDeleted > 0 || result.AddressesMerged > 0 {
+		// County details include address counts
+		markReferenceDataModified()
+	}
+	return result, nil
+}
+
+// deleteAddresses deletes addresses and takes them off the record count of
+// the latest completed dataset for their state and county, so the integrity
+// check doesn't report them missing
+func (s *DedupeService) deleteAddresses(ctx context.Context, tx *sql.Tx, ids pq.Int64Array) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM ohio_addresses WHERE id = ANY($1)
+			RETURNING UPPER(COALESCE(region, '')) AS state, LOWER(COALESCE(county, '')) AS county_key
+		)
+		SELECT state, county_key, COUNT(*) FROM deleted GROUP BY state, county_key
+	`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete duplicate addresses: %w", err)
+	}
+	type countyDeletes struct {
+		state, countyKey string
+		count            int
+	}
+	var counties []countyDeletes
+	for rows.Next() {
+		var c countyDeletes
+		if err := rows.Scan(&c.state, &c.countyKey, &c.count); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan deleted addresses: %w", err)
+		}
+		counties = append(counties, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to delete duplicate addresses: %w", err)
+	}
+
+	deleted := 0
+	for _, c := range counties {
+		deleted += c.count
+		if c.countyKey == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE datasets SET record_count = GREATEST(record_count - $3, 0), updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM datasets
+				WHERE status = 'completed' AND UPPER(state) = $1 AND LOWER(county) = $2
+				ORDER BY processed_at DESC NULLS LAST, id DESC
+				LIMIT 1
+			)
+		`, c.state, c.countyKey, c.count); err != nil {
+			return 0, fmt.Errorf("failed to update dataset record count: %w", err)
+		}
+	}
+	return deleted, nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"math"
 
@@ -8,14 +9,62 @@ import (
 	"geocoding-api/models"
 )
 
-// DistanceResponse represents the response for distance calculations
+// Distance modes for DistanceResponse.Mode
+const (
+	DistanceModeStraightLine = "straight_line"
+	DistanceModeDriving      = "driving"
+)
+
+// Distance units for DistanceResponse.Unit
+const (
+	DistanceUnitMiles         = "mi"
+	DistanceUnitKilometers    = "km"
+	DistanceUnitNauticalMiles = "nmi"
+)
+
+// distanceUnitsPerMile converts miles to each distance unit
+var distanceUnitsPerMile = map[string]float64{
+	DistanceUnitMiles:         1,
+	DistanceUnitKilometers:    1.60934,
+	DistanceUnitNauticalMiles: 0.868976,
+}
+
+// ValidDistanceUnit reports whether unit is mi, km or nmi
+func ValidDistanceUnit(unit string) bool {
+	_, ok := distanceUnitsPerMile[unit]
+	return ok
+}
+
+// DistanceResponse represents the response for distance calculations. The
+// straight-line distance is always set, in miles, kilometers and Unit;
+// Driving is set in driving mode.
 type DistanceResponse struct {
-	FromZipCode  string  `json:"from_zip_code"`
-	ToZipCode    string  `json:"to_zip_code"`
+	FromZipCode   string  `json:"from_zip_code"`
+	ToZipCode     string  `json:"to_zip_code"`
+	Mode          string  `json:"mode"`
+	Distance      float64 `json:"distance"`
+	Unit          string  `json:"unit"`
 	DistanceMiles float64 `json:"distance_miles"`
 	DistanceKm    float64 `json:"distance_km"`
+	// InitialBearing is the great-circle heading at the from ZIP code, in
+	// degrees clockwise from true north
+	InitialBearing float64            `json:"initial_bearing"`
+	Midpoint       models.Coordinates `json:"midpoint"`
+	// BBox is the [minLng, minLat, maxLng, maxLat] box around both ZIP codes
+	BBox    []float64     `json:"bbox"`
+	Driving *RouteSummary `json:"driving,omitempty"`
+}
+
+// SetUnit reports Distance in unit, which must be a valid distance unit
+func (r *DistanceResponse) SetUnit(unit string) {
+	r.Unit = unit
+	r.Distance = r.DistanceMiles * distanceUnitsPerMile[unit]
 }
 
+// earthRadiusMiles is the mean radius of the Earth used for haversine
+// distances
+const earthRadiusMiles = 3959.0
+
 // RadiusSearchResult represents a ZIP code with its distance from center
 type RadiusSearchResult struct {
 	ZipCode       *models.ZipCode `json:"zip_code"`
@@ -24,58 +73,121 @@ type RadiusSearchResult struct {
 }
 
 // CalculateDistanceBetweenZipCodes calculates the distance between two ZIP codes
-func CalculateDistanceBetweenZipCodes(fromZip, toZip string) (*DistanceResponse, error) {
-	// Get coordinates for both ZIP codes
-	fromZipCode, err := GetZipCodeByZip(fromZip)
+func CalculateDistanceBetweenZipCodes(ctx context.Context, fromZip, toZip string) (*DistanceResponse, error) {
+	fromZipCode, toZipCode, err := getZipCodePair(ctx, fromZip, toZip)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get from ZIP code: %w", err)
+		return nil, err
+	}
+	return straightLineDistance(fromZip, toZip, fromZipCode, toZipCode), nil
+}
+
+// CalculateDrivingDistanceBetweenZipCodes adds the road distance and
+// duration between two ZIP codes' centers to their straight-line distance
+func CalculateDrivingDistanceBetweenZipCodes(ctx context.Context, fromZip, toZip string) (*DistanceResponse, error) {
+	fromZipCode, toZipCode, err := getZipCodePair(ctx, fromZip, toZip)
+	if err != nil {
+		return nil, err
+	}
+
+	route, err := Routing.Route(ctx, fromZipCode.Latitude, fromZipCode.Longitude, toZipCode.Latitude, toZipCode.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	result := straightLineDistance(fromZip, toZip, fromZipCode, toZipCode)
+	result.Mode = DistanceModeDriving
+	result.Driving = route
+	return result, nil
+}
+
+// getZipCodePair looks up both ends of a distance calculation
+func getZipCodePair(ctx context.Context, fromZip, toZip string) (*models.ZipCode, *models.ZipCode, error) {
+	fromZipCode, err := getZipCodeCentroid(ctx, fromZip)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get from ZIP code: %w", err)
 	}
 	if fromZipCode == nil {
-		return nil, fmt.Errorf("from ZIP code %s not found", fromZip)
+		return nil, nil, fmt.Errorf("from ZIP code %s not found", fromZip)
 	}
 
-	toZipCode, err := GetZipCodeByZip(toZip)
+	toZipCode, err := getZipCodeCentroid(ctx, toZip)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get to ZIP code: %w", err)
+		return nil, nil, fmt.Errorf("failed to get to ZIP code: %w", err)
 	}
 	if toZipCode == nil {
-		return nil, fmt.Errorf("to ZIP code %s not found", toZip)
+		return nil, nil, fmt.Errorf("to ZIP code %s not found", toZip)
 	}
+	return fromZipCode, toZipCode, nil
+}
 
-	// Calculate distance
-	distanceMiles := haversineDistance(
-		fromZipCode.Latitude, fromZipCode.Longitude,
-		toZipCode.Latitude, toZipCode.Longitude,
-	)
+// getZipCodeCentroid looks up a ZIP code for distance math: from the
+// in-memory ZIP centroid index, or the database when it isn't indexed
+func getZipCodeCentroid(ctx context.Context, zipCode string) (*models.ZipCode, error) {
+	if zc := ZipCentroids.Get(zipCode); zc != nil {
+		return zc, nil
+	}
+	return GetZipCodeByZip(ctx, zipCode)
+}
+
+// straightLineDistance is the haversine distance between two ZIP codes
+func straightLineDistance(fromZip, toZip string, from, to *models.ZipCode) *DistanceResponse {
+	distanceMiles := haversineDistance(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
 
-	return &DistanceResponse{
-		FromZipCode:   fromZip,
-		ToZipCode:     toZip,
-		DistanceMiles: distanceMiles,
-		DistanceKm:    distanceMiles * 1.60934, // Convert miles to kilometers
-	}, nil
+	result := &DistanceResponse{
+		FromZipCode:    fromZip,
+		ToZipCode:      toZip,
+		Mode:           DistanceModeStraightLine,
+		DistanceMiles:  distanceMiles,
+		DistanceKm:     distanceMiles * 1.60934, // Convert miles to kilometers
+		InitialBearing: initialBearing(from.Latitude, from.Longitude, to.Latitude, to.Longitude),
+		Midpoint:       greatCircleMidpoint(from.Latitude, from.Longitude, to.Latitude, to.Longitude),
+		BBox: []float64{
+			math.Min(from.Longitude, to.Longitude), math.Min(from.Latitude, to.Latitude),
+			math.Max(from.Longitude, to.Longitude), math.Max(from.Latitude, to.Latitude),
+		},
+	}
+	result.SetUnit(DistanceUnitMiles)
+	return result
 }
 
 // FindZipCodesWithinRadius finds all ZIP codes within a specified radius of a center ZIP code
-func FindZipCodesWithinRadius(centerZip string, radiusMiles float64, limit int) ([]*RadiusSearchResult, error) {
+func FindZipCodesWithinRadius(ctx context.Context, centerZip string, radiusMiles float64, limit int) ([]*RadiusSearchResult, error) {
 	// Get center ZIP code coordinates
-	centerZipCode, err := GetZipCodeByZip(centerZip)
+	centerZipCode, err := getZipCodeCentroid(ctx, centerZip)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get center ZIP code: %w", err)
 	}
 	if centerZipCode == nil {
 		return nil, fmt.Errorf("center ZIP code %s not found", centerZip)
 	}
+	if results, ok := ZipCentroids.WithinRadius(centerZipCode, radiusMiles, limit); ok {
+		return results, nil
+	}
+
+	return queryZipCodesWithinRadius(ctx, centerZipCode.Latitude, centerZipCode.Longitude, radiusMiles, limit, centerZip)
+}
+
+// FindZipCodesNearPoint finds the ZIP codes whose centers are within
+// radiusMiles of a point, nearest first
+func FindZipCodesNearPoint(ctx context.Context, lat, lng, radiusMiles float64, limit int) ([]*RadiusSearchResult, error) {
+	if results, ok := ZipCentroids.NearPoint(lat, lng, radiusMiles, limit); ok {
+		return results, nil
+	}
+	return queryZipCodesWithinRadius(ctx, lat, lng, radiusMiles, limit, "")
+}
 
+// queryZipCodesWithinRadius finds the ZIP codes within radiusMiles of a
+// point in the database, leaving out exclude
+func queryZipCodesWithinRadius(ctx context.Context, lat, lng, radiusMiles float64, limit int, exclude string) ([]*RadiusSearchResult, error) {
 	// Calculate bounding box for efficient querying
 	// This creates a rough square around the center point to limit database results
 	latDelta := radiusMiles / 69.0 // Approximate miles per degree of latitude
-	lngDelta := radiusMiles / (69.0 * math.Cos(centerZipCode.Latitude*math.Pi/180.0)) // Adjust for longitude
+	lngDelta := radiusMiles / (69.0 * math.Cos(lat*math.Pi/180.0)) // Adjust for longitude
 
-	minLat := centerZipCode.Latitude - latDelta
-	maxLat := centerZipCode.Latitude + latDelta
-	minLng := centerZipCode.Longitude - lngDelta
-	maxLng := centerZipCode.Longitude + lngDelta
+	minLat := lat - latDelta
+	maxLat := lat + latDelta
+	minLng := lng - lngDelta
+	maxLng := lng + lngDelta
 
 	// Query database with bounding box filter
 	query := `
@@ -92,8 +204,8 @@ func FindZipCodesWithinRadius(centerZip string, radiusMiles float64, limit int)
 		LIMIT $8
 	`
 
-	rows, err := database.DB.Query(query, minLat, maxLat, minLng, maxLng, centerZip, 
-		centerZipCode.Latitude, centerZipCode.Longitude, limit*3) // Get more than needed for precise filtering
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, minLat, maxLat, minLng, maxLng, exclude,
+		lat, lng, limit*3) // Get more than needed for precise filtering
 	if err != nil {
 		return nil, fmt.Errorf("failed to query ZIP codes: %w", err)
 	}
@@ -113,10 +225,7 @@ func FindZipCodesWithinRadius(centerZip string, radiusMiles float64, limit int)
 		}
 
 		// Calculate precise distance using Haversine formula
-		distance := haversineDistance(
-			centerZipCode.Latitude, centerZipCode.Longitude,
-			zc.Latitude, zc.Longitude,
-		)
+		distance := haversineDistance(lat, lng, zc.Latitude, zc.Longitude)
 
 		// Only include if within the specified radius
 		if distance <= radiusMiles {
@@ -137,8 +246,8 @@ func FindZipCodesWithinRadius(centerZip string, radiusMiles float64, limit int)
 }
 
 // IsZipCodeWithinRadius checks if one ZIP code is within a specified radius of another
-func IsZipCodeWithinRadius(centerZip, targetZip string, radiusMiles float64) (bool, float64, error) {
-	distance, err := CalculateDistanceBetweenZipCodes(centerZip, targetZip)
+func IsZipCodeWithinRadius(ctx context.Context, centerZip, targetZip string, radiusMiles float64) (bool, float64, error) {
+	distance, err := CalculateDistanceBetweenZipCodes(ctx, centerZip, targetZip)
 	if err != nil {
 		return false, 0, err
 	}
@@ -149,8 +258,6 @@ func IsZipCodeWithinRadius(centerZip, targetZip string, radiusMiles float64) (bo
 // haversineDistance calculates the distance between two points on Earth using the Haversine formula
 // Returns distance in miles
 func haversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
-	const earthRadiusMiles = 3959.0
-
 	// Convert degrees to radians
 	lat1Rad := lat1 * math.Pi / 180.0
 	lng1Rad := lng1 * math.Pi / 180.0
@@ -170,4 +277,34 @@ func haversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
 
 	// Distance in miles
 	return earthRadiusMiles * c
-}
\ No newline at end of file
+}
+
+// initialBearing is the great-circle heading from the first point towards
+// the second, in degrees clockwise from true north (0 to 360)
+func initialBearing(lat1, lng1, lat2, lng2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180.0
+	lat2Rad := lat2 * math.Pi / 180.0
+	deltaLng := (lng2 - lng1) * math.Pi / 180.0
+
+	y := math.Sin(deltaLng) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLng)
+	return math.Mod(math.Atan2(y, x)*180.0/math.Pi+360, 360)
+}
+
+// greatCircleMidpoint is the point halfway along the great circle between
+// two points, which lies poleward of the midpoint of their coordinates
+func greatCircleMidpoint(lat1, lng1, lat2, lng2 float64) models.Coordinates {
+	lat1Rad := lat1 * math.Pi / 180.0
+	lng1Rad := lng1 * math.Pi / 180.0
+	lat2Rad := lat2 * math.Pi / 180.0
+	deltaLng := (lng2 - lng1) * math.Pi / 180.0
+
+	bx := math.Cos(lat2Rad) * math.Cos(deltaLng)
+	by := math.Cos(lat2Rad) * math.Sin(deltaLng)
+	lat := math.Atan2(math.Sin(lat1Rad)+math.Sin(lat2Rad), math.Sqrt((math.Cos(lat1Rad)+bx)*(math.Cos(lat1Rad)+bx)+by*by))
+	lng := lng1Rad + math.Atan2(by, math.Cos(lat1Rad)+bx)
+
+	// Keep the longitude in [-180, 180) when the path crosses the antimeridian
+	lngDeg := math.Mod(lng*180.0/math.Pi+540, 360) - 180
+	return models.Coordinates{Lat: lat * 180.0 / math.Pi, Lng: lngDeg}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitialBearing(t *testing.T) {
+	assert.InDelta(t, 0, initialBearing(40, -83, 41, -83), 1e-9)
+	assert.InDelta(t, 90, initialBearing(0, 0, 0, 10), 1e-9)
+	assert.InDelta(t, 180, initialBearing(41, -83, 40, -83), 1e-9)
+	assert.InDelta(t, 270, initialBearing(0, 10, 0, 0), 1e-9)
+	// Heading west from New York the great circle starts north of due west
+	assert.InDelta(t, 273.7, initialBearing(40.75066, -73.9967, 34.0901, -118.4065), 0.1)
+}
+
+func TestGreatCircleMidpoint(t *testing.T) {
+	mid := greatCircleMidpoint(0, 0, 0, 90)
+	assert.InDelta(t, 0, mid.Lat, 1e-9)
+	assert.InDelta(t, 45, mid.Lng, 1e-9)
+
+	// The great circle bows towards the pole
+	mid = greatCircleMidpoint(40, -120, 40, -80)
+	assert.Greater(t, mid.Lat, 40.0)
+	assert.InDelta(t, -100, mid.Lng, 1e-9)
+
+	// Longitude wraps across the antimeridian
+	mid = greatCircleMidpoint(10, 170, 10, -160)
+	assert.InDelta(t, -175, mid.Lng, 1e-9)
+}
+
+func TestStraightLineDistanceUnits(t *testing.T) {
+	from := &models.ZipCode{Latitude: 39.9612, Longitude: -83.0040}
+	to := &models.ZipCode{Latitude: 41.4993, Longitude: -81.6944}
+
+	result := straightLineDistance("43215", "44114", from, to)
+	assert.Equal(t, DistanceUnitMiles, result.Unit)
+	assert.Equal(t, result.DistanceMiles, result.Distance)
+	assert.Equal(t, []float64{-83.0040, 39.9612, -81.6944, 41.4993}, result.BBox)
+
+	result.SetUnit(DistanceUnitNauticalMiles)
+	assert.Equal(t, DistanceUnitNauticalMiles, result.Unit)
+	assert.InDelta(t, result.DistanceMiles*0.868976, result.Distance, 1e-9)
+
+	assert.True(t, ValidDistanceUnit(DistanceUnitKilometers))
+	assert.False(t, ValidDistanceUnit("ft"))
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrUnknownDistrictType is returned for a district type with no layer
+var ErrUnknownDistrictType = errors.New("unknown district type")
+
+// districtSource is a TIGER/Line district layer converted to GeoJSON, e.g.
+// with ogr2ogr -f GeoJSON tl_2025_39_sldu.geojson tl_2025_39_sldu.shp
+type districtSource struct {
+	districtType string
+	// pattern matches the layer's files, without the .geojson extension
+	pattern string
+	// codeProperty is the attribute holding the district code. It is a
+	// path.Match pattern because the congressional attribute is named for
+	// the Congress, e.g. CD119FP.
+	codeProperty string
+}
+
+// districtSources are the layers the districts dataset is loaded from, in
+// the order lookups list them
+var districtSources = []districtSource{
+	{models.DistrictCongressional, "tl_*_cd[0-9]*", "CD*FP"},
+	{models.DistrictStateUpper, "tl_*_sldu", "SLDUST"},
+	{models.DistrictStateLower, "tl_*_sldl", "SLDLST"},
+	{models.DistrictSchoolUnified, "tl_*_unsd", "UNSDLEA"},
+	{models.DistrictSchoolElementary, "tl_*_elsd", "ELSDLEA"},
+	{models.DistrictSchoolSecondary, "tl_*_scsd", "SCSDLEA"},
+}
+
+// DistrictTypes lists the district types in lookup order
+func DistrictTypes() []string {
+	types := make([]string, len(districtSources))
+	for i, source := range districtSources {
+		types[i] = source.districtType
+	}
+	return types
+}
+
+// DistrictService looks up the districts containing a point
+type DistrictService struct{}
+
+var Districts = &DistrictService{}
+
+// files returns the layer's GeoJSON files
+func (s districtSource) files() ([]string, error) {
+	return globDataFiles(s.pattern)
+}
+
+// districtFromProperties reads a district from the TIGER/Line attributes of
+// one of source's features
+func districtFromProperties(source districtSource, props map[string]interface{}) (models.District, error) {
+	text := func(key string) string {
+		value, _ := props[key].(string)
+		return value
+	}
+
+	district := models.District{
+		Type:      source.districtType,
+		GEOID:     text("GEOID"),
+		StateFIPS: text("STATEFP"),
+		Name:      text("NAMELSAD"),
+	}
+	if district.Name == "" {
+		district.Name = text("NAME")
+	}
+	for key := range props {
+		if matched, _ := path.Match(source.codeProperty, key); matched {
+			district.Code = text(key)
+			break
+		}
+	}
+
+	if district.GEOID == "" || len(district.StateFIPS) != 2 || district.Code == "" {
+		return models.District{}, fmt.Errorf("%s district %q is missing GEOID, STATEFP or %s",
+			source.districtType, district.GEOID, source.codeProperty)
+	}
+	return district, nil
+}
+
+// loadDistricts upserts every district layer that has a file, and fails
+// only when there are none
+func loadDistricts(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO districts (
+			district_type, geoid, state_fips, code, name, area_land, area_water, geom
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7,
+			ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($8), 4326))
+		)
+		ON CONFLICT (district_type, geoid) DO UPDATE SET
+			code = EXCLUDED.code,
+			name = EXCLUDED.name,
+			area_land = EXCLUDED.area_land,
+			area_water = EXCLUDED.area_water,
+			geom = EXCLUDED.geom,
+			updated_at = NOW()
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var loaded []string
+	for _, source := range districtSources {
+		files, err := source.files()
+		if err != nil {
+			return fmt.Errorf("failed to find %s district files: %w", source.districtType, err)
+		}
+		for _, file := range files {
+			if err := loadDistrictFile(ctx, stmt, run, source, file); err != nil {
+				return err
+			}
+			loaded = append(loaded, file)
+		}
+	}
+
+	if len(loaded) == 0 {
+		patterns := make([]string, len(districtSources))
+		for i, source := range districtSources {
+			patterns[i] = source.pattern + ".geojson"
+		}
+		return fmt.Errorf("no district files found (looked for %s)", strings.Join(patterns, ", "))
+	}
+	run.setSource(strings.Join(loaded, ", "))
+	return nil
+}
+
+// loadDistrictFile upserts the districts in one layer file
+func loadDistrictFile(ctx context.Context, stmt *sql.Stmt, run *loadRun, source districtSource, file string) error {
+	reader, _, err := openDataFile(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	features, err := newGeoJSONFeatureReader(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	for {
+		feature, err := features.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		district, err := districtFromProperties(source, feature.Properties)
+		if err != nil {
+			slog.Warn("skipping invalid district", "file", file, "error", err)
+			run.record(rowInvalid)
+			continue
+		}
+		if feature.Geometry.Type != "Polygon" && feature.Geometry.Type != "MultiPolygon" {
+			slog.Warn("skipping district without a polygon", "file", file, "geoid", district.GEOID)
+			run.record(rowInvalid)
+			continue
+		}
+		geometryJSON, err := json.Marshal(feature.Geometry)
+		if err != nil {
+			run.record(rowInvalid)
+			continue
+		}
+
+		areaLand, _ := feature.Properties["ALAND"].(float64)
+		areaWater, _ := feature.Properties["AWATER"].(float64)
+		outcome, err := upsertRow(ctx, stmt,
+			district.Type, district.GEOID, district.StateFIPS, district.Code, district.Name,
+			int64(areaLand), int64(areaWater), string(geometryJSON),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert %s district %s: %w", district.Type, district.GEOID, err)
+		}
+		run.record(outcome)
+	}
+}
+
+// LookupDistricts returns the districts containing a point, limited to
+// types when any are given, in districtSources order
+func (s *DistrictService) LookupDistricts(ctx context.Context, lat, lng float64, types []string) ([]models.District, error) {
+	for _, t := range types {
+		if !isDistrictType(t) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownDistrictType, t)
+		}
+	}
+	if len(types) == 0 {
+		types = DistrictTypes()
+	}
+
+	query := `
+		SELECT district_type, geoid, state_fips, code, name
+		FROM districts
+		WHERE ST_Covers(geom, ST_SetSRID(ST_MakePoint($2, $1), 4326))
+		  AND district_type = ANY($3)
+		ORDER BY array_position($4::text[], district_type::text), geoid
+	`
+
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, lat, lng, pq.Array(types), pq.Array(DistrictTypes()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up districts: %w", err)
+	}
+	defer rows.Close()
+
+	districts := []models.District{}
+	for rows.Next() {
+		var d models.District
+		if err := rows.Scan(&d.Type, &d.GEOID, &d.StateFIPS, &d.Code, &d.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan district: %w", err)
+		}
+		districts = append(districts, d)
+	}
+	return districts, rows.Err()
+}
+
+// isDistrictType reports whether t names a district layer
+func isDistrictType(t string) bool {
+	for _, source := range districtSources {
+		if source.districtType == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistrictFromProperties(t *testing.T) {
+	congressional := districtSources[0]
+	require.Equal(t, models.DistrictCongressional, congressional.districtType)
+
+	district, err := districtFromProperties(congressional, map[string]interface{}{
+		"STATEFP":  "39",
+		"CD119FP":  "03",
+		"GEOID":    "3903",
+		"NAMELSAD": "Congressional District 3",
+		"CDSESSN":  "119",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.District{
+		Type:      models.DistrictCongressional,
+		GEOID:     "3903",
+		StateFIPS: "39",
+		Code:      "03",
+		Name:      "Congressional District 3",
+	}, district)
+
+	t.Run("school district falls back to NAME", func(t *testing.T) {
+		unified := districtSources[3]
+		district, err := districtFromProperties(unified, map[string]interface{}{
+			"STATEFP": "39",
+			"UNSDLEA": "04380",
+			"GEOID":   "3904380",
+			"NAME":    "Columbus City School District",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "04380", district.Code)
+		assert.Equal(t, "Columbus City School District", district.Name)
+	})
+
+	t.Run("missing code", func(t *testing.T) {
+		_, err := districtFromProperties(districtSources[1], map[string]interface{}{
+			"STATEFP": "39",
+			"GEOID":   "39015",
+		})
+		assert.Error(t, err)
+	})
+}
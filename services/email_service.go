@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"geocoding-api/config"
+)
+
+// EmailMessage is a plain-text email
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email
+type Mailer interface {
+	Send(msg EmailMessage) error
+}
+
+// SMTPMailer sends email through an SMTP relay using STARTTLS and PLAIN auth
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send implements Mailer
+func (m *SMTPMailer) Send(msg EmailMessage) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	headers := []string{
+		"From: " + m.From,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(msg.Body, "\n", "\r\n")
+
+	if err := smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, emailAddress(m.From), []string{msg.To}, []byte(body)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// logMailer writes email to the log instead of sending it, for local
+// development without an SMTP relay
+type logMailer struct{}
+
+// Send implements Mailer
+func (logMailer) Send(msg EmailMessage) error {
+	slog.Info("SMTP_HOST not set, email not sent", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// NewMailer returns an SMTPMailer for the configured SMTP relay, or a mailer
+// that only logs when no SMTP host is configured
+func NewMailer() Mailer {
+	email := config.Get().Email
+	if email.SMTPHost == "" {
+		return logMailer{}
+	}
+
+	return &SMTPMailer{
+		Host:     email.SMTPHost,
+		Port:     strconv.Itoa(email.SMTPPort),
+		Username: email.SMTPUsername,
+		Password: email.SMTPPassword,
+		From:     email.From,
+	}
+}
+
+// AppURL returns the web app origin used in links sent by email
+func AppURL() string {
+	return strings.TrimRight(config.Get().Email.AppBaseURL, "/")
+}
+
+// emailAddress extracts the bare address from a "Name <addr>" header value
+func emailAddress(from string) string {
+	if start := strings.LastIndex(from, "<"); start >= 0 {
+		if end := strings.LastIndex(from, ">"); end > start {
+			return from[start+1 : end]
+		}
+	}
+	return from
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+var (
+	// ErrEmailVerificationTokenInvalid is returned for unknown or expired verification tokens
+	ErrEmailVerificationTokenInvalid = errors.New("invalid or expired verification token")
+	// ErrEmailAlreadyVerified is returned when resending to a verified address
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// verificationResendCooldown is how long after a verification email is sent
+// before the user can ask for another
+const verificationResendCooldown = 2 * time.Minute
+
+// VerificationResendWait returns how long the user must wait before another
+// verification email can be sent, or 0 when one can be sent now. Only the
+// newest token is kept, so its age is when the last email went out.
+func (as *AuthService) VerificationResendWait(ctx context.Context, userID int) (time.Duration, error) {
+	var wait sql.NullFloat64
+	if err := database.DB.QueryRowContext(ctx, `
+		SELECT EXTRACT(EPOCH FROM MAX(created_at) + $2 * INTERVAL '1 second' - NOW())
+		FROM email_verification_tokens
+		WHERE user_id = $1
+	`, userID, verificationResendCooldown.Seconds()).Scan(&wait); err != nil {
+		return 0, fmt.Errorf("failed to check recent verification emails: %w", err)
+	}
+	if !wait.Valid || wait.Float64 <= 0 {
+		return 0, nil
+	}
+	return time.Duration(wait.Float64 * float64(time.Second)), nil
+}
+
+// SendVerificationEmail emails user a link that verifies their address.
+// Any earlier link stops working.
+func (as *AuthService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+	lifetime := config.Get().Auth.EmailVerificationTokenLifetime
+
+	if _, err := database.DB.ExecContext(ctx, `DELETE FROM email_verification_tokens WHERE user_id = $1`, user.ID); err != nil {
+		return fmt.Errorf("failed to clear old verification tokens: %w", err)
+	}
+	if _, err := database.DB.ExecContext(ctx, `
+		INSERT INTO email_verification_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, user.ID, hashToken(token), time.Now().Add(lifetime)); err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	link := AppURL() + "/auth/verify-email?token=" + url.QueryEscape(token)
+	return NewMailer().Send(EmailMessage{
+		To:      user.Email,
+		Subject: "Verify your GeoCode API email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Thanks for signing up for GeoCode API. Confirm your email address to start creating API keys:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s. If you didn't create an account, you can ignore this email.\n",
+			user.Name, link, lifetime),
+	})
+}
+
+// VerifyEmail marks the owner of a verification token as verified and
+// consumes the token
+func (as *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM email_verification_tokens
+		WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING user_id
+	`, hashToken(token)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return ErrEmailVerificationTokenInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up verification token: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_verification_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear verification tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email verification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import "geocoding-api/config"
+
+// defaultEndpointCosts weighs requests that do far more work than a single
+// lookup. Keys are routes without the version prefix, optionally preceded by
+// a method; routes not listed cost 1.
+var defaultEndpointCosts = map[string]int{
+	"POST /geocode/jobs":               100,
+	"POST /counties/contains/batch":    10,
+	"POST /addresses/within":           5,
+	"POST /corridor":                   5,
+	"GET /counties/:name/boundary":     5,
+	"GET /states/:identifier/boundary": 5,
+	"GET /coverage/drive-time":         5,
+}
+
+// EndpointCost returns how many calls a request to route counts as against
+// quotas: its ENDPOINT_COSTS override, its built-in cost, or 1. A
+// method-specific entry wins over one for the whole route.
+func EndpointCost(method, route string) int {
+	return endpointCost(config.Get().Billing.EndpointCosts, method, route)
+}
+
+func endpointCost(overrides map[string]int, method, route string) int {
+	keys := []string{method + " " + route, route}
+	for _, costs := range []map[string]int{overrides, defaultEndpointCosts} {
+		for _, key := range keys {
+			if cost, ok := costs[key]; ok {
+				return cost
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCost(t *testing.T) {
+	assert.Equal(t, 1, endpointCost(nil, "GET", "/geocode/:zipcode"))
+	assert.Equal(t, 100, endpointCost(nil, "POST", "/geocode/jobs"))
+	assert.Equal(t, 1, endpointCost(nil, "GET", "/geocode/jobs"), "only creating a job is weighted")
+	assert.Equal(t, 5, endpointCost(nil, "GET", "/states/:identifier/boundary"))
+
+	overrides := map[string]int{
+		"/coverage/drive-time":       1,
+		"/tiles/:layer/:z/:x/:y":     2,
+		"GET /tiles/:layer/:z/:x/:y": 3,
+	}
+	assert.Equal(t, 1, endpointCost(overrides, "GET", "/coverage/drive-time"), "an override wins over the built-in cost")
+	assert.Equal(t, 3, endpointCost(overrides, "GET", "/tiles/:layer/:z/:x/:y"), "a method entry wins over the route's")
+	assert.Equal(t, 2, endpointCost(overrides, "HEAD", "/tiles/:layer/:z/:x/:y"))
+	assert.Equal(t, 100, endpointCost(overrides, "POST", "/geocode/jobs"))
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// flagCacheTTL controls how often flag definitions are reloaded from the database
+const flagCacheTTL = 30 * time.Second
+
+// FeatureFlagService evaluates DB-backed feature flags with an in-memory cache
+type FeatureFlagService struct {
+	mu       sync.RWMutex
+	flags    map[string]models.FeatureFlag
+	loadedAt time.Time
+}
+
+var Flags = &FeatureFlagService{}
+
+// loadFlags refreshes the cache if it is stale
+func (f *FeatureFlagService) loadFlags() (map[string]models.FeatureFlag, error) {
+	f.mu.RLock()
+	if f.flags != nil && time.Since(f.loadedAt) < flagCacheTTL {
+		flags := f.flags
+		f.mu.RUnlock()
+		return flags, nil
+	}
+	f.mu.RUnlock()
+
+	// The cache is shared, so reloading it is not tied to whichever request
+	// happened to find it stale
+	list, err := f.ListFlags(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]models.FeatureFlag, len(list))
+	for _, flag := range list {
+		flags[flag.Key] = flag
+	}
+
+	f.mu.Lock()
+	f.flags = flags
+	f.loadedAt = time.Now()
+	f.mu.Unlock()
+
+	return flags, nil
+}
+
+// invalidate forces the next evaluation to reload flags
+func (f *FeatureFlagService) invalidate() {
+	f.mu.Lock()
+	f.flags = nil
+	f.mu.Unlock()
+}
+
+// IsEnabled reports whether a flag is on for the given API key. Unknown flags
+// and lookup errors evaluate to false so the existing implementation is used.
+func (f *FeatureFlagService) IsEnabled(key string, apiKeyID int) bool {
+	flags, err := f.loadFlags()
+	if err != nil {
+		return false
+	}
+
+	flag, ok := flags[key]
+	if !ok || !flag.Enabled {
+		return false
+	}
+
+	for _, id := range flag.APIKeyIDs {
+		if int(id) == apiKeyID {
+			return true
+		}
+	}
+
+	return rolloutBucket(key, apiKeyID) < flag.RolloutPercent
+}
+
+// rolloutBucket deterministically maps a key/flag pair to 0-99 so the same API
+// key sees consistent behavior for a given rollout percentage
+func rolloutBucket(flagKey string, apiKeyID int) int {
+	h := fnv.New32a()
+	h.Write([]byte(flagKey + ":" + strconv.Itoa(apiKeyID)))
+	return int(h.Sum32() % 100)
+}
+
+// ListFlags returns all feature flags
+func (f *FeatureFlagService) ListFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT key, COALESCE(description, ''), enabled, rollout_percent, api_key_ids, created_at, updated_at
+		FROM feature_flags
+		ORDER BY key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.FeatureFlag{}
+	for rows.Next() {
+		var flag models.FeatureFlag
+		if err := rows.Scan(&flag.Key, &flag.Description, &flag.Enabled, &flag.RolloutPercent,
+			pq.Array(&flag.APIKeyIDs), &flag.CreatedAt, &flag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// UpsertFlag creates or updates a feature flag
+func (f *FeatureFlagService) UpsertFlag(ctx context.Context, flag models.FeatureFlag) (*models.FeatureFlag, error) {
+	if flag.Key == "" {
+		return nil, fmt.Errorf("flag key is required")
+	}
+	if flag.RolloutPercent < 0 || flag.RolloutPercent > 100 {
+		return nil, fmt.Errorf("rollout_percent must be between 0 and 100")
+	}
+	if flag.APIKeyIDs == nil {
+		flag.APIKeyIDs = []int64{}
+	}
+
+	err := database.DB.QueryRowContext(ctx, `
+		INSERT INTO feature_flags (key, description, enabled, rollout_percent, api_key_ids)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO UPDATE SET
+			description = EXCLUDED.description,
+			enabled = EXCLUDED.enabled,
+			rollout_percent = EXCLUDED.rollout_percent,
+			api_key_ids = EXCLUDED.api_key_ids,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`, flag.Key, flag.Description, flag.Enabled, flag.RolloutPercent, pq.Array(flag.APIKeyIDs)).
+		Scan(&flag.CreatedAt, &flag.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save feature flag: %w", err)
+	}
+
+	f.invalidate()
+	return &flag, nil
+}
+
+// DeleteFlag removes a feature flag
+func (f *FeatureFlagService) DeleteFlag(ctx context.Context, key string) error {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("feature flag not found")
+	}
+
+	f.invalidate()
+	return nil
+}
+
+// GetFlagStats compares usage recorded with a flag on versus off over the last N days
+func (f *FeatureFlagService) GetFlagStats(ctx context.Context, key string, days int) ([]models.FeatureFlagStats, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT
+			CASE WHEN (feature_flags->>$1)::boolean THEN 'on' ELSE 'off' END as variant,
+			COUNT(*),
+			COALESCE(AVG(response_time_ms), 0),
+			COALESCE(AVG(CASE WHEN status_code >= 400 THEN 1.0 ELSE 0.0 END), 0)
+		FROM usage_records
+		WHERE feature_flags ? $1
+		AND created_at >= CURRENT_DATE - $2 * INTERVAL '1 day'
+		GROUP BY variant
+		ORDER BY variant DESC
+	`, key, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.FeatureFlagStats{}
+	for rows.Next() {
+		var s models.FeatureFlagStats
+		if err := rows.Scan(&s.Variant, &s.Requests, &s.AvgResponseTimeMs, &s.ErrorRate); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
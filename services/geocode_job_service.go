@@ -0,0 +1,670 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// geocodeJobChunkSize is how many rows a worker geocodes between progress updates
+	geocodeJobChunkSize = 100
+	// geocodeJobPollInterval is how often idle workers look for queued jobs
+	// created by another instance
+	geocodeJobPollInterval = 5 * time.Second
+)
+
+var (
+	// ErrGeocodeJobNotFound is returned when a job doesn't exist or belongs to another user
+	ErrGeocodeJobNotFound = errors.New("geocode job not found")
+	// ErrGeocodeJobEmpty is returned when an upload contains no addresses
+	ErrGeocodeJobEmpty = errors.New("no addresses found in upload")
+	// ErrGeocodeJobTooLarge is returned when an upload has more rows than allowed
+	ErrGeocodeJobTooLarge = errors.New("upload has too many addresses")
+	// ErrGeocodeJobFinished is returned when cancelling a job that already stopped
+	ErrGeocodeJobFinished = errors.New("geocode job has already finished")
+)
+
+// Column names read as a full address, or combined from components, when a
+// CSV upload is split into parts
+var (
+	geocodeJobAddressColumns   = []string{"address", "full_address", "query", "q"}
+	geocodeJobComponentColumns = []string{"house_number", "street", "city", "state", "zip", "postcode"}
+)
+
+// GeocodeJobService runs bulk geocoding jobs in the background. Jobs and
+// their rows live in the database, so workers on any instance can pick them
+// up and a restart resumes unfinished rows. Subscribers are woken whenever
+// a job makes progress.
+type GeocodeJobService struct {
+	mu          sync.Mutex
+	running     bool
+	wake        chan struct{}
+	stop        chan struct{}
+	wg          sync.WaitGroup
+	subscribers map[int]map[chan struct{}]struct{}
+}
+
+var GeocodeJobs = &GeocodeJobService{
+	subscribers: make(map[int]map[chan struct{}]struct{}),
+}
+
+const geocodeJobFields = `id, user_id, api_key_id, COALESCE(filename, ''), status, total_rows, processed_rows,
+	matched_rows, failed_rows, COALESCE(error, ''), created_at, started_at, completed_at`
+
+func scanGeocodeJob(scanner interface{ Scan(...interface{}) error }) (*models.GeocodeJob, error) {
+	var job models.GeocodeJob
+	var apiKeyID sql.NullInt64
+	var startedAt, completedAt sql.NullTime
+	err := scanner.Scan(&job.ID, &job.UserID, &apiKeyID, &job.Filename, &job.Status, &job.TotalRows,
+		&job.ProcessedRows, &job.MatchedRows, &job.FailedRows, &job.Error, &job.CreatedAt, &startedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if apiKeyID.Valid {
+		id := int(apiKeyID.Int64)
+		job.APIKeyID = &id
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
+
+// MaxRows returns the most addresses accepted in one job
+func (s *GeocodeJobService) MaxRows() int {
+	return config.Get().Workers.GeocodeJobMaxRows
+}
+
+// Start launches the configured number of job workers. Jobs left running by
+// a previous process are queued again first.
+func (s *GeocodeJobService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+
+	if result, err := database.DB.Exec(`UPDATE geocode_jobs SET status = 'queued' WHERE status = 'running'`); err != nil {
+		slog.Error("failed to requeue interrupted geocode jobs", "error", err)
+	} else if n, _ := result.RowsAffected(); n > 0 {
+		slog.Info("requeued interrupted geocode jobs", "count", n)
+	}
+
+	s.wake = make(chan struct{}, 1)
+	s.stop = make(chan struct{})
+	s.running = true
+
+	workers := config.Get().Workers.GeocodeJobWorkers
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+}
+
+// Stop tells the workers to stop after their current chunk and waits for
+// them, or for ctx to end. Unfinished jobs resume on the next Start.
+func (s *GeocodeJobService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stopping returns a channel that is closed when Stop is called, so
+// long-lived progress streams can end before the server shuts down
+func (s *GeocodeJobService) Stopping() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop
+}
+
+// ReadQueries reads the addresses to geocode from an upload. CSV files need
+// a header row with an address column (address, full_address, query or q)
+// or address components (house_number, street, city, state, zip or
+// postcode); any other file is read as one address per line.
+func (s *GeocodeJobService) ReadQueries(r io.Reader, filename string) ([]string, error) {
+	var queries []string
+	add := func(query string) error {
+		query = strings.TrimSpace(query)
+		if query == "" {
+			return nil
+		}
+		if len(queries) >= s.MaxRows() {
+			return ErrGeocodeJobTooLarge
+		}
+		queries = append(queries, query)
+		return nil
+	}
+
+	if !isCSVFile(filename) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if err := add(scanner.Text()); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+	} else {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+
+		header, err := reader.Read()
+		if err == io.EOF {
+			return nil, ErrGeocodeJobEmpty
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+
+		columns := geocodeJobColumns(header)
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("CSV header must include an address column (%s) or address components (%s)",
+				strings.Join(geocodeJobAddressColumns, ", "), strings.Join(geocodeJobComponentColumns, ", "))
+		}
+
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CSV row: %w", err)
+			}
+
+			var parts []string
+			for _, i := range columns {
+				if i < len(record) && strings.TrimSpace(record[i]) != "" {
+					parts = append(parts, strings.TrimSpace(record[i]))
+				}
+			}
+			if err := add(strings.Join(parts, " ")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(queries) == 0 {
+		return nil, ErrGeocodeJobEmpty
+	}
+	return queries, nil
+}
+
+// geocodeJobColumns returns the indexes of the CSV columns that make up an
+// address: a single address column if present, otherwise the components in
+// address order
+func geocodeJobColumns(header []string) []int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range geocodeJobAddressColumns {
+		if i, ok := index[name]; ok {
+			return []int{i}
+		}
+	}
+
+	var columns []int
+	for _, name := range geocodeJobComponentColumns {
+		if i, ok := index[name]; ok {
+			columns = append(columns, i)
+		}
+	}
+	return columns
+}
+
+// CreateJob stores a job with one row per query and queues it
+func (s *GeocodeJobService) CreateJob(ctx context.Context, userID int, apiKeyID *int, filename string, queries []string) (*models.GeocodeJob, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	job, err := scanGeocodeJob(tx.QueryRowContext(ctx, `
+		INSERT INTO geocode_jobs (user_id, api_key_id, filename, total_rows)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING `+geocodeJobFields,
+		userID, apiKeyID, filename, len(queries)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geocode job: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO geocode_job_items (job_id, row_number, query)
+		SELECT $1, q.n, q.query
+		FROM unnest($2::text[]) WITH ORDINALITY AS q(query, n)
+	`, job.ID, pq.Array(queries))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store geocode job rows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit geocode job: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.running {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	s.mu.Unlock()
+
+	return job, nil
+}
+
+// GetJob returns one of the user's jobs
+func (s *GeocodeJobService) GetJob(ctx context.Context, userID, jobID int) (*models.GeocodeJob, error) {
+	job, err := scanGeocodeJob(database.DB.QueryRowContext(ctx, `
+		SELECT `+geocodeJobFields+` FROM geocode_jobs WHERE id = $1 AND user_id = $2
+	`, jobID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrGeocodeJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geocode job: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns the user's jobs, newest first, with the total count
+func (s *GeocodeJobService) ListJobs(ctx context.Context, userID, limit, offset int) ([]models.GeocodeJob, int, error) {
+	var total int
+	if err := database.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM geocode_jobs WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count geocode jobs: %w", err)
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+geocodeJobFields+` FROM geocode_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list geocode jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.GeocodeJob{}
+	for rows.Next() {
+		job, err := scanGeocodeJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan geocode job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, total, rows.Err()
+}
+
+// GetResults returns up to limit finished rows of a job numbered after
+// afterRow, in row order. Rows finish in order, so the last row returned is
+// a cursor for the next call.
+func (s *GeocodeJobService) GetResults(ctx context.Context, jobID, afterRow, limit int) ([]models.GeocodeJobResult, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT row_number, query, status, COALESCE(match_level, ''), address_id,
+			COALESCE(full_address, ''), latitude, longitude, COALESCE(error, '')
+		FROM geocode_job_items
+		WHERE job_id = $1 AND row_number > $2 AND status <> 'pending'
+		ORDER BY row_number
+		LIMIT $3
+	`, jobID, afterRow, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geocode job results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.GeocodeJobResult{}
+	for rows.Next() {
+		var r models.GeocodeJobResult
+		var addressID sql.NullInt64
+		var lat, lng sql.NullFloat64
+		if err := rows.Scan(&r.Row, &r.Query, &r.Status, &r.MatchLevel, &addressID,
+			&r.FullAddress, &lat, &lng, &r.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan geocode job result: %w", err)
+		}
+		if addressID.Valid {
+			r.AddressID = &addressID.Int64
+		}
+		if lat.Valid && lng.Valid {
+			r.Latitude, r.Longitude = &lat.Float64, &lng.Float64
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// CancelJob stops a queued or running job. Rows already geocoded are kept.
+func (s *GeocodeJobService) CancelJob(ctx context.Context, userID, jobID int) (*models.GeocodeJob, error) {
+	job, err := scanGeocodeJob(database.DB.QueryRowContext(ctx, `
+		UPDATE geocode_jobs SET status = 'cancelled', completed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status IN ('queued', 'running')
+		RETURNING `+geocodeJobFields,
+		jobID, userID))
+	if err == sql.ErrNoRows {
+		if _, err := s.GetJob(ctx, userID, jobID); err != nil {
+			return nil, err
+		}
+		return nil, ErrGeocodeJobFinished
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel geocode job: %w", err)
+	}
+
+	s.notify(jobID)
+	return job, nil
+}
+
+// Subscribe returns a channel that receives a signal whenever the job makes
+// progress, and a function to unsubscribe. Signals coalesce, so subscribers
+// should re-read the job rather than count them.
+func (s *GeocodeJobService) Subscribe(jobID int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	if s.subscribers[jobID] == nil {
+		s.subscribers[jobID] = make(map[chan struct{}]struct{})
+	}
+	s.subscribers[jobID][ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers[jobID], ch)
+		if len(s.subscribers[jobID]) == 0 {
+			delete(s.subscribers, jobID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// notify wakes the job's subscribers
+func (s *GeocodeJobService) notify(jobID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// work claims and processes queued jobs until Stop is called
+func (s *GeocodeJobService) work() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(geocodeJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		beat("geocode_jobs", geocodeJobPollInterval)
+		job, err := s.claimJob()
+		if err != nil {
+			slog.Error("failed to claim geocode job", "error", err)
+		}
+		if job != nil {
+			s.processJob(job)
+			continue
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimJob marks the oldest queued job as running and returns it, or nil
+// when none are queued
+func (s *GeocodeJobService) claimJob() (*models.GeocodeJob, error) {
+	job, err := scanGeocodeJob(database.DB.QueryRow(`
+		UPDATE geocode_jobs SET status = 'running', started_at = COALESCE(started_at, NOW())
+		WHERE id = (
+			SELECT id FROM geocode_jobs
+			WHERE status = 'queued'
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + geocodeJobFields))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// processJob geocodes the job's pending rows a chunk at a time until they
+// are done, the job is cancelled or the service stops
+func (s *GeocodeJobService) processJob(job *models.GeocodeJob) {
+	logger := slog.With("geocode_job_id", job.ID, "user_id", job.UserID)
+	logger.Info("processing geocode job", "total_rows", job.TotalRows, "processed_rows", job.ProcessedRows)
+	s.notify(job.ID)
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		beat("geocode_jobs", geocodeJobPollInterval)
+		processed, status, err := s.processChunk(job.ID)
+		if err != nil {
+			logger.Error("geocode job failed", "error", err)
+			if _, err := database.DB.Exec(`
+				UPDATE geocode_jobs SET status = 'failed', error = $2, completed_at = NOW()
+				WHERE id = $1 AND status = 'running'
+			`, job.ID, err.Error()); err != nil {
+				logger.Error("failed to mark geocode job failed", "error", err)
+			}
+			s.notify(job.ID)
+			return
+		}
+		if status != models.GeocodeJobRunning {
+			logger.Info("geocode job stopped", "status", status)
+			return
+		}
+		if processed == 0 {
+			break
+		}
+		s.notify(job.ID)
+	}
+
+	completed, err := scanGeocodeJob(database.DB.QueryRow(`
+		UPDATE geocode_jobs SET status = 'completed', completed_at = NOW()
+		WHERE id = $1 AND status = 'running'
+		RETURNING `+geocodeJobFields, job.ID))
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		logger.Error("failed to complete geocode job", "error", err)
+		return
+	}
+	s.notify(job.ID)
+	logger.Info("geocode job completed", "matched_rows", completed.MatchedRows, "failed_rows", completed.FailedRows)
+
+	if err := Webhooks.Emit(completed.UserID, models.WebhookEventGeocodeJobCompleted, "", map[string]interface{}{
+		"job_id":       completed.ID,
+		"filename":     completed.Filename,
+		"total_rows":   completed.TotalRows,
+		"matched_rows": completed.MatchedRows,
+		"failed_rows":  completed.FailedRows,
+	}); err != nil {
+		logger.Warn("failed to queue webhook", "event", models.WebhookEventGeocodeJobCompleted, "error", err)
+	}
+}
+
+// processChunk geocodes the next pending rows of a job and records the
+// results. It returns how many rows it processed and the job's status
+// afterwards; zero rows means the job has none left.
+func (s *GeocodeJobService) processChunk(jobID int) (int, string, error) {
+	rows, err := database.DB.Query(`
+		SELECT row_number, query FROM geocode_job_items
+		WHERE job_id = $1 AND status = 'pending'
+		ORDER BY row_number
+		LIMIT $2
+	`, jobID, geocodeJobChunkSize)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read pending rows: %w", err)
+	}
+
+	var results []models.GeocodeJobResult
+	for rows.Next() {
+		var r models.GeocodeJobResult
+		if err := rows.Scan(&r.Row, &r.Query); err != nil {
+			rows.Close()
+			return 0, "", fmt.Errorf("failed to scan pending row: %w", err)
+		}
+		results = append(results, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("failed to read pending rows: %w", err)
+	}
+	if len(results) == 0 {
+		return 0, models.GeocodeJobRunning, nil
+	}
+
+	matched, failed := 0, 0
+	for i := range results {
+		geocodeJobRow(&results[i])
+		switch results[i].Status {
+		case models.GeocodeItemMatched:
+			matched++
+		case models.GeocodeItemError:
+			failed++
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, r := range results {
+		_, err := tx.Exec(`
+			UPDATE geocode_job_items
+			SET status = $3, match_level = NULLIF($4, ''), address_id = $5, full_address = NULLIF($6, ''),
+				latitude = $7, longitude = $8, error = NULLIF($9, '')
+			WHERE job_id = $1 AND row_number = $2
+		`, jobID, r.Row, r.Status, r.MatchLevel, r.AddressID, r.FullAddress, r.Latitude, r.Longitude, r.Error)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to store geocode result: %w", err)
+		}
+	}
+
+	var status string
+	err = tx.QueryRow(`
+		UPDATE geocode_jobs
+		SET processed_rows = processed_rows + $2, matched_rows = matched_rows + $3, failed_rows = failed_rows + $4
+		WHERE id = $1
+		RETURNING status
+	`, jobID, len(results), matched, failed).Scan(&status)
+	if err == sql.ErrNoRows {
+		// The job was deleted along with its owner's account
+		return 0, models.GeocodeJobCancelled, nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to update geocode job progress: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, "", fmt.Errorf("failed to commit geocode results: %w", err)
+	}
+	return len(results), status, nil
+}
+
+// geocodeJobRow geocodes one row with the same search as
+// GET /addresses/search, keeping the best match
+func geocodeJobRow(r *models.GeocodeJobResult) {
+	// Not tied to a request: Stop waits for the current chunk to finish
+	result, err := Address.FullTextSearchAddresses(context.Background(), r.Query, 1)
+	if err != nil {
+		r.Status = models.GeocodeItemError
+		r.Error = "search failed"
+		slog.Warn("geocode job row failed", "query", r.Query, "error", err)
+		return
+	}
+
+	r.Status = models.GeocodeItemMatched
+	switch {
+	case len(result.Addresses) > 0:
+		a := result.Addresses[0]
+		r.MatchLevel = models.GeocodeMatchAddress
+		if result.ExactCount == 0 {
+			r.MatchLevel = models.GeocodeMatchNearby
+		}
+		r.AddressID = &a.ID
+		r.FullAddress = a.FullAddress
+		r.Latitude, r.Longitude = &a.Latitude, &a.Longitude
+	case len(result.Interpolated) > 0:
+		ia := result.Interpolated[0]
+		r.MatchLevel = models.GeocodeMatchInterpolated
+		r.FullAddress = ia.HouseNumber + " " + ia.Street
+		if ia.Postcode != "" {
+			r.FullAddress += ", " + ia.Postcode
+		}
+		r.Latitude, r.Longitude = &ia.Latitude, &ia.Longitude
+	case len(result.Streets) > 0:
+		st := result.Streets[0]
+		r.MatchLevel = models.GeocodeMatchStreet
+		r.FullAddress = strings.Join([]string{st.Street, st.City, st.Postcode}, ", ")
+		r.Latitude, r.Longitude = &st.Latitude, &st.Longitude
+	case result.CountyMatch != nil:
+		r.MatchLevel = models.GeocodeMatchCounty
+		r.FullAddress = result.CountyMatch.CountyName + " County"
+		r.Latitude, r.Longitude = &result.CountyMatch.Latitude, &result.CountyMatch.Longitude
+	default:
+		r.Status = models.GeocodeItemNotFound
+	}
+}
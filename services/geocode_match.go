@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// Base scores by match type. A rooftop match loses points for components it
+// couldn't confirm; centroids are guesses and score lowest.
+const (
+	scoreRooftop        = 100
+	scoreInterpolated   = 75
+	scoreStreet         = 60
+	scorePostcode       = 35
+	scoreLocality       = 25
+	scoreZipCentroid    = 30
+	scoreCountyCentroid = 15
+
+	// scoreUnanchored is taken off a match with no city, ZIP code or county to
+	// confirm the street is the one meant
+	scoreUnanchored = 10
+	// scoreFuzzyStreet is taken off a match on a misspelled street
+	scoreFuzzyStreet = 15
+)
+
+// addressComponentOrder is the order matched components are listed in
+var addressComponentOrder = []string{"house_number", "street", "city", "county", "state", "postcode", "location"}
+
+// sortComponents orders and deduplicates matched components
+func sortComponents(components []string) []string {
+	sorted := []string{}
+	for _, component := range addressComponentOrder {
+		for _, c := range components {
+			if c == component {
+				sorted = append(sorted, component)
+				break
+			}
+		}
+	}
+	return sorted
+}
+
+func hasComponent(components []string, component string) bool {
+	for _, c := range components {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// addressPointMatch scores an address point by the components of the query
+// it matched. fuzzy means the street only matched as a misspelling.
+func addressPointMatch(components []string, fuzzy bool) *models.Match {
+	components = sortComponents(components)
+	anchored := hasComponent(components, "city") || hasComponent(components, "county") ||
+		hasComponent(components, "postcode")
+
+	match := &models.Match{MatchedComponents: components, Source: models.MatchSourceAddresses}
+	switch {
+	case hasComponent(components, "location"):
+		match.MatchType, match.Score = models.MatchTypeRooftop, scoreRooftop
+	case hasComponent(components, "house_number") && hasComponent(components, "street"):
+		match.MatchType, match.Score = models.MatchTypeRooftop, scoreRooftop
+	case hasComponent(components, "street"):
+		match.MatchType, match.Score = models.MatchTypeStreet, scoreStreet
+	case hasComponent(components, "postcode"):
+		match.MatchType, match.Score = models.MatchTypePostcode, scorePostcode
+	default:
+		match.MatchType, match.Score = models.MatchTypeLocality, scoreLocality
+	}
+	if hasComponent(components, "street") {
+		if !anchored {
+			match.Score -= scoreUnanchored
+		}
+		if fuzzy {
+			match.Score -= scoreFuzzyStreet
+		}
+	}
+	return match
+}
+
+// reverseMatch scores the address point nearest a location: full marks
+// within 10 meters, one point less for every further 10 meters, down to the
+// locality score
+func reverseMatch(distanceMeters float64) *models.Match {
+	score := scoreRooftop
+	if distanceMeters > 10 {
+		score = max(scoreRooftop-int((distanceMeters-10)/10), scoreLocality)
+	}
+	return &models.Match{
+		Score:             score,
+		MatchType:         models.MatchTypeRooftop,
+		MatchedComponents: []string{"location"},
+		Source:            models.MatchSourceAddresses,
+	}
+}
+
+// interpolatedMatch describes a position interpolated along an address range
+func interpolatedMatch(city, postcode string, vintage time.Time) *models.Match {
+	components := []string{"house_number", "street"}
+	if city != "" {
+		components = append(components, "city")
+	}
+	if postcode != "" {
+		components = append(components, "postcode")
+	}
+	return &models.Match{
+		Score:             scoreInterpolated,
+		MatchType:         models.MatchTypeInterpolated,
+		MatchedComponents: components,
+		Source:            models.MatchSourceAddressRanges,
+		DataVintage:       &vintage,
+	}
+}
+
+// streetIndexMatch describes a street matched in the street index
+func streetIndexMatch(city, postcode string, vintage time.Time) *models.Match {
+	components := []string{"street"}
+	if city != "" {
+		components = append(components, "city")
+	}
+	if postcode != "" {
+		components = append(components, "postcode")
+	}
+	match := addressPointMatch(components, false)
+	match.Source = models.MatchSourceStreets
+	match.DataVintage = &vintage
+	return match
+}
+
+// zipCentroidMatch describes a ZIP code's centroid. Imprecise ZIP codes,
+// such as PO box only ones, score lower still.
+func zipCentroidMatch(imprecise bool, vintage *time.Time) *models.Match {
+	score := scoreZipCentroid
+	if imprecise {
+		score -= scoreUnanchored
+	}
+	return &models.Match{
+		Score:             score,
+		MatchType:         models.MatchTypeZipCentroid,
+		MatchedComponents: []string{"postcode"},
+		Source:            models.MatchSourceZipCodes,
+		DataVintage:       vintage,
+	}
+}
+
+// countyCentroidMatch describes a county's centroid
+func countyCentroidMatch(vintage *time.Time) *models.Match {
+	return &models.Match{
+		Score:             scoreCountyCentroid,
+		MatchType:         models.MatchTypeCountyCentroid,
+		MatchedComponents: []string{"county"},
+		Source:            models.MatchSourceCounties,
+		DataVintage:       vintage,
+	}
+}
+
+// queryComponents lists the components a search query supplied
+func queryComponents(houseNumber, street, city, county, state, postcode string) []string {
+	var components []string
+	for component, value := range map[string]string{
+		"house_number": houseNumber, "street": street, "city": city,
+		"county": county, "state": state, "postcode": postcode,
+	} {
+		if strings.TrimSpace(value) != "" {
+			components = append(components, component)
+		}
+	}
+	return sortComponents(components)
+}
+
+// addressDatasets caches the completed dataset behind each state and county
+// of address points, keyed "STATE|county". It isn't purged on import, so a
+// newly imported dataset shows up within the TTL.
+var addressDatasets = newLookupCache[*int]("address_datasets", 10*time.Minute, 5000)
+
+// addressDatasetID returns the latest completed dataset for a state and
+// county, or nil when the addresses weren't uploaded as a dataset
+func addressDatasetID(ctx context.Context, state, county string) (*int, error) {
+	key := strings.ToUpper(state) + "|" + strings.ToLower(county)
+	return addressDatasets.GetOrLoad(key, func() (*int, error) {
+		var id int
+		err := database.ReadDB(ctx).QueryRowContext(ctx, `
+			SELECT id FROM datasets
+			WHERE status = 'completed' AND UPPER(state) = UPPER($1) AND LOWER(county) = LOWER($2)
+			ORDER BY processed_at DESC NULLS LAST, id DESC
+			LIMIT 1
+		`, state, county).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &id, nil
+	})
+}
+
+// setAddressMatch attaches match to an address point with the dataset it
+// was imported from. The address's created_at is its data vintage. A failed
+// dataset lookup only leaves dataset_id out.
+func setAddressMatch(ctx context.Context, addr *models.OhioAddress, match *models.Match) {
+	if !addr.CreatedAt.IsZero() {
+		vintage := addr.CreatedAt
+		match.DataVintage = &vintage
+	}
+	if addr.County != "" && database.DB != nil {
+		datasetID, err := addressDatasetID(ctx, addr.Region, addr.County)
+		if err != nil {
+			slog.Warn("failed to look up address dataset", "state", addr.Region, "county", addr.County, "error", err)
+		}
+		match.DatasetID = datasetID
+	}
+	addr.Match = match
+}
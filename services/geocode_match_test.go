@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressPointMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		components     []string
+		fuzzy          bool
+		wantType       string
+		wantScore      int
+		wantComponents []string
+	}{
+		{
+			name:           "house and street in city is rooftop",
+			components:     []string{"postcode", "street", "house_number", "city"},
+			wantType:       models.MatchTypeRooftop,
+			wantScore:      100,
+			wantComponents: []string{"house_number", "street", "city", "postcode"},
+		},
+		{
+			name:           "house and street alone lose the anchor points",
+			components:     []string{"house_number", "street"},
+			wantType:       models.MatchTypeRooftop,
+			wantScore:      90,
+			wantComponents: []string{"house_number", "street"},
+		},
+		{
+			name:           "misspelled street",
+			components:     []string{"house_number", "street", "city"},
+			fuzzy:          true,
+			wantType:       models.MatchTypeRooftop,
+			wantScore:      85,
+			wantComponents: []string{"house_number", "street", "city"},
+		},
+		{
+			name:           "street without house number",
+			components:     []string{"street", "postcode"},
+			wantType:       models.MatchTypeStreet,
+			wantScore:      60,
+			wantComponents: []string{"street", "postcode"},
+		},
+		{
+			name:           "postcode only",
+			components:     []string{"postcode"},
+			wantType:       models.MatchTypePostcode,
+			wantScore:      35,
+			wantComponents: []string{"postcode"},
+		},
+		{
+			name:           "city only",
+			components:     []string{"city", "city"},
+			wantType:       models.MatchTypeLocality,
+			wantScore:      25,
+			wantComponents: []string{"city"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := addressPointMatch(tt.components, tt.fuzzy)
+			assert.Equal(t, tt.wantType, m.MatchType)
+			assert.Equal(t, tt.wantScore, m.Score)
+			assert.Equal(t, tt.wantComponents, m.MatchedComponents)
+			assert.Equal(t, models.MatchSourceAddresses, m.Source)
+		})
+	}
+}
+
+func TestReverseMatch(t *testing.T) {
+	assert.Equal(t, 100, reverseMatch(4).Score)
+	assert.Equal(t, 90, reverseMatch(110).Score)
+	assert.Equal(t, scoreLocality, reverseMatch(5000).Score)
+	assert.Equal(t, []string{"location"}, reverseMatch(0).MatchedComponents)
+}
+
+func TestCentroidMatches(t *testing.T) {
+	vintage := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	zip := zipCentroidMatch(false, &vintage)
+	assert.Equal(t, models.MatchTypeZipCentroid, zip.MatchType)
+	assert.Equal(t, models.MatchSourceZipCodes, zip.Source)
+	assert.Equal(t, &vintage, zip.DataVintage)
+	assert.Less(t, zipCentroidMatch(true, nil).Score, zip.Score)
+
+	county := countyCentroidMatch(nil)
+	assert.Equal(t, []string{"county"}, county.MatchedComponents)
+	assert.Less(t, county.Score, zip.Score)
+
+	interpolated := interpolatedMatch("", "43215", vintage)
+	assert.Equal(t, []string{"house_number", "street", "postcode"}, interpolated.MatchedComponents)
+	assert.Less(t, interpolated.Score, scoreRooftop)
+	assert.Greater(t, interpolated.Score, streetIndexMatch("Columbus", "", vintage).Score)
+}
+
+func TestSearchParamComponents(t *testing.T) {
+	assert.Empty(t, searchParamComponents(models.AddressSearchParams{Lat: 40, Lng: -83}))
+	assert.Equal(t, []string{"street", "county"},
+		searchParamComponents(models.AddressSearchParams{Street: "Main St", County: "Franklin"}))
+	assert.Equal(t, []string{"house_number", "street", "city", "postcode"},
+		searchParamComponents(models.AddressSearchParams{Query: "123 Main St, Columbus", Postcode: "43215"}))
+}
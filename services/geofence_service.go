@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxGeofencesPerUser caps how many geofences one user may keep
+	maxGeofencesPerUser = 100
+	// maxGeofenceVertices caps the size of one geofence's polygons
+	maxGeofenceVertices = 10000
+)
+
+var (
+	// ErrGeofenceNotFound is returned when a geofence doesn't exist or belongs to another user
+	ErrGeofenceNotFound = errors.New("geofence not found")
+	// ErrGeofenceExists is returned when the user already has a geofence with the name
+	ErrGeofenceExists = errors.New("a geofence with this name already exists")
+	// ErrGeofenceLimit is returned when the user has as many geofences as allowed
+	ErrGeofenceLimit = fmt.Errorf("geofence limit reached (%d per user)", maxGeofencesPerUser)
+	// ErrGeofenceInvalid wraps problems with the submitted name or geometry
+	ErrGeofenceInvalid = errors.New("invalid geofence")
+)
+
+// GeofenceService stores user-defined polygons and tests points, addresses
+// and ZIP codes against them
+type GeofenceService struct{}
+
+var Geofences = &GeofenceService{}
+
+// geofenceFields are the columns scanGeofence reads; the area is converted
+// from square meters to square miles
+const geofenceFields = `id, user_id, name, COALESCE(description, ''),
+	ST_Area(geom::geography) / 2589988.110336, created_at, updated_at`
+
+func scanGeofence(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.Geofence, error) {
+	var g models.Geofence
+	dest := append([]interface{}{&g.ID, &g.UserID, &g.Name, &g.Description, &g.AreaSqMiles,
+		&g.CreatedAt, &g.UpdatedAt}, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// polygonGeometry extracts the Polygon or MultiPolygon from a GeoJSON
+// geometry or Feature, wrapping problems in invalid
+func polygonGeometry(raw json.RawMessage, invalid error) (string, error) {
+	var object struct {
+		Type     string          `json:"type"`
+		Geometry json.RawMessage `json:"geometry"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &object) != nil {
+		return "", fmt.Errorf("%w: geometry must be a GeoJSON object", invalid)
+	}
+	if object.Type == "Feature" {
+		return polygonGeometry(object.Geometry, invalid)
+	}
+	if object.Type != "Polygon" && object.Type != "MultiPolygon" {
+		return "", fmt.Errorf("%w: geometry must be a Polygon or MultiPolygon, got %q", invalid, object.Type)
+	}
+	return string(raw), nil
+}
+
+// validateGeofenceRequest checks the name and returns the trimmed name and
+// the GeoJSON geometry to store
+func validateGeofenceRequest(req models.GeofenceRequest) (string, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 255 {
+		return "", "", fmt.Errorf("%w: name is required and must be at most 255 characters", ErrGeofenceInvalid)
+	}
+	geometry, err := polygonGeometry(req.Geometry, ErrGeofenceInvalid)
+	if err != nil {
+		return "", "", err
+	}
+	return name, geometry, nil
+}
+
+// checkGeometry has PostGIS parse the geometry and rejects invalid
+// geometries or ones with more than maxVertices, wrapped in invalid, so they
+// never reach the table or a query
+func checkGeometry(ctx context.Context, geometry string, maxVertices int, invalid error) error {
+	var valid bool
+	var reason string
+	var vertices int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT ST_IsValid(g), ST_IsValidReason(g), ST_NPoints(g)
+		FROM (SELECT ST_GeomFromGeoJSON($1) AS g) AS parsed
+	`, geometry).Scan(&valid, &reason, &vertices)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return fmt.Errorf("%w: %s", invalid, pqErr.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check geometry: %w", err)
+	}
+	if !valid {
+		return fmt.Errorf("%w: %s", invalid, reason)
+	}
+	if vertices > maxVertices {
+		return fmt.Errorf("%w: geometry has %d vertices, the limit is %d", invalid, vertices, maxVertices)
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// CreateGeofence stores a new geofence for userID
+func (s *GeofenceService) CreateGeofence(ctx context.Context, userID int, req models.GeofenceRequest) (*models.Geofence, error) {
+	name, geometry, err := validateGeofenceRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGeometry(ctx, geometry, maxGeofenceVertices, ErrGeofenceInvalid); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM geofences WHERE user_id = $1", userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count geofences: %w", err)
+	}
+	if count >= maxGeofencesPerUser {
+		return nil, ErrGeofenceLimit
+	}
+
+	var geoJSON string
+	g, err := scanGeofence(database.DB.QueryRowContext(ctx, fmt.Sprintf(`
+		INSERT INTO geofences (user_id, name, description, geom)
+		VALUES ($1, $2, NULLIF($3, ''), ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($4), 4326)))
+		RETURNING %s, ST_AsGeoJSON(geom)
+	`, geofenceFields), userID, name, strings.TrimSpace(req.Description), geometry), &geoJSON)
+	if isUniqueViolation(err) {
+		return nil, ErrGeofenceExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geofence: %w", err)
+	}
+	g.Geometry = json.RawMessage(geoJSON)
+	return g, nil
+}
+
+// UpdateGeofence replaces a geofence's name, description and geometry
+func (s *GeofenceService) UpdateGeofence(ctx context.Context, userID, id int, req models.GeofenceRequest) (*models.Geofence, error) {
+	name, geometry, err := validateGeofenceRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGeometry(ctx, geometry, maxGeofenceVertices, ErrGeofenceInvalid); err != nil {
+		return nil, err
+	}
+
+	var geoJSON string
+	g, err := scanGeofence(database.DB.QueryRowContext(ctx, fmt.Sprintf(`
+		UPDATE geofences
+		SET name = $3, description = NULLIF($4, ''),
+			geom = ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($5), 4326)), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING %s, ST_AsGeoJSON(geom)
+	`, geofenceFields), id, userID, name, strings.TrimSpace(req.Description), geometry), &geoJSON)
+	if err == sql.ErrNoRows {
+		return nil, ErrGeofenceNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrGeofenceExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update geofence: %w", err)
+	}
+	g.Geometry = json.RawMessage(geoJSON)
+	return g, nil
+}
+
+// GetUserGeofences lists userID's geofences by name, without their geometry
+func (s *GeofenceService) GetUserGeofences(ctx context.Context, userID int) ([]*models.Geofence, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM geofences WHERE user_id = $1 ORDER BY name", geofenceFields), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list geofences: %w", err)
+	}
+	defer rows.Close()
+
+	geofences := []*models.Geofence{}
+	for rows.Next() {
+		g, err := scanGeofence(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan geofence: %w", err)
+		}
+		geofences = append(geofences, g)
+	}
+	return geofences, rows.Err()
+}
+
+// GetGeofence returns one of userID's geofences with its geometry
+func (s *GeofenceService) GetGeofence(ctx context.Context, userID, id int) (*models.Geofence, error) {
+	var geoJSON string
+	g, err := scanGeofence(database.DB.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s, ST_AsGeoJSON(geom) FROM geofences WHERE id = $1 AND user_id = $2", geofenceFields),
+		id, userID), &geoJSON)
+	if err == sql.ErrNoRows {
+		return nil, ErrGeofenceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence: %w", err)
+	}
+	g.Geometry = json.RawMessage(geoJSON)
+	return g, nil
+}
+
+// DeleteGeofence removes one of userID's geofences
+func (s *GeofenceService) DeleteGeofence(ctx context.Context, userID, id int) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM geofences WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete geofence: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrGeofenceNotFound
+	}
+	return nil
+}
+
+// Contains reports whether a point is inside one of userID's geofences.
+// Points on the boundary are inside.
+func (s *GeofenceService) Contains(ctx context.Context, userID, id int, lat, lng float64) (*models.GeofenceContainment, error) {
+	result := &models.GeofenceContainment{GeofenceID: id, Lat: lat, Lng: lng}
+	err := database.DB.QueryRowContext(ctx, `
+		WITH point AS (SELECT ST_SetSRID(ST_MakePoint($3, $4), 4326) AS pt)
+		SELECT ST_Covers(g.geom, point.pt), ST_Distance(g.geom::geography, point.pt::geography)
+		FROM geofences g, point
+		WHERE g.id = $1 AND g.user_id = $2
+	`, id, userID, lng, lat).Scan(&result.Inside, &result.DistanceMeters)
+	if err == sql.ErrNoRows {
+		return nil, ErrGeofenceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to test geofence: %w", err)
+	}
+	return result, nil
+}
+
+// GetGeofenceAddresses returns one page of the address points inside a
+// geofence and the total number inside
+func (s *GeofenceService) GetGeofenceAddresses(ctx context.Context, userID, id, limit, offset int) ([]models.OhioAddress, int, error) {
+	if err := s.geofenceExists(ctx, userID, id); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := database.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM ohio_addresses a
+		JOIN geofences g ON ST_Covers(g.geom, a.geom)
+		WHERE g.id = $1
+	`, id).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count geofence addresses: %w", err)
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT a.id, a.hash, a.house_number, a.street, COALESCE(a.unit, ''), COALESCE(a.city, ''),
+			COALESCE(a.district, ''), COALESCE(a.region, ''), COALESCE(a.postcode, ''), COALESCE(a.county, ''),
+			COALESCE(a.full_address, ''), ST_Y(a.geom), ST_X(a.geom), a.created_at
+		FROM ohio_addresses a
+		JOIN geofences g ON ST_Covers(g.geom, a.geom)
+		WHERE g.id = $1
+		ORDER BY a.id
+		LIMIT $2 OFFSET $3
+	`, id, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query geofence addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []models.OhioAddress{}
+	for rows.Next() {
+		var addr models.OhioAddress
+		if err := rows.Scan(
+			&addr.ID, &addr.Hash, &addr.HouseNumber, &addr.Street, &addr.Unit,
+			&addr.City, &addr.District, &addr.Region, &addr.Postcode, &addr.County, &addr.FullAddress,
+			&addr.Latitude, &addr.Longitude, &addr.CreatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, addr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating address rows: %w", err)
+	}
+	return addresses, total, nil
+}
+
+// GetGeofenceZipCodes returns the ZIP codes whose centers lie inside a geofence
+func (s *GeofenceService) GetGeofenceZipCodes(ctx context.Context, userID, id int) ([]models.GeofenceZipCode, error) {
+	if err := s.geofenceExists(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	// The bounding box lets the latitude/longitude index narrow the
+	// candidates before the exact polygon test
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT z.zip_code, z.city_name, z.state_code, z.latitude, z.longitude
+		FROM zip_codes z
+		JOIN geofences g ON g.id = $1
+		WHERE z.latitude BETWEEN ST_YMin(g.geom) AND ST_YMax(g.geom)
+		  AND z.longitude BETWEEN ST_XMin(g.geom) AND ST_XMax(g.geom)
+		  AND ST_Covers(g.geom, ST_SetSRID(ST_MakePoint(z.longitude, z.latitude), 4326))
+		ORDER BY z.zip_code
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query geofence ZIP codes: %w", err)
+	}
+	defer rows.Close()
+
+	zipCodes := []models.GeofenceZipCode{}
+	for rows.Next() {
+		var zc models.GeofenceZipCode
+		if err := rows.Scan(&zc.ZipCode, &zc.CityName, &zc.StateCode, &zc.Latitude, &zc.Longitude); err != nil {
+			return nil, fmt.Errorf("failed to scan ZIP code: %w", err)
+		}
+		zipCodes = append(zipCodes, zc)
+	}
+	return zipCodes, rows.Err()
+}
+
+// geofenceExists checks that id is one of userID's geofences
+func (s *GeofenceService) geofenceExists(ctx context.Context, userID, id int) error {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM geofences WHERE id = $1 AND user_id = $2)", id, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to get geofence: %w", err)
+	}
+	if !exists {
+		return ErrGeofenceNotFound
+	}
+	return nil
+}
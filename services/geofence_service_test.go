@@ -0,0 +1,45 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGeofenceRequest(t *testing.T) {
+	polygon := `{"type":"Polygon","coordinates":[[[-84.53,39.09],[-84.49,39.09],[-84.49,39.12],[-84.53,39.09]]]}`
+
+	tests := []struct {
+		name     string
+		req      models.GeofenceRequest
+		wantGeom string
+		wantErr  bool
+	}{
+		{"polygon", models.GeofenceRequest{Name: " Downtown ", Geometry: json.RawMessage(polygon)}, polygon, false},
+		{"multipolygon", models.GeofenceRequest{Name: "Zone", Geometry: json.RawMessage(`{"type":"MultiPolygon","coordinates":[]}`)},
+			`{"type":"MultiPolygon","coordinates":[]}`, false},
+		{"feature", models.GeofenceRequest{Name: "Zone", Geometry: json.RawMessage(`{"type":"Feature","properties":{},"geometry":` + polygon + `}`)},
+			polygon, false},
+		{"point", models.GeofenceRequest{Name: "Zone", Geometry: json.RawMessage(`{"type":"Point","coordinates":[-84.5,39.1]}`)}, "", true},
+		{"not json", models.GeofenceRequest{Name: "Zone", Geometry: json.RawMessage(`"polygon"`)}, "", true},
+		{"missing geometry", models.GeofenceRequest{Name: "Zone"}, "", true},
+		{"blank name", models.GeofenceRequest{Name: "  ", Geometry: json.RawMessage(polygon)}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, geometry, err := validateGeofenceRequest(tt.req)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrGeofenceInvalid)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEqual(t, ' ', name[0])
+			assert.JSONEq(t, tt.wantGeom, geometry)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// GeoJSON layouts accepted for dataset uploads
+const (
+	GeoJSONFormatFeatureCollection = "FeatureCollection"
+	GeoJSONFormatNDJSON            = "NDJSON"
+)
+
+// geoJSONFeature is a single feature from a county address file. Coordinates
+// are kept raw so non-point geometries don't fail the decode.
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// point returns the longitude and latitude of a Point feature
+func (f *geoJSONFeature) point() (float64, float64, bool) {
+	if f.Geometry.Type != "Point" {
+		return 0, 0, false
+	}
+	var coords []float64
+	if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err != nil || len(coords) < 2 {
+		return 0, 0, false
+	}
+	return coords[0], coords[1], true
+}
+
+// geoJSONFeatureReader reads features one at a time from either a
+// FeatureCollection or newline-delimited Feature objects
+type geoJSONFeatureReader struct {
+	dec     *json.Decoder
+	format  string
+	pending *geoJSONFeature
+	done    bool
+}
+
+// newGeoJSONFeatureReader detects the layout of r and positions the reader
+// at the first feature
+func newGeoJSONFeatureReader(r io.Reader) (*geoJSONFeatureReader, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, 1<<20))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("GeoJSON must start with an object")
+	}
+
+	// Read top-level members until the features array is reached. If the
+	// object closes first it was a lone Feature, i.e. the first NDJSON line.
+	head := map[string]json.RawMessage{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoJSON: %w", err)
+		}
+		key, _ := tok.(string)
+
+		if key == "features" {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read GeoJSON: %w", err)
+			}
+			if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+				return nil, fmt.Errorf("GeoJSON features must be an array")
+			}
+			return &geoJSONFeatureReader{dec: dec, format: GeoJSONFormatFeatureCollection}, nil
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("failed to read GeoJSON: %w", err)
+		}
+		head[key] = value
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON: %w", err)
+	}
+
+	var objectType string
+	json.Unmarshal(head["type"], &objectType)
+	if objectType != "Feature" {
+		return nil, fmt.Errorf("expected a FeatureCollection or newline-delimited Features, got %q", objectType)
+	}
+
+	raw, err := json.Marshal(head)
+	if err != nil {
+		return nil, err
+	}
+	var first geoJSONFeature
+	if err := json.Unmarshal(raw, &first); err != nil {
+		return nil, fmt.Errorf("failed to parse feature: %w", err)
+	}
+	return &geoJSONFeatureReader{dec: dec, format: GeoJSONFormatNDJSON, pending: &first}, nil
+}
+
+// Format reports whether the input is a FeatureCollection or NDJSON
+func (fr *geoJSONFeatureReader) Format() string {
+	return fr.format
+}
+
+// Next returns the next feature, or io.EOF when there are none left
+func (fr *geoJSONFeatureReader) Next() (*geoJSONFeature, error) {
+	if fr.pending != nil {
+		f := fr.pending
+		fr.pending = nil
+		return f, nil
+	}
+	if fr.done {
+		return nil, io.EOF
+	}
+	if !fr.dec.More() {
+		fr.done = true
+		// A FeatureCollection must close its features array; anything else
+		// means the file was cut short
+		if fr.format == GeoJSONFormatFeatureCollection {
+			if _, err := fr.dec.Token(); err != nil {
+				return nil, io.ErrUnexpectedEOF
+			}
+		}
+		return nil, io.EOF
+	}
+
+	var f geoJSONFeature
+	if err := fr.dec.Decode(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
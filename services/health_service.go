@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// healthCheckTimeout bounds the queries of one health check together, so a
+// slow database answers the check instead of hanging it
+const healthCheckTimeout = 2 * time.Second
+
+// healthCritical are the checks whose failure makes the service unhealthy
+// rather than degraded
+var healthCritical = []string{"database", "postgis"}
+
+// HealthChecks checks the service's dependencies, keyed by name: database,
+// postgis, migrations, data and workers. postgis and migrations are left out
+// for the embedded database, and the checks that need the database are left
+// out while it is down. Details are only kept when verbose is set, which
+// also counts rows instead of only checking tables aren't empty.
+func HealthChecks(ctx context.Context, verbose bool) map[string]models.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	checks := map[string]models.DependencyHealth{
+		"database": checkDatabaseHealth(ctx),
+		"workers":  checkWorkerHealth(time.Now()),
+	}
+	if checks["database"].Status == models.HealthOK {
+		if !database.Embedded() {
+			checks["postgis"] = checkPostGISHealth(ctx)
+			checks["migrations"] = checkMigrationHealth(ctx)
+		}
+		checks["data"] = checkDataHealth(ctx, verbose)
+	}
+
+	if !verbose {
+		for name, check := range checks {
+			check.Details = nil
+			checks[name] = check
+		}
+	}
+	return checks
+}
+
+// HealthRollup is the overall status of checks: unhealthy when the database
+// or PostGIS is down, degraded when any other check isn't ok, and healthy
+// otherwise
+func HealthRollup(checks map[string]models.DependencyHealth) string {
+	for _, name := range healthCritical {
+		if check, ok := checks[name]; ok && check.Status == models.HealthDown {
+			return models.HealthUnhealthy
+		}
+	}
+	for _, check := range checks {
+		if check.Status != models.HealthOK {
+			return models.HealthDegraded
+		}
+	}
+	return models.HealthHealthy
+}
+
+// checkDatabaseHealth runs a trivial query, timing the round trip
+func checkDatabaseHealth(ctx context.Context) models.DependencyHealth {
+	start := time.Now()
+	var one int
+	if err := database.DB.QueryRowContext(ctx, `SELECT 1`).Scan(&one); err != nil {
+		return models.DependencyHealth{Status: models.HealthDown, Message: err.Error()}
+	}
+	latency := time.Since(start)
+	return models.DependencyHealth{
+		Status:  models.HealthOK,
+		Details: map[string]interface{}{"latency_ms": float64(latency.Microseconds()) / 1000},
+	}
+}
+
+// checkPostGISHealth reports whether the PostGIS extension is installed, and
+// its version
+func checkPostGISHealth(ctx context.Context) models.DependencyHealth {
+	var version string
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT extversion FROM pg_extension WHERE extname = 'postgis'`,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DependencyHealth{Status: models.HealthDown, Message: "PostGIS extension is not installed"}
+	}
+	if err != nil {
+		return models.DependencyHealth{Status: models.HealthDown, Message: err.Error()}
+	}
+	return models.DependencyHealth{
+		Status:  models.HealthOK,
+		Details: map[string]interface{}{"version": version},
+	}
+}
+
+// checkMigrationHealth compares the applied schema version with the newest
+// migration in this build. Running, failed or pending migrations degrade
+// the service.
+func checkMigrationHealth(ctx context.Context) models.DependencyHealth {
+	applied, latest, err := database.SchemaVersion(ctx)
+	if err != nil {
+		return models.DependencyHealth{Status: models.HealthDegraded, Message: err.Error()}
+	}
+	check := models.DependencyHealth{
+		Status:  models.HealthOK,
+		Details: map[string]interface{}{"applied_version": applied, "latest_version": latest},
+	}
+	switch {
+	case database.MigrationRunning:
+		check.Status = models.HealthDegraded
+		check.Message = "migrations in progress"
+	case database.MigrationError != nil:
+		check.Status = models.HealthDegraded
+		check.Message = "migration failed: " + database.MigrationError.Error()
+	case applied < latest:
+		check.Status = models.HealthDegraded
+		check.Message = fmt.Sprintf("%d migrations pending", latest-applied)
+	}
+	return check
+}
+
+// checkDataHealth checks the reference data is loaded. Empty zip_codes or
+// us_states tables degrade the service, since the API is up but can't
+// answer; address points are optional, so their absence is only noted.
+// Verbose checks count the rows, estimating address points from the
+// planner statistics.
+func checkDataHealth(ctx context.Context, verbose bool) models.DependencyHealth {
+	check := models.DependencyHealth{Status: models.HealthOK, Details: map[string]interface{}{}}
+
+	var zipCodes, states bool
+	if verbose {
+		var zipCount, stateCount int64
+		if err := database.DB.QueryRowContext(ctx,
+			`SELECT (SELECT COUNT(*) FROM zip_codes), (SELECT COUNT(*) FROM us_states)`,
+		).Scan(&zipCount, &stateCount); err != nil {
+			return models.DependencyHealth{Status: models.HealthDegraded, Message: err.Error()}
+		}
+		check.Details["zip_codes"] = zipCount
+		check.Details["us_states"] = stateCount
+		zipCodes, states = zipCount > 0, stateCount > 0
+	} else if err := database.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM zip_codes), EXISTS (SELECT 1 FROM us_states)`,
+	).Scan(&zipCodes, &states); err != nil {
+		return models.DependencyHealth{Status: models.HealthDegraded, Message: err.Error()}
+	}
+
+	var empty []string
+	if !zipCodes {
+		empty = append(empty, "zip_codes")
+	}
+	if !states {
+		empty = append(empty, "us_states")
+	}
+	if len(empty) > 0 {
+		check.Status = models.HealthDegraded
+		check.Message = "no rows loaded in " + strings.Join(empty, ", ")
+	}
+
+	// The embedded database has no address points
+	if database.Embedded() {
+		return check
+	}
+	var addresses bool
+	if err := database.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM ohio_addresses)`,
+	).Scan(&addresses); err != nil {
+		return models.DependencyHealth{Status: models.HealthDegraded, Message: err.Error()}
+	}
+	if verbose {
+		var estimate int64
+		if err := database.DB.QueryRowContext(ctx,
+			`SELECT GREATEST(reltuples, 0)::bigint FROM pg_class WHERE oid = 'ohio_addresses'::regclass`,
+		).Scan(&estimate); err != nil {
+			return models.DependencyHealth{Status: models.HealthDegraded, Message: err.Error()}
+		}
+		check.Details["addresses_estimate"] = estimate
+	}
+	if !addresses && check.Message == "" {
+		check.Message = "no address points loaded"
+	}
+	return check
+}
+
+// checkWorkerHealth degrades the service while any background worker has
+// stopped polling
+func checkWorkerHealth(now time.Time) models.DependencyHealth {
+	workers := WorkerStatuses(now)
+	check := models.DependencyHealth{
+		Status:  models.HealthOK,
+		Details: map[string]interface{}{"workers": workers},
+	}
+	var stalled []string
+	for _, worker := range workers {
+		if !worker.Alive {
+			stalled = append(stalled, worker.Name)
+		}
+	}
+	if len(stalled) > 0 {
+		check.Status = models.HealthDegraded
+		check.Message = "stalled: " + strings.Join(stalled, ", ")
+	}
+	return check
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthRollup(t *testing.T) {
+	ok := models.DependencyHealth{Status: models.HealthOK}
+	degraded := models.DependencyHealth{Status: models.HealthDegraded}
+	down := models.DependencyHealth{Status: models.HealthDown}
+
+	assert.Equal(t, models.HealthHealthy, HealthRollup(map[string]models.DependencyHealth{
+		"database": ok, "postgis": ok, "data": ok, "workers": ok,
+	}))
+	// Up but empty
+	assert.Equal(t, models.HealthDegraded, HealthRollup(map[string]models.DependencyHealth{
+		"database": ok, "postgis": ok, "data": degraded, "workers": ok,
+	}))
+	assert.Equal(t, models.HealthUnhealthy, HealthRollup(map[string]models.DependencyHealth{
+		"database": down, "workers": ok,
+	}))
+	assert.Equal(t, models.HealthUnhealthy, HealthRollup(map[string]models.DependencyHealth{
+		"database": ok, "postgis": down, "data": degraded,
+	}))
+	// Only the database and PostGIS are critical
+	assert.Equal(t, models.HealthDegraded, HealthRollup(map[string]models.DependencyHealth{
+		"database": ok, "migrations": down,
+	}))
+}
+
+func TestCheckWorkerHealth(t *testing.T) {
+	beat("test_worker", time.Second)
+
+	find := func(check models.DependencyHealth) models.WorkerStatus {
+		workers, ok := check.Details["workers"].([]models.WorkerStatus)
+		require.True(t, ok)
+		for _, worker := range workers {
+			if worker.Name == "test_worker" {
+				return worker
+			}
+		}
+		t.Fatal("test_worker not reported")
+		return models.WorkerStatus{}
+	}
+
+	worker := find(checkWorkerHealth(time.Now().Add(2 * time.Second)))
+	assert.True(t, worker.Alive)
+	assert.Equal(t, 1.0, worker.IntervalSeconds)
+
+	check := checkWorkerHealth(time.Now().Add(10 * time.Second))
+	assert.False(t, find(check).Alive)
+	assert.Equal(t, models.HealthDegraded, check.Status)
+	assert.Contains(t, check.Message, "test_worker")
+}
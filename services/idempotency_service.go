@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"geocoding-api/database"
+)
+
+const (
+	// IdempotencyTTL is how long a response is replayed for its key
+	IdempotencyTTL = 24 * time.Hour
+	// idempotencyLockTimeout is how long a key stays claimed by a request
+	// that never finished, e.g. because the server restarted mid-request
+	idempotencyLockTimeout = time.Hour
+	// idempotencyCleanupInterval is how often expired keys are deleted
+	idempotencyCleanupInterval = time.Hour
+)
+
+// ErrIdempotencyKeyInProgress is returned when another request with the same
+// key hasn't finished yet
+var ErrIdempotencyKeyInProgress = errors.New("idempotency key in progress")
+
+// IdempotentResponse is the stored response of a completed request
+type IdempotentResponse struct {
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyService stores responses by Idempotency-Key so retried
+// requests get the first response instead of repeating its side effects
+type IdempotencyService struct{}
+
+var Idempotency = &IdempotencyService{}
+
+// Begin claims key for a new request by userID. When the key already has a
+// completed response, Begin returns it instead; when another request holds
+// the key, it returns ErrIdempotencyKeyInProgress. Expired keys and keys
+// abandoned mid-request are claimed again.
+func (s *IdempotencyService) Begin(ctx context.Context, userID int, key string) (*IdempotentResponse, error) {
+	var claimed bool
+	err := database.DB.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second')
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE SET
+			request_hash = NULL,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()
+		   OR (idempotency_keys.status_code IS NULL
+		       AND idempotency_keys.created_at < NOW() - $4 * INTERVAL '1 second')
+		RETURNING true
+	`, userID, key, int(IdempotencyTTL.Seconds()), int(idempotencyLockTimeout.Seconds())).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	var response IdempotentResponse
+	var hash, contentType sql.NullString
+	var status sql.NullInt64
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT request_hash, status_code, content_type, response_body
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key).Scan(&hash, &status, &contentType, &response.Body)
+	if err == sql.ErrNoRows {
+		// Deleted since the insert lost, so the earlier request failed; retry
+		return s.Begin(ctx, userID, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if !status.Valid {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	response.RequestHash = hash.String
+	response.StatusCode = int(status.Int64)
+	response.ContentType = contentType.String
+	return &response, nil
+}
+
+// Complete stores the response of the request holding key
+func (s *IdempotencyService) Complete(ctx context.Context, userID int, key string, response IdempotentResponse) error {
+	_, err := database.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET request_hash = $3, status_code = $4, content_type = $5, response_body = $6
+		WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key, response.RequestHash, response.StatusCode, response.ContentType, response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release frees key without storing a response, so a retry runs again
+func (s *IdempotencyService) Release(ctx context.Context, userID int, key string) error {
+	_, err := database.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL
+	`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// StartCleanup deletes expired keys every hour until the process exits
+func (s *IdempotencyService) StartCleanup() {
+	go func() {
+		ticker := time.NewTicker(idempotencyCleanupInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := database.DB.Exec(`DELETE FROM idempotency_keys WHERE expires_at < NOW()`); err != nil {
+				slog.Error("failed to delete expired idempotency keys", "error", err)
+			}
+		}
+	}()
+}
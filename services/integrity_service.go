@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// integrityCheckLockKey is the Postgres advisory lock that keeps multiple
+	// API instances from running the check at the same time
+	integrityCheckLockKey = 2273001
+	// defaultIntegrityCheckHour is the UTC hour the nightly check runs at
+	defaultIntegrityCheckHour = 3
+)
+
+// IntegrityService compares per-county address row counts with the record
+// counts of completed datasets to catch silent data loss
+type IntegrityService struct{}
+
+var Integrity = &IntegrityService{}
+
+// countyCount is the expected and actual address count for one county
+type countyCount struct {
+	state      string
+	county     string
+	expected   int64
+	actual     int64
+	datasetIDs pq.Int64Array
+	tracked    bool
+}
+
+// integrityTolerance reads INTEGRITY_CHECK_TOLERANCE, the fraction of a
+// county's expected count that may drift before it is reported (default 0)
+func integrityTolerance() float64 {
+	if t, err := strconv.ParseFloat(os.Getenv("INTEGRITY_CHECK_TOLERANCE"), 64); err == nil && t >= 0 && t < 1 {
+		return t
+	}
+	return 0
+}
+
+// RunCheck runs the integrity check and records the run and any
+// discrepancies in the data-quality report. triggeredBy is "scheduled" or
+// "manual".
+func (is *IntegrityService) RunCheck(ctx context.Context, triggeredBy string) (*models.DataQualityReport, error) {
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", integrityCheckLockKey).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to acquire integrity check lock: %w", err)
+	}
+	if !locked {
+		return nil, fmt.Errorf("integrity check already running")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", integrityCheckLockKey)
+
+	var runID int
+	err = conn.QueryRowContext(ctx, `
+		INSERT INTO integrity_check_runs (triggered_by) VALUES ($1) RETURNING id
+	`, triggeredBy).Scan(&runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start integrity check run: %w", err)
+	}
+
+	checked, untracked, issues, err := is.compareCounts(ctx, conn, runID)
+	if err != nil {
+		conn.ExecContext(context.Background(), `
+			UPDATE integrity_check_runs SET status = 'failed', error_message = $2, finished_at = NOW() WHERE id = $1
+		`, runID, err.Error())
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		UPDATE integrity_check_runs
+		SET status = 'completed', counties_checked = $2, untracked_counties = $3, discrepancies = $4, finished_at = NOW()
+		WHERE id = $1
+	`, runID, checked, untracked, len(issues))
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete integrity check run: %w", err)
+	}
+
+	report, err := is.GetReport(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(issues) > 0 {
+		slog.Warn("integrity check found discrepancies", "run_id", runID, "discrepancies", len(issues), "counties_checked", checked)
+		if os.Getenv("INTEGRITY_CHECK_ALERTS") == "true" {
+			is.alertAdmins(report)
+		}
+	} else {
+		slog.Info("integrity check passed", "run_id", runID, "counties_checked", checked, "untracked_counties", untracked)
+	}
+
+	return report, nil
+}
+
+// compareCounts computes per-county drift and inserts an issue row for each
+// county outside tolerance. Counties with addresses but no completed dataset
+// (e.g. loaded from bundled files at startup) are counted as untracked.
+func (is *IntegrityService) compareCounts(ctx context.Context, conn *sql.Conn, runID int) (int, int, []models.DataQualityIssue, error) {
+	rows, err := conn.QueryContext(ctx, `
+		WITH expected AS (
+			SELECT UPPER(state) AS state, LOWER(county) AS county_key, MIN(county) AS county,
+			       SUM(record_count) AS expected, ARRAY_AGG(id ORDER BY id) AS dataset_ids
+			FROM datasets
+			WHERE status = 'completed'
+			GROUP BY UPPER(state), LOWER(county)
+		), actual AS (
+			SELECT UPPER(COALESCE(region, '')) AS state, LOWER(county) AS county_key, MIN(county) AS county,
+			       COUNT(*) AS actual
+			FROM ohio_addresses
+			WHERE county IS NOT NULL
+			GROUP BY UPPER(COALESCE(region, '')), LOWER(county)
+		)
+		SELECT COALESCE(e.state, a.state), COALESCE(e.county, a.county),
+		       COALESCE(e.expected, 0), COALESCE(a.actual, 0),
+		       COALESCE(e.dataset_ids, '{}'), e.county_key IS NOT NULL
+		FROM expected e
+		FULL OUTER JOIN actual a ON a.state = e.state AND a.county_key = e.county_key
+		ORDER BY 1, 2
+	`)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to count addresses: %w", err)
+	}
+
+	var counts []countyCount
+	for rows.Next() {
+		var cc countyCount
+		if err := rows.Scan(&cc.state, &cc.county, &cc.expected, &cc.actual, &cc.datasetIDs, &cc.tracked); err != nil {
+			rows.Close()
+			return 0, 0, nil, fmt.Errorf("failed to scan county counts: %w", err)
+		}
+		counts = append(counts, cc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to count addresses: %w", err)
+	}
+
+	tolerance := integrityTolerance()
+	checked, untracked := 0, 0
+	issues := []models.DataQualityIssue{}
+	for _, cc := range counts {
+		if !cc.tracked {
+			untracked++
+			continue
+		}
+		checked++
+
+		diff := cc.actual - cc.expected
+		if diff == 0 || math.Abs(float64(diff)) <= tolerance*float64(cc.expected) {
+			continue
+		}
+
+		issue := models.DataQualityIssue{
+			RunID:         runID,
+			CheckType:     models.DataQualityMissingRows,
+			State:         cc.state,
+			County:        cc.county,
+			ExpectedCount: cc.expected,
+			ActualCount:   cc.actual,
+			Difference:    diff,
+			DatasetIDs:    cc.datasetIDs,
+		}
+		if diff > 0 {
+			issue.CheckType = models.DataQualityExtraRows
+		}
+
+		_, err := conn.ExecContext(ctx, `
+			INSERT INTO data_quality_issues (run_id, check_type, state, county, expected_count, actual_count, difference, dataset_ids)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, runID, issue.CheckType, issue.State, issue.County, issue.ExpectedCount, issue.ActualCount, issue.Difference, pq.Array(cc.datasetIDs))
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("failed to record data quality issue: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+
+	return checked, untracked, issues, nil
+}
+
+// alertAdmins queues a data_quality.drift webhook for every admin user
+func (is *IntegrityService) alertAdmins(report *models.DataQualityReport) {
+	rows, err := database.DB.Query("SELECT id FROM users WHERE is_admin = true AND is_active = true")
+	if err != nil {
+		slog.Error("failed to list admins for integrity alert", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	dedupeKey := "integrity_run:" + strconv.Itoa(report.Run.ID)
+	for rows.Next() {
+		var adminID int
+		if err := rows.Scan(&adminID); err != nil {
+			continue
+		}
+		if err := Webhooks.Emit(adminID, models.WebhookEventDataQualityDrift, dedupeKey, report); err != nil {
+			slog.Warn("failed to queue webhook", "event", models.WebhookEventDataQualityDrift, "user_id", adminID, "error", err)
+		}
+	}
+}
+
+// GetReport returns a run and its discrepancies
+func (is *IntegrityService) GetReport(runID int) (*models.DataQualityReport, error) {
+	report := &models.DataQualityReport{Issues: []models.DataQualityIssue{}}
+	run := &report.Run
+	err := database.DB.QueryRow(`
+		SELECT id, triggered_by, status, counties_checked, untracked_counties, discrepancies,
+		       error_message, started_at, finished_at
+		FROM integrity_check_runs
+		WHERE id = $1
+	`, runID).Scan(&run.ID, &run.TriggeredBy, &run.Status, &run.CountiesChecked, &run.UntrackedCounties,
+		&run.Discrepancies, &run.ErrorMessage, &run.StartedAt, &run.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("integrity check run not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integrity check run: %w", err)
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, run_id, check_type, state, county, expected_count, actual_count, difference, dataset_ids, detected_at
+		FROM data_quality_issues
+		WHERE run_id = $1
+		ORDER BY ABS(difference) DESC, state, county
+	`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data quality issues: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var issue models.DataQualityIssue
+		var datasetIDs pq.Int64Array
+		if err := rows.Scan(&issue.ID, &issue.RunID, &issue.CheckType, &issue.State, &issue.County,
+			&issue.ExpectedCount, &issue.ActualCount, &issue.Difference, &datasetIDs, &issue.DetectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan data quality issue: %w", err)
+		}
+		issue.DatasetIDs = datasetIDs
+		if issue.DatasetIDs == nil {
+			issue.DatasetIDs = []int64{}
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	return report, rows.Err()
+}
+
+// GetLatestReport returns the most recent completed run's report
+func (is *IntegrityService) GetLatestReport() (*models.DataQualityReport, error) {
+	var runID int
+	err := database.DB.QueryRow(`
+		SELECT id FROM integrity_check_runs WHERE status = 'completed' ORDER BY started_at DESC LIMIT 1
+	`).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("integrity check report not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest integrity check run: %w", err)
+	}
+	return is.GetReport(runID)
+}
+
+// GetRuns returns the most recent runs, newest first
+func (is *IntegrityService) GetRuns(limit int) ([]models.IntegrityCheckRun, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, triggered_by, status, counties_checked, untracked_counties, discrepancies,
+		       error_message, started_at, finished_at
+		FROM integrity_check_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integrity check runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.IntegrityCheckRun{}
+	for rows.Next() {
+		var run models.IntegrityCheckRun
+		if err := rows.Scan(&run.ID, &run.TriggeredBy, &run.Status, &run.CountiesChecked, &run.UntrackedCounties,
+			&run.Discrepancies, &run.ErrorMessage, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// nextIntegrityRun returns the next occurrence of hour:00 UTC after now
+func nextIntegrityRun(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// StartScheduler runs the integrity check nightly at INTEGRITY_CHECK_HOUR
+// (UTC, default 3) until the process exits. INTEGRITY_CHECK_ENABLED=false
+// disables it.
+func (is *IntegrityService) StartScheduler() {
+	if os.Getenv("INTEGRITY_CHECK_ENABLED") == "false" {
+		slog.Info("nightly integrity check disabled")
+		return
+	}
+
+	hour := defaultIntegrityCheckHour
+	if h, err := strconv.Atoi(os.Getenv("INTEGRITY_CHECK_HOUR")); err == nil && h >= 0 && h < 24 {
+		hour = h
+	}
+
+	go func() {
+		for {
+			next := nextIntegrityRun(time.Now(), hour)
+			time.Sleep(time.Until(next))
+
+			if _, err := is.RunCheck(context.Background(), "scheduled"); err != nil {
+				slog.Error("scheduled integrity check failed", "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"geocoding-api/models"
+)
+
+// Defaults for the reference-data lookup caches. ZIP, state and county data
+// only changes when it is reloaded, so entries can live for a long time.
+const (
+	defaultLookupCacheTTL        = 24 * time.Hour
+	defaultLookupCacheMaxEntries = 10000
+	// maxTileCacheEntries bounds the vector tile cache separately, since a
+	// dense address tile can be hundreds of kilobytes
+	maxTileCacheEntries = 2000
+)
+
+// CacheStats reports hit/miss counters for one lookup cache
+type CacheStats struct {
+	Name       string  `json:"name"`
+	Enabled    bool    `json:"enabled"`
+	TTLSeconds int     `json:"ttl_seconds"`
+	MaxEntries int     `json:"max_entries"`
+	Entries    int     `json:"entries"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	Evictions  uint64  `json:"evictions"`
+	HitRate    float64 `json:"hit_rate"`
+}
+
+// LookupCache is an in-process LRU with per-entry TTL for read-only lookups.
+// A nil or zero-TTL cache passes every call straight through to the loader.
+type LookupCache[V any] struct {
+	name       string
+	ttl        time.Duration
+	maxEntries int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type lookupCacheEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+func newLookupCache[V any](name string, ttl time.Duration, maxEntries int) *LookupCache[V] {
+	return &LookupCache[V]{
+		name:       name,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// GetOrLoad returns the cached value for key or calls load and caches the
+// result. Errors are never cached.
+func (c *LookupCache[V]) GetOrLoad(key string, load func() (V, error)) (V, error) {
+	if c == nil || c.ttl <= 0 {
+		return load()
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lookupCacheEntry[V])
+		if now.Before(entry.expiresAt) {
+			c.ll.MoveToFront(elem)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return entry.value, nil
+		}
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.ll.Remove(elem)
+	}
+	c.items[key] = c.ll.PushFront(&lookupCacheEntry[V]{key: key, value: value, expiresAt: now.Add(c.ttl)})
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lookupCacheEntry[V]).key)
+		c.evictions.Add(1)
+	}
+
+	return value, nil
+}
+
+// Purge drops every entry, e.g. after the underlying data is reloaded
+func (c *LookupCache[V]) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.mu.Unlock()
+}
+
+// Stats returns the cache's counters
+func (c *LookupCache[V]) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.ll.Len()
+	c.mu.Unlock()
+
+	stats := CacheStats{
+		Name:       c.name,
+		Enabled:    c.ttl > 0,
+		TTLSeconds: int(c.ttl.Seconds()),
+		MaxEntries: c.maxEntries,
+		Entries:    entries,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Evictions:  c.evictions.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// lookupCaches holds the caches in front of hot reference lookups. They are
+// nil (pass-through) until InitLookupCaches runs.
+var lookupCaches struct {
+	zip    *LookupCache[*models.ZipCode]
+	state  *LookupCache[*models.State]
+	county *LookupCache[*models.CountyBoundaryGeoJSON]
+	route  *LookupCache[*RouteSummary]
+	tile   *LookupCache[[]byte]
+}
+
+// InitLookupCaches configures the lookup caches from the environment:
+// CACHE_ENABLED=false disables them, CACHE_MAX_ENTRIES bounds each cache and
+// CACHE_ZIP_TTL, CACHE_STATE_TTL, CACHE_COUNTY_TTL, CACHE_ROUTE_TTL and
+// CACHE_TILE_TTL take Go durations ("6h").
+func InitLookupCaches() {
+	enabled := os.Getenv("CACHE_ENABLED") != "false"
+
+	maxEntries := defaultLookupCacheMaxEntries
+	if n, err := strconv.Atoi(os.Getenv("CACHE_MAX_ENTRIES")); err == nil && n > 0 {
+		maxEntries = n
+	}
+
+	ttl := func(envVar string) time.Duration {
+		if !enabled {
+			return 0
+		}
+		if value := os.Getenv(envVar); value != "" {
+			d, err := time.ParseDuration(value)
+			if err == nil && d >= 0 {
+				return d
+			}
+			slog.Warn("invalid cache TTL, using default", "env", envVar, "value", value, "default", defaultLookupCacheTTL.String())
+		}
+		return defaultLookupCacheTTL
+	}
+
+	lookupCaches.zip = newLookupCache[*models.ZipCode]("zip_codes", ttl("CACHE_ZIP_TTL"), maxEntries)
+	lookupCaches.state = newLookupCache[*models.State]("state_by_coordinates", ttl("CACHE_STATE_TTL"), maxEntries)
+	lookupCaches.county = newLookupCache[*models.CountyBoundaryGeoJSON]("county_boundaries", ttl("CACHE_COUNTY_TTL"), maxEntries)
+	lookupCaches.route = newLookupCache[*RouteSummary]("driving_routes", ttl("CACHE_ROUTE_TTL"), maxEntries)
+	lookupCaches.tile = newLookupCache[[]byte]("vector_tiles", ttl("CACHE_TILE_TTL"), min(maxEntries, maxTileCacheEntries))
+}
+
+// GetLookupCacheStats returns hit/miss counters for every lookup cache
+func GetLookupCacheStats() []CacheStats {
+	stats := []CacheStats{}
+	if lookupCaches.zip != nil {
+		stats = append(stats, lookupCaches.zip.Stats(), lookupCaches.state.Stats(), lookupCaches.county.Stats(),
+			lookupCaches.route.Stats(), lookupCaches.tile.Stats())
+	}
+	return stats
+}
+
+// referenceDataModified is when this instance last saw the ZIP, state or
+// county data change, in Unix seconds. It starts at process start since an
+// earlier load can't be told apart from one that just happened.
+var referenceDataModified atomic.Int64
+
+func init() {
+	referenceDataModified.Store(time.Now().Unix())
+}
+
+// ReferenceDataModified returns when the reference data last changed, for
+// Last-Modified headers
+func ReferenceDataModified() time.Time {
+	return time.Unix(referenceDataModified.Load(), 0).UTC()
+}
+
+// markReferenceDataModified records that the reference data just changed.
+// Vector tiles and cached responses draw from every reference table, so they
+// are purged here rather than by each loader.
+func markReferenceDataModified() {
+	referenceDataModified.Store(time.Now().Unix())
+	lookupCaches.tile.Purge()
+	if _, err := PurgeResponseCache(context.Background(), ""); err != nil {
+		slog.Warn("failed to purge cached responses", "error", err)
+	}
+}
+
+// zipCodesChanged drops cached ZIP codes after zip_codes changes and rebuilds
+// the ZIP centroid index from it
+func zipCodesChanged() {
+	lookupCaches.zip.Purge()
+	ZipCentroids.Rebuild()
+}
+
+// PurgeLookupCaches empties every lookup cache and rebuilds the ZIP centroid
+// index
+func PurgeLookupCaches() {
+	markReferenceDataModified()
+	zipCodesChanged()
+	lookupCaches.state.Purge()
+	lookupCaches.county.Purge()
+	lookupCaches.route.Purge()
+	lookupCaches.tile.Purge()
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrMaintenanceRunning is returned when a maintenance run is already in progress
+var ErrMaintenanceRunning = errors.New("maintenance already running")
+
+// maintenanceTables are vacuumed and analyzed by vacuum_analyze: the
+// address tables and the reference tables bulk imports write to
+var maintenanceTables = []string{"ohio_addresses", "streets", "ohio_counties", "zip_codes", "cities"}
+
+// maintenanceStatement is a planned step and the SQL it runs
+type maintenanceStatement struct {
+	step models.MaintenanceStep
+	sql  string
+}
+
+// MaintenanceService runs database maintenance in the background. Only the
+// latest run is kept, in memory; runs never overlap.
+type MaintenanceService struct {
+	mu  sync.Mutex
+	run *models.MaintenanceRun
+}
+
+var Maintenance = &MaintenanceService{}
+
+// Latest returns a copy of the latest run, or an idle status if there
+// hasn't been one since startup
+func (s *MaintenanceService) Latest() *models.MaintenanceRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.run == nil {
+		return &models.MaintenanceRun{Status: models.MaintenanceIdle, Steps: []models.MaintenanceStep{}}
+	}
+	run := *s.run
+	run.Steps = append([]models.MaintenanceStep(nil), s.run.Steps...)
+	return &run
+}
+
+// Start plans the operations, in order, and runs them in the background.
+// startedBy identifies the admin for the run's status.
+func (s *MaintenanceService) Start(ctx context.Context, operations []string, startedBy string) (*models.MaintenanceRun, error) {
+	s.mu.Lock()
+	if s.run != nil && s.run.Status == models.MaintenanceRunning {
+		s.mu.Unlock()
+		return nil, ErrMaintenanceRunning
+	}
+	// Hold the slot while planning so a second request can't start alongside
+	now := time.Now()
+	s.run = &models.MaintenanceRun{
+		Status:     models.MaintenanceRunning,
+		Operations: operations,
+		Steps:      []models.MaintenanceStep{},
+		StartedBy:  startedBy,
+		StartedAt:  &now,
+	}
+	s.mu.Unlock()
+
+	statements, err := planMaintenance(ctx, operations)
+	if err != nil {
+		s.finish(err)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for _, statement := range statements {
+		s.run.Steps = append(s.run.Steps, statement.step)
+	}
+	s.mu.Unlock()
+
+	go s.execute(statements)
+	return s.Latest(), nil
+}
+
+// planMaintenance lists the statements each operation runs
+func planMaintenance(ctx context.Context, operations []string) ([]maintenanceStatement, error) {
+	var statements []maintenanceStatement
+	for _, operation := range operations {
+		switch operation {
+		case models.MaintenanceReindex:
+			// CONCURRENTLY keeps the tables readable while indexes rebuild
+			indexes, err := queryNames(ctx, `
+				SELECT indexname FROM pg_indexes
+				WHERE schemaname = 'public' AND indexdef ILIKE '%USING gist%'
+				ORDER BY tablename, indexname
+			`)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list spatial indexes: %w", err)
+			}
+			for _, index := range indexes {
+				statements = append(statements, newMaintenanceStatement(operation, index,
+					"REINDEX INDEX CONCURRENTLY "+pq.QuoteIdentifier(index)))
+			}
+
+		case models.MaintenanceVacuumAnalyze:
+			for _, table := range maintenanceTables {
+				statements = append(statements, newMaintenanceStatement(operation, table,
+					"VACUUM (ANALYZE) "+pq.QuoteIdentifier(table)))
+			}
+
+		case models.MaintenanceRefreshViews:
+			views, err := queryNames(ctx, `
+				SELECT matviewname FROM pg_matviews
+				WHERE schemaname = 'public'
+				ORDER BY matviewname
+			`)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list materialized views: %w", err)
+			}
+			for _, view := range views {
+				statements = append(statements, newMaintenanceStatement(operation, view,
+					"REFRESH MATERIALIZED VIEW "+pq.QuoteIdentifier(view)))
+			}
+
+		default:
+			return nil, fmt.Errorf("unknown maintenance operation %q", operation)
+		}
+	}
+	return statements, nil
+}
+
+func newMaintenanceStatement(operation, target, sql string) maintenanceStatement {
+	return maintenanceStatement{
+		step: models.MaintenanceStep{Operation: operation, Target: target, Status: models.MaintenancePending},
+		sql:  sql,
+	}
+}
+
+// queryNames returns the single text column of every row of query
+func queryNames(ctx context.Context, query string) ([]string, error) {
+	rows, err := database.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// execute runs the statements in order. VACUUM and REINDEX CONCURRENTLY
+// can't run inside a transaction, so each runs on its own.
+func (s *MaintenanceService) execute(statements []maintenanceStatement) {
+	ctx := context.Background()
+	failed := 0
+	for i, statement := range statements {
+		s.updateStep(i, func(step *models.MaintenanceStep) { step.Status = models.MaintenanceRunning })
+
+		started := time.Now()
+		_, err := database.DB.ExecContext(ctx, statement.sql)
+		elapsed := time.Since(started)
+
+		s.updateStep(i, func(step *models.MaintenanceStep) {
+			step.DurationMS = elapsed.Milliseconds()
+			step.Status = models.MaintenanceCompleted
+			if err != nil {
+				step.Status = models.MaintenanceFailed
+				step.Error = err.Error()
+			}
+		})
+		if err != nil {
+			failed++
+			slog.Warn("maintenance step failed", "operation", statement.step.Operation,
+				"target", statement.step.Target, "error", err)
+			continue
+		}
+		slog.Info("maintenance step completed", "operation", statement.step.Operation,
+			"target", statement.step.Target, "duration", elapsed)
+	}
+
+	if failed > 0 {
+		s.finish(fmt.Errorf("%d of %d steps failed", failed, len(statements)))
+		return
+	}
+	s.finish(nil)
+}
+
+func (s *MaintenanceService) updateStep(i int, update func(*models.MaintenanceStep)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.run.Steps[i])
+}
+
+// finish marks the run completed or failed
+func (s *MaintenanceService) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.run.FinishedAt = &now
+	s.run.Status = models.MaintenanceCompleted
+	if err != nil {
+		s.run.Status = models.MaintenanceFailed
+		s.run.Error = err.Error()
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+)
+
+// Notification kinds. Each has a template in email_templates/<kind>.txt
+// whose first line is the subject and the rest the body.
+const (
+	NotificationWelcome          = "welcome"
+	NotificationAPIKeyCreated    = "api_key_created"
+	NotificationQuotaWarning     = "quota_warning"  // 80% of the daily or monthly limit used
+	NotificationQuotaExceeded    = "quota_exceeded" // 100% of the daily or monthly limit used
+	NotificationPlanChanged      = "plan_changed"
+	NotificationDatasetCompleted = "dataset_completed"
+	NotificationDatasetFailed    = "dataset_failed"
+)
+
+const (
+	// notificationQueueSize is how many notifications can wait for the
+	// sender before new ones are dropped
+	notificationQueueSize = 1000
+	// notificationSendAttempts is how many times sending an email is tried
+	// before the notification is dropped
+	notificationSendAttempts = 3
+)
+
+//go:embed email_templates/*.txt
+var notificationTemplateFiles embed.FS
+
+var notificationTemplates = template.Must(template.New("").Option("missingkey=error").ParseFS(notificationTemplateFiles, "email_templates/*.txt"))
+
+// notification is one queued email to a user
+type notification struct {
+	userID    int
+	kind      string
+	dedupeKey string
+	data      map[string]interface{}
+}
+
+// NotificationService emails users about account, quota and dataset events.
+// Notifications are queued and sent by a single background sender, so the
+// request or import that raised them never waits on the mail relay. The
+// queue is in memory: notifications still waiting at shutdown are sent
+// before Stop returns, or lost if the process dies.
+type NotificationService struct {
+	mu      sync.RWMutex
+	queue   chan notification
+	running bool
+	done    chan struct{}
+	mailer  Mailer
+}
+
+var Notifications = &NotificationService{}
+
+// Start begins sending queued notifications in the background. It does
+// nothing when EMAIL_NOTIFICATIONS is off.
+func (ns *NotificationService) Start() {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.running || !config.Get().Email.Notifications {
+		return
+	}
+
+	ns.mailer = NewMailer()
+	ns.queue = make(chan notification, notificationQueueSize)
+	ns.done = make(chan struct{})
+	ns.running = true
+
+	go ns.run(ns.queue, ns.done)
+}
+
+// Notify queues a notification email to a user. A non-empty dedupeKey makes
+// it go out at most once per user. Notifications are dropped when the
+// sender isn't running or its queue is full.
+func (ns *NotificationService) Notify(userID int, kind, dedupeKey string, data map[string]interface{}) {
+	if userID <= 0 {
+		return
+	}
+
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if !ns.running {
+		return
+	}
+	select {
+	case ns.queue <- notification{userID: userID, kind: kind, dedupeKey: dedupeKey, data: data}:
+	default:
+		slog.Warn("notification queue full, dropping notification", "kind", kind, "user_id", userID)
+	}
+}
+
+// Stop stops accepting notifications and waits for the queued ones to be
+// sent, or for ctx to end
+func (ns *NotificationService) Stop(ctx context.Context) error {
+	ns.mu.Lock()
+	if !ns.running {
+		ns.mu.Unlock()
+		return nil
+	}
+	ns.running = false
+	close(ns.queue)
+	done := ns.done
+	ns.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notification sender did not drain: %w", ctx.Err())
+	}
+}
+
+func (ns *NotificationService) run(queue <-chan notification, done chan<- struct{}) {
+	defer close(done)
+	for n := range queue {
+		if err := ns.send(n); err != nil {
+			slog.Warn("failed to send notification", "kind", n.kind, "user_id", n.userID, "error", err)
+		}
+	}
+}
+
+// send renders and emails one notification. Deleted and deactivated users
+// are skipped, as are notifications whose dedupe key was already used.
+func (ns *NotificationService) send(n notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var email, name string
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT email, COALESCE(name, '') FROM users
+		WHERE id = $1 AND is_active = true AND deleted_at IS NULL
+	`, n.userID).Scan(&email, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	msg, err := renderNotification(n.kind, name, n.data)
+	if err != nil {
+		return err
+	}
+	msg.To = email
+
+	// Claim the dedupe key before sending, so two instances crossing the
+	// same quota threshold send one email between them
+	result, err := database.DB.ExecContext(ctx, `
+		INSERT INTO notification_log (user_id, kind, dedupe_key)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+	`, n.userID, n.kind, n.dedupeKey)
+	if err != nil {
+		return fmt.Errorf("failed to log notification: %w", err)
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = ns.mailer.Send(msg)
+		if err == nil || attempt == notificationSendAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 5 * time.Second)
+	}
+}
+
+// renderNotification fills in the kind's email template. Templates also see
+// the user's name as Name and the web app origin as AppURL.
+func renderNotification(kind, name string, data map[string]interface{}) (EmailMessage, error) {
+	values := map[string]interface{}{"Name": name, "AppURL": AppURL()}
+	if name == "" {
+		values["Name"] = "there"
+	}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := notificationTemplates.ExecuteTemplate(&buf, kind+".txt", values); err != nil {
+		return EmailMessage{}, fmt.Errorf("failed to render %s notification: %w", kind, err)
+	}
+	subject, body, _ := strings.Cut(buf.String(), "\n")
+	return EmailMessage{Subject: subject, Body: body}, nil
+}
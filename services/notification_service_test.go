@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderNotification(t *testing.T) {
+	dataset := map[string]interface{}{"DatasetID": 7, "DatasetName": "Franklin 2026", "State": "OH", "County": "Franklin"}
+	tests := []struct {
+		kind    string
+		data    map[string]interface{}
+		subject string
+		body    string
+	}{
+		{NotificationWelcome, map[string]interface{}{"Plan": "free"}, "Welcome to GeoCode API", "on the free plan"},
+		{NotificationAPIKeyCreated, map[string]interface{}{"KeyName": "prod", "KeyPreview": "gk_1234..."}, "New API key created: prod", `"prod" (gk_1234...)`},
+		{NotificationQuotaWarning, map[string]interface{}{"Period": "daily", "Threshold": 80, "Usage": 800, "Limit": 1000},
+			"You've used 80% of your daily API quota", "until the day is over"},
+		{NotificationQuotaExceeded, map[string]interface{}{"Period": "monthly", "Threshold": 100, "Usage": 1000, "Limit": 1000},
+			"You've reached your monthly API quota", "until the month is over"},
+		{NotificationPlanChanged, map[string]interface{}{"PreviousPlan": "free", "Plan": "pro"}, "Your GeoCode API plan is now pro", "from the free plan to the pro plan"},
+		{NotificationDatasetCompleted, withValues(dataset, map[string]interface{}{"RecordCount": 1200}), "Dataset imported: Franklin 2026", "with 1200 addresses"},
+		{NotificationDatasetFailed, withValues(dataset, map[string]interface{}{"Error": "no address columns"}), "Dataset import failed: Franklin 2026", "no address columns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			msg, err := renderNotification(tt.kind, "Ada", tt.data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, msg.Subject)
+			assert.Contains(t, msg.Body, "Hi Ada,")
+			assert.Contains(t, msg.Body, tt.body)
+			assert.Contains(t, msg.Body, AppURL()+"/")
+		})
+	}
+
+	msg, err := renderNotification(NotificationWelcome, "", map[string]interface{}{"Plan": "free"})
+	require.NoError(t, err)
+	assert.Contains(t, msg.Body, "Hi there,")
+
+	_, err = renderNotification(NotificationPlanChanged, "Ada", map[string]interface{}{"Plan": "pro"})
+	assert.Error(t, err, "a missing template value is an error")
+	_, err = renderNotification("unknown", "Ada", nil)
+	assert.Error(t, err)
+}
+
+func TestNotifyWithoutSender(t *testing.T) {
+	ns := &NotificationService{}
+	ns.Notify(1, NotificationWelcome, "welcome", nil)
+	assert.NoError(t, ns.Stop(context.Background()))
+}
+
+// withValues returns a copy of data with values added
+func withValues(data, values map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range data {
+		out[k] = v
+	}
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
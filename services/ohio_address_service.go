@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"geocoding-api/config"
 	"geocoding-api/database"
+	"geocoding-api/models"
 	"geocoding-api/utils"
 )
 
@@ -27,8 +29,8 @@ func InitializeOhioData() error {
 	}
 
 	if totalCount == 0 {
-		log.Println("No address data found in database.")
-		log.Println("Use the Data Manager UI at /data-manager to upload county address datasets.")
+		slog.Info("no address data found in database")
+		slog.Info("upload county address datasets from the Data Manager UI", "path", "/data-manager")
 		return nil
 	}
 
@@ -48,7 +50,7 @@ func InitializeOhioData() error {
 		loadedCounties = append(loadedCounties, county)
 	}
 	
-	log.Printf("Database contains %d address records across %d counties", totalCount, len(loadedCounties))
+	slog.Info("address data loaded", "records", totalCount, "counties", len(loadedCounties))
 	
 	return nil
 }
@@ -60,7 +62,7 @@ func LoadOhioAddressData() error {
 
 // loadMissingCounties loads data for counties not already in the database
 func loadMissingCounties(loadedCounties map[string]bool) error {
-	log.Println("Loading Ohio address data from GeoJSON files...")
+	slog.Info("loading Ohio address data from GeoJSON files")
 	
 	destDir := "."
 	ohDir := filepath.Join(destDir, "oh")
@@ -88,20 +90,20 @@ func loadMissingCounties(loadedCounties map[string]bool) error {
 		
 		// Decompress if needed (lazy decompression)
 		if err := decompressIfNeeded(addressFile); err != nil {
-			log.Printf("Failed to decompress %s: %v", county, err)
+			slog.Warn("failed to decompress county file", "county", county, "error", err)
 			continue
 		}
 		
 		// Check if file exists after decompression attempt
 		if _, err := os.Stat(addressFile); os.IsNotExist(err) {
-			log.Printf("GeoJSON file not found for %s, skipping (no compressed file available)", county)
+			slog.Info("GeoJSON file not found, skipping county", "county", county)
 			continue
 		}
 		
 		// Load county data
 		count, err := loadCountyAddresses(county, addressFile)
 		if err != nil {
-			log.Printf("Warning: Failed to load %s: %v", county, err)
+			slog.Warn("failed to load county", "county", county, "error", err)
 			continue
 		}
 		
@@ -109,35 +111,35 @@ func loadMissingCounties(loadedCounties map[string]bool) error {
 		successfulCounties++
 		
 		if count > 0 {
-			log.Printf("Loaded %d records from %s", count, strings.Title(county))
+			slog.Info("loaded county", "county", strings.Title(county), "records", count)
 		} else {
 			// Check if it's a placeholder file with ArcGIS source
 			content, readErr := os.ReadFile(addressFile)
 			if readErr != nil {
-				log.Printf("Loaded 0 records from %s (could not read file: %v)", strings.Title(county), readErr)
+				slog.Warn("loaded 0 records, could not read file", "county", strings.Title(county), "error", readErr)
 			} else {
 				contentStr := string(content)
 				
 				// Check for ArcGIS indicators
 				if strings.Contains(contentStr, "FeatureServer") {
-					log.Printf("Info: %s uses ArcGIS FeatureServer (not yet supported)", strings.Title(county))
+					slog.Info("county uses ArcGIS FeatureServer, not yet supported", "county", strings.Title(county))
 				} else if len(contentStr) < 500 && strings.Contains(contentStr, `"features": []`) {
-					log.Printf("Info: %s has empty placeholder file", strings.Title(county))
+					slog.Info("county has empty placeholder file", "county", strings.Title(county))
 				} else {
-					log.Printf("Loaded 0 records from %s (no features in shapefile - may be empty)", strings.Title(county))
+					slog.Info("loaded 0 records, no features in file", "county", strings.Title(county))
 				}
 			}
 		}
 	}
 	
 	if skippedCounties > 0 {
-		log.Printf("Skipped %d counties (already loaded)", skippedCounties)
+		slog.Info("skipped already loaded counties", "counties", skippedCounties)
 	}
-	log.Printf("Completed loading Ohio address data: %d records from %d counties", totalRecords, successfulCounties)
+	slog.Info("completed loading Ohio address data", "records", totalRecords, "counties", successfulCounties)
 	
 	// Clean up GeoJSON files after successful loading to save disk space
 	if err := cleanupGeoJSONFiles(); err != nil {
-		log.Printf("Warning: Failed to cleanup GeoJSON files: %v", err)
+		slog.Warn("failed to clean up GeoJSON files", "error", err)
 		// Don't return error as the loading was successful
 	}
 	
@@ -146,16 +148,12 @@ func loadMissingCounties(loadedCounties map[string]bool) error {
 
 // cleanupGeoJSONFiles removes GeoJSON and meta files after data has been loaded into database
 func cleanupGeoJSONFiles() error {
-	log.Println("Cleaning up GeoJSON files to save disk space...")
+	slog.Info("cleaning up GeoJSON files to save disk space")
 	
-	// Check if we're in production environment
-	isProd := os.Getenv("ENV") == "production" || os.Getenv("GO_ENV") == "production"
-	
-	// Also check if CLEANUP_GEOJSON is explicitly set
-	cleanupEnabled := os.Getenv("CLEANUP_GEOJSON") == "true"
-	
-	if !isProd && !cleanupEnabled {
-		log.Println("Skipping GeoJSON cleanup in development environment. Set CLEANUP_GEOJSON=true to force cleanup.")
+	// Always clean up in production; elsewhere only when CLEANUP_GEOJSON is set
+	cfg := config.Get()
+	if !cfg.IsProduction() && !cfg.Migrations.CleanupGeoJSON {
+		slog.Info("skipping GeoJSON cleanup in development; set CLEANUP_GEOJSON=true to force it")
 		return nil
 	}
 	
@@ -171,7 +169,7 @@ func cleanupGeoJSONFiles() error {
 	for _, pattern := range patterns {
 		files, err := filepath.Glob(pattern)
 		if err != nil {
-			log.Printf("Warning: Failed to find files with pattern %s: %v", pattern, err)
+			slog.Warn("failed to find files", "pattern", pattern, "error", err)
 			continue
 		}
 		
@@ -183,7 +181,7 @@ func cleanupGeoJSONFiles() error {
 			
 			// Delete the file
 			if err := os.Remove(filePath); err != nil {
-				log.Printf("Warning: Failed to delete %s: %v", filePath, err)
+				slog.Warn("failed to delete file", "path", filePath, "error", err)
 				continue
 			}
 			
@@ -194,15 +192,14 @@ func cleanupGeoJSONFiles() error {
 	// Convert bytes to human readable format
 	sizeFreedMB := float64(totalSizeFreed) / (1024 * 1024)
 	
-	log.Printf("Successfully cleaned up %d GeoJSON files, freed %.2f MB of disk space", 
-		totalFilesDeleted, sizeFreedMB)
+	slog.Info("cleaned up GeoJSON files", "files", totalFilesDeleted, "freed_mb", sizeFreedMB)
 	
 	// Remove the oh directory if it's empty
 	if entries, err := os.ReadDir("oh"); err == nil && len(entries) == 0 {
 		if err := os.Remove("oh"); err != nil {
-			log.Printf("Warning: Failed to remove empty oh directory: %v", err)
+			slog.Warn("failed to remove empty oh directory", "error", err)
 		} else {
-			log.Println("Removed empty oh directory")
+			slog.Info("removed empty oh directory")
 		}
 	}
 	
@@ -239,14 +236,7 @@ func loadCountyAddresses(county, filePath string) (int, error) {
 	}
 	// Detect format and parse accordingly
 	
-	var features []struct {
-		Type     string `json:"type"`
-		Geometry struct {
-			Type        string    `json:"type"`
-			Coordinates []float64 `json:"coordinates"`
-		} `json:"geometry"`
-		Properties map[string]interface{} `json:"properties"`
-	}
+	var features []countyAddressFeature
 
 	if isNDJSON {
 		// Parse newline-delimited JSON
@@ -258,14 +248,7 @@ func loadCountyAddresses(county, filePath string) (int, error) {
 		lineCount := 0
 		for scanner.Scan() {
 			lineCount++
-			var feature struct {
-				Type     string `json:"type"`
-				Geometry struct {
-					Type        string    `json:"type"`
-					Coordinates []float64 `json:"coordinates"`
-				} `json:"geometry"`
-				Properties map[string]interface{} `json:"properties"`
-			}
+			var feature countyAddressFeature
 			
 			if err := json.Unmarshal(scanner.Bytes(), &feature); err != nil {
 				if lineCount <= 3 {
@@ -286,14 +269,7 @@ func loadCountyAddresses(county, filePath string) (int, error) {
 		// Parse FeatureCollection format
 		var geoJSON struct {
 			Type     string `json:"type"`
-			Features []struct {
-				Type     string `json:"type"`
-				Geometry struct {
-					Type        string    `json:"type"`
-					Coordinates []float64 `json:"coordinates"`
-				} `json:"geometry"`
-				Properties map[string]interface{} `json:"properties"`
-			} `json:"features"`
+			Features []countyAddressFeature `json:"features"`
 		}
 
 		decoder := json.NewDecoder(file)
@@ -308,89 +284,84 @@ func loadCountyAddresses(county, filePath string) (int, error) {
 		return 0, nil
 	}
 
-	// Prepare batch insert - using actual table schema from migration
-	stmt, err := database.DB.Prepare(`
-		INSERT INTO ohio_addresses (
-			hash, house_number, street, unit, city, district, region, postcode, county, geom
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, ST_SetSRID(ST_MakePoint($10, $11), 4326))
-		ON CONFLICT (hash) DO NOTHING
-	`)
-	if err != nil {
-		return 0, fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
-
-	insertedCount := 0
-
+	// Rows reach the database as COPY data, never as SQL text
+	writer := newAddressBulkWriter(database.DB, defaultAddressBatchSize)
 	for _, feature := range features {
-		if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+		address, ok := countyAddressFromFeature(county, feature)
+		if !ok {
 			continue
 		}
+		if err := writer.Add(address); err != nil {
+			return writer.inserted, fmt.Errorf("failed to insert addresses: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return writer.inserted, fmt.Errorf("failed to insert addresses: %w", err)
+	}
 
-		// Extract properties
-		props := feature.Properties
-		
-		// Get coordinates (GeoJSON is [longitude, latitude])
-		longitude := feature.Geometry.Coordinates[0]
-		latitude := feature.Geometry.Coordinates[1]
+	return writer.inserted, nil
+}
 
-		// Extract address components with various possible field names from Ohio LBRS shapefiles and OpenAddresses
-	houseNumber := getStringProperty(props, "number", "HOUSENUM", "HouseNum", "house_number", "housenumber")
-	streetName := getStringProperty(props, "street", "ST_NAME", "StreetName", "street_name", "STREETNAME", "LSN")
-	unit := getStringProperty(props, "unit", "UNITNUM", "Unit", "UNIT")
-	city := getStringProperty(props, "city", "USPS_CITY", "City", "CITY", "MUNI")
-	state := getStringProperty(props, "region", "STATE", "State", "state", "REGION")
-	// Truncate state to 2 characters to match database schema VARCHAR(2)
-	if len(state) > 2 {
-		state = state[:2]
+// countyAddressFeature is a feature from a county address GeoJSON file
+type countyAddressFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// countyAddressFromFeature maps a point feature to an address, reporting
+// false for features without a point or any address. Property values are
+// kept verbatim.
+func countyAddressFromFeature(county string, feature countyAddressFeature) (models.OhioAddress, bool) {
+	if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+		return models.OhioAddress{}, false
 	}
-	zipCode := getStringProperty(props, "postcode", "ZIPCODE", "ZipCode", "zip_code", "POSTCODE")		
-		// Use existing hash if available (OpenAddresses format), otherwise generate one
-		hash := getStringProperty(props, "hash")
-		if hash == "" {
-			hash = fmt.Sprintf("%s_%s_%s_%f_%f", county, houseNumber, streetName, latitude, longitude)
-		}
-		
-		// Skip if no meaningful address data
-		if houseNumber == "" && streetName == "" {
-			continue
-		}
+	props := feature.Properties
 
-		// Insert record - matching migration schema
-		_, err = stmt.Exec(
-			hash,
-			houseNumber,
-			streetName,
-			unit,
-			city,
-			"", // district - not in Ohio LBRS data
-			state,
-			zipCode,
-			strings.Title(county),
-			longitude, // PostGIS point needs lon, lat
-			latitude,
-		)
-		if err != nil {
-			// Skip duplicate key errors silently
-			if !strings.Contains(err.Error(), "duplicate key") {
-				log.Printf("Warning: Failed to insert record for %s %s: %v", houseNumber, streetName, err)
-			}
-			continue
-		}
+	// Extract address components with various possible field names from Ohio LBRS shapefiles and OpenAddresses
+	address := models.OhioAddress{
+		HouseNumber: getStringProperty(props, "number", "HOUSENUM", "HouseNum", "house_number", "housenumber"),
+		Street:      getStringProperty(props, "street", "ST_NAME", "StreetName", "street_name", "STREETNAME", "LSN"),
+		Unit:        getStringProperty(props, "unit", "UNITNUM", "Unit", "UNIT"),
+		City:        getStringProperty(props, "city", "USPS_CITY", "City", "CITY", "MUNI"),
+		Region:      getStringProperty(props, "region", "STATE", "State", "state", "REGION"),
+		Postcode:    getStringProperty(props, "postcode", "ZIPCODE", "ZipCode", "zip_code", "POSTCODE"),
+		County:      strings.Title(county),
+		// GeoJSON is [longitude, latitude]
+		Longitude: feature.Geometry.Coordinates[0],
+		Latitude:  feature.Geometry.Coordinates[1],
+	}
 
-		insertedCount++
+	// Skip if no meaningful address data
+	if address.HouseNumber == "" && address.Street == "" {
+		return models.OhioAddress{}, false
 	}
 
-	return insertedCount, nil
+	// Truncate state to 2 characters to match database schema VARCHAR(2)
+	if region := []rune(address.Region); len(region) > 2 {
+		address.Region = string(region[:2])
+	}
+
+	// Use existing hash if available (OpenAddresses format), otherwise generate one
+	address.Hash = getStringProperty(props, "hash")
+	if address.Hash == "" {
+		address.Hash = fmt.Sprintf("%s_%s_%s_%f_%f", county, address.HouseNumber, address.Street,
+			address.Latitude, address.Longitude)
+	}
+	return address, true
 }
 
-// getStringProperty extracts a string property from a map, trying multiple possible keys
+// getStringProperty extracts a string property from a map, trying multiple
+// possible keys. NUL characters are dropped because Postgres text can't hold them.
 func getStringProperty(props map[string]interface{}, keys ...string) string {
 	for _, key := range keys {
 		if val, ok := props[key]; ok && val != nil {
 			switch v := val.(type) {
 			case string:
-				return strings.TrimSpace(v)
+				return strings.TrimSpace(strings.ReplaceAll(v, "\x00", ""))
 			case float64:
 				return fmt.Sprintf("%.0f", v)
 			case int:
@@ -415,7 +386,7 @@ func decompressIfNeeded(geojsonPath string) error {
 		return nil
 	}
 	
-	log.Printf("Decompressing %s...", filepath.Base(compressedPath))
+	slog.Info("decompressing file", "file", filepath.Base(compressedPath))
 	
 	// Open compressed file
 	compressedFile, err := os.Open(compressedPath)
@@ -445,6 +416,6 @@ func decompressIfNeeded(geojsonPath string) error {
 		return fmt.Errorf("failed to decompress: %w", err)
 	}
 	
-	log.Printf("Successfully decompressed %s", filepath.Base(geojsonPath))
+	slog.Info("decompressed file", "file", filepath.Base(geojsonPath))
 	return nil
 }
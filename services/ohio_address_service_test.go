@@ -0,0 +1,130 @@
+package services
+
+import (
+	"testing"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adversarialValues are property values that would break or inject into SQL
+// built by string concatenation, or into COPY text framing
+var adversarialValues = []string{
+	"O'Brien",
+	"'; DROP TABLE ohio_addresses; --",
+	`Robert'); DELETE FROM users WHERE ('1'='1`,
+	`back\slash\`,
+	`\N`,
+	`"double" quotes`,
+	"tab\tand\nnew\rline",
+	"$1::text",
+	"E'\\x41'",
+	"Straße ☃ 名前",
+}
+
+func pointFeature(props map[string]interface{}) countyAddressFeature {
+	var feature countyAddressFeature
+	feature.Type = "Feature"
+	feature.Geometry.Type = "Point"
+	feature.Geometry.Coordinates = []float64{-84.5, 39.1}
+	feature.Properties = props
+	return feature
+}
+
+func TestCountyAddressFromFeatureKeepsValuesVerbatim(t *testing.T) {
+	for _, value := range adversarialValues {
+		t.Run(value, func(t *testing.T) {
+			address, ok := countyAddressFromFeature("hamilton", pointFeature(map[string]interface{}{
+				"hash":     value,
+				"number":   "12" + value,
+				"street":   value,
+				"unit":     value,
+				"city":     value,
+				"postcode": value,
+			}))
+			require.True(t, ok)
+
+			assert.Equal(t, value, address.Hash)
+			assert.Equal(t, "12"+value, address.HouseNumber)
+			assert.Equal(t, value, address.Unit)
+			assert.Equal(t, value, address.City)
+			assert.Equal(t, value, address.Postcode)
+			assert.Equal(t, "Hamilton", address.County)
+			assert.Equal(t, -84.5, address.Longitude)
+			assert.Equal(t, 39.1, address.Latitude)
+		})
+	}
+}
+
+func TestCountyAddressFromFeature(t *testing.T) {
+	address, ok := countyAddressFromFeature("hamilton", pointFeature(map[string]interface{}{
+		"HOUSENUM": float64(2525),
+		"ST_NAME":  "Oak\x00ley Dr",
+		"STATE":    "ÖH-extra",
+	}))
+	require.True(t, ok)
+	assert.Equal(t, "2525", address.HouseNumber)
+	assert.Equal(t, "Oakley Dr", address.Street, "NUL characters are dropped")
+	assert.Equal(t, "ÖH", address.Region, "region is truncated by character, not byte")
+	assert.Equal(t, "hamilton_2525_Oakley Dr_39.100000_-84.500000", address.Hash)
+
+	_, ok = countyAddressFromFeature("hamilton", pointFeature(map[string]interface{}{"city": "Cincinnati"}))
+	assert.False(t, ok, "features without a house number or street are skipped")
+
+	line := pointFeature(map[string]interface{}{"street": "Main St"})
+	line.Geometry.Type = "LineString"
+	_, ok = countyAddressFromFeature("hamilton", line)
+	assert.False(t, ok, "non-point features are skipped")
+}
+
+func TestAddressBulkWriterStoresAdversarialValues(t *testing.T) {
+	if err := database.InitDB(); err != nil {
+		t.Skipf("database not available: %v", err)
+	}
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	const county = "Adversarial Test"
+	cleanup := func() {
+		database.DB.Exec("DELETE FROM ohio_addresses WHERE county = $1", county)
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	writer := newAddressBulkWriter(database.DB, 3)
+	for i, value := range adversarialValues {
+		require.NoError(t, writer.Add(models.OhioAddress{
+			Hash:        "adversarial-" + value,
+			HouseNumber: value,
+			Street:      value,
+			City:        value,
+			County:      county,
+			Longitude:   -84.5,
+			Latitude:    39.1 + float64(i)/1000,
+		}))
+	}
+	require.NoError(t, writer.Flush())
+	assert.Equal(t, len(adversarialValues), writer.inserted)
+
+	for _, value := range adversarialValues {
+		var houseNumber, street, city string
+		err := database.DB.QueryRow(
+			"SELECT house_number, street, city FROM ohio_addresses WHERE hash = $1",
+			"adversarial-"+value,
+		).Scan(&houseNumber, &street, &city)
+		require.NoError(t, err, value)
+		assert.Equal(t, value, houseNumber)
+		assert.Equal(t, value, street)
+		assert.Equal(t, value, city)
+	}
+
+	var tables int
+	require.NoError(t, database.DB.QueryRow(
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_name IN ('ohio_addresses', 'users')",
+	).Scan(&tables))
+	assert.Equal(t, 2, tables, "no value was executed as SQL")
+}
@@ -0,0 +1,544 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// organizationInvitationLifetime is how long an emailed invitation stays valid
+const organizationInvitationLifetime = 7 * 24 * time.Hour
+
+var (
+	// ErrOrganizationNotFound is returned for unknown organizations and for
+	// organizations the user isn't a member of
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrOrgPermissionDenied is returned when the user's role doesn't allow the action
+	ErrOrgPermissionDenied = errors.New("your organization role does not allow this action")
+	// ErrOrgMemberNotFound is returned when the target user isn't a member
+	ErrOrgMemberNotFound = errors.New("member not found")
+	// ErrLastOrgOwner is returned when a change would leave the organization without an owner
+	ErrLastOrgOwner = errors.New("an organization must keep at least one owner")
+	// ErrAlreadyOrgMember is returned when inviting someone who is already a member
+	ErrAlreadyOrgMember = errors.New("user is already a member of this organization")
+	// ErrInvitationInvalid is returned for unknown, expired or already accepted invitations
+	ErrInvitationInvalid = errors.New("invalid or expired invitation")
+	// ErrInvitationEmailMismatch is returned when an invitation is accepted
+	// from an account with a different email address
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+)
+
+// OrganizationService manages organizations, their members and pooled quota
+type OrganizationService struct{}
+
+var Organizations = &OrganizationService{}
+
+const organizationFields = `o.id, o.name, o.plan_type, o.monthly_limit, o.created_by, o.created_at, o.updated_at,
+	(SELECT COUNT(*) FROM organization_members c WHERE c.organization_id = o.id)`
+
+func scanOrganization(scanner interface{ Scan(...interface{}) error }, extra ...interface{}) (*models.Organization, error) {
+	var org models.Organization
+	dest := append([]interface{}{&org.ID, &org.Name, &org.PlanType, &org.MonthlyLimit, &org.CreatedBy,
+		&org.CreatedAt, &org.UpdatedAt, &org.MemberCount}, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// IsValidOrgRole reports whether role is one of models.OrgRoles
+func IsValidOrgRole(role string) bool {
+	for _, r := range models.OrgRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateOrganization creates an organization with userID as its owner
+func (o *OrganizationService) CreateOrganization(ctx context.Context, userID int, name string) (*models.Organization, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orgID int
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO organizations (name, plan_type, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id
+	`, name, models.DefaultPlanID, userID).Scan(&orgID); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, orgID, userID, models.OrgRoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit organization: %w", err)
+	}
+
+	return o.GetOrganization(ctx, orgID, userID)
+}
+
+// GetUserOrganizations lists the organizations userID belongs to
+func (o *OrganizationService) GetUserOrganizations(ctx context.Context, userID int) ([]models.Organization, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+organizationFields+`, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		var role string
+		org, err := scanOrganization(rows, &role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		org.Role = role
+		orgs = append(orgs, *org)
+	}
+	return orgs, rows.Err()
+}
+
+// GetOrganization returns an organization userID is a member of
+func (o *OrganizationService) GetOrganization(ctx context.Context, orgID, userID int) (*models.Organization, error) {
+	var role string
+	org, err := scanOrganization(database.DB.QueryRowContext(ctx, `
+		SELECT `+organizationFields+`, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE o.id = $1 AND m.user_id = $2
+	`, orgID, userID), &role)
+	if err == sql.ErrNoRows {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	org.Role = role
+	return org, nil
+}
+
+// RequireRole returns userID's role in the organization, or
+// ErrOrgPermissionDenied when it isn't one of roles
+func (o *OrganizationService) RequireRole(ctx context.Context, orgID, userID int, roles ...string) (string, error) {
+	var role string
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrOrganizationNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get organization role: %w", err)
+	}
+	for _, r := range roles {
+		if r == role {
+			return role, nil
+		}
+	}
+	return role, ErrOrgPermissionDenied
+}
+
+// GetMembers lists an organization's members; any member may call it
+func (o *OrganizationService) GetMembers(ctx context.Context, orgID, userID int) ([]models.OrganizationMember, error) {
+	if _, err := o.RequireRole(ctx, orgID, userID, models.OrgRoles...); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT u.id, u.email, u.name, m.role, m.created_at
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY m.created_at
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.OrganizationMember{}
+	for rows.Next() {
+		var m models.OrganizationMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Name, &m.Role, &m.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// InviteMember emails an invitation to join the organization. Only owners
+// may invite; an earlier pending invitation to the same address is replaced.
+func (o *OrganizationService) InviteMember(ctx context.Context, orgID, inviterID int, email, role string) (*models.OrganizationInvitation, error) {
+	if _, err := o.RequireRole(ctx, orgID, inviterID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+	email = strings.TrimSpace(email)
+
+	var isMember bool
+	if err := database.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM organization_members m JOIN users u ON u.id = m.user_id
+			WHERE m.organization_id = $1 AND LOWER(u.email) = LOWER($2)
+		)
+	`, orgID, email).Scan(&isMember); err != nil {
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+	if isMember {
+		return nil, ErrAlreadyOrgMember
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	if _, err := database.DB.ExecContext(ctx, `
+		DELETE FROM organization_invitations
+		WHERE organization_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL
+	`, orgID, email); err != nil {
+		return nil, fmt.Errorf("failed to replace invitation: %w", err)
+	}
+
+	invitation := models.OrganizationInvitation{OrganizationID: orgID, Email: email, Role: role, InvitedBy: &inviterID}
+	if err := database.DB.QueryRowContext(ctx, `
+		INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, expires_at, created_at
+	`, orgID, email, role, hashToken(token), inviterID, time.Now().Add(organizationInvitationLifetime)).Scan(
+		&invitation.ID, &invitation.ExpiresAt, &invitation.CreatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	var orgName, inviterName string
+	if err := database.DB.QueryRowContext(ctx, `
+		SELECT o.name, u.name FROM organizations o, users u WHERE o.id = $1 AND u.id = $2
+	`, orgID, inviterID).Scan(&orgName, &inviterName); err != nil {
+		return nil, fmt.Errorf("failed to load invitation details: %w", err)
+	}
+
+	link := AppURL() + "/dashboard?invitation=" + url.QueryEscape(token)
+	if err := NewMailer().Send(EmailMessage{
+		To:      email,
+		Subject: fmt.Sprintf("%s invited you to %s on GeoCode API", inviterName, orgName),
+		Body: fmt.Sprintf("%s invited you to join the %s organization on GeoCode API as %s.\n\n"+
+			"Sign in or create an account with this email address, then open the link below to accept:\n\n"+
+			"%s\n\n"+
+			"The invitation expires in 7 days.\n",
+			inviterName, orgName, role, link),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// GetInvitations lists an organization's pending invitations; owners only
+func (o *OrganizationService) GetInvitations(ctx context.Context, orgID, userID int) ([]models.OrganizationInvitation, error) {
+	if _, err := o.RequireRole(ctx, orgID, userID, models.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, organization_id, email, role, invited_by, expires_at, created_at, accepted_at
+		FROM organization_invitations
+		WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []models.OrganizationInvitation{}
+	for rows.Next() {
+		var inv models.OrganizationInvitation
+		if err := rows.Scan(&inv.ID, &inv.OrganizationID, &inv.Email, &inv.Role, &inv.InvitedBy,
+			&inv.ExpiresAt, &inv.CreatedAt, &inv.AcceptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, rows.Err()
+}
+
+// RevokeInvitation deletes a pending invitation; owners only
+func (o *OrganizationService) RevokeInvitation(ctx context.Context, orgID, userID, invitationID int) error {
+	if _, err := o.RequireRole(ctx, orgID, userID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	result, err := database.DB.ExecContext(ctx, `
+		DELETE FROM organization_invitations
+		WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL
+	`, invitationID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvitationInvalid
+	}
+	return nil
+}
+
+// AcceptInvitation adds userID to the organization the invitation is for.
+// The account's email must match the invited address.
+func (o *OrganizationService) AcceptInvitation(ctx context.Context, token string, userID int) (*models.Organization, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var invitationID, orgID int
+	var email, role string
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, organization_id, email, role FROM organization_invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, hashToken(token)).Scan(&invitationID, &orgID, &email, &role)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up invitation: %w", err)
+	}
+
+	var userEmail string
+	if err := tx.QueryRowContext(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&userEmail); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !strings.EqualFold(userEmail, email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (organization_id, user_id) DO NOTHING
+	`, orgID, userID, role); err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE organization_invitations SET accepted_at = NOW() WHERE id = $1`, invitationID); err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit invitation: %w", err)
+	}
+
+	return o.GetOrganization(ctx, orgID, userID)
+}
+
+// UpdateMemberRole changes a member's role; owners only
+func (o *OrganizationService) UpdateMemberRole(ctx context.Context, orgID, actorID, memberID int, role string) error {
+	if _, err := o.RequireRole(ctx, orgID, actorID, models.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnerChange(ctx, tx, orgID, memberID, role != models.OrgRoleOwner); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2
+	`, orgID, memberID, role); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	return tx.Commit()
+}
+
+// RemoveMember removes a member from the organization and deactivates the
+// organization API keys they created. Owners may remove anyone; other
+// members may only remove themselves.
+func (o *OrganizationService) RemoveMember(ctx context.Context, orgID, actorID, memberID int) error {
+	if actorID != memberID {
+		if _, err := o.RequireRole(ctx, orgID, actorID, models.OrgRoleOwner); err != nil {
+			return err
+		}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnerChange(ctx, tx, orgID, memberID, true); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, orgID, memberID); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET is_active = false WHERE organization_id = $1 AND user_id = $2
+	`, orgID, memberID); err != nil {
+		return fmt.Errorf("failed to deactivate member API keys: %w", err)
+	}
+	return tx.Commit()
+}
+
+// lockOwnerChange locks the organization's memberships and, when the change
+// takes memberID out of the owner role, makes sure another owner remains
+func lockOwnerChange(ctx context.Context, tx *sql.Tx, orgID, memberID int, losesOwner bool) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id, role FROM organization_members WHERE organization_id = $1 FOR UPDATE
+	`, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to lock members: %w", err)
+	}
+	defer rows.Close()
+
+	owners, found, memberIsOwner := 0, false, false
+	for rows.Next() {
+		var userID int
+		var role string
+		if err := rows.Scan(&userID, &role); err != nil {
+			return fmt.Errorf("failed to scan member: %w", err)
+		}
+		if role == models.OrgRoleOwner {
+			owners++
+		}
+		if userID == memberID {
+			found = true
+			memberIsOwner = role == models.OrgRoleOwner
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !found {
+		return ErrOrgMemberNotFound
+	}
+	if losesOwner && memberIsOwner && owners == 1 {
+		return ErrLastOrgOwner
+	}
+	return nil
+}
+
+// GetPlanLimits returns the organization's monthly and daily request
+// limits; -1 means unlimited
+func (o *OrganizationService) GetPlanLimits(ctx context.Context, orgID int) (int, int, error) {
+	var monthlyLimit, dailyLimit int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(o.monthly_limit, p.monthly_limit), p.daily_limit
+		FROM organizations o
+		JOIN plans p ON p.id = o.plan_type
+		WHERE o.id = $1
+	`, orgID).Scan(&monthlyLimit, &dailyLimit)
+	if err == sql.ErrNoRows {
+		return 0, 0, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get organization plan: %w", err)
+	}
+	return monthlyLimit, dailyLimit, nil
+}
+
+// orgUsageCounts returns the organization's billable requests this month and today
+func orgUsageCounts(ctx context.Context, orgID int) (int, int, error) {
+	var monthlyUsage, dailyUsage int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(cost), 0),
+			COALESCE(SUM(cost) FILTER (WHERE created_at >= CURRENT_DATE), 0)
+		FROM usage_records
+		WHERE organization_id = $1 AND billable = true
+		AND created_at >= date_trunc('month', CURRENT_DATE)
+	`, orgID).Scan(&monthlyUsage, &dailyUsage)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get organization usage count: %w", err)
+	}
+	return monthlyUsage, dailyUsage, nil
+}
+
+// CheckRateLimit verifies whether the organization's pooled usage is within
+// its monthly and daily limits
+func (o *OrganizationService) CheckRateLimit(ctx context.Context, orgID int) (bool, int, int, error) {
+	monthlyLimit, dailyLimit, err := o.GetPlanLimits(ctx, orgID)
+	if err != nil {
+		return false, 0, 0, err
+	}
+	monthlyUsage, dailyUsage, err := orgUsageCounts(ctx, orgID)
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	withinMonthlyLimit := monthlyLimit == -1 || monthlyUsage < monthlyLimit
+	withinDailyLimit := dailyLimit == -1 || dailyUsage < dailyLimit
+	return withinMonthlyLimit && withinDailyLimit, monthlyUsage, monthlyLimit, nil
+}
+
+// GetUsage returns the organization's pooled usage and limits with a
+// per-member breakdown; owners and billing members only
+func (o *OrganizationService) GetUsage(ctx context.Context, orgID, userID int) (*models.OrganizationUsage, error) {
+	if _, err := o.RequireRole(ctx, orgID, userID, models.OrgRoleOwner, models.OrgRoleBilling); err != nil {
+		return nil, err
+	}
+
+	usage := &models.OrganizationUsage{OrganizationID: orgID, ByMember: []models.MemberUsageSample{}}
+	if err := database.DB.QueryRowContext(ctx, `SELECT plan_type FROM organizations WHERE id = $1`, orgID).Scan(&usage.PlanType); err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	var err error
+	if usage.MonthlyLimit, usage.DailyLimit, err = o.GetPlanLimits(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if usage.MonthlyUsage, usage.DailyUsage, err = orgUsageCounts(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT u.id, u.email, SUM(ur.cost)
+		FROM usage_records ur
+		JOIN users u ON u.id = ur.user_id
+		WHERE ur.organization_id = $1 AND ur.billable = true
+		AND ur.created_at >= date_trunc('month', CURRENT_DATE)
+		GROUP BY u.id, u.email
+		ORDER BY SUM(ur.cost) DESC
+	`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage by member: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sample models.MemberUsageSample
+		if err := rows.Scan(&sample.UserID, &sample.Email, &sample.Requests); err != nil {
+			return nil, fmt.Errorf("failed to scan member usage: %w", err)
+		}
+		usage.ByMember = append(usage.ByMember, sample)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+var (
+	// ErrOverageUnavailable is returned when opting in to overage billing
+	// while it's turned off or the user's plan has no per-call price
+	ErrOverageUnavailable = errors.New("overage billing is not available")
+	// ErrOverageSpendCapRequired is returned when opting in without a spend cap
+	ErrOverageSpendCapRequired = errors.New("a spend cap is required to enable overage billing")
+	// ErrOverageSpendCapTooHigh is returned for a spend cap above
+	// OVERAGE_MAX_SPEND_CAP
+	ErrOverageSpendCapTooHigh = errors.New("spend cap exceeds the maximum allowed")
+)
+
+// overageUsage is what decides whether a call past the monthly limit can be
+// billed as overage. Limits of -1 are unlimited.
+type overageUsage struct {
+	enabled      bool
+	spendCap     *float64
+	pricePerCall float64
+	monthlyLimit int
+	dailyLimit   int
+	monthlyUsage int
+	dailyUsage   int
+	// overageUnits is the cost of this period's overage calls so far, and
+	// cost that of the call being checked
+	overageUnits int
+	cost         int
+}
+
+// GetOverageSettings returns the user's overage opt-in and what they've spent
+// on overage this billing period
+func (as *AuthService) GetOverageSettings(ctx context.Context, userID int) (*models.OverageSettings, error) {
+	settings := &models.OverageSettings{}
+	var spendCap sql.NullFloat64
+	var overageUnits int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			u.overage_enabled,
+			u.overage_spend_cap,
+			COALESCE(s.price_per_call, p.price_per_call),
+			counts.calls,
+			counts.units
+		FROM users u
+		JOIN plans p ON p.id = u.plan_type
+		LEFT JOIN subscriptions s ON s.user_id = u.id AND s.is_active = true
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS calls, COALESCE(SUM(ur.cost), 0) AS units
+			FROM usage_records ur
+			WHERE ur.user_id = u.id AND ur.billable = true AND ur.overage = true
+			AND ur.created_at >= date_trunc('month', CURRENT_DATE)
+		) counts
+		WHERE u.id = $1 AND u.deleted_at IS NULL
+	`, userID).Scan(&settings.Enabled, &spendCap, &settings.PricePerCall, &settings.OverageCalls, &overageUnits)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overage settings: %w", err)
+	}
+
+	if spendCap.Valid {
+		settings.SpendCap = &spendCap.Float64
+	}
+	settings.Available = config.Get().Billing.Overage && settings.PricePerCall > 0
+	settings.OverageSpend = roundCents(float64(overageUnits) * settings.PricePerCall)
+	return settings, nil
+}
+
+// UpdateOverageSettings opts the user in to or out of overage billing. A nil
+// spendCap keeps the current one; opting in needs a cap either way.
+func (as *AuthService) UpdateOverageSettings(ctx context.Context, userID int, enabled bool, spendCap *float64) (*models.OverageSettings, error) {
+	settings, err := as.GetOverageSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	spendCap, err = validateOverageSettings(*settings, enabled, spendCap, config.Get().Billing.MaxOverageSpendCap)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		UPDATE users SET overage_enabled = $2, overage_spend_cap = $3, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, userID, enabled, spendCap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update overage settings: %w", err)
+	}
+
+	settings.Enabled = enabled
+	settings.SpendCap = spendCap
+	return settings, nil
+}
+
+// validateOverageSettings checks an overage opt-in change against the
+// current settings and returns the spend cap to store
+func validateOverageSettings(current models.OverageSettings, enabled bool, spendCap *float64, maxSpendCap float64) (*float64, error) {
+	if spendCap == nil {
+		spendCap = current.SpendCap
+	} else if *spendCap > maxSpendCap {
+		return nil, ErrOverageSpendCapTooHigh
+	}
+	if !enabled {
+		return spendCap, nil
+	}
+	if !current.Available {
+		return nil, ErrOverageUnavailable
+	}
+	if spendCap == nil {
+		return nil, ErrOverageSpendCapRequired
+	}
+	return spendCap, nil
+}
+
+// AllowOverage reports whether a call CheckRateLimit refused can go ahead as
+// overage: the user opted in, only the monthly limit is used up, and a call
+// costing cost stays under their spend cap. The daily limit, API key caps
+// and organization limits are never billed past.
+func (as *AuthService) AllowOverage(ctx context.Context, userID int, apiKey *models.APIKey, cost int) (bool, error) {
+	if !config.Get().Billing.Overage || (apiKey != nil && apiKey.OrganizationID != nil) {
+		return false, nil
+	}
+
+	usage := overageUsage{cost: cost}
+	var spendCap sql.NullFloat64
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			u.overage_enabled,
+			u.overage_spend_cap,
+			COALESCE(s.price_per_call, p.price_per_call),
+			COALESCE(s.monthly_limit, p.monthly_limit),
+			p.daily_limit,
+			counts.monthly,
+			counts.daily,
+			counts.overage
+		FROM users u
+		JOIN plans p ON p.id = u.plan_type
+		LEFT JOIN subscriptions s ON s.user_id = u.id AND s.is_active = true
+		CROSS JOIN LATERAL (
+			SELECT
+				COALESCE(SUM(ur.cost), 0) AS monthly,
+				COALESCE(SUM(ur.cost) FILTER (WHERE ur.created_at >= CURRENT_DATE), 0) AS daily,
+				COALESCE(SUM(ur.cost) FILTER (WHERE ur.overage = true), 0) AS overage
+			FROM usage_records ur
+			WHERE ur.user_id = u.id AND ur.billable = true
+			AND ur.created_at >= date_trunc('month', CURRENT_DATE)
+		) counts
+		WHERE u.id = $1
+	`, userID).Scan(&usage.enabled, &spendCap, &usage.pricePerCall, &usage.monthlyLimit,
+		&usage.dailyLimit, &usage.monthlyUsage, &usage.dailyUsage, &usage.overageUnits)
+	if err != nil {
+		return false, fmt.Errorf("failed to get overage usage: %w", err)
+	}
+	if spendCap.Valid {
+		usage.spendCap = &spendCap.Float64
+	}
+	if !overageAllowed(usage) {
+		return false, nil
+	}
+
+	if apiKey != nil {
+		keyWithinLimit, _, _, err := as.checkAPIKeyLimit(ctx, apiKey)
+		if err != nil || !keyWithinLimit {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// overageAllowed reports whether a call past the monthly limit can be billed
+// as overage
+func overageAllowed(u overageUsage) bool {
+	if !u.enabled || u.spendCap == nil || u.pricePerCall <= 0 {
+		return false
+	}
+	if u.monthlyLimit < 0 || u.monthlyUsage < u.monthlyLimit {
+		return false
+	}
+	if u.dailyLimit >= 0 && u.dailyUsage >= u.dailyLimit {
+		return false
+	}
+	// Compare in hundredths of a cent so float error can't push the last
+	// affordable call over the cap
+	spend := float64(u.overageUnits+max(u.cost, 1)) * u.pricePerCall
+	return math.Round(spend*10000) <= math.Round(*u.spendCap*10000)
+}
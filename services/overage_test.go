@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverageAllowed(t *testing.T) {
+	spendCap := 10.0
+	// A starter user who has used up their monthly limit but not today's
+	atLimit := overageUsage{
+		enabled:      true,
+		spendCap:     &spendCap,
+		pricePerCall: 0.001,
+		monthlyLimit: 10000,
+		dailyLimit:   1000,
+		monthlyUsage: 10000,
+		dailyUsage:   200,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(u *overageUsage)
+		allowed bool
+	}{
+		{"monthly limit reached", func(u *overageUsage) {}, true},
+		{"not opted in", func(u *overageUsage) { u.enabled = false }, false},
+		{"no spend cap", func(u *overageUsage) { u.spendCap = nil }, false},
+		{"free plan", func(u *overageUsage) { u.pricePerCall = 0 }, false},
+		{"monthly limit not reached", func(u *overageUsage) { u.monthlyUsage = 9999 }, false},
+		{"unlimited plan", func(u *overageUsage) { u.monthlyLimit = -1 }, false},
+		{"daily limit reached", func(u *overageUsage) { u.dailyUsage = 1000 }, false},
+		{"unlimited daily", func(u *overageUsage) { u.dailyLimit = -1; u.dailyUsage = 5000 }, true},
+		{"last call under the cap", func(u *overageUsage) { u.overageUnits = 9999 }, true},
+		{"cap spent", func(u *overageUsage) { u.overageUnits = 10000 }, false},
+		{"heavy call over the cap", func(u *overageUsage) { u.overageUnits = 9950; u.cost = 100 }, false},
+		{"heavy call under the cap", func(u *overageUsage) { u.overageUnits = 9900; u.cost = 100 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := atLimit
+			tt.modify(&u)
+			assert.Equal(t, tt.allowed, overageAllowed(u))
+		})
+	}
+}
+
+func TestValidateOverageSettings(t *testing.T) {
+	dollars := func(v float64) *float64 { return &v }
+	available := models.OverageSettings{Available: true}
+
+	spendCap, err := validateOverageSettings(available, true, dollars(50), 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, *spendCap)
+
+	spendCap, err = validateOverageSettings(models.OverageSettings{Available: true, SpendCap: dollars(25)}, true, nil, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, *spendCap, "the stored cap is kept")
+
+	_, err = validateOverageSettings(available, true, nil, 1000)
+	assert.ErrorIs(t, err, ErrOverageSpendCapRequired)
+
+	_, err = validateOverageSettings(available, true, dollars(5000), 1000)
+	assert.ErrorIs(t, err, ErrOverageSpendCapTooHigh)
+
+	_, err = validateOverageSettings(models.OverageSettings{}, true, dollars(50), 1000)
+	assert.ErrorIs(t, err, ErrOverageUnavailable)
+
+	spendCap, err = validateOverageSettings(models.OverageSettings{}, false, nil, 1000)
+	require.NoError(t, err, "opting out is always allowed")
+	assert.Nil(t, spendCap)
+}
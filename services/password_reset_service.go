@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordResetTokenInvalid is returned for unknown, expired or already used reset tokens
+var ErrPasswordResetTokenInvalid = errors.New("invalid or expired password reset token")
+
+// passwordResetCooldown is how long after a reset link is sent before another
+// one goes to the same account, whatever address the requests come from
+const passwordResetCooldown = 5 * time.Minute
+
+// RequestPasswordReset emails a single-use reset link to the account with
+// this email. Unknown or inactive emails are silently ignored so the
+// endpoint can't be used to discover which addresses are registered, and so
+// are requests within passwordResetCooldown of the last link sent, so it
+// can't be used to flood an inbox either.
+func (as *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	var userID int
+	var name string
+	err := database.DB.QueryRowContext(ctx, `SELECT id, name FROM users WHERE email = $1 AND is_active = true`, email).Scan(&userID, &name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	// Only the newest token is kept, so its age is when the last link was sent
+	var recent bool
+	if err := database.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM password_reset_tokens
+			WHERE user_id = $1 AND created_at > NOW() - $2 * INTERVAL '1 second'
+		)
+	`, userID, passwordResetCooldown.Seconds()).Scan(&recent); err != nil {
+		return fmt.Errorf("failed to check recent reset tokens: %w", err)
+	}
+	if recent {
+		slog.Info("skipping password reset within cooldown", "user_id", userID)
+		return nil
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+	lifetime := config.Get().Auth.PasswordResetTokenLifetime
+
+	// Only the newest link works; earlier ones are dropped along with used ones
+	if _, err := database.DB.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear old reset tokens: %w", err)
+	}
+	if _, err := database.DB.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, userID, hashToken(token), time.Now().Add(lifetime)); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	link := AppURL() + "/auth/reset-password?token=" + url.QueryEscape(token)
+	return NewMailer().Send(EmailMessage{
+		To:      email,
+		Subject: "Reset your GeoCode API password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset the password for your GeoCode API account.\n"+
+			"Use the link below to choose a new one. It expires in %s and can only be used once.\n\n"+
+			"%s\n\n"+
+			"If you didn't ask for this, you can ignore this email; your password won't change.\n",
+			name, lifetime, link),
+	})
+}
+
+// ResetPassword sets a new password using a reset token, consumes the token
+// and signs the user out of every session
+func (as *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenID, userID int
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		FOR UPDATE
+	`, hashToken(token)).Scan(&tokenID, &userID)
+	if err == sql.ErrNoRows {
+		return ErrPasswordResetTokenInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up reset token: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id = $1`, userID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1`, tokenID); err != nil {
+		return fmt.Errorf("failed to consume reset token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit password reset: %w", err)
+	}
+
+	if err := as.RevokeUserSessions(ctx, userID); err != nil {
+		slog.Warn("failed to revoke sessions after password reset", "user_id", userID, "error", err)
+	}
+	return nil
+}
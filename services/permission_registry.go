@@ -0,0 +1,134 @@
+package services
+
+import (
+	"sort"
+	"sync"
+)
+
+// PermissionDefinition describes an API key permission and the routes it grants
+type PermissionDefinition struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Aliases     []string    `json:"aliases,omitempty"`
+	Routes      []RouteInfo `json:"routes"`
+}
+
+// RouteInfo identifies a registered route
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// PermissionRegistry is the single source of truth for permission strings.
+// Routes register the permission they require at startup; HasPermission and
+// API key validation read from the same registry so they cannot drift.
+type PermissionRegistry struct {
+	mu          sync.RWMutex
+	definitions map[string]*PermissionDefinition
+	aliases     map[string]string    // alias -> canonical permission
+	routes      map[RouteInfo]string // route -> required permission
+}
+
+// Permissions is the global permission registry
+var Permissions = newPermissionRegistry()
+
+// Wildcard grants every permission
+const WildcardPermission = "*"
+
+func newPermissionRegistry() *PermissionRegistry {
+	r := &PermissionRegistry{
+		definitions: make(map[string]*PermissionDefinition),
+		aliases:     make(map[string]string),
+		routes:      make(map[RouteInfo]string),
+	}
+
+	r.define("geocode", "Look up ZIP code details")
+	r.define("search", "Search ZIP codes by city")
+	r.define("distance", "Distance, nearby, proximity and drive-time coverage calculations", "nearby", "proximity")
+	r.define("addresses", "Address search, lookup and street index")
+	r.define("counties", "County listings and boundaries")
+	r.define("cities", "City search and lookup")
+	r.define("states", "State search, lookup and boundaries")
+	r.define("crosswalk", "ZIP code to county and CBSA crosswalks")
+	r.define("districts", "Congressional, state legislative and school district lookup")
+	r.define("cells", "H3 and geohash cell encoding and decoding")
+	r.define("tiles", "Vector tiles of addresses, counties and states")
+	r.define("geofences", "Manage geofences and test points and addresses against them")
+
+	return r
+}
+
+// define adds a permission with optional legacy aliases
+func (r *PermissionRegistry) define(name, description string, aliases ...string) {
+	r.definitions[name] = &PermissionDefinition{
+		Name:        name,
+		Description: description,
+		Aliases:     aliases,
+		Routes:      []RouteInfo{},
+	}
+	for _, alias := range aliases {
+		r.aliases[alias] = name
+	}
+}
+
+// Canonical resolves aliases to their canonical permission name
+func (r *PermissionRegistry) Canonical(permission string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if canonical, ok := r.aliases[permission]; ok {
+		return canonical
+	}
+	return permission
+}
+
+// IsValid reports whether a permission string may be assigned to an API key
+func (r *PermissionRegistry) IsValid(permission string) bool {
+	if permission == WildcardPermission {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.definitions[permission]; ok {
+		return true
+	}
+	_, ok := r.aliases[permission]
+	return ok
+}
+
+// RegisterRoute records the permission a route requires. It panics on an
+// undefined permission so a typo fails at startup rather than as a 403.
+func (r *PermissionRegistry) RegisterRoute(method, path, permission string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	def, ok := r.definitions[permission]
+	if !ok {
+		panic("permission registry: route " + method + " " + path + " uses undefined permission " + permission)
+	}
+	route := RouteInfo{Method: method, Path: path}
+	r.routes[route] = permission
+	def.Routes = append(def.Routes, route)
+}
+
+// PermissionForRoute returns the permission required by a registered route
+func (r *PermissionRegistry) PermissionForRoute(method, path string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	permission, ok := r.routes[RouteInfo{Method: method, Path: path}]
+	return permission, ok
+}
+
+// Definitions returns all permission definitions sorted by name
+func (r *PermissionRegistry) Definitions() []PermissionDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]PermissionDefinition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		copied := *def
+		copied.Routes = append([]RouteInfo{}, def.Routes...)
+		defs = append(defs, copied)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+var (
+	// ErrPlanUnchanged is returned when changing to the plan the user is
+	// already on
+	ErrPlanUnchanged = errors.New("already on this plan")
+	// ErrPlanChangeBlocked is returned when the user's current usage
+	// doesn't fit under the new plan's limits
+	ErrPlanChangeBlocked = errors.New("current usage exceeds the new plan's limits")
+)
+
+// GetSubscription returns the user's plan, its effective limits and the
+// usage counted against them this billing period
+func (as *AuthService) GetSubscription(ctx context.Context, userID int) (*models.UserSubscription, error) {
+	var planType string
+	sub := &models.UserSubscription{}
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			u.plan_type,
+			COALESCE(s.status, 'active'),
+			(SELECT COALESCE(SUM(ur.cost), 0) FROM usage_records ur
+			 WHERE ur.user_id = u.id AND ur.billable = true
+			 AND ur.created_at >= date_trunc('month', CURRENT_DATE)),
+			(SELECT COALESCE(SUM(ur.cost), 0) FROM usage_records ur
+			 WHERE ur.user_id = u.id AND ur.billable = true
+			 AND ur.created_at >= CURRENT_DATE),
+			(SELECT COUNT(*) FROM custom_addresses ca WHERE ca.user_id = u.id)
+		FROM users u
+		LEFT JOIN subscriptions s ON s.user_id = u.id AND s.is_active = true
+		WHERE u.id = $1 AND u.deleted_at IS NULL
+	`, userID).Scan(&planType, &sub.Status, &sub.MonthlyUsage, &sub.DailyUsage, &sub.CustomAddresses)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	plan, err := Plans.GetPlan(ctx, planType)
+	if err != nil {
+		return nil, err
+	}
+	sub.Plan = *plan
+	if sub.MonthlyLimit, sub.DailyLimit, err = as.GetPlanLimits(ctx, userID); err != nil {
+		return nil, err
+	}
+	sub.PeriodStart, sub.PeriodEnd = billingPeriod(time.Now())
+	return sub, nil
+}
+
+// PreviewPlanChange reports what moving the user to planType would cost
+// and whether their current usage allows it. Only public plans can be
+// chosen; hidden ones are reported as ErrPlanNotFound.
+func (as *AuthService) PreviewPlanChange(ctx context.Context, userID int, planType string) (*models.PlanChangePreview, error) {
+	sub, err := as.GetSubscription(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	next, err := Plans.GetPlan(ctx, planType)
+	if err != nil {
+		return nil, err
+	}
+	if !next.IsPublic {
+		return nil, ErrPlanNotFound
+	}
+	if next.ID == sub.Plan.ID {
+		return nil, ErrPlanUnchanged
+	}
+
+	blockers := planChangeBlockers(*sub, *next)
+	return &models.PlanChangePreview{
+		CurrentPlan: sub.Plan.ID,
+		NewPlan:     next.ID,
+		Proration:   prorate(sub.Plan, *next, time.Now()),
+		Allowed:     len(blockers) == 0,
+		Blockers:    blockers,
+	}, nil
+}
+
+// ChangePlan moves the user to planType when their usage allows it. The
+// new limits apply to the next request, since rate limiting reads the plan
+// on every call. A blocked change returns the preview with
+// ErrPlanChangeBlocked.
+func (as *AuthService) ChangePlan(ctx context.Context, userID int, planType string) (*models.PlanChangePreview, error) {
+	preview, err := as.PreviewPlanChange(ctx, userID, planType)
+	if err != nil {
+		return nil, err
+	}
+	if !preview.Allowed {
+		return preview, ErrPlanChangeBlocked
+	}
+	if _, err := as.UpdateUserPlan(ctx, userID, planType); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// billingPeriod returns the calendar month (UTC) containing now
+func billingPeriod(now time.Time) (start, end time.Time) {
+	now = now.UTC()
+	start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// prorate credits the unused part of the current plan's monthly price and
+// charges the new plan's for the rest of the billing period
+func prorate(current, next models.Plan, now time.Time) models.PlanProration {
+	start, end := billingPeriod(now)
+	remaining := float64(end.Sub(now)) / float64(end.Sub(start))
+
+	credit := roundCents(current.PriceMonthly * remaining)
+	charge := roundCents(next.PriceMonthly * remaining)
+	return models.PlanProration{
+		PeriodStart:       start,
+		PeriodEnd:         end,
+		RemainingFraction: math.Round(remaining*10000) / 10000,
+		Credit:            credit,
+		Charge:            charge,
+		AmountDue:         roundCents(charge - credit),
+	}
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// planChangeBlockers lists the usage that already exceeds a limit of the
+// new plan. Limits of -1 are unlimited.
+func planChangeBlockers(sub models.UserSubscription, next models.Plan) []models.PlanChangeBlocker {
+	blockers := []models.PlanChangeBlocker{}
+	for _, check := range []struct {
+		limit    string
+		usage    int
+		newLimit int
+		message  string
+	}{
+		{"monthly_limit", sub.MonthlyUsage, next.MonthlyLimit, "This month's %d billable requests exceed the %s plan's monthly limit of %d"},
+		{"daily_limit", sub.DailyUsage, next.DailyLimit, "Today's %d billable requests exceed the %s plan's daily limit of %d"},
+		{"custom_address_limit", sub.CustomAddresses, next.CustomAddressLimit, "Your %d custom addresses exceed the %s plan's limit of %d"},
+	} {
+		if check.newLimit < 0 || check.usage <= check.newLimit {
+			continue
+		}
+		blockers = append(blockers, models.PlanChangeBlocker{
+			Limit:    check.limit,
+			Usage:    check.usage,
+			NewLimit: check.newLimit,
+			Message:  fmt.Sprintf(check.message, check.usage, next.Name, check.newLimit),
+		})
+	}
+	return blockers
+}
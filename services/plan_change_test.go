@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingPeriod(t *testing.T) {
+	start, end := billingPeriod(time.Date(2026, 2, 14, 18, 30, 0, 0, time.FixedZone("EST", -5*3600)))
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestProrate(t *testing.T) {
+	starter := models.Plan{ID: "starter", PriceMonthly: 10}
+	pro := models.Plan{ID: "pro", PriceMonthly: 80}
+	// Halfway through a 30-day month
+	now := time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)
+
+	upgrade := prorate(starter, pro, now)
+	assert.Equal(t, 0.5, upgrade.RemainingFraction)
+	assert.Equal(t, 5.0, upgrade.Credit)
+	assert.Equal(t, 40.0, upgrade.Charge)
+	assert.Equal(t, 35.0, upgrade.AmountDue)
+
+	downgrade := prorate(pro, starter, now)
+	assert.Equal(t, -35.0, downgrade.AmountDue, "a downgrade is credited")
+
+	start := prorate(starter, pro, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, 1.0, start.RemainingFraction)
+	assert.Equal(t, 70.0, start.AmountDue)
+}
+
+func TestPlanChangeBlockers(t *testing.T) {
+	free := models.Plan{ID: "free", Name: "Free", MonthlyLimit: 3000, DailyLimit: 500, CustomAddressLimit: 100}
+	enterprise := models.Plan{ID: "enterprise", Name: "Enterprise", MonthlyLimit: -1, DailyLimit: -1, CustomAddressLimit: -1}
+
+	heavy := models.UserSubscription{MonthlyUsage: 4000, DailyUsage: 500, CustomAddresses: 250}
+	blockers := planChangeBlockers(heavy, free)
+	require.Len(t, blockers, 2, "usage at a limit still fits")
+	assert.Equal(t, "monthly_limit", blockers[0].Limit)
+	assert.Equal(t, 4000, blockers[0].Usage)
+	assert.Equal(t, 3000, blockers[0].NewLimit)
+	assert.Contains(t, blockers[0].Message, "Free plan's monthly limit of 3000")
+	assert.Equal(t, "custom_address_limit", blockers[1].Limit)
+
+	assert.Empty(t, planChangeBlockers(heavy, enterprise))
+	assert.NotNil(t, planChangeBlockers(models.UserSubscription{}, free), "no blockers is an empty list")
+}
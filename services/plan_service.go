@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrPlanNotFound is returned when a plan doesn't exist
+	ErrPlanNotFound = errors.New("plan not found")
+	// ErrPlanExists is returned when creating a plan with an ID already in use
+	ErrPlanExists = errors.New("a plan with this ID already exists")
+)
+
+// PlanService manages the pricing plans that rate limiting, subscriptions and
+// /auth/plans read their limits and prices from
+type PlanService struct{}
+
+var Plans = &PlanService{}
+
+const planFields = `id, name, monthly_limit, daily_limit, price_per_call, price_monthly, features,
+	is_public, sort_order, custom_address_limit, created_at, updated_at`
+
+func scanPlan(scanner interface{ Scan(...interface{}) error }) (*models.Plan, error) {
+	var plan models.Plan
+	if err := scanner.Scan(&plan.ID, &plan.Name, &plan.MonthlyLimit, &plan.DailyLimit, &plan.PricePerCall,
+		&plan.PriceMonthly, pq.Array(&plan.Features), &plan.IsPublic, &plan.SortOrder,
+		&plan.CustomAddressLimit, &plan.CreatedAt, &plan.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if plan.Features == nil {
+		plan.Features = []string{}
+	}
+	return &plan, nil
+}
+
+// ListPlans returns plans in display order. Hidden plans are only included
+// when includeHidden is set.
+func (p *PlanService) ListPlans(ctx context.Context, includeHidden bool) ([]models.Plan, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+planFields+`
+		FROM plans
+		WHERE is_public = true OR $1
+		ORDER BY sort_order, id
+	`, includeHidden)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+	defer rows.Close()
+
+	plans := []models.Plan{}
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %w", err)
+		}
+		plans = append(plans, *plan)
+	}
+	return plans, rows.Err()
+}
+
+// GetPlan returns a plan by ID
+func (p *PlanService) GetPlan(ctx context.Context, id string) (*models.Plan, error) {
+	plan, err := scanPlan(database.DB.QueryRowContext(ctx, `SELECT `+planFields+` FROM plans WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+	return plan, nil
+}
+
+// CreatePlan adds a new plan
+func (p *PlanService) CreatePlan(ctx context.Context, plan models.Plan) (*models.Plan, error) {
+	if plan.Features == nil {
+		plan.Features = []string{}
+	}
+
+	created, err := scanPlan(database.DB.QueryRowContext(ctx, `
+		INSERT INTO plans (id, name, monthly_limit, daily_limit, price_per_call, price_monthly, features, is_public, sort_order,
+			custom_address_limit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING `+planFields,
+		plan.ID, plan.Name, plan.MonthlyLimit, plan.DailyLimit, plan.PricePerCall, plan.PriceMonthly,
+		pq.Array(plan.Features), plan.IsPublic, plan.SortOrder, plan.CustomAddressLimit))
+	if err == sql.ErrNoRows {
+		return nil, ErrPlanExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plan: %w", err)
+	}
+	return created, nil
+}
+
+// UpdatePlan replaces a plan's name, limits, prices and listing. Users and
+// organizations on the plan pick up new limits on their next request.
+func (p *PlanService) UpdatePlan(ctx context.Context, id string, plan models.Plan) (*models.Plan, error) {
+	if plan.Features == nil {
+		plan.Features = []string{}
+	}
+
+	updated, err := scanPlan(database.DB.QueryRowContext(ctx, `
+		UPDATE plans
+		SET name = $2, monthly_limit = $3, daily_limit = $4, price_per_call = $5, price_monthly = $6,
+			features = $7, is_public = $8, sort_order = $9, custom_address_limit = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+planFields,
+		id, plan.Name, plan.MonthlyLimit, plan.DailyLimit, plan.PricePerCall, plan.PriceMonthly,
+		pq.Array(plan.Features), plan.IsPublic, plan.SortOrder, plan.CustomAddressLimit))
+	if err == sql.ErrNoRows {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update plan: %w", err)
+	}
+	return updated, nil
+}
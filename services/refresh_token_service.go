@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown, expired or revoked refresh tokens
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token
+	// is presented again; the whole session is revoked since the token has
+	// most likely been copied
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// hashToken returns the hex SHA-256 stored in place of a bearer token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// StartSession begins a new login session for user and returns its first
+// access/refresh token pair
+func (as *AuthService) StartSession(ctx context.Context, user *models.User) (*models.AuthTokens, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	// Expired tokens are only kept around for reuse detection; drop them as
+	// the user logs in again
+	if _, err := database.DB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < NOW()`, user.ID); err != nil {
+		slog.Warn("failed to prune expired refresh tokens", "user_id", user.ID, "error", err)
+	}
+
+	tokens, _, err := as.issueSessionTokens(ctx, database.DB, user, sessionID)
+	return tokens, err
+}
+
+// issueSessionTokens stores a new refresh token for sessionID and signs a
+// matching access token. It returns the refresh token's row ID.
+func (as *AuthService) issueSessionTokens(ctx context.Context, q rowQuerier, user *models.User, sessionID string) (*models.AuthTokens, int, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, 0, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	refreshToken := "rt_" + hex.EncodeToString(tokenBytes)
+
+	now := time.Now()
+	tokens := &models.AuthTokens{
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: now.Add(config.Get().Auth.RefreshTokenLifetime),
+		AccessTokenExpiresAt:  now.Add(loadJWTSettings().lifetime),
+	}
+
+	var id int
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id
+	`, user.ID, sessionID, hashToken(refreshToken), tokens.RefreshTokenExpiresAt).Scan(&id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	tokens.AccessToken, err = as.GenerateSessionJWT(user, sessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return tokens, id, nil
+}
+
+// RefreshSession exchanges a refresh token for a new access token. The
+// refresh token is rotated: the presented one is revoked and a new one is
+// returned in the same session.
+func (as *AuthService) RefreshSession(ctx context.Context, refreshToken string) (*models.AuthTokens, *models.User, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		id        int
+		userID    int
+		sessionID string
+		expiresAt time.Time
+		revokedAt sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, session_id, expires_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = $1
+		FOR UPDATE
+	`, hashToken(refreshToken)).Scan(&id, &userID, &sessionID, &expiresAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+
+	if revokedAt.Valid {
+		tx.Rollback()
+		slog.Warn("revoked refresh token presented, revoking session", "user_id", userID, "session_id", sessionID)
+		if err := as.RevokeRefreshSession(ctx, sessionID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(expiresAt) {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+
+	user, err := as.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.IsActive {
+		return nil, nil, ErrRefreshTokenInvalid
+	}
+
+	tokens, newID, err := as.issueSessionTokens(ctx, tx, user, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $2 WHERE id = $1`, id, newID); err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+
+	return tokens, user, nil
+}
+
+// RevokeRefreshToken ends the session the refresh token belongs to
+func (as *AuthService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	var sessionID string
+	err := database.DB.QueryRowContext(ctx, `SELECT session_id FROM refresh_tokens WHERE token_hash = $1`,
+		hashToken(refreshToken)).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	return as.RevokeRefreshSession(ctx, sessionID)
+}
+
+// RevokeRefreshSession revokes every refresh token in sessionID and the
+// session's outstanding access tokens
+func (as *AuthService) RevokeRefreshSession(ctx context.Context, sessionID string) error {
+	as.RevokeSession(sessionID)
+
+	if _, err := database.DB.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE session_id = $1 AND revoked_at IS NULL
+	`, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions ends every active session of a user, e.g. after a
+// password change
+func (as *AuthService) RevokeUserSessions(ctx context.Context, userID int) error {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT DISTINCT session_id FROM refresh_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var sessionIDs []string
+	for rows.Next() {
+		var sessionID string
+		if err := rows.Scan(&sessionID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	rows.Close()
+
+	for _, sessionID := range sessionIDs {
+		if err := as.RevokeRefreshSession(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeSessionIsImmediateLocally(t *testing.T) {
+	// No database needed: this instance remembers its own logouts
+	Auth.RevokeSession("local-logout")
+	revoked, err := Auth.IsSessionRevoked(context.Background(), "local-logout")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestSessionRevocationSurvivesRestart(t *testing.T) {
+	if err := database.InitDB(); err != nil {
+		t.Skipf("database not available: %v", err)
+	}
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	user := &models.User{Email: fmt.Sprintf("session-test-%d@example.com", time.Now().UnixNano())}
+	require.NoError(t, database.DB.QueryRowContext(ctx, `
+		INSERT INTO users (email, password_hash, is_active, plan_type)
+		VALUES ($1, 'x', true, 'free') RETURNING id
+	`, user.Email).Scan(&user.ID))
+	t.Cleanup(func() { database.DB.Exec(`DELETE FROM users WHERE id = $1`, user.ID) })
+
+	tokens, err := Auth.StartSession(ctx, user)
+	require.NoError(t, err)
+	claims, err := Auth.ValidateJWT(tokens.AccessToken)
+	require.NoError(t, err)
+
+	// Log out, then forget what this instance cached, as after a restart or
+	// on another instance
+	require.NoError(t, Auth.RevokeRefreshToken(ctx, tokens.RefreshToken))
+	sessionStates.Lock()
+	delete(sessionStates.entries, claims.SessionID)
+	sessionStates.Unlock()
+
+	_, err = Auth.ValidateJWT(tokens.AccessToken)
+	assert.Error(t, err, "the revoked refresh tokens revoke the access token")
+
+	// Rotating a refresh token keeps the session live
+	other, err := Auth.StartSession(ctx, user)
+	require.NoError(t, err)
+	rotated, _, err := Auth.RefreshSession(ctx, other.RefreshToken)
+	require.NoError(t, err)
+	_, err = Auth.ValidateJWT(rotated.AccessToken)
+	assert.NoError(t, err)
+}
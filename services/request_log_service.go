@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// ErrRequestNotFound is returned for a usage record that doesn't exist
+var ErrRequestNotFound = errors.New("request not found")
+
+// sensitiveQueryParams are dropped from captured query strings. Keys are
+// normally sent in headers, but nothing stops a client putting one in the URL.
+var sensitiveQueryParams = map[string]bool{
+	"api_key":       true,
+	"apikey":        true,
+	"key":           true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+	"secret":        true,
+	"signature":     true,
+}
+
+// SanitizeQuery drops credentials from a raw query string. A query that
+// can't be parsed is dropped entirely rather than stored as sent.
+func SanitizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	for name := range values {
+		if sensitiveQueryParams[strings.ToLower(name)] {
+			values.Del(name)
+		}
+	}
+	return values.Encode()
+}
+
+// replayContextKey marks a request context as an admin replay of a call
+// made with the API key it holds
+type replayContextKey struct{}
+
+// WithReplayAPIKey marks ctx as a replay running as the API key apiKeyID.
+// Only code in this process can set it, so it can't be forged by clients.
+func WithReplayAPIKey(ctx context.Context, apiKeyID int) context.Context {
+	return context.WithValue(ctx, replayContextKey{}, apiKeyID)
+}
+
+// ReplayAPIKeyFromContext returns the API key a replayed request runs as
+func ReplayAPIKeyFromContext(ctx context.Context) (int, bool) {
+	apiKeyID, ok := ctx.Value(replayContextKey{}).(int)
+	return apiKeyID, ok
+}
+
+// RequestLogService reads recorded API calls for the admin request console
+type RequestLogService struct{}
+
+var RequestLog = &RequestLogService{}
+
+const requestRecordFields = `id, user_id, api_key_id, endpoint, method, path, query_string,
+	status_code, response_time_ms, host(ip_address), user_agent, created_at`
+
+func scanRequestRecord(scanner interface{ Scan(...interface{}) error }) (*models.RequestRecord, error) {
+	var r models.RequestRecord
+	err := scanner.Scan(&r.ID, &r.UserID, &r.APIKeyID, &r.Endpoint, &r.Method, &r.Path, &r.QueryString,
+		&r.StatusCode, &r.ResponseTimeMS, &r.IPAddress, &r.UserAgent, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// List returns a page of recorded calls matching filter, newest first, and
+// the number that match
+func (s *RequestLogService) List(ctx context.Context, filter models.RequestLogFilter) ([]models.RequestRecord, int, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.UserID > 0 {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argIndex))
+		args = append(args, filter.UserID)
+		argIndex++
+	}
+	if filter.MinStatus > 0 {
+		conditions = append(conditions, fmt.Sprintf("status_code >= $%d", argIndex))
+		args = append(args, filter.MinStatus)
+		argIndex++
+	}
+	if filter.MaxStatus > 0 {
+		conditions = append(conditions, fmt.Sprintf("status_code <= $%d", argIndex))
+		args = append(args, filter.MaxStatus)
+		argIndex++
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argIndex))
+		args = append(args, filter.Since)
+		argIndex++
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argIndex))
+		args = append(args, filter.Until)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM usage_records "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count requests: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM usage_records %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, requestRecordFields, whereClause, argIndex, argIndex+1)
+	rows, err := database.DB.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query requests: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.RequestRecord{}
+	for rows.Next() {
+		record, err := scanRequestRecord(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan request: %w", err)
+		}
+		records = append(records, *record)
+	}
+	return records, total, rows.Err()
+}
+
+// Get returns one recorded call
+func (s *RequestLogService) Get(ctx context.Context, id int64) (*models.RequestRecord, error) {
+	row := database.DB.QueryRowContext(ctx, `SELECT `+requestRecordFields+` FROM usage_records WHERE id = $1`, id)
+	record, err := scanRequestRecord(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+	return record, nil
+}
+
+// ReplayDiff lists what changed between a recorded call and its replay
+func ReplayDiff(original models.RequestRecord, replay models.RequestReplayResult) []models.RequestDifference {
+	diff := []models.RequestDifference{}
+	if original.StatusCode == nil || *original.StatusCode != replay.StatusCode {
+		diff = append(diff, models.RequestDifference{Field: "status_code", Original: original.StatusCode, Replay: replay.StatusCode})
+	}
+	if original.ResponseTimeMS == nil || *original.ResponseTimeMS != replay.ResponseTimeMS {
+		diff = append(diff, models.RequestDifference{Field: "response_time_ms", Original: original.ResponseTimeMS, Replay: replay.ResponseTimeMS})
+	}
+	return diff
+}
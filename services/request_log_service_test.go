@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	assert.Equal(t, "lat=39.96&lng=-83", SanitizeQuery("lat=39.96&lng=-83"))
+	assert.Equal(t, "q=main+st", SanitizeQuery("q=main+st&api_key=sk_live_123&Token=abc"))
+	assert.Equal(t, "", SanitizeQuery("password=hunter2"))
+	assert.Equal(t, "", SanitizeQuery("q=%zz"), "an unparsable query is dropped")
+}
+
+func TestReplayAPIKeyContext(t *testing.T) {
+	_, ok := ReplayAPIKeyFromContext(context.Background())
+	assert.False(t, ok)
+
+	apiKeyID, ok := ReplayAPIKeyFromContext(WithReplayAPIKey(context.Background(), 42))
+	assert.True(t, ok)
+	assert.Equal(t, 42, apiKeyID)
+}
+
+func TestReplayDiff(t *testing.T) {
+	status, elapsed := 200, 35
+	original := models.RequestRecord{StatusCode: &status, ResponseTimeMS: &elapsed}
+
+	assert.Empty(t, ReplayDiff(original, models.RequestReplayResult{StatusCode: 200, ResponseTimeMS: 35}))
+
+	diff := ReplayDiff(original, models.RequestReplayResult{StatusCode: 404, ResponseTimeMS: 35})
+	assert.Len(t, diff, 1)
+	assert.Equal(t, "status_code", diff[0].Field)
+	assert.Equal(t, 404, diff[0].Replay)
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"geocoding-api/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisResponseKeyPrefix namespaces cached responses in a shared Redis
+const redisResponseKeyPrefix = "geocoding:response:"
+
+// CachedResponse is a successful response kept by the response cache
+type CachedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+	// Header holds the headers the handler set, such as pagination's Link
+	Header http.Header `json:"header,omitempty"`
+}
+
+// responseStore is where cached responses live. Keys start with the route
+// they were cached for, so a route's responses can be purged together.
+type responseStore interface {
+	// get returns nil, without an error, on a miss
+	get(ctx context.Context, key string) (*CachedResponse, error)
+	set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error
+	// purge drops every key starting with prefix and returns how many it dropped
+	purge(ctx context.Context, prefix string) (int, error)
+	// entries and evicted report the store's size for stats; Redis
+	// tracks neither per key prefix, so it reports zero
+	entries() int
+	evicted() uint64
+}
+
+// responseCache holds the response cache middleware's store, which is nil
+// while response caching is off, and its counters
+var responseCache struct {
+	store      responseStore
+	backend    string
+	maxEntries int
+	ttls       map[string]time.Duration
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+// InitResponseCache sets up the response cache: nothing when RESPONSE_CACHE
+// is unset, a bounded LRU for "memory" and a client for "redis". An
+// unreachable Redis only logs a warning; requests then miss until it comes
+// back.
+func InitResponseCache(cfg config.ResponseCacheConfig) error {
+	responseCache.backend = cfg.Backend
+	responseCache.maxEntries = cfg.MaxEntries
+	responseCache.ttls = cfg.TTLs
+
+	switch cfg.Backend {
+	case "":
+		responseCache.store = nil
+	case "memory":
+		responseCache.store = newMemoryResponseStore(cfg.MaxEntries)
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		// A slow cache must not make lookups slower than Postgres would
+		opts.DialTimeout = 2 * time.Second
+		opts.ReadTimeout = 500 * time.Millisecond
+		opts.WriteTimeout = 500 * time.Millisecond
+		client := redis.NewClient(opts)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			slog.Warn("response cache redis unreachable", "error", err)
+		}
+		responseCache.store = &redisResponseStore{client: client}
+	default:
+		return fmt.Errorf("unknown response cache backend %q", cfg.Backend)
+	}
+	return nil
+}
+
+// ResponseCacheEnabled reports whether responses are being cached
+func ResponseCacheEnabled() bool {
+	return responseCache.store != nil
+}
+
+// ResponseCacheTTL returns how long to cache a route's responses: its
+// RESPONSE_CACHE_TTLS override, or ttl
+func ResponseCacheTTL(route string, ttl time.Duration) time.Duration {
+	if override, ok := responseCache.ttls[route]; ok {
+		return override
+	}
+	return ttl
+}
+
+// responseCacheKey scopes key, a fingerprint of the request, to its route
+func responseCacheKey(route, key string) string {
+	return route + "|" + key
+}
+
+// GetCachedResponse returns the response cached for a request to route, or
+// nil. Store errors are logged and count as misses.
+func GetCachedResponse(ctx context.Context, route, key string) *CachedResponse {
+	if responseCache.store == nil {
+		return nil
+	}
+	resp, err := responseCache.store.get(ctx, responseCacheKey(route, key))
+	if err != nil {
+		slog.Warn("failed to read cached response", "route", route, "error", err)
+	}
+	if resp == nil {
+		responseCache.misses.Add(1)
+		return nil
+	}
+	responseCache.hits.Add(1)
+	return resp
+}
+
+// CacheResponse stores a response for a request to route for ttl. Store
+// errors are logged; the response was already sent.
+func CacheResponse(ctx context.Context, route, key string, resp *CachedResponse, ttl time.Duration) {
+	if responseCache.store == nil || ttl <= 0 {
+		return
+	}
+	if err := responseCache.store.set(ctx, responseCacheKey(route, key), resp, ttl); err != nil {
+		slog.Warn("failed to cache response", "route", route, "error", err)
+	}
+}
+
+// PurgeResponseCache drops the cached responses of route, a route without
+// its version prefix such as "/geocode/:zipcode", or of every route when
+// route is empty. It returns how many responses were dropped.
+func PurgeResponseCache(ctx context.Context, route string) (int, error) {
+	if responseCache.store == nil {
+		return 0, nil
+	}
+	prefix := ""
+	if route != "" {
+		prefix = responseCacheKey(route, "")
+	}
+	n, err := responseCache.store.purge(ctx, prefix)
+	if err != nil {
+		return n, fmt.Errorf("failed to purge response cache: %w", err)
+	}
+	return n, nil
+}
+
+// GetResponseCacheStats returns the response cache's counters. TTLs are set
+// per route, so TTLSeconds is zero.
+func GetResponseCacheStats() CacheStats {
+	name := "responses"
+	if responseCache.backend != "" {
+		name += "_" + responseCache.backend
+	}
+	stats := CacheStats{
+		Name:       name,
+		Enabled:    responseCache.store != nil,
+		MaxEntries: responseCache.maxEntries,
+		Hits:       responseCache.hits.Load(),
+		Misses:     responseCache.misses.Load(),
+	}
+	if responseCache.store != nil {
+		stats.Entries = responseCache.store.entries()
+		stats.Evictions = responseCache.store.evicted()
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// memoryResponseStore is a per-instance LRU of responses with per-entry TTL
+type memoryResponseStore struct {
+	maxEntries int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+
+	evictions atomic.Uint64
+}
+
+type memoryResponseEntry struct {
+	key       string
+	resp      *CachedResponse
+	expiresAt time.Time
+}
+
+func newMemoryResponseStore(maxEntries int) *memoryResponseStore {
+	return &memoryResponseStore{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (s *memoryResponseStore) get(_ context.Context, key string) (*CachedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := elem.Value.(*memoryResponseEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		s.ll.Remove(elem)
+		delete(s.items, key)
+		return nil, nil
+	}
+	s.ll.MoveToFront(elem)
+	return entry.resp, nil
+}
+
+func (s *memoryResponseStore) set(_ context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		s.ll.Remove(elem)
+	}
+	s.items[key] = s.ll.PushFront(&memoryResponseEntry{key: key, resp: resp, expiresAt: time.Now().Add(ttl)})
+	for s.maxEntries > 0 && s.ll.Len() > s.maxEntries {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryResponseEntry).key)
+		s.evictions.Add(1)
+	}
+	return nil
+}
+
+func (s *memoryResponseStore) purge(_ context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key, elem := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.ll.Remove(elem)
+			delete(s.items, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryResponseStore) entries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+func (s *memoryResponseStore) evicted() uint64 {
+	return s.evictions.Load()
+}
+
+// redisResponseStore keeps responses in Redis, shared by every instance.
+// Redis expires entries itself; its maxmemory policy bounds the size.
+type redisResponseStore struct {
+	client *redis.Client
+}
+
+func (s *redisResponseStore) get(ctx context.Context, key string) (*CachedResponse, error) {
+	data, err := s.client.Get(ctx, redisResponseKeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &resp, nil
+}
+
+func (s *redisResponseStore) set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached response: %w", err)
+	}
+	return s.client.Set(ctx, redisResponseKeyPrefix+key, data, ttl).Err()
+}
+
+// purge scans for the prefix rather than tracking keys per route, since
+// keys expire on their own
+func (s *redisResponseStore) purge(ctx context.Context, prefix string) (int, error) {
+	pattern := redisResponseKeyPrefix + redisGlobEscaper.Replace(prefix) + "*"
+	n := 0
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return n, err
+		}
+		if len(keys) > 0 {
+			if err := s.client.Unlink(ctx, keys...).Err(); err != nil {
+				return n, err
+			}
+			n += len(keys)
+		}
+		if cursor = next; cursor == 0 {
+			return n, nil
+		}
+	}
+}
+
+func (s *redisResponseStore) entries() int {
+	return 0
+}
+
+func (s *redisResponseStore) evicted() uint64 {
+	return 0
+}
+
+// redisGlobEscaper escapes the characters SCAN MATCH treats as patterns
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryResponseStore(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryResponseStore(2)
+	resp := &CachedResponse{Status: 200, ContentType: "application/json", Body: []byte(`{}`)}
+
+	require.NoError(t, store.set(ctx, "/geocode/:zipcode|a", resp, time.Hour))
+	require.NoError(t, store.set(ctx, "/geocode/:zipcode|b", resp, time.Hour))
+	got, err := store.get(ctx, "/geocode/:zipcode|a")
+	require.NoError(t, err)
+	assert.Same(t, resp, got)
+
+	// b is now the least recently used
+	require.NoError(t, store.set(ctx, "/states|a", resp, time.Hour))
+	got, _ = store.get(ctx, "/geocode/:zipcode|b")
+	assert.Nil(t, got)
+	assert.Equal(t, uint64(1), store.evicted())
+
+	n, err := store.purge(ctx, "/geocode/:zipcode|")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, store.entries())
+
+	n, _ = store.purge(ctx, "")
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 0, store.entries())
+
+	require.NoError(t, store.set(ctx, "/states|b", resp, -time.Second))
+	got, _ = store.get(ctx, "/states|b")
+	assert.Nil(t, got, "expired")
+}
+
+func TestRedisResponseKeyPattern(t *testing.T) {
+	assert.Equal(t, `/tiles/:layer/\[x\]/\*|`, redisGlobEscaper.Replace("/tiles/:layer/[x]/*|"))
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"geocoding-api/config"
+)
+
+var (
+	// ErrRoutingDisabled is returned when no routing engine is configured
+	ErrRoutingDisabled = errors.New("driving distance is not configured")
+	// ErrNoRoute is returned when the engine finds no road route between the points
+	ErrNoRoute = errors.New("no driving route between the locations")
+	// ErrRoutingUnavailable wraps failures to reach or understand the engine
+	ErrRoutingUnavailable = errors.New("routing engine unavailable")
+)
+
+// RouteSummary is the road distance and travel time of a driving route
+type RouteSummary struct {
+	Engine          string  `json:"engine"`
+	DistanceMiles   float64 `json:"distance_miles"`
+	DistanceKm      float64 `json:"distance_km"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	DurationMinutes float64 `json:"duration_minutes"`
+}
+
+// RoutingService asks the configured OSRM or Valhalla instance for driving
+// routes. Results are cached by engine and coordinates.
+type RoutingService struct {
+	client *http.Client
+}
+
+var Routing = &RoutingService{client: &http.Client{}}
+
+// Route returns the driving route between two points
+func (s *RoutingService) Route(ctx context.Context, fromLat, fromLng, toLat, toLng float64) (*RouteSummary, error) {
+	return s.route(ctx, config.Get().Routing, fromLat, fromLng, toLat, toLng)
+}
+
+func (s *RoutingService) route(ctx context.Context, cfg config.RoutingConfig, fromLat, fromLng, toLat, toLng float64) (*RouteSummary, error) {
+	if cfg.Engine == "" {
+		return nil, ErrRoutingDisabled
+	}
+
+	key := fmt.Sprintf("%s|%.5f,%.5f|%.5f,%.5f", cfg.Engine, fromLat, fromLng, toLat, toLng)
+	return lookupCaches.route.GetOrLoad(key, func() (*RouteSummary, error) {
+		ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+
+		baseURL := strings.TrimRight(cfg.URL, "/")
+		var meters, seconds float64
+		var err error
+		switch cfg.Engine {
+		case "osrm":
+			meters, seconds, err = s.osrmRoute(ctx, baseURL, fromLat, fromLng, toLat, toLng)
+		case "valhalla":
+			meters, seconds, err = s.valhallaRoute(ctx, baseURL, fromLat, fromLng, toLat, toLng)
+		default:
+			err = fmt.Errorf("unsupported routing engine %q", cfg.Engine)
+		}
+		if errors.Is(err, ErrNoRoute) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRoutingUnavailable, err)
+		}
+
+		return &RouteSummary{
+			Engine:          cfg.Engine,
+			DistanceMiles:   meters / 1609.344,
+			DistanceKm:      meters / 1000,
+			DurationSeconds: seconds,
+			DurationMinutes: seconds / 60,
+		}, nil
+	})
+}
+
+// routingMatrixBatch is how many destinations are sent per matrix request,
+// within OSRM's default table size of 100 locations
+const routingMatrixBatch = 99
+
+// DriveDurations returns the driving time in seconds from one point to each
+// destination, or -1 where the engine finds no route
+func (s *RoutingService) DriveDurations(ctx context.Context, fromLat, fromLng float64, destinations [][2]float64) ([]float64, error) {
+	return s.driveDurations(ctx, config.Get().Routing, fromLat, fromLng, destinations)
+}
+
+func (s *RoutingService) driveDurations(ctx context.Context, cfg config.RoutingConfig, fromLat, fromLng float64, destinations [][2]float64) ([]float64, error) {
+	if cfg.Engine == "" {
+		return nil, ErrRoutingDisabled
+	}
+
+	baseURL := strings.TrimRight(cfg.URL, "/")
+	durations := make([]float64, 0, len(destinations))
+	for start := 0; start < len(destinations); start += routingMatrixBatch {
+		batch := destinations[start:min(start+routingMatrixBatch, len(destinations))]
+
+		batchCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		var batchDurations []float64
+		var err error
+		switch cfg.Engine {
+		case "osrm":
+			batchDurations, err = s.osrmTable(batchCtx, baseURL, fromLat, fromLng, batch)
+		case "valhalla":
+			batchDurations, err = s.valhallaMatrix(batchCtx, baseURL, fromLat, fromLng, batch)
+		default:
+			err = fmt.Errorf("unsupported routing engine %q", cfg.Engine)
+		}
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRoutingUnavailable, err)
+		}
+		durations = append(durations, batchDurations...)
+	}
+	return durations, nil
+}
+
+// osrmRoute calls the OSRM route service and returns meters and seconds
+func (s *RoutingService) osrmRoute(ctx context.Context, baseURL string, fromLat, fromLng, toLat, toLng float64) (float64, float64, error) {
+	url := fmt.Sprintf("%s/route/v1/driving/%f,%f;%f,%f?overview=false", baseURL, fromLng, fromLat, toLng, toLat)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Routes  []struct {
+			Distance float64 `json:"distance"`
+			Duration float64 `json:"duration"`
+		} `json:"routes"`
+	}
+	if err := s.do(req, &body); err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case body.Code == "NoRoute" || (body.Code == "Ok" && len(body.Routes) == 0):
+		return 0, 0, ErrNoRoute
+	case body.Code != "Ok":
+		return 0, 0, fmt.Errorf("osrm: %s: %s", body.Code, body.Message)
+	}
+	return body.Routes[0].Distance, body.Routes[0].Duration, nil
+}
+
+// osrmTable calls the OSRM table service for durations from the first
+// coordinate to the rest
+func (s *RoutingService) osrmTable(ctx context.Context, baseURL string, fromLat, fromLng float64, destinations [][2]float64) ([]float64, error) {
+	coordinates := []string{fmt.Sprintf("%f,%f", fromLng, fromLat)}
+	for _, d := range destinations {
+		coordinates = append(coordinates, fmt.Sprintf("%f,%f", d[1], d[0]))
+	}
+	url := fmt.Sprintf("%s/table/v1/driving/%s?sources=0&annotations=duration", baseURL, strings.Join(coordinates, ";"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Code      string       `json:"code"`
+		Message   string       `json:"message"`
+		Durations [][]*float64 `json:"durations"`
+	}
+	if err := s.do(req, &body); err != nil {
+		return nil, err
+	}
+	if body.Code != "Ok" || len(body.Durations) != 1 || len(body.Durations[0]) != len(destinations)+1 {
+		return nil, fmt.Errorf("osrm: %s: %s", body.Code, body.Message)
+	}
+
+	durations := make([]float64, len(destinations))
+	for i, d := range body.Durations[0][1:] {
+		durations[i] = -1
+		if d != nil {
+			durations[i] = *d
+		}
+	}
+	return durations, nil
+}
+
+// valhallaMatrix calls the Valhalla sources_to_targets action for durations
+// from one source to each target
+func (s *RoutingService) valhallaMatrix(ctx context.Context, baseURL string, fromLat, fromLng float64, destinations [][2]float64) ([]float64, error) {
+	targets := make([]map[string]float64, len(destinations))
+	for i, d := range destinations {
+		targets[i] = map[string]float64{"lat": d[0], "lon": d[1]}
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"sources": []map[string]float64{{"lat": fromLat, "lon": fromLng}},
+		"targets": targets,
+		"costing": "auto",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/sources_to_targets", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var body struct {
+		ErrorCode       int    `json:"error_code"`
+		Error           string `json:"error"`
+		SourcesToTarget [][]struct {
+			Time *float64 `json:"time"`
+		} `json:"sources_to_targets"`
+	}
+	if err := s.do(req, &body); err != nil {
+		return nil, err
+	}
+	if body.ErrorCode != 0 || len(body.SourcesToTarget) != 1 || len(body.SourcesToTarget[0]) != len(destinations) {
+		return nil, fmt.Errorf("valhalla: %d: %s", body.ErrorCode, body.Error)
+	}
+
+	durations := make([]float64, len(destinations))
+	for i, target := range body.SourcesToTarget[0] {
+		durations[i] = -1
+		if target.Time != nil {
+			durations[i] = *target.Time
+		}
+	}
+	return durations, nil
+}
+
+// valhallaNoRouteCodes are the Valhalla error codes for points that cannot
+// be connected by road
+var valhallaNoRouteCodes = map[int]bool{171: true, 442: true, 443: true}
+
+// valhallaRoute calls the Valhalla route action and returns meters and seconds
+func (s *RoutingService) valhallaRoute(ctx context.Context, baseURL string, fromLat, fromLng, toLat, toLng float64) (float64, float64, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"locations": []map[string]float64{
+			{"lat": fromLat, "lon": fromLng},
+			{"lat": toLat, "lon": toLng},
+		},
+		"costing":            "auto",
+		"directions_options": map[string]string{"units": "kilometers"},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/route", bytes.NewReader(payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var body struct {
+		ErrorCode int    `json:"error_code"`
+		Error     string `json:"error"`
+		Trip      struct {
+			Summary struct {
+				Length float64 `json:"length"`
+				Time   float64 `json:"time"`
+			} `json:"summary"`
+		} `json:"trip"`
+	}
+	if err := s.do(req, &body); err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case valhallaNoRouteCodes[body.ErrorCode]:
+		return 0, 0, ErrNoRoute
+	case body.ErrorCode != 0:
+		return 0, 0, fmt.Errorf("valhalla: %d: %s", body.ErrorCode, body.Error)
+	}
+	return body.Trip.Summary.Length * 1000, body.Trip.Summary.Time, nil
+}
+
+// do sends req and decodes the JSON body. Both engines describe routing
+// failures in a JSON body with a 4xx status, so only other statuses fail here.
+func (s *RoutingService) do(req *http.Request, body interface{}) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("routing request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("routing engine returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(body); err != nil {
+		return fmt.Errorf("failed to decode routing response (%s): %w", resp.Status, err)
+	}
+	return nil
+}
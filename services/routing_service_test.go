@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"geocoding-api/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteOSRM(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"code":"Ok","routes":[{"distance":16093.44,"duration":900}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.RoutingConfig{Engine: "osrm", URL: server.URL + "/", Timeout: time.Second}
+	route, err := Routing.route(context.Background(), cfg, 39.1, -84.5, 39.9, -83.0)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/route/v1/driving/-84.500000,39.100000;-83.000000,39.900000", path, "OSRM takes lng,lat")
+	assert.Equal(t, "osrm", route.Engine)
+	assert.InDelta(t, 10.0, route.DistanceMiles, 1e-9)
+	assert.InDelta(t, 16.09344, route.DistanceKm, 1e-9)
+	assert.Equal(t, 15.0, route.DurationMinutes)
+}
+
+func TestRouteValhalla(t *testing.T) {
+	var request struct {
+		Locations []map[string]float64 `json:"locations"`
+		Costing   string               `json:"costing"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/route", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Write([]byte(`{"trip":{"summary":{"length":25.5,"time":1800}}}`))
+	}))
+	defer server.Close()
+
+	cfg := config.RoutingConfig{Engine: "valhalla", URL: server.URL, Timeout: time.Second}
+	route, err := Routing.route(context.Background(), cfg, 39.1, -84.5, 39.9, -83.0)
+	require.NoError(t, err)
+
+	assert.Equal(t, "auto", request.Costing)
+	assert.Equal(t, map[string]float64{"lat": 39.1, "lon": -84.5}, request.Locations[0])
+	assert.Equal(t, 25.5, route.DistanceKm)
+	assert.Equal(t, 30.0, route.DurationMinutes)
+}
+
+func TestRouteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine string
+		status int
+		body   string
+		want   error
+	}{
+		{"OSRM no route", "osrm", http.StatusBadRequest, `{"code":"NoRoute","message":"Impossible route"}`, ErrNoRoute},
+		{"OSRM bad request", "osrm", http.StatusBadRequest, `{"code":"InvalidQuery","message":"Query string malformed"}`, ErrRoutingUnavailable},
+		{"Valhalla no path", "valhalla", http.StatusBadRequest, `{"error_code":442,"error":"No path could be found for input"}`, ErrNoRoute},
+		{"engine down", "osrm", http.StatusBadGateway, `bad gateway`, ErrRoutingUnavailable},
+		{"malformed response", "valhalla", http.StatusOK, `<html>`, ErrRoutingUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cfg := config.RoutingConfig{Engine: tt.engine, URL: server.URL, Timeout: time.Second}
+			_, err := Routing.route(context.Background(), cfg, 39.1, -84.5, 39.9, -83.0)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	_, err := Routing.route(context.Background(), config.RoutingConfig{}, 39.1, -84.5, 39.9, -83.0)
+	assert.ErrorIs(t, err, ErrRoutingDisabled)
+}
+
+func TestDriveDurationsOSRMBatches(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "0", r.URL.Query().Get("sources"))
+		// One row from the source: 0 to itself, then 60s per destination,
+		// with the last destination unreachable
+		locations := strings.Count(r.URL.Path, ";") + 1
+		row := []interface{}{0}
+		for i := 1; i < locations; i++ {
+			row = append(row, 60)
+		}
+		if requests == 2 {
+			row[len(row)-1] = nil
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": "Ok", "durations": [][]interface{}{row}})
+	}))
+	defer server.Close()
+
+	destinations := make([][2]float64, routingMatrixBatch+5)
+	cfg := config.RoutingConfig{Engine: "osrm", URL: server.URL, Timeout: time.Second}
+	durations, err := Routing.driveDurations(context.Background(), cfg, 39.1, -84.5, destinations)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, requests)
+	require.Len(t, durations, len(destinations))
+	assert.Equal(t, 60.0, durations[0])
+	assert.Equal(t, -1.0, durations[len(durations)-1], "unreachable destinations are -1")
+}
+
+func TestDriveDurationsValhalla(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/sources_to_targets", r.URL.Path)
+		w.Write([]byte(`{"sources_to_targets":[[{"time":120},{"time":null}]]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.RoutingConfig{Engine: "valhalla", URL: server.URL, Timeout: time.Second}
+	durations, err := Routing.driveDurations(context.Background(), cfg, 39.1, -84.5, [][2]float64{{39.2, -84.4}, {39.3, -84.3}})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{120, -1}, durations)
+}
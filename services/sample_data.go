@@ -0,0 +1,161 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"geocoding-api/database"
+)
+
+// sampleData is loaded into the embedded SQLite database (DB_DRIVER=sqlite):
+// every state, from the TIGER/Line state attributes, and Ohio's ZIP codes
+//
+//go:embed sampledata
+var sampleData embed.FS
+
+// sampleLoads fill the embedded database's tables, in order
+var sampleLoads = []struct {
+	table string
+	load  func(ctx context.Context, tx *sql.Tx) (int, error)
+}{
+	{"us_states", loadSampleStates},
+	{"zip_codes", loadSampleZipCodes},
+}
+
+// LoadSampleData loads the bundled sample data into the embedded database's
+// empty tables, so a DB_SQLITE_PATH file is only filled once
+func LoadSampleData(ctx context.Context) error {
+	for _, sample := range sampleLoads {
+		var count int
+		if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+sample.table).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count %s: %w", sample.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		tx, err := database.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		rows, err := sample.load(ctx, tx)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to load sample %s: %w", sample.table, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit sample %s: %w", sample.table, err)
+		}
+		slog.Info("loaded sample data", "table", sample.table, "rows", rows)
+	}
+	return nil
+}
+
+// loadSampleZipCodes inserts the sample ZIP codes, which are rows of the
+// bundled opendatasoft export
+func loadSampleZipCodes(ctx context.Context, tx *sql.Tx) (int, error) {
+	file, err := sampleData.Open("sampledata/zip_codes_oh.csv.gz")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	reader, err := newZipCodeCSVReader(gz)
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		INSERT INTO zip_codes (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, zipCodeColumns))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		zipCode, err := parseCSVRecord(record)
+		if err != nil {
+			return rows, fmt.Errorf("invalid ZIP code %s: %w", record[0], err)
+		}
+		if _, err := stmt.ExecContext(ctx, zipCodeValues(zipCode)...); err != nil {
+			return rows, fmt.Errorf("failed to insert ZIP code %s: %w", zipCode.ZipCode, err)
+		}
+		rows++
+	}
+}
+
+// loadSampleStates inserts the sample states, one per row of
+// sampledata/states.csv with the TIGER/Line attribute names as its header
+func loadSampleStates(ctx context.Context, tx *sql.Tx) (int, error) {
+	file, err := sampleData.Open("sampledata/states.csv")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 14
+	if _, err := reader.Read(); err != nil {
+		return 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO us_states (
+			state_fips, state_abbr, state_name, state_ns, geoid,
+			region, division, lsad, mtfcc, funcstat,
+			area_land, area_water, internal_lat, internal_lng
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		areaLand, landErr := strconv.ParseInt(record[10], 10, 64)
+		areaWater, waterErr := strconv.ParseInt(record[11], 10, 64)
+		lat, latErr := strconv.ParseFloat(record[12], 64)
+		lng, lngErr := strconv.ParseFloat(record[13], 64)
+		if err := errors.Join(landErr, waterErr, latErr, lngErr); err != nil {
+			return rows, fmt.Errorf("invalid state %s: %w", record[2], err)
+		}
+		_, err = stmt.ExecContext(ctx,
+			record[0], record[1], record[2], record[3], record[4],
+			record[5], record[6], record[7], record[8], record[9],
+			areaLand, areaWater, lat, lng,
+		)
+		if err != nil {
+			return rows, fmt.Errorf("failed to insert state %s: %w", record[2], err)
+		}
+		rows++
+	}
+}
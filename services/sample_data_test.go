@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSampleData(t *testing.T) {
+	db, err := database.OpenSQLite("")
+	require.NoError(t, err)
+	defer db.Close()
+	original := database.DB
+	database.DB = db
+	defer func() { database.DB = original }()
+
+	ctx := context.Background()
+	require.NoError(t, LoadSampleData(ctx))
+	// Tables that already have rows are left alone
+	require.NoError(t, LoadSampleData(ctx))
+
+	var states, zipCodes int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM us_states").Scan(&states))
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM zip_codes").Scan(&zipCodes))
+	assert.Equal(t, 56, states)
+	assert.Greater(t, zipCodes, 1000)
+
+	// The lookups' PostgreSQL queries run unchanged on the embedded database
+	t.Run("ZIP code", func(t *testing.T) {
+		zc, err := queryZipCodeByZip(ctx, "43215")
+		require.NoError(t, err)
+		require.NotNil(t, zc)
+		assert.Equal(t, "Columbus", zc.CityName)
+		assert.Equal(t, "OH", zc.StateCode)
+		assert.Equal(t, "39049", zc.PrimaryCountyCode)
+		assert.NotEmpty(t, zc.CountyWeights)
+		assert.Contains(t, zc.CountyCodes, "39049")
+		assert.InDelta(t, 39.96, zc.Latitude, 0.1)
+		require.NotNil(t, zc.Match)
+		assert.NotNil(t, zc.Match.DataVintage)
+
+		missing, err := queryZipCodeByZip(ctx, "10001")
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("city search", func(t *testing.T) {
+		results, total, err := SearchZipCodesByCity(ctx, "Columbus", "OH", 5, 0, false)
+		require.NoError(t, err)
+		assert.Len(t, results, 5)
+		assert.Greater(t, total, 5)
+
+		results, total, err = SearchZipCodesByCity(ctx, "Columbus", "OH", 5, 1000, false)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Greater(t, total, 5)
+
+		// Andover sorts before Dover by name; ranked puts the exact match first
+		results, _, err = SearchZipCodesByCity(ctx, "Dover", "OH", 1, 0, false)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Andover", results[0].CityName)
+
+		results, total, err = SearchZipCodesByCity(ctx, "Dover", "OH", 1, 0, true)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Dover", results[0].CityName)
+		assert.Greater(t, total, 1)
+	})
+
+	t.Run("ZIP centroid index", func(t *testing.T) {
+		index := &ZipCentroidIndex{}
+		require.NoError(t, index.Load(ctx))
+		assert.Equal(t, zipCodes, index.Stats().Entries)
+		assert.Equal(t, "Columbus", index.Get("43215").CityName)
+	})
+
+	t.Run("states", func(t *testing.T) {
+		state, err := State.GetStateByIdentifier(ctx, "ohio")
+		require.NoError(t, err)
+		assert.Equal(t, "39", state.StateFIPS)
+		assert.Equal(t, "OH", state.StateAbbr)
+		assert.Greater(t, state.AreaLand, int64(0))
+		assert.InDelta(t, 40.4, state.InternalLat, 0.1)
+
+		response, err := State.SearchStates(ctx, models.StateSearchParams{Name: "new", Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, response.States, 4)
+	})
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// sloMinAlertRequests keeps an alert window with only a handful of requests,
+// where one slow call is a large share, from sending alerts
+const sloMinAlertRequests = 20
+
+// SLOService measures endpoints against their latency and error rate
+// targets using usage_records, and alerts admins when an endpoint burns
+// through its budget too fast
+type SLOService struct{}
+
+var SLO = &SLOService{}
+
+// sloCounts is the raw usage of one endpoint over a window
+type sloCounts struct {
+	requests int64
+	errors   int64
+	slow     int64
+	p95      float64
+}
+
+// sloWindow turns a window's counts into rates and burn rates against target
+func sloWindow(window time.Duration, target models.SLOTarget, counts sloCounts) models.SLOWindow {
+	w := models.SLOWindow{
+		Window:       window.String(),
+		Requests:     counts.requests,
+		P95LatencyMS: counts.p95,
+	}
+	if counts.requests == 0 {
+		return w
+	}
+	w.ErrorRate = float64(counts.errors) / float64(counts.requests)
+	w.SlowRate = float64(counts.slow) / float64(counts.requests)
+	w.ErrorBurnRate = w.ErrorRate / target.MaxErrorRate
+	w.LatencyBurnRate = w.SlowRate / models.SLOLatencyBudget
+	return w
+}
+
+// sloCompliant reports whether a window meets its target. A window without
+// requests has nothing to violate the target.
+func sloCompliant(target models.SLOTarget, w models.SLOWindow) bool {
+	return w.Requests == 0 || (w.P95LatencyMS <= float64(target.P95LatencyMS) && w.ErrorRate <= target.MaxErrorRate)
+}
+
+// sloAlerting reports whether an alert window burns either budget at or
+// above threshold
+func sloAlerting(w models.SLOWindow, threshold float64) bool {
+	return w.Requests >= sloMinAlertRequests && (w.LatencyBurnRate >= threshold || w.ErrorBurnRate >= threshold)
+}
+
+// UpsertTarget creates or replaces an endpoint's SLO target
+func (s *SLOService) UpsertTarget(ctx context.Context, target models.SLOTarget) (*models.SLOTarget, error) {
+	target.Endpoint = strings.TrimSpace(target.Endpoint)
+	if target.Endpoint == "" || len(target.Endpoint) > 100 {
+		return nil, fmt.Errorf("endpoint must be 1 to 100 characters")
+	}
+
+	err := database.DB.QueryRowContext(ctx, `
+		INSERT INTO slo_targets (endpoint, p95_latency_ms, max_error_rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (endpoint) DO UPDATE SET
+			p95_latency_ms = EXCLUDED.p95_latency_ms,
+			max_error_rate = EXCLUDED.max_error_rate,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`, target.Endpoint, target.P95LatencyMS, target.MaxErrorRate).Scan(&target.CreatedAt, &target.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save SLO target: %w", err)
+	}
+	return &target, nil
+}
+
+// DeleteTarget removes an endpoint's SLO target and its alert history
+func (s *SLOService) DeleteTarget(ctx context.Context, endpoint string) error {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM slo_targets WHERE endpoint = $1`, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to delete SLO target: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("SLO target not found")
+	}
+	return nil
+}
+
+// GetStatus reports every target's compliance over SLO_WINDOW and burn
+// rates over SLO_ALERT_WINDOW, ordered by endpoint
+func (s *SLOService) GetStatus(ctx context.Context) ([]models.SLOStatus, error) {
+	cfg := config.Get().SLO
+
+	targets, window, err := s.measure(ctx, cfg.Window)
+	if err != nil {
+		return nil, err
+	}
+	_, alertWindow, err := s.measure(ctx, cfg.AlertWindow)
+	if err != nil {
+		return nil, err
+	}
+	lastAlerts, err := s.lastAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.SLOStatus, len(targets))
+	for i, target := range targets {
+		statuses[i] = models.SLOStatus{
+			Target:      target,
+			Window:      sloWindow(cfg.Window, target, window[target.Endpoint]),
+			AlertWindow: sloWindow(cfg.AlertWindow, target, alertWindow[target.Endpoint]),
+			LastAlertAt: lastAlerts[target.Endpoint],
+		}
+		statuses[i].Compliant = sloCompliant(target, statuses[i].Window)
+		statuses[i].Alerting = sloAlerting(statuses[i].AlertWindow, cfg.BurnRateThreshold)
+	}
+	return statuses, nil
+}
+
+// measure returns the targets and each target endpoint's usage over the
+// last window
+func (s *SLOService) measure(ctx context.Context, window time.Duration) ([]models.SLOTarget, map[string]sloCounts, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT t.endpoint, t.p95_latency_ms, t.max_error_rate, t.created_at, t.updated_at,
+		       COUNT(u.id),
+		       COUNT(u.id) FILTER (WHERE u.status_code >= 500),
+		       COUNT(u.id) FILTER (WHERE u.response_time_ms > t.p95_latency_ms),
+		       COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY u.response_time_ms), 0)
+		FROM slo_targets t
+		LEFT JOIN usage_records u
+		       ON u.endpoint = t.endpoint
+		      AND u.created_at >= NOW() - $1 * INTERVAL '1 second'
+		GROUP BY t.endpoint
+		ORDER BY t.endpoint
+	`, int(window.Seconds()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to measure SLOs: %w", err)
+	}
+	defer rows.Close()
+
+	targets := []models.SLOTarget{}
+	counts := make(map[string]sloCounts)
+	for rows.Next() {
+		var t models.SLOTarget
+		var c sloCounts
+		if err := rows.Scan(&t.Endpoint, &t.P95LatencyMS, &t.MaxErrorRate, &t.CreatedAt, &t.UpdatedAt,
+			&c.requests, &c.errors, &c.slow, &c.p95); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan SLO measurement: %w", err)
+		}
+		targets = append(targets, t)
+		counts[t.Endpoint] = c
+	}
+	return targets, counts, rows.Err()
+}
+
+// lastAlerts returns when each endpoint last alerted
+func (s *SLOService) lastAlerts(ctx context.Context) (map[string]*time.Time, error) {
+	rows, err := database.DB.QueryContext(ctx, `SELECT endpoint, MAX(created_at) FROM slo_alerts GROUP BY endpoint`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SLO alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make(map[string]*time.Time)
+	for rows.Next() {
+		var endpoint string
+		var at time.Time
+		if err := rows.Scan(&endpoint, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan SLO alert: %w", err)
+		}
+		alerts[endpoint] = &at
+	}
+	return alerts, rows.Err()
+}
+
+// StartAlerter checks burn rates every SLO_CHECK_INTERVAL until the process
+// exits
+func (s *SLOService) StartAlerter() {
+	interval := config.Get().SLO.CheckInterval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.checkBurnRates(context.Background()); err != nil {
+				slog.Error("SLO burn rate check failed", "error", err)
+			}
+		}
+	}()
+}
+
+// checkBurnRates alerts admins about every endpoint burning its budget too
+// fast. Each endpoint alerts at most once per alert window: the slo_alerts
+// insert only succeeds for the first check, on any instance, to see it.
+func (s *SLOService) checkBurnRates(ctx context.Context) error {
+	statuses, err := s.GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	alertWindow := config.Get().SLO.AlertWindow
+	windowStart := time.Now().UTC().Truncate(alertWindow)
+	for _, status := range statuses {
+		if !status.Alerting {
+			continue
+		}
+
+		var recorded bool
+		err := database.DB.QueryRowContext(ctx, `
+			INSERT INTO slo_alerts (endpoint, window_start, latency_burn_rate, error_burn_rate)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (endpoint, window_start) DO NOTHING
+			RETURNING true
+		`, status.Target.Endpoint, windowStart, status.AlertWindow.LatencyBurnRate, status.AlertWindow.ErrorBurnRate).Scan(&recorded)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record SLO alert: %w", err)
+		}
+
+		slog.Warn("SLO burn rate exceeded", "endpoint", status.Target.Endpoint,
+			"latency_burn_rate", status.AlertWindow.LatencyBurnRate, "error_burn_rate", status.AlertWindow.ErrorBurnRate)
+		s.alertAdmins(ctx, status, windowStart)
+	}
+	return nil
+}
+
+// alertAdmins queues an slo.burn_rate webhook for and emails every admin
+func (s *SLOService) alertAdmins(ctx context.Context, status models.SLOStatus, windowStart time.Time) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, email FROM users
+		WHERE is_admin = true AND is_active = true AND deleted_at IS NULL
+	`)
+	if err != nil {
+		slog.Error("failed to list admins for SLO alert", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	dedupeKey := fmt.Sprintf("slo:%s:%d", status.Target.Endpoint, windowStart.Unix())
+	message := sloAlertEmail(status)
+	mailer := NewMailer()
+	for rows.Next() {
+		var adminID int
+		var email string
+		if err := rows.Scan(&adminID, &email); err != nil {
+			continue
+		}
+		if err := Webhooks.Emit(adminID, models.WebhookEventSLOBurnRate, dedupeKey, status); err != nil {
+			slog.Warn("failed to queue webhook", "event", models.WebhookEventSLOBurnRate, "user_id", adminID, "error", err)
+		}
+		message.To = email
+		if err := mailer.Send(message); err != nil {
+			slog.Warn("failed to send SLO alert email", "user_id", adminID, "error", err)
+		}
+	}
+}
+
+// sloAlertEmail describes a burn rate alert; To is filled in per admin
+func sloAlertEmail(status models.SLOStatus) EmailMessage {
+	w := status.AlertWindow
+	return EmailMessage{
+		Subject: fmt.Sprintf("SLO alert: %s is burning its budget", status.Target.Endpoint),
+		Body: fmt.Sprintf(`The %s endpoint is missing its SLO over the last %s (%d requests).
+
+p95 latency: %.0f ms (target %d ms), latency burn rate %.1fx
+Error rate: %.2f%% (target %.2f%%), error burn rate %.1fx
+
+Details: %s/admin/slo
+`,
+			status.Target.Endpoint, w.Window, w.Requests,
+			w.P95LatencyMS, status.Target.P95LatencyMS, w.LatencyBurnRate,
+			w.ErrorRate*100, status.Target.MaxErrorRate*100, w.ErrorBurnRate,
+			AppURL()),
+	}
+}
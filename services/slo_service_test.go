@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLOWindow(t *testing.T) {
+	target := models.SLOTarget{Endpoint: "geocode", P95LatencyMS: 200, MaxErrorRate: 0.01}
+
+	w := sloWindow(time.Hour, target, sloCounts{requests: 1000, errors: 20, slow: 100, p95: 250})
+	assert.Equal(t, "1h0m0s", w.Window)
+	assert.InDelta(t, 0.02, w.ErrorRate, 1e-9)
+	assert.InDelta(t, 0.1, w.SlowRate, 1e-9)
+	assert.InDelta(t, 2, w.ErrorBurnRate, 1e-9)
+	assert.InDelta(t, 2, w.LatencyBurnRate, 1e-9)
+	assert.False(t, sloCompliant(target, w))
+	assert.True(t, sloAlerting(w, 2))
+	assert.False(t, sloAlerting(w, 3))
+
+	empty := sloWindow(time.Hour, target, sloCounts{})
+	assert.Zero(t, empty.ErrorBurnRate)
+	assert.True(t, sloCompliant(target, empty), "no traffic has nothing to violate the target")
+	assert.False(t, sloAlerting(empty, 1))
+}
+
+func TestSLOAlertingNeedsEnoughRequests(t *testing.T) {
+	target := models.SLOTarget{Endpoint: "search", P95LatencyMS: 300, MaxErrorRate: 0.01}
+
+	few := sloWindow(time.Hour, target, sloCounts{requests: sloMinAlertRequests - 1, errors: 5})
+	assert.False(t, sloAlerting(few, 2))
+
+	enough := sloWindow(time.Hour, target, sloCounts{requests: sloMinAlertRequests, errors: 5})
+	assert.True(t, sloAlerting(enough, 2))
+}
+
+func TestSLOCompliantWithinTargets(t *testing.T) {
+	target := models.SLOTarget{Endpoint: "geocode", P95LatencyMS: 200, MaxErrorRate: 0.01}
+
+	w := sloWindow(24*time.Hour, target, sloCounts{requests: 10000, errors: 50, slow: 400, p95: 180})
+	assert.True(t, sloCompliant(target, w))
+	assert.InDelta(t, 0.8, w.LatencyBurnRate, 1e-9)
+}
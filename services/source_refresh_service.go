@@ -0,0 +1,509 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+	"geocoding-api/models"
+	"geocoding-api/utils"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// sourceRefreshLockKey is the Postgres advisory lock that keeps multiple
+	// API instances from refreshing sources at the same time
+	sourceRefreshLockKey = 2273003
+	// sourceRefreshPollInterval is how often the scheduler looks for sources
+	// due a check; each source is checked every DATASET_REFRESH_INTERVAL
+	sourceRefreshPollInterval = time.Hour
+)
+
+var (
+	// ErrSourceRefreshRunning is returned when a refresh is already running
+	ErrSourceRefreshRunning = errors.New("source refresh already running")
+	// ErrSourceNotTracked is returned when no tracked source matches a
+	// manual refresh
+	ErrSourceNotTracked = errors.New("no tracked source matches")
+)
+
+// SourceRefreshService keeps the counties listed in DATASET_REFRESH_SOURCES
+// up to date with OpenAddresses. When a county's upstream file changes it is
+// downloaded and replaces the county's dataset through the same pipeline as
+// an upload, and admins are notified.
+type SourceRefreshService struct {
+	downloader *utils.RealDataDownloader
+}
+
+var SourceRefresh = &SourceRefreshService{downloader: utils.NewRealDataDownloader(UploadDirectory)}
+
+// sourceKey is one tracked county
+type sourceKey struct {
+	state  string
+	county string
+}
+
+// datasetSourceColumns are scanned by scanDatasetSource
+const datasetSourceColumns = `id, state, county, config_url, data_url, etag, last_modified, content_sha256,
+	last_status, last_error, dataset_id, last_checked_at, last_changed_at, created_at`
+
+// scanDatasetSource scans datasetSourceColumns and works out the next check
+func scanDatasetSource(row interface{ Scan(...any) error }) (models.DatasetSource, error) {
+	var src models.DatasetSource
+	err := row.Scan(&src.ID, &src.State, &src.County, &src.ConfigURL, &src.DataURL, &src.ETag, &src.LastModified,
+		&src.ContentSHA256, &src.LastStatus, &src.LastError, &src.DatasetID, &src.LastCheckedAt, &src.LastChangedAt, &src.CreatedAt)
+	if err != nil {
+		return src, err
+	}
+	src.NextCheckAt = src.CreatedAt
+	if src.LastCheckedAt != nil {
+		src.NextCheckAt = src.LastCheckedAt.Add(config.Get().Datasets.RefreshInterval)
+	}
+	return src, nil
+}
+
+// trackedSources expands DATASET_REFRESH_SOURCES into counties. A bare state
+// tracks every county with a dataset in that state.
+func (s *SourceRefreshService) trackedSources(ctx context.Context) ([]sourceKey, error) {
+	var keys []sourceKey
+	for _, entry := range config.Get().Datasets.RefreshSources {
+		state, county, hasCounty := strings.Cut(entry, "/")
+		state = strings.ToUpper(strings.TrimSpace(state))
+		if hasCounty {
+			keys = append(keys, sourceKey{state: state, county: strings.Title(strings.ToLower(strings.TrimSpace(county)))})
+			continue
+		}
+
+		rows, err := database.DB.QueryContext(ctx, `
+			SELECT MIN(county) FROM datasets WHERE UPPER(state) = $1 GROUP BY LOWER(county) ORDER BY 1
+		`, state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list counties for %s: %w", state, err)
+		}
+		for rows.Next() {
+			var county string
+			if err := rows.Scan(&county); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan county: %w", err)
+			}
+			keys = append(keys, sourceKey{state: state, county: county})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// sync adds a dataset_sources row for every tracked county and drops the
+// rows of counties no longer tracked
+func (s *SourceRefreshService) sync(ctx context.Context, keys []sourceKey) error {
+	states := make([]string, len(keys))
+	counties := make([]string, len(keys))
+	for i, key := range keys {
+		states[i], counties[i] = key.state, key.county
+		if _, err := database.DB.ExecContext(ctx, `
+			INSERT INTO dataset_sources (state, county, config_url) VALUES ($1, $2, $3)
+			ON CONFLICT (UPPER(state), LOWER(county)) DO NOTHING
+		`, key.state, key.county, utils.OpenAddressesConfigURL(key.state, key.county)); err != nil {
+			return fmt.Errorf("failed to track source: %w", err)
+		}
+	}
+
+	_, err := database.DB.ExecContext(ctx, `
+		DELETE FROM dataset_sources ds
+		WHERE NOT EXISTS (
+			SELECT 1 FROM unnest($1::text[], $2::text[]) AS t(state, county)
+			WHERE UPPER(t.state) = UPPER(ds.state) AND LOWER(t.county) = LOWER(ds.county)
+		)
+	`, pq.Array(states), pq.Array(counties))
+	if err != nil {
+		return fmt.Errorf("failed to drop untracked sources: %w", err)
+	}
+	return nil
+}
+
+// selectSources syncs the tracked sources and returns those matching state
+// and county (either may be empty). Without force only sources due a check
+// are returned.
+func (s *SourceRefreshService) selectSources(ctx context.Context, state, county string, force bool) ([]models.DatasetSource, error) {
+	keys, err := s.trackedSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sync(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+datasetSourceColumns+` FROM dataset_sources
+		WHERE ($1 = '' OR UPPER(state) = UPPER($1))
+		  AND ($2 = '' OR LOWER(county) = LOWER($2))
+		  AND ($3 OR last_checked_at IS NULL OR last_checked_at <= NOW() - $4 * INTERVAL '1 second')
+		ORDER BY state, county
+	`, state, county, force, int(config.Get().Datasets.RefreshInterval.Seconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []models.DatasetSource{}
+	for rows.Next() {
+		src, err := scanDatasetSource(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan source: %w", err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
+
+// List returns every tracked source with the outcome of its last check
+func (s *SourceRefreshService) List(ctx context.Context) ([]models.DatasetSource, error) {
+	return s.selectSources(ctx, "", "", true)
+}
+
+// lock takes the refresh lock on a dedicated connection, which holds it
+// until unlock
+func (s *SourceRefreshService) lock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", sourceRefreshLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire source refresh lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrSourceRefreshRunning
+	}
+	return conn, nil
+}
+
+// unlock releases the refresh lock and its connection
+func (s *SourceRefreshService) unlock(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", sourceRefreshLockKey)
+	conn.Close()
+}
+
+// Refresh checks the tracked sources matching state and county (either may
+// be empty for all) in the background, whether or not they are due, and
+// returns the sources being checked
+func (s *SourceRefreshService) Refresh(ctx context.Context, state, county string) ([]models.DatasetSource, error) {
+	conn, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := s.selectSources(ctx, state, county, true)
+	if err != nil {
+		s.unlock(conn)
+		return nil, err
+	}
+	if len(sources) == 0 {
+		s.unlock(conn)
+		return nil, ErrSourceNotTracked
+	}
+
+	// The lock is held on conn until every source has been checked
+	go s.refreshAll(conn, sources)
+	return sources, nil
+}
+
+// StartScheduler checks sources due a refresh every hour until the process
+// exits. It does nothing while DATASET_REFRESH_SOURCES is empty.
+func (s *SourceRefreshService) StartScheduler() {
+	if len(config.Get().Datasets.RefreshSources) == 0 {
+		slog.Info("dataset source refresh disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(sourceRefreshPollInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.refreshDue(context.Background()); err != nil {
+				slog.Error("scheduled source refresh failed", "error", err)
+			}
+		}
+	}()
+}
+
+// refreshDue checks every source due a refresh, unless another instance is
+// already refreshing
+func (s *SourceRefreshService) refreshDue(ctx context.Context) error {
+	conn, err := s.lock(ctx)
+	if errors.Is(err, ErrSourceRefreshRunning) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sources, err := s.selectSources(ctx, "", "", false)
+	if err != nil {
+		s.unlock(conn)
+		return err
+	}
+	s.refreshAll(conn, sources)
+	return nil
+}
+
+// refreshAll checks each source in turn, then releases the lock held on conn
+func (s *SourceRefreshService) refreshAll(conn *sql.Conn, sources []models.DatasetSource) {
+	defer s.unlock(conn)
+	for _, src := range sources {
+		s.refresh(context.Background(), src)
+	}
+}
+
+// refresh checks one source, records the outcome and notifies admins when
+// the county was updated or the refresh failed
+func (s *SourceRefreshService) refresh(ctx context.Context, src models.DatasetSource) {
+	logger := slog.With("state", src.State, "county", src.County)
+	status, checkErr := s.check(ctx, &src)
+
+	var lastError *string
+	if checkErr != nil {
+		message := checkErr.Error()
+		lastError = &message
+		logger.Warn("source refresh failed", "error", checkErr)
+	} else {
+		logger.Info("source refreshed", "status", status, "dataset_id", src.DatasetID)
+	}
+
+	src.LastStatus = &status
+	src.LastError = lastError
+	err := database.DB.QueryRowContext(ctx, `
+		UPDATE dataset_sources
+		SET data_url = $2, etag = $3, last_modified = $4, content_sha256 = $5, dataset_id = $6,
+		    last_status = $7, last_error = $8, last_checked_at = NOW(),
+		    last_changed_at = CASE WHEN $7 = 'updated' THEN NOW() ELSE last_changed_at END
+		WHERE id = $1
+		RETURNING last_checked_at, last_changed_at
+	`, src.ID, src.DataURL, src.ETag, src.LastModified, src.ContentSHA256, src.DatasetID,
+		status, lastError).Scan(&src.LastCheckedAt, &src.LastChangedAt)
+	if err != nil {
+		logger.Error("failed to record source refresh", "error", err)
+		return
+	}
+	src.NextCheckAt = src.LastCheckedAt.Add(config.Get().Datasets.RefreshInterval)
+
+	if status != models.SourceUnchanged {
+		s.alertAdmins(ctx, src)
+	}
+}
+
+// check downloads the source's data if it changed since the last download
+// and imports it, updating src with what was downloaded. It returns the
+// outcome to record and, when failed, why.
+func (s *SourceRefreshService) check(ctx context.Context, src *models.DatasetSource) (string, error) {
+	source, err := s.downloader.FetchOpenAddressesSource(src.State, src.County)
+	if err != nil {
+		return models.SourceFailed, err
+	}
+	dataURL, err := source.AddressDataURL()
+	if err != nil {
+		return models.SourceFailed, err
+	}
+	parsed, err := url.Parse(dataURL)
+	if err != nil {
+		return models.SourceFailed, fmt.Errorf("invalid data URL: %w", err)
+	}
+	filename := path.Base(parsed.Path)
+	if err := CheckDatasetExtension(filename); err != nil {
+		return models.SourceFailed, err
+	}
+
+	// Validators from a different file don't apply to this one
+	var etag, lastModified string
+	if src.DataURL != nil && *src.DataURL == dataURL {
+		if src.ETag != nil {
+			etag = *src.ETag
+		}
+		if src.LastModified != nil {
+			lastModified = *src.LastModified
+		}
+	} else {
+		src.ETag, src.LastModified, src.ContentSHA256 = nil, nil, nil
+	}
+	src.DataURL = &dataURL
+
+	if err := EnsureUploadDirectory(); err != nil {
+		return models.SourceFailed, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	destPath := filepath.Join(UploadDirectory, fmt.Sprintf("%d_%s_%s_openaddresses%s",
+		time.Now().UnixNano(), src.State, strings.ReplaceAll(src.County, " ", "_"), filepath.Ext(filename)))
+
+	download, err := s.downloader.DownloadIfChanged(dataURL, destPath, etag, lastModified)
+	if err != nil {
+		return models.SourceFailed, err
+	}
+	if download.NotModified {
+		return models.SourceUnchanged, nil
+	}
+	if download.ETag != "" {
+		src.ETag = &download.ETag
+	}
+	if download.LastModified != "" {
+		src.LastModified = &download.LastModified
+	}
+	if src.ContentSHA256 != nil && *src.ContentSHA256 == download.SHA256 {
+		os.Remove(destPath)
+		return models.SourceUnchanged, nil
+	}
+
+	dataset, err := s.importDownload(ctx, src, destPath, filename, download.Size)
+	if dataset == nil {
+		// Nothing was imported, so the next check downloads it again
+		src.ETag, src.LastModified = nil, nil
+		return models.SourceFailed, err
+	}
+	src.ContentSHA256 = &download.SHA256
+	src.DatasetID = &dataset.ID
+	if err != nil {
+		return models.SourceFailed, err
+	}
+	return models.SourceUpdated, nil
+}
+
+// importDownload replaces the county's dataset with the downloaded file and
+// processes it, as an upload with replace=true does. The new dataset keeps
+// the replaced one's uploader and field mapping. The dataset is nil if the
+// download couldn't be handed to the pipeline; otherwise err reports why
+// processing failed.
+func (s *SourceRefreshService) importDownload(ctx context.Context, src *models.DatasetSource, destPath, filename string, size int64) (*models.Dataset, error) {
+	release, ok := ReserveUpload(src.State, src.County)
+	if !ok {
+		os.Remove(destPath)
+		return nil, fmt.Errorf("an upload for %s County, %s is in progress", src.County, src.State)
+	}
+
+	datasets := NewDatasetService(database.DB)
+	dataset := &models.Dataset{
+		Name:       fmt.Sprintf("%s County OpenAddresses %s", src.County, time.Now().Format("2006-01-02")),
+		State:      src.State,
+		County:     src.County,
+		FileType:   DatasetFileType(filename),
+		FilePath:   destPath,
+		FileSize:   size,
+		Status:     "pending",
+		UploadedAt: time.Now(),
+	}
+
+	exists, existing, err := datasets.CheckDatasetExists(src.State, src.County)
+	if err == nil && exists {
+		if existing.Status == "validating" || existing.Status == "processing" {
+			release()
+			os.Remove(destPath)
+			return nil, fmt.Errorf("dataset %d for %s County, %s is still processing", existing.ID, src.County, src.State)
+		}
+		if previous, err := datasets.GetDatasetByID(existing.ID); err == nil {
+			dataset.UploadedBy = previous.UploadedBy
+			dataset.FieldMapping = previous.FieldMapping
+		}
+	}
+	if dataset.UploadedBy == 0 {
+		// The county has never been uploaded; attribute it to the first admin
+		if err := database.DB.QueryRowContext(ctx, `
+			SELECT id FROM users WHERE is_admin = true AND is_active = true AND deleted_at IS NULL ORDER BY id LIMIT 1
+		`).Scan(&dataset.UploadedBy); err != nil {
+			release()
+			os.Remove(destPath)
+			return nil, fmt.Errorf("no admin to attribute the dataset to: %w", err)
+		}
+	}
+
+	if exists {
+		if err := datasets.ReplaceDatasets(src.State, src.County); err != nil {
+			release()
+			os.Remove(destPath)
+			return nil, fmt.Errorf("failed to replace existing dataset: %w", err)
+		}
+	}
+	if err := datasets.CreateDataset(dataset); err != nil {
+		release()
+		os.Remove(destPath)
+		return nil, fmt.Errorf("failed to create dataset record: %w", err)
+	}
+	release()
+
+	return dataset, datasets.ProcessGeoJSONDataset(dataset.ID)
+}
+
+// alertAdmins queues a dataset_source.refreshed webhook for and emails every
+// admin
+func (s *SourceRefreshService) alertAdmins(ctx context.Context, src models.DatasetSource) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, email FROM users
+		WHERE is_admin = true AND is_active = true AND deleted_at IS NULL
+	`)
+	if err != nil {
+		slog.Error("failed to list admins for source refresh", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	dedupeKey := fmt.Sprintf("source:%d:%d", src.ID, src.LastCheckedAt.Unix())
+	message := sourceRefreshEmail(src)
+	mailer := NewMailer()
+	for rows.Next() {
+		var adminID int
+		var email string
+		if err := rows.Scan(&adminID, &email); err != nil {
+			continue
+		}
+		if err := Webhooks.Emit(adminID, models.WebhookEventSourceRefreshed, dedupeKey, src); err != nil {
+			slog.Warn("failed to queue webhook", "event", models.WebhookEventSourceRefreshed, "user_id", adminID, "error", err)
+		}
+		message.To = email
+		if err := mailer.Send(message); err != nil {
+			slog.Warn("failed to send source refresh email", "user_id", adminID, "error", err)
+		}
+	}
+}
+
+// sourceRefreshEmail describes an updated or failed refresh; To is filled in
+// per admin
+func sourceRefreshEmail(src models.DatasetSource) EmailMessage {
+	if *src.LastStatus == models.SourceUpdated {
+		return EmailMessage{
+			Subject: fmt.Sprintf("%s County, %s address data refreshed", src.County, src.State),
+			Body: fmt.Sprintf(`OpenAddresses published new address data for %s County, %s, which has been imported as dataset %d.
+
+Details: %s/admin/datasets
+`,
+				src.County, src.State, *src.DatasetID, AppURL()),
+		}
+	}
+
+	lastError := ""
+	if src.LastError != nil {
+		lastError = *src.LastError
+	}
+	return EmailMessage{
+		Subject: fmt.Sprintf("%s County, %s address data refresh failed", src.County, src.State),
+		Body: fmt.Sprintf(`The scheduled refresh of %s County, %s from OpenAddresses failed:
+
+%s
+
+The source will be checked again at %s.
+
+Details: %s/admin/datasets
+`,
+			src.County, src.State, lastError, src.NextCheckAt.UTC().Format(time.RFC1123), AppURL()),
+	}
+}
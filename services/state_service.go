@@ -1,12 +1,11 @@
 package services
 
 import (
-	"compress/gzip"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"strings"
 
 	"geocoding-api/database"
@@ -18,32 +17,15 @@ type StateService struct{}
 
 var State = &StateService{}
 
-// InitializeStateData loads state data from GeoJSON if the table is empty
-func InitializeStateData() error {
-	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM us_states").Scan(&count)
+// loadStates inserts the states in the bundled TIGER/Line GeoJSON,
+// skipping states that are already present
+func loadStates(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	file, source, err := openDataFile("tl_2025_us_state.geojson")
 	if err != nil {
-		return fmt.Errorf("failed to check us_states table: %w", err)
-	}
-
-	if count > 0 {
-		log.Printf("States table already contains %d records, skipping initialization", count)
-		return nil
-	}
-
-	log.Println("States table is empty, loading data from tl_2025_us_state.geojson.gz...")
-	
-	file, err := os.Open("tl_2025_us_state.geojson.gz")
-	if err != nil {
-		return fmt.Errorf("failed to open tl_2025_us_state.geojson.gz: %w", err)
+		return err
 	}
 	defer file.Close()
-
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzReader.Close()
+	run.setSource(source)
 
 	// Read the entire GeoJSON
 	var geoJSON struct {
@@ -73,15 +55,14 @@ func InitializeStateData() error {
 		} `json:"features"`
 	}
 
-	decoder := json.NewDecoder(gzReader)
+	decoder := json.NewDecoder(file)
 	if err := decoder.Decode(&geoJSON); err != nil {
 		return fmt.Errorf("failed to decode GeoJSON: %w", err)
 	}
 
-	log.Printf("Loaded %d state features from GeoJSON", len(geoJSON.Features))
+	slog.Debug("read state features", "features", len(geoJSON.Features))
 
-	// Prepare insert statement
-	stmt, err := database.DB.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO us_states (
 			state_fips, state_abbr, state_name, state_ns, geoid,
 			region, division, lsad, mtfcc, funcstat,
@@ -91,29 +72,30 @@ func InitializeStateData() error {
 			ST_GeomFromGeoJSON($15)
 		)
 		ON CONFLICT (state_fips) DO NOTHING
+		RETURNING (xmax = 0)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer stmt.Close()
 
-	count = 0
-	skipped := 0
-	
 	for _, feature := range geoJSON.Features {
 		props := feature.Properties
-		
+
 		// Parse internal point coordinates
 		var internalLat, internalLng float64
 		fmt.Sscanf(props.INTPTLAT, "%f", &internalLat)
 		fmt.Sscanf(props.INTPTLON, "%f", &internalLng)
 
 		// Create a GeoJSON geometry string for PostGIS
-		geometryJSON := fmt.Sprintf(`{"type":"%s","coordinates":%s}`, 
-			feature.Geometry.Type, 
-			string(feature.Geometry.Coordinates))
+		geometryJSON, err := json.Marshal(feature.Geometry)
+		if err != nil {
+			slog.Warn("skipping state with invalid geometry", "state", props.NAME, "error", err)
+			run.record(rowInvalid)
+			continue
+		}
 
-		_, err := stmt.Exec(
+		outcome, err := upsertRow(ctx, stmt,
 			props.STATEFP,
 			props.STUSPS,
 			props.NAME,
@@ -128,24 +110,18 @@ func InitializeStateData() error {
 			props.AWATER,
 			internalLat,
 			internalLng,
-			geometryJSON,
+			string(geometryJSON),
 		)
-
 		if err != nil {
-			log.Printf("Failed to insert state %s: %v", props.NAME, err)
-			skipped++
-			continue
+			return fmt.Errorf("failed to insert state %s: %w", props.NAME, err)
 		}
-		
-		count++
+		run.record(outcome)
 	}
-
-	log.Printf("Successfully loaded %d states (%d skipped)", count, skipped)
 	return nil
 }
 
 // SearchStates searches for states by name or abbreviation
-func (ss *StateService) SearchStates(params models.StateSearchParams) (*models.StateSearchResponse, error) {
+func (ss *StateService) SearchStates(ctx context.Context, params models.StateSearchParams) (*models.StateSearchResponse, error) {
 	query := `
 		SELECT id, state_fips, state_abbr, state_name, state_ns, geoid,
 			   region, division, lsad, mtfcc, funcstat,
@@ -183,6 +159,15 @@ func (ss *StateService) SearchStates(params models.StateSearchParams) (*models.S
 		argIndex++
 	}
 
+	// The && prefilter narrows to states whose extent overlaps the box
+	// using idx_states_geometry before the exact intersection test
+	if params.BBox != nil {
+		envelope := bboxEnvelope(argIndex)
+		conditions = append(conditions, fmt.Sprintf("geometry && %s AND ST_Intersects(geometry, %s)", envelope, envelope))
+		args = append(args, bboxArgs(params.BBox)...)
+		argIndex += 4
+	}
+
 	// Add conditions to query
 	if len(conditions) > 0 {
 		query += " AND " + strings.Join(conditions, " AND ")
@@ -204,7 +189,7 @@ func (ss *StateService) SearchStates(params models.StateSearchParams) (*models.S
 		args = append(args, params.Offset)
 	}
 
-	rows, err := database.DB.Query(query, args...)
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query states: %w", err)
 	}
@@ -269,7 +254,7 @@ func (ss *StateService) SearchStates(params models.StateSearchParams) (*models.S
 	if len(conditions) > 0 {
 		countQuery += " AND " + strings.Join(conditions, " AND ")
 	}
-	err = database.DB.QueryRow(countQuery, args[:len(args)-2]...).Scan(&total)
+	err = database.ReadDB(ctx).QueryRowContext(ctx, countQuery, args[:len(args)-2]...).Scan(&total)
 	if err != nil {
 		total = len(states)
 	}
@@ -283,7 +268,7 @@ func (ss *StateService) SearchStates(params models.StateSearchParams) (*models.S
 }
 
 // GetStateByIdentifier gets a state by FIPS code, abbreviation, or name
-func (ss *StateService) GetStateByIdentifier(identifier string) (*models.State, error) {
+func (ss *StateService) GetStateByIdentifier(ctx context.Context, identifier string) (*models.State, error) {
 	query := `
 		SELECT id, state_fips, state_abbr, state_name, state_ns, geoid,
 			   region, division, lsad, mtfcc, funcstat,
@@ -298,7 +283,7 @@ func (ss *StateService) GetStateByIdentifier(identifier string) (*models.State,
 	var areaLand, areaWater sql.NullInt64
 	var internalLat, internalLng sql.NullFloat64
 
-	err := database.DB.QueryRow(query, identifier).Scan(
+	err := database.ReadDB(ctx).QueryRowContext(ctx, query, identifier).Scan(
 		&state.ID, &state.StateFIPS, &state.StateAbbr, &state.StateName,
 		&stateNS, &geoid, &region, &division, &lsad, &mtfcc, &funcstat,
 		&areaLand, &areaWater, &internalLat, &internalLng, &state.CreatedAt,
@@ -348,22 +333,24 @@ func (ss *StateService) GetStateByIdentifier(identifier string) (*models.State,
 	return &state, nil
 }
 
-// GetStateBoundaryGeoJSON returns the state boundary as GeoJSON
-func (ss *StateService) GetStateBoundaryGeoJSON(identifier string) (map[string]interface{}, error) {
+// GetStateBoundaryGeoJSON returns the state boundary as GeoJSON. A
+// resolution from BoundaryResolutions returns that pre-computed outline;
+// otherwise a positive tolerance, in degrees, simplifies the boundary with
+// ST_SimplifyPreserveTopology.
+func (ss *StateService) GetStateBoundaryGeoJSON(ctx context.Context, identifier string, tolerance float64, resolution string) (*models.StateBoundaryFeature, error) {
 	query := `
 		SELECT state_abbr, state_name, state_fips, area_land, area_water,
-			   ST_AsGeoJSON(geometry)::json as geometry
+			   ST_AsGeoJSON(` + boundaryGeometry("geometry", resolution, "$2") + `)::json as geometry
 		FROM us_states
 		WHERE state_fips = $1 OR UPPER(state_abbr) = UPPER($1) OR LOWER(state_name) = LOWER($1)
 		LIMIT 1
 	`
 
-	var stateAbbr, stateName, stateFIPS string
-	var areaLand, areaWater int64
-	var geometryJSON json.RawMessage
+	feature := &models.StateBoundaryFeature{Type: "Feature"}
+	props := &feature.Properties
 
-	err := database.DB.QueryRow(query, identifier).Scan(
-		&stateAbbr, &stateName, &stateFIPS, &areaLand, &areaWater, &geometryJSON,
+	err := database.ReadDB(ctx).QueryRowContext(ctx, query, identifier, tolerance).Scan(
+		&props.StateAbbr, &props.StateName, &props.StateFIPS, &props.AreaLand, &props.AreaWater, &feature.Geometry,
 	)
 
 	if err != nil {
@@ -373,30 +360,20 @@ func (ss *StateService) GetStateBoundaryGeoJSON(identifier string) (map[string]i
 		return nil, fmt.Errorf("failed to query state boundary: %w", err)
 	}
 
-	// Parse the geometry JSON
-	var geometry map[string]interface{}
-	if err := json.Unmarshal(geometryJSON, &geometry); err != nil {
-		return nil, fmt.Errorf("failed to parse geometry: %w", err)
-	}
-
-	// Build GeoJSON feature
-	feature := map[string]interface{}{
-		"type": "Feature",
-		"properties": map[string]interface{}{
-			"state_abbr": stateAbbr,
-			"state_name": stateName,
-			"state_fips": stateFIPS,
-			"area_land":  areaLand,
-			"area_water": areaWater,
-		},
-		"geometry": geometry,
-	}
-
 	return feature, nil
 }
 
-// GetStateByCoordinates finds which state contains the given coordinates
-func (ss *StateService) GetStateByCoordinates(lat, lng float64) (*models.State, error) {
+// GetStateByCoordinates finds which state contains the given coordinates.
+// Coordinates are rounded to ~1 m for the lookup cache key.
+func (ss *StateService) GetStateByCoordinates(ctx context.Context, lat, lng float64) (*models.State, error) {
+	key := fmt.Sprintf("%.5f,%.5f", lat, lng)
+	return lookupCaches.state.GetOrLoad(key, func() (*models.State, error) {
+		return ss.queryStateByCoordinates(ctx, lat, lng)
+	})
+}
+
+// queryStateByCoordinates runs the point-in-polygon state lookup
+func (ss *StateService) queryStateByCoordinates(ctx context.Context, lat, lng float64) (*models.State, error) {
 	query := `
 		SELECT id, state_fips, state_abbr, state_name, state_ns, geoid,
 			   region, division, lsad, mtfcc, funcstat,
@@ -411,7 +388,7 @@ func (ss *StateService) GetStateByCoordinates(lat, lng float64) (*models.State,
 	var areaLand, areaWater sql.NullInt64
 	var internalLat, internalLng sql.NullFloat64
 
-	err := database.DB.QueryRow(query, lng, lat).Scan(
+	err := database.ReadDB(ctx).QueryRowContext(ctx, query, lng, lat).Scan(
 		&state.ID, &state.StateFIPS, &state.StateAbbr, &state.StateName,
 		&stateNS, &geoid, &region, &division, &lsad, &mtfcc, &funcstat,
 		&areaLand, &areaWater, &internalLat, &internalLng, &state.CreatedAt,
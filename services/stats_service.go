@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+
+	"github.com/lib/pq"
+)
+
+// statsViews are the materialized views the admin dashboard counts are
+// served from, so dashboard loads never count whole tables
+var statsViews = []string{"admin_stats_summary", "county_address_counts"}
+
+// StatsService refreshes the dashboard stats views
+type StatsService struct{}
+
+var Stats = &StatsService{}
+
+// Refresh recomputes every stats view. CONCURRENTLY lets dashboards keep
+// reading the old counts while the new ones are computed.
+func (s *StatsService) Refresh(ctx context.Context) error {
+	for _, view := range statsViews {
+		if _, err := database.DB.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+pq.QuoteIdentifier(view)); err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+	return nil
+}
+
+// StartRefresher refreshes the stats views every STATS_REFRESH_INTERVAL in
+// the background until the process exits
+func (s *StatsService) StartRefresher() {
+	interval := config.Get().Workers.StatsRefreshInterval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		beat("stats_refresher", interval)
+
+		for range ticker.C {
+			beat("stats_refresher", interval)
+			if err := s.Refresh(context.Background()); err != nil {
+				slog.Error("stats refresh failed", "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// StreetService handles the precomputed street index
+type StreetService struct{}
+
+var Street = &StreetService{}
+
+const streetFields = `id, street, city, postcode, COALESCE(county, ''), COALESCE(region, ''),
+		ST_Y(geom) as latitude, ST_X(geom) as longitude, address_count,
+		min_house_number, max_house_number, updated_at`
+
+// scanStreet scans a row selected with streetFields
+func scanStreet(scanner interface{ Scan(...interface{}) error }) (models.Street, error) {
+	var st models.Street
+	err := scanner.Scan(
+		&st.ID, &st.Street, &st.City, &st.Postcode, &st.County, &st.Region,
+		&st.Latitude, &st.Longitude, &st.AddressCount,
+		&st.MinHouseNumber, &st.MaxHouseNumber, &st.UpdatedAt,
+	)
+	return st, err
+}
+
+// SearchStreets searches the street index by name with optional city/ZIP/county filters
+func (s *StreetService) SearchStreets(ctx context.Context, params models.StreetSearchParams) ([]models.Street, int, error) {
+	if params.Limit <= 0 {
+		params.Limit = 50
+	}
+	if params.Limit > 500 {
+		params.Limit = 500
+	}
+
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+	orderBy := "ORDER BY address_count DESC, street"
+
+	if params.Query != "" {
+		conditions = append(conditions, fmt.Sprintf("street ILIKE $%d", argIndex))
+		args = append(args, "%"+params.Query+"%")
+		argIndex++
+	}
+	if params.City != "" {
+		conditions = append(conditions, fmt.Sprintf("city ILIKE $%d", argIndex))
+		args = append(args, params.City)
+		argIndex++
+	}
+	if params.Postcode != "" {
+		conditions = append(conditions, fmt.Sprintf("postcode = $%d", argIndex))
+		args = append(args, params.Postcode)
+		argIndex++
+	}
+	if params.County != "" {
+		conditions = append(conditions, fmt.Sprintf("county ILIKE $%d", argIndex))
+		args = append(args, params.County)
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := database.ReadDB(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM streets "+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count streets: %w", err)
+	}
+
+	queryArgs := append([]interface{}{}, args...)
+	if params.Query != "" {
+		// Similarity ordering placeholder sits after the WHERE args
+		orderBy = fmt.Sprintf("ORDER BY similarity(street, $%d) DESC, address_count DESC", argIndex)
+		queryArgs = append(queryArgs, params.Query)
+		argIndex++
+	}
+	query := fmt.Sprintf(`SELECT %s FROM streets %s %s LIMIT $%d OFFSET $%d`,
+		streetFields, whereClause, orderBy, argIndex, argIndex+1)
+	queryArgs = append(queryArgs, params.Limit, params.Offset)
+
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search streets: %w", err)
+	}
+	defer rows.Close()
+
+	streets := []models.Street{}
+	for rows.Next() {
+		st, err := scanStreet(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan street: %w", err)
+		}
+		streets = append(streets, st)
+	}
+
+	return streets, total, rows.Err()
+}
+
+// MatchStreet finds the best street-level match for a parsed street name,
+// optionally narrowed by city and ZIP. Used by the geocoder when no rooftop
+// address matches.
+func (s *StreetService) MatchStreet(ctx context.Context, street, city, postcode string, limit int) ([]models.Street, error) {
+	if street == "" {
+		return []models.Street{}, nil
+	}
+	streets, _, err := s.SearchStreets(ctx, models.StreetSearchParams{
+		Query:    street,
+		City:     city,
+		Postcode: postcode,
+		Limit:    limit,
+	})
+	for i := range streets {
+		streets[i].Match = streetIndexMatch(city, postcode, streets[i].UpdatedAt)
+	}
+	return streets, err
+}
+
+// RefreshStreets rebuilds street aggregates from ohio_addresses. When county is
+// non-empty only streets with addresses in that county, or indexed under it,
+// are recomputed; their aggregates still cover every address on the street so
+// streets crossing a county line keep their full extent. Streets left without
+// addresses are removed.
+func (s *StreetService) RefreshStreets(county string) error {
+	var args []interface{}
+	keys := `
+		SELECT DISTINCT street, COALESCE(city, '') AS city, COALESCE(postcode, '') AS postcode
+		FROM ohio_addresses
+		WHERE street IS NOT NULL AND street <> ''`
+	orphanFilter := ""
+	if county != "" {
+		keys = `
+		SELECT street, COALESCE(city, '') AS city, COALESCE(postcode, '') AS postcode
+		FROM ohio_addresses
+		WHERE street IS NOT NULL AND street <> '' AND county = $1
+		UNION
+		SELECT street, city, postcode FROM streets WHERE county = $1`
+		orphanFilter = "AND s.county = $1"
+		args = append(args, county)
+	}
+
+	upsert := fmt.Sprintf(`
+		WITH keys AS (%s)
+		INSERT INTO streets (street, city, postcode, county, region, geom, address_count, min_house_number, max_house_number, updated_at)
+		SELECT
+			a.street,
+			COALESCE(a.city, ''),
+			COALESCE(a.postcode, ''),
+			MAX(a.county),
+			MAX(a.region),
+			ST_Centroid(ST_Collect(a.geom)),
+			COUNT(*),
+			MIN(substring(a.house_number from '^[0-9]+')::int),
+			MAX(substring(a.house_number from '^[0-9]+')::int),
+			CURRENT_TIMESTAMP
+		FROM ohio_addresses a
+		JOIN keys k ON k.street = a.street
+			AND k.city = COALESCE(a.city, '')
+			AND k.postcode = COALESCE(a.postcode, '')
+		GROUP BY a.street, COALESCE(a.city, ''), COALESCE(a.postcode, '')
+		ON CONFLICT (street, city, postcode) DO UPDATE SET
+			county = EXCLUDED.county,
+			region = EXCLUDED.region,
+			geom = EXCLUDED.geom,
+			address_count = EXCLUDED.address_count,
+			min_house_number = EXCLUDED.min_house_number,
+			max_house_number = EXCLUDED.max_house_number,
+			updated_at = CURRENT_TIMESTAMP`, keys)
+
+	prune := fmt.Sprintf(`
+		DELETE FROM streets s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM ohio_addresses a
+			WHERE a.street = s.street
+				AND COALESCE(a.city, '') = s.city
+				AND COALESCE(a.postcode, '') = s.postcode
+		) %s`, orphanFilter)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to refresh streets: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Prune first so the county filter still sees rows the upsert would relabel
+	pruned, err := tx.Exec(prune, args...)
+	if err != nil {
+		return fmt.Errorf("failed to prune streets: %w", err)
+	}
+	result, err := tx.Exec(upsert, args...)
+	if err != nil {
+		return fmt.Errorf("failed to refresh streets: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to refresh streets: %w", err)
+	}
+
+	affected, _ := result.RowsAffected()
+	removed, _ := pruned.RowsAffected()
+	slog.Info("refreshed street index", "rows", affected, "removed", removed, "county", county)
+	return nil
+}
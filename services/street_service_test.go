@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"geocoding-api/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshStreetsCountyKeepsWholeStreet(t *testing.T) {
+	if err := database.InitDB(); err != nil {
+		t.Skipf("database not available: %v", err)
+	}
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	// A street crossing a county line, indexed per county
+	street := fmt.Sprintf("Refreshtest%d", time.Now().UnixNano())
+	east, west := street+" East", street+" West"
+	insert := func(hash, houseNumber, county string, lng float64) {
+		_, err := database.DB.ExecContext(ctx, `
+			INSERT INTO ohio_addresses (hash, house_number, street, city, region, postcode, county, geom)
+			VALUES ($1, $2, $3, 'Testville', 'OH', '43000', $4, ST_SetSRID(ST_MakePoint($5, 40), 4326))
+		`, street+hash, houseNumber, street, county, lng)
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		database.DB.Exec(`DELETE FROM ohio_addresses WHERE street = $1`, street)
+		database.DB.Exec(`DELETE FROM streets WHERE street = $1`, street)
+	})
+	insert("-1", "100", west, -83.0)
+	insert("-2", "900", east, -82.0)
+
+	require.NoError(t, Street.RefreshStreets(east))
+
+	var count, minHouse, maxHouse int
+	var lng float64
+	load := func() error {
+		return database.DB.QueryRowContext(ctx, `
+			SELECT address_count, min_house_number, max_house_number, ST_X(geom)
+			FROM streets WHERE street = $1
+		`, street).Scan(&count, &minHouse, &maxHouse, &lng)
+	}
+	require.NoError(t, load())
+	assert.Equal(t, 2, count, "aggregates cover both counties")
+	assert.Equal(t, 100, minHouse)
+	assert.Equal(t, 900, maxHouse)
+	assert.InDelta(t, -82.5, lng, 1e-6)
+
+	// Re-importing a county without the street drops it from the aggregate
+	_, err := database.DB.ExecContext(ctx, `DELETE FROM ohio_addresses WHERE street = $1 AND county = $2`, street, west)
+	require.NoError(t, err)
+	require.NoError(t, Street.RefreshStreets(west))
+	require.NoError(t, load())
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 900, minHouse)
+
+	// Streets without addresses are removed
+	_, err = database.DB.ExecContext(ctx, `DELETE FROM ohio_addresses WHERE street = $1`, street)
+	require.NoError(t, err)
+	require.NoError(t, Street.RefreshStreets(east))
+	var remaining int
+	require.NoError(t, database.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM streets WHERE street = $1`, street,
+	).Scan(&remaining))
+	assert.Zero(t, remaining)
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"geocoding-api/database"
+)
+
+const (
+	// MaxTileZoom is the deepest zoom level tiles are served for
+	MaxTileZoom = 22
+	// tileExtent and tileBuffer are the MVT grid size and the margin, in
+	// grid units, kept around each tile so lines and labels join cleanly
+	tileExtent = 4096
+	tileBuffer = 64
+	// maxTileFeatures caps the features in one tile so a dense address tile
+	// can't produce an unbounded response
+	maxTileFeatures = 50000
+)
+
+var (
+	// ErrUnknownTileLayer is returned for a layer name with no tile source
+	ErrUnknownTileLayer = errors.New("unknown tile layer")
+	// ErrInvalidTile is returned for coordinates outside the tile pyramid
+	ErrInvalidTile = errors.New("invalid tile coordinates")
+)
+
+// tileLayer describes the table and columns a vector tile layer is built from
+type tileLayer struct {
+	table    string
+	geometry string
+	// properties are the columns of t carried as feature attributes
+	properties string
+	// minZoom is the shallowest zoom the layer is drawn at; above it the
+	// features are too dense to be useful and tiles are empty
+	minZoom int
+}
+
+// tileLayers are the layers served at /tiles/:layer
+var tileLayers = map[string]tileLayer{
+	"addresses": {
+		table:      "ohio_addresses",
+		geometry:   "geom",
+		properties: "t.id, t.full_address, t.house_number, t.street, t.city, t.postcode",
+		minZoom:    14,
+	},
+	"counties": {
+		table:      "ohio_counties",
+		geometry:   "bounds_geometry",
+		properties: "t.id, t.county_name, t.address_count",
+	},
+	"states": {
+		table:      "us_states",
+		geometry:   "geometry",
+		properties: "t.state_fips, t.state_abbr, t.state_name",
+	},
+}
+
+// TileService renders Mapbox Vector Tiles with PostGIS
+type TileService struct{}
+
+var Tiles = &TileService{}
+
+// TileLayerNames lists the layers tiles can be requested for
+func TileLayerNames() []string {
+	names := make([]string, 0, len(tileLayers))
+	for name := range tileLayers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetTile returns the MVT-encoded tile z/x/y of a layer. An empty result
+// means the tile has no features.
+func (s *TileService) GetTile(ctx context.Context, layer string, z, x, y int) ([]byte, error) {
+	source, ok := tileLayers[layer]
+	if !ok {
+		return nil, ErrUnknownTileLayer
+	}
+	if z < 0 || z > MaxTileZoom || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return nil, ErrInvalidTile
+	}
+	if z < source.minZoom {
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("%s/%d/%d/%d", layer, z, x, y)
+	return lookupCaches.tile.GetOrLoad(key, func() ([]byte, error) {
+		return s.queryTile(ctx, layer, source, z, x, y)
+	})
+}
+
+// queryTile builds a tile with ST_AsMVT. Features are selected with the
+// tile envelope plus its buffer, transformed to Web Mercator, so the
+// geometry's spatial index is used.
+func (s *TileService) queryTile(ctx context.Context, name string, layer tileLayer, z, x, y int) ([]byte, error) {
+	query := fmt.Sprintf(`
+		WITH bounds AS (
+			SELECT ST_TileEnvelope($1, $2, $3) AS tile,
+				   ST_Transform(ST_TileEnvelope($1, $2, $3, margin => $4::float8 / $5), 4326) AS search
+		),
+		features AS (
+			SELECT ST_AsMVTGeom(ST_Transform(t.%[1]s, 3857), bounds.tile, $5, $6, true) AS geom, %[2]s
+			FROM %[3]s t, bounds
+			WHERE t.%[1]s && bounds.search
+			LIMIT $7
+		)
+		SELECT ST_AsMVT(features, $8, $5, 'geom') FROM features WHERE geom IS NOT NULL
+	`, layer.geometry, layer.properties, layer.table)
+
+	var tile []byte
+	err := database.ReadDB(ctx).QueryRowContext(ctx, query,
+		z, x, y, tileBuffer, tileExtent, tileBuffer, maxTileFeatures, name,
+	).Scan(&tile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s tile: %w", name, err)
+	}
+	return tile, nil
+}
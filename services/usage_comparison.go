@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// usagePeriodIntervals maps a comparison period to its Postgres interval
+var usagePeriodIntervals = map[string]string{
+	models.UsagePeriodDay:   "1 day",
+	models.UsagePeriodWeek:  "1 week",
+	models.UsagePeriodMonth: "1 month",
+}
+
+// ValidUsagePeriod reports whether period can be compared
+func ValidUsagePeriod(period string) bool {
+	_, ok := usagePeriodIntervals[period]
+	return ok
+}
+
+// growthPercent returns the percentage change from previous to current,
+// or nil when there is no previous value to compare against
+func growthPercent(current, previous int) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	growth := math.Round(float64(current-previous)/float64(previous)*10000) / 100
+	return &growth
+}
+
+// GetUsageComparison compares usage for the current period to date with the
+// same span of the previous period. userID 0 covers all users.
+func (as *AuthService) GetUsageComparison(ctx context.Context, userID int, period string) (*models.UsageComparison, error) {
+	interval, ok := usagePeriodIntervals[period]
+	if !ok {
+		return nil, fmt.Errorf("unsupported period %q", period)
+	}
+
+	comparison := &models.UsageComparison{
+		Period:    period,
+		Endpoints: []models.EndpointUsageDelta{},
+	}
+	if userID != 0 {
+		comparison.UserID = &userID
+	}
+
+	// Period boundaries come from the database clock, which also stamps usage_records
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT
+			date_trunc($1, NOW()),
+			NOW(),
+			date_trunc($1, NOW()) - $2::interval,
+			LEAST(NOW() - $2::interval, date_trunc($1, NOW()))
+	`, period, interval).Scan(
+		&comparison.Current.Start, &comparison.Current.End,
+		&comparison.Previous.Start, &comparison.Previous.End,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute usage periods: %w", err)
+	}
+
+	// The full previous period ends where the current one starts
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT
+			endpoint,
+			COUNT(*) FILTER (WHERE created_at >= $1 AND created_at < $2) AS current_calls,
+			COUNT(*) FILTER (WHERE created_at >= $1 AND created_at < $2 AND billable = true) AS current_billable,
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $4) AS previous_calls,
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $4 AND billable = true) AS previous_billable,
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $5) AS previous_full_calls
+		FROM usage_records
+		WHERE created_at >= $3 AND created_at < $2
+			AND ($6 = 0 OR user_id = $6)
+		GROUP BY endpoint
+	`, comparison.Current.Start, comparison.Current.End,
+		comparison.Previous.Start, comparison.Previous.End, comparison.Current.Start, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage comparison: %w", err)
+	}
+	defer rows.Close()
+
+	var currentBillable, previousBillable int
+	for rows.Next() {
+		var endpoint models.EndpointUsageDelta
+		var curBillable, prevBillable, prevFull int
+		if err := rows.Scan(&endpoint.Endpoint, &endpoint.CurrentCalls, &curBillable,
+			&endpoint.PreviousCalls, &prevBillable, &prevFull); err != nil {
+			return nil, fmt.Errorf("failed to scan usage comparison: %w", err)
+		}
+
+		comparison.Current.TotalCalls += endpoint.CurrentCalls
+		comparison.Previous.TotalCalls += endpoint.PreviousCalls
+		comparison.PreviousFullPeriodCalls += prevFull
+		currentBillable += curBillable
+		previousBillable += prevBillable
+
+		if endpoint.CurrentCalls == 0 && endpoint.PreviousCalls == 0 {
+			continue
+		}
+		endpoint.Delta = endpoint.CurrentCalls - endpoint.PreviousCalls
+		endpoint.GrowthPercent = growthPercent(endpoint.CurrentCalls, endpoint.PreviousCalls)
+		comparison.Endpoints = append(comparison.Endpoints, endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get usage comparison: %w", err)
+	}
+
+	comparison.Current.BillableCalls = currentBillable
+	comparison.Previous.BillableCalls = previousBillable
+	comparison.Delta = comparison.Current.TotalCalls - comparison.Previous.TotalCalls
+	comparison.GrowthPercent = growthPercent(comparison.Current.TotalCalls, comparison.Previous.TotalCalls)
+	comparison.BillableGrowthPercent = growthPercent(currentBillable, previousBillable)
+
+	// Largest swings first
+	sort.Slice(comparison.Endpoints, func(i, j int) bool {
+		di, dj := comparison.Endpoints[i].Delta, comparison.Endpoints[j].Delta
+		if di < 0 {
+			di = -di
+		}
+		if dj < 0 {
+			dj = -dj
+		}
+		if di != dj {
+			return di > dj
+		}
+		return comparison.Endpoints[i].Endpoint < comparison.Endpoints[j].Endpoint
+	})
+
+	return comparison, nil
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// latencyBucketBounds are the inclusive upper bounds, in milliseconds, of the
+// response time histogram kept in usage_hourly.latency_buckets. One more
+// bucket follows for anything slower. Must match migration 000032.
+var latencyBucketBounds = []int{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// usageGranularity describes how one time-series granularity buckets and
+// bounds its range
+type usageGranularity struct {
+	step         func(time.Time) time.Time
+	defaultRange time.Duration
+	maxRange     time.Duration
+}
+
+var usageGranularities = map[string]usageGranularity{
+	models.UsageGranularityHour: {
+		step:         func(t time.Time) time.Time { return t.Add(time.Hour) },
+		defaultRange: 48 * time.Hour,
+		maxRange:     31 * 24 * time.Hour,
+	},
+	models.UsageGranularityDay: {
+		step:         func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+		defaultRange: 30 * 24 * time.Hour,
+		maxRange:     366 * 24 * time.Hour,
+	},
+	models.UsageGranularityWeek: {
+		step:         func(t time.Time) time.Time { return t.AddDate(0, 0, 7) },
+		defaultRange: 12 * 7 * 24 * time.Hour,
+		maxRange:     2 * 366 * 24 * time.Hour,
+	},
+}
+
+var (
+	// ErrUsageRangeInvalid is returned when from is not before to
+	ErrUsageRangeInvalid = errors.New("from must be before to")
+	// ErrUsageRangeTooLarge is returned when a range spans too many buckets
+	ErrUsageRangeTooLarge = errors.New("time range is too large for this granularity")
+)
+
+// ValidUsageGranularity reports whether granularity can be used for a time series
+func ValidUsageGranularity(granularity string) bool {
+	_, ok := usageGranularities[granularity]
+	return ok
+}
+
+// MaxUsageRange returns the longest range allowed for granularity
+func MaxUsageRange(granularity string) time.Duration {
+	return usageGranularities[granularity].maxRange
+}
+
+// truncateToGranularity returns the UTC start of the bucket containing t.
+// Weeks start on Monday, like Postgres date_trunc.
+func truncateToGranularity(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case models.UsageGranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case models.UsageGranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return t.Truncate(time.Hour)
+	}
+}
+
+// latencyHistogramSQL builds the SQL array expression that counts
+// usage_records.response_time_ms into the latency histogram buckets
+func latencyHistogramSQL() string {
+	var counts []string
+	lower := -1
+	for _, bound := range latencyBucketBounds {
+		if lower < 0 {
+			counts = append(counts, fmt.Sprintf("COUNT(*) FILTER (WHERE response_time_ms <= %d)", bound))
+		} else {
+			counts = append(counts, fmt.Sprintf("COUNT(*) FILTER (WHERE response_time_ms > %d AND response_time_ms <= %d)", lower, bound))
+		}
+		lower = bound
+	}
+	counts = append(counts, fmt.Sprintf("COUNT(*) FILTER (WHERE response_time_ms > %d)", lower))
+	return "ARRAY[" + strings.Join(counts, ", ") + "]::INTEGER[]"
+}
+
+// latencyPercentile estimates the p-th percentile (0-1) from a histogram as
+// the upper bound of the bucket it falls in, capped at the largest response
+// time seen
+func latencyPercentile(histogram []int64, maxResponseMs int, p float64) int {
+	var total int64
+	for _, count := range histogram {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p * float64(total)))
+	var cumulative int64
+	for i, count := range histogram {
+		cumulative += count
+		if cumulative >= rank {
+			if i < len(latencyBucketBounds) && latencyBucketBounds[i] < maxResponseMs {
+				return latencyBucketBounds[i]
+			}
+			return maxResponseMs
+		}
+	}
+	return maxResponseMs
+}
+
+// rollUpUsageRecords adds the given usage_records rows to the hourly usage summary
+func rollUpUsageRecords(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO usage_hourly (user_id, bucket, total_calls, billable_calls, error_calls,
+			total_response_ms, max_response_ms, latency_buckets)
+		SELECT
+			user_id,
+			date_trunc('hour', created_at),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE billable),
+			COUNT(*) FILTER (WHERE status_code >= 400),
+			COALESCE(SUM(response_time_ms), 0),
+			COALESCE(MAX(response_time_ms), 0),
+			`+latencyHistogramSQL()+`
+		FROM usage_records
+		WHERE id = ANY($1) AND user_id IS NOT NULL
+		GROUP BY user_id, date_trunc('hour', created_at)
+		ON CONFLICT (user_id, bucket) DO UPDATE SET
+			total_calls = usage_hourly.total_calls + EXCLUDED.total_calls,
+			billable_calls = usage_hourly.billable_calls + EXCLUDED.billable_calls,
+			error_calls = usage_hourly.error_calls + EXCLUDED.error_calls,
+			total_response_ms = usage_hourly.total_response_ms + EXCLUDED.total_response_ms,
+			max_response_ms = GREATEST(usage_hourly.max_response_ms, EXCLUDED.max_response_ms),
+			latency_buckets = ARRAY(
+				SELECT a + b
+				FROM unnest(usage_hourly.latency_buckets, EXCLUDED.latency_buckets) WITH ORDINALITY AS h(a, b, i)
+				ORDER BY i
+			)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to roll up usage: %w", err)
+	}
+	return nil
+}
+
+// usageAccumulator sums hourly rows into one time-series bucket
+type usageAccumulator struct {
+	total, billable, errors int
+	responseMs              int64
+	maxResponseMs           int
+	histogram               []int64
+}
+
+func (a *usageAccumulator) point(start time.Time) models.UsageTimeSeriesPoint {
+	point := models.UsageTimeSeriesPoint{
+		Start:         start,
+		TotalCalls:    a.total,
+		BillableCalls: a.billable,
+		ErrorCalls:    a.errors,
+	}
+	if a.total > 0 {
+		point.ErrorRate = math.Round(float64(a.errors)/float64(a.total)*10000) / 10000
+		point.AvgResponseTimeMs = math.Round(float64(a.responseMs)/float64(a.total)*100) / 100
+	}
+	point.P50ResponseTimeMs = latencyPercentile(a.histogram, a.maxResponseMs, 0.50)
+	point.P95ResponseTimeMs = latencyPercentile(a.histogram, a.maxResponseMs, 0.95)
+	point.P99ResponseTimeMs = latencyPercentile(a.histogram, a.maxResponseMs, 0.99)
+	return point
+}
+
+// GetUsageTimeSeries returns the user's usage bucketed by granularity between
+// from and to, read from the hourly rollup. A zero to means now and a zero
+// from means the granularity's default range before to.
+func (as *AuthService) GetUsageTimeSeries(ctx context.Context, userID int, granularity string, from, to time.Time) (*models.UsageTimeSeries, error) {
+	g, ok := usageGranularities[granularity]
+	if !ok {
+		return nil, fmt.Errorf("unsupported granularity %q", granularity)
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-g.defaultRange)
+	}
+	from = truncateToGranularity(from, granularity)
+	to = to.UTC()
+	if !from.Before(to) {
+		return nil, ErrUsageRangeInvalid
+	}
+	if to.Sub(from) > g.maxRange {
+		return nil, ErrUsageRangeTooLarge
+	}
+
+	series := &models.UsageTimeSeries{
+		Granularity: granularity,
+		From:        from,
+		To:          to,
+		Points:      []models.UsageTimeSeriesPoint{},
+	}
+	buckets := map[time.Time]*usageAccumulator{}
+	var starts []time.Time
+	for start := from; start.Before(to); start = g.step(start) {
+		starts = append(starts, start)
+		buckets[start] = &usageAccumulator{histogram: make([]int64, len(latencyBucketBounds)+1)}
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT bucket, total_calls, billable_calls, error_calls, total_response_ms, max_response_ms, latency_buckets
+		FROM usage_hourly
+		WHERE user_id = $1 AND bucket >= $2 AND bucket < $3
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage time series: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour time.Time
+		var total, billable, errorCalls, maxResponseMs int
+		var responseMs int64
+		var histogram []int64
+		if err := rows.Scan(&hour, &total, &billable, &errorCalls, &responseMs, &maxResponseMs, pq.Array(&histogram)); err != nil {
+			return nil, fmt.Errorf("failed to scan usage time series: %w", err)
+		}
+
+		acc, ok := buckets[truncateToGranularity(hour, granularity)]
+		if !ok {
+			continue
+		}
+		acc.total += total
+		acc.billable += billable
+		acc.errors += errorCalls
+		acc.responseMs += responseMs
+		if maxResponseMs > acc.maxResponseMs {
+			acc.maxResponseMs = maxResponseMs
+		}
+		for i := 0; i < len(histogram) && i < len(acc.histogram); i++ {
+			acc.histogram[i] += histogram[i]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage time series: %w", err)
+	}
+
+	for _, start := range starts {
+		series.Points = append(series.Points, buckets[start].point(start))
+	}
+	return series, nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"geocoding-api/config"
+	"geocoding-api/database"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// usageFlushAttempts is how many times a failed batch is written before
+	// it is dropped
+	usageFlushAttempts = 3
+)
+
+// UsageEvent is one API call to be recorded for billing and analytics
+type UsageEvent struct {
+	UserID         int
+	APIKeyID       int
+	Endpoint       string
+	Method         string
+	StatusCode     int
+	ResponseTimeMs int
+	IPAddress      string
+	UserAgent      string
+	Billable       bool
+	// Overage marks a billable call made past the monthly limit under
+	// overage billing
+	Overage bool
+	// Cost is how many calls the request counts as against quotas; zero
+	// counts as 1
+	Cost         int
+	FeatureFlags map[string]bool
+	// Path and QueryString are captured only with USAGE_CAPTURE_REQUESTS;
+	// QueryString has already been through SanitizeQuery
+	Path        string
+	QueryString string
+
+	recordedAt time.Time
+}
+
+// UsageWriter buffers usage events and writes them to usage_records in
+// batches from a single background flusher, so request handling never waits
+// on the database and write load stays smooth. Rate limit checks read
+// usage_records, so they can lag by up to one flush interval.
+type UsageWriter struct {
+	mu      sync.RWMutex
+	events  chan UsageEvent
+	running bool
+	done    chan struct{}
+
+	flushSize     int
+	flushInterval time.Duration
+}
+
+var Usage = &UsageWriter{}
+
+// Start begins buffering events and flushing them in the background. When
+// the buffer is full, Record falls back to writing synchronously.
+func (w *UsageWriter) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		return
+	}
+
+	workers := config.Get().Workers
+	w.flushSize = workers.UsageFlushSize
+	w.flushInterval = workers.UsageFlushInterval
+	w.events = make(chan UsageEvent, workers.UsageBufferSize)
+	w.done = make(chan struct{})
+	w.running = true
+
+	go w.run(w.events, w.done)
+}
+
+// Record queues a usage event. When the writer isn't running or its buffer
+// is full the event is written synchronously instead, which slows the caller
+// down rather than losing the record.
+func (w *UsageWriter) Record(event UsageEvent) {
+	event.recordedAt = time.Now()
+
+	w.mu.RLock()
+	if w.running {
+		select {
+		case w.events <- event:
+			w.mu.RUnlock()
+			return
+		default:
+			slog.Warn("usage buffer full, writing synchronously", "user_id", event.UserID)
+		}
+	}
+	w.mu.RUnlock()
+
+	if err := writeUsageBatch([]UsageEvent{event}); err != nil {
+		slog.Error("failed to record usage", "user_id", event.UserID, "api_key_id", event.APIKeyID,
+			"endpoint", event.Endpoint, "error", err)
+	}
+}
+
+// Stop stops accepting events and waits for the buffered ones to be written,
+// or for ctx to end
+func (w *UsageWriter) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return nil
+	}
+	w.running = false
+	close(w.events)
+	done := w.done
+	w.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("usage writer did not drain: %w", ctx.Err())
+	}
+}
+
+// run collects events into batches until events is closed, then flushes
+// what is left
+func (w *UsageWriter) run(events <-chan UsageEvent, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+	beat("usage_writer", w.flushInterval)
+
+	batch := make([]UsageEvent, 0, w.flushSize)
+	attempts := 0
+	flush := func(final bool) {
+		if len(batch) == 0 {
+			return
+		}
+		err := writeUsageBatch(batch)
+		if err == nil {
+			batch = batch[:0]
+			attempts = 0
+			return
+		}
+
+		attempts++
+		if attempts < usageFlushAttempts && !final {
+			slog.Warn("failed to write usage batch, will retry", "events", len(batch), "attempt", attempts, "error", err)
+			return
+		}
+		slog.Error("dropping usage batch after failed writes", "events", len(batch), "attempts", attempts, "error", err)
+		batch = batch[:0]
+		attempts = 0
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				flush(true)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= w.flushSize {
+				flush(false)
+			}
+		case <-ticker.C:
+			beat("usage_writer", w.flushInterval)
+			flush(false)
+		}
+	}
+}
+
+// writeUsageBatch inserts events into usage_records in one statement and
+// rolls them up into usage_hourly. created_at is derived from the database
+// clock minus each event's age, matching what a direct NOW() insert stored.
+func writeUsageBatch(events []UsageEvent) error {
+	n := len(events)
+	userIDs := make([]int64, n)
+	apiKeyIDs := make([]int64, n)
+	endpoints := make([]string, n)
+	methods := make([]string, n)
+	statusCodes := make([]int64, n)
+	responseTimes := make([]int64, n)
+	ipAddresses := make([]string, n)
+	userAgents := make([]string, n)
+	billable := make([]bool, n)
+	overage := make([]bool, n)
+	costs := make([]int64, n)
+	flags := make([]string, n)
+	paths := make([]string, n)
+	queries := make([]string, n)
+	ages := make([]int64, n)
+
+	now := time.Now()
+	for i, e := range events {
+		userIDs[i] = int64(e.UserID)
+		apiKeyIDs[i] = int64(e.APIKeyID)
+		endpoints[i] = e.Endpoint
+		methods[i] = e.Method
+		statusCodes[i] = int64(e.StatusCode)
+		responseTimes[i] = int64(e.ResponseTimeMs)
+		ipAddresses[i] = e.IPAddress
+		userAgents[i] = e.UserAgent
+		billable[i] = e.Billable
+		overage[i] = e.Overage
+		costs[i] = int64(max(e.Cost, 1))
+		if len(e.FeatureFlags) > 0 {
+			if encoded, err := json.Marshal(e.FeatureFlags); err == nil {
+				flags[i] = string(encoded)
+			}
+		}
+		paths[i] = e.Path
+		queries[i] = e.QueryString
+		if !e.recordedAt.IsZero() {
+			ages[i] = now.Sub(e.recordedAt).Microseconds()
+		}
+	}
+
+	rows, err := database.DB.Query(`
+		INSERT INTO usage_records (user_id, api_key_id, organization_id, endpoint, method, status_code,
+			response_time_ms, ip_address, user_agent, billable, overage, cost, feature_flags, path, query_string, created_at)
+		SELECT e.user_id, e.api_key_id, k.organization_id, e.endpoint, e.method, e.status_code,
+			e.response_time_ms, NULLIF(e.ip_address, '')::inet, e.user_agent, e.billable, e.overage, e.cost,
+			NULLIF(e.feature_flags, '')::jsonb, NULLIF(e.path, ''), NULLIF(e.query_string, ''),
+			NOW() - e.age_us * INTERVAL '1 microsecond'
+		FROM unnest($1::int[], $2::int[], $3::text[], $4::text[], $5::int[], $6::int[],
+			$7::text[], $8::text[], $9::bool[], $10::bool[], $11::int[], $12::text[], $13::text[], $14::text[], $15::bigint[])
+			AS e(user_id, api_key_id, endpoint, method, status_code, response_time_ms,
+				ip_address, user_agent, billable, overage, cost, feature_flags, path, query_string, age_us)
+		LEFT JOIN api_keys k ON k.id = e.api_key_id
+		RETURNING id
+	`, pq.Array(userIDs), pq.Array(apiKeyIDs), pq.Array(endpoints), pq.Array(methods),
+		pq.Array(statusCodes), pq.Array(responseTimes), pq.Array(ipAddresses), pq.Array(userAgents),
+		pq.Array(billable), pq.Array(overage), pq.Array(costs), pq.Array(flags), pq.Array(paths), pq.Array(queries), pq.Array(ages))
+	if err != nil {
+		return fmt.Errorf("failed to insert usage records: %w", err)
+	}
+
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan usage record id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to insert usage records: %w", err)
+	}
+
+	// The rollup only feeds analytics, so a failure there doesn't fail the batch
+	if err := rollUpUsageRecords(ids); err != nil {
+		slog.Error("failed to roll up usage", "records", len(ids), "error", err)
+	}
+	return nil
+}
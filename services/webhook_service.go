@@ -0,0 +1,541 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxWebhooksPerUser caps how many endpoints a single user may register
+	maxWebhooksPerUser = 10
+	// webhookMaxAttempts is how many times a delivery is tried before it is marked failed
+	webhookMaxAttempts = 8
+	// webhookPollInterval is how often the delivery worker looks for due deliveries
+	webhookPollInterval = 10 * time.Second
+	// webhookBatchSize is how many deliveries the worker claims per poll
+	webhookBatchSize = 50
+	// webhookLease keeps a claimed delivery from being picked up again while in flight
+	webhookLease = 2 * time.Minute
+)
+
+// WebhookService manages user webhooks and delivers queued events to them
+type WebhookService struct {
+	client *http.Client
+
+	// notified remembers quota events already queued so the rate limit check
+	// doesn't hit the database on every request once a threshold is crossed
+	mu       sync.Mutex
+	notified map[string]bool
+}
+
+var Webhooks = &WebhookService{
+	client:   newWebhookClient(),
+	notified: make(map[string]bool),
+}
+
+const webhookFields = `id, user_id, url, events, COALESCE(description, ''), is_active, created_at, updated_at`
+
+func scanWebhook(scanner interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	var w models.Webhook
+	var events pq.StringArray
+	err := scanner.Scan(&w.ID, &w.UserID, &w.URL, &events, &w.Description, &w.IsActive, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	w.Events = []string(events)
+	return &w, nil
+}
+
+// validateWebhookRequest checks the URL and event list and returns the
+// normalized events. The URL's host must resolve to public addresses only.
+func validateWebhookRequest(ctx context.Context, req models.WebhookRequest) ([]string, error) {
+	if len(req.URL) > 2048 {
+		return nil, fmt.Errorf("url must be at most 2048 characters")
+	}
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	if len(req.Events) == 0 {
+		return nil, fmt.Errorf("events must include at least one of: *, %s", strings.Join(models.WebhookEvents, ", "))
+	}
+
+	events := make([]string, 0, len(req.Events))
+	seen := make(map[string]bool)
+	for _, event := range req.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if seen[event] {
+			continue
+		}
+		valid := event == "*"
+		for _, known := range models.WebhookEvents {
+			if event == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown event: %s", event)
+		}
+		seen[event] = true
+		events = append(events, event)
+	}
+
+	if err := checkWebhookHost(ctx, parsed.Hostname()); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// generateWebhookSecret returns a random signing secret
+func generateWebhookSecret() (string, error) {
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secretBytes), nil
+}
+
+// CreateWebhook registers a new webhook for a user. The returned webhook
+// includes the signing secret, which is not shown again.
+func (ws *WebhookService) CreateWebhook(ctx context.Context, userID int, req models.WebhookRequest) (*models.Webhook, error) {
+	events, err := validateWebhookRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := database.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhooks WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, fmt.Errorf("webhook limit reached (%d per user)", maxWebhooksPerUser)
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	row := database.DB.QueryRowContext(ctx, `
+		INSERT INTO webhooks (user_id, url, secret, events, description, is_active)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING `+webhookFields,
+		userID, req.URL, secret, pq.Array(events), req.Description, isActive)
+	webhook, err := scanWebhook(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	webhook.Secret = secret
+
+	return webhook, nil
+}
+
+// GetUserWebhooks lists a user's webhooks
+func (ws *WebhookService) GetUserWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT `+webhookFields+`
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *w)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetWebhook retrieves one of a user's webhooks
+func (ws *WebhookService) GetWebhook(ctx context.Context, userID, id int) (*models.Webhook, error) {
+	row := database.DB.QueryRowContext(ctx, `
+		SELECT `+webhookFields+`
+		FROM webhooks
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	w, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return w, nil
+}
+
+// UpdateWebhook replaces a webhook's URL, events and description, and
+// optionally toggles whether it is active
+func (ws *WebhookService) UpdateWebhook(ctx context.Context, userID, id int, req models.WebhookRequest) (*models.Webhook, error) {
+	events, err := validateWebhookRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	row := database.DB.QueryRowContext(ctx, `
+		UPDATE webhooks
+		SET url = $1, events = $2, description = NULLIF($3, ''),
+		    is_active = COALESCE($4, is_active), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND user_id = $6
+		RETURNING `+webhookFields,
+		req.URL, pq.Array(events), req.Description, req.IsActive, id, userID)
+	w, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return w, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery history
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, userID, id int) error {
+	result, err := database.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	return nil
+}
+
+// GetDeliveries returns the most recent deliveries for one of a user's webhooks
+func (ws *WebhookService) GetDeliveries(ctx context.Context, userID, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := ws.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at,
+		       last_status_code, COALESCE(last_error, ''), created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.Payload = json.RawMessage(payload)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Emit queues an event for every active webhook of the user subscribed to it.
+// A non-empty dedupeKey makes the event fire at most once per webhook.
+func (ws *WebhookService) Emit(userID int, event, dedupeKey string, data interface{}) error {
+	payload, err := json.Marshal(models.WebhookPayload{
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, dedupe_key, payload)
+		SELECT id, $2, NULLIF($3, ''), $4
+		FROM webhooks
+		WHERE user_id = $1 AND is_active = true AND ($2 = ANY(events) OR '*' = ANY(events))
+		ON CONFLICT (webhook_id, dedupe_key) DO NOTHING
+	`, userID, event, dedupeKey, payload)
+	if err != nil {
+		return fmt.Errorf("failed to queue webhook event: %w", err)
+	}
+	return nil
+}
+
+// SendTestEvent queues a webhook.test delivery for one webhook regardless of
+// its event subscriptions
+func (ws *WebhookService) SendTestEvent(ctx context.Context, userID, webhookID int) error {
+	if _, err := ws.GetWebhook(ctx, userID, webhookID); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		Event:     models.WebhookEventTest,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]interface{}{"webhook_id": webhookID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	_, err = database.DB.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		VALUES ($1, $2, $3)
+	`, webhookID, models.WebhookEventTest, payload)
+	if err != nil {
+		return fmt.Errorf("failed to queue webhook event: %w", err)
+	}
+	return nil
+}
+
+// CheckQuotaThresholds queues quota.warning at 80% and quota.exceeded at 100%
+// of a limit, once per webhook per period, and emails the user about each
+// once per period. period is "daily" or "monthly".
+func (ws *WebhookService) CheckQuotaThresholds(userID, usage, limit int, period string) {
+	if limit <= 0 {
+		return
+	}
+
+	periodKey := time.Now().Format("2006-01")
+	if period == "daily" {
+		periodKey = time.Now().Format("2006-01-02")
+	}
+
+	thresholds := []struct {
+		percent      int
+		event        string
+		notification string
+	}{
+		{80, models.WebhookEventQuotaWarning, NotificationQuotaWarning},
+		{100, models.WebhookEventQuotaExceeded, NotificationQuotaExceeded},
+	}
+
+	for _, t := range thresholds {
+		if usage*100 < limit*t.percent {
+			continue
+		}
+
+		dedupeKey := fmt.Sprintf("%s:%s:%s", t.event, period, periodKey)
+		cacheKey := fmt.Sprintf("%d:%s", userID, dedupeKey)
+
+		ws.mu.Lock()
+		done := ws.notified[cacheKey]
+		ws.mu.Unlock()
+		if done {
+			continue
+		}
+
+		err := ws.Emit(userID, t.event, dedupeKey, map[string]interface{}{
+			"period":    period,
+			"threshold": t.percent,
+			"usage":     usage,
+			"limit":     limit,
+		})
+		if err != nil {
+			slog.Warn("failed to queue webhook", "event", t.event, "user_id", userID, "error", err)
+			continue
+		}
+		Notifications.Notify(userID, t.notification, dedupeKey, map[string]interface{}{
+			"Period":    period,
+			"Threshold": t.percent,
+			"Usage":     usage,
+			"Limit":     limit,
+		})
+
+		ws.mu.Lock()
+		ws.notified[cacheKey] = true
+		ws.mu.Unlock()
+	}
+}
+
+// StartDeliveryWorker polls for due deliveries in the background until the
+// process exits
+func (ws *WebhookService) StartDeliveryWorker() {
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+		beat("webhook_delivery", webhookPollInterval)
+
+		for range ticker.C {
+			beat("webhook_delivery", webhookPollInterval)
+			if err := ws.deliverDue(); err != nil {
+				slog.Error("webhook delivery worker failed", "error", err)
+			}
+		}
+	}()
+}
+
+type claimedDelivery struct {
+	id       int
+	event    string
+	payload  []byte
+	attempts int
+	url      string
+	secret   string
+	isActive bool
+}
+
+// deliverDue claims a batch of due deliveries and attempts each one. Claiming
+// pushes next_attempt_at forward, so concurrent workers skip in-flight rows.
+func (ws *WebhookService) deliverDue() error {
+	rows, err := database.DB.Query(`
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $1 * INTERVAL '1 second'
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event, d.payload, d.attempts, w.url, w.secret, w.is_active
+	`, int(webhookLease.Seconds()), webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	var claimed []claimedDelivery
+	for rows.Next() {
+		var d claimedDelivery
+		if err := rows.Scan(&d.id, &d.event, &d.payload, &d.attempts, &d.url, &d.secret, &d.isActive); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		claimed = append(claimed, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range claimed {
+		ws.attempt(d)
+	}
+	return nil
+}
+
+// attempt POSTs a single delivery and records the outcome, scheduling a retry
+// with exponential backoff on failure
+func (ws *WebhookService) attempt(d claimedDelivery) {
+	if !d.isActive {
+		database.DB.Exec(`
+			UPDATE webhook_deliveries SET status = 'failed', last_error = 'webhook is disabled'
+			WHERE id = $1
+		`, d.id)
+		return
+	}
+
+	statusCode, err := ws.post(d)
+	attempts := d.attempts + 1
+
+	var codeArg interface{}
+	if statusCode > 0 {
+		codeArg = statusCode
+	}
+
+	if err == nil {
+		_, dbErr := database.DB.Exec(`
+			UPDATE webhook_deliveries
+			SET status = 'delivered', attempts = $1, last_status_code = $2, last_error = NULL, delivered_at = NOW()
+			WHERE id = $3
+		`, attempts, codeArg, d.id)
+		if dbErr != nil {
+			slog.Error("failed to record webhook delivery", "delivery_id", d.id, "error", dbErr)
+		}
+		return
+	}
+
+	status := "pending"
+	if attempts >= webhookMaxAttempts {
+		status = "failed"
+	}
+	_, dbErr := database.DB.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_status_code = $3, last_error = $4,
+		    next_attempt_at = NOW() + $5 * INTERVAL '1 second'
+		WHERE id = $6
+	`, status, attempts, codeArg, err.Error(), int(webhookBackoff(attempts).Seconds()), d.id)
+	if dbErr != nil {
+		slog.Error("failed to record webhook delivery", "delivery_id", d.id, "error", dbErr)
+	}
+}
+
+// post sends the signed payload and returns the response status code
+func (ws *WebhookService) post(d claimedDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "geocoding-api-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.event)
+	req.Header.Set("X-Webhook-Delivery", fmt.Sprintf("%d", d.id))
+	req.Header.Set("X-Webhook-Signature", signWebhookPayload(d.secret, timestamp, d.payload))
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload builds the X-Webhook-Signature header value. Receivers
+// recompute HMAC-SHA256 over "<t>.<body>" with their secret and compare.
+func signWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// webhookBackoff returns the delay before the next attempt: 30s doubling
+// each attempt, capped at six hours
+func webhookBackoff(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= 6*time.Hour {
+			return 6 * time.Hour
+		}
+	}
+	return delay
+}
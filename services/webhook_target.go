@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// webhookBlockedNets are ranges webhooks may not target on top of the
+// loopback, private, link-local, multicast and unspecified addresses net.IP
+// recognizes: "this network" and carrier-grade NAT
+var webhookBlockedNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// lookupWebhookHost resolves webhook hosts at registration
+var lookupWebhookHost = net.DefaultResolver.LookupIPAddr
+
+// webhookIPAllowed reports whether a webhook may be delivered to ip. Internal
+// addresses are refused so webhooks can't be used to reach the server's own
+// network, such as cloud metadata endpoints or the database.
+func webhookIPAllowed(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, blocked := range webhookBlockedNets {
+		if blocked.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookHost resolves host and refuses it when any of its addresses
+// is internal
+func checkWebhookHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !webhookIPAllowed(ip) {
+			return fmt.Errorf("url must not point to a private, loopback or link-local address")
+		}
+		return nil
+	}
+
+	addrs, err := lookupWebhookHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("url host %q could not be resolved", host)
+	}
+	for _, addr := range addrs {
+		if !webhookIPAllowed(addr.IP) {
+			return fmt.Errorf("url must not point to a private, loopback or link-local address")
+		}
+	}
+	return nil
+}
+
+// webhookDialControl refuses connections to internal addresses. It runs on
+// the address actually dialed, after DNS resolution, so a host that resolves
+// differently at delivery than at registration is still caught.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !webhookIPAllowed(ip) {
+		return fmt.Errorf("webhook target %s is not allowed", host)
+	}
+	return nil
+}
+
+// newWebhookClient returns the client deliveries are sent with. It dials
+// only public addresses, ignores proxy settings and doesn't follow
+// redirects, which count as failed deliveries.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
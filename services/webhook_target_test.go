@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWebhookRequestRejectsInternalTargets(t *testing.T) {
+	for _, target := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:5432/",
+		"http://10.1.2.3/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]:8080/hook",
+		"http://[fe80::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://224.0.0.1/hook",
+	} {
+		t.Run(target, func(t *testing.T) {
+			_, err := validateWebhookRequest(context.Background(), models.WebhookRequest{URL: target, Events: []string{"*"}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidateWebhookRequestResolvesHosts(t *testing.T) {
+	original := lookupWebhookHost
+	defer func() { lookupWebhookHost = original }()
+	lookupWebhookHost = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "rebind.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+
+	events, err := validateWebhookRequest(context.Background(), models.WebhookRequest{URL: "https://hooks.example.com/in", Events: []string{"*"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*"}, events)
+
+	_, err = validateWebhookRequest(context.Background(), models.WebhookRequest{URL: "https://rebind.example.com/in", Events: []string{"*"}})
+	assert.Error(t, err)
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The test server listens on loopback, so the dial is refused even
+	// though the URL was never validated
+	_, err := newWebhookClient().Get(server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	client := newWebhookClient()
+	client.Transport = http.DefaultTransport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+}
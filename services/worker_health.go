@@ -0,0 +1,55 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"geocoding-api/models"
+)
+
+// workerStallPolls is how many polls a worker can miss before the health
+// check reports it stalled
+const workerStallPolls = 3
+
+// workerHeartbeat is when a polling worker last went round its loop and how
+// often it is meant to
+type workerHeartbeat struct {
+	interval time.Duration
+	last     time.Time
+}
+
+// workerHeartbeats holds a heartbeat for each polling background worker
+// that has started, so the health check can tell a stalled worker from an
+// idle one
+var workerHeartbeats = struct {
+	sync.Mutex
+	workers map[string]*workerHeartbeat
+}{workers: map[string]*workerHeartbeat{}}
+
+// beat records that the named worker, which polls every interval, is alive
+func beat(name string, interval time.Duration) {
+	workerHeartbeats.Lock()
+	defer workerHeartbeats.Unlock()
+	workerHeartbeats.workers[name] = &workerHeartbeat{interval: interval, last: time.Now()}
+}
+
+// WorkerStatuses reports every background worker that has started, by
+// name. A worker is stalled once it has missed workerStallPolls polls.
+func WorkerStatuses(now time.Time) []models.WorkerStatus {
+	workerHeartbeats.Lock()
+	defer workerHeartbeats.Unlock()
+
+	statuses := make([]models.WorkerStatus, 0, len(workerHeartbeats.workers))
+	for name, hb := range workerHeartbeats.workers {
+		status := models.WorkerStatus{
+			Name:            name,
+			LastHeartbeat:   hb.last,
+			IntervalSeconds: hb.interval.Seconds(),
+			Alive:           now.Sub(hb.last) <= workerStallPolls*hb.interval,
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
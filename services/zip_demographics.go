@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+
+	"github.com/lib/pq"
+)
+
+// zipDemographicsFiles are the names the ZIP demographics CSV is looked for
+// under
+var zipDemographicsFiles = []string{"zip_demographics.csv", "ZIP_DEMOGRAPHICS.csv"}
+
+// zipDemographicsColumns are the header names each field is found under,
+// covering both plain names and the raw ACS table variables
+var zipDemographicsColumns = map[string][]string{
+	"zip":        {"ZCTA", "ZCTA5", "ZCTA5CE20", "ZIP", "ZIP_CODE", "GEOID", "zip code tabulation area"},
+	"households": {"HOUSEHOLDS", "B11001_001E"},
+	"income":     {"MEDIAN_HOUSEHOLD_INCOME", "B19013_001E"},
+	"latitude":   {"POP_LATITUDE", "POP_LAT", "LATITUDE"},
+	"longitude":  {"POP_LONGITUDE", "POP_LON", "POP_LNG", "LONGITUDE"},
+}
+
+// GetZipDemographics returns the loaded demographics of the ZIP codes, keyed
+// by ZIP code. ZIP codes without a row are left out.
+func GetZipDemographics(ctx context.Context, zipCodes []string) (map[string]*models.ZipDemographics, error) {
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, `
+		SELECT zip_code, households, median_household_income, population_latitude, population_longitude
+		FROM zip_demographics
+		WHERE zip_code = ANY($1)
+	`, pq.Array(zipCodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ZIP demographics: %w", err)
+	}
+	defer rows.Close()
+
+	demographics := make(map[string]*models.ZipDemographics, len(zipCodes))
+	for rows.Next() {
+		var zipCode string
+		var households, income sql.NullInt64
+		var lat, lng sql.NullFloat64
+		if err := rows.Scan(&zipCode, &households, &income, &lat, &lng); err != nil {
+			return nil, fmt.Errorf("failed to scan ZIP demographics: %w", err)
+		}
+		d := &models.ZipDemographics{}
+		if households.Valid {
+			value := int(households.Int64)
+			d.Households = &value
+		}
+		if income.Valid {
+			value := int(income.Int64)
+			d.MedianHouseholdIncome = &value
+		}
+		if lat.Valid && lng.Valid {
+			d.PopulationCentroid = &models.Coordinates{Lat: lat.Float64, Lng: lng.Float64}
+		}
+		demographics[zipCode] = d
+	}
+	return demographics, rows.Err()
+}
+
+// parseZipDemographicsRow reads one CSV row through field, which returns ""
+// for columns the file doesn't have. ACS marks suppressed estimates with
+// large negative values, which are stored as null; a malformed value makes
+// the whole row invalid.
+func parseZipDemographicsRow(field func(name string) string) (string, models.ZipDemographics, bool) {
+	var d models.ZipDemographics
+
+	// GEOIDs like "8600000US43215" end in the ZCTA
+	zipCode := field("zip")
+	if len(zipCode) > 5 {
+		zipCode = zipCode[len(zipCode)-5:]
+	}
+	zipCode = padCode(zipCode, 5)
+	if len(zipCode) != 5 || strings.Trim(zipCode, "0123456789") != "" || zipCode == "00000" {
+		return "", d, false
+	}
+
+	for name, target := range map[string]**int{"households": &d.Households, "income": &d.MedianHouseholdIncome} {
+		value := field(name)
+		if value == "" {
+			continue
+		}
+		count, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", d, false
+		}
+		if count >= 0 {
+			n := int(count)
+			*target = &n
+		}
+	}
+
+	latValue, lngValue := field("latitude"), field("longitude")
+	if latValue != "" || lngValue != "" {
+		lat, latErr := strconv.ParseFloat(latValue, 64)
+		lng, lngErr := strconv.ParseFloat(lngValue, 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			return "", d, false
+		}
+		d.PopulationCentroid = &models.Coordinates{Lat: lat, Lng: lng}
+	}
+	return zipCode, d, true
+}
+
+// loadZipDemographics upserts the rows of the ZIP demographics CSV. Columns
+// are found by name; only the ZCTA column is required.
+func loadZipDemographics(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	file, source, err := openDataFile(zipDemographicsFiles...)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	run.setSource(source)
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := map[string]int{}
+	for name, candidates := range zipDemographicsColumns {
+		if index := csvColumn(header, "", candidates); index >= 0 {
+			columns[name] = index
+		}
+	}
+	if _, ok := columns["zip"]; !ok {
+		return fmt.Errorf("%s has no ZCTA column", source)
+	}
+	if len(columns) == 1 {
+		return fmt.Errorf("%s has no income, household or centroid columns", source)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO zip_demographics (zip_code, households, median_household_income, population_latitude, population_longitude)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (zip_code) DO UPDATE SET
+			households = EXCLUDED.households,
+			median_household_income = EXCLUDED.median_household_income,
+			population_latitude = EXCLUDED.population_latitude,
+			population_longitude = EXCLUDED.population_longitude,
+			updated_at = NOW()
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			slog.Warn("failed to read ZIP demographics row", "error", err)
+			run.record(rowInvalid)
+			continue
+		}
+
+		zipCode, d, ok := parseZipDemographicsRow(func(name string) string {
+			if index, ok := columns[name]; ok && index < len(record) {
+				return strings.TrimSpace(record[index])
+			}
+			return ""
+		})
+		if !ok {
+			slog.Warn("skipping invalid ZIP demographics row", "row", record)
+			run.record(rowInvalid)
+			continue
+		}
+
+		var lat, lng interface{}
+		if d.PopulationCentroid != nil {
+			lat, lng = d.PopulationCentroid.Lat, d.PopulationCentroid.Lng
+		}
+		outcome, err := upsertRow(ctx, stmt, zipCode, d.Households, d.MedianHouseholdIncome, lat, lng)
+		if err != nil {
+			return fmt.Errorf("failed to insert demographics for ZIP %s: %w", zipCode, err)
+		}
+		run.record(outcome)
+	}
+}
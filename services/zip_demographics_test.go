@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseZipDemographicsRow(t *testing.T) {
+	row := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	zipCode, d, ok := parseZipDemographicsRow(row(map[string]string{
+		"zip": "8600000US43215", "households": "9310", "income": "71250",
+		"latitude": "39.9612", "longitude": "-83.0040",
+	}))
+	require.True(t, ok)
+	assert.Equal(t, "43215", zipCode)
+	require.NotNil(t, d.Households)
+	assert.Equal(t, 9310, *d.Households)
+	require.NotNil(t, d.MedianHouseholdIncome)
+	assert.Equal(t, 71250, *d.MedianHouseholdIncome)
+	require.NotNil(t, d.PopulationCentroid)
+	assert.Equal(t, 39.9612, d.PopulationCentroid.Lat)
+	assert.Equal(t, -83.0040, d.PopulationCentroid.Lng)
+
+	// Suppressed ACS estimates and missing columns are null
+	zipCode, d, ok = parseZipDemographicsRow(row(map[string]string{"zip": "1001", "households": "120", "income": "-666666666"}))
+	require.True(t, ok)
+	assert.Equal(t, "01001", zipCode)
+	assert.NotNil(t, d.Households)
+	assert.Nil(t, d.MedianHouseholdIncome)
+	assert.Nil(t, d.PopulationCentroid)
+
+	for name, values := range map[string]map[string]string{
+		"missing ZIP":           {"households": "10"},
+		"non-numeric ZIP":       {"zip": "4321A"},
+		"malformed income":      {"zip": "43215", "income": "n/a"},
+		"half a centroid":       {"zip": "43215", "latitude": "39.96"},
+		"centroid out of range": {"zip": "43215", "latitude": "139.96", "longitude": "-83.00"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, ok := parseZipDemographicsRow(row(values))
+			assert.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+)
+
+// ZipCentroidIndex holds every ZIP code in memory with a k-d tree over their
+// centers, so distance, nearby and proximity queries are answered with
+// haversine math instead of a database round trip. Until it is loaded, and
+// for ZIP codes it doesn't have, queries fall back to the database.
+type ZipCentroidIndex struct {
+	current atomic.Pointer[zipIndex]
+	// loading serializes rebuilds
+	loading sync.Mutex
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// ZipCentroids is the index used by the distance endpoints
+var ZipCentroids = &ZipCentroidIndex{}
+
+// zipIndex is one immutable build of the index
+type zipIndex struct {
+	byZip map[string]*models.ZipCode
+	// tree is a k-d tree laid out in place: each slice's middle element
+	// splits it on axis depth%3, with the lower half before it
+	tree []zipPoint
+}
+
+// zipPoint is a ZIP code's center as a point on the unit sphere, where the
+// straight-line (chord) distance between points grows with their
+// great-circle distance
+type zipPoint struct {
+	xyz [3]float64
+	zip *models.ZipCode
+}
+
+// Load reads every ZIP code from the database and replaces the index
+func (z *ZipCentroidIndex) Load(ctx context.Context) error {
+	z.loading.Lock()
+	defer z.loading.Unlock()
+
+	start := time.Now()
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, `
+		SELECT zip_code, city_name, state_code, state_name, zcta, zcta_parent,
+			   population, density, primary_county_code, primary_county_name,
+			   county_weights, county_names, county_codes, imprecise, military,
+			   timezone, latitude, longitude
+		FROM zip_codes
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query ZIP codes: %w", err)
+	}
+	defer rows.Close()
+
+	var zipCodes []*models.ZipCode
+	for rows.Next() {
+		zc := &models.ZipCode{}
+		err := rows.Scan(
+			&zc.ZipCode, &zc.CityName, &zc.StateCode, &zc.StateName, &zc.ZCTA, &zc.ZCTAParent,
+			&zc.Population, &zc.Density, &zc.PrimaryCountyCode, &zc.PrimaryCountyName,
+			&zc.CountyWeights, &zc.CountyNames, &zc.CountyCodes, &zc.Imprecise, &zc.Military,
+			&zc.Timezone, &zc.Latitude, &zc.Longitude,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan ZIP code: %w", err)
+		}
+		zipCodes = append(zipCodes, zc)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read ZIP codes: %w", err)
+	}
+
+	z.current.Store(newZipIndex(zipCodes))
+	slog.Info("loaded ZIP centroid index", "zip_codes", len(zipCodes), "duration_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// Rebuild reloads the index in the background, after the ZIP codes change
+func (z *ZipCentroidIndex) Rebuild() {
+	go func() {
+		if err := z.Load(context.Background()); err != nil {
+			slog.Warn("failed to rebuild ZIP centroid index", "error", err)
+		}
+	}()
+}
+
+// Get returns an indexed ZIP code, or nil when the index doesn't have it.
+// The result is shared and must not be modified.
+func (z *ZipCentroidIndex) Get(zipCode string) *models.ZipCode {
+	idx := z.current.Load()
+	if idx == nil {
+		z.misses.Add(1)
+		return nil
+	}
+	zc := idx.byZip[zipCode]
+	if zc == nil {
+		z.misses.Add(1)
+		return nil
+	}
+	z.hits.Add(1)
+	return zc
+}
+
+// WithinRadius returns up to limit ZIP codes other than center within
+// radiusMiles of it, nearest first. ok is false when center isn't indexed.
+func (z *ZipCentroidIndex) WithinRadius(center *models.ZipCode, radiusMiles float64, limit int) ([]*RadiusSearchResult, bool) {
+	idx := z.current.Load()
+	if idx == nil || idx.byZip[center.ZipCode] == nil {
+		z.misses.Add(1)
+		return nil, false
+	}
+	z.hits.Add(1)
+	return idx.nearPoint(center.Latitude, center.Longitude, radiusMiles, limit, center.ZipCode), true
+}
+
+// NearPoint returns up to limit ZIP codes whose centers are within
+// radiusMiles of a point, nearest first. ok is false until the index is
+// loaded.
+func (z *ZipCentroidIndex) NearPoint(lat, lng, radiusMiles float64, limit int) ([]*RadiusSearchResult, bool) {
+	idx := z.current.Load()
+	if idx == nil {
+		z.misses.Add(1)
+		return nil, false
+	}
+	z.hits.Add(1)
+	return idx.nearPoint(lat, lng, radiusMiles, limit, ""), true
+}
+
+// nearPoint finds the ZIP codes within radiusMiles of a point, leaving out
+// exclude
+func (idx *zipIndex) nearPoint(lat, lng, radiusMiles float64, limit int, exclude string) []*RadiusSearchResult {
+	// The chord subtending radiusMiles on the unit sphere, padded so
+	// rounding can't drop a ZIP code the haversine check below keeps
+	chord := 2.0
+	if angle := radiusMiles / earthRadiusMiles; angle < math.Pi {
+		chord = 2*math.Sin(angle/2) + 1e-9
+	}
+
+	results := []*RadiusSearchResult{}
+	withinChord(idx.tree, 0, unitVector(lat, lng), chord, func(p *zipPoint) {
+		if p.zip.ZipCode == exclude {
+			return
+		}
+		distance := haversineDistance(lat, lng, p.zip.Latitude, p.zip.Longitude)
+		if distance <= radiusMiles {
+			results = append(results, &RadiusSearchResult{
+				ZipCode:       p.zip,
+				DistanceMiles: distance,
+				DistanceKm:    distance * 1.60934,
+			})
+		}
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DistanceMiles != results[j].DistanceMiles {
+			return results[i].DistanceMiles < results[j].DistanceMiles
+		}
+		return results[i].ZipCode.ZipCode < results[j].ZipCode.ZipCode
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// Stats reports the index's size and how often it answered a query (hits)
+// or sent it to the database (misses)
+func (z *ZipCentroidIndex) Stats() CacheStats {
+	stats := CacheStats{
+		Name:   "zip_centroid_index",
+		Hits:   z.hits.Load(),
+		Misses: z.misses.Load(),
+	}
+	if idx := z.current.Load(); idx != nil {
+		stats.Enabled = true
+		stats.Entries = len(idx.byZip)
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// newZipIndex builds the index over zipCodes
+func newZipIndex(zipCodes []*models.ZipCode) *zipIndex {
+	idx := &zipIndex{
+		byZip: make(map[string]*models.ZipCode, len(zipCodes)),
+		tree:  make([]zipPoint, len(zipCodes)),
+	}
+	for i, zc := range zipCodes {
+		idx.byZip[zc.ZipCode] = zc
+		idx.tree[i] = zipPoint{xyz: unitVector(zc.Latitude, zc.Longitude), zip: zc}
+	}
+	buildKDTree(idx.tree, 0)
+	return idx
+}
+
+// buildKDTree arranges points so each slice's middle element splits the
+// rest on axis depth%3
+func buildKDTree(points []zipPoint, depth int) {
+	if len(points) <= 1 {
+		return
+	}
+	axis := depth % 3
+	sort.Slice(points, func(i, j int) bool { return points[i].xyz[axis] < points[j].xyz[axis] })
+	mid := len(points) / 2
+	buildKDTree(points[:mid], depth+1)
+	buildKDTree(points[mid+1:], depth+1)
+}
+
+// withinChord calls visit for every point of a k-d tree within chord of
+// target
+func withinChord(points []zipPoint, depth int, target [3]float64, chord float64, visit func(*zipPoint)) {
+	if len(points) == 0 {
+		return
+	}
+	mid := len(points) / 2
+	p := &points[mid]
+	dx, dy, dz := p.xyz[0]-target[0], p.xyz[1]-target[1], p.xyz[2]-target[2]
+	if dx*dx+dy*dy+dz*dz <= chord*chord {
+		visit(p)
+	}
+
+	offset := target[depth%3] - p.xyz[depth%3]
+	if offset <= chord {
+		withinChord(points[:mid], depth+1, target, chord, visit)
+	}
+	if offset >= -chord {
+		withinChord(points[mid+1:], depth+1, target, chord, visit)
+	}
+}
+
+// unitVector is a latitude and longitude in degrees as a point on the unit
+// sphere
+func unitVector(lat, lng float64) [3]float64 {
+	latRad := lat * math.Pi / 180.0
+	lngRad := lng * math.Pi / 180.0
+	return [3]float64{
+		math.Cos(latRad) * math.Cos(lngRad),
+		math.Cos(latRad) * math.Sin(lngRad),
+		math.Sin(latRad),
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+	"testing"
+
+	"geocoding-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleZipCodes reads the bundled Ohio sample ZIP codes
+func sampleZipCodes(t *testing.T) []*models.ZipCode {
+	file, err := sampleData.Open("sampledata/zip_codes_oh.csv.gz")
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	reader, err := newZipCodeCSVReader(gz)
+	require.NoError(t, err)
+
+	var zipCodes []*models.ZipCode
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return zipCodes
+		}
+		require.NoError(t, err)
+		zc, err := parseCSVRecord(record)
+		require.NoError(t, err)
+		zipCodes = append(zipCodes, zc)
+	}
+}
+
+func TestZipCentroidIndex(t *testing.T) {
+	zipCodes := sampleZipCodes(t)
+	index := &ZipCentroidIndex{}
+
+	_, ok := index.WithinRadius(zipCodes[0], 10, 10)
+	assert.False(t, ok, "an unloaded index answers nothing")
+	_, ok = index.NearPoint(zipCodes[0].Latitude, zipCodes[0].Longitude, 10, 10)
+	assert.False(t, ok)
+	assert.Nil(t, index.Get(zipCodes[0].ZipCode))
+
+	index.current.Store(newZipIndex(zipCodes))
+	byZip := map[string]*models.ZipCode{}
+	for _, zc := range zipCodes {
+		byZip[zc.ZipCode] = zc
+	}
+
+	// The tree finds exactly what comparing every pair would
+	for _, center := range []string{"43215", "45202", "44101", "45750"} {
+		for _, radius := range []float64{0.5, 5, 25, 150, 20000} {
+			var want []string
+			for _, zc := range zipCodes {
+				if zc.ZipCode != center && haversineDistance(byZip[center].Latitude, byZip[center].Longitude, zc.Latitude, zc.Longitude) <= radius {
+					want = append(want, zc.ZipCode)
+				}
+			}
+
+			results, ok := index.WithinRadius(byZip[center], radius, len(zipCodes))
+			require.True(t, ok)
+			var got []string
+			for i, r := range results {
+				got = append(got, r.ZipCode.ZipCode)
+				assert.LessOrEqual(t, r.DistanceMiles, radius)
+				if i > 0 {
+					assert.LessOrEqual(t, results[i-1].DistanceMiles, r.DistanceMiles, "nearest first")
+				}
+			}
+			sort.Strings(want)
+			sort.Strings(got)
+			assert.Equal(t, want, got, "%s within %g miles", center, radius)
+		}
+	}
+
+	t.Run("limit keeps the nearest", func(t *testing.T) {
+		all, _ := index.WithinRadius(byZip["43215"], 25, len(zipCodes))
+		limited, _ := index.WithinRadius(byZip["43215"], 25, 5)
+		require.Len(t, limited, 5)
+		assert.Equal(t, all[:5], limited)
+	})
+
+	t.Run("near a point", func(t *testing.T) {
+		// Columbus city hall, not a ZIP center, so every ZIP code is kept
+		results, ok := index.NearPoint(39.9612, -83.0007, 5, len(zipCodes))
+		require.True(t, ok)
+		require.NotEmpty(t, results)
+		for i, r := range results {
+			assert.InDelta(t, haversineDistance(39.9612, -83.0007, r.ZipCode.Latitude, r.ZipCode.Longitude), r.DistanceMiles, 1e-9)
+			assert.LessOrEqual(t, r.DistanceMiles, 5.0)
+			if i > 0 {
+				assert.LessOrEqual(t, results[i-1].DistanceMiles, r.DistanceMiles, "nearest first")
+			}
+		}
+
+		// A point on a ZIP center includes that ZIP code at distance 0
+		results, _ = index.NearPoint(byZip["43215"].Latitude, byZip["43215"].Longitude, 25, 1)
+		require.Len(t, results, 1)
+		assert.Equal(t, "43215", results[0].ZipCode.ZipCode)
+		assert.Zero(t, results[0].DistanceMiles)
+	})
+
+	t.Run("unindexed center", func(t *testing.T) {
+		_, ok := index.WithinRadius(&models.ZipCode{ZipCode: "10001", Latitude: 40.75, Longitude: -73.99}, 25, 10)
+		assert.False(t, ok)
+		assert.Nil(t, index.Get("10001"))
+	})
+
+	assert.Equal(t, "43215", index.Get("43215").ZipCode)
+	stats := index.Stats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, len(zipCodes), stats.Entries)
+	assert.Positive(t, stats.Hits)
+	assert.Positive(t, stats.Misses)
+}
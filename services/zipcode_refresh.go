@@ -0,0 +1,227 @@
+package services
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"geocoding-api/database"
+	"geocoding-api/models"
+	"geocoding-api/utils"
+)
+
+const (
+	// defaultZipCodeSourceURL is the opendatasoft export the bundled CSV came from
+	defaultZipCodeSourceURL = "https://public.opendatasoft.com/api/explore/v2.1/catalog/datasets/georef-united-states-of-america-zc-point/exports/csv?delimiter=%3B"
+	// minZipCodeRefreshRatio guards against a truncated download wiping most
+	// of zip_codes: the new file must have at least this share of current rows
+	minZipCodeRefreshRatio = 0.9
+)
+
+// ErrZipCodeRefreshRunning is returned when a refresh is already in progress
+var ErrZipCodeRefreshRunning = errors.New("ZIP code refresh already running")
+
+// zipCodeRefreshMu keeps refreshes from overlapping
+var zipCodeRefreshMu sync.Mutex
+
+// zipCodeColumns are the zip_codes columns filled from the upstream CSV
+const zipCodeColumns = `zip_code, city_name, state_code, state_name, zcta, zcta_parent,
+	population, density, primary_county_code, primary_county_name,
+	county_weights, county_names, county_codes, imprecise, military,
+	timezone, latitude, longitude`
+
+// ZipCodeSourceURL returns ZIPCODE_SOURCE_URL or the opendatasoft export
+func ZipCodeSourceURL() string {
+	if url := os.Getenv("ZIPCODE_SOURCE_URL"); url != "" {
+		return url
+	}
+	return defaultZipCodeSourceURL
+}
+
+// RefreshZipCodes downloads the upstream ZIP code CSV, diffs it against
+// zip_codes and applies the inserts, updates and removals in one
+// transaction. With dryRun the changes are counted and rolled back. Unless
+// force is set, a file with far fewer rows than the table is rejected.
+func RefreshZipCodes(dryRun, force bool) (*models.ZipCodeRefreshSummary, error) {
+	if !zipCodeRefreshMu.TryLock() {
+		return nil, ErrZipCodeRefreshRunning
+	}
+	defer zipCodeRefreshMu.Unlock()
+
+	start := time.Now()
+	summary := &models.ZipCodeRefreshSummary{SourceURL: ZipCodeSourceURL(), DryRun: dryRun}
+
+	tempDir, err := os.MkdirTemp("", "zipcodes_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	destination := filepath.Join(tempDir, "zipcodes.csv")
+	downloader := utils.NewFileDownloader(tempDir)
+	if err := downloader.DownloadFile(utils.DownloadConfig{
+		URL:         summary.SourceURL,
+		Destination: destination,
+		CacheDir:    tempDir,
+	}); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open downloaded file: %w", err)
+	}
+	defer file.Close()
+
+	var source io.Reader = file
+	if strings.HasSuffix(strings.SplitN(summary.SourceURL, "?", 2)[0], ".gz") {
+		gzReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gzReader.Close()
+		source = gzReader
+	}
+
+	reader, err := newZipCodeCSVReader(source)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`CREATE TEMP TABLE zip_codes_staging (LIKE zip_codes INCLUDING DEFAULTS) ON COMMIT DROP`); err != nil {
+		return nil, fmt.Errorf("failed to create staging table: %w", err)
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf(`
+		INSERT INTO zip_codes_staging (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, zipCodeColumns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare staging insert: %w", err)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			summary.ParseErrors++
+			continue
+		}
+
+		zipCode, err := parseCSVRecord(record)
+		if err != nil {
+			summary.ParseErrors++
+			continue
+		}
+		if err := insertZipCode(stmt, zipCode); err != nil {
+			stmt.Close()
+			return nil, fmt.Errorf("failed to stage ZIP code %s: %w", zipCode.ZipCode, err)
+		}
+		summary.RecordsRead++
+	}
+	stmt.Close()
+
+	var current int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM zip_codes`).Scan(&current); err != nil {
+		return nil, fmt.Errorf("failed to count ZIP codes: %w", err)
+	}
+	if !force && float64(summary.RecordsRead) < float64(current)*minZipCodeRefreshRatio {
+		return nil, fmt.Errorf("upstream file has %d ZIP codes but zip_codes has %d; refusing to refresh without force", summary.RecordsRead, current)
+	}
+
+	// A ZIP listed twice upstream keeps its first row
+	if _, err := tx.Exec(`
+		DELETE FROM zip_codes_staging s
+		USING zip_codes_staging d
+		WHERE s.zip_code = d.zip_code AND s.ctid > d.ctid
+	`); err != nil {
+		return nil, fmt.Errorf("failed to deduplicate staging rows: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE zip_codes z SET
+			city_name = s.city_name,
+			state_code = s.state_code,
+			state_name = s.state_name,
+			zcta = s.zcta,
+			zcta_parent = s.zcta_parent,
+			population = s.population,
+			density = s.density,
+			primary_county_code = s.primary_county_code,
+			primary_county_name = s.primary_county_name,
+			county_weights = s.county_weights,
+			county_names = s.county_names,
+			county_codes = s.county_codes,
+			imprecise = s.imprecise,
+			military = s.military,
+			timezone = s.timezone,
+			latitude = s.latitude,
+			longitude = s.longitude,
+			updated_at = CURRENT_TIMESTAMP
+		FROM zip_codes_staging s
+		WHERE z.zip_code = s.zip_code
+			AND (z.city_name, z.state_code, z.state_name, z.zcta, z.zcta_parent, z.population, z.density,
+				z.primary_county_code, z.primary_county_name, z.county_weights, z.county_names, z.county_codes,
+				z.imprecise, z.military, z.timezone, z.latitude, z.longitude)
+			IS DISTINCT FROM
+				(s.city_name, s.state_code, s.state_name, s.zcta, s.zcta_parent, s.population, s.density,
+				s.primary_county_code, s.primary_county_name, s.county_weights, s.county_names, s.county_codes,
+				s.imprecise, s.military, s.timezone, s.latitude, s.longitude)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ZIP codes: %w", err)
+	}
+	updated, _ := result.RowsAffected()
+
+	result, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO zip_codes (%[1]s)
+		SELECT %[1]s FROM zip_codes_staging s
+		WHERE NOT EXISTS (SELECT 1 FROM zip_codes z WHERE z.zip_code = s.zip_code)
+	`, zipCodeColumns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert ZIP codes: %w", err)
+	}
+	added, _ := result.RowsAffected()
+
+	result, err = tx.Exec(`
+		DELETE FROM zip_codes z
+		WHERE NOT EXISTS (SELECT 1 FROM zip_codes_staging s WHERE s.zip_code = z.zip_code)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove ZIP codes: %w", err)
+	}
+	removed, _ := result.RowsAffected()
+
+	summary.Added = int(added)
+	summary.Updated = int(updated)
+	summary.Removed = int(removed)
+	summary.Unchanged = current - summary.Updated - summary.Removed
+
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit ZIP code refresh: %w", err)
+		}
+		zipCodesChanged()
+		markReferenceDataModified()
+	}
+
+	summary.DurationMs = time.Since(start).Milliseconds()
+	slog.Info("ZIP code refresh completed", "dry_run", dryRun, "read", summary.RecordsRead, "parse_errors", summary.ParseErrors,
+		"added", summary.Added, "updated", summary.Updated, "removed", summary.Removed, "duration_ms", summary.DurationMs)
+	return summary, nil
+}
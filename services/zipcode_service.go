@@ -1,45 +1,44 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"encoding/csv"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"geocoding-api/database"
 	"geocoding-api/models"
 )
 
-// LoadZipCodesFromCSV loads ZIP code data from CSV file into the database
-func LoadZipCodesFromCSV(filePath string) error {
-	file, err := os.Open(filePath)
+// zipCodeDataPaths are where the bundled ZIP code CSV is looked for
+var zipCodeDataPaths = []string{
+	"georef-united-states-of-america-zc-point.csv",
+	"/app/georef-united-states-of-america-zc-point.csv",
+}
+
+// loadZipCodes upserts every ZIP code in the bundled CSV
+func loadZipCodes(ctx context.Context, tx *sql.Tx, run *loadRun) error {
+	file, source, err := openDataFile(zipCodeDataPaths...)
 	if err != nil {
-		return fmt.Errorf("failed to open CSV file: %w", err)
+		return err
 	}
 	defer file.Close()
+	run.setSource(source)
 
-	reader := csv.NewReader(file)
-	reader.Comma = ';' // CSV uses semicolon as delimiter
-	reader.FieldsPerRecord = 17 // Expected number of fields
-
-	// Skip header row
-	_, err = reader.Read()
+	reader, err := newZipCodeCSVReader(file)
 	if err != nil {
-		return fmt.Errorf("failed to read CSV header: %w", err)
+		return err
 	}
 
-	// Prepare insert statement
-	stmt, err := database.DB.Prepare(`
-		INSERT INTO zip_codes (
-			zip_code, city_name, state_code, state_name, zcta, zcta_parent,
-			population, density, primary_county_code, primary_county_name,
-			county_weights, county_names, county_codes, imprecise, military,
-			timezone, latitude, longitude
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		INSERT INTO zip_codes (%s)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		ON CONFLICT (zip_code) DO UPDATE SET
 			city_name = EXCLUDED.city_name,
 			state_code = EXCLUDED.state_code,
@@ -59,48 +58,51 @@ func LoadZipCodesFromCSV(filePath string) error {
 			latitude = EXCLUDED.latitude,
 			longitude = EXCLUDED.longitude,
 			updated_at = CURRENT_TIMESTAMP
-	`)
+		RETURNING (xmax = 0)
+	`, zipCodeColumns))
 	if err != nil {
 		return fmt.Errorf("failed to prepare insert statement: %w", err)
 	}
 	defer stmt.Close()
 
-	recordCount := 0
-	errorCount := 0
-
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			log.Printf("Error reading CSV record: %v", err)
-			errorCount++
+			slog.Warn("failed to read ZIP CSV record", "error", err)
+			run.record(rowInvalid)
 			continue
 		}
 
 		zipCode, err := parseCSVRecord(record)
 		if err != nil {
-			log.Printf("Error parsing record %d: %v", recordCount+1, err)
-			errorCount++
+			slog.Warn("failed to parse ZIP record", "zip_code", record[0], "error", err)
+			run.record(rowInvalid)
 			continue
 		}
 
-		err = insertZipCode(stmt, zipCode)
+		outcome, err := upsertRow(ctx, stmt, zipCodeValues(zipCode)...)
 		if err != nil {
-			log.Printf("Error inserting ZIP code %s: %v", zipCode.ZipCode, err)
-			errorCount++
-			continue
-		}
-
-		recordCount++
-		if recordCount%1000 == 0 {
-			log.Printf("Processed %d records...", recordCount)
+			return fmt.Errorf("failed to insert ZIP code %s: %w", zipCode.ZipCode, err)
 		}
+		run.record(outcome)
 	}
+}
+
+// newZipCodeCSVReader returns a reader for the opendatasoft ZIP code export,
+// positioned after the header row
+func newZipCodeCSVReader(r io.Reader) (*csv.Reader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = ';' // CSV uses semicolon as delimiter
+	reader.FieldsPerRecord = 17 // Expected number of fields
 
-	log.Printf("CSV import completed. Successfully processed: %d, Errors: %d", recordCount, errorCount)
-	return nil
+	// Skip header row
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	return reader, nil
 }
 
 // parseCSVRecord parses a single CSV record into a ZipCode struct
@@ -175,7 +177,13 @@ func parseCSVRecord(record []string) (*models.ZipCode, error) {
 
 // insertZipCode inserts a ZipCode into the database
 func insertZipCode(stmt *sql.Stmt, zipCode *models.ZipCode) error {
-	_, err := stmt.Exec(
+	_, err := stmt.Exec(zipCodeValues(zipCode)...)
+	return err
+}
+
+// zipCodeValues returns the values for zipCodeColumns, in order
+func zipCodeValues(zipCode *models.ZipCode) []interface{} {
+	return []interface{}{
 		zipCode.ZipCode,
 		zipCode.CityName,
 		zipCode.StateCode,
@@ -194,24 +202,32 @@ func insertZipCode(stmt *sql.Stmt, zipCode *models.ZipCode) error {
 		zipCode.Timezone,
 		zipCode.Latitude,
 		zipCode.Longitude,
-	)
-	return err
+	}
 }
 
-// GetZipCodeByZip retrieves a ZIP code by its ZIP code
-func GetZipCodeByZip(zipCode string) (*models.ZipCode, error) {
+// GetZipCodeByZip retrieves a ZIP code by its ZIP code. Results, including
+// misses (nil), are served from the lookup cache when enabled.
+func GetZipCodeByZip(ctx context.Context, zipCode string) (*models.ZipCode, error) {
+	return lookupCaches.zip.GetOrLoad(zipCode, func() (*models.ZipCode, error) {
+		return queryZipCodeByZip(ctx, zipCode)
+	})
+}
+
+// queryZipCodeByZip reads a ZIP code from the database
+func queryZipCodeByZip(ctx context.Context, zipCode string) (*models.ZipCode, error) {
 	query := `
 		SELECT zip_code, city_name, state_code, state_name, zcta, zcta_parent,
 			   population, density, primary_county_code, primary_county_name,
 			   county_weights, county_names, county_codes, imprecise, military,
-			   timezone, latitude, longitude
+			   timezone, latitude, longitude, updated_at
 		FROM zip_codes
 		WHERE zip_code = $1
 	`
 
-	row := database.DB.QueryRow(query, zipCode)
+	row := database.ReadDB(ctx).QueryRowContext(ctx, query, zipCode)
 	
 	zc := &models.ZipCode{}
+	var updatedAt sql.NullTime
 	err := row.Scan(
 		&zc.ZipCode,
 		&zc.CityName,
@@ -231,6 +247,7 @@ func GetZipCodeByZip(zipCode string) (*models.ZipCode, error) {
 		&zc.Timezone,
 		&zc.Latitude,
 		&zc.Longitude,
+		&updatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -240,16 +257,25 @@ func GetZipCodeByZip(zipCode string) (*models.ZipCode, error) {
 		return nil, fmt.Errorf("failed to scan ZIP code: %w", err)
 	}
 
+	var vintage *time.Time
+	if updatedAt.Valid {
+		vintage = &updatedAt.Time
+	}
+	zc.Match = zipCentroidMatch(zc.Imprecise, vintage)
+
 	return zc, nil
 }
 
-// SearchZipCodesByCity searches for ZIP codes by city name
-func SearchZipCodesByCity(cityName string, stateCode string, limit int) ([]*models.ZipCode, error) {
+// SearchZipCodesByCity searches for ZIP codes by city name, returning one
+// page of results and the total number of matches. Results are ordered by
+// city name, or with ranked, exact city matches first, then cities starting
+// with the name, then the rest.
+func SearchZipCodesByCity(ctx context.Context, cityName string, stateCode string, limit, offset int, ranked bool) ([]*models.ZipCode, int, error) {
 	query := `
 		SELECT zip_code, city_name, state_code, state_name, zcta, zcta_parent,
 			   population, density, primary_county_code, primary_county_name,
 			   county_weights, county_names, county_codes, imprecise, military,
-			   timezone, latitude, longitude
+			   timezone, latitude, longitude, COUNT(*) OVER()
 		FROM zip_codes
 		WHERE LOWER(city_name) LIKE LOWER($1)
 	`
@@ -261,16 +287,29 @@ func SearchZipCodesByCity(cityName string, stateCode string, limit int) ([]*mode
 		args = append(args, stateCode)
 	}
 	
-	query += " ORDER BY city_name, zip_code LIMIT $" + strconv.Itoa(len(args)+1)
-	args = append(args, limit)
+	filterArgs := len(args)
+
+	if ranked {
+		n := strconv.Itoa(len(args) + 1)
+		query += ` ORDER BY CASE
+			WHEN LOWER(city_name) = LOWER($` + n + `) THEN 0
+			WHEN LOWER(city_name) LIKE LOWER($` + n + `) || '%' THEN 1
+			ELSE 2 END, city_name, zip_code`
+		args = append(args, cityName)
+	} else {
+		query += " ORDER BY city_name, zip_code"
+	}
+	query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, limit, offset)
 
-	rows, err := database.DB.Query(query, args...)
+	rows, err := database.ReadDB(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query ZIP codes: %w", err)
+		return nil, 0, fmt.Errorf("failed to query ZIP codes: %w", err)
 	}
 	defer rows.Close()
 
 	var zipCodes []*models.ZipCode
+	total := 0
 	for rows.Next() {
 		zc := &models.ZipCode{}
 		err := rows.Scan(
@@ -292,52 +331,169 @@ func SearchZipCodesByCity(cityName string, stateCode string, limit int) ([]*mode
 			&zc.Timezone,
 			&zc.Latitude,
 			&zc.Longitude,
+			&total,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan ZIP code: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan ZIP code: %w", err)
 		}
 		zipCodes = append(zipCodes, zc)
 	}
 
-	return zipCodes, nil
+	// An offset past the end returns no rows, so the window count is lost;
+	// count separately so clients still see the total
+	if len(zipCodes) == 0 && offset > 0 {
+		countQuery := "SELECT COUNT(*) FROM zip_codes WHERE LOWER(city_name) LIKE LOWER($1)"
+		if stateCode != "" {
+			countQuery += " AND state_code = $2"
+		}
+		if err := database.ReadDB(ctx).QueryRowContext(ctx, countQuery, args[:filterArgs]...).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("failed to count ZIP codes: %w", err)
+		}
+	}
+
+	return zipCodes, total, nil
 }
 
-// InitializeData checks if ZIP code data exists and loads it if empty
-func InitializeData() error {
-	// Check if we have any data
-	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM zip_codes").Scan(&count)
+// defaultSearchSimilarityThreshold is the minimum pg_trgm similarity for a
+// misspelled city or state name to be corrected
+const defaultSearchSimilarityThreshold = 0.3
+
+// searchSimilarityThreshold reads SEARCH_SIMILARITY_THRESHOLD (0 < t <= 1)
+func searchSimilarityThreshold() float64 {
+	if t, err := strconv.ParseFloat(os.Getenv("SEARCH_SIMILARITY_THRESHOLD"), 64); err == nil && t > 0 && t <= 1 {
+		return t
+	}
+	return defaultSearchSimilarityThreshold
+}
+
+// SearchZipCodesByCityFuzzy searches like SearchZipCodesByCity, ordered the
+// same way for ranked, but tolerates
+// misspellings. The state may be a code or a (possibly misspelled) name. When
+// the city matches nothing, the closest city name by trigram similarity is
+// searched instead and returned as the correction; correction is nil when the
+// query was used as given.
+func SearchZipCodesByCityFuzzy(ctx context.Context, cityName string, state string, limit, offset int, ranked bool) ([]*models.ZipCode, int, *models.CorrectedQuery, error) {
+	threshold := searchSimilarityThreshold()
+
+	stateCode, stateScore, err := resolveStateCode(ctx, state, threshold)
 	if err != nil {
-		return fmt.Errorf("failed to count existing records: %w", err)
+		return nil, 0, nil, err
+	}
+	if state != "" && stateCode == "" {
+		return nil, 0, nil, nil
 	}
 
-	if count > 0 {
-		log.Printf("Database already contains %d ZIP code records", count)
-		return nil
+	results, total, err := SearchZipCodesByCity(ctx, cityName, stateCode, limit, offset, ranked)
+	if err != nil || total > 0 {
+		var correction *models.CorrectedQuery
+		if err == nil && stateScore < 1 {
+			correction = &models.CorrectedQuery{
+				City:          cityName,
+				State:         stateCode,
+				OriginalCity:  cityName,
+				OriginalState: state,
+				Similarity:    stateScore,
+			}
+		}
+		return results, total, correction, err
 	}
 
-	log.Println("No ZIP code data found, attempting to load from CSV...")
-	
-	// Try to find the CSV file in common locations
-	csvPaths := []string{
-		"georef-united-states-of-america-zc-point.csv",
-		"/app/georef-united-states-of-america-zc-point.csv",
-		"./georef-united-states-of-america-zc-point.csv",
-	}
-
-	var csvPath string
-	for _, path := range csvPaths {
-		if _, err := os.Stat(path); err == nil {
-			csvPath = path
-			break
+	corrected, cityScore, err := closestCityName(ctx, cityName, stateCode, threshold)
+	if err != nil || corrected == "" {
+		return nil, 0, nil, err
+	}
+
+	results, total, err = SearchZipCodesByCity(ctx, corrected, stateCode, limit, offset, ranked)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	similarity := cityScore
+	if stateScore < similarity {
+		similarity = stateScore
+	}
+	return results, total, &models.CorrectedQuery{
+		City:          corrected,
+		State:         stateCode,
+		OriginalCity:  cityName,
+		OriginalState: state,
+		Similarity:    similarity,
+	}, nil
+}
+
+// resolveStateCode maps a state code or name to its code. A score below 1
+// means the name was spelling-corrected; an empty code means no state matched.
+func resolveStateCode(ctx context.Context, state string, threshold float64) (string, float64, error) {
+	state = strings.TrimSpace(state)
+	if state == "" {
+		return "", 1, nil
+	}
+	if len(state) == 2 {
+		return strings.ToUpper(state), 1, nil
+	}
+
+	var code string
+	if database.Embedded() {
+		// SQLite has no pg_trgm, so names aren't spelling-corrected
+		err := database.DB.QueryRowContext(ctx, "SELECT state_code FROM zip_codes WHERE LOWER(state_name) = LOWER($1) LIMIT 1", state).Scan(&code)
+		if err == sql.ErrNoRows {
+			return "", 0, nil
 		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to resolve state: %w", err)
+		}
+		return code, 1, nil
 	}
 
-	if csvPath == "" {
-		log.Println("CSV file not found in common locations. You can load data manually using the /api/v1/admin/load-data endpoint")
-		return nil
+	var score float64
+	var exact bool
+	err := database.ReadDB(ctx).QueryRowContext(ctx, `
+		SELECT state_code, similarity(state_name, $1) AS score, LOWER(state_name) = LOWER($1) AS exact
+		FROM zip_codes
+		WHERE LOWER(state_name) = LOWER($1) OR similarity(state_name, $1) >= $2
+		GROUP BY state_code, state_name
+		ORDER BY exact DESC, score DESC
+		LIMIT 1
+	`, state, threshold).Scan(&code, &score, &exact)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
 	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to resolve state: %w", err)
+	}
+	if exact {
+		return code, 1, nil
+	}
+	return code, score, nil
+}
 
-	log.Printf("Found CSV file at: %s", csvPath)
-	return LoadZipCodesFromCSV(csvPath)
-}
\ No newline at end of file
+// closestCityName returns the city name most similar to cityName, optionally
+// within one state, or "" when nothing reaches the threshold. Ties go to the
+// city with more ZIP codes. The embedded SQLite database never corrects.
+func closestCityName(ctx context.Context, cityName, stateCode string, threshold float64) (string, float64, error) {
+	if database.Embedded() {
+		return "", 0, nil
+	}
+	query := `
+		SELECT city_name, similarity(city_name, $1) AS score
+		FROM zip_codes
+		WHERE similarity(city_name, $1) >= $2
+	`
+	args := []interface{}{cityName, threshold}
+	if stateCode != "" {
+		query += " AND state_code = $3"
+		args = append(args, stateCode)
+	}
+	query += " GROUP BY city_name ORDER BY score DESC, COUNT(*) DESC LIMIT 1"
+
+	var name string
+	var score float64
+	err := database.ReadDB(ctx).QueryRowContext(ctx, query, args...).Scan(&name, &score)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to find similar city: %w", err)
+	}
+	return name, score, nil
+}
@@ -196,6 +196,7 @@ func isDirectional(word string) bool {
 // This avoids false positives on place names like "Ste. Genevieve".
 var (
 	unitDesignatorPattern = regexp.MustCompile(`(?i)[,\s]*#\s*[a-zA-Z0-9]+|[,\s]+(?:apt|apartment|ste|suite|unit|bldg|building|fl|floor|rm|room)\b\.?\s*(?:#\s*[a-zA-Z0-9]+|\d+[a-zA-Z]?\b|[a-zA-Z]\b)`)
+	unitIdentifierPattern = regexp.MustCompile(`[a-zA-Z0-9]+$`)
 	multiSpacePattern     = regexp.MustCompile(`\s{2,}`)
 	doubleCommaPattern    = regexp.MustCompile(`\s*,\s*,\s*`)
 )
@@ -211,3 +212,64 @@ func StripUnitDesignator(query string) string {
 	stripped = strings.TrimSpace(stripped)
 	return stripped
 }
+
+// ExtractUnitDesignator splits the unit/apartment/suite designator off an address
+// query, returning the base street address and the unit's identifier, or "" when
+// there is none. When several are given the last, most specific one is kept.
+// Example: "123 Main St Apt 2B, Columbus, OH 43215" -> ("123 Main St, Columbus, OH 43215", "2B")
+func ExtractUnitDesignator(query string) (string, string) {
+	unit := ""
+	if designators := unitDesignatorPattern.FindAllString(query, -1); len(designators) > 0 {
+		unit = unitIdentifierPattern.FindString(designators[len(designators)-1])
+	}
+	return StripUnitDesignator(query), unit
+}
+
+// ordinalWords maps spelled-out ordinals to their numeric form
+var ordinalWords = map[string]string{
+	"first": "1st", "second": "2nd", "third": "3rd", "fourth": "4th", "fifth": "5th",
+	"sixth": "6th", "seventh": "7th", "eighth": "8th", "ninth": "9th", "tenth": "10th",
+	"eleventh": "11th", "twelfth": "12th", "thirteenth": "13th", "fourteenth": "14th",
+	"fifteenth": "15th", "sixteenth": "16th", "seventeenth": "17th", "eighteenth": "18th",
+	"nineteenth": "19th", "twentieth": "20th",
+}
+
+// canonicalStreetWords maps every street type, directional and ordinal form
+// to the form NormalizeStreetName uses: the first abbreviation ("street" and
+// "st." become "st") or the numeric ordinal ("first" becomes "1st")
+var canonicalStreetWords map[string]string
+
+func init() {
+	canonicalStreetWords = make(map[string]string)
+	for full, forms := range streetAbbreviations {
+		canonical := forms[1]
+		canonicalStreetWords[full] = canonical
+		for _, form := range forms[1:] {
+			canonicalStreetWords[strings.TrimSuffix(form, ".")] = canonical
+		}
+	}
+	for word, ordinal := range ordinalWords {
+		canonicalStreetWords[word] = ordinal
+	}
+}
+
+var (
+	streetElidedPattern    = regexp.MustCompile(`[.']`)
+	streetSeparatorPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// NormalizeStreetName reduces a street name to a canonical form for
+// matching: lowercase, punctuation removed, and every street type,
+// directional and ordinal word abbreviated, so "North First Street" and
+// "N 1ST ST." both become "n 1st st". The normalize_street_name database
+// function applies the same rules to stored streets.
+func NormalizeStreetName(street string) string {
+	street = streetElidedPattern.ReplaceAllString(strings.ToLower(street), "")
+	words := strings.Fields(streetSeparatorPattern.ReplaceAllString(street, " "))
+	for i, word := range words {
+		if canonical, ok := canonicalStreetWords[word]; ok {
+			words[i] = canonical
+		}
+	}
+	return strings.Join(words, " ")
+}
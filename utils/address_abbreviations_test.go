@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"geocoding-api/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeStreetName(t *testing.T) {
+	tests := []struct {
+		street string
+		want   string
+	}{
+		{"Main Street", "main st"},
+		{"MAIN ST", "main st"},
+		{"Main St.", "main st"},
+		{"North First Avenue", "n 1st ave"},
+		{"N 1ST AVE", "n 1st ave"},
+		{"n. first av", "n 1st ave"},
+		{"Martin Luther King Jr Boulevard", "martin luther king jr blvd"},
+		{"O'Bannon Creek Rd", "obannon creek rd"},
+		{"State Route 4 - Bypass", "state route 4 bypass"},
+		{"  Twentieth   Street  SW ", "20th st sw"},
+		{"", ""},
+		{"...", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.street, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeStreetName(tt.street))
+		})
+	}
+}
+
+// The normalize_street_name migration must map every word NormalizeStreetName does
+func TestNormalizeStreetNameMatchesMigration(t *testing.T) {
+	sql, err := fs.ReadFile(migrations.Files, "000036_add_street_name_normalization.up.sql")
+	require.NoError(t, err)
+
+	for word, canonical := range canonicalStreetWords {
+		if word == canonical {
+			continue
+		}
+		assert.True(t, strings.Contains(string(sql), fmt.Sprintf("('%s', '%s')", word, canonical)),
+			"migration is missing %s -> %s", word, canonical)
+	}
+}
+
+func TestExtractUnitDesignator(t *testing.T) {
+	tests := []struct {
+		query, base, unit string
+	}{
+		{"2525 Oakley Ave Apt 3, Cincinnati OH", "2525 Oakley Ave, Cincinnati OH", "3"},
+		{"123 Main St Apt 2B, Columbus, OH 43215", "123 Main St, Columbus, OH 43215", "2B"},
+		{"20 Overbrook Ct #F, Monroe, OH 45050", "20 Overbrook Ct, Monroe, OH 45050", "F"},
+		{"100 Broad St Bldg 2 Suite #12", "100 Broad St", "12"},
+		{"12 Ste. Genevieve Rd", "12 Ste. Genevieve Rd", ""},
+		{"2525 Oakley Ave, Cincinnati OH 45209", "2525 Oakley Ave, Cincinnati OH 45209", ""},
+	}
+	for _, tt := range tests {
+		base, unit := ExtractUnitDesignator(tt.query)
+		assert.Equal(t, tt.base, base, tt.query)
+		assert.Equal(t, tt.unit, unit, tt.query)
+	}
+}
@@ -9,10 +9,74 @@ import (
 type ParsedAddress struct {
 	HouseNumber string `json:"house_number,omitempty"`
 	Street      string `json:"street,omitempty"`
+	Unit        string `json:"unit,omitempty"`
 	City        string `json:"city,omitempty"`
 	State       string `json:"state,omitempty"`
 	Zip         string `json:"zip,omitempty"`
 	Raw         string `json:"raw"`
+
+	// rules and confidence record how each component was derived; see Rules and Confidence
+	rules      []string
+	confidence map[string]float64
+}
+
+// Parser rule identifiers reported by ParsedAddress.Rules
+const (
+	RuleCommaStrategy            = "strategy.comma"
+	RuleSpaceStrategy            = "strategy.space"
+	RuleTrailingZip              = "zip.trailing"
+	RuleTrailingState            = "state.trailing_code"
+	RuleStateAmbiguousSkipped    = "state.skipped_street_type" // e.g. "Ct" kept as Court
+	RuleStateOwnPart             = "state.own_comma_part"
+	RuleLeadingHouseNumber       = "house_number.leading"
+	RuleFirstPartStreet          = "street.first_comma_part"
+	RuleStreetTypeBoundary       = "street.street_type_boundary"
+	RuleStreetTypeAtEnd          = "street.street_type_at_end"
+	RuleHouseNumberImpliesStreet = "street.house_number_implies_street"
+	RuleNoStreetTypeIsCity       = "city.no_street_type"
+	RuleCityOwnPart              = "city.own_comma_part"
+	RuleCityMiddleParts          = "city.joined_middle_parts"
+	RuleCityBeforeState          = "city.leftover_before_state"
+	RuleCityAfterStreetType      = "city.after_street_type"
+)
+
+// fire records a rule and the confidence of the component it produced
+func (p *ParsedAddress) fire(rule, field string, confidence float64) {
+	if len(p.rules) == 0 || p.rules[len(p.rules)-1] != rule {
+		p.rules = append(p.rules, rule)
+	}
+	if field != "" {
+		if p.confidence == nil {
+			p.confidence = make(map[string]float64)
+		}
+		p.confidence[field] = confidence
+	}
+}
+
+// Rules returns the parser rules that fired, in order
+func (p *ParsedAddress) Rules() []string {
+	return append([]string{}, p.rules...)
+}
+
+// Confidence returns a 0-1 confidence for each non-empty component, keyed by
+// its JSON field name. It reflects how strong the parsing signal was, e.g. a
+// trailing 5-digit ZIP is near certain while a city inferred from a missing
+// street type is a guess.
+func (p *ParsedAddress) Confidence() map[string]float64 {
+	values := map[string]string{
+		"house_number": p.HouseNumber,
+		"street":       p.Street,
+		"city":         p.City,
+		"state":        p.State,
+		"zip":          p.Zip,
+	}
+	result := make(map[string]float64)
+	for field, value := range values {
+		if value != "" {
+			result[field] = p.confidence[field]
+		}
+	}
+	return result
 }
 
 var (
@@ -50,8 +114,10 @@ func ParseAddressQuery(query string) *ParsedAddress {
 	}
 
 	if strings.Contains(query, ",") {
+		parsed.fire(RuleCommaStrategy, "", 0)
 		parseCommaDelimited(query, parsed)
 	} else {
+		parsed.fire(RuleSpaceStrategy, "", 0)
 		parseSpaceDelimited(query, parsed)
 	}
 
@@ -108,8 +174,10 @@ func parseCommaDelimited(query string, parsed *ParsedAddress) {
 				// Check if second-to-last is state or city
 				if parsed.State == "" && IsUSStateCode(secondToLast) {
 					parsed.State = strings.ToUpper(secondToLast)
+					parsed.fire(RuleStateOwnPart, "state", 0.9)
 					if len(parts) >= 4 {
 						parsed.City = parts[1]
+						parsed.fire(RuleCityOwnPart, "city", 0.8)
 					}
 				} else {
 					// Second-to-last is the city
@@ -118,8 +186,10 @@ func parseCommaDelimited(query string, parsed *ParsedAddress) {
 						// Multiple middle parts - join them as city
 						cityParts := parts[1 : len(parts)-1]
 						parsed.City = strings.Join(cityParts, ", ")
+						parsed.fire(RuleCityMiddleParts, "city", 0.6)
 					} else {
 						parsed.City = parts[cityIdx]
+						parsed.fire(RuleCityOwnPart, "city", 0.9)
 					}
 				}
 			}
@@ -127,9 +197,11 @@ func parseCommaDelimited(query string, parsed *ParsedAddress) {
 			// Last part didn't have state/zip - might be "20 Main St, Monroe"
 			if len(parts) == 2 {
 				parsed.City = parts[1]
+				parsed.fire(RuleCityOwnPart, "city", 0.8)
 			} else if len(parts) >= 3 {
 				// Try second-to-last as city, last as state/zip
 				parsed.City = secondToLast
+				parsed.fire(RuleCityOwnPart, "city", 0.7)
 				extractStateAndZip(lastPart, parsed)
 			}
 		}
@@ -143,6 +215,7 @@ func parseSpaceDelimited(query string, parsed *ParsedAddress) {
 	// 1. Extract zip from end
 	if match := zipPattern.FindStringSubmatch(remaining); match != nil {
 		parsed.Zip = match[1]
+		parsed.fire(RuleTrailingZip, "zip", 0.98)
 		remaining = strings.TrimSpace(remaining[:len(remaining)-len(match[0])])
 	}
 
@@ -156,13 +229,21 @@ func parseSpaceDelimited(query string, parsed *ParsedAddress) {
 		// This prevents "Ct" from being misidentified as Connecticut when it means Court.
 		if IsUSStateCode(lastWord) && (!IsStreetType(lastWord) || parsed.Zip != "") {
 			parsed.State = strings.ToUpper(lastWord)
+			if parsed.Zip != "" {
+				parsed.fire(RuleTrailingState, "state", 0.95)
+			} else {
+				parsed.fire(RuleTrailingState, "state", 0.8)
+			}
 			remaining = strings.TrimSpace(strings.Join(words[:len(words)-1], " "))
+		} else if IsUSStateCode(lastWord) {
+			parsed.fire(RuleStateAmbiguousSkipped, "", 0)
 		}
 	}
 
 	// 3. Extract house number from start
 	if match := houseNumberPattern.FindStringSubmatch(remaining); match != nil {
 		parsed.HouseNumber = match[1]
+		parsed.fire(RuleLeadingHouseNumber, "house_number", houseNumberConfidence(match[1]))
 		remaining = strings.TrimSpace(remaining[len(match[0]):])
 	}
 
@@ -176,10 +257,29 @@ func extractHouseAndStreet(s string, parsed *ParsedAddress) {
 	s = strings.TrimSpace(s)
 	if match := houseNumberPattern.FindStringSubmatch(s); match != nil {
 		parsed.HouseNumber = match[1]
+		parsed.fire(RuleLeadingHouseNumber, "house_number", houseNumberConfidence(match[1]))
 		parsed.Street = strings.TrimSpace(s[len(match[0]):])
 	} else {
 		parsed.Street = s
 	}
+	if parsed.Street != "" {
+		confidence := 0.75
+		if words := strings.Fields(parsed.Street); IsStreetType(words[len(words)-1]) {
+			confidence = 0.9
+		}
+		parsed.fire(RuleFirstPartStreet, "street", confidence)
+	}
+}
+
+// houseNumberConfidence is lower for forms like "12B" or "10-12" that are
+// sometimes unit or range notation rather than a plain house number
+func houseNumberConfidence(houseNumber string) float64 {
+	for _, r := range houseNumber {
+		if r < '0' || r > '9' {
+			return 0.8
+		}
+	}
+	return 0.95
 }
 
 // extractStateAndZip tries to extract state code and/or zip from a string.
@@ -191,6 +291,7 @@ func extractStateAndZip(s string, parsed *ParsedAddress) bool {
 	// Try zip
 	if match := zipPattern.FindStringSubmatch(s); match != nil {
 		parsed.Zip = match[1]
+		parsed.fire(RuleTrailingZip, "zip", 0.98)
 		s = strings.TrimSpace(s[:len(s)-len(match[0])])
 		found = true
 	}
@@ -201,11 +302,13 @@ func extractStateAndZip(s string, parsed *ParsedAddress) bool {
 		lastWord := words[len(words)-1]
 		if IsUSStateCode(lastWord) {
 			parsed.State = strings.ToUpper(lastWord)
+			parsed.fire(RuleTrailingState, "state", 0.95)
 			// Anything before the state code is leftover (possibly city)
 			if len(words) > 1 {
 				leftover := strings.Join(words[:len(words)-1], " ")
 				if parsed.City == "" {
 					parsed.City = leftover
+					parsed.fire(RuleCityBeforeState, "city", 0.75)
 				}
 			}
 			found = true
@@ -213,11 +316,13 @@ func extractStateAndZip(s string, parsed *ParsedAddress) bool {
 			// Single word, not a state code, not a zip - could be a city
 			if parsed.City == "" {
 				parsed.City = s
+				parsed.fire(RuleCityOwnPart, "city", 0.7)
 			}
 		} else if !found {
 			// Multiple words, no state/zip found - treat as city
 			if parsed.City == "" {
 				parsed.City = s
+				parsed.fire(RuleCityOwnPart, "city", 0.6)
 			}
 		}
 	}
@@ -246,14 +351,19 @@ func splitStreetAndCity(s string, parsed *ParsedAddress) {
 		// Street type found with words after it → split there
 		parsed.Street = strings.Join(words[:lastStreetTypeIdx+1], " ")
 		parsed.City = strings.Join(words[lastStreetTypeIdx+1:], " ")
+		parsed.fire(RuleStreetTypeBoundary, "street", 0.85)
+		parsed.fire(RuleCityAfterStreetType, "city", 0.7)
 	} else if lastStreetTypeIdx >= 0 {
 		// Street type at the end → all street, no city
 		parsed.Street = s
+		parsed.fire(RuleStreetTypeAtEnd, "street", 0.9)
 	} else if parsed.HouseNumber != "" {
 		// No street type but has house number → likely a street name
 		parsed.Street = s
+		parsed.fire(RuleHouseNumberImpliesStreet, "street", 0.6)
 	} else {
 		// No street type, no house number → more likely a city/place name
 		parsed.City = s
+		parsed.fire(RuleNoStreetTypeIsCity, "city", 0.4)
 	}
 }
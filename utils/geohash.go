@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxGeohashPrecision is the longest geohash encoded; 12 characters is
+// already a cell a few centimeters across
+const MaxGeohashPrecision = 12
+
+// geohashAlphabet is the base32 alphabet geohashes are written in
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashCell is the bounding box a geohash stands for
+type GeohashCell struct {
+	MinLat, MinLng, MaxLat, MaxLng float64
+}
+
+// Center returns the middle of the cell
+func (c GeohashCell) Center() (lat, lng float64) {
+	return (c.MinLat + c.MaxLat) / 2, (c.MinLng + c.MaxLng) / 2
+}
+
+// EncodeGeohash returns the geohash of a point with precision characters.
+// Each character alternately halves the longitude and latitude ranges
+// starting with longitude, five halvings per character.
+func EncodeGeohash(lat, lng float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+
+	var hash strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for hash.Len() < precision {
+		if even {
+			mid := (minLng + maxLng) / 2
+			if lng >= mid {
+				ch |= 1 << (4 - bit)
+				minLng = mid
+			} else {
+				maxLng = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+			continue
+		}
+		hash.WriteByte(geohashAlphabet[ch])
+		bit, ch = 0, 0
+	}
+	return hash.String()
+}
+
+// DecodeGeohash returns the cell a geohash stands for. It is case-insensitive.
+func DecodeGeohash(hash string) (GeohashCell, error) {
+	if hash == "" || len(hash) > MaxGeohashPrecision {
+		return GeohashCell{}, fmt.Errorf("geohash must be 1 to %d characters", MaxGeohashPrecision)
+	}
+
+	cell := GeohashCell{MinLat: -90, MaxLat: 90, MinLng: -180, MaxLng: 180}
+	even := true
+	for _, r := range strings.ToLower(hash) {
+		ch := strings.IndexRune(geohashAlphabet, r)
+		if ch < 0 {
+			return GeohashCell{}, fmt.Errorf("invalid geohash character %q", r)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := ch&(1<<bit) != 0
+			if even {
+				mid := (cell.MinLng + cell.MaxLng) / 2
+				if set {
+					cell.MinLng = mid
+				} else {
+					cell.MaxLng = mid
+				}
+			} else {
+				mid := (cell.MinLat + cell.MaxLat) / 2
+				if set {
+					cell.MinLat = mid
+				} else {
+					cell.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return cell, nil
+}
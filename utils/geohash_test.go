@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		lat, lng  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{42.6, -5.6, 5, "ezs42"},
+		{39.9612, -82.9988, 7, "dphgr6d"},
+		{-33.8688, 151.2093, 6, "r3gx2f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, EncodeGeohash(tt.lat, tt.lng, tt.precision))
+		})
+	}
+}
+
+func TestDecodeGeohash(t *testing.T) {
+	cell, err := DecodeGeohash("EZS42")
+	require.NoError(t, err)
+	lat, lng := cell.Center()
+	assert.InDelta(t, 42.6, lat, 0.03)
+	assert.InDelta(t, -5.6, lng, 0.03)
+	assert.True(t, cell.MinLat <= 42.6 && 42.6 <= cell.MaxLat)
+
+	// Decoding an encoded point gives a cell containing it
+	cell, err = DecodeGeohash(EncodeGeohash(39.9612, -82.9988, 9))
+	require.NoError(t, err)
+	assert.True(t, cell.MinLat <= 39.9612 && 39.9612 <= cell.MaxLat)
+	assert.True(t, cell.MinLng <= -82.9988 && -82.9988 <= cell.MaxLng)
+
+	for _, hash := range []string{"", "ezs4a", "0123456789bcd"} {
+		_, err := DecodeGeohash(hash)
+		assert.Error(t, err, hash)
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,6 +31,32 @@ type OpenAddressesSource struct {
 	} `json:"layers"`
 }
 
+// openAddressesSourcesURL is where OpenAddresses publishes its US source configs
+const openAddressesSourcesURL = "https://raw.githubusercontent.com/openaddresses/openaddresses/master/sources/us"
+
+// OpenAddressesConfigURL returns the URL of a county's OpenAddresses source
+// config, e.g. sources/us/oh/van_wert.json for "OH", "Van Wert"
+func OpenAddressesConfigURL(state, county string) string {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(county)), " ", "_")
+	return fmt.Sprintf("%s/%s/%s.json", openAddressesSourcesURL, strings.ToLower(state), name)
+}
+
+// AddressDataURL returns the download URL of the source's first address
+// layer. ArcGIS FeatureServer layers can't be downloaded as a file.
+func (s *OpenAddressesSource) AddressDataURL() (string, error) {
+	if len(s.Layers.Addresses) == 0 {
+		return "", fmt.Errorf("no address data layer found in config")
+	}
+	layer := s.Layers.Addresses[0]
+	if strings.EqualFold(layer.Protocol, "ESRI") || strings.Contains(layer.Data, "FeatureServer") || strings.Contains(layer.Data, "MapServer") {
+		return "", fmt.Errorf("source uses an ArcGIS server (not supported yet): %s", layer.Data)
+	}
+	if !strings.HasPrefix(layer.Data, "http://") && !strings.HasPrefix(layer.Data, "https://") {
+		return "", fmt.Errorf("source data is not an HTTP download: %s", layer.Data)
+	}
+	return layer.Data, nil
+}
+
 // RealDataDownloader handles downloading real data from various sources
 type RealDataDownloader struct {
 	Client   *http.Client
@@ -148,36 +176,42 @@ type DataSource struct {
 
 // generateOpenAddressesURLs generates URLs for OpenAddresses configuration files
 func (rdd *RealDataDownloader) generateOpenAddressesURLs() map[string]string {
-	baseURL := "https://raw.githubusercontent.com/openaddresses/openaddresses/master/sources/us/oh"
 	counties := GetOhioCountyList()
 	urls := make(map[string]string)
 	
 	for _, county := range counties {
-		urls[county] = fmt.Sprintf("%s/%s.json", baseURL, county)
+		urls[county] = OpenAddressesConfigURL("oh", county)
 	}
 	
 	return urls
 }
 
-// DownloadAndConvertCounty downloads and converts a single county's data
-func (rdd *RealDataDownloader) DownloadAndConvertCounty(county, destDir string) error {
-	// Get the OpenAddresses configuration for this county
-	configURL := fmt.Sprintf("https://raw.githubusercontent.com/openaddresses/openaddresses/master/sources/us/oh/%s.json", county)
-	
-	resp, err := rdd.Client.Get(configURL)
+// FetchOpenAddressesSource downloads and parses a county's OpenAddresses
+// source config
+func (rdd *RealDataDownloader) FetchOpenAddressesSource(state, county string) (*OpenAddressesSource, error) {
+	resp, err := rdd.Client.Get(OpenAddressesConfigURL(state, county))
 	if err != nil {
-		return fmt.Errorf("failed to download config: %w", err)
+		return nil, fmt.Errorf("failed to download config: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("config returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("config returned status %d", resp.StatusCode)
 	}
 
-	// Parse the configuration
 	var source OpenAddressesSource
 	if err := json.NewDecoder(resp.Body).Decode(&source); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &source, nil
+}
+
+// DownloadAndConvertCounty downloads and converts a single county's data
+func (rdd *RealDataDownloader) DownloadAndConvertCounty(county, destDir string) error {
+	// Get the OpenAddresses configuration for this county
+	source, err := rdd.FetchOpenAddressesSource("oh", county)
+	if err != nil {
+		return err
 	}
 
 	// Get the data source URL
@@ -621,4 +655,68 @@ func (rdd *RealDataDownloader) DownloadFileFromURL(url, destination string) erro
 
 	fmt.Printf("Successfully downloaded: %s\n", destination)
 	return nil
-}
\ No newline at end of file
+}
+
+// ConditionalDownload describes a DownloadIfChanged request
+type ConditionalDownload struct {
+	// NotModified is set when the server answered 304 and nothing was written
+	NotModified  bool
+	ETag         string
+	LastModified string
+	// SHA256 is the hex digest of the downloaded file
+	SHA256 string
+	Size   int64
+}
+
+// DownloadIfChanged downloads url to destination unless the server reports
+// it unchanged since the given ETag or Last-Modified value, either of which
+// may be empty. Servers that ignore conditional requests send the file
+// again; compare SHA256 with the previous download to catch those.
+func (rdd *RealDataDownloader) DownloadIfChanged(url, destination, etag, lastModified string) (*ConditionalDownload, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid download URL: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := rdd.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result := &ConditionalDownload{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.Create(destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	result.Size, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if err != nil {
+		os.Remove(destination)
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	result.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return result, nil
+}
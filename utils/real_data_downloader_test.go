@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAddressesConfigURL(t *testing.T) {
+	assert.Equal(t, "https://raw.githubusercontent.com/openaddresses/openaddresses/master/sources/us/oh/van_wert.json",
+		OpenAddressesConfigURL("OH", "Van Wert"))
+}
+
+func TestOpenAddressesAddressDataURL(t *testing.T) {
+	source := func(protocol, data string) *OpenAddressesSource {
+		var s OpenAddressesSource
+		s.Layers.Addresses = append(s.Layers.Addresses, struct {
+			Name     string `json:"name"`
+			Data     string `json:"data"`
+			Protocol string `json:"protocol"`
+		}{Name: "county", Data: data, Protocol: protocol})
+		return &s
+	}
+
+	url, err := source("http", "https://gis1.oit.ohio.gov/LBRS/_downloads/FRA_ADDS.zip").AddressDataURL()
+	require.NoError(t, err)
+	assert.Equal(t, "https://gis1.oit.ohio.gov/LBRS/_downloads/FRA_ADDS.zip", url)
+
+	_, err = source("ESRI", "https://example.com/arcgis/rest/services/Addresses/FeatureServer/0").AddressDataURL()
+	assert.ErrorContains(t, err, "ArcGIS")
+
+	_, err = (&OpenAddressesSource{}).AddressDataURL()
+	assert.ErrorContains(t, err, "no address data layer")
+}
+
+func TestDownloadIfChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	rdd := NewRealDataDownloader(t.TempDir())
+	dest := filepath.Join(t.TempDir(), "county.geojson")
+
+	first, err := rdd.DownloadIfChanged(server.URL, dest, "", "")
+	require.NoError(t, err)
+	assert.False(t, first.NotModified)
+	assert.Equal(t, `"v1"`, first.ETag)
+	assert.Equal(t, int64(5), first.Size)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", first.SHA256)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	again, err := rdd.DownloadIfChanged(server.URL, filepath.Join(t.TempDir(), "unused.geojson"), first.ETag, "")
+	require.NoError(t, err)
+	assert.True(t, again.NotModified)
+	assert.Empty(t, again.SHA256)
+}